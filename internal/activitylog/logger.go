@@ -1,6 +1,7 @@
 package activitylog
 
 import (
+	"bytes"
 	"encoding/json"
 	"os"
 	"sync"
@@ -13,8 +14,18 @@ import (
 type Logger struct {
 	mu        sync.Mutex
 	w         *os.File
+	path      string
 	actor     string
 	sessionID string
+	secrets   [][]byte // JSON-encoded values masked in every entry; guarded by mu
+
+	// Rotation state, guarded by mu.
+	rotation Rotation
+	started  time.Time // first event in the open file
+	checked  time.Time // last checkRotation
+
+	// compressing tracks segments being compressed in the background.
+	compressing sync.WaitGroup
 }
 
 // New creates a Logger that appends to logPath. If enabled is false or the
@@ -23,11 +34,22 @@ func New(enabled bool, logPath, actor, sessionID string) *Logger {
 	if !enabled {
 		return &Logger{}
 	}
-	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0o644)
 	if err != nil {
 		return &Logger{}
 	}
-	return &Logger{w: f, actor: actor, sessionID: sessionID}
+	l := &Logger{path: logPath, actor: actor, sessionID: sessionID}
+	l.setFile(f)
+	return l
+}
+
+// setFile makes f the file entries are appended to.
+func (l *Logger) setFile(f *os.File) {
+	l.w = f
+	l.started = firstTimestamp(f)
+	if l.started.IsZero() {
+		l.started = time.Now()
+	}
 }
 
 // Nop returns a disabled logger. All methods are no-ops.
@@ -35,6 +57,33 @@ func Nop() *Logger {
 	return &Logger{}
 }
 
+// SetSecrets makes the logger write "***" in place of each of secrets, so
+// values like a role's secret variables never reach the log file.
+func (l *Logger) SetSecrets(secrets []string) {
+	var encoded [][]byte
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		data, err := json.Marshal(secret)
+		if err != nil {
+			continue
+		}
+		// Strip the quotes to match the value inside any JSON string.
+		encoded = append(encoded, data[1:len(data)-1])
+	}
+	l.mu.Lock()
+	l.secrets = encoded
+	l.mu.Unlock()
+}
+
+// SetRotation makes the logger rotate its file as configured by r.
+func (l *Logger) SetRotation(r Rotation) {
+	l.mu.Lock()
+	l.rotation = r
+	l.mu.Unlock()
+}
+
 // entry is the common envelope for all log lines.
 type entry struct {
 	Timestamp string `json:"ts"`
@@ -95,6 +144,35 @@ func (l *Logger) StateChange(from, to string) {
 	})
 }
 
+// PostLaunch logs a role post_launch command and its combined output.
+func (l *Logger) PostLaunch(command string, exitCode int, output string) {
+	l.log(struct {
+		entry
+		Command  string `json:"command"`
+		ExitCode int    `json:"exit_code"`
+		Output   string `json:"output,omitempty"`
+	}{
+		entry:    l.entry("post_launch"),
+		Command:  command,
+		ExitCode: exitCode,
+		Output:   output,
+	})
+}
+
+// RoleReload logs a reload of the agent's role file: the fields applied
+// and the changed fields that only take effect after a restart.
+func (l *Logger) RoleReload(applied, restartRequired []string) {
+	l.log(struct {
+		entry
+		Applied         []string `json:"applied,omitempty"`
+		RestartRequired []string `json:"restart_required,omitempty"`
+	}{
+		entry:           l.entry("role_reload"),
+		Applied:         applied,
+		RestartRequired: restartRequired,
+	})
+}
+
 // SessionSummaryData contains all metrics for a session_summary log entry.
 type SessionSummaryData struct {
 	InputTokens  int64
@@ -172,6 +250,9 @@ func (l *Logger) Close() error {
 	if l.w == nil {
 		return nil
 	}
+	l.compressing.Wait()
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	return l.w.Close()
 }
 
@@ -203,6 +284,10 @@ func (l *Logger) log(v any) {
 	}
 	data = append(data, '\n')
 	l.mu.Lock()
+	for _, secret := range l.secrets {
+		data = bytes.ReplaceAll(data, secret, []byte("***"))
+	}
+	l.checkRotation(time.Now())
 	l.w.Write(data)
 	l.mu.Unlock()
 }
@@ -85,6 +85,29 @@ func TestPermissionDecision(t *testing.T) {
 	}
 }
 
+func TestSetSecretsRedactsEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "activity.log")
+	l := New(true, path, "agent", "sess")
+	defer l.Close()
+
+	l.SetSecrets([]string{"tok-123", `a"b`, ""})
+	l.PermissionDecision("sess", "Bash", "deny", `curl -H "Authorization: tok-123" and a"b`)
+
+	lines := readLines(t, path)
+	if strings.Contains(lines[0], "tok-123") || strings.Contains(lines[0], `a\"b`) {
+		t.Fatalf("secret leaked into log: %s", lines[0])
+	}
+	var e struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &e); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if want := `curl -H "Authorization: ***" and ***`; e.Reason != want {
+		t.Errorf("reason = %q, want %q", e.Reason, want)
+	}
+}
+
 func TestOtelConnected(t *testing.T) {
 	path := filepath.Join(t.TempDir(), "activity.log")
 	l := New(true, path, "agent", "sess")
@@ -0,0 +1,340 @@
+package activitylog
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofrs/flock"
+)
+
+// Rotation configures when a Logger rotates its log file. Rotating renames
+// the file to <name>.1.jsonl, shifts older segments up by one and deletes
+// those past Keep. Agents share one log, so rotation is coordinated through
+// a lock file next to it and each Logger follows the log to its new file.
+type Rotation struct {
+	MaxBytes int64         // rotate once the file reaches this size; 0 disables
+	MaxAge   time.Duration // rotate once the file's first event is this old; 0 disables
+	Keep     int           // rotated segments to keep
+	Compress bool          // gzip rotated segments, except the newest
+}
+
+func (r Rotation) enabled() bool {
+	return r.Keep > 0 && (r.MaxBytes > 0 || r.MaxAge > 0)
+}
+
+func (r Rotation) due(size int64, started, now time.Time) bool {
+	if size == 0 {
+		return false
+	}
+	if r.MaxBytes > 0 && size >= r.MaxBytes {
+		return true
+	}
+	return r.MaxAge > 0 && !started.IsZero() && now.Sub(started) >= r.MaxAge
+}
+
+// checkInterval is how often a Logger checks whether its file is due for
+// rotation or was rotated by another process.
+const checkInterval = time.Second
+
+// lockTimeout bounds how long a reader waits for a rotation to finish.
+const lockTimeout = 5 * time.Second
+
+func lockPath(path string) string {
+	return path + ".lock"
+}
+
+// LockForRead takes a shared lock on the log at path, which keeps it from
+// being rotated until unlock is called, so a reader going through the
+// segments sees each event exactly once.
+func LockForRead(path string) (unlock func(), err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), lockTimeout)
+	defer cancel()
+
+	fl := flock.New(lockPath(path))
+	ok, err := fl.TryRLockContext(ctx, 50*time.Millisecond)
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("acquire activity log read lock: %w", err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("acquire activity log read lock: timed out after %s waiting for %s; the log is being rotated", lockTimeout, lockPath(path))
+	}
+	return func() { fl.Unlock() }, nil
+}
+
+// Segment is a rotated log file.
+type Segment struct {
+	Path    string
+	ModTime time.Time // last write; no event in the segment is later
+}
+
+// Segments returns the rotated segments of the log at path, oldest first.
+// A segment caught mid-compression is returned once, uncompressed.
+func Segments(path string) ([]Segment, error) {
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read log dir: %w", err)
+	}
+	byIndex := make(map[int]string)
+	for _, e := range entries {
+		n, gz, ok := segmentIndex(path, e.Name())
+		if !ok {
+			continue
+		}
+		if _, seen := byIndex[n]; seen && gz {
+			continue
+		}
+		byIndex[n] = filepath.Join(filepath.Dir(path), e.Name())
+	}
+	indexes := make([]int, 0, len(byIndex))
+	for n := range byIndex {
+		indexes = append(indexes, n)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(indexes)))
+
+	segments := make([]Segment, 0, len(indexes))
+	for _, n := range indexes {
+		info, err := os.Stat(byIndex[n])
+		if err != nil {
+			continue
+		}
+		segments = append(segments, Segment{Path: byIndex[n], ModTime: info.ModTime()})
+	}
+	return segments, nil
+}
+
+// segmentPath returns the path of rotated segment n of the log at path,
+// e.g. session-activity.3.jsonl.
+func segmentPath(path string, n int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s.%d%s", strings.TrimSuffix(path, ext), n, ext)
+}
+
+// segmentIndex parses a file name in the log's dir as a rotated segment
+// of the log at path.
+func segmentIndex(path, name string) (n int, gz bool, ok bool) {
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	name, gz = strings.CutSuffix(name, ".gz")
+	rest, found := strings.CutPrefix(name, strings.TrimSuffix(base, ext)+".")
+	if !found {
+		return 0, false, false
+	}
+	digits, found := strings.CutSuffix(rest, ext)
+	if !found {
+		return 0, false, false
+	}
+	n, err := strconv.Atoi(digits)
+	if err != nil || n < 1 {
+		return 0, false, false
+	}
+	return n, gz, true
+}
+
+// rotate moves the log at path to segment 1, shifting the other segments
+// up and deleting the ones past r.Keep. The caller holds the lock. Files
+// are only renamed, never copied, so a writer that hasn't noticed the
+// rotation yet keeps appending to segment 1 and no event is lost. With
+// r.Compress, it returns segment 2, opened, for the caller to compress
+// with compressSegment once the lock is released; otherwise nil.
+func rotate(path string, r Rotation) (*os.File, error) {
+	segments, err := Segments(path)
+	if err != nil {
+		return nil, err
+	}
+	// Oldest first, so no rename overwrites a segment still to be moved.
+	for _, seg := range segments {
+		n, gz, _ := segmentIndex(path, filepath.Base(seg.Path))
+		if n >= r.Keep {
+			if err := os.Remove(seg.Path); err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("remove old log segment: %w", err)
+			}
+			continue
+		}
+		next := segmentPath(path, n+1)
+		if gz {
+			next += ".gz"
+		}
+		if err := os.Rename(seg.Path, next); err != nil {
+			return nil, fmt.Errorf("shift log segment: %w", err)
+		}
+	}
+	if err := os.Rename(path, segmentPath(path, 1)); err != nil {
+		return nil, fmt.Errorf("rotate log: %w", err)
+	}
+	// Segment 1 stays uncompressed so writers that still have it open
+	// don't write into a file that is about to be replaced.
+	if !r.Compress {
+		return nil, nil
+	}
+	seg, err := os.Open(segmentPath(path, 2))
+	if err != nil {
+		return nil, nil // no segment 2 yet
+	}
+	return seg, nil
+}
+
+// compressSegment gzips the rotated segment open as in, a segment of the
+// log at path, and closes in. The copy is made without the lock, so other
+// agents keep logging meanwhile; the lock is only taken to swap the
+// compressed file in. By then a later rotation may have moved the segment
+// up, so it is found again by identity, and if it was deleted the copy is
+// dropped. The modification time is kept so readers can still tell how
+// recent its last event is.
+func compressSegment(path string, in *os.File) error {
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return fmt.Errorf("compress log segment: %w", err)
+	}
+	out, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.gz.tmp")
+	if err != nil {
+		return fmt.Errorf("compress log segment: %w", err)
+	}
+	tmp := out.Name()
+	defer os.Remove(tmp) // no-op once renamed
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chtimes(tmp, info.ModTime(), info.ModTime())
+	}
+	if err != nil {
+		return fmt.Errorf("compress log segment: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), lockTimeout)
+	defer cancel()
+	fl := flock.New(lockPath(path))
+	if ok, err := fl.TryLockContext(ctx, 50*time.Millisecond); err != nil || !ok {
+		return fmt.Errorf("compress log segment: timed out after %s waiting for %s", lockTimeout, lockPath(path))
+	}
+	defer fl.Unlock()
+
+	segments, err := Segments(path)
+	if err != nil {
+		return fmt.Errorf("compress log segment: %w", err)
+	}
+	for _, seg := range segments {
+		if segInfo, err := os.Stat(seg.Path); err != nil || !os.SameFile(info, segInfo) {
+			continue
+		}
+		// Both files exist until the segment is removed; readers prefer
+		// the uncompressed one.
+		if err := os.Rename(tmp, seg.Path+".gz"); err != nil {
+			return fmt.Errorf("compress log segment: %w", err)
+		}
+		return os.Remove(seg.Path)
+	}
+	return nil // deleted past Keep meanwhile
+}
+
+// OpenSegment opens a log file or segment for reading, decompressing
+// gzipped segments.
+func OpenSegment(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return f, nil
+	}
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{zr, f}, nil
+}
+
+// firstTimestamp returns the timestamp of the first entry in f, or the zero
+// time if it has none.
+func firstTimestamp(f *os.File) time.Time {
+	line, err := bufio.NewReader(io.NewSectionReader(f, 0, 1<<20)).ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		return time.Time{}
+	}
+	var e entry
+	if json.Unmarshal(line, &e) != nil {
+		return time.Time{}
+	}
+	ts, _ := time.Parse(time.RFC3339Nano, e.Timestamp)
+	return ts
+}
+
+// checkRotation follows the log to a new file if another process rotated
+// it, and rotates it if it is due. Called with l.mu held.
+func (l *Logger) checkRotation(now time.Time) {
+	if l.path == "" || now.Sub(l.checked) < checkInterval {
+		return
+	}
+	l.checked = now
+	open, err := l.w.Stat()
+	if err != nil {
+		return
+	}
+	onDisk, err := os.Stat(l.path)
+	if err != nil || !os.SameFile(open, onDisk) {
+		l.reopen()
+		return
+	}
+	if !l.rotation.enabled() || !l.rotation.due(onDisk.Size(), l.started, now) {
+		return
+	}
+
+	// Skip this round if another process is rotating or a reader holds
+	// the log; it is checked again a second later.
+	fl := flock.New(lockPath(l.path))
+	if ok, err := fl.TryLock(); err != nil || !ok {
+		return
+	}
+	defer fl.Unlock()
+	if onDisk, err := os.Stat(l.path); err != nil || !os.SameFile(open, onDisk) {
+		l.reopen()
+		return
+	}
+	seg, err := rotate(l.path, l.rotation)
+	if err != nil {
+		return
+	}
+	l.reopen()
+	if seg != nil {
+		l.compressing.Add(1)
+		go func() {
+			defer l.compressing.Done()
+			compressSegment(l.path, seg) //nolint:errcheck // best-effort; the segment stays readable uncompressed
+		}()
+	}
+}
+
+// reopen switches the logger to the file now at its path. On failure it
+// keeps writing to the old file. Called with l.mu held.
+func (l *Logger) reopen() {
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return
+	}
+	l.w.Close()
+	l.setFile(f)
+}
@@ -0,0 +1,244 @@
+package activitylog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readAll returns the "from" field of every entry across the log's rotated
+// segments and the current file, oldest first.
+func readAll(t *testing.T, path string) []string {
+	t.Helper()
+	segments, err := Segments(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var froms []string
+	for _, p := range append(segmentPaths(segments), path) {
+		f, err := OpenSegment(p)
+		if err != nil {
+			t.Fatalf("open %s: %v", p, err)
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("read %s: %v", p, err)
+		}
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			if line == "" {
+				continue
+			}
+			var e struct {
+				From string `json:"from"`
+			}
+			if err := json.Unmarshal([]byte(line), &e); err != nil {
+				t.Fatalf("bad line in %s: %q", p, line)
+			}
+			froms = append(froms, e.From)
+		}
+	}
+	return froms
+}
+
+func segmentPaths(segments []Segment) []string {
+	var paths []string
+	for _, s := range segments {
+		paths = append(paths, s.Path)
+	}
+	return paths
+}
+
+// logN writes n state changes numbered from start, letting l check for
+// rotation before each one.
+func logN(l *Logger, start, n int) {
+	for i := start; i < start+n; i++ {
+		l.mu.Lock()
+		l.checked = time.Time{}
+		l.mu.Unlock()
+		l.StateChange(fmt.Sprint(i), "x")
+		l.compressing.Wait()
+	}
+}
+
+func TestRotation_BySizeKeepsEveryEventOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session-activity.jsonl")
+	l := New(true, path, "agent", "sess")
+	defer l.Close()
+	l.SetRotation(Rotation{MaxBytes: 300, Keep: 2})
+
+	logN(l, 0, 12)
+
+	segments, err := Segments(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(filepath.Dir(path), "session-activity.2.jsonl"),
+		filepath.Join(filepath.Dir(path), "session-activity.1.jsonl"),
+	}
+	if got := segmentPaths(segments); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("segments = %v, want %v", got, want)
+	}
+	if _, err := os.Stat(strings.Replace(want[0], ".2.", ".3.", 1)); !os.IsNotExist(err) {
+		t.Errorf("expected segments past keep to be deleted, got %v", err)
+	}
+
+	// Whatever wasn't deleted with old segments is in order with no gaps
+	// or repeats, ending with the last event.
+	froms := readAll(t, path)
+	if len(froms) == 0 || froms[len(froms)-1] != "11" {
+		t.Fatalf("events = %v", froms)
+	}
+	first := 12 - len(froms)
+	for i, f := range froms {
+		if f != fmt.Sprint(first+i) {
+			t.Fatalf("events = %v, want a run ending at 11", froms)
+		}
+	}
+}
+
+func TestRotation_SharedLogIsFollowedByOtherLoggers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session-activity.jsonl")
+	a := New(true, path, "a", "sess")
+	defer a.Close()
+	b := New(true, path, "b", "sess")
+	defer b.Close()
+	a.SetRotation(Rotation{MaxBytes: 200, Keep: 10})
+
+	for i := 0; i < 10; i++ {
+		logN(a, i*2, 1)
+		logN(b, i*2+1, 1)
+	}
+
+	segments, err := Segments(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) < 2 {
+		t.Fatalf("expected several rotations, got segments %v", segmentPaths(segments))
+	}
+	froms := readAll(t, path)
+	if len(froms) != 20 {
+		t.Fatalf("got %d events, want 20: %v", len(froms), froms)
+	}
+	for i, f := range froms {
+		if f != fmt.Sprint(i) {
+			t.Fatalf("events out of order: %v", froms)
+		}
+	}
+}
+
+func TestRotation_ByAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session-activity.jsonl")
+	old := `{"ts":"2020-01-01T00:00:00Z","actor":"a","session_id":"s","event":"state_change","from":"old","to":"x"}` + "\n"
+	if err := os.WriteFile(path, []byte(old), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	l := New(true, path, "agent", "sess")
+	defer l.Close()
+	l.SetRotation(Rotation{MaxAge: time.Hour, Keep: 3})
+
+	logN(l, 0, 2)
+
+	if froms := readAll(t, filepath.Join(filepath.Dir(path), "session-activity.1.jsonl")); strings.Join(froms, ",") != "old" {
+		t.Errorf("rotated segment = %v, want just the old event", froms)
+	}
+	if froms := readLines(t, path); len(froms) != 2 {
+		t.Errorf("current file has %d events, want 2", len(froms))
+	}
+}
+
+func TestRotation_CompressesAllButNewestSegment(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "session-activity.jsonl")
+	l := New(true, path, "agent", "sess")
+	defer l.Close()
+	l.SetRotation(Rotation{MaxBytes: 100, Keep: 5, Compress: true})
+
+	logN(l, 0, 4)
+
+	for name, want := range map[string]bool{
+		"session-activity.1.jsonl":    true,
+		"session-activity.1.jsonl.gz": false,
+		"session-activity.2.jsonl":    false,
+		"session-activity.2.jsonl.gz": true,
+		"session-activity.3.jsonl.gz": true,
+	} {
+		if _, err := os.Stat(filepath.Join(dir, name)); (err == nil) != want {
+			t.Errorf("%s exists = %v, want %v", name, err == nil, want)
+		}
+	}
+	if froms := readAll(t, path); strings.Join(froms, ",") != "0,1,2,3" {
+		t.Errorf("events = %v", froms)
+	}
+}
+
+func TestCompressSegment_FollowsSegmentShiftedMeanwhile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "session-activity.jsonl")
+	line := `{"from":"old"}` + "\n"
+	for _, p := range []string{filepath.Join(dir, "session-activity.2.jsonl"), path} {
+		if err := os.WriteFile(p, []byte(line), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	seg, err := os.Open(filepath.Join(dir, "session-activity.2.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Another rotation moves segment 2 up before the copy is swapped in.
+	if _, err := rotate(path, Rotation{Keep: 5}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := compressSegment(path, seg); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{
+		"session-activity.2.jsonl.gz": false,
+		"session-activity.3.jsonl":    false,
+		"session-activity.3.jsonl.gz": true,
+	} {
+		if _, err := os.Stat(filepath.Join(dir, name)); (err == nil) != want {
+			t.Errorf("%s exists = %v, want %v", name, err == nil, want)
+		}
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(leftovers) > 0 {
+		t.Errorf("temp files left behind: %v", leftovers)
+	}
+}
+
+func TestSegments_PrefersUncompressedCopy(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "session-activity.jsonl")
+	for _, name := range []string{
+		"session-activity.1.jsonl",
+		"session-activity.2.jsonl",
+		"session-activity.2.jsonl.gz",
+		"session-activity.10.jsonl.gz",
+		"session-activity.2.jsonl.gz.tmp",
+		"session-activity.jsonl.lock",
+		"other.1.jsonl",
+	} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0o644)
+	}
+
+	segments, err := Segments(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(dir, "session-activity.10.jsonl.gz"),
+		filepath.Join(dir, "session-activity.2.jsonl"),
+		filepath.Join(dir, "session-activity.1.jsonl"),
+	}
+	if got := segmentPaths(segments); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("segments = %v, want %v", got, want)
+	}
+}
@@ -4,10 +4,13 @@
 package automation
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
 	"strings"
+	"text/template"
 	"time"
 
 	"h2/internal/session/agent/monitor"
@@ -246,13 +249,49 @@ func (t *Trigger) MatchesEvent(evt monitor.AgentEvent) bool {
 // additional environment variables overlaid on top of the inherited process
 // environment.
 func EvalCondition(ctx context.Context, condition string, env map[string]string, workDir string) bool {
+	ok, _ := EvalConditionOutput(ctx, condition, env, workDir)
+	return ok
+}
+
+// maxConditionOutput caps the condition stdout kept for message rendering.
+const maxConditionOutput = 4096
+
+// EvalConditionOutput is like EvalCondition but also returns the command's
+// stdout, with surrounding whitespace trimmed and capped at 4 KB.
+func EvalConditionOutput(ctx context.Context, condition string, env map[string]string, workDir string) (bool, string) {
 	if condition == "" {
-		return true
+		return true, ""
 	}
+	var stdout bytes.Buffer
 	cmd := exec.CommandContext(ctx, "sh", "-c", condition)
 	cmd.Env = buildFullEnv(env)
 	cmd.Dir = workDir
-	return cmd.Run() == nil
+	cmd.Stdout = &stdout
+	err := cmd.Run()
+	out := stdout.Bytes()
+	if len(out) > maxConditionOutput {
+		out = out[:maxConditionOutput]
+	}
+	return err == nil, strings.TrimSpace(string(out))
+}
+
+// RenderMessage fills in {{ .ConditionOutput }} references in a schedule
+// message. Messages without template actions are returned unchanged, as is
+// a message that fails to render (the error is logged).
+func RenderMessage(message, conditionOutput string) string {
+	if !strings.Contains(message, "{{") {
+		return message
+	}
+	t, err := template.New("message").Option("missingkey=error").Parse(message)
+	if err == nil {
+		var buf strings.Builder
+		data := struct{ ConditionOutput string }{conditionOutput}
+		if err = t.Execute(&buf, data); err == nil {
+			return buf.String()
+		}
+	}
+	fmt.Fprintf(os.Stderr, "automation: render message: %v\n", err)
+	return message
 }
 
 // DefaultConditionTimeout is the maximum time a condition command can run.
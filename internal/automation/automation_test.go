@@ -1,6 +1,7 @@
 package automation
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -244,3 +245,33 @@ func TestEvalCondition_EnvVar(t *testing.T) {
 		t.Fatal("expected condition to fail with wrong env var value")
 	}
 }
+
+func TestRenderMessage(t *testing.T) {
+	tests := []struct {
+		name, message, output, want string
+	}{
+		{"static", "check your tasks", "3", "check your tasks"},
+		{"output", "ready: {{ .ConditionOutput }}", "3", "ready: 3"},
+		{"empty output", "ready: {{ .ConditionOutput }}", "", "ready: "},
+		{"unknown field kept", "{{ .Nope }} left as is", "3", "{{ .Nope }} left as is"},
+		{"unparsable kept", "literal {{ braces", "3", "literal {{ braces"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RenderMessage(tt.message, tt.output); got != tt.want {
+				t.Errorf("RenderMessage(%q, %q) = %q, want %q", tt.message, tt.output, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalConditionOutput(t *testing.T) {
+	ok, out := EvalConditionOutput(context.Background(), "printf '  2 ready\\n\\n'", nil, "")
+	if !ok || out != "2 ready" {
+		t.Errorf("got (%v, %q), want (true, %q)", ok, out, "2 ready")
+	}
+	ok, out = EvalConditionOutput(context.Background(), "echo nope; exit 1", nil, "")
+	if ok || out != "nope" {
+		t.Errorf("got (%v, %q), want (false, %q)", ok, out, "nope")
+	}
+}
@@ -2,6 +2,7 @@ package automation
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"sync"
@@ -70,6 +71,9 @@ func (se *ScheduleEngine) Run(ctx context.Context) {
 	}
 }
 
+// ErrScheduleExists is returned (wrapped) by Add when the ID is taken.
+var ErrScheduleExists = errors.New("already exists")
+
 // Add registers a schedule. Returns an error if the RRULE is invalid or the
 // ID already exists.
 func (se *ScheduleEngine) Add(s *Schedule) error {
@@ -85,7 +89,7 @@ func (se *ScheduleEngine) Add(s *Schedule) error {
 		s.ID = uuid.New().String()[:8]
 	}
 	if _, exists := se.schedules[s.ID]; exists {
-		return fmt.Errorf("schedule ID %q already exists", s.ID)
+		return fmt.Errorf("schedule ID %q %w", s.ID, ErrScheduleExists)
 	}
 
 	as := &activeSchedule{
@@ -182,7 +186,7 @@ func (se *ScheduleEngine) handleFiring(as *activeSchedule) {
 	// Merge runner's base env (H2_ACTOR, H2_ROLE, etc.) into condition env.
 	condEnv := se.runner.MergeEnv(env)
 	condCtx, cancel := context.WithTimeout(context.Background(), DefaultConditionTimeout)
-	condPass := EvalCondition(condCtx, s.Condition, condEnv, se.runner.WorkDir())
+	condPass, condOutput := EvalConditionOutput(condCtx, s.Condition, condEnv, se.runner.WorkDir())
 	cancel()
 
 	shouldRun, shouldRemove := evalConditionMode(s.ConditionMode, condPass, s.Condition == "")
@@ -192,6 +196,9 @@ func (se *ScheduleEngine) handleFiring(as *activeSchedule) {
 			s.ID, s.Name, s.ConditionMode.String())
 		action := s.Action
 		action.Header = s.ScheduleHeader()
+		if action.Message != "" {
+			action.Message = RenderMessage(action.Message, condOutput)
+		}
 		if err := se.runner.Run(action, env); err != nil {
 			fmt.Fprintf(os.Stderr, "automation: schedule action failed id=%s error=%v\n", s.ID, err)
 		}
@@ -295,6 +295,37 @@ func TestScheduleEngine_RunIf_ConditionPass(t *testing.T) {
 	}
 }
 
+func TestScheduleEngine_RendersConditionOutput(t *testing.T) {
+	se, enq, clk := newFakeScheduleEngine()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go se.Run(ctx)
+
+	start := clk.Now().Add(1 * time.Second)
+	err := se.Add(&Schedule{
+		ID:            "s1",
+		Start:         start.Format(time.RFC3339),
+		RRule:         "FREQ=SECONDLY;INTERVAL=1;COUNT=1",
+		Condition:     "echo 3",
+		ConditionMode: RunIf,
+		Action: Action{
+			Message: "{{ .ConditionOutput }} tasks are ready",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	clk.Advance(1 * time.Second)
+
+	if !waitForMessages(enq, 1, 2*time.Second) {
+		t.Fatal("expected a message")
+	}
+	if got := enq.getMessages()[0].Body; got != "3 tasks are ready" {
+		t.Errorf("body = %q, want %q", got, "3 tasks are ready")
+	}
+}
+
 func TestScheduleEngine_RunIf_ConditionFail(t *testing.T) {
 	se, enq, clk := newFakeScheduleEngine()
 	ctx, cancel := context.WithCancel(context.Background())
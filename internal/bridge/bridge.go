@@ -2,7 +2,10 @@ package bridge
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 )
 
@@ -17,14 +20,138 @@ type Sender interface {
 	Send(ctx context.Context, text string) error
 }
 
+// Urgency is how prominently an outbound message should be delivered.
+type Urgency string
+
+const (
+	UrgencyLow    Urgency = "low"    // routine; delivered quietly where the platform allows
+	UrgencyNormal Urgency = "normal" // the default
+	UrgencyHigh   Urgency = "high"   // needs attention, e.g. the agent is blocked on a decision
+)
+
+// ValidUrgencies lists the accepted urgency values.
+var ValidUrgencies = []Urgency{UrgencyLow, UrgencyNormal, UrgencyHigh}
+
+// ParseUrgency parses an urgency name; empty means UrgencyNormal.
+func ParseUrgency(s string) (Urgency, error) {
+	if s == "" {
+		return UrgencyNormal, nil
+	}
+	u := Urgency(s)
+	if !slices.Contains(ValidUrgencies, u) {
+		return "", fmt.Errorf("invalid urgency %q; valid values: low, normal, high", s)
+	}
+	return u, nil
+}
+
+// UrgencySender is the capability interface for Senders that can deliver a
+// message more or less prominently than usual, e.g. a silent notification
+// for low urgency. WithUrgency returns a Sender that delivers at urgency and
+// implements the same Formatter and Threader interfaces as the bridge, so it
+// can stand in for it for one message. Bridges without it deliver every
+// message the same way.
+type UrgencySender interface {
+	WithUrgency(urgency Urgency) Sender
+}
+
+// Formatter is the capability interface for Senders that render the
+// Markdown subset from ParseMarkdown in their platform's native markup.
+// SendFormatted takes text produced by FormatOutbound; if it fails (e.g. the
+// platform rejects the markup), callers fall back to Send with the original
+// text.
+type Formatter interface {
+	FormatOutbound(text string) string
+	SendFormatted(ctx context.Context, text string) error
+}
+
+// Attachment is the capability interface for bridges that can upload a
+// local file, with an optional caption, to the external platform.
+type Attachment interface {
+	SendFile(ctx context.Context, path, caption string) error
+}
+
+// FormatFileFallback renders a file send as plain text for bridges that
+// can't upload files: the caption (if any) followed by the file path.
+func FormatFileFallback(path, caption string) string {
+	caption = strings.TrimSpace(caption)
+	if caption == "" {
+		return "[file: " + path + "]"
+	}
+	return caption + "\n[file: " + path + "]"
+}
+
 // InboundHandler is called when a message arrives from an external platform.
 // targetAgent is empty string if no prefix was parsed (un-addressed).
 type InboundHandler func(targetAgent string, body string)
 
 // Receiver is the capability interface for bridges that can receive messages.
+// Healthy reports whether the receiver is still getting messages; the bridge
+// service announces outages of receivers that report false. Running reports
+// whether the receive loop is still going (retrying on its own if need be);
+// the bridge service restarts (Stop, then Start) receivers whose loop has
+// exited.
 type Receiver interface {
 	Start(ctx context.Context, handler InboundHandler) error
 	Stop()
+	Healthy() bool
+	Running() bool
+}
+
+// ThreadInboundHandler is like InboundHandler for bridges that know which
+// thread a message was posted in. thread is the ID Threader.SendThreaded
+// returned for that thread, or empty string outside any thread.
+type ThreadInboundHandler func(thread, targetAgent, body string)
+
+// InboundFile is a file a human sent through a bridge, such as a photo,
+// document or voice note. It is fetched only when Save is called.
+type InboundFile struct {
+	Kind string // what was sent, e.g. "photo", "document" or "voice note"
+	Name string // suggested file name
+	Save func(ctx context.Context, path string) error
+}
+
+// ErrFileTooLarge is returned by InboundFile.Save when the file turns out
+// to be bigger than the bridge passes on.
+var ErrFileTooLarge = errors.New("file too large")
+
+// FileInboundHandler is like ThreadInboundHandler for a message carrying a
+// file; body is the message's caption, with any agent prefix parsed off.
+type FileInboundHandler func(thread, targetAgent, body string, file InboundFile)
+
+// FileReceiver is the capability interface for Receivers that pass on files
+// as well as text. SetFileHandler is called before Start; receivers without
+// a file handler ignore the files they are sent.
+type FileReceiver interface {
+	SetFileHandler(handler FileInboundHandler)
+}
+
+// ReplyInboundHandler is like ThreadInboundHandler for an un-addressed
+// message sent as a reply; quoted is the text of the message replied to, so
+// the handler can route by it (e.g. by its "[agent-name]" tag).
+type ReplyInboundHandler func(thread, quoted, body string)
+
+// ReplyReceiver is the capability interface for Receivers that pass on the
+// message a reply quotes. SetReplyHandler is called before Start; receivers
+// without a reply handler route text replies by the quoted message's agent
+// tag themselves.
+type ReplyReceiver interface {
+	SetReplyHandler(handler ReplyInboundHandler)
+}
+
+// Threader is the capability interface for Senders that can post into
+// threads (or reply chains), so each agent's messages stay together.
+// SendThreaded posts text into thread, starting a new thread when thread is
+// empty, and returns the thread's ID for later messages. When formatted is
+// true, text was produced by Formatter.FormatOutbound.
+type Threader interface {
+	SendThreaded(ctx context.Context, thread, text string, formatted bool) (string, error)
+}
+
+// ThreadReceiver is the capability interface for Receivers that report the
+// thread of each inbound message. StartThreaded is used in place of
+// Receiver.Start when the bridge service threads messages by agent.
+type ThreadReceiver interface {
+	StartThreaded(ctx context.Context, handler ThreadInboundHandler) error
 }
 
 // TypingIndicator is the capability interface for bridges that can show a
@@ -63,6 +190,29 @@ func ParseAgentPrefix(text string) (agent, body string) {
 	return strings.ToLower(m[1]), m[2]
 }
 
+// ConciergeCommand is the slash command the bridge service handles itself to
+// show or change the concierge. When allowed, receivers pass it through to
+// the inbound handler instead of running it as a program.
+const ConciergeCommand = "concierge"
+
+// MuteCommand and UnmuteCommand are the slash commands the bridge service
+// handles itself to stop and resume delivering an agent's messages. Allowing
+// MuteCommand allows both.
+const (
+	MuteCommand   = "mute"
+	UnmuteCommand = "unmute"
+)
+
+// IsServiceCommand reports whether command is handled by the bridge service
+// rather than run as a program.
+func IsServiceCommand(command string) bool {
+	switch command {
+	case ConciergeCommand, MuteCommand, UnmuteCommand:
+		return true
+	}
+	return false
+}
+
 // ParseSlashCommand checks if text starts with /<command> where command
 // is in the allowed list. Returns the command name and args string,
 // or empty command if not matched.
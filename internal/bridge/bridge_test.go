@@ -138,3 +138,40 @@ func TestStripH2Envelope(t *testing.T) {
 		}
 	}
 }
+
+func TestParseUrgency(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Urgency
+		wantErr bool
+	}{
+		{"", UrgencyNormal, false},
+		{"low", UrgencyLow, false},
+		{"normal", UrgencyNormal, false},
+		{"high", UrgencyHigh, false},
+		{"urgent", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseUrgency(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseUrgency(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseUrgency(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestIsServiceCommand(t *testing.T) {
+	for _, cmd := range []string{"concierge", "mute", "unmute"} {
+		if !IsServiceCommand(cmd) {
+			t.Errorf("IsServiceCommand(%q) = false, want true", cmd)
+		}
+	}
+	for _, cmd := range []string{"", "h2", "muted"} {
+		if IsServiceCommand(cmd) {
+			t.Errorf("IsServiceCommand(%q) = true, want false", cmd)
+		}
+	}
+}
@@ -5,10 +5,16 @@ import (
 	"fmt"
 	"os/exec"
 	"strings"
+
+	"h2/internal/bridge"
 )
 
-// MacOSNotify implements bridge.Bridge and bridge.Sender using macOS native
-// notifications via osascript. It does not implement bridge.Receiver (send-only).
+// urgentSound is played with high-urgency notifications.
+const urgentSound = "Glass"
+
+// MacOSNotify implements bridge.Bridge, bridge.Sender, and
+// bridge.UrgencySender using macOS native notifications via osascript. It
+// does not implement bridge.Receiver (send-only).
 type MacOSNotify struct {
 	// ExecCommand is used to create the exec.Cmd. If nil, defaults to
 	// exec.CommandContext. Injected for testing.
@@ -21,8 +27,34 @@ func (m *MacOSNotify) Close() error { return nil }
 
 // Send posts a macOS notification with the given text.
 func (m *MacOSNotify) Send(ctx context.Context, text string) error {
+	return m.notify(ctx, text, "")
+}
+
+// WithUrgency returns a sender whose high-urgency notifications play a
+// sound. Other urgencies notify as usual.
+func (m *MacOSNotify) WithUrgency(urgency bridge.Urgency) bridge.Sender {
+	if urgency != bridge.UrgencyHigh {
+		return m
+	}
+	return &urgentSender{m: m}
+}
+
+// urgentSender posts notifications with urgentSound.
+type urgentSender struct {
+	m *MacOSNotify
+}
+
+func (u *urgentSender) Send(ctx context.Context, text string) error {
+	return u.m.notify(ctx, text, urgentSound)
+}
+
+// notify posts a notification, playing the named sound if it is non-empty.
+func (m *MacOSNotify) notify(ctx context.Context, text, sound string) error {
 	escaped := escapeAppleScript(text)
 	script := fmt.Sprintf(`display notification %q with title "h2"`, escaped)
+	if sound != "" {
+		script += fmt.Sprintf(` sound name %q`, sound)
+	}
 
 	cmdFn := m.ExecCommand
 	if cmdFn == nil {
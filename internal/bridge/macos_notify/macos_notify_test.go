@@ -130,3 +130,35 @@ func TestInterfaces(t *testing.T) {
 	// Sender interface
 	var _ bridge.Sender = m
 }
+
+func TestWithUrgency_HighPlaysSound(t *testing.T) {
+	var mu sync.Mutex
+	var scripts []string
+
+	m := &MacOSNotify{
+		ExecCommand: func(ctx context.Context, name string, args ...string) *exec.Cmd {
+			mu.Lock()
+			scripts = append(scripts, args[1])
+			mu.Unlock()
+			return exec.CommandContext(ctx, "true")
+		},
+	}
+
+	ctx := context.Background()
+	if err := m.WithUrgency(bridge.UrgencyHigh).Send(ctx, "blocked"); err != nil {
+		t.Fatalf("high Send: %v", err)
+	}
+	if err := m.WithUrgency(bridge.UrgencyLow).Send(ctx, "fyi"); err != nil {
+		t.Fatalf("low Send: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{
+		`display notification "blocked" with title "h2" sound name "Glass"`,
+		`display notification "fyi" with title "h2"`,
+	}
+	if len(scripts) != 2 || scripts[0] != want[0] || scripts[1] != want[1] {
+		t.Errorf("scripts = %q, want %q", scripts, want)
+	}
+}
@@ -0,0 +1,88 @@
+package bridge
+
+import "strings"
+
+// SpanKind identifies a run of text in the Markdown subset bridges render.
+type SpanKind int
+
+const (
+	SpanText      SpanKind = iota // plain text
+	SpanBold                      // **bold**
+	SpanCode                      // `inline code`
+	SpanCodeBlock                 // ```lang\nfenced code\n```
+)
+
+// Span is one run of parsed Markdown. Text excludes the delimiters.
+type Span struct {
+	Kind SpanKind
+	Text string
+	Lang string // code fence language, SpanCodeBlock only
+}
+
+// ParseMarkdown splits text into spans of the Markdown subset agents
+// commonly produce: bold, inline code, and fenced code blocks. Anything
+// else, including unmatched delimiters, is plain text, so Formatters only
+// need to escape SpanText rather than guess at the author's intent.
+func ParseMarkdown(text string) []Span {
+	var spans []Span
+	var plain strings.Builder
+	flush := func() {
+		if plain.Len() > 0 {
+			spans = append(spans, Span{Kind: SpanText, Text: plain.String()})
+			plain.Reset()
+		}
+	}
+
+	for i := 0; i < len(text); {
+		rest := text[i:]
+		switch {
+		case strings.HasPrefix(rest, "```"):
+			if span, n, ok := parseCodeFence(rest); ok {
+				flush()
+				spans = append(spans, span)
+				i += n
+				continue
+			}
+		case rest[0] == '`':
+			if end := strings.IndexByte(rest[1:], '`'); end > 0 && !strings.Contains(rest[1:1+end], "\n") {
+				flush()
+				spans = append(spans, Span{Kind: SpanCode, Text: rest[1 : 1+end]})
+				i += end + 2
+				continue
+			}
+		case strings.HasPrefix(rest, "**"):
+			if end := strings.Index(rest[2:], "**"); end > 0 {
+				flush()
+				spans = append(spans, Span{Kind: SpanBold, Text: rest[2 : 2+end]})
+				i += end + 4
+				continue
+			}
+		}
+		plain.WriteByte(text[i])
+		i++
+	}
+	flush()
+	return spans
+}
+
+// parseCodeFence parses a fenced code block at the start of s, returning
+// the span and the number of bytes consumed. An unclosed fence isn't a
+// code block.
+func parseCodeFence(s string) (Span, int, bool) {
+	body := s[3:]
+	nl := strings.IndexByte(body, '\n')
+	if nl < 0 {
+		return Span{}, 0, false
+	}
+	lang := strings.TrimSpace(body[:nl])
+	if strings.ContainsAny(lang, " `") {
+		return Span{}, 0, false
+	}
+	code := body[nl+1:]
+	end := strings.Index(code, "```")
+	if end < 0 {
+		return Span{}, 0, false
+	}
+	consumed := 3 + nl + 1 + end + 3
+	return Span{Kind: SpanCodeBlock, Text: strings.TrimSuffix(code[:end], "\n"), Lang: lang}, consumed, true
+}
@@ -0,0 +1,55 @@
+package bridge
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []Span
+	}{
+		{
+			name: "plain",
+			in:   "snake_case and 2*3",
+			want: []Span{{Kind: SpanText, Text: "snake_case and 2*3"}},
+		},
+		{
+			name: "bold and inline code",
+			in:   "**done**: ran `go test`",
+			want: []Span{
+				{Kind: SpanBold, Text: "done"},
+				{Kind: SpanText, Text: ": ran "},
+				{Kind: SpanCode, Text: "go test"},
+			},
+		},
+		{
+			name: "code fence with language",
+			in:   "diff:\n```go\nx := 1\n```\nok",
+			want: []Span{
+				{Kind: SpanText, Text: "diff:\n"},
+				{Kind: SpanCodeBlock, Text: "x := 1", Lang: "go"},
+				{Kind: SpanText, Text: "\nok"},
+			},
+		},
+		{
+			name: "unclosed delimiters stay literal",
+			in:   "a ** b ```\nd",
+			want: []Span{{Kind: SpanText, Text: "a ** b ```\nd"}},
+		},
+		{
+			name: "inline code does not span lines",
+			in:   "`a\nb`",
+			want: []Span{{Kind: SpanText, Text: "`a\nb`"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseMarkdown(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ParseMarkdown(%q) =\n  %+v\nwant\n  %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
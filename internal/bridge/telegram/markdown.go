@@ -0,0 +1,42 @@
+package telegram
+
+import (
+	"strings"
+
+	"h2/internal/bridge"
+)
+
+// markdownV2Special are the characters Telegram MarkdownV2 requires to be
+// escaped in ordinary text.
+const markdownV2Special = "_*[]()~`>#+-=|{}.!\\"
+
+// FormatOutbound converts bold, inline code, and code fences to Telegram
+// MarkdownV2 and escapes everything else, so literal underscores,
+// asterisks, and periods come through as typed.
+func (t *Telegram) FormatOutbound(text string) string {
+	var b strings.Builder
+	for _, span := range bridge.ParseMarkdown(text) {
+		switch span.Kind {
+		case bridge.SpanBold:
+			b.WriteString("*" + escapeMarkdownV2(span.Text, markdownV2Special) + "*")
+		case bridge.SpanCode:
+			b.WriteString("`" + escapeMarkdownV2(span.Text, "`\\") + "`")
+		case bridge.SpanCodeBlock:
+			b.WriteString("```" + span.Lang + "\n" + escapeMarkdownV2(span.Text, "`\\") + "\n```")
+		default:
+			b.WriteString(escapeMarkdownV2(span.Text, markdownV2Special))
+		}
+	}
+	return b.String()
+}
+
+func escapeMarkdownV2(s, special string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(special, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
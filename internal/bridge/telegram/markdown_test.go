@@ -0,0 +1,63 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFormatOutbound(t *testing.T) {
+	tg := &Telegram{}
+	tests := []struct {
+		in, want string
+	}{
+		{"[coder] build complete.", `\[coder\] build complete\.`},
+		{"see my_var_name and 2*3=6!", `see my\_var\_name and 2\*3\=6\!`},
+		{"**Done** (v1.2)", `*Done* \(v1\.2\)`},
+		{"run `a_b.sh`", "run `a_b.sh`"},
+		{"```go\nfmt.Println(`x`)\n```", "```go\nfmt.Println(\\`x\\`)\n```"},
+		{`path C:\tmp`, `path C:\\tmp`},
+	}
+	for _, tt := range tests {
+		if got := tg.FormatOutbound(tt.in); got != tt.want {
+			t.Errorf("FormatOutbound(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSendFormatted_UsesMarkdownV2(t *testing.T) {
+	var gotMode, gotText string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		gotMode = r.FormValue("parse_mode")
+		gotText = r.FormValue("text")
+		json.NewEncoder(w).Encode(apiResponse{OK: true})
+	}))
+	defer srv.Close()
+
+	tg := &Telegram{Token: "TOKEN", ChatID: 42, BaseURL: srv.URL}
+	if err := tg.SendFormatted(context.Background(), `*hi*`); err != nil {
+		t.Fatalf("SendFormatted: %v", err)
+	}
+	if gotMode != "MarkdownV2" || gotText != `*hi*` {
+		t.Fatalf("parse_mode=%q text=%q", gotMode, gotText)
+	}
+
+	// Plain Send must not set a parse mode.
+	if err := tg.Send(context.Background(), "plain_text"); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if gotMode != "" {
+		t.Fatalf("Send set parse_mode=%q", gotMode)
+	}
+}
+
+func TestSendFormatted_TooLongErrors(t *testing.T) {
+	tg := &Telegram{Token: "TOKEN", ChatID: 42, BaseURL: "http://127.0.0.1:0"}
+	if err := tg.SendFormatted(context.Background(), strings.Repeat("x", maxMessageLen+1)); err == nil {
+		t.Fatal("expected error for over-long formatted message")
+	}
+}
@@ -0,0 +1,161 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"h2/internal/bridge"
+)
+
+// defaultMaxFileBytes is the largest inbound file passed on to agents by
+// default: the most the Bot API lets a bot download.
+const defaultMaxFileBytes = 20 << 20
+
+// SetFileHandler sets the handler for inbound photos, documents and voice
+// notes. Without one, they are handled like text messages.
+func (t *Telegram) SetFileHandler(handler bridge.FileInboundHandler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.fileHandler = handler
+}
+
+func (t *Telegram) maxFileBytes() int64 {
+	if t.MaxFileBytes > 0 {
+		return t.MaxFileBytes
+	}
+	return defaultMaxFileBytes
+}
+
+// mediaFile is the file attached to a message.
+type mediaFile struct {
+	kind   string
+	name   string
+	fileID string
+	size   int64 // as reported by Telegram; 0 if unknown
+}
+
+// attachedFile returns the photo, document or voice note attached to msg.
+// Photos come in several sizes; the largest is used.
+func attachedFile(msg *message) (mediaFile, bool) {
+	switch {
+	case len(msg.Photo) > 0:
+		p := msg.Photo[len(msg.Photo)-1]
+		return mediaFile{kind: "photo", name: fmt.Sprintf("photo-%d.jpg", msg.MessageID), fileID: p.FileID, size: p.FileSize}, true
+	case msg.Document != nil:
+		name := filepath.Base(msg.Document.FileName)
+		if msg.Document.FileName == "" || name == "." || name == string(filepath.Separator) {
+			name = fmt.Sprintf("document-%d", msg.MessageID)
+		}
+		return mediaFile{kind: "document", name: name, fileID: msg.Document.FileID, size: msg.Document.FileSize}, true
+	case msg.Voice != nil:
+		return mediaFile{kind: "voice note", name: fmt.Sprintf("voice-%d.ogg", msg.MessageID), fileID: msg.Voice.FileID, size: msg.Voice.FileSize}, true
+	}
+	return mediaFile{}, false
+}
+
+// handleFile passes the file attached to msg to the file handler, routed by
+// its caption like a text message. Files over the size cap are turned away
+// with a reply instead. Returns false if msg has no file or there is no file
+// handler.
+func (t *Telegram) handleFile(ctx context.Context, msg *message) bool {
+	f, ok := attachedFile(msg)
+	if !ok {
+		return false
+	}
+	t.mu.Lock()
+	handler := t.fileHandler
+	t.mu.Unlock()
+	if handler == nil {
+		return false
+	}
+
+	limit := t.maxFileBytes()
+	if f.size > limit {
+		reply := fmt.Sprintf("Sorry, that %s is too large to pass on (%s; the limit is %s).", f.kind, formatMB(f.size), formatMB(limit))
+		if err := t.Send(ctx, reply); err != nil {
+			log.Printf("bridge: telegram: send file too large reply: %v", err)
+		}
+		return true
+	}
+
+	thread, agent, body, quoted := t.route(msg, msg.Caption)
+	if agent == "" {
+		agent = bridge.ParseAgentTag(quoted)
+	}
+	handler(thread, agent, body, bridge.InboundFile{
+		Kind: f.kind,
+		Name: f.name,
+		Save: func(ctx context.Context, path string) error {
+			return t.downloadFile(ctx, f.fileID, path, limit)
+		},
+	})
+	return true
+}
+
+// downloadFile saves the file with Telegram file ID fileID to path. Files
+// turning out larger than limit bytes are discarded with an error wrapping
+// bridge.ErrFileTooLarge.
+func (t *Telegram) downloadFile(ctx context.Context, fileID, path string, limit int64) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", t.apiURL("getFile")+"?"+url.Values{"file_id": {fileID}}.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("telegram getFile: %w", err)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("telegram getFile: %w", err)
+	}
+	var result getFileResponse
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("telegram getFile: decode response: %w", err)
+	}
+	if !result.OK {
+		return fmt.Errorf("telegram getFile: API error: %s", result.Description)
+	}
+
+	req, err = http.NewRequestWithContext(ctx, "GET", t.fileURL(result.Result.FilePath), nil)
+	if err != nil {
+		return fmt.Errorf("telegram download: %w", err)
+	}
+	resp, err = t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("telegram download: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("telegram download: %s", resp.Status)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("telegram download: %w", err)
+	}
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("telegram download: %w", err)
+	}
+	n, err := io.Copy(out, io.LimitReader(resp.Body, limit+1))
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && n > limit {
+		err = fmt.Errorf("%w: exceeds %s", bridge.ErrFileTooLarge, formatMB(limit))
+	}
+	if err != nil {
+		os.Remove(path)
+		return fmt.Errorf("telegram download: %w", err)
+	}
+	return nil
+}
+
+// formatMB renders a byte count in megabytes for replies to the human.
+func formatMB(n int64) string {
+	return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+}
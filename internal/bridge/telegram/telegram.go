@@ -1,13 +1,20 @@
 package telegram
 
 import (
+	"bytes"
+	"container/list"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"mime/multipart"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,19 +25,40 @@ var (
 	// initialBackoff is the starting backoff after a poll error.
 	// Var so tests can override it.
 	initialBackoff = 1 * time.Second
+
+	// unhealthyAfter is how long getUpdates must keep failing before the
+	// receiver reports itself unhealthy. Longer than pollTimeout so a slow
+	// long poll isn't taken for an outage. Var so tests can override it.
+	unhealthyAfter = 2 * pollTimeout
+
+	// maxThreadMessages caps how many messages' thread roots are remembered;
+	// the least recently used are forgotten first. Var so tests can
+	// override it.
+	maxThreadMessages = 10000
 )
 
 const (
 	maxBackoff = 60 * time.Second
 
+	// pollTimeout is how long each getUpdates long poll waits for updates.
+	pollTimeout = 30 * time.Second
+
 	// maxMessageLen is Telegram's maximum message length.
 	maxMessageLen = 4096
 	// maxPages is the maximum number of messages to send for a single response.
 	maxPages = 3
+	// maxCaptionLen is Telegram's maximum caption length for media messages.
+	maxCaptionLen = 1024
 )
 
-// Telegram implements bridge.Bridge, bridge.Sender, and bridge.Receiver
-// using the Telegram Bot API. Standard library only — no external Telegram SDK.
+// Telegram implements bridge.Bridge, bridge.Sender, bridge.Receiver,
+// bridge.Formatter, bridge.Attachment, bridge.Threader,
+// bridge.ThreadReceiver, bridge.FileReceiver, and bridge.UrgencySender
+// using the Telegram Bot API. Standard library only — no external Telegram
+// SDK.
+//
+// Telegram has no threads in ordinary chats, so a thread is a reply chain:
+// its ID is the root message's ID, and every later message replies to it.
 type Telegram struct {
 	Token           string
 	ChatID          int64
@@ -40,11 +68,34 @@ type Telegram struct {
 	// If empty, defaults to "https://api.telegram.org".
 	BaseURL string
 
+	// MaxFileBytes caps the size of inbound files passed on to agents.
+	// Zero uses defaultMaxFileBytes.
+	MaxFileBytes int64
+
 	client http.Client
 	cancel context.CancelFunc
 	wg     sync.WaitGroup
 	mu     sync.Mutex
 	offset int64
+
+	// failingSince is when getUpdates started failing, zero while it
+	// succeeds; polling is set while the poll loop runs. Guarded by mu.
+	failingSince time.Time
+	polling      bool
+
+	// threadOf maps each message in a reply-chain thread to its element in
+	// threadLRU, which holds a threadEntry with the thread's root message ID,
+	// so replies to any message in it can be routed. threadLRU is ordered
+	// most recently used first and capped at maxThreadMessages.
+	threadOf  map[int64]*list.Element
+	threadLRU *list.List
+
+	// fileHandler receives inbound photos, documents and voice notes;
+	// guarded by mu.
+	fileHandler bridge.FileInboundHandler
+
+	// replyHandler receives un-addressed text replies; guarded by mu.
+	replyHandler bridge.ReplyInboundHandler
 }
 
 func (t *Telegram) Name() string { return "telegram" }
@@ -54,43 +105,221 @@ func (t *Telegram) Close() error {
 	return nil
 }
 
-func (t *Telegram) apiURL(method string) string {
-	base := t.BaseURL
-	if base == "" {
-		base = "https://api.telegram.org"
+func (t *Telegram) baseURL() string {
+	if t.BaseURL == "" {
+		return "https://api.telegram.org"
 	}
-	return fmt.Sprintf("%s/bot%s/%s", base, t.Token, method)
+	return t.BaseURL
+}
+
+func (t *Telegram) apiURL(method string) string {
+	return fmt.Sprintf("%s/bot%s/%s", t.baseURL(), t.Token, method)
+}
+
+// fileURL returns the download URL for a file path from getFile.
+func (t *Telegram) fileURL(filePath string) string {
+	return fmt.Sprintf("%s/file/bot%s/%s", t.baseURL(), t.Token, filePath)
 }
 
 // Send posts a text message to the configured chat. Messages longer than
 // Telegram's 4096-character limit are split into multiple messages at line
 // boundaries when possible, up to maxPages messages.
 func (t *Telegram) Send(ctx context.Context, text string) error {
+	return t.send(ctx, text, false)
+}
+
+func (t *Telegram) send(ctx context.Context, text string, silent bool) error {
 	chunks := bridge.SplitMessage(text, maxMessageLen, maxPages)
 	for _, chunk := range chunks {
-		if err := t.sendChunk(ctx, chunk); err != nil {
+		if _, err := t.sendChunk(ctx, chunk, "", 0, silent); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (t *Telegram) sendChunk(ctx context.Context, text string) error {
-	resp, err := t.client.PostForm(t.apiURL("sendMessage"), url.Values{
+// SendFormatted posts text produced by FormatOutbound with MarkdownV2
+// parsing. Formatted text isn't split, since a cut could land inside an
+// entity; text over the message limit returns an error so the caller can
+// fall back to Send.
+func (t *Telegram) SendFormatted(ctx context.Context, text string) error {
+	return t.sendFormatted(ctx, text, false)
+}
+
+func (t *Telegram) sendFormatted(ctx context.Context, text string, silent bool) error {
+	if len([]rune(text)) > maxMessageLen {
+		return fmt.Errorf("telegram send: formatted message exceeds %d characters", maxMessageLen)
+	}
+	_, err := t.sendChunk(ctx, text, "MarkdownV2", 0, silent)
+	return err
+}
+
+// SendThreaded posts text as a reply to the thread's root message, or as a
+// new root message when thread is empty, and returns the root's ID. Plain
+// text is split like Send; formatted text is limited like SendFormatted.
+func (t *Telegram) SendThreaded(ctx context.Context, thread, text string, formatted bool) (string, error) {
+	return t.sendThreaded(ctx, thread, text, formatted, false)
+}
+
+func (t *Telegram) sendThreaded(ctx context.Context, thread, text string, formatted, silent bool) (string, error) {
+	var root int64
+	if thread != "" {
+		id, err := strconv.ParseInt(thread, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("telegram send: invalid thread %q", thread)
+		}
+		root = id
+	}
+
+	chunks := []string{text}
+	parseMode := "MarkdownV2"
+	if formatted {
+		if len([]rune(text)) > maxMessageLen {
+			return "", fmt.Errorf("telegram send: formatted message exceeds %d characters", maxMessageLen)
+		}
+	} else {
+		chunks = bridge.SplitMessage(text, maxMessageLen, maxPages)
+		parseMode = ""
+	}
+	for _, chunk := range chunks {
+		id, err := t.sendChunk(ctx, chunk, parseMode, root, silent)
+		if err != nil {
+			return "", err
+		}
+		if root == 0 {
+			root = id
+		}
+		t.addToThread(id, root)
+	}
+	return strconv.FormatInt(root, 10), nil
+}
+
+// threadEntry is a threadLRU element: message id belongs to the thread
+// rooted at root.
+type threadEntry struct {
+	id, root int64
+}
+
+// addToThread records that message id belongs to the thread rooted at root,
+// forgetting the least recently used message beyond maxThreadMessages.
+func (t *Telegram) addToThread(id, root int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.threadOf == nil {
+		t.threadOf = make(map[int64]*list.Element)
+		t.threadLRU = list.New()
+	}
+	if e, ok := t.threadOf[id]; ok {
+		e.Value = threadEntry{id, root}
+		t.threadLRU.MoveToFront(e)
+		return
+	}
+	t.threadOf[id] = t.threadLRU.PushFront(threadEntry{id, root})
+	for t.threadLRU.Len() > maxThreadMessages {
+		oldest := t.threadLRU.Back()
+		t.threadLRU.Remove(oldest)
+		delete(t.threadOf, oldest.Value.(threadEntry).id)
+	}
+}
+
+// threadFor returns the thread root of message id, or 0 if it isn't part of
+// a thread (or has been forgotten).
+func (t *Telegram) threadFor(id int64) int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e, ok := t.threadOf[id]
+	if !ok {
+		return 0
+	}
+	t.threadLRU.MoveToFront(e)
+	return e.Value.(threadEntry).root
+}
+
+// sendChunk posts one message, as a reply to message replyTo when it is
+// non-zero, and returns the new message's ID. Silent messages arrive
+// without a notification sound.
+func (t *Telegram) sendChunk(ctx context.Context, text, parseMode string, replyTo int64, silent bool) (int64, error) {
+	form := url.Values{
 		"chat_id": {strconv.FormatInt(t.ChatID, 10)},
 		"text":    {text},
-	})
+	}
+	if parseMode != "" {
+		form.Set("parse_mode", parseMode)
+	}
+	if replyTo != 0 {
+		form.Set("reply_to_message_id", strconv.FormatInt(replyTo, 10))
+	}
+	if silent {
+		form.Set("disable_notification", "true")
+	}
+	resp, err := t.client.PostForm(t.apiURL("sendMessage"), form)
+	if err != nil {
+		return 0, fmt.Errorf("telegram send: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result sendMessageResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("telegram send: decode response: %w", err)
+	}
+	if !result.OK {
+		return 0, fmt.Errorf("telegram send: API error: %s", result.Description)
+	}
+	return result.Result.MessageID, nil
+}
+
+// SendFile uploads the file at path to the configured chat as a document.
+// Captions longer than Telegram's 1024-character media caption limit are
+// sent as a separate text message before the file.
+func (t *Telegram) SendFile(ctx context.Context, path, caption string) error {
+	caption = strings.TrimSpace(caption)
+	if len([]rune(caption)) > maxCaptionLen {
+		if err := t.Send(ctx, caption); err != nil {
+			return err
+		}
+		caption = ""
+	}
+
+	f, err := os.Open(path)
 	if err != nil {
-		return fmt.Errorf("telegram send: %w", err)
+		return fmt.Errorf("telegram sendDocument: %w", err)
+	}
+	defer f.Close()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("chat_id", strconv.FormatInt(t.ChatID, 10))
+	if caption != "" {
+		mw.WriteField("caption", caption)
+	}
+	part, err := mw.CreateFormFile("document", filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("telegram sendDocument: %w", err)
+	}
+	if _, err := io.Copy(part, f); err != nil {
+		return fmt.Errorf("telegram sendDocument: read file: %w", err)
+	}
+	if err := mw.Close(); err != nil {
+		return fmt.Errorf("telegram sendDocument: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", t.apiURL("sendDocument"), &body)
+	if err != nil {
+		return fmt.Errorf("telegram sendDocument: %w", err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("telegram sendDocument: %w", err)
 	}
 	defer resp.Body.Close()
 
 	var result apiResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return fmt.Errorf("telegram send: decode response: %w", err)
+		return fmt.Errorf("telegram sendDocument: decode response: %w", err)
 	}
 	if !result.OK {
-		return fmt.Errorf("telegram send: API error: %s", result.Description)
+		return fmt.Errorf("telegram sendDocument: API error: %s", result.Description)
 	}
 	return nil
 }
@@ -99,9 +328,17 @@ func (t *Telegram) sendChunk(ctx context.Context, text string) error {
 // that polls getUpdates and calls handler for each message from the
 // configured ChatID.
 func (t *Telegram) Start(ctx context.Context, handler bridge.InboundHandler) error {
+	return t.StartThreaded(ctx, func(_, agent, body string) { handler(agent, body) })
+}
+
+// StartThreaded is like Start, but also reports the thread of messages that
+// reply to a message in a thread started by SendThreaded.
+func (t *Telegram) StartThreaded(ctx context.Context, handler bridge.ThreadInboundHandler) error {
 	ctx, cancel := context.WithCancel(ctx)
 	t.mu.Lock()
 	t.cancel = cancel
+	t.failingSince = time.Time{}
+	t.polling = true
 	t.mu.Unlock()
 
 	t.wg.Add(1)
@@ -121,8 +358,40 @@ func (t *Telegram) Stop() {
 	t.wg.Wait()
 }
 
-func (t *Telegram) poll(ctx context.Context, handler bridge.InboundHandler) {
+// Healthy reports whether getUpdates is succeeding, or has been failing
+// for less than unhealthyAfter.
+func (t *Telegram) Healthy() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.failingSince.IsZero() || time.Since(t.failingSince) < unhealthyAfter
+}
+
+// Running reports whether the polling goroutine is running. It keeps
+// retrying failed polls itself, so it only stops when Stop is called.
+func (t *Telegram) Running() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.polling
+}
+
+func (t *Telegram) setPollFailing(failing bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch {
+	case !failing:
+		t.failingSince = time.Time{}
+	case t.failingSince.IsZero():
+		t.failingSince = time.Now()
+	}
+}
+
+func (t *Telegram) poll(ctx context.Context, handler bridge.ThreadInboundHandler) {
 	defer t.wg.Done()
+	defer func() {
+		t.mu.Lock()
+		t.polling = false
+		t.mu.Unlock()
+	}()
 
 	backoff := initialBackoff
 
@@ -136,6 +405,7 @@ func (t *Telegram) poll(ctx context.Context, handler bridge.InboundHandler) {
 			if ctx.Err() != nil {
 				return
 			}
+			t.setPollFailing(true)
 			select {
 			case <-time.After(backoff):
 			case <-ctx.Done():
@@ -149,6 +419,7 @@ func (t *Telegram) poll(ctx context.Context, handler bridge.InboundHandler) {
 		}
 
 		backoff = initialBackoff
+		t.setPollFailing(false)
 
 		for _, u := range updates {
 			if u.UpdateID >= t.offset {
@@ -159,21 +430,60 @@ func (t *Telegram) poll(ctx context.Context, handler bridge.InboundHandler) {
 			}
 			// Check for slash commands before agent routing.
 			cmd, args := bridge.ParseSlashCommand(u.Message.Text, t.AllowedCommands)
-			if cmd != "" {
+			if cmd != "" && !bridge.IsServiceCommand(cmd) {
 				log.Printf("bridge: telegram: executing command /%s %s", cmd, args)
 				go t.execAndReply(ctx, cmd, args)
 				continue
 			}
-			agent, body := bridge.ParseAgentPrefix(u.Message.Text)
-			// If no explicit prefix, check reply-to message for agent tag.
-			if agent == "" && u.Message.ReplyToMessage != nil {
-				agent = bridge.ParseAgentTag(u.Message.ReplyToMessage.Text)
+			if t.handleFile(ctx, u.Message) {
+				continue
+			}
+			thread, agent, body, quoted := t.route(u.Message, u.Message.Text)
+			if replyHandler := t.getReplyHandler(); agent == "" && quoted != "" && replyHandler != nil {
+				replyHandler(thread, quoted, body)
+				continue
+			}
+			if agent == "" {
+				agent = bridge.ParseAgentTag(quoted)
 			}
-			handler(agent, body)
+			handler(thread, agent, body)
 		}
 	}
 }
 
+// SetReplyHandler sets the handler for text replies without an agent
+// prefix. Without one, they go to the agent tagged in the quoted message.
+func (t *Telegram) SetReplyHandler(handler bridge.ReplyInboundHandler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.replyHandler = handler
+}
+
+func (t *Telegram) getReplyHandler() bridge.ReplyInboundHandler {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.replyHandler
+}
+
+// route parses the explicit agent prefix and thread of msg, whose text (or
+// caption) is text, and returns them with the message body and the text of
+// the message msg replies to, if any.
+func (t *Telegram) route(msg *message, text string) (thread, agent, body, quoted string) {
+	agent, body = bridge.ParseAgentPrefix(text)
+	if reply := msg.ReplyToMessage; reply != nil {
+		quoted = reply.Text
+		if quoted == "" {
+			quoted = reply.Caption // a file an agent sent
+		}
+		if root := t.threadFor(reply.MessageID); root != 0 {
+			// Later replies to this message stay in the thread too.
+			t.addToThread(msg.MessageID, root)
+			thread = strconv.FormatInt(root, 10)
+		}
+	}
+	return thread, agent, body, quoted
+}
+
 func (t *Telegram) execAndReply(ctx context.Context, cmd, args string) {
 	result := bridge.ExecCommand(cmd, args)
 	tagged := fmt.Sprintf("[%s result]\n%s", cmd, result)
@@ -185,7 +495,7 @@ func (t *Telegram) execAndReply(ctx context.Context, cmd, args string) {
 func (t *Telegram) getUpdates(ctx context.Context) ([]update, error) {
 	params := url.Values{
 		"offset":  {strconv.FormatInt(t.offset, 10)},
-		"timeout": {"30"},
+		"timeout": {strconv.Itoa(int(pollTimeout / time.Second))},
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "GET", t.apiURL("getUpdates")+"?"+params.Encode(), nil)
@@ -238,6 +548,12 @@ type apiResponse struct {
 	Description string `json:"description,omitempty"`
 }
 
+type sendMessageResponse struct {
+	OK          bool    `json:"ok"`
+	Description string  `json:"description,omitempty"`
+	Result      message `json:"result"`
+}
+
 type getUpdatesResponse struct {
 	OK          bool     `json:"ok"`
 	Description string   `json:"description,omitempty"`
@@ -250,9 +566,36 @@ type update struct {
 }
 
 type message struct {
-	Text           string   `json:"text"`
-	Chat           chat     `json:"chat"`
-	ReplyToMessage *message `json:"reply_to_message,omitempty"`
+	MessageID      int64       `json:"message_id"`
+	Text           string      `json:"text"`
+	Caption        string      `json:"caption,omitempty"`
+	Chat           chat        `json:"chat"`
+	ReplyToMessage *message    `json:"reply_to_message,omitempty"`
+	Photo          []photoSize `json:"photo,omitempty"`
+	Document       *fileInfo   `json:"document,omitempty"`
+	Voice          *fileInfo   `json:"voice,omitempty"`
+}
+
+// photoSize is one resolution of a photo; Telegram lists them smallest
+// first.
+type photoSize struct {
+	FileID   string `json:"file_id"`
+	FileSize int64  `json:"file_size,omitempty"`
+}
+
+type fileInfo struct {
+	FileID   string `json:"file_id"`
+	FileName string `json:"file_name,omitempty"`
+	MimeType string `json:"mime_type,omitempty"`
+	FileSize int64  `json:"file_size,omitempty"`
+}
+
+type getFileResponse struct {
+	OK          bool   `json:"ok"`
+	Description string `json:"description,omitempty"`
+	Result      struct {
+		FilePath string `json:"file_path"`
+	} `json:"result"`
 }
 
 type chat struct {
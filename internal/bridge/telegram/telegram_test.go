@@ -3,13 +3,19 @@ package telegram
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"h2/internal/bridge"
 )
 
 func TestSend(t *testing.T) {
@@ -130,6 +136,82 @@ func TestSendTyping(t *testing.T) {
 	}
 }
 
+func TestSendFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.txt")
+	os.WriteFile(path, []byte("report contents"), 0o644)
+
+	var gotChatID, gotCaption, gotName, gotContents string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/botTOKEN/sendDocument" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("ParseMultipartForm: %v", err)
+		}
+		gotChatID = r.FormValue("chat_id")
+		gotCaption = r.FormValue("caption")
+		f, hdr, err := r.FormFile("document")
+		if err != nil {
+			t.Errorf("FormFile: %v", err)
+		} else {
+			gotName = hdr.Filename
+			data, _ := io.ReadAll(f)
+			gotContents = string(data)
+		}
+		json.NewEncoder(w).Encode(apiResponse{OK: true})
+	}))
+	defer srv.Close()
+
+	tg := &Telegram{Token: "TOKEN", ChatID: 42, BaseURL: srv.URL}
+	if err := tg.SendFile(context.Background(), path, "[coder] nightly report"); err != nil {
+		t.Fatalf("SendFile: %v", err)
+	}
+	if gotChatID != "42" || gotCaption != "[coder] nightly report" {
+		t.Errorf("chat_id=%q caption=%q", gotChatID, gotCaption)
+	}
+	if gotName != "report.txt" || gotContents != "report contents" {
+		t.Errorf("document name=%q contents=%q", gotName, gotContents)
+	}
+}
+
+func TestSendFile_LongCaptionSentSeparately(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.diff")
+	os.WriteFile(path, []byte("diff"), 0o644)
+
+	var mu sync.Mutex
+	var calls []string
+	var docCaption string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, strings.TrimPrefix(r.URL.Path, "/botTOKEN/"))
+		if strings.HasSuffix(r.URL.Path, "sendDocument") {
+			r.ParseMultipartForm(1 << 20)
+			docCaption = r.FormValue("caption")
+		}
+		json.NewEncoder(w).Encode(apiResponse{OK: true})
+	}))
+	defer srv.Close()
+
+	tg := &Telegram{Token: "TOKEN", ChatID: 42, BaseURL: srv.URL}
+	if err := tg.SendFile(context.Background(), path, strings.Repeat("x", maxCaptionLen+1)); err != nil {
+		t.Fatalf("SendFile: %v", err)
+	}
+	if strings.Join(calls, ",") != "sendMessage,sendDocument" {
+		t.Fatalf("calls = %v, want sendMessage then sendDocument", calls)
+	}
+	if docCaption != "" {
+		t.Errorf("document caption = %q, want empty", docCaption)
+	}
+}
+
+func TestSendFile_MissingFile(t *testing.T) {
+	tg := &Telegram{Token: "TOKEN", ChatID: 42, BaseURL: "http://127.0.0.1:0"}
+	if err := tg.SendFile(context.Background(), filepath.Join(t.TempDir(), "missing"), ""); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
+
 func TestSend_APIError(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(apiResponse{OK: false, Description: "bad request"})
@@ -322,6 +404,264 @@ func TestStartStop_ReplyRouting(t *testing.T) {
 	}
 }
 
+func TestSendThreaded_RepliesToRoot(t *testing.T) {
+	var mu sync.Mutex
+	var replyTos []string
+	nextID := int64(100)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		mu.Lock()
+		replyTos = append(replyTos, r.FormValue("reply_to_message_id"))
+		nextID++
+		id := nextID
+		mu.Unlock()
+		json.NewEncoder(w).Encode(sendMessageResponse{OK: true, Result: message{MessageID: id}})
+	}))
+	defer srv.Close()
+
+	tg := &Telegram{Token: "TOKEN", ChatID: 42, BaseURL: srv.URL}
+
+	thread, err := tg.SendThreaded(context.Background(), "", "[coder] starting", false)
+	if err != nil {
+		t.Fatalf("SendThreaded: %v", err)
+	}
+	if thread != "101" {
+		t.Errorf("thread = %q, want the first message's ID %q", thread, "101")
+	}
+	again, err := tg.SendThreaded(context.Background(), thread, "[coder] done", false)
+	if err != nil {
+		t.Fatalf("SendThreaded: %v", err)
+	}
+	if again != thread {
+		t.Errorf("thread = %q, want %q", again, thread)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(replyTos) != 2 || replyTos[0] != "" || replyTos[1] != "101" {
+		t.Errorf("reply_to_message_id values = %q, want [\"\" \"101\"]", replyTos)
+	}
+	if root := tg.threadFor(102); root != 101 {
+		t.Errorf("threadFor(102) = %d, want 101", root)
+	}
+}
+
+func TestWithUrgency(t *testing.T) {
+	type sent struct{ text, silent string }
+	var mu sync.Mutex
+	var calls []sent
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		mu.Lock()
+		calls = append(calls, sent{r.FormValue("text"), r.FormValue("disable_notification")})
+		mu.Unlock()
+		json.NewEncoder(w).Encode(sendMessageResponse{OK: true, Result: message{MessageID: 1}})
+	}))
+	defer srv.Close()
+
+	tg := &Telegram{Token: "TOKEN", ChatID: 42, BaseURL: srv.URL}
+	ctx := context.Background()
+
+	if err := tg.WithUrgency(bridge.UrgencyLow).Send(ctx, "[coder] fyi"); err != nil {
+		t.Fatalf("low Send: %v", err)
+	}
+	if err := tg.WithUrgency(bridge.UrgencyHigh).Send(ctx, "[coder] need you"); err != nil {
+		t.Fatalf("high Send: %v", err)
+	}
+	high := tg.WithUrgency(bridge.UrgencyHigh).(bridge.Threader)
+	if _, err := high.SendThreaded(ctx, "", "untagged", false); err != nil {
+		t.Fatalf("high SendThreaded: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []sent{
+		{"[coder] fyi", "true"},
+		{"[coder] " + urgentMarker + "need you", ""},
+		{urgentMarker + "untagged", ""},
+	}
+	if len(calls) != len(want) {
+		t.Fatalf("calls = %q, want %q", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("call %d = %q, want %q", i, calls[i], want[i])
+		}
+	}
+	if agent := bridge.ParseAgentTag(calls[1].text); agent != "coder" {
+		t.Errorf("ParseAgentTag(%q) = %q, want the tag to survive the marker", calls[1].text, agent)
+	}
+}
+
+func TestStartThreaded_ReportsThreadOfReply(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	polled := false
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		first := !polled
+		polled = true
+		mu.Unlock()
+		if !first {
+			<-r.Context().Done()
+			return
+		}
+		json.NewEncoder(w).Encode(getUpdatesResponse{
+			OK: true,
+			Result: []update{
+				{UpdateID: 1, Message: &message{
+					MessageID:      200,
+					Text:           "use main",
+					Chat:           chat{ID: 42},
+					ReplyToMessage: &message{MessageID: 102, Text: "[coder] done", Chat: chat{ID: 42}},
+				}},
+				{UpdateID: 2, Message: &message{MessageID: 201, Text: "hello", Chat: chat{ID: 42}}},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	tg := &Telegram{Token: "TOKEN", ChatID: 42, BaseURL: srv.URL}
+	tg.addToThread(101, 101)
+	tg.addToThread(102, 101)
+
+	handler := func(thread, agent, body string) {
+		mu.Lock()
+		received = append(received, thread+"|"+agent+"|"+body)
+		mu.Unlock()
+	}
+	if err := tg.StartThreaded(context.Background(), handler); err != nil {
+		t.Fatalf("StartThreaded: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		n := len(received)
+		mu.Unlock()
+		if n >= 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	tg.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"101|coder|use main", "||hello"}
+	if strings.Join(received, ",") != strings.Join(want, ",") {
+		t.Errorf("received = %q, want %q", received, want)
+	}
+	// The user's reply joins the thread, so replies to it route there too.
+	if root := tg.threadFor(200); root != 101 {
+		t.Errorf("threadFor(200) = %d, want 101", root)
+	}
+}
+
+func TestAddToThread_ForgetsLeastRecentlyUsed(t *testing.T) {
+	old := maxThreadMessages
+	maxThreadMessages = 2
+	t.Cleanup(func() { maxThreadMessages = old })
+
+	tg := &Telegram{}
+	tg.addToThread(101, 101)
+	tg.addToThread(102, 101)
+	tg.threadFor(101) // 102 is now the least recently used
+	tg.addToThread(103, 101)
+
+	if root := tg.threadFor(102); root != 0 {
+		t.Errorf("threadFor(102) = %d, want 0 (evicted)", root)
+	}
+	for _, id := range []int64{101, 103} {
+		if root := tg.threadFor(id); root != 101 {
+			t.Errorf("threadFor(%d) = %d, want 101", id, root)
+		}
+	}
+	if len(tg.threadOf) != 2 || tg.threadLRU.Len() != 2 {
+		t.Errorf("kept %d/%d entries, want 2", len(tg.threadOf), tg.threadLRU.Len())
+	}
+}
+
+func TestStartThreaded_ReplyHandlerGetsQuote(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	polled := false
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		first := !polled
+		polled = true
+		mu.Unlock()
+		if !first {
+			<-r.Context().Done()
+			return
+		}
+		json.NewEncoder(w).Encode(getUpdatesResponse{
+			OK: true,
+			Result: []update{
+				{UpdateID: 1, Message: &message{
+					MessageID:      200,
+					Text:           "use main",
+					Chat:           chat{ID: 42},
+					ReplyToMessage: &message{MessageID: 102, Text: "[coder] done", Chat: chat{ID: 42}},
+				}},
+				{UpdateID: 2, Message: &message{
+					MessageID:      201,
+					Text:           "nice chart",
+					Chat:           chat{ID: 42},
+					ReplyToMessage: &message{MessageID: 103, Caption: "[analyst] chart", Chat: chat{ID: 42}},
+				}},
+				{UpdateID: 3, Message: &message{
+					MessageID:      202,
+					Text:           "ops: restart it",
+					Chat:           chat{ID: 42},
+					ReplyToMessage: &message{MessageID: 102, Text: "[coder] done", Chat: chat{ID: 42}},
+				}},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	tg := &Telegram{Token: "TOKEN", ChatID: 42, BaseURL: srv.URL}
+	tg.addToThread(102, 101)
+	tg.SetReplyHandler(func(thread, quoted, body string) {
+		mu.Lock()
+		received = append(received, "reply:"+thread+"|"+quoted+"|"+body)
+		mu.Unlock()
+	})
+	handler := func(thread, agent, body string) {
+		mu.Lock()
+		received = append(received, thread+"|"+agent+"|"+body)
+		mu.Unlock()
+	}
+	if err := tg.StartThreaded(context.Background(), handler); err != nil {
+		t.Fatalf("StartThreaded: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		n := len(received)
+		mu.Unlock()
+		if n >= 3 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	tg.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	// An explicit prefix still wins over the quoted message.
+	want := []string{"reply:101|[coder] done|use main", "reply:|[analyst] chart|nice chart", "101|ops|restart it"}
+	if strings.Join(received, ",") != strings.Join(want, ",") {
+		t.Errorf("received = %q, want %q", received, want)
+	}
+}
+
 func TestStartStop_FiltersChatID(t *testing.T) {
 	var mu sync.Mutex
 	var received []string
@@ -569,6 +909,80 @@ func TestPoll_PlainMessage_NotIntercepted(t *testing.T) {
 	}
 }
 
+func TestPoll_ConciergeCommand_PassedToHandler(t *testing.T) {
+	var mu sync.Mutex
+	var handlerCalls []struct{ agent, body string }
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/botTOKEN/getUpdates" {
+			http.NotFound(w, r)
+			return
+		}
+
+		mu.Lock()
+		first := len(handlerCalls) == 0
+		mu.Unlock()
+
+		if first {
+			json.NewEncoder(w).Encode(getUpdatesResponse{
+				OK: true,
+				Result: []update{
+					{
+						UpdateID: 500,
+						Message: &message{
+							Text: "/concierge set coder",
+							Chat: chat{ID: 42},
+						},
+					},
+				},
+			})
+		} else {
+			<-r.Context().Done()
+		}
+	}))
+	defer srv.Close()
+
+	tg := &Telegram{
+		Token:           "TOKEN",
+		ChatID:          42,
+		BaseURL:         srv.URL,
+		AllowedCommands: []string{"h2", "concierge"},
+	}
+
+	handler := func(agent, body string) {
+		mu.Lock()
+		handlerCalls = append(handlerCalls, struct{ agent, body string }{agent, body})
+		mu.Unlock()
+	}
+
+	if err := tg.Start(context.Background(), handler); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		n := len(handlerCalls)
+		mu.Unlock()
+		if n >= 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	tg.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(handlerCalls) != 1 {
+		t.Fatalf("handler called %d times, want 1", len(handlerCalls))
+	}
+	if handlerCalls[0].agent != "" || handlerCalls[0].body != "/concierge set coder" {
+		t.Errorf("handler got (%q, %q), want (\"\", %q)", handlerCalls[0].agent, handlerCalls[0].body, "/concierge set coder")
+	}
+}
+
 func TestPoll_SlashCommand_EmptyAllowedList(t *testing.T) {
 	var mu sync.Mutex
 	var handlerCalls []struct{ agent, body string }
@@ -836,3 +1250,200 @@ func TestPoll_BackoffResetsOnSuccess(t *testing.T) {
 		t.Errorf("backoff did not reset after success: gap between call 4 and 5 was %v, expected ~1ms", gap)
 	}
 }
+
+func TestHealthy_TracksPollFailures(t *testing.T) {
+	old, oldAfter := initialBackoff, unhealthyAfter
+	initialBackoff = 1 * time.Millisecond
+	unhealthyAfter = 20 * time.Millisecond
+	t.Cleanup(func() { initialBackoff, unhealthyAfter = old, oldAfter })
+
+	var failing atomic.Bool
+	failing.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		json.NewEncoder(w).Encode(getUpdatesResponse{OK: true})
+	}))
+	defer srv.Close()
+
+	tg := &Telegram{Token: "TOKEN", ChatID: 42, BaseURL: srv.URL}
+	if !tg.Healthy() {
+		t.Fatal("expected a new receiver to be healthy")
+	}
+	if err := tg.Start(context.Background(), func(agent, body string) {}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer tg.Stop()
+
+	waitHealthy := func(want bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for tg.Healthy() != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("Healthy() never became %v", want)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	waitHealthy(false)
+	if !tg.Running() {
+		t.Error("poll loop should keep running while polls fail")
+	}
+	failing.Store(false)
+	waitHealthy(true)
+}
+
+func TestHealthy_BriefFailuresAndRestart(t *testing.T) {
+	old := initialBackoff
+	initialBackoff = time.Hour // stay in the failed state
+	t.Cleanup(func() { initialBackoff = old })
+
+	var polls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		polls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	tg := &Telegram{Token: "TOKEN", ChatID: 42, BaseURL: srv.URL}
+	if tg.Running() {
+		t.Fatal("receiver should not be running before Start")
+	}
+	if err := tg.Start(context.Background(), func(agent, body string) {}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	for polls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	// A failure shorter than unhealthyAfter doesn't count as an outage.
+	if !tg.Healthy() || !tg.Running() {
+		t.Errorf("healthy=%v running=%v after one failed poll, want both", tg.Healthy(), tg.Running())
+	}
+
+	tg.mu.Lock()
+	tg.failingSince = time.Now().Add(-2 * unhealthyAfter)
+	tg.mu.Unlock()
+	if tg.Healthy() {
+		t.Error("expected a long-failing receiver to be unhealthy")
+	}
+	tg.Stop()
+	if tg.Running() {
+		t.Error("receiver should not be running after Stop")
+	}
+
+	// Starting again resets health.
+	if err := tg.Start(context.Background(), func(agent, body string) {}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer tg.Stop()
+	if !tg.Healthy() || !tg.Running() {
+		t.Errorf("healthy=%v running=%v after restart, want both", tg.Healthy(), tg.Running())
+	}
+}
+
+func TestHandleFile_PhotoSavedOnDemand(t *testing.T) {
+	var gotFileID string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/botTOKEN/getFile":
+			gotFileID = r.URL.Query().Get("file_id")
+			json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": map[string]any{"file_path": "photos/p.jpg"}})
+		case "/file/botTOKEN/photos/p.jpg":
+			w.Write([]byte("jpeg bytes"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tg := &Telegram{Token: "TOKEN", ChatID: 42, BaseURL: srv.URL}
+	var got []string
+	var file bridge.InboundFile
+	tg.SetFileHandler(func(thread, agent, body string, f bridge.InboundFile) {
+		got = append(got, thread+"|"+agent+"|"+body)
+		file = f
+	})
+
+	msg := &message{
+		MessageID: 7,
+		Caption:   "coder: what's wrong here?",
+		Chat:      chat{ID: 42},
+		Photo:     []photoSize{{FileID: "small", FileSize: 100}, {FileID: "big", FileSize: 1000}},
+	}
+	if !tg.handleFile(context.Background(), msg) {
+		t.Fatal("handleFile should handle a photo")
+	}
+	if strings.Join(got, ",") != "|coder|what's wrong here?" {
+		t.Errorf("handler got %q", got)
+	}
+	if file.Kind != "photo" || file.Name != "photo-7.jpg" {
+		t.Errorf("file = %q %q, want photo photo-7.jpg", file.Kind, file.Name)
+	}
+
+	path := filepath.Join(t.TempDir(), "inbox", file.Name)
+	if err := file.Save(context.Background(), path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if gotFileID != "big" {
+		t.Errorf("downloaded file_id %q, want the largest size", gotFileID)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "jpeg bytes" {
+		t.Errorf("saved file = %q, %v", data, err)
+	}
+
+	// A file bigger than the cap is discarded even when Telegram didn't
+	// report its size up front.
+	tg.MaxFileBytes = 4
+	msg = &message{MessageID: 8, Chat: chat{ID: 42}, Voice: &fileInfo{FileID: "v"}}
+	if !tg.handleFile(context.Background(), msg) {
+		t.Fatal("handleFile should handle a voice note")
+	}
+	path = filepath.Join(t.TempDir(), file.Name)
+	if err := file.Save(context.Background(), path); file.Kind != "voice note" || !errors.Is(err, bridge.ErrFileTooLarge) {
+		t.Errorf("saving an oversized %s: err = %v, want ErrFileTooLarge", file.Kind, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("oversized download should be removed, stat err = %v", err)
+	}
+}
+
+func TestHandleFile_TooLargeRepliesToHuman(t *testing.T) {
+	var sent []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		sent = append(sent, r.FormValue("text"))
+		json.NewEncoder(w).Encode(sendMessageResponse{OK: true})
+	}))
+	defer srv.Close()
+
+	tg := &Telegram{Token: "TOKEN", ChatID: 42, BaseURL: srv.URL, MaxFileBytes: 1 << 20}
+	called := false
+	tg.SetFileHandler(func(_, _, _ string, _ bridge.InboundFile) { called = true })
+
+	msg := &message{MessageID: 9, Chat: chat{ID: 42}, Document: &fileInfo{FileID: "d", FileName: "dump.tar", FileSize: 3 << 20}}
+	if !tg.handleFile(context.Background(), msg) {
+		t.Fatal("handleFile should handle an oversized document")
+	}
+	if called {
+		t.Error("oversized file should not reach the handler")
+	}
+	if len(sent) != 1 || !strings.Contains(sent[0], "too large") || !strings.Contains(sent[0], "1.0 MB") {
+		t.Errorf("sent = %q, want a file too large reply", sent)
+	}
+}
+
+func TestHandleFile_NoHandlerOrNoFile(t *testing.T) {
+	tg := &Telegram{Token: "TOKEN", ChatID: 42}
+	photo := &message{MessageID: 1, Chat: chat{ID: 42}, Photo: []photoSize{{FileID: "p"}}}
+	if tg.handleFile(context.Background(), photo) {
+		t.Error("without a file handler, files are left to the text handler")
+	}
+	tg.SetFileHandler(func(_, _, _ string, _ bridge.InboundFile) {})
+	if tg.handleFile(context.Background(), &message{MessageID: 2, Text: "hi", Chat: chat{ID: 42}}) {
+		t.Error("a text message has no file to handle")
+	}
+}
@@ -0,0 +1,62 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+
+	"h2/internal/bridge"
+)
+
+// urgentMarker flags high-urgency messages. Telegram has no louder-than-
+// normal notification, so the text itself has to stand out.
+const urgentMarker = "🚨 "
+
+// WithUrgency returns a sender that delivers low-urgency messages silently
+// and marks high-urgency ones with urgentMarker.
+func (t *Telegram) WithUrgency(urgency bridge.Urgency) bridge.Sender {
+	return &urgentSender{t: t, urgency: urgency}
+}
+
+// urgentSender sends through a Telegram bridge at a non-default urgency. It
+// implements bridge.Sender, bridge.Formatter, and bridge.Threader.
+type urgentSender struct {
+	t       *Telegram
+	urgency bridge.Urgency
+}
+
+func (u *urgentSender) silent() bool { return u.urgency == bridge.UrgencyLow }
+
+// mark adds urgentMarker to high-urgency text, after any [agent] tag so
+// replies still route back to the agent.
+func (u *urgentSender) mark(text string) string {
+	if u.urgency != bridge.UrgencyHigh {
+		return text
+	}
+	if agent := bridge.ParseAgentTag(text); agent != "" {
+		if tag := bridge.FormatAgentTag(agent, ""); strings.HasPrefix(text, tag) {
+			return tag + urgentMarker + text[len(tag):]
+		}
+	}
+	return urgentMarker + text
+}
+
+func (u *urgentSender) Send(ctx context.Context, text string) error {
+	return u.t.send(ctx, u.mark(text), u.silent())
+}
+
+// FormatOutbound marks text before formatting it, so SendFormatted and
+// formatted SendThreaded take it as-is.
+func (u *urgentSender) FormatOutbound(text string) string {
+	return u.t.FormatOutbound(u.mark(text))
+}
+
+func (u *urgentSender) SendFormatted(ctx context.Context, text string) error {
+	return u.t.sendFormatted(ctx, text, u.silent())
+}
+
+func (u *urgentSender) SendThreaded(ctx context.Context, thread, text string, formatted bool) (string, error) {
+	if !formatted {
+		text = u.mark(text)
+	}
+	return u.t.sendThreaded(ctx, thread, text, formatted, u.silent())
+}
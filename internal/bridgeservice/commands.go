@@ -0,0 +1,152 @@
+package bridgeservice
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"h2/internal/bridge"
+	"h2/internal/socketdir"
+)
+
+// conciergeUsage is the reply to a malformed /concierge command.
+const conciergeUsage = "Usage: /concierge, /concierge set <agent>, or /concierge remove."
+
+// muteUsage is the reply to a malformed /mute or /unmute command.
+const muteUsage = "Usage: /mute, /mute <agent>, or /unmute <agent>."
+
+// serviceCommand returns the name and arguments of body if it is a chat
+// command the service handles itself and it's allowed on this bridge, or an
+// empty name otherwise. Allowing mute also allows unmute.
+func (s *Service) serviceCommand(body string) (cmd, args string) {
+	allowed := s.allowedCommands
+	if slices.Contains(allowed, bridge.MuteCommand) {
+		allowed = append(slices.Clip(allowed), bridge.UnmuteCommand)
+	}
+	cmd, args = bridge.ParseSlashCommand(body, allowed)
+	if !bridge.IsServiceCommand(cmd) {
+		return "", ""
+	}
+	return cmd, args
+}
+
+// handleServiceCommand runs a chat command returned by serviceCommand.
+func (s *Service) handleServiceCommand(cmd, args string) {
+	switch cmd {
+	case bridge.ConciergeCommand:
+		s.handleConciergeCommand(args)
+	case bridge.MuteCommand:
+		s.handleMuteCommand(args)
+	case bridge.UnmuteCommand:
+		s.handleUnmuteCommand(args)
+	}
+}
+
+// handleConciergeCommand handles an inbound /concierge command: with no
+// arguments it reports the current concierge, "set <agent>" and "remove"
+// change it the same way h2 bridge set-concierge and remove-concierge do.
+// All replies are bridge status messages.
+func (s *Service) handleConciergeCommand(args string) {
+	ctx := context.Background()
+	fields := strings.Fields(args)
+	switch {
+	case len(fields) == 0:
+		s.mu.Lock()
+		concierge := s.concierge
+		s.mu.Unlock()
+		if concierge == "" {
+			s.sendBridgeStatus(ctx, s.fallbackRouting())
+		} else {
+			s.sendBridgeStatus(ctx, conciergeRouting(concierge))
+		}
+
+	case fields[0] == "set" && len(fields) == 2:
+		agentName := strings.ToLower(fields[1])
+		agents := s.runningAgentNames()
+		if !slices.Contains(agents, agentName) {
+			s.sendBridgeStatus(ctx, noSuchAgentMessage(agentName, agents))
+			return
+		}
+		if resp := s.handleSetConcierge(agentName); resp.Error != "" {
+			s.sendBridgeStatus(ctx, resp.Error)
+		}
+
+	case fields[0] == "remove" && len(fields) == 1:
+		if resp := s.handleRemoveConcierge(); resp.Error != "" {
+			s.sendBridgeStatus(ctx, fmt.Sprintf("No concierge is set. %s",
+				s.fallbackRouting()))
+		}
+
+	default:
+		s.sendBridgeStatus(ctx, conciergeUsage)
+	}
+}
+
+// handleMuteCommand handles an inbound /mute command: with no arguments it
+// lists the muted agents, with an agent name it mutes that running agent.
+func (s *Service) handleMuteCommand(args string) {
+	ctx := context.Background()
+	fields := strings.Fields(args)
+	switch len(fields) {
+	case 0:
+		muted := s.mutedAgents()
+		if len(muted) == 0 {
+			s.sendBridgeStatus(ctx, "No agents are muted.")
+		} else {
+			s.sendBridgeStatus(ctx, fmt.Sprintf("Muted agents: %s.", strings.Join(muted, ", ")))
+		}
+
+	case 1:
+		agentName := strings.ToLower(fields[0])
+		agents := s.runningAgentNames()
+		if !slices.Contains(agents, agentName) {
+			s.sendBridgeStatus(ctx, noSuchAgentMessage(agentName, agents))
+			return
+		}
+		if resp := s.handleMute(agentName); resp.Error != "" {
+			s.sendBridgeStatus(ctx, resp.Error)
+			return
+		}
+		s.sendBridgeStatus(ctx, fmt.Sprintf("Muted %s. Its messages are dropped until /unmute %s, except high-urgency ones.", agentName, agentName))
+
+	default:
+		s.sendBridgeStatus(ctx, muteUsage)
+	}
+}
+
+// handleUnmuteCommand handles an inbound /unmute <agent> command.
+func (s *Service) handleUnmuteCommand(args string) {
+	ctx := context.Background()
+	fields := strings.Fields(args)
+	if len(fields) != 1 {
+		s.sendBridgeStatus(ctx, muteUsage)
+		return
+	}
+	agentName := strings.ToLower(fields[0])
+	if resp := s.handleUnmute(agentName); resp.Error != "" {
+		s.sendBridgeStatus(ctx, resp.Error)
+		return
+	}
+	s.sendBridgeStatus(ctx, fmt.Sprintf("Unmuted %s.", agentName))
+}
+
+// runningAgentNames returns the names of agents with a socket in the socket
+// directory.
+func (s *Service) runningAgentNames() []string {
+	agents, _ := socketdir.ListByTypeIn(s.socketDir, socketdir.TypeAgent)
+	names := make([]string, 0, len(agents))
+	for _, a := range agents {
+		names = append(names, a.Name)
+	}
+	return names
+}
+
+// noSuchAgentMessage explains that agentName can't become the concierge,
+// listing the agents that could.
+func noSuchAgentMessage(agentName string, running []string) string {
+	if len(running) == 0 {
+		return fmt.Sprintf("No such running agent %q. No agents are running. Create agents with h2 run.", agentName)
+	}
+	return fmt.Sprintf("No such running agent %q. Running agents: %s.", agentName, strings.Join(running, ", "))
+}
@@ -0,0 +1,146 @@
+package bridgeservice
+
+import (
+	"strings"
+	"testing"
+
+	"h2/internal/bridge"
+)
+
+func TestConciergeCommand_ShowsCurrent(t *testing.T) {
+	tmpDir := shortTempDir(t)
+	sender := &mockSender{name: "telegram"}
+	svc := New([]bridge.Bridge{sender}, "alice", "sage", "", tmpDir, []string{"concierge"})
+
+	svc.handleInbound("", "/concierge")
+
+	msgs := sender.Messages()
+	if len(msgs) != 1 {
+		t.Fatalf("expected 1 status message, got %d: %v", len(msgs), msgs)
+	}
+	want := bridge.FormatAgentTag("bridge alice", conciergeRouting("sage"))
+	if msgs[0] != want {
+		t.Errorf("got %q, want %q", msgs[0], want)
+	}
+}
+
+func TestConciergeCommand_ShowsNoConcierge(t *testing.T) {
+	tmpDir := shortTempDir(t)
+	sender := &mockSender{name: "telegram"}
+	_ = newMockAgent(t, tmpDir, "coder")
+	svc := New([]bridge.Bridge{sender}, "alice", "", "", tmpDir, []string{"concierge"})
+
+	svc.handleInbound("", "/concierge")
+
+	msgs := sender.Messages()
+	if len(msgs) != 1 {
+		t.Fatalf("expected 1 status message, got %d: %v", len(msgs), msgs)
+	}
+	if !strings.Contains(msgs[0], noConciergeRouting("coder")) {
+		t.Errorf("expected no-concierge routing, got %q", msgs[0])
+	}
+}
+
+func TestConciergeCommand_Set(t *testing.T) {
+	tmpDir := shortTempDir(t)
+	sender := &mockSender{name: "telegram"}
+	agent := newMockAgent(t, tmpDir, "sage")
+	svc := New([]bridge.Bridge{sender}, "alice", "", "", tmpDir, []string{"concierge"})
+
+	svc.handleInbound("", "/concierge set sage")
+
+	svc.mu.Lock()
+	got := svc.concierge
+	svc.mu.Unlock()
+	if got != "sage" {
+		t.Errorf("expected concierge=sage, got %q", got)
+	}
+	msgs := sender.Messages()
+	if len(msgs) != 1 || !strings.Contains(msgs[0], "Concierge added") {
+		t.Errorf("expected 'Concierge added' status, got %v", msgs)
+	}
+	if reqs := agent.Received(); len(reqs) != 0 {
+		t.Errorf("command should not be delivered to an agent, got %d requests", len(reqs))
+	}
+}
+
+func TestConciergeCommand_SetUnknownAgent(t *testing.T) {
+	tmpDir := shortTempDir(t)
+	sender := &mockSender{name: "telegram"}
+	_ = newMockAgent(t, tmpDir, "coder")
+	svc := New([]bridge.Bridge{sender}, "alice", "", "", tmpDir, []string{"concierge"})
+
+	svc.handleInbound("", "/concierge set ghost")
+
+	svc.mu.Lock()
+	got := svc.concierge
+	svc.mu.Unlock()
+	if got != "" {
+		t.Errorf("expected concierge to stay unset, got %q", got)
+	}
+	msgs := sender.Messages()
+	if len(msgs) != 1 {
+		t.Fatalf("expected 1 status message, got %d: %v", len(msgs), msgs)
+	}
+	if !strings.Contains(msgs[0], `No such running agent "ghost"`) || !strings.Contains(msgs[0], "coder") {
+		t.Errorf("expected no-such-agent reply listing coder, got %q", msgs[0])
+	}
+}
+
+func TestConciergeCommand_Remove(t *testing.T) {
+	tmpDir := shortTempDir(t)
+	sender := &mockSender{name: "telegram"}
+	svc := New([]bridge.Bridge{sender}, "alice", "sage", "", tmpDir, []string{"concierge"})
+
+	svc.handleInbound("", "/concierge remove")
+
+	svc.mu.Lock()
+	got := svc.concierge
+	svc.mu.Unlock()
+	if got != "" {
+		t.Errorf("expected concierge cleared, got %q", got)
+	}
+	msgs := sender.Messages()
+	if len(msgs) != 1 || !strings.Contains(msgs[0], "Concierge removed") {
+		t.Errorf("expected 'Concierge removed' status, got %v", msgs)
+	}
+
+	svc.handleInbound("", "/concierge remove")
+	msgs = sender.Messages()
+	if len(msgs) != 2 || !strings.Contains(msgs[1], "No concierge is set") {
+		t.Errorf("expected 'No concierge is set' status, got %v", msgs)
+	}
+}
+
+func TestConciergeCommand_Usage(t *testing.T) {
+	tmpDir := shortTempDir(t)
+	sender := &mockSender{name: "telegram"}
+	svc := New([]bridge.Bridge{sender}, "alice", "", "", tmpDir, []string{"concierge"})
+
+	svc.handleInbound("", "/concierge frobnicate")
+
+	msgs := sender.Messages()
+	if len(msgs) != 1 || !strings.Contains(msgs[0], conciergeUsage) {
+		t.Errorf("expected usage reply, got %v", msgs)
+	}
+}
+
+func TestConciergeCommand_NotAllowedIsDelivered(t *testing.T) {
+	tmpDir := shortTempDir(t)
+	sender := &mockSender{name: "telegram"}
+	agent := newMockAgent(t, tmpDir, "sage")
+	svc := New([]bridge.Bridge{sender}, "alice", "sage", "", tmpDir, nil)
+
+	svc.handleInbound("", "/concierge remove")
+
+	svc.mu.Lock()
+	got := svc.concierge
+	svc.mu.Unlock()
+	if got != "sage" {
+		t.Errorf("concierge should be unchanged, got %q", got)
+	}
+	reqs := agent.Received()
+	if len(reqs) != 1 || reqs[0].Body != "/concierge remove" {
+		t.Errorf("expected message delivered to sage, got %v", reqs)
+	}
+}
@@ -15,6 +15,7 @@ func FromConfig(cfg *config.BridgesConfig) []bridge.Bridge {
 			Token:           cfg.Telegram.BotToken,
 			ChatID:          cfg.Telegram.ChatID,
 			AllowedCommands: cfg.Telegram.AllowedCommands,
+			MaxFileBytes:    int64(cfg.Telegram.MaxFileMB) << 20,
 		})
 	}
 	if cfg.MacOSNotify != nil && cfg.MacOSNotify.Enabled {
@@ -0,0 +1,45 @@
+package bridgeservice
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Policies for un-addressed inbound messages when there is no live concierge
+// and no agent has sent over the bridge yet.
+const (
+	FallbackFirstAgent = "first-agent" // route to the first running agent
+	FallbackReject     = "reject"      // ask the human to address an agent
+	FallbackBroadcast  = "broadcast"   // send to every running agent
+)
+
+// fallback returns the no-concierge fallback policy in effect.
+func (s *Service) fallback() string {
+	if s.noConciergeFallback == "" {
+		return FallbackFirstAgent
+	}
+	return s.noConciergeFallback
+}
+
+// fallbackRouting returns the routing explanation when no concierge is set,
+// according to the fallback policy.
+func (s *Service) fallbackRouting() string {
+	firstAgent := s.firstAvailableAgent()
+	switch {
+	case firstAgent == "" || s.fallback() == FallbackFirstAgent:
+		return noConciergeRouting(firstAgent)
+	case s.fallback() == FallbackBroadcast:
+		return "There is no concierge agent set, so messages will get routed to the last agent " +
+			"that sent a message over this bridge. Until one does, messages go to every running agent."
+	default:
+		return "There is no concierge agent set, so messages will get routed to the last agent " +
+			"that sent a message over this bridge. Until one does, address an agent explicitly."
+	}
+}
+
+// rejectedMessageHint is the reply to an un-addressed message turned away by
+// the reject fallback, listing the running agents.
+func rejectedMessageHint(agents []string) string {
+	return fmt.Sprintf("No concierge is set, address an agent explicitly by prefixing your message "+
+		"with \"<agent name>: \". Running agents: %s.", strings.Join(agents, ", "))
+}
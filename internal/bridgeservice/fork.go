@@ -8,6 +8,7 @@ import (
 	"syscall"
 	"time"
 
+	"h2/internal/config"
 	"h2/internal/socketdir"
 )
 
@@ -18,9 +19,10 @@ const ProbeTimeout = 500 * time.Millisecond
 // bridgeName is the key in config.yaml's top-level bridges map.
 // concierge is the optional concierge agent name for message routing.
 // pod is the optional pod name (empty for standalone bridges).
+// metricsAddr is the optional address to serve Prometheus metrics on.
 // It re-execs with the hidden _bridge-service subcommand and waits for
 // the bridge socket to appear.
-func ForkBridge(bridgeName, concierge, pod string) error {
+func ForkBridge(bridgeName, concierge, pod, metricsAddr string) error {
 	exePath, err := os.Executable()
 	if err != nil {
 		return fmt.Errorf("find executable: %w", err)
@@ -33,6 +35,9 @@ func ForkBridge(bridgeName, concierge, pod string) error {
 	if pod != "" {
 		args = append(args, "--pod", pod)
 	}
+	if metricsAddr != "" {
+		args = append(args, "--metrics-addr", metricsAddr)
+	}
 
 	cmd := exec.Command(exePath, args...)
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
@@ -43,7 +48,7 @@ func ForkBridge(bridgeName, concierge, pod string) error {
 	}
 	cmd.Stdin = devNull
 
-	logDir := filepath.Join(filepath.Dir(socketdir.Dir()), "logs")
+	logDir := filepath.Join(config.ConfigDir(), "logs")
 	os.MkdirAll(logDir, 0o700)
 	logFile, err := os.OpenFile(filepath.Join(logDir, "bridge.log"),
 		os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
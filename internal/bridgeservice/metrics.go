@@ -0,0 +1,201 @@
+package bridgeservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metrics counts bridge activity. Status responses and the Prometheus
+// endpoint both read these counters, so they always agree.
+type metrics struct {
+	mu               sync.Mutex
+	messagesSent     int64            // outbound messages from agents
+	messagesMuted    int64            // outbound messages dropped because the agent is muted
+	messagesReceived int64            // inbound messages from the human
+	channelSent      map[string]int64 // bridge channel -> messages delivered through it
+	channelReceived  map[string]int64 // bridge channel -> messages received on it
+	routingFailures  int64            // inbound messages that couldn't be delivered
+	typingCalls      int64            // typing indicators sent
+	conciergeDowns   int64            // times the concierge was detected as stopped
+}
+
+// metricsSnapshot is a point-in-time copy of the bridge metrics.
+type metricsSnapshot struct {
+	MessagesSent     int64
+	MessagesMuted    int64
+	MessagesReceived int64
+	ChannelSent      map[string]int64
+	ChannelReceived  map[string]int64
+	RoutingFailures  int64
+	TypingCalls      int64
+	ConciergeDowns   int64
+}
+
+func (m *metrics) messageSent() {
+	m.mu.Lock()
+	m.messagesSent++
+	m.mu.Unlock()
+}
+
+func (m *metrics) messageMuted() {
+	m.mu.Lock()
+	m.messagesMuted++
+	m.mu.Unlock()
+}
+
+// messageReceived counts an inbound message arriving on channel, or on an
+// unknown channel if channel is empty.
+func (m *metrics) messageReceived(channel string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messagesReceived++
+	if channel != "" {
+		if m.channelReceived == nil {
+			m.channelReceived = map[string]int64{}
+		}
+		m.channelReceived[channel]++
+	}
+}
+
+// channelDelivered counts a message delivered through channel.
+func (m *metrics) channelDelivered(channel string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.channelSent == nil {
+		m.channelSent = map[string]int64{}
+	}
+	m.channelSent[channel]++
+}
+
+func (m *metrics) routingFailure() {
+	m.mu.Lock()
+	m.routingFailures++
+	m.mu.Unlock()
+}
+
+func (m *metrics) typingCall() {
+	m.mu.Lock()
+	m.typingCalls++
+	m.mu.Unlock()
+}
+
+func (m *metrics) conciergeDown() {
+	m.mu.Lock()
+	m.conciergeDowns++
+	m.mu.Unlock()
+}
+
+func (m *metrics) snapshot() metricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snap := metricsSnapshot{
+		MessagesSent:     m.messagesSent,
+		MessagesMuted:    m.messagesMuted,
+		MessagesReceived: m.messagesReceived,
+		ChannelSent:      map[string]int64{},
+		ChannelReceived:  map[string]int64{},
+		RoutingFailures:  m.routingFailures,
+		TypingCalls:      m.typingCalls,
+		ConciergeDowns:   m.conciergeDowns,
+	}
+	for k, v := range m.channelSent {
+		snap.ChannelSent[k] = v
+	}
+	for k, v := range m.channelReceived {
+		snap.ChannelReceived[k] = v
+	}
+	return snap
+}
+
+// writePrometheus writes the metrics in the Prometheus text exposition
+// format, labelled with the bridge name. Every configured channel is listed,
+// even before it has seen traffic.
+func (snap metricsSnapshot) writePrometheus(w io.Writer, bridgeName string, channels []string) {
+	counter := func(name, help string, value int64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+		fmt.Fprintf(w, "%s{bridge=\"%s\"} %d\n", name, labelValue(bridgeName), value)
+	}
+	channelCounter := func(name, help string, values map[string]int64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+		seen := map[string]bool{}
+		var names []string
+		for _, ch := range channels {
+			if !seen[ch] {
+				seen[ch] = true
+				names = append(names, ch)
+			}
+		}
+		for ch := range values {
+			if !seen[ch] {
+				seen[ch] = true
+				names = append(names, ch)
+			}
+		}
+		sort.Strings(names)
+		for _, ch := range names {
+			fmt.Fprintf(w, "%s{bridge=\"%s\",channel=\"%s\"} %d\n", name, labelValue(bridgeName), labelValue(ch), values[ch])
+		}
+	}
+
+	counter("h2_bridge_messages_sent_total", "Messages sent by agents through the bridge.", snap.MessagesSent)
+	counter("h2_bridge_messages_muted_total", "Messages from muted agents that the bridge dropped.", snap.MessagesMuted)
+	counter("h2_bridge_messages_received_total", "Messages received from the bridge's channels.", snap.MessagesReceived)
+	channelCounter("h2_bridge_channel_messages_sent_total", "Messages delivered through each channel.", snap.ChannelSent)
+	channelCounter("h2_bridge_channel_messages_received_total", "Messages received on each channel.", snap.ChannelReceived)
+	counter("h2_bridge_routing_failures_total", "Inbound messages that could not be delivered to an agent.", snap.RoutingFailures)
+	counter("h2_bridge_typing_calls_total", "Typing indicators sent.", snap.TypingCalls)
+	counter("h2_bridge_concierge_down_total", "Times the concierge agent was detected as stopped.", snap.ConciergeDowns)
+}
+
+// prometheusLabelEscaper escapes a label value for the Prometheus text
+// format, which only escapes backslash, double quote and newline. (Go's %q
+// would also escape other characters, e.g. non-ASCII ones, which
+// Prometheus would then read literally.)
+var prometheusLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labelValue escapes v for use inside a quoted Prometheus label value.
+func labelValue(v string) string {
+	return prometheusLabelEscaper.Replace(v)
+}
+
+// handleMetrics serves the bridge metrics in Prometheus text format.
+func (s *Service) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	var channels []string
+	for _, b := range s.bridges {
+		channels = append(channels, b.Name())
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	s.metrics.snapshot().writePrometheus(w, s.name, channels)
+}
+
+// serveMetrics listens on s.metricsAddr and serves /metrics until ctx is
+// done.
+func (s *Service) serveMetrics(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.metricsAddr)
+	if err != nil {
+		return fmt.Errorf("listen on metrics address: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	go func() {
+		log.Printf("bridge: serving metrics on http://%s/metrics", ln.Addr())
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("bridge: metrics server: %v", err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,84 @@
+package bridgeservice
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"h2/internal/bridge"
+)
+
+func TestMetrics_AgreeWithStatus(t *testing.T) {
+	tmpDir := shortTempDir(t)
+	_ = newMockAgent(t, tmpDir, "concierge")
+	sender := &mockSender{name: "telegram"}
+	svc := New([]bridge.Bridge{sender}, "alice", "concierge", "", tmpDir, nil)
+	svc.conciergeAlive = true
+
+	svc.inboundHandler("telegram")("", "hello")
+	svc.inboundHandler("telegram")("ghost", "anyone?") // not running
+	if err := svc.sendOutbound("concierge", "hi there", bridge.UrgencyNormal); err != nil {
+		t.Fatal(err)
+	}
+	svc.handleConciergeDown(context.Background(), "concierge")
+
+	info := svc.buildBridgeInfo()
+	if info.MessagesSent != 1 || info.MessagesReceived != 2 {
+		t.Errorf("status: sent=%d received=%d, want 1 and 2", info.MessagesSent, info.MessagesReceived)
+	}
+
+	rec := httptest.NewRecorder()
+	svc.handleMetrics(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`h2_bridge_messages_sent_total{bridge="alice"} 1`,
+		`h2_bridge_messages_received_total{bridge="alice"} 2`,
+		`h2_bridge_channel_messages_sent_total{bridge="alice",channel="telegram"} 1`,
+		`h2_bridge_channel_messages_received_total{bridge="alice",channel="telegram"} 2`,
+		`h2_bridge_routing_failures_total{bridge="alice"} 1`,
+		`h2_bridge_typing_calls_total{bridge="alice"} 0`,
+		`h2_bridge_concierge_down_total{bridge="alice"} 1`,
+		"# TYPE h2_bridge_messages_sent_total counter",
+	} {
+		if !strings.Contains(body, want+"\n") {
+			t.Errorf("metrics missing %q:\n%s", want, body)
+		}
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+}
+
+func TestMetrics_ListsIdleChannels(t *testing.T) {
+	svc := New([]bridge.Bridge{&mockSender{name: "telegram"}, &mockSender{name: "macos_notify"}}, "alice", "", "", t.TempDir(), nil)
+
+	rec := httptest.NewRecorder()
+	svc.handleMetrics(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, ch := range []string{"telegram", "macos_notify"} {
+		want := `h2_bridge_channel_messages_received_total{bridge="alice",channel="` + ch + `"} 0`
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestWritePrometheus_EscapesLabelValues(t *testing.T) {
+	var buf strings.Builder
+	metricsSnapshot{}.writePrometheus(&buf, "café \"q\"\\\n", nil)
+	want := `h2_bridge_messages_sent_total{bridge="café \"q\"\\\n"} 0`
+	if !strings.Contains(buf.String(), want+"\n") {
+		t.Errorf("metrics missing %q:\n%s", want, buf.String())
+	}
+}
+
+func TestRun_InvalidMetricsAddr(t *testing.T) {
+	svc := New([]bridge.Bridge{&mockSender{name: "telegram"}}, "alice", "", "", shortTempDir(t), nil,
+		ServiceOpts{MetricsAddr: "not-an-address"})
+
+	err := svc.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "listen on metrics address") {
+		t.Fatalf("expected metrics listen error, got %v", err)
+	}
+}
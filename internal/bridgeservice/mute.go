@@ -0,0 +1,93 @@
+package bridgeservice
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"h2/internal/bridge"
+	"h2/internal/session/message"
+)
+
+// Muting an agent stops its messages reaching the bridge's channels until
+// it is unmuted. The mute set lives in memory for the bridge's lifetime;
+// dropped messages are counted but not kept, so unmuting resumes delivery
+// without replaying them.
+
+// muteKey is the mute set key for an agent name. Agent names can have any
+// case, so the set ignores it.
+func muteKey(agentName string) string {
+	return strings.ToLower(strings.TrimSpace(agentName))
+}
+
+// handleMute adds agentName to the mute set. The agent doesn't need to be
+// running.
+func (s *Service) handleMute(agentName string) *message.Response {
+	agentName = muteKey(agentName)
+	if agentName == "" {
+		return &message.Response{Error: "agent name is required"}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.muted[agentName] {
+		return &message.Response{Error: fmt.Sprintf("%s is already muted", agentName)}
+	}
+	if s.muted == nil {
+		s.muted = make(map[string]bool)
+	}
+	s.muted[agentName] = true
+	log.Printf("bridge: muted %s", agentName)
+	return &message.Response{OK: true}
+}
+
+// handleUnmute removes agentName from the mute set.
+func (s *Service) handleUnmute(agentName string) *message.Response {
+	agentName = muteKey(agentName)
+	if agentName == "" {
+		return &message.Response{Error: "agent name is required"}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.muted[agentName] {
+		return &message.Response{Error: fmt.Sprintf("%s is not muted", agentName)}
+	}
+	delete(s.muted, agentName)
+	log.Printf("bridge: unmuted %s", agentName)
+	return &message.Response{OK: true}
+}
+
+// dropMuted reports whether a message from agent at urgency should be
+// dropped because agent is muted, counting and logging it if so.
+// High-urgency messages always get through.
+func (s *Service) dropMuted(agent string, urgency bridge.Urgency) bool {
+	if urgency == bridge.UrgencyHigh {
+		return false
+	}
+	s.mu.Lock()
+	muted := s.muted[muteKey(agent)]
+	s.mu.Unlock()
+	if !muted {
+		return false
+	}
+	log.Printf("bridge: dropped message from muted agent %s", agent)
+	s.metrics.messageMuted()
+	return true
+}
+
+// mutedAgents returns the muted agents, sorted.
+func (s *Service) mutedAgents() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mutedAgentsLocked()
+}
+
+// mutedAgentsLocked is mutedAgents for callers holding s.mu.
+func (s *Service) mutedAgentsLocked() []string {
+	var names []string
+	for name := range s.muted {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,210 @@
+package bridgeservice
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"h2/internal/bridge"
+	"h2/internal/session/message"
+	"h2/internal/socketdir"
+)
+
+func TestSendOutbound_MutedAgent(t *testing.T) {
+	sender := &mockSender{name: "telegram"}
+	svc := New([]bridge.Bridge{sender}, "alice", "concierge", "", t.TempDir(), nil)
+
+	if resp := svc.handleMute("Coder"); !resp.OK {
+		t.Fatalf("mute: %s", resp.Error)
+	}
+	for _, body := range []string{"working", "still working"} {
+		if err := svc.sendOutbound("coder", body, bridge.UrgencyNormal); err != nil {
+			t.Fatalf("sendOutbound: %v", err)
+		}
+	}
+	if err := svc.sendOutbound("coder", "blocked", bridge.UrgencyHigh); err != nil {
+		t.Fatalf("sendOutbound: %v", err)
+	}
+	if err := svc.sendOutbound("reviewer", "lgtm", bridge.UrgencyNormal); err != nil {
+		t.Fatalf("sendOutbound: %v", err)
+	}
+	if msgs := sender.Messages(); !slices.Equal(msgs, []string{"[coder] blocked", "[reviewer] lgtm"}) {
+		t.Errorf("messages = %q, want only the high-urgency and unmuted ones", msgs)
+	}
+
+	info := svc.buildBridgeInfo()
+	if info.MessagesSent != 2 || info.MessagesMuted != 2 {
+		t.Errorf("sent=%d muted=%d, want 2 and 2", info.MessagesSent, info.MessagesMuted)
+	}
+	if !slices.Equal(info.Muted, []string{"coder"}) {
+		t.Errorf("muted = %v, want [coder]", info.Muted)
+	}
+
+	// Unmuting resumes delivery without replaying what was dropped.
+	if resp := svc.handleUnmute("coder"); !resp.OK {
+		t.Fatalf("unmute: %s", resp.Error)
+	}
+	if err := svc.sendOutbound("coder", "done", bridge.UrgencyNormal); err != nil {
+		t.Fatalf("sendOutbound: %v", err)
+	}
+	msgs := sender.Messages()
+	if len(msgs) != 3 || msgs[2] != "[coder] done" {
+		t.Errorf("messages = %q, want just the new message after unmute", msgs)
+	}
+	if info := svc.buildBridgeInfo(); len(info.Muted) != 0 {
+		t.Errorf("muted = %v, want none", info.Muted)
+	}
+}
+
+func TestSendOutbound_MutedAgentDoesNotBecomeLastSender(t *testing.T) {
+	sender := &mockSender{name: "telegram"}
+	svc := New([]bridge.Bridge{sender}, "alice", "", "", t.TempDir(), nil)
+
+	svc.sendOutbound("reviewer", "lgtm", bridge.UrgencyNormal)
+	svc.handleMute("coder")
+	svc.sendOutbound("coder", "working", bridge.UrgencyNormal)
+
+	svc.mu.Lock()
+	last := svc.lastSender
+	svc.mu.Unlock()
+	if last != "reviewer" {
+		t.Errorf("lastSender = %q, want reviewer", last)
+	}
+}
+
+func TestSendOutbound_MutedAgentMixedCase(t *testing.T) {
+	sender := &mockSender{name: "telegram"}
+	svc := New([]bridge.Bridge{sender}, "alice", "", "", t.TempDir(), nil)
+
+	// An agent launched with --name Coder, muted by either spelling.
+	if resp := svc.handleMute("Coder"); !resp.OK {
+		t.Fatalf("mute: %s", resp.Error)
+	}
+	if err := svc.sendOutbound("Coder", "working", bridge.UrgencyNormal); err != nil {
+		t.Fatalf("sendOutbound: %v", err)
+	}
+	if msgs := sender.Messages(); len(msgs) != 0 {
+		t.Errorf("messages = %q, want the muted agent's message dropped", msgs)
+	}
+}
+
+func TestHandleMute_Errors(t *testing.T) {
+	svc := New(nil, "alice", "", "", t.TempDir(), nil)
+
+	if resp := svc.handleMute(""); resp.Error != "agent name is required" {
+		t.Errorf("empty name: got %+v", resp)
+	}
+	svc.handleMute("coder")
+	if resp := svc.handleMute("coder"); resp.Error != "coder is already muted" {
+		t.Errorf("double mute: got %+v", resp)
+	}
+	if resp := svc.handleUnmute("reviewer"); resp.Error != "reviewer is not muted" {
+		t.Errorf("unmute unmuted: got %+v", resp)
+	}
+}
+
+func TestMuteRequest(t *testing.T) {
+	tmpDir := shortTempDir(t)
+	sender := &mockSender{name: "telegram"}
+	svc := New([]bridge.Bridge{sender}, "alice", "", "", tmpDir, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- svc.Run(ctx) }()
+	defer func() {
+		cancel()
+		<-errCh
+	}()
+
+	sockPath := filepath.Join(tmpDir, socketdir.Format(socketdir.TypeBridge, "alice"))
+	waitForSocket(t, sockPath)
+
+	request := func(req *message.Request) *message.Response {
+		t.Helper()
+		conn, err := net.Dial("unix", sockPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		if err := message.SendRequest(conn, req); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := message.ReadResponse(conn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	if resp := request(&message.Request{Type: "mute", Body: "coder"}); !resp.OK {
+		t.Fatalf("mute: %s", resp.Error)
+	}
+	if resp := request(&message.Request{Type: "send", From: "coder", Body: "hi"}); !resp.OK {
+		t.Fatalf("send: %s", resp.Error)
+	}
+	if msgs := sender.Messages(); slices.Contains(msgs, "[coder] hi") {
+		t.Errorf("muted send was delivered: %q", msgs)
+	}
+	resp := request(&message.Request{Type: "status"})
+	if resp.Bridge == nil || !slices.Equal(resp.Bridge.Muted, []string{"coder"}) || resp.Bridge.MessagesMuted != 1 {
+		t.Errorf("status = %+v, want coder muted with 1 dropped message", resp.Bridge)
+	}
+	if resp := request(&message.Request{Type: "unmute", Body: "coder"}); !resp.OK {
+		t.Fatalf("unmute: %s", resp.Error)
+	}
+}
+
+func TestMuteCommand(t *testing.T) {
+	tmpDir := shortTempDir(t)
+	sender := &mockSender{name: "telegram"}
+	agent := newMockAgent(t, tmpDir, "coder")
+	svc := New([]bridge.Bridge{sender}, "alice", "", "", tmpDir, []string{"mute"})
+
+	svc.handleInbound("", "/mute")
+	svc.handleInbound("", "/mute Coder")
+	svc.handleInbound("", "/mute")
+	svc.handleInbound("", "/mute ghost")
+	svc.handleInbound("", "/unmute coder")
+	svc.handleInbound("", "/unmute")
+
+	msgs := sender.Messages()
+	wants := []string{
+		"No agents are muted.",
+		"Muted coder.",
+		"Muted agents: coder.",
+		`No such running agent "ghost"`,
+		"Unmuted coder.",
+		muteUsage,
+	}
+	if len(msgs) != len(wants) {
+		t.Fatalf("expected %d status messages, got %d: %q", len(wants), len(msgs), msgs)
+	}
+	for i, want := range wants {
+		if !strings.Contains(msgs[i], want) {
+			t.Errorf("reply %d = %q, want it to contain %q", i, msgs[i], want)
+		}
+	}
+	if reqs := agent.Received(); len(reqs) != 0 {
+		t.Errorf("commands should not be delivered to an agent, got %d requests", len(reqs))
+	}
+}
+
+func TestMuteCommand_NotAllowedIsDelivered(t *testing.T) {
+	tmpDir := shortTempDir(t)
+	sender := &mockSender{name: "telegram"}
+	agent := newMockAgent(t, tmpDir, "coder")
+	svc := New([]bridge.Bridge{sender}, "alice", "coder", "", tmpDir, []string{"concierge"})
+
+	svc.handleInbound("", "/mute coder")
+
+	if muted := svc.mutedAgents(); len(muted) != 0 {
+		t.Errorf("muted = %v, want none", muted)
+	}
+	reqs := agent.Received()
+	if len(reqs) != 1 || reqs[0].Body != "/mute coder" {
+		t.Errorf("expected message delivered to coder, got %v", reqs)
+	}
+}
@@ -0,0 +1,97 @@
+package bridgeservice
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// ConciergeShift is one entry of a concierge rotation: Agent becomes the
+// concierge at Start (the local wall-clock time of day, as an offset from
+// midnight) each day.
+type ConciergeShift struct {
+	Agent string
+	Start time.Duration
+}
+
+// shiftAt returns the shift in effect at now, given shifts sorted by Start.
+// Before the first shift of the day, the last shift of the previous day is
+// still in effect.
+func shiftAt(shifts []ConciergeShift, now time.Time) ConciergeShift {
+	current := shifts[len(shifts)-1]
+	for _, sh := range shifts {
+		if shiftStart(now, sh).After(now) {
+			break
+		}
+		current = sh
+	}
+	return current
+}
+
+// nextShiftChange returns the first shift start strictly after now.
+func nextShiftChange(shifts []ConciergeShift, now time.Time) time.Time {
+	for _, sh := range shifts {
+		if t := shiftStart(now, sh); t.After(now) {
+			return t
+		}
+	}
+	return shiftStart(now.AddDate(0, 0, 1), shifts[0])
+}
+
+// shiftStart returns when sh starts on day's date, by the wall clock of
+// day's location. Building the time from its fields rather than adding
+// sh.Start to midnight keeps shifts on the hour across DST changes, when
+// a day is 23 or 25 hours long.
+func shiftStart(day time.Time, sh ConciergeShift) time.Time {
+	y, m, d := day.Date()
+	hh, mm := int(sh.Start/time.Hour), int(sh.Start%time.Hour/time.Minute)
+	return time.Date(y, m, d, hh, mm, 0, 0, day.Location())
+}
+
+// runConciergeRotation switches the concierge at each shift start until ctx
+// is done. The concierge set at startup is left alone until the first switch.
+func (s *Service) runConciergeRotation(ctx context.Context) {
+	shifts := append([]ConciergeShift(nil), s.conciergeRotation...)
+	if len(shifts) == 0 {
+		return
+	}
+	sort.Slice(shifts, func(i, j int) bool { return shifts[i].Start < shifts[j].Start })
+
+	for {
+		next := nextShiftChange(shifts, time.Now())
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.rotateConcierge(ctx, shiftAt(shifts, next).Agent)
+	}
+}
+
+// rotateConcierge promotes agentName to concierge if its socket is alive.
+// If it isn't running, the current concierge is kept and the bridge warns.
+func (s *Service) rotateConcierge(ctx context.Context, agentName string) {
+	s.mu.Lock()
+	current := s.concierge
+	s.mu.Unlock()
+	if current == agentName {
+		return
+	}
+
+	if _, err := s.queryAgentStateFn(agentName); err != nil {
+		log.Printf("bridge: concierge rotation: %s not reachable, keeping %q: %v", agentName, current, err)
+		keeping := "no concierge"
+		if current != "" {
+			keeping = current
+		}
+		s.sendBridgeStatus(ctx, fmt.Sprintf(
+			"Scheduled concierge %s is not running; keeping %s.", agentName, keeping))
+		return
+	}
+	log.Printf("bridge: concierge rotation: switching from %q to %s", current, agentName)
+	s.handleSetConcierge(agentName)
+}
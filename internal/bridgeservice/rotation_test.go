@@ -0,0 +1,105 @@
+package bridgeservice
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"h2/internal/bridge"
+)
+
+func TestShiftAtAndNextShiftChange(t *testing.T) {
+	shifts := []ConciergeShift{
+		{Agent: "day", Start: 9 * time.Hour},
+		{Agent: "night", Start: 18 * time.Hour},
+	}
+	day := time.Date(2026, 3, 10, 0, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		at        time.Duration
+		wantAgent string
+		wantNext  time.Time
+	}{
+		{2 * time.Hour, "night", day.Add(9 * time.Hour)},
+		{9 * time.Hour, "day", day.Add(18 * time.Hour)},
+		{12 * time.Hour, "day", day.Add(18 * time.Hour)},
+		{23 * time.Hour, "night", day.AddDate(0, 0, 1).Add(9 * time.Hour)},
+	}
+	for _, tt := range tests {
+		now := day.Add(tt.at)
+		if got := shiftAt(shifts, now).Agent; got != tt.wantAgent {
+			t.Errorf("shiftAt(%s) = %q, want %q", tt.at, got, tt.wantAgent)
+		}
+		if got := nextShiftChange(shifts, now); !got.Equal(tt.wantNext) {
+			t.Errorf("nextShiftChange(%s) = %s, want %s", tt.at, got, tt.wantNext)
+		}
+	}
+}
+
+func TestShiftAtAndNextShiftChange_DSTDays(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+	shifts := []ConciergeShift{
+		{Agent: "day", Start: 9 * time.Hour},
+		{Agent: "night", Start: 18 * time.Hour},
+	}
+	// Clocks spring forward on 2026-03-08 and fall back on 2026-11-01.
+	for _, date := range []time.Time{
+		time.Date(2026, 3, 8, 1, 0, 0, 0, loc),
+		time.Date(2026, 11, 1, 1, 0, 0, 0, loc),
+	} {
+		nine := time.Date(date.Year(), date.Month(), date.Day(), 9, 0, 0, 0, loc)
+		if got := nextShiftChange(shifts, date); !got.Equal(nine) {
+			t.Errorf("nextShiftChange(%s) = %s, want %s", date, got, nine)
+		}
+		if got := shiftAt(shifts, nine.Add(-time.Minute)).Agent; got != "night" {
+			t.Errorf("shiftAt(%s) = %q, want night", nine.Add(-time.Minute), got)
+		}
+		if got := shiftAt(shifts, nine).Agent; got != "day" {
+			t.Errorf("shiftAt(%s) = %q, want day", nine, got)
+		}
+	}
+}
+
+func TestRotateConcierge_SwitchesToLiveAgent(t *testing.T) {
+	tmpDir := shortTempDir(t)
+	_ = newMockStatusAgent(t, tmpDir, "night", "idle")
+
+	sender := &mockSender{name: "telegram"}
+	svc := New([]bridge.Bridge{sender}, "alice", "day", "", tmpDir, nil)
+
+	svc.rotateConcierge(context.Background(), "night")
+
+	svc.mu.Lock()
+	concierge, alive := svc.concierge, svc.conciergeAlive
+	svc.mu.Unlock()
+	if concierge != "night" || !alive {
+		t.Errorf("concierge = %q (alive=%v), want night (alive)", concierge, alive)
+	}
+	msgs := sender.Messages()
+	if len(msgs) != 1 || !strings.Contains(msgs[0], "Concierge changed") {
+		t.Errorf("messages = %q, want a concierge-changed status", msgs)
+	}
+}
+
+func TestRotateConcierge_KeepsCurrentWhenScheduledAgentDown(t *testing.T) {
+	tmpDir := shortTempDir(t)
+	sender := &mockSender{name: "telegram"}
+	svc := New([]bridge.Bridge{sender}, "alice", "day", "", tmpDir, nil)
+
+	svc.rotateConcierge(context.Background(), "night")
+
+	svc.mu.Lock()
+	concierge := svc.concierge
+	svc.mu.Unlock()
+	if concierge != "day" {
+		t.Errorf("concierge = %q, want day to be kept", concierge)
+	}
+	msgs := sender.Messages()
+	if len(msgs) != 1 || !strings.Contains(msgs[0], "Scheduled concierge night is not running; keeping day.") {
+		t.Errorf("messages = %q, want a keeping-current warning", msgs)
+	}
+}
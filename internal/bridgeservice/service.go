@@ -3,45 +3,71 @@ package bridgeservice
 import (
 	"context"
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"log"
 	"net"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"h2/internal/bridge"
+	"h2/internal/config"
 	"h2/internal/session/message"
 	"h2/internal/socketdir"
 )
 
 const defaultConciergeFailureThreshold = 2
 
+// inboundFileTimeout bounds how long saving a file sent through a bridge may
+// take.
+const inboundFileTimeout = 2 * time.Minute
+
+// defaultAskTimeout is how long an ask request waits for the agent's reply
+// when neither the request nor the bridge config sets a timeout.
+const defaultAskTimeout = 2 * time.Minute
+
 // Service manages bridge instances and routes messages between external
 // platforms (Telegram, macOS notifications) and h2 agent sessions.
 type Service struct {
-	bridges            []bridge.Bridge
-	name               string        // bridge config name (used in socket name and status)
-	concierge          string        // session name, empty if --no-concierge; guarded by mu
-	conciergeAlive     bool          // whether the concierge agent socket is reachable; guarded by mu
-	conciergeFailures  int           // consecutive failed concierge liveness probes; guarded by mu
-	pod                string        // pod name, empty for standalone bridges
-	socketDir          string        // ~/.h2/sockets/
-	lastSender         string        // tracks last agent who sent outbound
-	lastRoutedAgent    string        // tracks last agent an inbound message was delivered to
-	allowedCommands    []string      // slash commands allowed on this bridge
-	expectsResponse    bool          // auto-set --expects-response on inbound messages
-	typingTickInterval time.Duration // interval between typing indicator ticks; 0 uses default
-	queryAgentStateFn  func(string) (string, error)
-	cancel             context.CancelFunc
+	bridges               []bridge.Bridge
+	name                  string                   // bridge config name (used in socket name and status)
+	concierge             string                   // session name, empty if --no-concierge; guarded by mu
+	conciergeAlive        bool                     // whether the concierge agent socket is reachable; guarded by mu
+	conciergeFailures     int                      // consecutive failed concierge liveness probes; guarded by mu
+	pod                   string                   // pod name, empty for standalone bridges
+	socketDir             string                   // ~/.h2/sockets/
+	lastSender            string                   // tracks last agent who sent outbound
+	lastRoutedAgent       string                   // tracks last agent an inbound message was delivered to
+	allowedCommands       []string                 // slash commands allowed on this bridge
+	expectsResponse       bool                     // auto-set --expects-response on inbound messages
+	askTimeout            time.Duration            // await each inbound message's reply for this long; 0 disables
+	askWaiters            map[string][]chan string // agent name -> pending asks; guarded by mu
+	muted                 map[string]bool          // agents whose outbound messages are dropped; guarded by mu
+	threads               bool                     // post each agent's messages into its own thread
+	agentThreads          map[threadKey]string     // {bridge, agent} -> thread ID; guarded by mu
+	threadAgents          map[threadKey]string     // {bridge, thread ID} -> agent; guarded by mu
+	typingTickInterval    time.Duration            // interval between typing indicator ticks; 0 uses default
+	typingBackoff         time.Duration            // pause after a failed typing indicator; 0 uses default
+	receiverCheckInterval time.Duration            // interval between receiver health probes; 0 uses default
+	receiverOutageNotice  time.Duration            // announce receivers down for this long; 0 uses default
+	notifyTransitions     []string                 // agent state changes to announce
+	notifyDebounce        time.Duration            // how long a new state must hold before it's announced; 0 uses default
+	transitionInterval    time.Duration            // interval between agent state polls for notifications; 0 uses default
+	noConciergeFallback   string                   // where un-addressed messages go without a concierge or last sender
+	conciergeRotation     []ConciergeShift
+	queryAgentStateFn     func(string) (string, error)
+	queryAgentInfoFn      func(string) (*message.AgentInfo, error)
+	cancel                context.CancelFunc
 
 	// Status tracking.
 	startTime        time.Time
 	lastActivityTime time.Time
-	messagesSent     int64
-	messagesReceived int64
+	metrics          metrics
+	metricsAddr      string // serve Prometheus metrics on this address; empty disables
 
 	mu sync.Mutex
 }
@@ -52,6 +78,45 @@ type ServiceOpts struct {
 	// the recipient agent for every inbound message from the bridge. This
 	// causes the agent to receive an idle reminder if it hasn't responded.
 	ExpectsResponse bool
+
+	// ConciergeRotation switches the concierge to each shift's agent at
+	// the shift's start time, if that agent is running.
+	ConciergeRotation []ConciergeShift
+
+	// AskTimeout, when positive, makes every inbound message an ask: the
+	// bridge waits this long for the agent's next outbound message and
+	// posts a timeout notice to the channel if none arrives.
+	AskTimeout time.Duration
+
+	// Threads posts each non-concierge agent's messages into a thread of
+	// its own on bridges that support threads, and routes replies in that
+	// thread back to the agent.
+	Threads bool
+
+	// NotifyTransitions lists the agent state changes announced on the
+	// bridge: TransitionIdle and/or TransitionBlocked.
+	NotifyTransitions []string
+
+	// NotifyDebounce is how long a new agent state must hold before it is
+	// announced. Zero uses defaultNotifyDebounce.
+	NotifyDebounce time.Duration
+
+	// NoConciergeFallback decides where un-addressed messages go when there
+	// is no live concierge and no agent has sent over the bridge:
+	// FallbackFirstAgent (the default when empty), FallbackReject or
+	// FallbackBroadcast.
+	NoConciergeFallback string
+
+	// MetricsAddr, when set, serves the bridge's counters in Prometheus
+	// text format at http://<MetricsAddr>/metrics.
+	MetricsAddr string
+}
+
+// threadKey identifies an agent's thread, or the agent owning a thread, on
+// one bridge.
+type threadKey struct {
+	bridge string
+	id     string
 }
 
 // New creates a bridge service.
@@ -68,8 +133,16 @@ func New(bridges []bridge.Bridge, name, concierge, pod, socketDir string, allowe
 	}
 	if len(opts) > 0 {
 		s.expectsResponse = opts[0].ExpectsResponse
+		s.conciergeRotation = opts[0].ConciergeRotation
+		s.askTimeout = opts[0].AskTimeout
+		s.threads = opts[0].Threads
+		s.notifyTransitions = opts[0].NotifyTransitions
+		s.notifyDebounce = opts[0].NotifyDebounce
+		s.noConciergeFallback = opts[0].NoConciergeFallback
+		s.metricsAddr = opts[0].MetricsAddr
 	}
 	s.queryAgentStateFn = s.queryAgentState
+	s.queryAgentInfoFn = s.queryAgentInfo
 	return s
 }
 
@@ -87,23 +160,41 @@ func (s *Service) Run(ctx context.Context) error {
 		return fmt.Errorf("create socket dir: %w", err)
 	}
 
+	if s.metricsAddr != "" {
+		if err := s.serveMetrics(ctx); err != nil {
+			return err
+		}
+	}
+
 	// Start receivers before creating the socket, so the socket's existence
-	// signals that everything is ready.
+	// signals that everything is ready. Each is then supervised, so one
+	// that fails to start or loses its connection is restarted.
+	var supervisors sync.WaitGroup
 	for _, b := range s.bridges {
-		if r, ok := b.(bridge.Receiver); ok {
-			if err := r.Start(ctx, s.handleInbound); err != nil {
-				return fmt.Errorf("start receiver %s: %w", b.Name(), err)
-			}
+		if _, ok := b.(bridge.Receiver); !ok {
+			continue
 		}
+		err := s.startReceiver(ctx, b)
+		if err != nil {
+			log.Printf("bridge: start receiver %s: %v (will retry)", b.Name(), err)
+		}
+		supervisors.Add(1)
+		go func() {
+			defer supervisors.Done()
+			s.superviseReceiver(ctx, b, err == nil)
+		}()
 	}
 
+	if err := os.MkdirAll(s.socketDir, 0o700); err != nil {
+		return fmt.Errorf("create socket dir: %w", err)
+	}
 	sockPath := filepath.Join(s.socketDir, socketdir.Format(socketdir.TypeBridge, s.name))
 
 	if err := socketdir.ProbeSocket(sockPath, fmt.Sprintf("bridge %q", s.name)); err != nil {
 		return err
 	}
 
-	ln, err := net.Listen("unix", sockPath)
+	ln, err := socketdir.Listen(sockPath)
 	if err != nil {
 		return fmt.Errorf("listen on bridge socket: %w", err)
 	}
@@ -117,6 +208,10 @@ func (s *Service) Run(ctx context.Context) error {
 	// Start typing indicator loop.
 	go s.runTypingLoop(ctx)
 
+	go s.runConciergeRotation(ctx)
+
+	go s.runTransitionWatcher(ctx)
+
 	// Send startup status message.
 	s.sendStartupMessage(ctx)
 
@@ -128,7 +223,8 @@ func (s *Service) Run(ctx context.Context) error {
 	defer shutdownCancel()
 	s.sendBridgeStatus(shutdownCtx, "Bridge is shutting down.")
 
-	// Stop receivers.
+	// Stop receivers once their supervisors can no longer restart them.
+	supervisors.Wait()
 	for _, b := range s.bridges {
 		if r, ok := b.(bridge.Receiver); ok {
 			r.Stop()
@@ -163,11 +259,28 @@ func (s *Service) handleConn(conn net.Conn) {
 
 	switch req.Type {
 	case "send":
-		if err := s.sendOutbound(req.From, req.Body); err != nil {
+		urgency, err := bridge.ParseUrgency(req.Urgency)
+		if err != nil {
+			message.SendResponse(conn, &message.Response{Error: err.Error()})
+			return
+		}
+		send := s.sendOutbound
+		if req.Replay {
+			send = s.sendReplay
+		}
+		if err := send(req.From, req.Body, urgency); err != nil {
+			message.SendResponse(conn, &message.Response{Error: err.Error()})
+		} else {
+			message.SendResponse(conn, &message.Response{OK: true})
+		}
+	case "send-file":
+		if err := s.sendOutboundFile(req.From, req.FilePath, req.Body); err != nil {
 			message.SendResponse(conn, &message.Response{Error: err.Error()})
 		} else {
 			message.SendResponse(conn, &message.Response{OK: true})
 		}
+	case "ask":
+		message.SendResponse(conn, s.handleAsk(req))
 	case "status":
 		message.SendResponse(conn, &message.Response{
 			OK:     true,
@@ -179,40 +292,354 @@ func (s *Service) handleConn(conn net.Conn) {
 	case "remove-concierge":
 		resp := s.handleRemoveConcierge()
 		message.SendResponse(conn, resp)
+	case "mute":
+		message.SendResponse(conn, s.handleMute(req.Body))
+	case "unmute":
+		message.SendResponse(conn, s.handleUnmute(req.Body))
 	case "stop":
 		message.SendResponse(conn, &message.Response{OK: true})
 		s.cancel()
 	default:
 		message.SendResponse(conn, &message.Response{
-			Error: "bridge only handles 'send', 'status', 'stop', 'set-concierge', and 'remove-concierge' requests",
+			Error: "bridge only handles 'send', 'send-file', 'ask', 'status', 'stop', 'set-concierge', 'remove-concierge', 'mute', and 'unmute' requests",
 		})
 	}
 }
 
 // handleInbound routes a message from an external platform to an agent.
 func (s *Service) handleInbound(targetAgent, body string) {
+	s.deliverInbound("", targetAgent, body, nil)
+}
+
+// inboundHandler returns the inbound handler for the receiver of the bridge
+// named bridgeName.
+func (s *Service) inboundHandler(bridgeName string) bridge.InboundHandler {
+	return func(targetAgent, body string) {
+		s.deliverInbound(bridgeName, targetAgent, body, nil)
+	}
+}
+
+// deliverInbound routes an inbound message received on channel, and the
+// file sent with it if file is non-nil, to targetAgent or the default
+// target. The file is saved to the agent's session dir and delivered as a
+// file attachment.
+func (s *Service) deliverInbound(channel, targetAgent, body string, file *bridge.InboundFile) {
 	log.Printf("bridge: inbound message (target=%q, body=%q)", targetAgent, body)
+	s.metrics.messageReceived(channel)
 	s.mu.Lock()
-	s.messagesReceived++
 	s.lastActivityTime = time.Now()
 	s.mu.Unlock()
+	if targetAgent == "" && file == nil {
+		if cmd, args := s.serviceCommand(body); cmd != "" {
+			s.handleServiceCommand(cmd, args)
+			return
+		}
+	}
 	target := targetAgent
 	if target == "" {
 		target = s.resolveDefaultTarget()
 	}
 	if target == "" {
-		log.Printf("bridge: no target agent for inbound message, no agents available")
-		s.replyError("No agents are running, unable to deliver message.")
+		agents := s.runningAgentNames()
+		switch {
+		case len(agents) == 0:
+			log.Printf("bridge: no target agent for inbound message, no agents available")
+			s.metrics.routingFailure()
+			s.replyError("No agents are running, unable to deliver message.")
+		case s.noConciergeFallback == FallbackBroadcast:
+			log.Printf("bridge: broadcasting inbound to %s", strings.Join(agents, ", "))
+			// The file is saved once, in the first agent's inbox, and
+			// shared. No asks are registered: any one agent's message
+			// would answer the lot.
+			var attachments []message.Attachment
+			if file != nil {
+				a, ok := s.fetchInboundFile(agents[0], *file)
+				if !ok {
+					return
+				}
+				attachments = append(attachments, a)
+			}
+			for _, agent := range agents {
+				s.sendInbound(agent, body, attachments, false)
+			}
+		default:
+			log.Printf("bridge: no target agent for inbound message, no concierge set")
+			s.metrics.routingFailure()
+			s.replyError(rejectedMessageHint(agents))
+		}
 		return
 	}
+	s.deliverTo(target, body, file)
+}
+
+// deliverTo sends an inbound message, and the file sent with it if file is
+// non-nil, to target.
+func (s *Service) deliverTo(target, body string, file *bridge.InboundFile) {
+	var attachments []message.Attachment
+	if file != nil {
+		a, ok := s.fetchInboundFile(target, *file)
+		if !ok {
+			return
+		}
+		attachments = append(attachments, a)
+	}
+	s.sendInbound(target, body, attachments, s.askTimeout > 0)
+}
+
+// fetchInboundFile saves file to target's inbox, once target is known to be
+// running. On failure it tells the channel why and returns false.
+func (s *Service) fetchInboundFile(target string, file bridge.InboundFile) (message.Attachment, bool) {
+	if !slices.Contains(s.runningAgentNames(), target) {
+		log.Printf("bridge: not saving inbound %s, %s is not running", file.Kind, target)
+		s.metrics.routingFailure()
+		s.replyError(deliveryFailureReply(target, errors.New("not running")))
+		return message.Attachment{}, false
+	}
+	a, err := s.saveInboundFile(target, file)
+	if err != nil {
+		log.Printf("bridge: save inbound %s for %s: %v", file.Kind, target, err)
+		s.metrics.routingFailure()
+		if errors.Is(err, bridge.ErrFileTooLarge) {
+			s.replyError(fmt.Sprintf("Sorry, that %s is too large to pass on.", file.Kind))
+		} else {
+			s.replyError(fmt.Sprintf("Couldn't pass the %s on to %s.", file.Kind, target))
+		}
+		return message.Attachment{}, false
+	}
+	return a, true
+}
+
+// sendInbound sends an inbound message with its attachments to target.
+// With ask, target's next message back is awaited (see awaitInboundReply).
+func (s *Service) sendInbound(target, body string, attachments []message.Attachment, ask bool) {
 	log.Printf("bridge: routing inbound to %s", target)
-	if err := s.sendToAgent(target, s.name, body); err != nil {
+	var waiter chan string
+	if ask {
+		// Register before sending so a fast reply can't slip past.
+		waiter = s.addAskWaiter(target)
+	}
+	if err := s.sendToAgent(target, s.name, body, attachments...); err != nil {
+		s.removeAskWaiter(target, waiter)
 		log.Printf("bridge: send to agent %s: %v", target, err)
-		s.replyError(fmt.Sprintf("%s agent is not running, unable to deliver message.", target))
+		s.metrics.routingFailure()
+		s.replyError(deliveryFailureReply(target, err))
 	} else {
 		s.mu.Lock()
 		s.lastRoutedAgent = target
 		s.mu.Unlock()
+		if waiter != nil {
+			go s.awaitInboundReply(target, waiter)
+		}
+	}
+}
+
+// deliveryFailureReply returns the reply telling the user why target didn't
+// get their message. An agent that answered says why through its error
+// code; one that didn't answer at all is taken to be not running.
+func deliveryFailureReply(target string, err error) string {
+	var re *message.ResponseError
+	if !errors.As(err, &re) {
+		return fmt.Sprintf("%s agent is not running, unable to deliver message.", target)
+	}
+	switch re.Code {
+	case message.ErrCodeTooLarge:
+		return fmt.Sprintf("Message is too large for %s, not delivered.", target)
+	default:
+		return fmt.Sprintf("%s agent couldn't take the message: %s", target, re.Message)
+	}
+}
+
+// threadInboundHandler returns the inbound handler for a threaded bridge:
+// un-addressed messages posted in an agent's thread go to that agent.
+func (s *Service) threadInboundHandler(bridgeName string) bridge.ThreadInboundHandler {
+	return func(thread, targetAgent, body string) {
+		if cmd, _ := s.serviceCommand(body); targetAgent == "" && thread != "" && cmd == "" {
+			s.mu.Lock()
+			targetAgent = s.threadAgents[threadKey{bridgeName, thread}]
+			s.mu.Unlock()
+		}
+		s.deliverInbound(bridgeName, targetAgent, body, nil)
+	}
+}
+
+// replyInboundHandler returns the handler for un-addressed replies on a
+// bridge: a reply to a message tagged with a running agent's name goes to
+// that agent. Other replies (to the concierge, to bridge notices, or to an
+// agent that has since stopped) are routed like any un-addressed message.
+func (s *Service) replyInboundHandler(bridgeName string) bridge.ReplyInboundHandler {
+	threaded := s.threadInboundHandler(bridgeName)
+	return func(thread, quoted, body string) {
+		var target string
+		if cmd, _ := s.serviceCommand(body); cmd == "" {
+			target = s.replyTarget(quoted)
+		}
+		threaded(thread, target, body)
+	}
+}
+
+// replyTarget returns the running agent whose tag starts quoted, or empty
+// string if there is none.
+func (s *Service) replyTarget(quoted string) string {
+	agent := bridge.ParseAgentTag(quoted)
+	if agent == "" {
+		return ""
+	}
+	if !slices.Contains(s.runningAgentNames(), agent) {
+		log.Printf("bridge: reply quotes [%s], which is not a running agent; using default routing", agent)
+		return ""
+	}
+	return agent
+}
+
+// fileInboundHandler returns the handler for files arriving on a bridge.
+// They are routed like text, threads included. Downloading a file can take
+// a while, so it is delivered in the background rather than holding up the
+// bridge's receive loop.
+func (s *Service) fileInboundHandler(bridgeName string) bridge.FileInboundHandler {
+	return func(thread, targetAgent, body string, file bridge.InboundFile) {
+		if targetAgent == "" && thread != "" {
+			s.mu.Lock()
+			targetAgent = s.threadAgents[threadKey{bridgeName, thread}]
+			s.mu.Unlock()
+		}
+		go s.deliverInbound(bridgeName, targetAgent, body, &file)
+	}
+}
+
+// saveInboundFile downloads file into the inbox of agent's session dir and
+// returns the attachment that points the agent at it.
+func (s *Service) saveInboundFile(agent string, file bridge.InboundFile) (message.Attachment, error) {
+	name := filepath.Base(file.Name)
+	path := filepath.Join(config.SessionDir(agent), "inbox", time.Now().Format("20060102-150405")+"-"+name)
+	ctx, cancel := context.WithTimeout(context.Background(), inboundFileTimeout)
+	defer cancel()
+	if err := file.Save(ctx, path); err != nil {
+		return message.Attachment{}, err
+	}
+	return message.Attachment{
+		Kind:        message.AttachmentFile,
+		Path:        path,
+		Description: file.Kind + " the human sent you",
+	}, nil
+}
+
+// awaitInboundReply waits for target's reply to an inbound message. The
+// reply itself reaches the channel through sendOutbound; only a timeout
+// needs reporting here.
+func (s *Service) awaitInboundReply(target string, waiter chan string) {
+	if _, ok := s.waitForReply(target, waiter, s.askTimeout); !ok {
+		s.replyError(fmt.Sprintf("%s agent did not respond in time.", target))
+	}
+}
+
+// handleAsk sends req.Body to an agent and blocks until the agent's next
+// outbound message through this bridge, returning it as the reply.
+func (s *Service) handleAsk(req *message.Request) *message.Response {
+	if req.Body == "" {
+		return &message.Response{Error: "message body is required"}
+	}
+	timeout := s.askTimeout
+	if req.Timeout != "" {
+		d, err := time.ParseDuration(req.Timeout)
+		if err != nil || d <= 0 {
+			return &message.Response{Error: fmt.Sprintf("invalid timeout %q", req.Timeout)}
+		}
+		timeout = d
+	}
+	if timeout <= 0 {
+		timeout = defaultAskTimeout
+	}
+	target := req.To
+	if target == "" {
+		target = s.resolveDefaultTarget()
+	}
+	if target == "" {
+		if s.firstAvailableAgent() != "" {
+			return &message.Response{Error: "no concierge is set; address an agent explicitly"}
+		}
+		return &message.Response{Error: "no agents are running"}
+	}
+	from := req.From
+	if from == "" {
+		from = s.name
+	}
+
+	waiter := s.addAskWaiter(target)
+	if err := s.sendToAgent(target, from, req.Body); err != nil {
+		s.removeAskWaiter(target, waiter)
+		return &message.Response{Error: fmt.Sprintf("send to %s: %v", target, err), Code: message.ErrorCodeOf(err)}
+	}
+	reply, ok := s.waitForReply(target, waiter, timeout)
+	if !ok {
+		return &message.Response{Error: "agent did not respond in time"}
+	}
+	return &message.Response{OK: true, Reply: reply}
+}
+
+// addAskWaiter registers a channel that receives agent's next outbound
+// message.
+func (s *Service) addAskWaiter(agent string) chan string {
+	ch := make(chan string, 1)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.askWaiters == nil {
+		s.askWaiters = make(map[string][]chan string)
+	}
+	s.askWaiters[agent] = append(s.askWaiters[agent], ch)
+	return ch
+}
+
+// removeAskWaiter unregisters ch if it hasn't been answered yet. A nil ch
+// is a no-op.
+func (s *Service) removeAskWaiter(agent string, ch chan string) {
+	if ch == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	waiters := s.askWaiters[agent]
+	for i, w := range waiters {
+		if w == ch {
+			waiters = append(waiters[:i], waiters[i+1:]...)
+			break
+		}
+	}
+	if len(waiters) == 0 {
+		delete(s.askWaiters, agent)
+	} else {
+		s.askWaiters[agent] = waiters
+	}
+}
+
+// waitForReply blocks until waiter is answered or timeout passes. ok is
+// false on timeout, after which the waiter is unregistered.
+func (s *Service) waitForReply(agent string, waiter chan string, timeout time.Duration) (string, bool) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case reply := <-waiter:
+		return reply, true
+	case <-timer.C:
+		s.removeAskWaiter(agent, waiter)
+		// The reply may have raced the timer.
+		select {
+		case reply := <-waiter:
+			return reply, true
+		default:
+			return "", false
+		}
+	}
+}
+
+// answerAskWaiters hands body to every ask pending on agent. Each ask is
+// answered by a single message.
+func (s *Service) answerAskWaiters(agent, body string) {
+	s.mu.Lock()
+	waiters := s.askWaiters[agent]
+	delete(s.askWaiters, agent)
+	s.mu.Unlock()
+	for _, ch := range waiters {
+		ch <- body
 	}
 }
 
@@ -290,8 +717,7 @@ func (s *Service) handleRemoveConcierge() *message.Response {
 	}
 
 	ctx := context.Background()
-	firstAgent := s.firstAvailableAgent()
-	msg := fmt.Sprintf("Concierge removed. %s", noConciergeRouting(firstAgent))
+	msg := fmt.Sprintf("Concierge removed. %s", s.fallbackRouting())
 	s.sendBridgeStatus(ctx, msg)
 
 	return &message.Response{OK: true}
@@ -299,34 +725,194 @@ func (s *Service) handleRemoveConcierge() *message.Response {
 
 // sendOutbound sends a message from an agent to all Sender bridges.
 // Messages from non-concierge agents are tagged with [agent-name] so that
-// replies can be routed back to the correct agent.
+// replies can be routed back to the correct agent. Formatter bridges then
+// render the tagged text's Markdown natively. With threads enabled, tagged
+// messages go into the agent's thread on Threader bridges. UrgencySender
+// bridges deliver at the given urgency; the rest ignore it.
+// Messages from muted agents are dropped (see dropMuted), though they still
+// answer pending asks.
 // Returns an error if any bridge fails to deliver the message.
-func (s *Service) sendOutbound(from, body string) error {
+func (s *Service) sendOutbound(from, body string, urgency bridge.Urgency) error {
+	if s.dropMuted(from, urgency) {
+		s.answerAskWaiters(from, body)
+		return nil
+	}
+	tagged := s.recordOutbound(from, body)
+	s.answerAskWaiters(from, body)
+	return s.deliverOutbound(from, body, tagged, urgency)
+}
+
+// replayPrefix marks messages an agent re-sends with h2 resend.
+const replayPrefix = "(replay) "
+
+// sendReplay sends a message an agent already sent once, prefixed with
+// "(replay)". Replays are tagged and threaded like sendOutbound, but don't
+// count as new messages: they leave the sent count, the last sender and
+// pending asks alone.
+func (s *Service) sendReplay(from, body string, urgency bridge.Urgency) error {
+	if s.dropMuted(from, urgency) {
+		return nil
+	}
+	body = replayPrefix + body
+	return s.deliverOutbound(from, body, s.tagOutbound(from, body), urgency)
+}
+
+// deliverOutbound sends tagged, body as tagged by tagOutbound, to all Sender
+// bridges.
+func (s *Service) deliverOutbound(from, body, tagged string, urgency bridge.Urgency) error {
+	threaded := s.threads && tagged != body // only tagged agents get threads
+
+	ctx := context.Background()
+	var errs []string
+	for _, b := range s.bridges {
+		var target any = b
+		if u, ok := b.(bridge.UrgencySender); ok && urgency != bridge.UrgencyNormal {
+			target = u.WithUrgency(urgency)
+		}
+		if t, ok := target.(bridge.Threader); ok && threaded {
+			if err := s.sendThreaded(ctx, b.Name(), t, from, tagged); err != nil {
+				log.Printf("bridge: send via %s: %v", b.Name(), err)
+				errs = append(errs, fmt.Sprintf("%s: %v", b.Name(), err))
+			} else {
+				s.metrics.channelDelivered(b.Name())
+			}
+		} else if sender, ok := target.(bridge.Sender); ok {
+			if err := sendFormatted(ctx, sender, tagged); err != nil {
+				log.Printf("bridge: send via %s: %v", b.Name(), err)
+				errs = append(errs, fmt.Sprintf("%s: %v", b.Name(), err))
+			} else {
+				s.metrics.channelDelivered(b.Name())
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("send failed: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// sendFormatted sends agent text through sender, rendered in the platform's
+// native markup if the sender is a bridge.Formatter. If the formatted send
+// fails, the text is resent as-is so the message still gets through.
+func sendFormatted(ctx context.Context, sender bridge.Sender, text string) error {
+	f, ok := sender.(bridge.Formatter)
+	if !ok {
+		return sender.Send(ctx, text)
+	}
+	err := f.SendFormatted(ctx, f.FormatOutbound(text))
+	if err == nil {
+		return nil
+	}
+	log.Printf("bridge: formatted send failed, retrying as plain text: %v", err)
+	return sender.Send(ctx, text)
+}
+
+// sendThreaded posts text into agent's thread on bridge t, starting the
+// thread on the agent's first message. Like sendFormatted, Formatter
+// bridges get native markup with a plain-text retry.
+func (s *Service) sendThreaded(ctx context.Context, bridgeName string, t bridge.Threader, agent, text string) error {
+	key := threadKey{bridgeName, agent}
+	s.mu.Lock()
+	thread := s.agentThreads[key]
+	s.mu.Unlock()
+
+	var id string
+	var err error
+	if f, ok := t.(bridge.Formatter); ok {
+		id, err = t.SendThreaded(ctx, thread, f.FormatOutbound(text), true)
+		if err != nil {
+			log.Printf("bridge: formatted send failed, retrying as plain text: %v", err)
+		}
+	}
+	if id == "" {
+		id, err = t.SendThreaded(ctx, thread, text, false)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.agentThreads == nil {
+		s.agentThreads = make(map[threadKey]string)
+		s.threadAgents = make(map[threadKey]string)
+	}
+	s.agentThreads[key] = id
+	s.threadAgents[threadKey{bridgeName, id}] = agent
+	return nil
+}
+
+// recordOutbound updates outbound tracking for a message from an agent and
+// returns body tagged for reply routing. Messages from non-concierge agents
+// are tagged with [agent-name].
+func (s *Service) recordOutbound(from, body string) string {
+	s.metrics.messageSent()
 	s.mu.Lock()
 	s.lastSender = from
-	s.messagesSent++
 	s.lastActivityTime = time.Now()
+	s.mu.Unlock()
+	return s.tagOutbound(from, body)
+}
+
+// tagOutbound tags body with [from] unless from is the concierge.
+func (s *Service) tagOutbound(from, body string) string {
+	s.mu.Lock()
 	concierge := s.concierge
 	s.mu.Unlock()
 
-	// Tag messages from non-concierge agents so reply routing works.
-	tagged := body
 	if from != "" && from != concierge {
-		tagged = bridge.FormatAgentTag(from, body)
+		return bridge.FormatAgentTag(from, body)
 	}
+	return body
+}
+
+// sendOutboundFile uploads a local file from an agent to all Attachment
+// bridges, with caption tagged the same way as sendOutbound. Sender bridges
+// that can't upload files get the caption and file path as text instead.
+// Like a message, the file answers pending asks, with the caption and path.
+// Returns an error if any bridge fails to deliver.
+func (s *Service) sendOutboundFile(from, path, caption string) error {
+	if path == "" {
+		return fmt.Errorf("file path is required")
+	}
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("file path must be absolute: %s", path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("stat file: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("not a regular file: %s", path)
+	}
+	if s.dropMuted(from, bridge.UrgencyNormal) {
+		s.answerAskWaiters(from, bridge.FormatFileFallback(path, caption))
+		return nil
+	}
+
+	tagged := s.recordOutbound(from, caption)
+	s.answerAskWaiters(from, bridge.FormatFileFallback(path, caption))
 
 	ctx := context.Background()
 	var errs []string
 	for _, b := range s.bridges {
-		if sender, ok := b.(bridge.Sender); ok {
-			if err := sender.Send(ctx, tagged); err != nil {
-				log.Printf("bridge: send via %s: %v", b.Name(), err)
-				errs = append(errs, fmt.Sprintf("%s: %v", b.Name(), err))
-			}
+		var err error
+		if a, ok := b.(bridge.Attachment); ok {
+			err = a.SendFile(ctx, path, tagged)
+		} else if sender, ok := b.(bridge.Sender); ok {
+			err = sender.Send(ctx, bridge.FormatFileFallback(path, tagged))
+		} else {
+			continue
+		}
+		if err != nil {
+			log.Printf("bridge: send file via %s: %v", b.Name(), err)
+			errs = append(errs, fmt.Sprintf("%s: %v", b.Name(), err))
+		} else {
+			s.metrics.channelDelivered(b.Name())
 		}
 	}
 	if len(errs) > 0 {
-		return fmt.Errorf("send failed: %s", strings.Join(errs, "; "))
+		return fmt.Errorf("send file failed: %s", strings.Join(errs, "; "))
 	}
 	return nil
 }
@@ -334,7 +920,7 @@ func (s *Service) sendOutbound(from, body string) error {
 // sendToAgent connects to an agent's socket and sends a message.
 // When s.expectsResponse is true, it also registers an idle reminder trigger
 // on the recipient so the agent gets nudged if it doesn't respond.
-func (s *Service) sendToAgent(name, from, body string) error {
+func (s *Service) sendToAgent(name, from, body string, attachments ...message.Attachment) error {
 	sockPath := filepath.Join(s.socketDir, socketdir.Format(socketdir.TypeAgent, name))
 
 	var triggerID string
@@ -355,10 +941,11 @@ func (s *Service) sendToAgent(name, from, body string) error {
 	defer conn.Close()
 
 	req := &message.Request{
-		Type:     "send",
-		Priority: "normal",
-		From:     from,
-		Body:     body,
+		Type:        "send",
+		Priority:    "normal",
+		From:        from,
+		Body:        body,
+		Attachments: attachments,
 	}
 	if triggerID != "" {
 		req.ExpectsResponse = true
@@ -377,7 +964,7 @@ func (s *Service) sendToAgent(name, from, body string) error {
 	}
 	if !resp.OK {
 		s.removeTriggerBestEffort(sockPath, triggerID)
-		return fmt.Errorf("agent error: %s", resp.Error)
+		return fmt.Errorf("agent error: %w", resp.Err())
 	}
 	return nil
 }
@@ -416,8 +1003,9 @@ func (s *Service) registerExpectsResponseTrigger(sockPath, agentName, sender, tr
 		return fmt.Errorf("read trigger_add response: %w", err)
 	}
 	if !resp.OK {
-		// On ID collision, retry once with a new ID.
-		if strings.Contains(resp.Error, "already exists") {
+		// On ID collision, retry once with a new ID. Daemons started before
+		// error codes existed only say so in the message.
+		if resp.Code == message.ErrCodeAlreadyExists || strings.Contains(resp.Error, "already exists") {
 			return s.registerExpectsResponseTrigger(sockPath, agentName, sender, genShortID())
 		}
 		return fmt.Errorf("trigger_add: %s", resp.Error)
@@ -454,6 +1042,10 @@ func genShortID() string {
 // refreshes. Telegram's typing indicator lasts ~5s, so 4s keeps it alive.
 const defaultTypingTickInterval = 4 * time.Second
 
+// defaultTypingBackoff is how long a bridge's typing indicator is paused
+// after the provider rejects one (e.g. rate limiting).
+const defaultTypingBackoff = 30 * time.Second
+
 // runTypingLoop periodically checks agent state and sends typing indicators
 // to all TypingIndicator bridges while the target agent is active. It also
 // monitors concierge liveness and handles auto-reassociation when a concierge
@@ -463,9 +1055,17 @@ func (s *Service) runTypingLoop(ctx context.Context) {
 	if interval == 0 {
 		interval = defaultTypingTickInterval
 	}
+	backoff := s.typingBackoff
+	if backoff == 0 {
+		backoff = defaultTypingBackoff
+	}
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	// Per-bridge time before which typing indicators are skipped because
+	// the provider rejected the last one.
+	typingPausedUntil := map[string]time.Time{}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -522,11 +1122,16 @@ func (s *Service) runTypingLoop(ctx context.Context) {
 			if err != nil || state != "active" {
 				continue
 			}
+			now := time.Now()
 			for _, b := range s.bridges {
-				if ti, ok := b.(bridge.TypingIndicator); ok {
-					if err := ti.SendTyping(ctx); err != nil {
-						log.Printf("bridge: typing indicator via %s: %v", b.Name(), err)
-					}
+				ti, ok := b.(bridge.TypingIndicator)
+				if !ok || now.Before(typingPausedUntil[b.Name()]) {
+					continue
+				}
+				s.metrics.typingCall()
+				if err := ti.SendTyping(ctx); err != nil {
+					log.Printf("bridge: typing indicator via %s: %v; pausing for %s", b.Name(), err, backoff)
+					typingPausedUntil[b.Name()] = now.Add(backoff)
 				}
 			}
 		}
@@ -535,33 +1140,43 @@ func (s *Service) runTypingLoop(ctx context.Context) {
 
 // queryAgentState connects to an agent's socket and returns its state string.
 func (s *Service) queryAgentState(name string) (string, error) {
+	info, err := s.queryAgentInfo(name)
+	if err != nil {
+		return "", err
+	}
+	return info.State, nil
+}
+
+// queryAgentInfo connects to an agent's socket and returns its status.
+func (s *Service) queryAgentInfo(name string) (*message.AgentInfo, error) {
 	sockPath := filepath.Join(s.socketDir, socketdir.Format(socketdir.TypeAgent, name))
 	conn, err := net.DialTimeout("unix", sockPath, 2*time.Second)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer conn.Close()
 
 	if err := message.SendRequest(conn, &message.Request{Type: "status"}); err != nil {
-		return "", err
+		return nil, err
 	}
 
 	resp, err := message.ReadResponse(conn)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	if !resp.OK || resp.Agent == nil {
-		return "", fmt.Errorf("bad status response")
+		return nil, fmt.Errorf("bad status response")
 	}
-	return resp.Agent.State, nil
+	return resp.Agent, nil
 }
 
 // buildBridgeInfo constructs a BridgeInfo snapshot for status responses.
 func (s *Service) buildBridgeInfo() *message.BridgeInfo {
+	counts := s.metrics.snapshot()
 	s.mu.Lock()
-	sent := s.messagesSent
-	received := s.messagesReceived
 	lastActivity := s.lastActivityTime
+	concierge := s.concierge
+	muted := s.mutedAgentsLocked()
 	s.mu.Unlock()
 
 	var channels []string
@@ -579,15 +1194,21 @@ func (s *Service) buildBridgeInfo() *message.BridgeInfo {
 	return &message.BridgeInfo{
 		Name:             s.name,
 		Pod:              s.pod,
+		Concierge:        concierge,
 		Channels:         channels,
 		Uptime:           uptime,
-		MessagesSent:     sent,
-		MessagesReceived: received,
+		MessagesSent:     counts.MessagesSent,
+		MessagesReceived: counts.MessagesReceived,
+		MessagesMuted:    counts.MessagesMuted,
+		Muted:            muted,
 		LastActivity:     lastActivityStr,
 	}
 }
 
-// resolveDefaultTarget returns the agent to route un-addressed inbound messages to.
+// resolveDefaultTarget returns the agent to route un-addressed inbound
+// messages to: the live concierge, else the last agent to send over the
+// bridge, else the first running agent under the first-agent fallback.
+// Returns "" when the fallback policy has no single target.
 func (s *Service) resolveDefaultTarget() string {
 	s.mu.Lock()
 	concierge := s.concierge
@@ -602,12 +1223,10 @@ func (s *Service) resolveDefaultTarget() string {
 		return last
 	}
 
-	// Fall back to first agent socket.
-	agents, _ := socketdir.ListByTypeIn(s.socketDir, socketdir.TypeAgent)
-	if len(agents) > 0 {
-		return agents[0].Name
+	if s.fallback() != FallbackFirstAgent {
+		return ""
 	}
-	return ""
+	return s.firstAvailableAgent()
 }
 
 // firstAvailableAgent returns the name of the first agent socket in the
@@ -624,9 +1243,9 @@ func (s *Service) firstAvailableAgent() string {
 // detected as stopped. The concierge name is NOT cleared — it is remembered
 // so that auto-reassociation works when the agent restarts.
 func (s *Service) handleConciergeDown(ctx context.Context, agentName string) {
-	firstAgent := s.firstAvailableAgent()
+	s.metrics.conciergeDown()
 	msg := fmt.Sprintf("Concierge agent %s stopped. %s",
-		agentName, noConciergeRouting(firstAgent))
+		agentName, s.fallbackRouting())
 	s.sendBridgeStatus(ctx, msg)
 }
 
@@ -664,7 +1283,7 @@ func (s *Service) sendStartupMessage(ctx context.Context) {
 			s.sendBridgeStatus(ctx, msg)
 			return
 		}
-		routing = noConciergeRouting(firstAgent)
+		routing = s.fallbackRouting()
 	}
 
 	msg := fmt.Sprintf("Bridge is up and running. %s %s %s",
@@ -3,15 +3,18 @@ package bridgeservice
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"h2/internal/bridge"
+	"h2/internal/config"
 	"h2/internal/session/message"
 	"h2/internal/socketdir"
 )
@@ -43,6 +46,7 @@ func (m *mockSender) Messages() []string {
 type mockTypingBridge struct {
 	name        string
 	typingCalls int
+	typingErr   error // returned from SendTyping if set
 	mu          sync.Mutex
 }
 
@@ -55,7 +59,7 @@ func (m *mockTypingBridge) SendTyping(_ context.Context) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.typingCalls++
-	return nil
+	return m.typingErr
 }
 func (m *mockTypingBridge) TypingCalls() int {
 	m.mu.Lock()
@@ -65,11 +69,15 @@ func (m *mockTypingBridge) TypingCalls() int {
 
 // mockReceiver exposes its handler so tests can simulate inbound messages.
 type mockReceiver struct {
-	name    string
-	handler bridge.InboundHandler
-	mu      sync.Mutex
-	started bool
-	stopped bool
+	name      string
+	handler   bridge.InboundHandler
+	mu        sync.Mutex
+	started   bool
+	stopped   bool
+	failStart int  // number of Start calls to fail before succeeding
+	unhealthy bool // reported by Healthy until the next successful Start
+	exited    bool // loop stopped; reported by Running until the next successful Start
+	starts    int  // successful Start calls
 }
 
 func (m *mockReceiver) Name() string { return m.name }
@@ -77,8 +85,15 @@ func (m *mockReceiver) Close() error { return nil }
 func (m *mockReceiver) Start(_ context.Context, h bridge.InboundHandler) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if m.failStart > 0 {
+		m.failStart--
+		return errors.New("connection refused")
+	}
 	m.handler = h
 	m.started = true
+	m.unhealthy = false
+	m.exited = false
+	m.starts++
 	return nil
 }
 func (m *mockReceiver) Stop() {
@@ -86,6 +101,21 @@ func (m *mockReceiver) Stop() {
 	defer m.mu.Unlock()
 	m.stopped = true
 }
+func (m *mockReceiver) Healthy() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return !m.unhealthy
+}
+func (m *mockReceiver) Running() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.started && !m.exited
+}
+func (m *mockReceiver) Starts() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.starts
+}
 func (m *mockReceiver) Started() bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -108,10 +138,19 @@ func (m *mockReceiver) Handler() bridge.InboundHandler {
 type mockAgent struct {
 	listener net.Listener
 	received []message.Request
+	resp     *message.Response // reply to send requests; nil means OK
 	mu       sync.Mutex
 	wg       sync.WaitGroup
 }
 
+// RespondWith makes the agent answer every request with resp, e.g. an
+// error with a code.
+func (a *mockAgent) RespondWith(resp *message.Response) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.resp = resp
+}
+
 func newMockAgent(t *testing.T, socketDir, name string) *mockAgent {
 	t.Helper()
 	sockPath := filepath.Join(socketDir, socketdir.Format(socketdir.TypeAgent, name))
@@ -138,8 +177,12 @@ func newMockAgent(t *testing.T, socketDir, name string) *mockAgent {
 				}
 				a.mu.Lock()
 				a.received = append(a.received, *req)
+				resp := a.resp
 				a.mu.Unlock()
-				message.SendResponse(conn, &message.Response{OK: true, MessageID: "test-id"})
+				if resp == nil {
+					resp = &message.Response{OK: true, MessageID: "test-id"}
+				}
+				message.SendResponse(conn, resp)
 			}()
 		}
 	}()
@@ -325,6 +368,238 @@ func TestHandleInbound_UnaddressedNoConciergeFirstAgent(t *testing.T) {
 	}
 }
 
+func TestHandleInbound_FallbackFirstAgentExplicit(t *testing.T) {
+	tmpDir := shortTempDir(t)
+	alpha := newMockAgent(t, tmpDir, "alpha")
+	beta := newMockAgent(t, tmpDir, "beta")
+	svc := New(nil, "alice", "", "", tmpDir, nil, ServiceOpts{NoConciergeFallback: FallbackFirstAgent})
+
+	svc.handleInbound("", "fallback message")
+
+	if got := len(alpha.Received()); got != 1 {
+		t.Errorf("expected 1 request to alpha, got %d", got)
+	}
+	if got := len(beta.Received()); got != 0 {
+		t.Errorf("expected no requests to beta, got %d", got)
+	}
+}
+
+func TestHandleInbound_FallbackReject(t *testing.T) {
+	tmpDir := shortTempDir(t)
+	alpha := newMockAgent(t, tmpDir, "alpha")
+	beta := newMockAgent(t, tmpDir, "beta")
+	sender := &mockSender{name: "telegram"}
+	svc := New([]bridge.Bridge{sender}, "alice", "", "", tmpDir, nil, ServiceOpts{NoConciergeFallback: FallbackReject})
+
+	svc.handleInbound("", "who gets this?")
+
+	if got := len(alpha.Received()) + len(beta.Received()); got != 0 {
+		t.Errorf("expected no agent to receive the message, got %d requests", got)
+	}
+	msgs := sender.Messages()
+	if len(msgs) != 1 {
+		t.Fatalf("expected 1 reply, got %d", len(msgs))
+	}
+	for _, want := range []string{"No concierge is set", "address an agent explicitly", "alpha, beta"} {
+		if !strings.Contains(msgs[0], want) {
+			t.Errorf("reply %q missing %q", msgs[0], want)
+		}
+	}
+}
+
+func TestHandleInbound_FallbackRejectStillUsesLastSender(t *testing.T) {
+	tmpDir := shortTempDir(t)
+	_ = newMockAgent(t, tmpDir, "alpha")
+	beta := newMockAgent(t, tmpDir, "beta")
+	svc := New(nil, "alice", "", "", tmpDir, nil, ServiceOpts{NoConciergeFallback: FallbackReject})
+	svc.lastSender = "beta"
+
+	svc.handleInbound("", "reply to last sender")
+
+	if got := len(beta.Received()); got != 1 {
+		t.Errorf("expected 1 request to beta, got %d", got)
+	}
+}
+
+func TestHandleInbound_FallbackBroadcast(t *testing.T) {
+	tmpDir := shortTempDir(t)
+	alpha := newMockAgent(t, tmpDir, "alpha")
+	beta := newMockAgent(t, tmpDir, "beta")
+	sender := &mockSender{name: "telegram"}
+	svc := New([]bridge.Bridge{sender}, "alice", "", "", tmpDir, nil, ServiceOpts{NoConciergeFallback: FallbackBroadcast})
+
+	svc.handleInbound("", "everyone")
+
+	for name, agent := range map[string]*mockAgent{"alpha": alpha, "beta": beta} {
+		reqs := agent.Received()
+		if len(reqs) != 1 || reqs[0].Body != "everyone" {
+			t.Errorf("%s: expected 1 request with body 'everyone', got %+v", name, reqs)
+		}
+	}
+	if msgs := sender.Messages(); len(msgs) != 0 {
+		t.Errorf("expected no replies, got %v", msgs)
+	}
+}
+
+func TestHandleInbound_FallbackBroadcastSharesFileAndSkipsAsks(t *testing.T) {
+	t.Setenv("H2_DIR", t.TempDir())
+	config.ResetResolveCache()
+	t.Cleanup(config.ResetResolveCache)
+
+	tmpDir := shortTempDir(t)
+	alpha := newMockAgent(t, tmpDir, "alpha")
+	beta := newMockAgent(t, tmpDir, "beta")
+	svc := New([]bridge.Bridge{&mockSender{name: "telegram"}}, "alice", "", "", tmpDir, nil,
+		ServiceOpts{NoConciergeFallback: FallbackBroadcast, AskTimeout: time.Minute})
+
+	saves := 0
+	svc.deliverInbound("telegram", "", "look", &bridge.InboundFile{
+		Kind: "photo",
+		Name: "p.jpg",
+		Save: func(context.Context, string) error { saves++; return nil },
+	})
+
+	if saves != 1 {
+		t.Errorf("file saved %d times, want once", saves)
+	}
+	var paths []string
+	for name, agent := range map[string]*mockAgent{"alpha": alpha, "beta": beta} {
+		reqs := agent.Received()
+		if len(reqs) != 1 || len(reqs[0].Attachments) != 1 {
+			t.Fatalf("%s: expected 1 request with the file, got %+v", name, reqs)
+		}
+		paths = append(paths, reqs[0].Attachments[0].Path)
+	}
+	if paths[0] != paths[1] {
+		t.Errorf("agents got different files: %v", paths)
+	}
+	svc.mu.Lock()
+	pending := len(svc.askWaiters)
+	svc.mu.Unlock()
+	if pending != 0 {
+		t.Errorf("expected no asks for a broadcast, %d agents pending", pending)
+	}
+}
+
+func TestHandleInbound_FallbackNoAgents(t *testing.T) {
+	for _, fallback := range []string{FallbackReject, FallbackBroadcast} {
+		sender := &mockSender{name: "telegram"}
+		svc := New([]bridge.Bridge{sender}, "alice", "", "", shortTempDir(t), nil, ServiceOpts{NoConciergeFallback: fallback})
+
+		svc.handleInbound("", "anyone there?")
+
+		msgs := sender.Messages()
+		if len(msgs) != 1 || msgs[0] != "No agents are running, unable to deliver message." {
+			t.Errorf("%s: unexpected replies %q", fallback, msgs)
+		}
+	}
+}
+
+func TestFileInboundHandler_SavesFileAndSendsAttachment(t *testing.T) {
+	t.Setenv("H2_DIR", t.TempDir())
+	config.ResetResolveCache()
+	t.Cleanup(config.ResetResolveCache)
+
+	tmpDir := shortTempDir(t)
+	agent := newMockAgent(t, tmpDir, "coder")
+	svc := New(nil, "alice", "", "", tmpDir, nil, ServiceOpts{Threads: true})
+	svc.threadAgents = map[threadKey]string{{"telegram", "101"}: "coder"}
+
+	handler := svc.fileInboundHandler("telegram")
+	handler("101", "", "what's this?", bridge.InboundFile{
+		Kind: "photo",
+		Name: "../photo-7.jpg",
+		Save: func(_ context.Context, path string) error {
+			if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+				return err
+			}
+			return os.WriteFile(path, []byte("jpeg"), 0o600)
+		},
+	})
+
+	waitFor(t, "file delivery", func() bool { return len(agent.Received()) > 0 })
+	reqs := agent.Received()
+	if len(reqs) != 1 {
+		t.Fatalf("expected 1 request to coder, got %d", len(reqs))
+	}
+	if reqs[0].Body != "what's this?" || len(reqs[0].Attachments) != 1 {
+		t.Fatalf("request = %+v, want the caption with one attachment", reqs[0])
+	}
+	a := reqs[0].Attachments[0]
+	if a.Kind != message.AttachmentFile || a.Description != "photo the human sent you" {
+		t.Errorf("attachment = %+v", a)
+	}
+	inbox := filepath.Join(config.SessionDir("coder"), "inbox")
+	if filepath.Dir(a.Path) != inbox || !strings.HasSuffix(a.Path, "-photo-7.jpg") {
+		t.Errorf("attachment path = %q, want a file in %s", a.Path, inbox)
+	}
+	if data, err := os.ReadFile(a.Path); err != nil || string(data) != "jpeg" {
+		t.Errorf("saved file = %q, %v", data, err)
+	}
+}
+
+func TestFileInboundHandler_SaveFailureRepliesWithError(t *testing.T) {
+	t.Setenv("H2_DIR", t.TempDir())
+	config.ResetResolveCache()
+	t.Cleanup(config.ResetResolveCache)
+
+	tmpDir := shortTempDir(t)
+	agent := newMockAgent(t, tmpDir, "coder")
+	sender := &mockSender{name: "telegram"}
+	svc := New([]bridge.Bridge{sender}, "alice", "", "", tmpDir, nil)
+
+	svc.fileInboundHandler("telegram")("", "coder", "", bridge.InboundFile{
+		Kind: "document",
+		Name: "notes.txt",
+		Save: func(context.Context, string) error { return fmt.Errorf("download failed") },
+	})
+	waitFor(t, "error reply", func() bool { return len(sender.Messages()) > 0 })
+
+	if n := len(agent.Received()); n != 0 {
+		t.Errorf("agent got %d requests, want none", n)
+	}
+	if msgs := sender.Messages(); len(msgs) != 1 || msgs[0] != "Couldn't pass the document on to coder." {
+		t.Errorf("replies = %q", msgs)
+	}
+}
+
+func TestFileInboundHandler_TooLargeAndNotRunning(t *testing.T) {
+	t.Setenv("H2_DIR", t.TempDir())
+	config.ResetResolveCache()
+	t.Cleanup(config.ResetResolveCache)
+
+	tmpDir := shortTempDir(t)
+	newMockAgent(t, tmpDir, "coder")
+	sender := &mockSender{name: "telegram"}
+	svc := New([]bridge.Bridge{sender}, "alice", "", "", tmpDir, nil)
+
+	svc.fileInboundHandler("telegram")("", "coder", "", bridge.InboundFile{
+		Kind: "video",
+		Name: "clip.mp4",
+		Save: func(context.Context, string) error {
+			return fmt.Errorf("telegram download: %w: exceeds 20.0 MB", bridge.ErrFileTooLarge)
+		},
+	})
+	waitFor(t, "too large reply", func() bool { return len(sender.Messages()) == 1 })
+	if msgs := sender.Messages(); msgs[0] != "Sorry, that video is too large to pass on." {
+		t.Errorf("reply = %q, want a too large reply", msgs[0])
+	}
+
+	saved := false
+	svc.fileInboundHandler("telegram")("", "ghost", "", bridge.InboundFile{
+		Kind: "photo",
+		Name: "p.jpg",
+		Save: func(context.Context, string) error { saved = true; return nil },
+	})
+	waitFor(t, "not running reply", func() bool { return len(sender.Messages()) == 2 })
+	if msgs := sender.Messages(); msgs[1] != "ghost agent is not running, unable to deliver message." {
+		t.Errorf("reply = %q, want a not running reply", msgs[1])
+	}
+	if saved {
+		t.Error("file for an agent that isn't running should not be downloaded")
+	}
+}
+
 // --- Error reply tests ---
 
 func TestHandleInbound_DeadAgentRepliesWithError(t *testing.T) {
@@ -362,6 +637,36 @@ func TestHandleInbound_ExplicitDeadAgentRepliesWithError(t *testing.T) {
 	}
 }
 
+func TestHandleInbound_AgentErrorCodeReplies(t *testing.T) {
+	tests := []struct {
+		name string
+		resp *message.Response
+		want string
+	}{
+		{"too large", message.ErrorResponse(message.ErrCodeTooLarge, "message is too large"), "Message is too large for coder, not delivered."},
+		{"other code", message.ErrorResponse(message.ErrCodeInvalidRequest, "attachment 1: bad path"), "coder agent couldn't take the message: attachment 1: bad path"},
+		{"no code", &message.Response{Error: "write failed"}, "coder agent couldn't take the message: write failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := shortTempDir(t)
+			agent := newMockAgent(t, tmpDir, "coder")
+			agent.RespondWith(tt.resp)
+			sender := &mockSender{name: "telegram"}
+			svc := New([]bridge.Bridge{sender}, "alice", "", "", tmpDir, nil)
+
+			svc.handleInbound("coder", "hello")
+
+			if n := len(agent.Received()); n != 1 {
+				t.Fatalf("agent got %d requests, want 1", n)
+			}
+			if msgs := sender.Messages(); len(msgs) != 1 || msgs[0] != tt.want {
+				t.Errorf("replies = %q, want [%q]", msgs, tt.want)
+			}
+		})
+	}
+}
+
 func TestHandleInbound_NoAgentsRepliesWithError(t *testing.T) {
 	tmpDir := shortTempDir(t)
 	sender := &mockSender{name: "telegram"}
@@ -390,7 +695,7 @@ func TestHandleOutbound(t *testing.T) {
 		"alice", "", "", t.TempDir(), nil,
 	)
 
-	svc.sendOutbound("myagent", "build complete")
+	svc.sendOutbound("myagent", "build complete", bridge.UrgencyNormal)
 
 	// Both senders should have received the tagged message (non-concierge agent).
 	want := "[myagent] build complete"
@@ -416,7 +721,7 @@ func TestHandleOutbound_TagsNonConcierge(t *testing.T) {
 	sender := &mockSender{name: "telegram"}
 	svc := New([]bridge.Bridge{sender}, "alice", "concierge", "", t.TempDir(), nil)
 
-	svc.sendOutbound("researcher", "here are the results")
+	svc.sendOutbound("researcher", "here are the results", bridge.UrgencyNormal)
 
 	msgs := sender.Messages()
 	if len(msgs) != 1 {
@@ -432,7 +737,7 @@ func TestHandleOutbound_NoConciergeTag(t *testing.T) {
 	sender := &mockSender{name: "telegram"}
 	svc := New([]bridge.Bridge{sender}, "alice", "concierge", "", t.TempDir(), nil)
 
-	svc.sendOutbound("concierge", "build complete")
+	svc.sendOutbound("concierge", "build complete", bridge.UrgencyNormal)
 
 	msgs := sender.Messages()
 	if len(msgs) != 1 {
@@ -444,6 +749,177 @@ func TestHandleOutbound_NoConciergeTag(t *testing.T) {
 	}
 }
 
+// mockAttachmentBridge implements Bridge, Sender, and Attachment.
+type mockAttachmentBridge struct {
+	mockSender
+	files []string // "path|caption"
+}
+
+func (m *mockAttachmentBridge) SendFile(_ context.Context, path, caption string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files = append(m.files, path+"|"+caption)
+	return nil
+}
+
+func TestSendReplay_PrefixedAndNotCounted(t *testing.T) {
+	sender := &mockSender{name: "telegram"}
+	svc := New([]bridge.Bridge{sender}, "alice", "concierge", "", t.TempDir(), nil)
+	svc.lastSender = "concierge"
+
+	if err := svc.sendReplay("myagent", "build complete", bridge.UrgencyNormal); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.sendReplay("concierge", "all done", bridge.UrgencyNormal); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := sender.Messages()
+	want := []string{"[myagent] (replay) build complete", "(replay) all done"}
+	if len(msgs) != len(want) || msgs[0] != want[0] || msgs[1] != want[1] {
+		t.Errorf("messages = %q, want %q", msgs, want)
+	}
+	if info := svc.buildBridgeInfo(); info.MessagesSent != 0 {
+		t.Errorf("MessagesSent = %d, want replays left uncounted", info.MessagesSent)
+	}
+	svc.mu.Lock()
+	last := svc.lastSender
+	svc.mu.Unlock()
+	if last != "concierge" {
+		t.Errorf("lastSender = %q, want it unchanged by replays", last)
+	}
+}
+
+func TestHandleOutboundFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.md")
+	os.WriteFile(path, []byte("# report"), 0o644)
+
+	uploader := &mockAttachmentBridge{mockSender: mockSender{name: "telegram"}}
+	textOnly := &mockSender{name: "macos"}
+	svc := New([]bridge.Bridge{uploader, textOnly}, "alice", "concierge", "", t.TempDir(), nil)
+
+	if err := svc.sendOutboundFile("researcher", path, "nightly report"); err != nil {
+		t.Fatalf("sendOutboundFile: %v", err)
+	}
+
+	// Attachment bridges upload the file with the tagged caption.
+	if len(uploader.files) != 1 || uploader.files[0] != path+"|[researcher] nightly report" {
+		t.Errorf("uploader files = %v", uploader.files)
+	}
+	if len(uploader.Messages()) != 0 {
+		t.Errorf("uploader should not get a text fallback, got %v", uploader.Messages())
+	}
+
+	// Text-only bridges get the caption plus the path.
+	want := "[researcher] nightly report\n[file: " + path + "]"
+	if msgs := textOnly.Messages(); len(msgs) != 1 || msgs[0] != want {
+		t.Errorf("text-only messages = %q, want [%q]", msgs, want)
+	}
+}
+
+func TestHandleOutboundFile_RejectsBadPaths(t *testing.T) {
+	sender := &mockSender{name: "telegram"}
+	svc := New([]bridge.Bridge{sender}, "alice", "", "", t.TempDir(), nil)
+
+	for _, path := range []string{"", "relative.txt", filepath.Join(t.TempDir(), "missing"), t.TempDir()} {
+		if err := svc.sendOutboundFile("agent", path, ""); err == nil {
+			t.Errorf("expected error for path %q", path)
+		}
+	}
+	if len(sender.Messages()) != 0 {
+		t.Errorf("nothing should be sent for bad paths, got %v", sender.Messages())
+	}
+}
+
+// mockFormatterBridge implements Bridge, Sender, and Formatter. Formatted
+// sends are recorded with a "fmt:" prefix.
+type mockFormatterBridge struct {
+	mockSender
+	failFormatted bool
+}
+
+func (m *mockFormatterBridge) FormatOutbound(text string) string {
+	return strings.ReplaceAll(text, "**", "*")
+}
+
+func (m *mockFormatterBridge) SendFormatted(_ context.Context, text string) error {
+	if m.failFormatted {
+		return errors.New("can't parse entities")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, "fmt:"+text)
+	return nil
+}
+
+func TestSendOutbound_UsesFormatter(t *testing.T) {
+	formatter := &mockFormatterBridge{mockSender: mockSender{name: "telegram"}}
+	plain := &mockSender{name: "macos"}
+	svc := New([]bridge.Bridge{formatter, plain}, "alice", "concierge", "", t.TempDir(), nil)
+
+	if err := svc.sendOutbound("coder", "**done**", bridge.UrgencyNormal); err != nil {
+		t.Fatalf("sendOutbound: %v", err)
+	}
+	if msgs := formatter.Messages(); len(msgs) != 1 || msgs[0] != "fmt:[coder] *done*" {
+		t.Errorf("formatter messages = %q", msgs)
+	}
+	if msgs := plain.Messages(); len(msgs) != 1 || msgs[0] != "[coder] **done**" {
+		t.Errorf("plain messages = %q", msgs)
+	}
+}
+
+func TestSendOutbound_FormatterFailureFallsBackToPlain(t *testing.T) {
+	formatter := &mockFormatterBridge{mockSender: mockSender{name: "telegram"}, failFormatted: true}
+	svc := New([]bridge.Bridge{formatter}, "alice", "concierge", "", t.TempDir(), nil)
+
+	if err := svc.sendOutbound("coder", "**done**", bridge.UrgencyNormal); err != nil {
+		t.Fatalf("sendOutbound: %v", err)
+	}
+	if msgs := formatter.Messages(); len(msgs) != 1 || msgs[0] != "[coder] **done**" {
+		t.Errorf("messages = %q, want plain fallback", msgs)
+	}
+}
+
+// mockUrgencyBridge implements Bridge, Sender, and UrgencySender. Sends at
+// a non-default urgency are recorded with an "<urgency>:" prefix.
+type mockUrgencyBridge struct {
+	mockSender
+}
+
+func (m *mockUrgencyBridge) WithUrgency(urgency bridge.Urgency) bridge.Sender {
+	return &urgencyView{m: m, urgency: urgency}
+}
+
+type urgencyView struct {
+	m       *mockUrgencyBridge
+	urgency bridge.Urgency
+}
+
+func (v *urgencyView) Name() string { return v.m.name }
+func (v *urgencyView) Close() error { return nil }
+func (v *urgencyView) Send(ctx context.Context, text string) error {
+	return v.m.Send(ctx, string(v.urgency)+":"+text)
+}
+
+func TestSendOutbound_Urgency(t *testing.T) {
+	urgent := &mockUrgencyBridge{mockSender: mockSender{name: "telegram"}}
+	plain := &mockSender{name: "macos"}
+	svc := New([]bridge.Bridge{urgent, plain}, "alice", "concierge", "", t.TempDir(), nil)
+
+	for _, u := range []bridge.Urgency{bridge.UrgencyLow, bridge.UrgencyNormal, bridge.UrgencyHigh} {
+		if err := svc.sendOutbound("coder", "done", u); err != nil {
+			t.Fatalf("sendOutbound(%s): %v", u, err)
+		}
+	}
+	want := []string{"low:[coder] done", "[coder] done", "high:[coder] done"}
+	if msgs := urgent.Messages(); !slices.Equal(msgs, want) {
+		t.Errorf("urgency bridge messages = %q, want %q", msgs, want)
+	}
+	if msgs := plain.Messages(); len(msgs) != 3 || msgs[0] != "[coder] done" || msgs[2] != "[coder] done" {
+		t.Errorf("plain messages = %q, want urgency ignored", msgs)
+	}
+}
+
 // --- Socket listener test ---
 
 func TestSocketListener(t *testing.T) {
@@ -508,6 +984,51 @@ func TestSocketListener(t *testing.T) {
 	<-errCh
 }
 
+func TestSocketListener_RejectsInvalidUrgency(t *testing.T) {
+	tmpDir := shortTempDir(t)
+	sender := &mockSender{name: "test"}
+	svc := New([]bridge.Bridge{sender}, "alice", "", "", tmpDir, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- svc.Run(ctx) }()
+
+	sockPath := filepath.Join(tmpDir, socketdir.Format(socketdir.TypeBridge, "alice"))
+	waitForSocket(t, sockPath)
+
+	conn, err := net.Dial("unix", sockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if err := message.SendRequest(conn, &message.Request{
+		Type:    "send",
+		From:    "agent1",
+		Body:    "hello human",
+		Urgency: "urgent",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := message.ReadResponse(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.OK || !strings.Contains(resp.Error, "invalid urgency") {
+		t.Errorf("response = %+v, want invalid urgency error", resp)
+	}
+	for _, msg := range sender.Messages() {
+		if strings.Contains(msg, "hello human") {
+			t.Errorf("message delivered despite invalid urgency: %q", msg)
+		}
+	}
+
+	cancel()
+	<-errCh
+}
+
 // --- Stop request test ---
 
 func TestStopRequest_ShutdownService(t *testing.T) {
@@ -623,6 +1144,19 @@ func TestResolveDefaultTarget_FirstAgent(t *testing.T) {
 	}
 }
 
+func TestResolveDefaultTarget_NoSingleFallback(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, socketdir.Format(socketdir.TypeAgent, "alpha")), nil, 0o600)
+	os.WriteFile(filepath.Join(tmpDir, socketdir.Format(socketdir.TypeAgent, "beta")), nil, 0o600)
+
+	for _, fallback := range []string{FallbackReject, FallbackBroadcast} {
+		svc := New(nil, "alice", "", "", tmpDir, nil, ServiceOpts{NoConciergeFallback: fallback})
+		if got := svc.resolveDefaultTarget(); got != "" {
+			t.Errorf("%s: expected empty, got %q", fallback, got)
+		}
+	}
+}
+
 func TestResolveDefaultTarget_NoAgents(t *testing.T) {
 	svc := New(nil, "alice", "", "", t.TempDir(), nil)
 	if got := svc.resolveDefaultTarget(); got != "" {
@@ -652,6 +1186,30 @@ func TestTypingLoop_SendsWhenActive(t *testing.T) {
 	}
 }
 
+func TestTypingLoop_BacksOffFailingBridge(t *testing.T) {
+	tmpDir := shortTempDir(t)
+	_ = newMockStatusAgent(t, tmpDir, "concierge", "active")
+
+	failing := &mockTypingBridge{name: "telegram", typingErr: errors.New("429 Too Many Requests")}
+	healthy := &mockTypingBridge{name: "other"}
+	svc := New([]bridge.Bridge{failing, healthy}, "alice", "concierge", "", tmpDir, nil)
+	svc.typingTickInterval = 50 * time.Millisecond
+	svc.typingBackoff = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go svc.runTypingLoop(ctx)
+
+	time.Sleep(300 * time.Millisecond)
+	cancel()
+
+	if calls := failing.TypingCalls(); calls != 1 {
+		t.Errorf("expected failing bridge to be tried once then paused, got %d calls", calls)
+	}
+	if calls := healthy.TypingCalls(); calls < 3 {
+		t.Errorf("expected healthy bridge to keep getting typing calls, got %d", calls)
+	}
+}
+
 func TestTypingLoop_SkipsWhenIdle(t *testing.T) {
 	tmpDir := shortTempDir(t)
 	_ = newMockStatusAgent(t, tmpDir, "concierge", "idle")
@@ -809,6 +1367,9 @@ func TestStatusRequest(t *testing.T) {
 	if b.Name != "alice" {
 		t.Errorf("expected name=alice, got %q", b.Name)
 	}
+	if b.Concierge != "concierge" {
+		t.Errorf("expected concierge=concierge, got %q", b.Concierge)
+	}
 	if len(b.Channels) != 1 || b.Channels[0] != "telegram" {
 		t.Errorf("expected channels=[telegram], got %v", b.Channels)
 	}
@@ -1739,3 +2300,260 @@ func TestHandleInbound_ExpectsResponse_TriggerFailStillDelivers(t *testing.T) {
 		t.Errorf("send body = %q, want 'hello despite trigger fail'", sendReq.Body)
 	}
 }
+
+// --- Ask tests ---
+
+// waitForReceived polls until agent has received n requests.
+func waitForReceived(t *testing.T, agent *mockAgent, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(agent.Received()) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("agent received %d requests, want %d", len(agent.Received()), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHandleAsk_ReturnsAgentReply(t *testing.T) {
+	tmpDir := shortTempDir(t)
+	agent := newMockAgent(t, tmpDir, "myagent")
+	sender := &mockSender{name: "telegram"}
+	svc := New([]bridge.Bridge{sender}, "alice", "", "", tmpDir, nil)
+
+	done := make(chan *message.Response, 1)
+	go func() {
+		done <- svc.handleAsk(&message.Request{Type: "ask", To: "myagent", Body: "what's 6*7?", Timeout: "5s"})
+	}()
+	waitForReceived(t, agent, 1)
+	if err := svc.sendOutbound("myagent", "42", bridge.UrgencyNormal); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := <-done
+	if !resp.OK || resp.Reply != "42" {
+		t.Fatalf("expected OK reply 42, got %+v", resp)
+	}
+	if got := agent.Received()[0].Body; got != "what's 6*7?" {
+		t.Errorf("agent got body %q", got)
+	}
+	// The reply is still relayed to the channel.
+	if msgs := sender.Messages(); len(msgs) != 1 || msgs[0] != "[myagent] 42" {
+		t.Errorf("channel messages = %v", msgs)
+	}
+}
+
+func TestHandleAsk_AnsweredByFile(t *testing.T) {
+	tmpDir := shortTempDir(t)
+	agent := newMockAgent(t, tmpDir, "myagent")
+	svc := New([]bridge.Bridge{&mockSender{name: "telegram"}}, "alice", "", "", tmpDir, nil)
+	file := filepath.Join(tmpDir, "chart.png")
+	if err := os.WriteFile(file, []byte("png"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan *message.Response, 1)
+	go func() {
+		done <- svc.handleAsk(&message.Request{Type: "ask", To: "myagent", Body: "send the chart", Timeout: "5s"})
+	}()
+	waitForReceived(t, agent, 1)
+	if err := svc.sendOutboundFile("myagent", file, "here it is"); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := <-done
+	if want := "here it is\n[file: " + file + "]"; !resp.OK || resp.Reply != want {
+		t.Fatalf("expected OK reply %q, got %+v", want, resp)
+	}
+}
+
+func TestHandleAsk_Timeout(t *testing.T) {
+	tmpDir := shortTempDir(t)
+	newMockAgent(t, tmpDir, "myagent")
+	svc := New(nil, "alice", "", "", tmpDir, nil)
+
+	resp := svc.handleAsk(&message.Request{Type: "ask", To: "myagent", Body: "hello?", Timeout: "20ms"})
+	if resp.OK || resp.Error != "agent did not respond in time" {
+		t.Fatalf("expected timeout error, got %+v", resp)
+	}
+	svc.mu.Lock()
+	pending := len(svc.askWaiters)
+	svc.mu.Unlock()
+	if pending != 0 {
+		t.Errorf("expected waiter to be removed after timeout, %d agents still pending", pending)
+	}
+}
+
+func TestHandleAsk_InvalidTimeout(t *testing.T) {
+	svc := New(nil, "alice", "", "", t.TempDir(), nil)
+	resp := svc.handleAsk(&message.Request{Type: "ask", To: "myagent", Body: "hi", Timeout: "soon"})
+	if resp.OK || !strings.Contains(resp.Error, "invalid timeout") {
+		t.Fatalf("expected invalid timeout error, got %+v", resp)
+	}
+}
+
+func TestHandleInbound_AskTimeoutRepliesToChannel(t *testing.T) {
+	tmpDir := shortTempDir(t)
+	newMockAgent(t, tmpDir, "myagent")
+	sender := &mockSender{name: "telegram"}
+	svc := New([]bridge.Bridge{sender}, "alice", "", "", tmpDir, nil, ServiceOpts{AskTimeout: 20 * time.Millisecond})
+
+	svc.handleInbound("myagent", "are you there?")
+
+	want := "myagent agent did not respond in time."
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		msgs := sender.Messages()
+		if len(msgs) == 1 && msgs[0] == want {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %q, got %v", want, msgs)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHandleInbound_AskAnsweredSkipsTimeoutNotice(t *testing.T) {
+	tmpDir := shortTempDir(t)
+	newMockAgent(t, tmpDir, "myagent")
+	sender := &mockSender{name: "telegram"}
+	svc := New([]bridge.Bridge{sender}, "alice", "", "", tmpDir, nil, ServiceOpts{AskTimeout: 50 * time.Millisecond})
+
+	svc.handleInbound("myagent", "are you there?")
+	svc.sendOutbound("myagent", "yes", bridge.UrgencyNormal)
+	time.Sleep(100 * time.Millisecond)
+
+	if msgs := sender.Messages(); len(msgs) != 1 || msgs[0] != "[myagent] yes" {
+		t.Errorf("expected only the agent's reply, got %v", msgs)
+	}
+}
+
+// --- Thread tests ---
+
+// mockThreadBridge implements Bridge, Sender, and Threader. Threaded sends
+// are recorded as "<thread>:<text>", and new threads are numbered t1, t2, ...
+type mockThreadBridge struct {
+	mockSender
+	threads int
+}
+
+func (m *mockThreadBridge) SendThreaded(_ context.Context, thread, text string, _ bool) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if thread == "" {
+		m.threads++
+		thread = fmt.Sprintf("t%d", m.threads)
+	}
+	m.messages = append(m.messages, thread+":"+text)
+	return thread, nil
+}
+
+func TestSendOutbound_ThreadsPerAgent(t *testing.T) {
+	threader := &mockThreadBridge{mockSender: mockSender{name: "telegram"}}
+	plain := &mockSender{name: "macos"}
+	svc := New([]bridge.Bridge{threader, plain}, "alice", "concierge", "", t.TempDir(), nil, ServiceOpts{Threads: true})
+
+	for _, m := range []struct{ from, body string }{
+		{"coder", "starting"},
+		{"reviewer", "looks good"},
+		{"coder", "done"},
+		{"concierge", "all set"},
+	} {
+		if err := svc.sendOutbound(m.from, m.body, bridge.UrgencyNormal); err != nil {
+			t.Fatalf("sendOutbound: %v", err)
+		}
+	}
+
+	want := []string{"t1:[coder] starting", "t2:[reviewer] looks good", "t1:[coder] done", "all set"}
+	if msgs := threader.Messages(); strings.Join(msgs, "|") != strings.Join(want, "|") {
+		t.Errorf("threaded messages = %q, want %q", msgs, want)
+	}
+	// Bridges without threads keep flat tagging.
+	if msgs := plain.Messages(); len(msgs) != 4 || msgs[2] != "[coder] done" {
+		t.Errorf("plain messages = %q", msgs)
+	}
+}
+
+func TestSendOutbound_ThreadsDisabled(t *testing.T) {
+	threader := &mockThreadBridge{mockSender: mockSender{name: "telegram"}}
+	svc := New([]bridge.Bridge{threader}, "alice", "concierge", "", t.TempDir(), nil)
+
+	if err := svc.sendOutbound("coder", "starting", bridge.UrgencyNormal); err != nil {
+		t.Fatalf("sendOutbound: %v", err)
+	}
+	if msgs := threader.Messages(); len(msgs) != 1 || msgs[0] != "[coder] starting" {
+		t.Errorf("messages = %q, want flat tagged message", msgs)
+	}
+}
+
+func TestThreadInbound_RoutesToThreadAgent(t *testing.T) {
+	tmpDir := shortTempDir(t)
+	coder := newMockAgent(t, tmpDir, "coder")
+	concierge := newMockAgent(t, tmpDir, "concierge")
+	threader := &mockThreadBridge{mockSender: mockSender{name: "telegram"}}
+	svc := New([]bridge.Bridge{threader}, "alice", "concierge", "", tmpDir, nil, ServiceOpts{Threads: true})
+	svc.conciergeAlive = true
+
+	if err := svc.sendOutbound("coder", "which branch?", bridge.UrgencyNormal); err != nil {
+		t.Fatal(err)
+	}
+	handler := svc.threadInboundHandler("telegram")
+	handler("t1", "", "main")
+	handler("", "", "unrelated")
+
+	if reqs := coder.Received(); len(reqs) != 1 || reqs[0].Body != "main" {
+		t.Errorf("coder received %+v, want the in-thread reply", reqs)
+	}
+	if reqs := concierge.Received(); len(reqs) != 1 || reqs[0].Body != "unrelated" {
+		t.Errorf("concierge received %+v, want the un-threaded message", reqs)
+	}
+}
+
+func TestReplyInbound_RoutesToQuotedAgent(t *testing.T) {
+	tmpDir := shortTempDir(t)
+	researcher := newMockAgent(t, tmpDir, "researcher")
+	concierge := newMockAgent(t, tmpDir, "concierge")
+	sender := &mockSender{name: "telegram"}
+	svc := New([]bridge.Bridge{sender}, "alice", "concierge", "", tmpDir, nil)
+	svc.conciergeAlive = true
+
+	handler := svc.replyInboundHandler("telegram")
+	handler("", "[researcher] here are the results", "what's the status?")
+	// Replies to a stopped agent, a bridge notice or the (untagged)
+	// concierge fall back to the default target.
+	handler("", "[coder] done", "thanks")
+	handler("", "[bridge alice] telegram is back", "ok")
+	handler("", "[bridge] status", "hi")
+	handler("", "sure, on it", "great")
+
+	if reqs := researcher.Received(); len(reqs) != 1 || reqs[0].Body != "what's the status?" {
+		t.Errorf("researcher received %+v, want the reply to its message", reqs)
+	}
+	var bodies []string
+	for _, req := range concierge.Received() {
+		bodies = append(bodies, req.Body)
+	}
+	if got := strings.Join(bodies, ","); got != "thanks,ok,hi,great" {
+		t.Errorf("concierge received %q, want the replies with no running agent quoted", got)
+	}
+}
+
+func TestReplyInbound_FallsBackToThreadAgent(t *testing.T) {
+	tmpDir := shortTempDir(t)
+	coder := newMockAgent(t, tmpDir, "coder")
+	newMockAgent(t, tmpDir, "concierge")
+	threader := &mockThreadBridge{mockSender: mockSender{name: "telegram"}}
+	svc := New([]bridge.Bridge{threader}, "alice", "concierge", "", tmpDir, nil, ServiceOpts{Threads: true})
+	svc.conciergeAlive = true
+
+	if err := svc.sendOutbound("coder", "which branch?", bridge.UrgencyNormal); err != nil {
+		t.Fatal(err)
+	}
+	// The quoted page carries no tag, but the thread still belongs to coder.
+	svc.replyInboundHandler("telegram")("t1", "...and the rest", "main")
+
+	if reqs := coder.Received(); len(reqs) != 1 || reqs[0].Body != "main" {
+		t.Errorf("coder received %+v, want the in-thread reply", reqs)
+	}
+}
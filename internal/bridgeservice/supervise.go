@@ -0,0 +1,102 @@
+package bridgeservice
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"h2/internal/bridge"
+)
+
+// defaultReceiverCheckInterval is how often a receiver's health is probed,
+// and the first retry delay after it fails.
+const defaultReceiverCheckInterval = 5 * time.Second
+
+// maxReceiverBackoff caps the delay between receiver restart attempts.
+const maxReceiverBackoff = time.Minute
+
+// defaultReceiverOutageNotice is how long a receiver must be down before the
+// outage is announced on the bridge.
+const defaultReceiverOutageNotice = time.Minute
+
+// startReceiver starts b's receiver, threaded when threads are enabled and
+// the bridge supports them, passing inbound files and reply context on when
+// it can.
+func (s *Service) startReceiver(ctx context.Context, b bridge.Bridge) error {
+	if fr, ok := b.(bridge.FileReceiver); ok {
+		fr.SetFileHandler(s.fileInboundHandler(b.Name()))
+	}
+	if rr, ok := b.(bridge.ReplyReceiver); ok {
+		rr.SetReplyHandler(s.replyInboundHandler(b.Name()))
+	}
+	if tr, ok := b.(bridge.ThreadReceiver); ok && s.threads {
+		return tr.StartThreaded(ctx, s.threadInboundHandler(b.Name()))
+	}
+	return b.(bridge.Receiver).Start(ctx, s.inboundHandler(b.Name()))
+}
+
+// superviseReceiver keeps b's receiver running until ctx is done. running
+// says whether the initial Start succeeded. A receiver that failed to start
+// or whose loop has exited is stopped and started again, backing off
+// exponentially between attempts. One that is still running but unhealthy
+// is left to retry on its own. Outages (not running or unhealthy) longer
+// than the notice period are announced, along with the recovery.
+func (s *Service) superviseReceiver(ctx context.Context, b bridge.Bridge, running bool) {
+	r := b.(bridge.Receiver)
+	interval := s.receiverCheckInterval
+	if interval == 0 {
+		interval = defaultReceiverCheckInterval
+	}
+	notice := s.receiverOutageNotice
+	if notice == 0 {
+		notice = defaultReceiverOutageNotice
+	}
+
+	wait, backoff := interval, interval
+	var downSince time.Time
+	announced := false
+	for {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if running && r.Running() && r.Healthy() {
+			if announced {
+				s.sendBridgeStatus(ctx, fmt.Sprintf("%s receiver reconnected.", b.Name()))
+			}
+			downSince, announced = time.Time{}, false
+			wait, backoff = interval, interval
+			continue
+		}
+
+		if downSince.IsZero() {
+			downSince = time.Now()
+		}
+		if !announced && time.Since(downSince) >= notice {
+			s.sendBridgeStatus(ctx, fmt.Sprintf("%s receiver is down, reconnecting...", b.Name()))
+			announced = true
+		}
+
+		if running && r.Running() {
+			// Still polling; it reconnects by itself.
+			wait, backoff = interval, interval
+			continue
+		}
+		if running {
+			log.Printf("bridge: %s receiver stopped, restarting", b.Name())
+			r.Stop()
+		}
+		err := s.startReceiver(ctx, b)
+		running = err == nil
+		if err != nil {
+			log.Printf("bridge: restart receiver %s: %v", b.Name(), err)
+		}
+		wait = backoff
+		backoff = min(backoff*2, maxReceiverBackoff)
+	}
+}
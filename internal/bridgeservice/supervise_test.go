@@ -0,0 +1,111 @@
+package bridgeservice
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"h2/internal/bridge"
+)
+
+// waitFor polls cond until it holds or the deadline passes.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSuperviseReceiver_RetriesFailedStart(t *testing.T) {
+	recv := &mockReceiver{name: "telegram", failStart: 1}
+	sender := &mockSender{name: "macos"}
+	svc := New([]bridge.Bridge{recv, sender}, "alice", "", "", t.TempDir(), nil)
+	svc.receiverCheckInterval = 5 * time.Millisecond
+	svc.receiverOutageNotice = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err := svc.startReceiver(ctx, recv)
+	if err == nil {
+		t.Fatal("expected the first start to fail")
+	}
+	go svc.superviseReceiver(ctx, recv, false)
+
+	waitFor(t, "receiver restart", func() bool { return recv.Starts() == 1 })
+	if recv.Handler() == nil {
+		t.Error("restarted receiver has no handler")
+	}
+	// Short outages aren't announced.
+	if msgs := sender.Messages(); len(msgs) != 0 {
+		t.Errorf("messages = %q, want none", msgs)
+	}
+}
+
+func TestSuperviseReceiver_RestartsExitedAndAnnouncesOutage(t *testing.T) {
+	recv := &mockReceiver{name: "telegram"}
+	sender := &mockSender{name: "macos"}
+	svc := New([]bridge.Bridge{recv, sender}, "alice", "", "", t.TempDir(), nil)
+	svc.receiverCheckInterval = 5 * time.Millisecond
+	svc.receiverOutageNotice = time.Nanosecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := svc.startReceiver(ctx, recv); err != nil {
+		t.Fatal(err)
+	}
+	recv.mu.Lock()
+	recv.unhealthy = true
+	recv.exited = true
+	recv.failStart = 1 // the first reconnect fails too
+	recv.mu.Unlock()
+	go svc.superviseReceiver(ctx, recv, true)
+
+	waitFor(t, "reconnect notice", func() bool { return len(sender.Messages()) == 2 })
+	msgs := sender.Messages()
+	if !strings.Contains(msgs[0], "telegram receiver is down") {
+		t.Errorf("first message = %q, want an outage notice", msgs[0])
+	}
+	if !strings.Contains(msgs[1], "telegram receiver reconnected") {
+		t.Errorf("second message = %q, want a recovery notice", msgs[1])
+	}
+	if !recv.Stopped() || recv.Starts() != 2 {
+		t.Errorf("stopped=%v starts=%d, want stopped and restarted once", recv.Stopped(), recv.Starts())
+	}
+}
+
+func TestSuperviseReceiver_LeavesRunningUnhealthyReceiverAlone(t *testing.T) {
+	recv := &mockReceiver{name: "telegram"}
+	sender := &mockSender{name: "macos"}
+	svc := New([]bridge.Bridge{recv, sender}, "alice", "", "", t.TempDir(), nil)
+	svc.receiverCheckInterval = 5 * time.Millisecond
+	svc.receiverOutageNotice = time.Nanosecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := svc.startReceiver(ctx, recv); err != nil {
+		t.Fatal(err)
+	}
+	recv.mu.Lock()
+	recv.unhealthy = true
+	recv.mu.Unlock()
+	go svc.superviseReceiver(ctx, recv, true)
+
+	waitFor(t, "outage notice", func() bool { return len(sender.Messages()) == 1 })
+	time.Sleep(30 * time.Millisecond)
+	if recv.Stopped() || recv.Starts() != 1 {
+		t.Errorf("stopped=%v starts=%d, want the running receiver left to retry", recv.Stopped(), recv.Starts())
+	}
+
+	recv.mu.Lock()
+	recv.unhealthy = false
+	recv.mu.Unlock()
+	waitFor(t, "recovery notice", func() bool { return len(sender.Messages()) == 2 })
+	if msgs := sender.Messages(); !strings.Contains(msgs[1], "telegram receiver reconnected") {
+		t.Errorf("second message = %q, want a recovery notice", msgs[1])
+	}
+}
@@ -0,0 +1,130 @@
+package bridgeservice
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Agent state changes that can be announced on the bridge.
+const (
+	TransitionIdle    = "idle"    // busy to idle: the agent likely finished its task
+	TransitionBlocked = "blocked" // blocked on a permission prompt
+)
+
+// defaultNotifyDebounce is how long a new agent state must hold before it is
+// announced.
+const defaultNotifyDebounce = 10 * time.Second
+
+// defaultTransitionInterval is how often agent states are polled for
+// transition notifications.
+const defaultTransitionInterval = 4 * time.Second
+
+// Coarse agent activity, as tracked for transition notifications.
+const (
+	activityBusy    = "busy"
+	activityIdle    = "idle"
+	activityBlocked = "blocked"
+)
+
+// agentActivity tracks one agent's debounced activity.
+type agentActivity struct {
+	settled      string    // last activity that held for the debounce period
+	pending      string    // differing activity seen since pendingSince, "" if none
+	pendingSince time.Time // when pending was first seen
+}
+
+// observe records activity seen at now and returns the activity it replaces
+// once the change has held for debounce, or "" if nothing settled.
+func (a *agentActivity) observe(activity string, now time.Time, debounce time.Duration) (prev string) {
+	if activity == a.settled {
+		a.pending = ""
+		return ""
+	}
+	if activity != a.pending {
+		a.pending, a.pendingSince = activity, now
+	}
+	if now.Sub(a.pendingSince) < debounce {
+		return ""
+	}
+	prev = a.settled
+	a.settled, a.pending = activity, ""
+	return prev
+}
+
+// runTransitionWatcher polls every running agent's status and announces the
+// configured state transitions on the bridge. A change is only announced
+// once it has held for the debounce period, so an agent flapping to idle
+// between tool calls stays quiet. Agents are first seen silently.
+func (s *Service) runTransitionWatcher(ctx context.Context) {
+	if len(s.notifyTransitions) == 0 {
+		return
+	}
+	interval := s.transitionInterval
+	if interval == 0 {
+		interval = defaultTransitionInterval
+	}
+	debounce := s.notifyDebounce
+	if debounce == 0 {
+		debounce = defaultNotifyDebounce
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	agents := map[string]*agentActivity{}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		now := time.Now()
+		running := s.runningAgentNames()
+		for name := range agents {
+			if !slices.Contains(running, name) {
+				delete(agents, name)
+			}
+		}
+		for _, name := range running {
+			info, err := s.queryAgentInfoFn(name)
+			if err != nil {
+				continue
+			}
+			activity := info.State
+			switch {
+			case info.BlockedOnPermission:
+				activity = activityBlocked
+			case info.State == "active":
+				activity = activityBusy
+			}
+			a, ok := agents[name]
+			if !ok {
+				agents[name] = &agentActivity{settled: activity}
+				continue
+			}
+			prev := a.observe(activity, now, debounce)
+			if prev == "" {
+				continue
+			}
+			if msg := s.transitionNotice(name, prev, activity, info.BlockedToolName); msg != "" {
+				s.sendBridgeStatus(ctx, msg)
+			}
+		}
+	}
+}
+
+// transitionNotice returns the announcement for agent going from prev to
+// activity, or "" if that transition isn't watched.
+func (s *Service) transitionNotice(agent, prev, activity, blockedTool string) string {
+	switch {
+	case activity == activityIdle && prev == activityBusy && slices.Contains(s.notifyTransitions, TransitionIdle):
+		return fmt.Sprintf("%s is idle.", agent)
+	case activity == activityBlocked && slices.Contains(s.notifyTransitions, TransitionBlocked):
+		if blockedTool != "" {
+			return fmt.Sprintf("%s is blocked on permission to use %s.", agent, blockedTool)
+		}
+		return fmt.Sprintf("%s is blocked on permission.", agent)
+	}
+	return ""
+}
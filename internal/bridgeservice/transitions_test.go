@@ -0,0 +1,108 @@
+package bridgeservice
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"h2/internal/bridge"
+	"h2/internal/session/message"
+)
+
+func TestAgentActivity_DebouncesFlaps(t *testing.T) {
+	base := time.Now()
+	a := &agentActivity{settled: activityBusy}
+	debounce := 10 * time.Second
+
+	if prev := a.observe(activityIdle, base, debounce); prev != "" {
+		t.Fatalf("change settled immediately (prev %q)", prev)
+	}
+	// Busy again before the debounce elapses: the idle blip is dropped.
+	if prev := a.observe(activityBusy, base.Add(3*time.Second), debounce); prev != "" {
+		t.Fatalf("flap settled (prev %q)", prev)
+	}
+	if prev := a.observe(activityIdle, base.Add(5*time.Second), debounce); prev != "" {
+		t.Fatalf("new idle settled immediately (prev %q)", prev)
+	}
+	if prev := a.observe(activityIdle, base.Add(14*time.Second), debounce); prev != "" {
+		t.Fatalf("idle settled before holding for the debounce (prev %q)", prev)
+	}
+	if prev := a.observe(activityIdle, base.Add(15*time.Second), debounce); prev != activityBusy {
+		t.Fatalf("prev = %q, want %q", prev, activityBusy)
+	}
+	if a.settled != activityIdle {
+		t.Fatalf("settled = %q, want %q", a.settled, activityIdle)
+	}
+}
+
+func TestTransitionNotice(t *testing.T) {
+	svc := New(nil, "alice", "", "", t.TempDir(), nil, ServiceOpts{NotifyTransitions: []string{TransitionBlocked}})
+
+	if got := svc.transitionNotice("coder", activityBusy, activityIdle, ""); got != "" {
+		t.Errorf("unwatched idle transition announced: %q", got)
+	}
+	if got, want := svc.transitionNotice("coder", activityBusy, activityBlocked, "Bash"), "coder is blocked on permission to use Bash."; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	svc.notifyTransitions = []string{TransitionIdle}
+	if got, want := svc.transitionNotice("coder", activityBusy, activityIdle, ""), "coder is idle."; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := svc.transitionNotice("coder", activityBlocked, activityIdle, ""); got != "" {
+		t.Errorf("blocked to idle announced as idle: %q", got)
+	}
+}
+
+func TestTransitionWatcher_AnnouncesSettledChanges(t *testing.T) {
+	tmpDir := shortTempDir(t)
+	_ = newMockAgent(t, tmpDir, "coder")
+	sender := &mockSender{name: "telegram"}
+	svc := New([]bridge.Bridge{sender}, "alice", "", "", tmpDir, nil, ServiceOpts{
+		NotifyTransitions: []string{TransitionIdle, TransitionBlocked},
+		NotifyDebounce:    30 * time.Millisecond,
+	})
+	svc.transitionInterval = 5 * time.Millisecond
+
+	var mu sync.Mutex
+	info := &message.AgentInfo{State: "active"}
+	setInfo := func(i *message.AgentInfo) {
+		mu.Lock()
+		info = i
+		mu.Unlock()
+	}
+	var polls int
+	svc.queryAgentInfoFn = func(string) (*message.AgentInfo, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		polls++
+		return info, nil
+	}
+	pollCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return polls
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go svc.runTransitionWatcher(ctx)
+	waitFor(t, "first poll", func() bool { return pollCount() > 0 })
+
+	setInfo(&message.AgentInfo{State: "active", BlockedOnPermission: true, BlockedToolName: "Bash"})
+	waitFor(t, "blocked notice", func() bool { return len(sender.Messages()) == 1 })
+	setInfo(&message.AgentInfo{State: "active"})
+	time.Sleep(60 * time.Millisecond)
+	setInfo(&message.AgentInfo{State: "idle"})
+	waitFor(t, "idle notice", func() bool { return len(sender.Messages()) == 2 })
+
+	msgs := sender.Messages()
+	if !strings.Contains(msgs[0], "coder is blocked on permission to use Bash.") {
+		t.Errorf("first notice = %q", msgs[0])
+	}
+	if !strings.Contains(msgs[1], "coder is idle.") {
+		t.Errorf("second notice = %q", msgs[1])
+	}
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"h2/internal/session/message"
+	"h2/internal/socketdir"
+)
+
+func newAgentCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "agent",
+		Short: "Inspect and control running agents",
+	}
+	cmd.AddCommand(newAgentStatusCmd(), newAgentStopCmd())
+	return cmd
+}
+
+// agentStateDown is reported for an agent whose socket is missing or not
+// accepting connections.
+const agentStateDown = "down"
+
+// isAgentDown reports whether err from fetchAgentStatus means the agent
+// isn't running, rather than that it failed to answer.
+func isAgentDown(err error) bool {
+	return errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ECONNREFUSED)
+}
+
+func newAgentStatusCmd() *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "status <name>",
+		Short: "Show an agent's status",
+		Long: `Query a running agent's status: state, permission blocking, and queued
+messages by priority.
+
+An agent that isn't running is reported with state "down" (exit status 0),
+so monitoring scripts can poll every agent the same way:
+
+  h2 agent status coder-1 --json   # {"name":"coder-1","state":"down"}
+
+A running agent that doesn't answer properly is an error (non-zero exit).`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			out := cmd.OutOrStdout()
+
+			info, err := fetchAgentStatus(socketdir.Path(socketdir.TypeAgent, name))
+			if err != nil && !isAgentDown(err) {
+				return fmt.Errorf("query agent %q: %w", name, err)
+			}
+
+			if jsonOutput {
+				var v any = info
+				if info == nil {
+					v = map[string]string{"name": name, "state": agentStateDown}
+				}
+				data, err := json.MarshalIndent(v, "", "  ")
+				if err != nil {
+					return fmt.Errorf("marshal: %w", err)
+				}
+				fmt.Fprintln(out, string(data))
+				return nil
+			}
+
+			if info == nil {
+				fmt.Fprintf(out, "Agent:          %s\nState:          %s\n", name, agentStateDown)
+				return nil
+			}
+			printAgentStatus(out, info)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print status as JSON")
+	return cmd
+}
+
+func newAgentStopCmd() *cobra.Command {
+	var force bool
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "stop <name>",
+		Short: "Stop an agent once its current turn finishes",
+		Long: `Stop an agent gracefully: queued messages are held back, the current turn
+is allowed to finish, and the agent then exits cleanly, flushing its
+activity log and removing its socket.
+
+The stop is refused while the agent is running a tool, and fails if the
+turn is still going after --timeout; the agent keeps running either way.
+--force stops it immediately, like h2 stop.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			conn, err := net.Dial("unix", socketdir.Path(socketdir.TypeAgent, name))
+			if err != nil {
+				return fmt.Errorf("cannot connect to agent %q: %w", name, err)
+			}
+			defer conn.Close()
+
+			req := &message.Request{Type: "graceful_stop", Timeout: timeout.String()}
+			if force {
+				req = &message.Request{Type: "stop"}
+			}
+			if err := message.SendRequest(conn, req); err != nil {
+				return fmt.Errorf("send %s request: %w", req.Type, err)
+			}
+			resp, err := message.ReadResponse(conn)
+			if err != nil {
+				return fmt.Errorf("read response: %w", err)
+			}
+			if !resp.OK {
+				return fmt.Errorf("stop %s: %s", name, resp.Error)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Stopped %s.\n", name)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "Stop immediately, even mid-turn or while a tool is running")
+	cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "How long to wait for the current turn to finish")
+	return cmd
+}
+
+// printAgentStatus writes a multi-line status view of an agent.
+func printAgentStatus(w io.Writer, info *message.AgentInfo) {
+	fmt.Fprintf(w, "Agent:          %s\n", info.Name)
+	if info.RoleName != "" {
+		fmt.Fprintf(w, "Role:           %s\n", info.RoleName)
+	}
+	if info.Pod != "" {
+		fmt.Fprintf(w, "Pod:            %s\n", info.Pod)
+	}
+	state := info.StateDisplayText
+	if state == "" {
+		state = info.State
+	}
+	if info.StateDuration != "" {
+		state += " for " + info.StateDuration
+	}
+	fmt.Fprintf(w, "State:          %s\n", state)
+	if info.BlockedOnPermission {
+		blocked := "yes"
+		if info.BlockedToolName != "" {
+			blocked += " (" + info.BlockedToolName + ")"
+		}
+		fmt.Fprintf(w, "Blocked:        %s\n", blocked)
+	}
+
+	queued := fmt.Sprintf("%d", info.QueuedCount)
+	if info.QueuedCount > 0 {
+		var parts []string
+		for _, p := range []struct {
+			label string
+			n     int
+		}{
+			{"interrupt", info.QueuedInterrupt},
+			{"normal", info.QueuedNormal},
+			{"idle-first", info.QueuedIdleFirst},
+			{"idle", info.QueuedIdle},
+		} {
+			if p.n > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s", p.n, p.label))
+			}
+		}
+		if len(parts) > 0 {
+			queued += " (" + strings.Join(parts, ", ") + ")"
+		}
+		if info.OldestQueuedAt != "" {
+			queued += ", oldest " + info.OldestQueuedAt
+		}
+	}
+	fmt.Fprintf(w, "Queued:         %s\n", queued)
+	fmt.Fprintf(w, "Uptime:         %s\n", info.Uptime)
+	if info.LastActivity != "" {
+		fmt.Fprintf(w, "Last activity:  %s ago\n", info.LastActivity)
+	}
+}
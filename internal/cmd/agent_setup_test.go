@@ -9,41 +9,6 @@ import (
 	"h2/internal/config"
 )
 
-func TestValidateHarnessConfigDirExists_MissingProfileDerivedDir(t *testing.T) {
-	h2Dir := setupProfileTestH2Dir(t)
-	role := &config.Role{
-		AgentHarness: "codex",
-		Profile:      "alt",
-	}
-
-	err := validateHarnessConfigDirExists(role, buildRoleRuntimeConfig(role))
-	if err == nil {
-		t.Fatal("expected error for missing profile-derived config dir")
-	}
-	if !strings.Contains(err.Error(), `profile "alt" not found`) {
-		t.Fatalf("unexpected error: %v", err)
-	}
-	if !strings.Contains(err.Error(), filepath.Join(h2Dir, "codex-config", "alt")) {
-		t.Fatalf("error missing expected config dir path: %v", err)
-	}
-}
-
-func TestValidateHarnessConfigDirExists_ExistingProfileDerivedDir(t *testing.T) {
-	h2Dir := setupProfileTestH2Dir(t)
-	role := &config.Role{
-		AgentHarness: "codex",
-		Profile:      "alt1",
-	}
-
-	if err := os.MkdirAll(filepath.Join(h2Dir, "codex-config", "alt1"), 0o755); err != nil {
-		t.Fatal(err)
-	}
-	err := validateHarnessConfigDirExists(role, buildRoleRuntimeConfig(role))
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-}
-
 func TestDoSetupAndForkAgent_FailsWhenProfileMissing(t *testing.T) {
 	setupProfileTestH2Dir(t)
 	role := &config.Role{
@@ -56,7 +21,7 @@ func TestDoSetupAndForkAgent_FailsWhenProfileMissing(t *testing.T) {
 		ClaudePermissionMode: "",
 	}
 
-	err := doSetupAndForkAgent("missing-profile-test", role, true, "", 0, nil, true)
+	err := doSetupAndForkAgent("missing-profile-test", role, true, "", 0, nil, true, false)
 	if err == nil {
 		t.Fatal("expected error for missing profile")
 	}
@@ -64,3 +29,13 @@ func TestDoSetupAndForkAgent_FailsWhenProfileMissing(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+// writeClaudeAuth marks a Claude config dir as logged in, so launches from
+// it pass checkRoleAuthenticated.
+func writeClaudeAuth(t *testing.T, dir string) {
+	t.Helper()
+	data := `{"oauthAccount":{"accountUuid":"u-1","emailAddress":"test@example.com"}}`
+	if err := os.WriteFile(filepath.Join(dir, ".claude.json"), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"h2/internal/session/message"
+	"h2/internal/socketdir"
+)
+
+// serveAgentStatus answers one status request on a fake agent socket.
+func serveAgentStatus(t *testing.T, sockDir, name string, info *message.AgentInfo) {
+	t.Helper()
+	ln, err := net.Listen("unix", filepath.Join(sockDir, socketdir.Format(socketdir.TypeAgent, name)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if req, err := message.ReadRequest(conn); err != nil || req.Type != "status" {
+			return
+		}
+		_ = message.SendResponse(conn, &message.Response{OK: true, Agent: info})
+	}()
+}
+
+func runAgentStatus(t *testing.T, args ...string) string {
+	t.Helper()
+	var out bytes.Buffer
+	cmd := newAgentStatusCmd()
+	cmd.SetOut(&out)
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("agent status %v: %v", args, err)
+	}
+	return out.String()
+}
+
+func TestAgentStatusCmd_JSON(t *testing.T) {
+	sockDir := setupBridgeSocketDir(t)
+	serveAgentStatus(t, sockDir, "coder-1", &message.AgentInfo{
+		Name:                "coder-1",
+		State:               "active",
+		BlockedOnPermission: true,
+		QueuedCount:         3,
+		QueuedNormal:        1,
+		QueuedIdle:          2,
+	})
+
+	var got map[string]any
+	if err := json.Unmarshal([]byte(runAgentStatus(t, "coder-1", "--json")), &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got["state"] != "active" || got["blocked_on_permission"] != true || got["queued_idle"] != float64(2) {
+		t.Errorf("status JSON = %v", got)
+	}
+}
+
+func TestAgentStatusCmd_Human(t *testing.T) {
+	sockDir := setupBridgeSocketDir(t)
+	serveAgentStatus(t, sockDir, "coder-1", &message.AgentInfo{
+		Name:                "coder-1",
+		State:               "active",
+		StateDisplayText:    "Active (blocked)",
+		StateDuration:       "2m",
+		BlockedOnPermission: true,
+		BlockedToolName:     "Bash",
+		QueuedCount:         3,
+		QueuedNormal:        1,
+		QueuedIdle:          2,
+		Uptime:              "1h",
+	})
+
+	out := runAgentStatus(t, "coder-1")
+	for _, want := range []string{
+		"Agent:          coder-1",
+		"State:          Active (blocked) for 2m",
+		"Blocked:        yes (Bash)",
+		"Queued:         3 (1 normal, 2 idle)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestAgentStatusCmd_DownAgent(t *testing.T) {
+	setupBridgeSocketDir(t)
+
+	out := runAgentStatus(t, "ghost", "--json")
+	var got map[string]string
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("unmarshal %q: %v", out, err)
+	}
+	if got["state"] != "down" || got["name"] != "ghost" {
+		t.Errorf("down status = %v", got)
+	}
+
+	if out := runAgentStatus(t, "ghost"); !strings.Contains(out, "State:          down") {
+		t.Errorf("human output = %q", out)
+	}
+}
+
+func TestAgentStatusCmd_StaleSocketIsDown(t *testing.T) {
+	sockDir := setupBridgeSocketDir(t)
+	ln, err := net.Listen("unix", filepath.Join(sockDir, socketdir.Format(socketdir.TypeAgent, "ghost")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	ln.Close()
+
+	if out := runAgentStatus(t, "ghost"); !strings.Contains(out, "State:          down") {
+		t.Errorf("stale socket output = %q, want down", out)
+	}
+}
+
+func TestAgentStatusCmd_BadResponseIsError(t *testing.T) {
+	sockDir := setupBridgeSocketDir(t)
+	ln, err := net.Listen("unix", filepath.Join(sockDir, socketdir.Format(socketdir.TypeAgent, "coder-1")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		message.ReadRequest(conn)
+		conn.Close() // hang up without answering
+	}()
+
+	cmd := newAgentStatusCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{"coder-1", "--json"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), `query agent "coder-1"`) {
+		t.Fatalf("expected query error, got %v", err)
+	}
+}
+
+func TestAgentStopCmd(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantType string
+		wantReq  func(*message.Request) bool
+	}{
+		{"graceful", []string{"coder-1", "--timeout", "5s"}, "graceful_stop", func(r *message.Request) bool { return r.Timeout == "5s" }},
+		{"force", []string{"coder-1", "--force"}, "stop", func(r *message.Request) bool { return true }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sockDir := setupBridgeSocketDir(t)
+			ln, err := net.Listen("unix", filepath.Join(sockDir, socketdir.Format(socketdir.TypeAgent, "coder-1")))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ln.Close()
+			got := make(chan *message.Request, 1)
+			go func() {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				req, err := message.ReadRequest(conn)
+				if err != nil {
+					return
+				}
+				got <- req
+				_ = message.SendResponse(conn, &message.Response{OK: true})
+			}()
+
+			var out bytes.Buffer
+			cmd := newAgentStopCmd()
+			cmd.SetOut(&out)
+			cmd.SetArgs(tt.args)
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("agent stop: %v", err)
+			}
+			req := <-got
+			if req.Type != tt.wantType || !tt.wantReq(req) {
+				t.Errorf("request = %+v", req)
+			}
+			if !strings.Contains(out.String(), "Stopped coder-1.") {
+				t.Errorf("output = %q", out.String())
+			}
+		})
+	}
+}
+
+func TestAgentStopCmd_Refused(t *testing.T) {
+	sockDir := setupBridgeSocketDir(t)
+	ln, err := net.Listen("unix", filepath.Join(sockDir, socketdir.Format(socketdir.TypeAgent, "coder-1")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if _, err := message.ReadRequest(conn); err != nil {
+			return
+		}
+		_ = message.SendResponse(conn, &message.Response{Error: "agent is running Bash; stop it once the tool finishes, or use --force to stop it now"})
+	}()
+
+	cmd := newAgentStopCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{"coder-1"})
+	err = cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "stop coder-1: agent is running Bash") {
+		t.Fatalf("expected the refusal, got %v", err)
+	}
+}
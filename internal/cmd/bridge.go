@@ -1,10 +1,13 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -34,8 +37,11 @@ to manage running bridges.`,
 	createCmd := newBridgeCreateCmd()
 	cmd.AddCommand(createCmd)
 	cmd.AddCommand(newBridgeStopCmd())
+	cmd.AddCommand(newBridgeStatusCmd())
 	cmd.AddCommand(newBridgeSetConciergeCmd())
 	cmd.AddCommand(newBridgeRemoveConciergeCmd())
+	cmd.AddCommand(newBridgeMuteCmd())
+	cmd.AddCommand(newBridgeUnmuteCmd())
 
 	// If parent is invoked with flags but no subcommand, run create.
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
@@ -53,11 +59,13 @@ to manage running bridges.`,
 func newBridgeCreateCmd() *cobra.Command {
 	var bridgeName string
 	var noConcierge bool
+	var force bool
 	var setConcierge string
 	var conciergeRole string
+	var metricsAddr string
 
 	cmd := &cobra.Command{
-		Use:   "create --bridge <name> [--no-concierge | --set-concierge <name>] [--concierge-role <name>]",
+		Use:   "create --bridge <name> [--no-concierge | --set-concierge <name>] [--concierge-role <name>] [--metrics-addr <host:port>]",
 		Short: "Create and start a bridge service",
 		Long: `Creates and starts a bridge service that routes messages between external
 platforms (Telegram, macOS notifications) and h2 agent sessions.
@@ -65,7 +73,8 @@ platforms (Telegram, macOS notifications) and h2 agent sessions.
 By default, also starts a concierge session (named "concierge") using the
 "concierge" role and attaches to it interactively. Use --no-concierge to run
 only the bridge service with no default routing. Use --set-concierge <name>
-to route to an existing agent without spawning a new session.`,
+to route to an existing agent without spawning a new session. Use
+--metrics-addr to serve the bridge's counters in Prometheus format.`,
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if bridgeName == "" {
@@ -110,7 +119,7 @@ to route to an existing agent without spawning a new session.`,
 				fmt.Fprintf(os.Stderr, "Stopped existing bridge %q.\n", bridgeName)
 			}
 			fmt.Fprintf(os.Stderr, "Starting bridge %q...\n", bridgeName)
-			if err := forkBridgeFunc(bridgeName, concierge, ""); err != nil {
+			if err := forkBridgeFunc(bridgeName, concierge, "", metricsAddr); err != nil {
 				return err
 			}
 			fmt.Fprintf(os.Stderr, "Bridge service started.\n")
@@ -131,7 +140,7 @@ to route to an existing agent without spawning a new session.`,
 			if err != nil {
 				return fmt.Errorf("concierge role not found; create one with: h2 role create concierge --template concierge")
 			}
-			return setupAndForkAgent(conciergeSessionName, role, false, "", 0, nil)
+			return setupAndForkAgent(conciergeSessionName, role, false, "", 0, nil, force)
 		},
 	}
 
@@ -139,6 +148,8 @@ to route to an existing agent without spawning a new session.`,
 	cmd.Flags().BoolVar(&noConcierge, "no-concierge", false, "Run without a concierge session")
 	cmd.Flags().StringVar(&setConcierge, "set-concierge", "", "Route to an existing concierge agent by name")
 	cmd.Flags().StringVar(&conciergeRole, "concierge-role", "concierge", "Role to use for the concierge session")
+	cmd.Flags().BoolVar(&force, "force", false, "Launch the concierge even if its harness config dir isn't authenticated")
+	cmd.Flags().StringVar(&metricsAddr, "metrics-addr", "", "Serve Prometheus metrics at http://<addr>/metrics (e.g. 127.0.0.1:9464)")
 
 	return cmd
 }
@@ -219,6 +230,69 @@ listing them.`,
 	}
 }
 
+func newBridgeStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status [name]",
+		Short: "Show status of a running bridge",
+		Long: `Show the concierge, channels, message counts, uptime, and last activity
+of a running bridge. If name is omitted and exactly one bridge is running,
+shows that one.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var name string
+			if len(args) > 0 {
+				name = args[0]
+			}
+
+			resp, err := bridgeRequest(name, "status", "")
+			if err != nil {
+				return err
+			}
+			if !resp.OK {
+				return fmt.Errorf("status failed: %s", resp.Error)
+			}
+			if resp.Bridge == nil {
+				return fmt.Errorf("status failed: bridge returned no status")
+			}
+			printBridgeStatus(cmd.OutOrStdout(), resp.Bridge)
+			return nil
+		},
+	}
+}
+
+// printBridgeStatus writes a multi-line status view of a bridge.
+func printBridgeStatus(w io.Writer, info *message.BridgeInfo) {
+	concierge := info.Concierge
+	if concierge == "" {
+		concierge = "(none)"
+	}
+	channels := strings.Join(info.Channels, ", ")
+	if channels == "" {
+		channels = "(none)"
+	}
+	lastActivity := "never"
+	if info.LastActivity != "" {
+		lastActivity = info.LastActivity + " ago"
+	}
+
+	fmt.Fprintf(w, "Bridge:         %s\n", info.Name)
+	if info.Pod != "" {
+		fmt.Fprintf(w, "Pod:            %s\n", info.Pod)
+	}
+	fmt.Fprintf(w, "Concierge:      %s\n", concierge)
+	fmt.Fprintf(w, "Channels:       %s\n", channels)
+	if info.MessagesMuted > 0 {
+		fmt.Fprintf(w, "Messages:       %d sent, %d received, %d muted\n", info.MessagesSent, info.MessagesReceived, info.MessagesMuted)
+	} else {
+		fmt.Fprintf(w, "Messages:       %d sent, %d received\n", info.MessagesSent, info.MessagesReceived)
+	}
+	if len(info.Muted) > 0 {
+		fmt.Fprintf(w, "Muted:          %s\n", strings.Join(info.Muted, ", "))
+	}
+	fmt.Fprintf(w, "Uptime:         %s\n", info.Uptime)
+	fmt.Fprintf(w, "Last activity:  %s\n", lastActivity)
+}
+
 func newBridgeSetConciergeCmd() *cobra.Command {
 	var bridgeName string
 
@@ -280,6 +354,68 @@ func newBridgeRemoveConciergeCmd() *cobra.Command {
 	return cmd
 }
 
+func newBridgeMuteCmd() *cobra.Command {
+	var bridgeName string
+
+	cmd := &cobra.Command{
+		Use:   "mute <agent-name>",
+		Short: "Stop delivering an agent's messages through a running bridge",
+		Long: `Stop delivering an agent's messages through a running bridge. The bridge
+drops them, counting them in h2 bridge status, until the agent is unmuted.
+High-urgency messages still get through. The named agent does not need to
+be running yet.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			agentName := args[0]
+
+			resp, err := bridgeRequest(bridgeName, "mute", agentName)
+			if err != nil {
+				return err
+			}
+			if !resp.OK {
+				return fmt.Errorf("mute failed: %s", resp.Error)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Muted %s.\n", agentName)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&bridgeName, "bridge", "", "Which bridge to target")
+
+	return cmd
+}
+
+func newBridgeUnmuteCmd() *cobra.Command {
+	var bridgeName string
+
+	cmd := &cobra.Command{
+		Use:   "unmute <agent-name>",
+		Short: "Resume delivering a muted agent's messages through a running bridge",
+		Long: `Resume delivering a muted agent's messages through a running bridge.
+Messages dropped while the agent was muted are not replayed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			agentName := args[0]
+
+			resp, err := bridgeRequest(bridgeName, "unmute", agentName)
+			if err != nil {
+				return err
+			}
+			if !resp.OK {
+				return fmt.Errorf("unmute failed: %s", resp.Error)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Unmuted %s.\n", agentName)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&bridgeName, "bridge", "", "Which bridge to target")
+
+	return cmd
+}
+
 // bridgeRequest sends a request to a running bridge's socket and returns the response.
 // If bridgeName is empty and exactly one bridge is running, it targets that bridge.
 func bridgeRequest(bridgeName, reqType, body string) (*message.Response, error) {
@@ -290,6 +426,10 @@ func bridgeRequest(bridgeName, reqType, body string) (*message.Response, error)
 
 	conn, err := net.Dial("unix", sockPath)
 	if err != nil {
+		// No socket file, or a stale one left by a crashed bridge.
+		if bridgeName != "" && (errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ECONNREFUSED)) {
+			return nil, fmt.Errorf("bridge %q is not running", bridgeName)
+		}
 		return nil, fmt.Errorf("cannot connect to bridge: %w", err)
 	}
 	defer conn.Close()
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -17,6 +18,7 @@ func newBridgeDaemonCmd() *cobra.Command {
 	var bridgeName string
 	var concierge string
 	var pod string
+	var metricsAddr string
 
 	cmd := &cobra.Command{
 		Use:    "_bridge-service",
@@ -48,6 +50,34 @@ func newBridgeDaemonCmd() *cobra.Command {
 				allowedCommands = bc.Telegram.AllowedCommands
 				opts.ExpectsResponse = bc.Telegram.ExpectsResponse
 			}
+			for _, shift := range bc.ConciergeRotation {
+				start, err := shift.StartOffset()
+				if err != nil {
+					return fmt.Errorf("bridges.%s.concierge_rotation: %w", bridgeName, err)
+				}
+				opts.ConciergeRotation = append(opts.ConciergeRotation, bridgeservice.ConciergeShift{Agent: shift.Agent, Start: start})
+			}
+
+			if bc.AskTimeout != "" {
+				d, err := time.ParseDuration(bc.AskTimeout)
+				if err != nil || d < 0 {
+					return fmt.Errorf("bridges.%s.ask_timeout: invalid duration %q", bridgeName, bc.AskTimeout)
+				}
+				opts.AskTimeout = d
+			}
+
+			opts.Threads = bc.Threads
+			opts.NotifyTransitions = bc.NotifyTransitions
+			opts.NoConciergeFallback = bc.NoConciergeFallback
+			if bc.NotifyDebounce != "" {
+				d, err := time.ParseDuration(bc.NotifyDebounce)
+				if err != nil || d < 0 {
+					return fmt.Errorf("bridges.%s.notify_debounce: invalid duration %q", bridgeName, bc.NotifyDebounce)
+				}
+				opts.NotifyDebounce = d
+			}
+
+			opts.MetricsAddr = metricsAddr
 
 			svc := bridgeservice.New(bridges, bridgeName, concierge, pod, socketdir.Dir(), allowedCommands, opts)
 
@@ -61,6 +91,7 @@ func newBridgeDaemonCmd() *cobra.Command {
 	cmd.Flags().StringVar(&bridgeName, "bridge", "", "Named bridge config to load")
 	cmd.Flags().StringVar(&concierge, "concierge", "", "Concierge session name")
 	cmd.Flags().StringVar(&pod, "pod", "", "Pod name this bridge belongs to")
+	cmd.Flags().StringVar(&metricsAddr, "metrics-addr", "", "Serve Prometheus metrics on this address")
 
 	return cmd
 }
@@ -1,9 +1,11 @@
 package cmd
 
 import (
+	"bytes"
 	"net"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"h2/internal/config"
@@ -104,3 +106,170 @@ func TestStopExistingBridgeIfRunning_StopsRunningBridge(t *testing.T) {
 
 	<-done
 }
+
+// setupBridgeSocketDir points H2_DIR at a fresh h2 dir under /tmp (short
+// enough for unix socket paths) and returns its socket directory.
+func setupBridgeSocketDir(t *testing.T) string {
+	t.Helper()
+	config.ResetResolveCache()
+	socketdir.ResetDirCache()
+	t.Cleanup(func() {
+		config.ResetResolveCache()
+		socketdir.ResetDirCache()
+	})
+
+	tmpDir, err := os.MkdirTemp("/tmp", "h2t-bridge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(tmpDir) })
+
+	h2Root := filepath.Join(tmpDir, ".h2")
+	sockDir := filepath.Join(h2Root, "sockets")
+	if err := os.MkdirAll(sockDir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.WriteMarker(h2Root); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("H2_ROOT_DIR", h2Root)
+	t.Setenv("H2_DIR", h2Root)
+	return sockDir
+}
+
+func TestBridgeStatusCmd_PrintsStatus(t *testing.T) {
+	sockDir := setupBridgeSocketDir(t)
+	ln, err := net.Listen("unix", filepath.Join(sockDir, socketdir.Format(socketdir.TypeBridge, "alice")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if req, err := message.ReadRequest(conn); err != nil || req.Type != "status" {
+			return
+		}
+		_ = message.SendResponse(conn, &message.Response{OK: true, Bridge: &message.BridgeInfo{
+			Name:             "alice",
+			Concierge:        "concierge",
+			Channels:         []string{"telegram", "macos_notify"},
+			Uptime:           "1h2m3s",
+			MessagesSent:     12,
+			MessagesReceived: 3,
+			LastActivity:     "5s",
+		}})
+	}()
+
+	var out bytes.Buffer
+	cmd := newBridgeStatusCmd()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"alice"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("status: %v", err)
+	}
+
+	for _, want := range []string{
+		"Bridge:         alice",
+		"Concierge:      concierge",
+		"Channels:       telegram, macos_notify",
+		"Messages:       12 sent, 3 received",
+		"Uptime:         1h2m3s",
+		"Last activity:  5s ago",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestBridgeStatusCmd_NotRunning(t *testing.T) {
+	setupBridgeSocketDir(t)
+
+	cmd := newBridgeStatusCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"alice"})
+	err := cmd.Execute()
+	if err == nil || err.Error() != `bridge "alice" is not running` {
+		t.Fatalf("err = %v, want not-running error", err)
+	}
+}
+
+func TestBridgeMuteCmd(t *testing.T) {
+	sockDir := setupBridgeSocketDir(t)
+	ln, err := net.Listen("unix", filepath.Join(sockDir, socketdir.Format(socketdir.TypeBridge, "alice")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	reqs := make(chan *message.Request, 2)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			req, err := message.ReadRequest(conn)
+			if err == nil {
+				reqs <- req
+				if req.Type == "unmute" {
+					_ = message.SendResponse(conn, &message.Response{Error: "coder is not muted"})
+				} else {
+					_ = message.SendResponse(conn, &message.Response{OK: true})
+				}
+			}
+			conn.Close()
+		}
+	}()
+
+	var out bytes.Buffer
+	cmd := newBridgeMuteCmd()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"coder", "--bridge", "alice"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("mute: %v", err)
+	}
+	if req := <-reqs; req.Type != "mute" || req.Body != "coder" {
+		t.Errorf("request = %+v, want mute coder", req)
+	}
+	if out.String() != "Muted coder.\n" {
+		t.Errorf("output = %q", out.String())
+	}
+
+	cmd = newBridgeUnmuteCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"coder", "--bridge", "alice"})
+	err = cmd.Execute()
+	<-reqs
+	if err == nil || err.Error() != "unmute failed: coder is not muted" {
+		t.Errorf("err = %v, want unmute failure", err)
+	}
+}
+
+func TestPrintBridgeStatus_Muted(t *testing.T) {
+	var out bytes.Buffer
+	printBridgeStatus(&out, &message.BridgeInfo{
+		Name:             "alice",
+		Channels:         []string{"telegram"},
+		MessagesSent:     4,
+		MessagesReceived: 2,
+		MessagesMuted:    7,
+		Muted:            []string{"coder", "reviewer"},
+	})
+	for _, want := range []string{
+		"Messages:       4 sent, 2 received, 7 muted",
+		"Muted:          coder, reviewer",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
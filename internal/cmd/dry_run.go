@@ -2,11 +2,14 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"sort"
 	"strings"
+	"time"
 
 	"h2/internal/config"
+	"h2/internal/session"
 	"h2/internal/session/agent/harness"
 )
 
@@ -38,7 +41,7 @@ func resolveAgentConfig(name string, role *config.Role, pod string, overrides []
 	}
 
 	// Build a minimal RuntimeConfig for harness resolution.
-	minRC := buildRoleRuntimeConfig(role)
+	minRC := session.BuildRoleRuntimeConfig(role)
 	h, err := harness.Resolve(minRC, nil)
 	if err != nil {
 		return nil, fmt.Errorf("resolve harness: %w", err)
@@ -98,32 +101,31 @@ func resolveAgentConfig(name string, role *config.Role, pod string, overrides []
 	}
 
 	// Resolve additional dirs.
-	additionalDirs, err := role.ResolveAdditionalDirs(agentCWD)
+	additionalDirs, err := role.ResolveAdditionalDirs(cwd)
 	if err != nil {
 		return nil, fmt.Errorf("resolve additional_dirs: %w", err)
 	}
-
-	// Build a full RuntimeConfig for dry-run arg generation.
-	// We need a RuntimeConfig with all fields so the harness can pull from it.
-	dryRunRC := &config.RuntimeConfig{
-		AgentName:               name,
-		SessionID:               "<generated-uuid>",
-		HarnessType:             minRC.HarnessType,
-		HarnessConfigPathPrefix: minRC.HarnessConfigPathPrefix,
-		Profile:                 role.GetProfile(),
-		Command:                 h.Command(),
-		Args:                    extraArgs,
-		Model:                   minRC.Model,
-		CWD:                     agentCWD,
-		Instructions:            role.GetInstructions(),
-		SystemPrompt:            role.SystemPrompt,
-		ClaudePermissionMode:    role.ClaudePermissionMode,
-		CodexSandboxMode:        role.CodexSandboxMode,
-		CodexAskForApproval:     role.CodexAskForApproval,
-		AdditionalDirs:          additionalDirs,
-		StartedAt:               "dry-run",
+	skillsDir, err := role.ResolveSkillsDir()
+	if err != nil {
+		return nil, err
+	}
+	if skillsDir != "" {
+		additionalDirs = append(additionalDirs, config.SessionSkillsDir(sessionDir))
 	}
 
+	// Build the RuntimeConfig a launch would write, so the harness builds
+	// the same args from it.
+	dryRunRC := session.BuildLaunchRuntimeConfig(role, h, session.LaunchValues{
+		Name:           name,
+		SessionID:      "<generated-uuid>",
+		Pod:            pod,
+		CWD:            agentCWD,
+		AdditionalDirs: additionalDirs,
+		SkillsDir:      skillsDir,
+		StartedAt:      "dry-run",
+	})
+	dryRunRC.Args = extraArgs
+
 	// Re-resolve harness with full config so BuildCommandArgs has access to all fields.
 	dryRunH, err := harness.Resolve(dryRunRC, nil)
 	if err != nil {
@@ -147,56 +149,90 @@ func resolveAgentConfig(name string, role *config.Role, pod string, overrides []
 }
 
 // printDryRun displays the resolved agent configuration without launching.
+// Values of the role's secret variables are masked.
 func printDryRun(rc *ResolvedAgentConfig) {
+	var buf strings.Builder
+	writeDryRun(&buf, rc)
+	fmt.Print(rc.Role.Redact(buf.String()))
+}
+
+func writeDryRun(w io.Writer, rc *ResolvedAgentConfig) {
 	role := rc.Role
 
-	fmt.Printf("Agent: %s\n", rc.Name)
-	fmt.Printf("Role: %s\n", role.RoleName)
+	fmt.Fprintf(w, "Agent: %s\n", rc.Name)
+	fmt.Fprintf(w, "Role: %s\n", role.RoleName)
 	if role.Description != "" {
-		fmt.Printf("Description: %s\n", role.Description)
+		fmt.Fprintf(w, "Description: %s\n", role.Description)
 	}
 	if rc.Model != "" {
-		fmt.Printf("Model: %s\n", rc.Model)
+		fmt.Fprintf(w, "Model: %s\n", rc.Model)
+	}
+	if len(role.Profiles) > 0 {
+		if role.HasExplicitProfile() {
+			fmt.Fprintf(w, "Profile: %s (set explicitly)\n", role.GetProfile())
+		} else {
+			fmt.Fprintf(w, "Profile: %s (%s over %s)\n", role.GetProfile(), role.GetProfileStrategy(), strings.Join(role.Profiles, ", "))
+		}
 	}
 	if role.ClaudePermissionMode != "" {
-		fmt.Printf("Permission Mode: %s\n", role.ClaudePermissionMode)
+		fmt.Fprintf(w, "Permission Mode: %s\n", role.ClaudePermissionMode)
+	}
+	if len(role.AllowedTools) > 0 {
+		fmt.Fprintf(w, "Allowed Tools: %s\n", strings.Join(role.AllowedTools, ", "))
+	}
+	if len(role.DeniedTools) > 0 {
+		fmt.Fprintf(w, "Denied Tools: %s\n", strings.Join(role.DeniedTools, ", "))
 	}
 
 	// System prompt (truncated with line count).
 	if role.SystemPrompt != "" {
 		lines := strings.Split(role.SystemPrompt, "\n")
-		fmt.Printf("\nSystem Prompt: (%d lines)\n", len(lines))
+		fmt.Fprintf(w, "\nSystem Prompt: (%d lines)\n", len(lines))
 		const maxLines = 10
 		for i, line := range lines {
 			if i >= maxLines {
-				fmt.Printf("  ... (%d more lines)\n", len(lines)-maxLines)
+				fmt.Fprintf(w, "  ... (%d more lines)\n", len(lines)-maxLines)
 				break
 			}
-			fmt.Printf("  %s\n", line)
+			fmt.Fprintf(w, "  %s\n", line)
 		}
 	}
 
-	fmt.Println()
+	fmt.Fprintln(w)
 
 	// Instructions (truncated with line count).
 	if instr := role.GetInstructions(); instr != "" {
 		lines := strings.Split(instr, "\n")
-		fmt.Printf("Instructions: (%d lines)\n", len(lines))
+		fmt.Fprintf(w, "Instructions: (%d lines)\n", len(lines))
+		const maxLines = 10
+		for i, line := range lines {
+			if i >= maxLines {
+				fmt.Fprintf(w, "  ... (%d more lines)\n", len(lines)-maxLines)
+				break
+			}
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+
+	// Initial prompt (truncated with line count).
+	if role.InitialPrompt != "" {
+		lines := strings.Split(role.InitialPrompt, "\n")
+		fmt.Fprintf(w, "\nInitial Prompt: (%d lines)\n", len(lines))
 		const maxLines = 10
 		for i, line := range lines {
 			if i >= maxLines {
-				fmt.Printf("  ... (%d more lines)\n", len(lines)-maxLines)
+				fmt.Fprintf(w, "  ... (%d more lines)\n", len(lines)-maxLines)
 				break
 			}
-			fmt.Printf("  %s\n", line)
+			fmt.Fprintf(w, "  %s\n", line)
 		}
 	}
 
-	fmt.Println()
+	fmt.Fprintln(w)
 	// Print command + args in a copy-pasteable format with \ continuations.
-	fmt.Println("Command:")
+	fmt.Fprintln(w, "Command:")
 	if len(rc.ChildArgs) == 0 {
-		fmt.Printf("%s\n", rc.Command)
+		fmt.Fprintf(w, "%s\n", rc.Command)
 	} else {
 		// Group flags with their values.
 		var parts []string
@@ -215,104 +251,108 @@ func printDryRun(rc *ResolvedAgentConfig) {
 				parts = append(parts, arg)
 			}
 		}
-		fmt.Printf("%s \\\n", rc.Command)
+		fmt.Fprintf(w, "%s \\\n", rc.Command)
 		for i, part := range parts {
 			if i < len(parts)-1 {
-				fmt.Printf("  %s \\\n", part)
+				fmt.Fprintf(w, "  %s \\\n", part)
 			} else {
-				fmt.Printf("  %s\n", part)
+				fmt.Fprintf(w, "  %s\n", part)
 			}
 		}
 	}
 
-	fmt.Println()
+	fmt.Fprintln(w)
 	if rc.IsWorktree {
-		fmt.Printf("Working Dir: %s (worktree)\n", rc.WorkingDir)
+		fmt.Fprintf(w, "Working Dir: %s (worktree)\n", rc.WorkingDir)
 	} else {
-		fmt.Printf("Working Dir: %s\n", rc.WorkingDir)
+		fmt.Fprintf(w, "Working Dir: %s\n", rc.WorkingDir)
 	}
-	fmt.Printf("Session Dir: %s\n", rc.SessionDir)
+	fmt.Fprintf(w, "Session Dir: %s\n", rc.SessionDir)
 
 	// Environment variables.
-	fmt.Println()
-	fmt.Println("Environment:")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Environment:")
 	var envKeys []string
 	for k := range rc.EnvVars {
 		envKeys = append(envKeys, k)
 	}
 	sort.Strings(envKeys)
 	for _, key := range envKeys {
-		fmt.Printf("  %s=%s\n", key, rc.EnvVars[key])
+		fmt.Fprintf(w, "  %s=%s\n", key, rc.EnvVars[key])
 	}
 
 	// Permission review.
 	if role.PermissionReview != nil {
 		pr := role.PermissionReview
 		if pr.DCG != nil && pr.DCG.IsEnabled() {
-			fmt.Println()
-			fmt.Printf("Permission Review (DCG): enabled\n")
+			fmt.Fprintln(w)
+			fmt.Fprintf(w, "Permission Review (DCG): enabled\n")
 			if pr.DCG.DestructivePolicy != "" {
-				fmt.Printf("  Destructive Policy: %s\n", pr.DCG.DestructivePolicy)
+				fmt.Fprintf(w, "  Destructive Policy: %s\n", pr.DCG.DestructivePolicy)
 			}
 			if pr.DCG.PrivacyPolicy != "" {
-				fmt.Printf("  Privacy Policy: %s\n", pr.DCG.PrivacyPolicy)
+				fmt.Fprintf(w, "  Privacy Policy: %s\n", pr.DCG.PrivacyPolicy)
 			}
 		}
 		if pr.AIReviewer != nil && pr.AIReviewer.IsEnabled() {
-			fmt.Println()
-			fmt.Printf("Permission Review (AI Reviewer): enabled (model: %s)\n", pr.AIReviewer.GetModel())
+			fmt.Fprintln(w)
+			fmt.Fprintf(w, "Permission Review (AI Reviewer): enabled (model: %s)\n", pr.AIReviewer.GetModel())
 		}
 	}
 
 	// Triggers.
 	if len(rc.Role.Triggers) > 0 {
-		fmt.Println()
-		fmt.Printf("Triggers: %d\n", len(rc.Role.Triggers))
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "Triggers: %d\n", len(rc.Role.Triggers))
 		for _, t := range rc.Role.Triggers {
-			fmt.Printf("  - %s (event=%s)\n", t.Name, t.Event)
+			fmt.Fprintf(w, "  - %s (event=%s)\n", t.Name, t.Event)
 		}
 	}
 
 	// Schedules (includes heartbeat if converted).
-	if role.Heartbeat != nil || len(rc.Role.Schedules) > 0 {
-		fmt.Println()
+	heartbeat := session.HeartbeatSchedule(role, time.Now())
+	if heartbeat != nil || len(rc.Role.Schedules) > 0 {
+		fmt.Fprintln(w)
 		total := len(rc.Role.Schedules)
-		if role.Heartbeat != nil {
+		if heartbeat != nil {
 			total++
 		}
-		fmt.Printf("Schedules: %d\n", total)
-		if role.Heartbeat != nil {
-			fmt.Printf("  - heartbeat (rrule=FREQ=SECONDLY;INTERVAL=%s)\n",
-				heartbeatIntervalFromDuration(role.Heartbeat.IdleTimeout))
+		fmt.Fprintf(w, "Schedules: %d\n", total)
+		if heartbeat != nil {
+			if heartbeat.Start != "" {
+				fmt.Fprintf(w, "  - heartbeat (rrule=%s, start=%s)\n", heartbeat.RRule, heartbeat.Start)
+			} else {
+				fmt.Fprintf(w, "  - heartbeat (rrule=%s)\n", heartbeat.RRule)
+			}
 		}
 		for _, s := range rc.Role.Schedules {
-			fmt.Printf("  - %s (rrule=%s)\n", s.Name, s.RRule)
+			fmt.Fprintf(w, "  - %s (rrule=%s)\n", s.Name, s.RRule)
 		}
 	}
 
 	// Overrides.
 	if len(rc.Overrides) > 0 {
-		fmt.Println()
-		fmt.Printf("Overrides: %s\n", strings.Join(rc.Overrides, ", "))
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "Overrides: %s\n", strings.Join(rc.Overrides, ", "))
 	}
 
 	// Merged vars (pod dry-run only).
 	if len(rc.MergedVars) > 0 {
-		fmt.Println()
-		fmt.Println("Variables:")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Variables:")
 		var varKeys []string
 		for k := range rc.MergedVars {
 			varKeys = append(varKeys, k)
 		}
 		sort.Strings(varKeys)
 		for _, k := range varKeys {
-			fmt.Printf("  %s=%s\n", k, rc.MergedVars[k])
+			fmt.Fprintf(w, "  %s=%s\n", k, rc.MergedVars[k])
 		}
 	}
 
 	// Role scope (pod dry-run only).
 	if rc.RoleScope != "" {
-		fmt.Printf("Role Scope: %s\n", rc.RoleScope)
+		fmt.Fprintf(w, "Role Scope: %s\n", rc.RoleScope)
 	}
 }
 
@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"h2/internal/config"
+	"h2/internal/tmpl"
 )
 
 func TestResolveAgentConfig_Basic(t *testing.T) {
@@ -1096,3 +1097,35 @@ func captureStdout(fn func()) string {
 	io.Copy(&buf, r)
 	return buf.String()
 }
+
+func TestPrintDryRun_MasksSecretVars(t *testing.T) {
+	t.Setenv("H2_DIR", "")
+
+	path := filepath.Join(t.TempDir(), "deployer.yaml")
+	if err := os.WriteFile(path, []byte(`
+role_name: deployer
+variables:
+  api_token:
+    secret: true
+instructions: "Deploy with token {{ .Var.api_token }}."
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	role, err := config.LoadRoleRenderedFrom(path, &tmpl.Context{Var: map[string]string{"api_token": "tok-real-456"}})
+	if err != nil {
+		t.Fatalf("LoadRoleRenderedFrom: %v", err)
+	}
+
+	rc, err := resolveAgentConfig("deployer-1", role, "", nil, nil)
+	if err != nil {
+		t.Fatalf("resolveAgentConfig: %v", err)
+	}
+	output := capturePrintDryRun(rc)
+
+	if strings.Contains(output, "tok-real-456") {
+		t.Errorf("dry-run output leaks secret:\n%s", output)
+	}
+	if !strings.Contains(output, "Deploy with token ***.") {
+		t.Errorf("expected masked instructions, got:\n%s", output)
+	}
+}
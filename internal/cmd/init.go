@@ -39,6 +39,7 @@ func newInitCmd() *cobra.Command {
 	var prefix string
 	var updateConfig bool
 	var style string
+	var fromTemplate string
 
 	cmd := &cobra.Command{
 		Use:   "init <dir>",
@@ -48,7 +49,12 @@ func newInitCmd() *cobra.Command {
 Use --global to initialize ~/.h2/, or pass a directory path.
 
 Use --update-config to refresh generated default config files in an existing
-h2 directory to match the current h2 binary's init output.`,
+h2 directory to match the current h2 binary's init output.
+
+Use --from-template to bootstrap from a shared setup: a local directory or a
+git URL laid out like an h2 directory, with at least a roles/ dir. Its files
+replace the built-in ones; h2 still writes the marker and registers the route.
+The template is checked before anything is written.`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if !global && len(args) == 0 {
@@ -79,10 +85,13 @@ h2 directory to match the current h2 binary's init output.`,
 			}
 
 			if updateConfig {
+				if fromTemplate != "" {
+					return fmt.Errorf("--from-template can't be combined with --update-config")
+				}
 				return runUpdateConfig(abs, resolvedStyle, out)
 			}
 
-			return runFullInit(cmd, abs, prefix, resolvedStyle, out)
+			return runFullInit(cmd, abs, prefix, resolvedStyle, fromTemplate, out)
 		},
 	}
 
@@ -90,6 +99,7 @@ h2 directory to match the current h2 binary's init output.`,
 	cmd.Flags().StringVar(&prefix, "prefix", "", "Custom prefix for this h2 directory in the routes registry")
 	cmd.Flags().BoolVar(&updateConfig, "update-config", false, "Refresh init-managed default config in an existing h2 directory")
 	cmd.Flags().StringVar(&style, "style", initStyleOpinionated, "Generation style: minimal, opinionated")
+	cmd.Flags().StringVar(&fromTemplate, "from-template", "", "Bootstrap from a template h2 directory (local path or git URL)")
 	return cmd
 }
 
@@ -104,8 +114,9 @@ func resolveInitStyle(style string) (string, error) {
 	return s, nil
 }
 
-// runFullInit performs a full h2 directory initialization.
-func runFullInit(cmd *cobra.Command, abs, prefix, style string, out io.Writer) error {
+// runFullInit performs a full h2 directory initialization. With a template,
+// its files are copied over the built-in scaffold.
+func runFullInit(cmd *cobra.Command, abs, prefix, style, template string, out io.Writer) error {
 	// --- Pre-flight validation (all checks before any writes) ---
 
 	if config.IsH2Dir(abs) {
@@ -136,6 +147,19 @@ func runFullInit(cmd *cobra.Command, abs, prefix, style string, out io.Writer) e
 		return err
 	}
 
+	var templateDir string
+	if template != "" {
+		dir, cleanup, err := fetchInitTemplate(template)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		if err := checkInitTemplate(dir); err != nil {
+			return fmt.Errorf("template %s: %w", template, err)
+		}
+		templateDir = dir
+	}
+
 	// --- All validation passed, start writing ---
 
 	fmt.Fprintf(out, "Creating h2 directory at %s...\n", abs)
@@ -175,12 +199,6 @@ func runFullInit(cmd *cobra.Command, abs, prefix, style string, out io.Writer) e
 		return fmt.Errorf("scaffold default profile: %w", err)
 	}
 
-	// Register this h2 directory in the routes registry (pre-flight check already passed).
-	resolvedPrefix, err := config.RegisterRouteWithAutoPrefix(rootDir, explicitPrefix, abs)
-	if err != nil {
-		return fmt.Errorf("register route: %w", err)
-	}
-
 	// Create the default role.
 	rolePath, err := createOrUpdateRole(filepath.Join(abs, "roles"), "default", "default", style, false, true, true, out)
 	if err != nil {
@@ -193,6 +211,19 @@ func runFullInit(cmd *cobra.Command, abs, prefix, style string, out io.Writer) e
 		return fmt.Errorf("create default pods: %w", err)
 	}
 
+	if templateDir != "" {
+		if err := copyInitTemplate(templateDir, abs, out); err != nil {
+			return err
+		}
+	}
+
+	// Register this h2 directory in the routes registry last, so a failed
+	// init doesn't leave a route behind (pre-flight check already passed).
+	resolvedPrefix, err := config.RegisterRouteWithAutoPrefix(rootDir, explicitPrefix, abs)
+	if err != nil {
+		return fmt.Errorf("register route: %w", err)
+	}
+
 	fmt.Fprintf(out, "  Registered route (prefix: %s)\n", resolvedPrefix)
 	fmt.Fprintf(out, "Initialized h2 directory at %s (prefix: %s)\n", abs, resolvedPrefix)
 	return nil
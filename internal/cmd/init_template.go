@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"h2/internal/config"
+	"h2/internal/git"
+)
+
+// initTemplateSkipped are top-level template entries that are never copied:
+// h2 writes the marker and the route itself, and the rest is runtime state.
+var initTemplateSkipped = map[string]bool{
+	".git":         true,
+	".h2-dir.txt":  true,
+	"routes.jsonl": true,
+	"sessions":     true,
+	"sockets":      true,
+	"worktrees":    true,
+	"logs":         true,
+}
+
+// fetchInitTemplate returns a directory holding the template at src: src
+// itself when it is a local directory, otherwise a shallow clone of the git
+// repository at src in a temp dir, which cleanup removes.
+func fetchInitTemplate(src string) (dir string, cleanup func(), err error) {
+	if info, err := os.Stat(src); err == nil {
+		if !info.IsDir() {
+			return "", nil, fmt.Errorf("template %s is not a directory", src)
+		}
+		abs, err := filepath.Abs(src)
+		if err != nil {
+			return "", nil, err
+		}
+		return abs, func() {}, nil
+	}
+
+	tmp, err := os.MkdirTemp("", "h2-init-template-")
+	if err != nil {
+		return "", nil, fmt.Errorf("create template dir: %w", err)
+	}
+	cleanup = func() { os.RemoveAll(tmp) }
+	dir = filepath.Join(tmp, "template")
+	if err := git.Clone(src, dir); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("fetch template (not a local directory, so tried it as a git URL): %w", err)
+	}
+	return dir, cleanup, nil
+}
+
+// checkInitTemplate checks that the template in dir can set up an h2
+// directory: it needs a roles/ dir whose roles all load, and its
+// config.yaml, if it has one, must load too.
+func checkInitTemplate(dir string) error {
+	var problems []string
+
+	rolesDir := filepath.Join(dir, "roles")
+	listings, err := config.ListRoleFilesIn(rolesDir)
+	switch {
+	case err != nil:
+		problems = append(problems, fmt.Sprintf("roles/: %v", err))
+	case len(listings) == 0:
+		problems = append(problems, "roles/: missing or has no role files; a template needs at least one role")
+	}
+	for _, l := range listings {
+		if l.Err != nil {
+			problems = append(problems, fmt.Sprintf("roles/%s: %v", l.Name, l.Err))
+		}
+	}
+
+	configPath := filepath.Join(dir, "config.yaml")
+	if _, err := os.Stat(configPath); err == nil {
+		if _, err := config.LoadFrom(configPath); err != nil {
+			problems = append(problems, fmt.Sprintf("config.yaml: %v", err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("not usable; nothing was written:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// copyInitTemplate copies the template in src into the h2 directory abs,
+// replacing whatever the built-in scaffold wrote at the same paths.
+// Symlinks are copied as symlinks.
+func copyInitTemplate(src, abs string, out io.Writer) error {
+	copied := 0
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if !strings.Contains(rel, string(filepath.Separator)) && initTemplateSkipped[rel] {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		dst := filepath.Join(abs, rel)
+
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if err := os.RemoveAll(dst); err != nil {
+				return err
+			}
+			if err := os.Symlink(target, dst); err != nil {
+				return err
+			}
+		case d.IsDir():
+			// A scaffolded symlink where the template has a real dir is
+			// replaced by the dir.
+			if info, err := os.Lstat(dst); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+				if err := os.Remove(dst); err != nil {
+					return err
+				}
+			}
+			return os.MkdirAll(dst, 0o755)
+		default:
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			// Remove first so a scaffolded symlink is replaced rather than
+			// written through.
+			if err := os.RemoveAll(dst); err != nil {
+				return err
+			}
+			if err := os.WriteFile(dst, data, info.Mode().Perm()); err != nil {
+				return err
+			}
+		}
+		copied++
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("copy template: %w", err)
+	}
+	fmt.Fprintf(out, "  Copied %d file(s) from template\n", copied)
+	return nil
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"h2/internal/config"
+)
+
+// writeInitTemplate writes a template h2 dir with the given files and
+// returns its path.
+func writeInitTemplate(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestInitCmd_FromTemplate(t *testing.T) {
+	fakeHome := setupFakeHome(t)
+	tmplDir := writeInitTemplate(t, map[string]string{
+		"roles/base.yaml":  "role_name: base\ndescription: Team base role\n",
+		"roles/coder.yaml": "inherits: base\nrole_name: coder\n",
+		"config.yaml":      "messages:\n  inline_max_length: 500\n",
+		"profiles-shared/default/CLAUDE_AND_AGENTS.md": "# Team instructions\n",
+		".git/HEAD":    "ref: refs/heads/main\n",
+		"routes.jsonl": `{"prefix":"stale","path":"/nowhere"}` + "\n",
+		"sessions/old-agent/session.metadata.json": "{}",
+	})
+
+	dir := filepath.Join(fakeHome, "team")
+	cmd := newInitCmd()
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetArgs([]string{dir, "--from-template", tmplDir})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("init --from-template: %v\n%s", err, buf.String())
+	}
+
+	if !config.IsH2Dir(dir) {
+		t.Error("expected h2 to write the marker")
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "config.yaml"))
+	if err != nil || !strings.Contains(string(data), "inline_max_length: 500") {
+		t.Errorf("config.yaml should come from the template, got %q (%v)", data, err)
+	}
+	// The template's instructions replace the built-in ones, and the
+	// scaffolded symlinks to them still resolve.
+	data, err = os.ReadFile(filepath.Join(dir, "claude-config", "default", "CLAUDE.md"))
+	if err != nil || string(data) != "# Team instructions\n" {
+		t.Errorf("CLAUDE.md = %q (%v), want the template's instructions", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "roles", "coder.yaml")); err != nil {
+		t.Errorf("expected template role to be copied: %v", err)
+	}
+	for _, skipped := range []string{".git", "routes.jsonl", "sessions/old-agent"} {
+		if _, err := os.Stat(filepath.Join(dir, skipped)); !os.IsNotExist(err) {
+			t.Errorf("%s should not be copied from the template (err %v)", skipped, err)
+		}
+	}
+
+	routes, err := config.ReadRoutes(filepath.Join(fakeHome, ".h2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 1 || routes[0].Prefix != "team" {
+		t.Errorf("routes = %+v, want just team", routes)
+	}
+}
+
+func TestInitCmd_FromTemplate_MissingPiecesWritesNothing(t *testing.T) {
+	fakeHome := setupFakeHome(t)
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			name:  "no roles",
+			files: map[string]string{"config.yaml": "{}\n"},
+			want:  []string{"roles/: missing or has no role files"},
+		},
+		{
+			name: "broken role and config",
+			files: map[string]string{
+				"roles/coder.yaml": "role_name: [coder\n",
+				"config.yaml":      "messages:\n  inline_max_length: -1\n",
+			},
+			want: []string{"roles/coder: parse role YAML", "config.yaml: messages.inline_max_length must be positive"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(fakeHome, strings.ReplaceAll(tt.name, " ", "-"))
+			cmd := newInitCmd()
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetArgs([]string{dir, "--from-template", writeInitTemplate(t, tt.files)})
+			err := cmd.Execute()
+			if err == nil {
+				t.Fatal("expected init to fail")
+			}
+			for _, want := range append(tt.want, "nothing was written") {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error missing %q:\n%v", want, err)
+				}
+			}
+			if _, err := os.Stat(dir); !os.IsNotExist(err) {
+				t.Errorf("expected %s not to be created (err %v)", dir, err)
+			}
+		})
+	}
+}
+
+func TestInitCmd_FromTemplate_CopyFailureRegistersNoRoute(t *testing.T) {
+	fakeHome := setupFakeHome(t)
+	tmplDir, err := os.MkdirTemp("/tmp", "h2t-tpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tmplDir) })
+	if err := os.MkdirAll(filepath.Join(tmplDir, "roles"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(tmplDir, "roles", "coder.yaml"), []byte("role_name: coder\n"), 0o644)
+	// A socket passes the template checks but can't be copied.
+	ln, err := net.Listen("unix", filepath.Join(tmplDir, "sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	cmd := newInitCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{filepath.Join(fakeHome, "team"), "--from-template", tmplDir})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "copy template") {
+		t.Fatalf("expected a copy error, got %v", err)
+	}
+
+	routes, err := config.ReadRoutes(filepath.Join(fakeHome, ".h2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 0 {
+		t.Errorf("routes = %+v, want none after a failed init", routes)
+	}
+}
+
+func TestInitCmd_FromTemplate_GitURL(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	fakeHome := setupFakeHome(t)
+	repo := writeInitTemplate(t, map[string]string{"roles/coder.yaml": "role_name: coder\n"})
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"-c", "user.email=test@test.com", "-c", "user.name=Test", "add", "."},
+		{"-c", "user.email=test@test.com", "-c", "user.name=Test", "commit", "--quiet", "-m", "template"},
+	} {
+		c := exec.Command("git", args...)
+		c.Dir = repo
+		if out, err := c.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	dir := filepath.Join(fakeHome, "cloned")
+	cmd := newInitCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{dir, "--from-template", "file://" + repo})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("init from git template: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "roles", "coder.yaml")); err != nil {
+		t.Errorf("expected the cloned role: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); !os.IsNotExist(err) {
+		t.Errorf("the template's .git should not be copied (err %v)", err)
+	}
+}
+
+func TestInitCmd_FromTemplate_NotFound(t *testing.T) {
+	fakeHome := setupFakeHome(t)
+	dir := filepath.Join(fakeHome, "team")
+	cmd := newInitCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{dir, "--from-template", filepath.Join(fakeHome, "no-such-template")})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "fetch template") {
+		t.Fatalf("expected fetch error, got %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected %s not to be created (err %v)", dir, err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
 
 	"h2/internal/config"
@@ -368,6 +369,50 @@ func TestInitCmd_AutoIncrementPrefix(t *testing.T) {
 	}
 }
 
+func TestInitCmd_ConcurrentInitsGetDistinctPrefixes(t *testing.T) {
+	fakeHome := setupFakeHome(t)
+	rootDir := filepath.Join(fakeHome, ".h2")
+
+	// Both dirs are named "project", so both want the same prefix.
+	dirs := []string{
+		filepath.Join(fakeHome, "a", "project"),
+		filepath.Join(fakeHome, "b", "project"),
+	}
+	errs := make([]error, len(dirs))
+	var wg sync.WaitGroup
+	for i, dir := range dirs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cmd := newInitCmd()
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetArgs([]string{dir})
+			errs[i] = cmd.Execute()
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("init %s: %v", dirs[i], err)
+		}
+	}
+
+	routes, err := config.ReadRoutes(rootDir)
+	if err != nil {
+		t.Fatalf("ReadRoutes: %v", err)
+	}
+	if len(routes) != 2 {
+		t.Fatalf("expected 2 routes, got %+v", routes)
+	}
+	prefixes := map[string]string{}
+	for _, r := range routes {
+		prefixes[r.Prefix] = r.Path
+	}
+	if len(prefixes) != 2 || prefixes["project"] == "" || prefixes["project-2"] == "" {
+		t.Errorf("expected prefixes project and project-2, got %+v", routes)
+	}
+}
+
 func TestInitCmd_RootInit(t *testing.T) {
 	fakeHome := setupFakeHome(t)
 	rootDir := filepath.Join(fakeHome, ".h2")
@@ -849,21 +849,31 @@ func queryBridge(sockPath string) *message.BridgeInfo {
 	return resp.Bridge
 }
 
-// queryAgent connects to a socket path and queries agent status.
+// queryAgent connects to a socket path and queries agent status, returning
+// nil on any error.
 func queryAgent(sockPath string) *message.AgentInfo {
+	info, _ := fetchAgentStatus(sockPath)
+	return info
+}
+
+// fetchAgentStatus connects to a socket path and queries agent status.
+func fetchAgentStatus(sockPath string) (*message.AgentInfo, error) {
 	conn, err := net.DialTimeout("unix", sockPath, 2*time.Second)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 	defer conn.Close()
 
 	if err := message.SendRequest(conn, &message.Request{Type: "status"}); err != nil {
-		return nil
+		return nil, fmt.Errorf("send status request: %w", err)
 	}
 
 	resp, err := message.ReadResponse(conn)
-	if err != nil || !resp.OK {
-		return nil
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if !resp.OK {
+		return nil, fmt.Errorf("status: %s", resp.Error)
 	}
-	return resp.Agent
+	return resp.Agent, nil
 }
@@ -13,6 +13,7 @@ import (
 
 	"h2/internal/bridgeservice"
 	"h2/internal/config"
+	"h2/internal/session"
 	"h2/internal/session/message"
 	"h2/internal/socketdir"
 	"h2/internal/tmpl"
@@ -39,6 +40,7 @@ func newPodLaunchCmd() *cobra.Command {
 	var podName string
 	var detach bool
 	var dryRun bool
+	var force bool
 	var varFlags []string
 
 	cmd := &cobra.Command{
@@ -99,6 +101,15 @@ func newPodLaunchCmd() *cobra.Command {
 				return fmt.Errorf("template %q has no agents", templateName)
 			}
 
+			// Name agents the template left unnamed from their roles.
+			nameGen, err := session.LoadNameGenerator(config.NamesDir())
+			if err != nil {
+				return fmt.Errorf("load agent name word lists: %w", err)
+			}
+			if err := config.ResolvePodAgentNames(expanded, podCtx, nameGen.Generate, getExistingAgentNames()); err != nil {
+				return fmt.Errorf("template %q: %w", templateName, err)
+			}
+
 			if dryRun {
 				return podDryRun(templateName, pod, expanded, cliVars)
 			}
@@ -153,7 +164,7 @@ func newPodLaunchCmd() *cobra.Command {
 					}
 				}
 
-				if err := setupAndForkAgentQuiet(agent.Name, role, pod, i, overrideSlice); err != nil {
+				if err := setupAndForkAgentQuiet(agent.Name, role, pod, i, overrideSlice, force); err != nil {
 					return fmt.Errorf("start agent %q: %w", agent.Name, err)
 				}
 				fmt.Fprintf(os.Stderr, "  %s started\n", agent.Name)
@@ -197,6 +208,7 @@ func newPodLaunchCmd() *cobra.Command {
 	cmd.Flags().StringVar(&podName, "pod", "", "Override pod name (default: template's pod_name or template name)")
 	cmd.Flags().BoolVar(&detach, "detach", false, "Don't auto-attach after launching")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show resolved pod config without launching")
+	cmd.Flags().BoolVar(&force, "force", false, "Launch even if an agent's harness config dir isn't authenticated")
 	cmd.Flags().StringArrayVar(&varFlags, "var", nil, "Set template variable (key=value, repeatable)")
 
 	return cmd
@@ -259,7 +271,7 @@ func podLaunchBridges(bridges []config.PodBridge, pod string) error {
 			}
 		}
 
-		if err := forkBridgeFunc(pb.Bridge, pb.Concierge, pod); err != nil {
+		if err := forkBridgeFunc(pb.Bridge, pb.Concierge, pod, pb.MetricsAddr); err != nil {
 			fmt.Fprintf(os.Stderr, "  Warning: bridge %q failed to start: %v\n", pb.Bridge, err)
 			bridgesFailed = append(bridgesFailed, pb.Bridge)
 			continue
@@ -290,6 +302,11 @@ func podLaunchBridges(bridges []config.PodBridge, pod string) error {
 func podDryRun(templateName string, pod string, expanded []config.ExpandedAgent, cliVars map[string]string) error {
 	rootDir, _ := config.RootDir()
 	var resolved []*ResolvedAgentConfig
+	// Agents sharing a profiles list take turns, as they would at launch.
+	preview, err := config.NewProfilePreview()
+	if err != nil {
+		return err
+	}
 
 	for _, agent := range expanded {
 		roleName := agent.Role
@@ -331,6 +348,7 @@ func podDryRun(templateName string, pod string, expanded []config.ExpandedAgent,
 			}
 		}
 
+		preview.Select(role)
 		rc, err := resolveAgentConfig(agent.Name, role, pod, overrideSlice, nil)
 		if err != nil {
 			return fmt.Errorf("resolve agent %q: %w", agent.Name, err)
@@ -39,6 +39,7 @@ func setupPodTestEnv(t *testing.T) string {
 	os.MkdirAll(filepath.Join(h2Root, "pods"), 0o755)
 	os.MkdirAll(filepath.Join(h2Root, "sessions"), 0o755)
 	os.MkdirAll(filepath.Join(h2Root, "claude-config", "default"), 0o755)
+	writeClaudeAuth(t, filepath.Join(h2Root, "claude-config", "default"))
 	config.WriteMarker(h2Root)
 
 	t.Setenv("H2_DIR", h2Root)
@@ -481,7 +482,7 @@ func TestPodLaunchBridges_SkipsBridgeAlreadyRunningForSamePod(t *testing.T) {
 
 	origForkBridge := forkBridgeFunc
 	forkCalls := 0
-	forkBridgeFunc = func(bridgeName, concierge, pod string) error {
+	forkBridgeFunc = func(bridgeName, concierge, pod, metricsAddr string) error {
 		forkCalls++
 		return nil
 	}
@@ -499,6 +500,34 @@ func TestPodLaunchBridges_SkipsBridgeAlreadyRunningForSamePod(t *testing.T) {
 	}
 }
 
+func TestPodLaunchBridges_PassesMetricsAddr(t *testing.T) {
+	h2Root := setupPodTestEnv(t)
+	configYAML := `bridges:
+  personal:
+    macos_notify:
+      enabled: true
+`
+	if err := os.WriteFile(filepath.Join(h2Root, "config.yaml"), []byte(configYAML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	origForkBridge := forkBridgeFunc
+	var gotAddr string
+	forkBridgeFunc = func(bridgeName, concierge, pod, metricsAddr string) error {
+		gotAddr = metricsAddr
+		return nil
+	}
+	t.Cleanup(func() { forkBridgeFunc = origForkBridge })
+
+	bridges := []config.PodBridge{{Bridge: "personal", MetricsAddr: "127.0.0.1:9464"}}
+	if err := podLaunchBridges(bridges, "dev-pod"); err != nil {
+		t.Fatalf("podLaunchBridges returned error: %v", err)
+	}
+	if gotAddr != "127.0.0.1:9464" {
+		t.Errorf("fork metricsAddr = %q, want the pod entry's metrics_addr", gotAddr)
+	}
+}
+
 func TestPodLaunchBridges_FailsWhenBridgeAlreadyRunningStandalone(t *testing.T) {
 	h2Root := setupPodTestEnv(t)
 	sockDir := filepath.Join(h2Root, "sockets")
@@ -673,3 +702,129 @@ agents:
 		t.Fatal("expected auto-detached pod launch to skip tile attach")
 	}
 }
+
+func TestPodLaunchCmd_RoleProfilesRoundRobin(t *testing.T) {
+	h2Root := setupPodTestEnv(t)
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	for _, p := range []string{"acct-1", "acct-2"} {
+		dir := filepath.Join(h2Root, "claude-config", p)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		writeClaudeAuth(t, dir)
+	}
+
+	tmplContent := `pod_name: test
+agents:
+  - name: worker
+    role: coder
+    count: 3
+`
+	if err := os.WriteFile(filepath.Join(h2Root, "pods", "workers.yaml"), []byte(tmplContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	roleContent := "role_name: coder\nprofiles: [acct-1, acct-2]\ninstructions: |\n  test\n"
+	if err := os.WriteFile(filepath.Join(h2Root, "roles", "coder.yaml"), []byte(roleContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var forkSessionDirs []string
+	origFork := forkDaemonFunc
+	forkDaemonFunc = func(sessionDir string, hints session.TerminalHints, resume bool) error {
+		forkSessionDirs = append(forkSessionDirs, sessionDir)
+		return nil
+	}
+	t.Cleanup(func() { forkDaemonFunc = origFork })
+
+	cmd := newPodLaunchCmd()
+	cmd.SetArgs([]string{"workers", "--detach"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var profiles []string
+	for _, dir := range forkSessionDirs {
+		rc, err := config.ReadRuntimeConfig(dir)
+		if err != nil {
+			t.Fatalf("read runtime config: %v", err)
+		}
+		profiles = append(profiles, rc.Profile)
+	}
+	if want := "acct-1 acct-2 acct-1"; strings.Join(profiles, " ") != want {
+		t.Errorf("profiles = %v, want %s", profiles, want)
+	}
+}
+
+func TestPodLaunchCmd_RoleProfilesKeepExplicitProfile(t *testing.T) {
+	h2Root := setupPodTestEnv(t)
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	for _, p := range []string{"acct-1", "acct-2"} {
+		dir := filepath.Join(h2Root, "claude-config", p)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		writeClaudeAuth(t, dir)
+	}
+
+	tmplContent := `pod_name: test
+agents:
+  - name: worker
+    role: coder
+    count: 2
+  - name: pinned
+    role: coder
+    overrides:
+      profile: acct-2
+`
+	if err := os.WriteFile(filepath.Join(h2Root, "pods", "workers.yaml"), []byte(tmplContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	roleContent := "role_name: coder\nprofiles: [acct-1, acct-2]\ninstructions: |\n  test\n"
+	if err := os.WriteFile(filepath.Join(h2Root, "roles", "coder.yaml"), []byte(roleContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The dry run shows what the launch picks, without recording it.
+	out := captureStdout(func() {
+		cmd := newPodLaunchCmd()
+		cmd.SetArgs([]string{"workers", "--dry-run"})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("dry run: %v", err)
+		}
+	})
+	for _, want := range []string{
+		"Profile: acct-1 (round-robin over acct-1, acct-2)",
+		"Profile: acct-2 (round-robin over acct-1, acct-2)",
+		"Profile: acct-2 (set explicitly)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dry run output missing %q:\n%s", want, out)
+		}
+	}
+
+	var forkSessionDirs []string
+	origFork := forkDaemonFunc
+	forkDaemonFunc = func(sessionDir string, hints session.TerminalHints, resume bool) error {
+		forkSessionDirs = append(forkSessionDirs, sessionDir)
+		return nil
+	}
+	t.Cleanup(func() { forkDaemonFunc = origFork })
+
+	cmd := newPodLaunchCmd()
+	cmd.SetArgs([]string{"workers", "--detach"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var profiles []string
+	for _, dir := range forkSessionDirs {
+		rc, err := config.ReadRuntimeConfig(dir)
+		if err != nil {
+			t.Fatalf("read runtime config: %v", err)
+		}
+		profiles = append(profiles, rc.Profile)
+	}
+	if want := "acct-1 acct-2 acct-2"; strings.Join(profiles, " ") != want {
+		t.Errorf("profiles = %v, want %s", profiles, want)
+	}
+}
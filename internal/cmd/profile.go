@@ -214,6 +214,14 @@ func newProfileShowCmd() *cobra.Command {
 					fmt.Fprintf(out, "  Claude authenticated: %s\n", yesNo(auth))
 				}
 			}
+			if codexExists {
+				auth, err := config.IsCodexConfigAuthenticated(codexDir)
+				if err != nil {
+					fmt.Fprintf(out, "  Codex authenticated: error (%v)\n", err)
+				} else {
+					fmt.Fprintf(out, "  Codex authenticated: %s\n", yesNo(auth))
+				}
+			}
 			if err := printContentMeta(out, "profiles-shared/"+name, sharedDir); err != nil {
 				return err
 			}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"h2/internal/session/message"
+)
+
+func newResendCmd() *cobra.Command {
+	var last int
+	var agent string
+
+	cmd := &cobra.Command{
+		Use:   "resend [--last N] [--agent <name>]",
+		Short: "Re-send an agent's recent bridge messages",
+		Long: `Re-send the last N messages an agent sent to bridges, oldest first, each to
+the bridge it originally went to. Use it after a bridge was down to replay
+messages the human missed. Replays are prefixed with "(replay)".
+
+Runs for the current agent by default; use --agent to pick another.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if last <= 0 {
+				return fmt.Errorf("--last must be positive")
+			}
+			if agent == "" {
+				agent = os.Getenv("H2_ACTOR")
+			}
+			if agent == "" {
+				return fmt.Errorf("--agent is required outside an agent session")
+			}
+
+			resp, err := sendSocketRequest(agent, &message.Request{Type: "resend", Last: last})
+			if err != nil {
+				return err
+			}
+			if !resp.OK {
+				return fmt.Errorf("resend failed (%d re-sent): %s", resp.Resent, resp.Error)
+			}
+			fmt.Printf("Re-sent %d message(s).\n", resp.Resent)
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&last, "last", 1, "Number of recent messages to re-send")
+	cmd.Flags().StringVar(&agent, "agent", "", "Agent whose messages to re-send (default: the current agent)")
+
+	return cmd
+}
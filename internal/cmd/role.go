@@ -21,10 +21,15 @@ func newRoleCmd() *cobra.Command {
 	}
 
 	cmd.AddCommand(newRoleListCmd())
+	cmd.AddCommand(newRolePickCmd())
 	cmd.AddCommand(newRoleShowCmd())
 	cmd.AddCommand(newRoleCreateCmd())
 	cmd.AddCommand(newRoleUpdateCmd())
 	cmd.AddCommand(newRoleCheckCmd())
+	cmd.AddCommand(newRoleValidateCmd())
+	cmd.AddCommand(newRoleDiffCmd())
+	cmd.AddCommand(newRoleInheritsCmd())
+	cmd.AddCommand(newRoleSchemaCmd())
 	return cmd
 }
 
@@ -108,10 +113,19 @@ func newRoleShowCmd() *cobra.Command {
 			if role.CodexAskForApproval != "" {
 				fmt.Printf("Codex Ask For Approval: %s\n", role.CodexAskForApproval)
 			}
+			if len(role.AllowedTools) > 0 {
+				fmt.Printf("Allowed Tools: %s\n", strings.Join(role.AllowedTools, ", "))
+			}
+			if len(role.DeniedTools) > 0 {
+				fmt.Printf("Denied Tools: %s\n", strings.Join(role.DeniedTools, ", "))
+			}
 
 			if len(role.AdditionalDirs) > 0 {
 				fmt.Printf("Additional Dirs: %s\n", strings.Join(role.AdditionalDirs, ", "))
 			}
+			if role.SkillsDir != "" {
+				fmt.Printf("Skills Dir: %s\n", role.SkillsDir)
+			}
 
 			if instr := role.GetInstructions(); instr != "" {
 				fmt.Printf("\nInstructions:\n")
@@ -163,6 +177,9 @@ func printVariables(defs map[string]tmpl.VarDef, origins map[string]string) {
 		} else {
 			defVal = "(required)"
 		}
+		if def.Secret {
+			defVal += " (secret)"
+		}
 		origin := ""
 		if from := origins[name]; from != "" {
 			origin = fmt.Sprintf(" [from: %s]", from)
@@ -289,6 +306,31 @@ func newRoleUpdateCmd() *cobra.Command {
 	return cmd
 }
 
+func newRoleSchemaCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema for role YAML files",
+		Long: `Print a JSON Schema describing role YAML files, for use with editors.
+
+For example, with yaml-language-server:
+
+  h2 role schema > ~/.h2/role.schema.json
+
+then add this comment at the top of a role file:
+
+  # yaml-language-server: $schema=../role.schema.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := config.RoleJSONSchema()
+			if err != nil {
+				return fmt.Errorf("generate role schema: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+			return nil
+		},
+	}
+}
+
 func newRoleCheckCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "check <name>",
@@ -317,6 +359,9 @@ func newRoleCheckCmd() *cobra.Command {
 			if role.GetModel() != "" {
 				fmt.Printf("  Model:       %s\n", role.GetModel())
 			}
+			for _, w := range append(role.HarnessFieldWarnings(), role.HookWarnings()...) {
+				fmt.Printf("  Warning:     %s\n", w)
+			}
 			if role.PermissionReview != nil {
 				if role.PermissionReview.DCG != nil && role.PermissionReview.DCG.IsEnabled() {
 					fmt.Printf("  DCG: enabled\n")
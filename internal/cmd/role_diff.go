@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"h2/internal/config"
+)
+
+func newRoleDiffCmd() *cobra.Command {
+	var varFlags []string
+
+	cmd := &cobra.Command{
+		Use:   "diff <roleA> <roleB>",
+		Short: "Compare the effective config of two roles",
+		Long: `Render both roles (resolving inheritance and templates) and print every
+field whose effective value differs. Instructions are compared line by line.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			vars, err := parseVarFlags(varFlags)
+			if err != nil {
+				return err
+			}
+			a, _, err := config.LoadRoleForDisplayWithVars(args[0], vars)
+			if err != nil {
+				return fmt.Errorf("load role %q: %w", args[0], err)
+			}
+			b, _, err := config.LoadRoleForDisplayWithVars(args[1], vars)
+			if err != nil {
+				return fmt.Errorf("load role %q: %w", args[1], err)
+			}
+			return printRoleDiff(cmd.OutOrStdout(), args[0], args[1], a, b)
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&varFlags, "var", nil, "Set template variable for both roles (key=value, repeatable)")
+	return cmd
+}
+
+// printRoleDiff writes a field-by-field diff of two rendered roles.
+func printRoleDiff(w io.Writer, nameA, nameB string, a, b *config.Role) error {
+	fieldsA, err := config.FlattenRoleFields(a)
+	if err != nil {
+		return err
+	}
+	fieldsB, err := config.FlattenRoleFields(b)
+	if err != nil {
+		return err
+	}
+
+	keys := map[string]bool{}
+	for k := range fieldsA {
+		keys[k] = true
+	}
+	for k := range fieldsB {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", nameA, nameB)
+	changed := 0
+	for _, k := range sorted {
+		va, okA := fieldsA[k]
+		vb, okB := fieldsB[k]
+		if okA == okB && va == vb {
+			continue
+		}
+		changed++
+		fmt.Fprintf(w, "\n%s:\n", k)
+		if okA {
+			fmt.Fprintf(w, "  - %s\n", va)
+		}
+		if okB {
+			fmt.Fprintf(w, "  + %s\n", vb)
+		}
+	}
+
+	instrA, instrB := a.GetInstructions(), b.GetInstructions()
+	if instrA != instrB {
+		changed++
+		fmt.Fprintf(w, "\ninstructions:\n")
+		for _, line := range diffLines(splitInstructionLines(instrA), splitInstructionLines(instrB)) {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+
+	if changed == 0 {
+		fmt.Fprintf(w, "\nNo differences in effective config.\n")
+	}
+	return nil
+}
+
+func splitInstructionLines(s string) []string {
+	s = strings.TrimRight(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// diffLines returns a unified-style line diff of a and b: unchanged lines
+// are prefixed with two spaces, removed with "- " and added with "+ ".
+// Uses a longest-common-subsequence table, which is fine for the size of
+// role instructions.
+func diffLines(a, b []string) []string {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []string
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, "  "+a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, "- "+a[i])
+			i++
+		default:
+			out = append(out, "+ "+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		out = append(out, "- "+a[i])
+	}
+	for ; j < len(b); j++ {
+		out = append(out, "+ "+b[j])
+	}
+	return out
+}
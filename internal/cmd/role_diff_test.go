@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRoleDiffCmd_ShowsChangedFieldsAndInstructionLines(t *testing.T) {
+	h2Dir := setupRoleTestH2Dir(t)
+
+	os.WriteFile(filepath.Join(h2Dir, "roles", "base.yaml"), []byte(`
+role_name: base
+agent_model: sonnet
+claude_permission_mode: plan
+instructions: |
+  Line one
+  Line two
+`), 0o644)
+	os.WriteFile(filepath.Join(h2Dir, "roles", "child.yaml"), []byte(`
+role_name: child
+inherits: base
+agent_model: opus
+instructions: |
+  Line one
+  Line three
+`), 0o644)
+
+	output := captureStdout(func() {
+		cmd := newRoleDiffCmd()
+		cmd.SetArgs([]string{"base", "child"})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("role diff failed: %v", err)
+		}
+	})
+
+	checks := []string{
+		"--- base\n+++ child",
+		"agent_model:\n  - sonnet\n  + opus",
+		"instructions:\n    Line one\n  - Line two\n  + Line three",
+	}
+	for _, check := range checks {
+		if !strings.Contains(output, check) {
+			t.Fatalf("output should contain %q, got:\n%s", check, output)
+		}
+	}
+	// Inherited field is the same in both, so it should not be reported.
+	if strings.Contains(output, "claude_permission_mode:") {
+		t.Fatalf("unchanged inherited field should not be shown, got:\n%s", output)
+	}
+}
+
+func TestRoleDiffCmd_VarAppliesToBothRoles(t *testing.T) {
+	h2Dir := setupRoleTestH2Dir(t)
+
+	for _, name := range []string{"a", "b"} {
+		os.WriteFile(filepath.Join(h2Dir, "roles", name+".yaml.tmpl"), []byte(`
+role_name: `+name+`
+variables:
+  team:
+    description: "Team"
+instructions: |
+  Team {{ .Var.team }}
+`), 0o644)
+	}
+
+	output := captureStdout(func() {
+		cmd := newRoleDiffCmd()
+		cmd.SetArgs([]string{"a", "b", "--var", "team=platform"})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("role diff failed: %v", err)
+		}
+	})
+	if !strings.Contains(output, "No differences") {
+		t.Fatalf("expected no differences, got:\n%s", output)
+	}
+}
+
+func TestRoleDiffCmd_UnknownRole(t *testing.T) {
+	setupRoleTestH2Dir(t)
+
+	cmd := newRoleDiffCmd()
+	cmd.SetArgs([]string{"missing", "other"})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), `load role "missing"`) {
+		t.Fatalf("expected load error for missing role, got %v", err)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	got := diffLines([]string{"a", "b", "c"}, []string{"a", "c", "d"})
+	want := []string{"  a", "- b", "  c", "+ d"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("diffLines = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"h2/internal/config"
+)
+
+func newRoleInheritsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "inherits <name>",
+		Short: "Show a role's inheritance chain and variable origins",
+		Long: `Print the chain of roles a role inherits from (root parent first), and
+for every template variable, whether it can be set with --var on this role
+and which role in the chain defined it.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			meta, err := config.GetRoleInheritanceMetadata(args[0])
+			if err != nil {
+				return fmt.Errorf("load role %q: %w", args[0], err)
+			}
+			printRoleInherits(cmd.OutOrStdout(), args[0], meta)
+			return nil
+		},
+	}
+}
+
+// printRoleInherits renders inheritance metadata as an indented tree from
+// the root parent down to name, followed by the variables grouped by
+// whether the role exposes them.
+func printRoleInherits(w io.Writer, name string, meta *config.RoleInheritanceMetadata) {
+	if meta.DirectParent == "" {
+		fmt.Fprintf(w, "%s: standalone role (no inheritance)\n", name)
+		printVarOrigins(w, "Variables", meta.ExposedVarOrigins)
+		return
+	}
+
+	fmt.Fprintf(w, "Role:   %s\n", name)
+	fmt.Fprintf(w, "Parent: %s\n\n", meta.DirectParent)
+	for i, level := range meta.Chain {
+		if i == 0 {
+			fmt.Fprintln(w, level)
+			continue
+		}
+		fmt.Fprintf(w, "%s└─ %s\n", strings.Repeat("   ", i-1), level)
+	}
+
+	printVarOrigins(w, "Exposed variables (settable via --var)", meta.ExposedVarOrigins)
+	printVarOrigins(w, "Hidden variables (pinned by a parent)", meta.HiddenVarOrigins)
+}
+
+func printVarOrigins(w io.Writer, title string, origins map[string]string) {
+	if len(origins) == 0 {
+		return
+	}
+	names := make([]string, 0, len(origins))
+	for name := range origins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(w, "\n%s:\n", title)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-16s [from: %s]\n", name, origins[name])
+	}
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRoleInheritsCmd_PrintsChainAndVariableOrigins(t *testing.T) {
+	h2Dir := setupRoleTestH2Dir(t)
+
+	os.WriteFile(filepath.Join(h2Dir, "roles", "base.yaml.tmpl"), []byte(`
+role_name: base
+variables:
+  team:
+    description: "Team"
+  tone:
+    default: "terse"
+instructions: |
+  {{ .Var.team }} {{ .Var.tone }}
+`), 0o644)
+	os.WriteFile(filepath.Join(h2Dir, "roles", "mid.yaml"), []byte(`
+role_name: mid
+inherits: base
+`), 0o644)
+	os.WriteFile(filepath.Join(h2Dir, "roles", "leaf.yaml.tmpl"), []byte(`
+role_name: leaf
+inherits: mid
+variables:
+  team:
+    default: "platform"
+`), 0o644)
+
+	output := captureStdout(func() {
+		cmd := newRoleInheritsCmd()
+		cmd.SetArgs([]string{"leaf"})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("role inherits failed: %v", err)
+		}
+	})
+
+	checks := []string{
+		"Parent: mid\n",
+		"base\n└─ mid\n   └─ leaf\n",
+		"Exposed variables (settable via --var):\n  team             [from: leaf]\n",
+		"Hidden variables (pinned by a parent):\n  tone             [from: base]\n",
+	}
+	for _, check := range checks {
+		if !strings.Contains(output, check) {
+			t.Fatalf("output should contain %q, got:\n%s", check, output)
+		}
+	}
+}
+
+func TestRoleInheritsCmd_StandaloneRole(t *testing.T) {
+	h2Dir := setupRoleTestH2Dir(t)
+	os.WriteFile(filepath.Join(h2Dir, "roles", "solo.yaml"), []byte("role_name: solo\ninstructions: hi\n"), 0o644)
+
+	output := captureStdout(func() {
+		cmd := newRoleInheritsCmd()
+		cmd.SetArgs([]string{"solo"})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("role inherits failed: %v", err)
+		}
+	})
+	if output != "solo: standalone role (no inheritance)\n" {
+		t.Fatalf("unexpected output:\n%s", output)
+	}
+}
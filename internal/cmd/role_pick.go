@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"h2/internal/config"
+	s "h2/internal/termstyle"
+	"h2/internal/tmpl"
+)
+
+func newRolePickCmd() *cobra.Command {
+	var detach bool
+
+	cmd := &cobra.Command{
+		Use:   "pick [name]",
+		Short: "Pick a role from a list and start an agent with it",
+		Long: `Lists the available roles with their descriptions, lets you pick one,
+asks for the role's template variables, then starts an agent with it the
+same way "h2 run --role <role> --var ..." does.
+
+Roles that fail to load are listed greyed out with their error and can't
+be picked.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			listings, err := config.ListRoleFiles()
+			if err != nil {
+				return err
+			}
+			if len(listings) == 0 {
+				return fmt.Errorf("no roles found in %s; create one with 'h2 role create <name>'", config.RolesDir())
+			}
+
+			in := bufio.NewReader(cmd.InOrStdin())
+			out := cmd.OutOrStdout()
+			picked, err := pickRole(in, out, listings)
+			if err != nil {
+				return err
+			}
+
+			var readSecret func() (string, error)
+			if fd := int(os.Stdin.Fd()); cmd.InOrStdin() == os.Stdin && term.IsTerminal(fd) {
+				readSecret = func() (string, error) {
+					b, err := term.ReadPassword(fd)
+					fmt.Fprintln(out)
+					return string(b), err
+				}
+			}
+			// The display load sees variables inherited from parent roles too.
+			defs := picked.Role.Variables
+			if _, allDefs, err := config.LoadRoleForDisplay(picked.Name); err == nil {
+				defs = allDefs
+			}
+			vars, err := promptRoleVars(in, out, defs, readSecret)
+			if err != nil {
+				return err
+			}
+
+			runArgs := append([]string{}, args...)
+			runArgs = append(runArgs, "--role", picked.Name)
+			for _, k := range sortedKeys(vars) {
+				runArgs = append(runArgs, "--var", k+"="+vars[k])
+			}
+			if detach {
+				runArgs = append(runArgs, "--detach")
+			}
+			run := newRunCmd()
+			run.SetArgs(runArgs)
+			return run.Execute()
+		},
+	}
+
+	cmd.Flags().BoolVar(&detach, "detach", false, "Don't auto-attach after starting")
+
+	return cmd
+}
+
+// errNoRolePicked is returned when input ends before a role is picked.
+var errNoRolePicked = errors.New("no role picked")
+
+// pickRole lists the roles with numbers for the ones that loaded and reads
+// the user's choice from in, asking again until it is valid. A typed name is
+// matched against the role's file name, which is what --role resolves.
+func pickRole(in *bufio.Reader, out io.Writer, listings []config.RoleListing) (config.RoleListing, error) {
+	var choices []config.RoleListing
+	fmt.Fprintf(out, "Roles in %s:\n", config.RolesDir())
+	for _, l := range listings {
+		if l.Err != nil {
+			fmt.Fprintf(out, "  %s\n", s.Dim(fmt.Sprintf("  -  %-16s failed to load: %v", l.Name, l.Err)))
+			continue
+		}
+		choices = append(choices, l)
+		desc := l.Role.Description
+		if desc == "" {
+			desc = s.Dim("(no description)")
+		}
+		fmt.Fprintf(out, "  %3d  %-16s %s\n", len(choices), l.Name, desc)
+	}
+	if len(choices) == 0 {
+		return config.RoleListing{}, fmt.Errorf("none of the roles in %s load; fix them or check with 'h2 role check <name>'", config.RolesDir())
+	}
+
+	for {
+		fmt.Fprintf(out, "Pick a role [1-%d]: ", len(choices))
+		line, err := readLine(in)
+		if err != nil {
+			return config.RoleListing{}, errNoRolePicked
+		}
+		n, convErr := strconv.Atoi(line)
+		if convErr == nil && n >= 1 && n <= len(choices) {
+			return choices[n-1], nil
+		}
+		for _, l := range choices {
+			if l.Name == line {
+				return l, nil
+			}
+		}
+		fmt.Fprintf(out, "Enter a number from 1 to %d or a role name.\n", len(choices))
+	}
+}
+
+// promptRoleVars asks for each template variable in defs, in name order,
+// showing its description and default. An empty answer keeps the default;
+// required variables are asked for again until answered. Secret variables
+// are read with readSecret when it is non-nil. Returns only the variables
+// that were answered.
+func promptRoleVars(in *bufio.Reader, out io.Writer, defs map[string]tmpl.VarDef, readSecret func() (string, error)) (map[string]string, error) {
+	vars := map[string]string{}
+	for _, name := range sortedKeys(defs) {
+		def := defs[name]
+		label := name
+		if def.Description != "" {
+			label = fmt.Sprintf("%s (%s)", name, def.Description)
+		}
+		switch {
+		case def.Required():
+			label += " [required]"
+		case def.Secret:
+			label += " [default: " + tmpl.RedactedValue + "]"
+		default:
+			label += fmt.Sprintf(" [default: %s]", *def.Default)
+		}
+
+		for {
+			fmt.Fprintf(out, "%s: ", label)
+			var value string
+			var err error
+			if def.Secret && readSecret != nil {
+				value, err = readSecret()
+				value = strings.TrimSpace(value)
+			} else {
+				value, err = readLine(in)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("read variable %q: %w", name, err)
+			}
+			if value != "" {
+				vars[name] = value
+				break
+			}
+			if !def.Required() {
+				break
+			}
+			fmt.Fprintf(out, "%s is required.\n", name)
+		}
+	}
+	return vars, nil
+}
+
+// readLine reads one trimmed line from in. A last line without a newline is
+// returned as is; io.EOF is only returned when nothing was read.
+func readLine(in *bufio.Reader) (string, error) {
+	line, err := in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"h2/internal/config"
+	"h2/internal/tmpl"
+)
+
+func TestPickRole_ListsBrokenRolesAndSkipsThemWhenNumbering(t *testing.T) {
+	setupRoleTestH2Dir(t)
+	listings := []config.RoleListing{
+		{Name: "broken", Err: errors.New("yaml: line 3: bad indentation")},
+		{Name: "coder", Role: &config.Role{RoleName: "coder", Description: "Writes code"}},
+		{Name: "reviewer", Role: &config.Role{RoleName: "reviewer"}},
+	}
+
+	var out bytes.Buffer
+	picked, err := pickRole(bufio.NewReader(strings.NewReader("2\n")), &out, listings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if picked.Name != "reviewer" {
+		t.Errorf("picked %q, want reviewer", picked.Name)
+	}
+	for _, want := range []string{
+		"broken           failed to load: yaml: line 3: bad indentation",
+		"1  coder            Writes code",
+		"2  reviewer         (no description)",
+		"Pick a role [1-2]: ",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestPickRole_ByNameAfterInvalidInput(t *testing.T) {
+	setupRoleTestH2Dir(t)
+	listings := []config.RoleListing{
+		{Name: "coder", Role: &config.Role{RoleName: "coder"}},
+		// role_name differs from the file name, which is what --role takes.
+		{Name: "reviewer", Role: &config.Role{RoleName: "code-reviewer"}},
+	}
+
+	var out bytes.Buffer
+	picked, err := pickRole(bufio.NewReader(strings.NewReader("7\ncode-reviewer\nreviewer")), &out, listings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if picked.Name != "reviewer" || picked.Role.RoleName != "code-reviewer" {
+		t.Errorf("picked %q (%q), want the reviewer file", picked.Name, picked.Role.RoleName)
+	}
+	if n := strings.Count(out.String(), "Enter a number from 1 to 2 or a role name."); n != 2 {
+		t.Errorf("got %d retry hints, want 2:\n%s", n, out.String())
+	}
+
+	_, err = pickRole(bufio.NewReader(strings.NewReader("")), &out, listings)
+	if !errors.Is(err, errNoRolePicked) {
+		t.Errorf("expected errNoRolePicked at end of input, got %v", err)
+	}
+}
+
+func TestPickRole_NoLoadableRoles(t *testing.T) {
+	setupRoleTestH2Dir(t)
+	listings := []config.RoleListing{{Name: "broken", Err: errors.New("bad")}}
+
+	_, err := pickRole(bufio.NewReader(strings.NewReader("1\n")), &bytes.Buffer{}, listings)
+	if err == nil || !strings.Contains(err.Error(), "none of the roles") {
+		t.Fatalf("expected no loadable roles error, got %v", err)
+	}
+}
+
+func TestPromptRoleVars(t *testing.T) {
+	def := "main"
+	defs := map[string]tmpl.VarDef{
+		"team":   {Description: "Team name"},
+		"branch": {Description: "Base branch", Default: &def},
+		"token":  {Secret: true, Default: &def},
+	}
+
+	// branch keeps its default, team is asked twice, token is read as a
+	// secret.
+	in := bufio.NewReader(strings.NewReader("\n\nplatform\n"))
+	var out bytes.Buffer
+	vars, err := promptRoleVars(in, &out, defs, func() (string, error) { return "s3cret\n", nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vars) != 2 || vars["team"] != "platform" || vars["token"] != "s3cret" {
+		t.Errorf("vars = %v", vars)
+	}
+	for _, want := range []string{
+		"branch (Base branch) [default: main]: ",
+		"team (Team name) [required]: team is required.",
+		"token [default: ***]: ",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestPromptRoleVars_EndOfInput(t *testing.T) {
+	defs := map[string]tmpl.VarDef{"team": {}}
+	_, err := promptRoleVars(bufio.NewReader(strings.NewReader("")), &bytes.Buffer{}, defs, nil)
+	if err == nil || !strings.Contains(err.Error(), `read variable "team"`) {
+		t.Fatalf("expected read error, got %v", err)
+	}
+}
+
+func TestRolePickCmd_NoRoles(t *testing.T) {
+	h2Dir := setupRoleTestH2Dir(t)
+	os.RemoveAll(filepath.Join(h2Dir, "roles"))
+
+	cmd := newRolePickCmd()
+	cmd.SetArgs(nil)
+	cmd.SetIn(strings.NewReader("1\n"))
+	cmd.SetOut(&bytes.Buffer{})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "no roles found") {
+		t.Fatalf("expected no roles error, got %v", err)
+	}
+}
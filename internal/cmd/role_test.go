@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
@@ -728,3 +729,21 @@ instructions: |
 		t.Error("old ${name} syntax should appear literally in instructions")
 	}
 }
+
+func TestRoleSchemaCmd_PrintsJSONSchema(t *testing.T) {
+	output := captureStdout(func() {
+		cmd := newRoleSchemaCmd()
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("role schema failed: %v", err)
+		}
+	})
+
+	var schema map[string]any
+	if err := json.Unmarshal([]byte(output), &schema); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, output)
+	}
+	props, _ := schema["properties"].(map[string]any)
+	if _, ok := props["role_name"]; !ok {
+		t.Fatalf("schema should describe role_name, got:\n%s", output)
+	}
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"h2/internal/config"
+)
+
+func newRoleValidateCmd() *cobra.Command {
+	var varFlags []string
+
+	cmd := &cobra.Command{
+		Use:   "validate <path>",
+		Short: "Validate a role file at any path",
+		Long: `Render and validate a role file that needn't be in the roles dir, e.g. one
+you're editing before moving it there. Works on .yaml and .yaml.tmpl files.
+If it inherits, parents are resolved from the roles dir. Every problem found
+is reported, not just the first; required variables can be set with --var.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			vars, err := parseVarFlags(varFlags)
+			if err != nil {
+				return err
+			}
+			check := config.CheckRoleFile(args[0], vars)
+			return printRoleFileCheck(cmd.OutOrStdout(), args[0], check)
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&varFlags, "var", nil, "Set template variable (key=value, repeatable)")
+	return cmd
+}
+
+// printRoleFileCheck writes the result of checking the role file at path,
+// returning an error if it has problems.
+func printRoleFileCheck(w io.Writer, path string, check *config.RoleFileCheck) error {
+	if len(check.Problems) > 0 {
+		fmt.Fprintf(w, "%s:\n", path)
+		for _, p := range check.Problems {
+			fmt.Fprintf(w, "  Problem:     %s\n", strings.ReplaceAll(p.Error(), "\n", "\n               "))
+		}
+		for _, warning := range check.Warnings {
+			fmt.Fprintf(w, "  Warning:     %s\n", warning)
+		}
+		noun := "problems"
+		if len(check.Problems) == 1 {
+			noun = "problem"
+		}
+		return fmt.Errorf("role file %s has %d %s", path, len(check.Problems), noun)
+	}
+
+	role := check.Role
+	fmt.Fprintf(w, "Role %q is valid.\n", role.RoleName)
+	if len(check.Chain) > 1 {
+		fmt.Fprintf(w, "  Inherits:    %s\n", check.Chain[len(check.Chain)-2])
+		fmt.Fprintf(w, "  Chain:       %s\n", strings.Join(check.Chain, " -> "))
+	}
+	fmt.Fprintf(w, "  Harness type: %s\n", role.GetHarnessType())
+	if role.GetModel() != "" {
+		fmt.Fprintf(w, "  Model:       %s\n", role.GetModel())
+	}
+	for _, warning := range check.Warnings {
+		fmt.Fprintf(w, "  Warning:     %s\n", warning)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRoleValidateCmd_FileOutsideRolesDir(t *testing.T) {
+	h2Dir := setupRoleTestH2Dir(t)
+	os.WriteFile(filepath.Join(h2Dir, "roles", "base.yaml"), []byte(`
+role_name: base
+agent_model: opus
+instructions: base
+`), 0o644)
+	path := filepath.Join(t.TempDir(), "child.yaml.tmpl")
+	os.WriteFile(path, []byte(`
+role_name: child
+inherits: base
+variables:
+  team:
+    description: "Team"
+instructions: |
+  Team {{ .Var.team }}
+`), 0o644)
+
+	var out bytes.Buffer
+	cmd := newRoleValidateCmd()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{path, "--var", "team=platform"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("role validate failed: %v\n%s", err, out.String())
+	}
+	for _, want := range []string{`Role "child" is valid.`, "Chain:       base -> child", "Model:       opus"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output should contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRoleValidateCmd_ReportsProblems(t *testing.T) {
+	setupRoleTestH2Dir(t)
+	path := filepath.Join(t.TempDir(), "worker.yaml.tmpl")
+	os.WriteFile(path, []byte(`
+role_name: worker
+variables:
+  team:
+    description: "Team"
+instructions: |
+  Team {{ .Var.team }}
+claude_permission_mode: sometimes
+`), 0o644)
+
+	var out bytes.Buffer
+	cmd := newRoleValidateCmd()
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{path})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "has 2 problems") {
+		t.Fatalf("error = %v, want 2 problems", err)
+	}
+	for _, want := range []string{"required variables not provided: team", `invalid claude_permission_mode "sometimes"`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output should contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
@@ -19,6 +19,11 @@ func NewRootCmd() *cobra.Command {
 			case "init", "version", "help", "completion":
 				return nil
 			}
+			// Matched on the full path so another "schema" subcommand
+			// doesn't skip the h2 dir check by accident.
+			if cmd.CommandPath() == "h2 role schema" {
+				return nil
+			}
 			_, err := config.ResolveDir()
 			return err
 		},
@@ -29,10 +34,12 @@ func NewRootCmd() *cobra.Command {
 		newRunCmd(),
 		newAttachCmd(),
 		newSendCmd(),
+		newResendCmd(),
 		listCmd,
 		newLsAlias(listCmd),
 		newShowCmd(),
 		newStatusCmd(),
+		newAgentCmd(),
 		newDaemonCmd(),
 		newWhoamiCmd(),
 		newBridgeCmd(),
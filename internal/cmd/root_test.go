@@ -1,11 +1,14 @@
 package cmd
 
 import (
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/spf13/cobra"
+
 	"h2/internal/config"
 )
 
@@ -65,3 +68,27 @@ func TestRootCmd_H2DIRValidation_VersionExempt(t *testing.T) {
 		t.Fatalf("version should be exempt from H2_DIR validation, got: %v", err)
 	}
 }
+
+func TestRootCmd_H2DIRValidation_RoleSchemaExempt(t *testing.T) {
+	config.ResetResolveCache()
+	t.Cleanup(config.ResetResolveCache)
+
+	setupFakeHome(t)
+	t.Setenv("H2_DIR", t.TempDir()) // no marker file
+
+	cmd := NewRootCmd()
+	cmd.SetOut(io.Discard)
+	cmd.SetArgs([]string{"role", "schema"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("role schema should be exempt from H2_DIR validation, got: %v", err)
+	}
+
+	// Only that command is exempt, not anything else named schema.
+	config.ResetResolveCache()
+	cmd = NewRootCmd()
+	cmd.AddCommand(&cobra.Command{Use: "schema", RunE: func(*cobra.Command, []string) error { return nil }})
+	cmd.SetArgs([]string{"schema"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "not an h2 directory") {
+		t.Errorf("other schema command: err = %v, want 'not an h2 directory'", err)
+	}
+}
@@ -22,6 +22,7 @@ func newRunCmd() *cobra.Command {
 	var name string
 	var detach bool
 	var dryRun bool
+	var force bool
 	var resume bool
 	var resumeFromSessionID string
 	var roleName string
@@ -145,6 +146,12 @@ By default, uses the "default" role from ~/.h2/roles/default.yaml.
 			}
 			name = positionalName
 
+			// Random names come from <h2-dir>/names/ word lists if present.
+			nameGen, err := session.LoadNameGenerator(config.NamesDir())
+			if err != nil {
+				return fmt.Errorf("load agent name word lists: %w", err)
+			}
+
 			if cmd.Flags().Changed("command") {
 				// command mode already resolved cmdCommand/cmdArgs above.
 			} else if cmd.Flags().Changed("agent-type") {
@@ -174,7 +181,7 @@ By default, uses the "default" role from ~/.h2/roles/default.yaml.
 
 				// Create name template functions with collision avoidance.
 				existingNames := getExistingAgentNames()
-				nameFuncs := tmpl.NameFuncs(session.GenerateName, existingNames)
+				nameFuncs := tmpl.NameFuncs(nameGen.Generate, existingNames)
 
 				// Load the role with two-pass agent name resolution.
 				var role *config.Role
@@ -185,7 +192,7 @@ By default, uses the "default" role from ~/.h2/roles/default.yaml.
 						if dryRun {
 							agentName = dryRunAgentNamePlaceholder
 						} else {
-							agentName = session.GenerateName()
+							agentName = nameGen.Generate()
 						}
 					}
 					ctx.AgentName = agentName
@@ -199,7 +206,7 @@ By default, uses the "default" role from ~/.h2/roles/default.yaml.
 						resolvedCLIName = dryRunAgentNamePlaceholder
 					}
 					role, name, err = config.LoadRoleWithNameResolution(
-						rolePath, ctx, nameFuncs, resolvedCLIName, session.GenerateName,
+						rolePath, ctx, nameFuncs, resolvedCLIName, nameGen.Generate,
 					)
 				}
 				if err != nil {
@@ -213,12 +220,20 @@ By default, uses the "default" role from ~/.h2/roles/default.yaml.
 					}
 					return fmt.Errorf("load role %q: %w", roleName, err)
 				}
+				for _, w := range append(role.HarnessFieldWarnings(), role.HookWarnings()...) {
+					fmt.Fprintf(os.Stderr, "Warning: role %q: %s\n", roleName, w)
+				}
 				if len(overrides) > 0 {
 					if err := config.ApplyOverrides(role, overrides); err != nil {
 						return fmt.Errorf("apply overrides: %w", err)
 					}
 				}
 				if dryRun {
+					preview, err := config.NewProfilePreview()
+					if err != nil {
+						return err
+					}
+					preview.Select(role)
 					rc, err := resolveAgentConfig(name, role, pod, overrides, nil)
 					if err != nil {
 						return err
@@ -226,7 +241,7 @@ By default, uses the "default" role from ~/.h2/roles/default.yaml.
 					printDryRun(rc)
 					return nil
 				}
-				return setupAndForkAgent(name, role, detach, pod, 0, overrides)
+				return setupAndForkAgent(name, role, detach, pod, 0, overrides, force)
 			}
 
 			// Agent-type or command mode: --dry-run requires a role.
@@ -236,7 +251,7 @@ By default, uses the "default" role from ~/.h2/roles/default.yaml.
 
 			// Agent-type or command mode: fork without a role.
 			if name == "" {
-				name = session.GenerateName()
+				name = nameGen.Generate()
 			}
 			if err := ensureAgentSocketAvailable(name); err != nil {
 				return err
@@ -299,6 +314,7 @@ By default, uses the "default" role from ~/.h2/roles/default.yaml.
 
 	cmd.Flags().BoolVar(&detach, "detach", false, "Don't auto-attach after starting")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show resolved config without launching")
+	cmd.Flags().BoolVar(&force, "force", false, "Launch even if the harness config dir isn't authenticated")
 	cmd.Flags().BoolVar(&resume, "resume", false, "Resume a previous agent session")
 	cmd.Flags().StringVar(&resumeFromSessionID, "resume-from-session-id", "", "Resume the h2 session with this underlying claude/codex session id (no name needed)")
 	cmd.Flags().StringVar(&roleName, "role", "", "Role to use (defaults to 'default')")
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
 
+	"h2/internal/bridge"
 	"h2/internal/session/message"
 	"h2/internal/socketdir"
 )
@@ -20,18 +22,27 @@ func newSendCmd() *cobra.Command {
 	var raw bool
 	var expectsResponse bool
 	var respondsTo string
+	var attach string
+	var refs []string
+	var urgency string
 
 	cmd := &cobra.Command{
-		Use:   "send [<name>] [--priority=normal] [--file=path] [--raw] [--expects-response] [--closes=<id>] [message...]",
+		Use:   "send [<name>] [--priority=normal] [--file=path] [--raw] [--expects-response] [--closes=<id>] [--attach=path] [--ref=path]... [--urgency=normal] [message...]",
 		Short: "Send a message to an agent",
 		Long: `Send a message to a running agent. The message body can be provided as arguments or read from a file.
 With --raw, the body is sent directly to the agent's PTY without the header prefix.
 With --expects-response, a reminder trigger is registered on the recipient that fires at idle.
-With --closes <id>, the reminder trigger is removed from your own daemon (and optionally a response is sent).`,
+With --closes <id>, the reminder trigger is removed from your own daemon (and optionally a response is sent).
+With --attach <path>, the file is uploaded through a bridge (e.g. to Telegram); the message, if any, is used as the caption.
+With --ref <path> (repeatable), the agent is told to read the file, whatever the message's length.
+With --urgency low|high, a message to a bridge is delivered quietly or loudly (e.g. a silent or flagged Telegram message); it is rejected for agents, --attach and --closes.`,
 		Args: cobra.MinimumNArgs(0),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// --closes mode: target and body are both optional.
 			if respondsTo != "" {
+				if cmd.Flags().Changed("urgency") {
+					return fmt.Errorf("--urgency cannot be combined with --closes; it only applies to messages sent to a bridge")
+				}
 				return handleCloses(respondsTo, args, file, priority, allowSelf)
 			}
 
@@ -41,6 +52,25 @@ With --closes <id>, the reminder trigger is removed from your own daemon (and op
 			}
 			name := args[0]
 
+			if attach != "" {
+				if raw || expectsResponse || cmd.Flags().Changed("urgency") {
+					return fmt.Errorf("--attach cannot be combined with --raw, --expects-response or --urgency")
+				}
+				return sendAttachment(name, attach, file, args[1:])
+			}
+
+			attachments, err := fileAttachments(refs)
+			if err != nil {
+				return err
+			}
+			urg, err := bridge.ParseUrgency(urgency)
+			if err != nil {
+				return err
+			}
+			if len(attachments) > 0 && raw {
+				return fmt.Errorf("--ref cannot be combined with --raw")
+			}
+
 			var body string
 			if file != "" {
 				data, err := os.ReadFile(file)
@@ -50,9 +80,12 @@ With --closes <id>, the reminder trigger is removed from your own daemon (and op
 				body = string(data)
 			} else if len(args) > 1 {
 				body = cleanLLMEscapes(strings.Join(args[1:], " "))
-			} else {
+			} else if len(attachments) == 0 {
 				return fmt.Errorf("message body is required (provide as arguments or --file)")
 			}
+			if err := checkSendBodySize(body); err != nil {
+				return err
+			}
 
 			if priority == "" {
 				priority = "normal"
@@ -66,6 +99,12 @@ With --closes <id>, the reminder trigger is removed from your own daemon (and op
 				}
 			}
 
+			if cmd.Flags().Changed("urgency") {
+				if sockPath, err := socketdir.Find(name); err == nil && !isBridgeSocket(sockPath) {
+					return fmt.Errorf("--urgency only applies to messages sent to a bridge; %s is an agent", name)
+				}
+			}
+
 			// Register trigger first for expects-response so we have the
 			// confirmed ID before sending the message annotation.
 			var triggerID string
@@ -89,13 +128,20 @@ With --closes <id>, the reminder trigger is removed from your own daemon (and op
 				removeTriggerBestEffort(name, triggerID)
 				return agentConnError(name, err)
 			}
+			var urgencyField string
+			if urg != bridge.UrgencyNormal {
+				urgencyField = string(urg)
+			}
+			recordBridgeSendBestEffort(sockPath, body, urgencyField)
 
 			req := &message.Request{
-				Type:     "send",
-				Priority: priority,
-				From:     from,
-				Body:     body,
-				Raw:      raw,
+				Type:        "send",
+				Priority:    priority,
+				From:        from,
+				Body:        body,
+				Raw:         raw,
+				Attachments: attachments,
+				Urgency:     urgencyField,
 			}
 			if expectsResponse {
 				req.ExpectsResponse = true
@@ -135,10 +181,50 @@ With --closes <id>, the reminder trigger is removed from your own daemon (and op
 	cmd.Flags().BoolVar(&raw, "raw", false, "Send body directly to PTY without header prefix (useful for permission prompts)")
 	cmd.Flags().BoolVar(&expectsResponse, "expects-response", false, "Register an idle reminder trigger on the recipient")
 	cmd.Flags().StringVar(&respondsTo, "closes", "", "Close a reminder trigger by ID (and optionally send a response)")
+	cmd.Flags().StringVar(&attach, "attach", "", "Upload a file through a bridge; the message becomes the caption")
+	cmd.Flags().StringArrayVar(&refs, "ref", nil, "Reference a file for the agent to read (repeatable)")
+	cmd.Flags().StringVar(&urgency, "urgency", "normal", "Notification urgency for bridges (low|normal|high)")
 
 	return cmd
 }
 
+// sendAttachment asks a bridge to upload a local file. The caption comes
+// from captionFile if set, otherwise from the remaining args.
+func sendAttachment(bridgeName, path, captionFile string, captionArgs []string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("resolve attachment path: %w", err)
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return fmt.Errorf("attachment: %w", err)
+	}
+
+	var caption string
+	if captionFile != "" {
+		data, err := os.ReadFile(captionFile)
+		if err != nil {
+			return fmt.Errorf("read file: %w", err)
+		}
+		caption = string(data)
+	} else {
+		caption = cleanLLMEscapes(strings.Join(captionArgs, " "))
+	}
+
+	resp, err := sendSocketRequest(bridgeName, &message.Request{
+		Type:     "send-file",
+		From:     resolveActor(),
+		Body:     caption,
+		FilePath: abs,
+	})
+	if err != nil {
+		return err
+	}
+	if !resp.OK {
+		return fmt.Errorf("send file failed: %s", resp.Error)
+	}
+	return nil
+}
+
 // registerExpectsResponseTrigger registers an idle reminder trigger on the
 // recipient's daemon. Retries once on ID collision. Returns the final trigger
 // ID used (which may differ from the input on collision retry).
@@ -168,8 +254,9 @@ func registerExpectsResponseTrigger(agentName, sender, triggerID string) (string
 		return "", err
 	}
 	if !resp.OK {
-		// Check if collision — retry once with new ID.
-		if strings.Contains(resp.Error, "already exists") {
+		// Check if collision — retry once with new ID. Daemons started before
+		// error codes existed only say so in the message.
+		if resp.Code == message.ErrCodeAlreadyExists || strings.Contains(resp.Error, "already exists") {
 			newID := genShortID()
 			spec = buildSpec(newID)
 			resp2, err2 := sendSocketRequest(agentName, &message.Request{
@@ -206,6 +293,46 @@ func removeTriggerBestEffort(agentName, triggerID string) {
 	_ = resp
 }
 
+// checkSendBodySize refuses a body the agent would reject as too large,
+// before anything is sent or recorded.
+func checkSendBodySize(body string) error {
+	if err := message.CheckBodySize(body); err != nil {
+		return fmt.Errorf("%w; save it to a file and pass the file with --ref instead", err)
+	}
+	return nil
+}
+
+// recordBridgeSendBestEffort records body in the sending agent's outbox if
+// sockPath is a bridge's socket. It is called before the message is sent,
+// so messages the bridge fails to deliver can still be resent.
+func recordBridgeSendBestEffort(sockPath, body, urgency string) {
+	if entry, ok := socketdir.Parse(filepath.Base(sockPath)); ok && entry.Type == socketdir.TypeBridge {
+		recordOutboxBestEffort(entry.Name, body, urgency)
+	}
+}
+
+// isBridgeSocket reports whether sockPath is a bridge's socket.
+func isBridgeSocket(sockPath string) bool {
+	entry, ok := socketdir.Parse(filepath.Base(sockPath))
+	return ok && entry.Type == socketdir.TypeBridge
+}
+
+// recordOutboxBestEffort records a message sent to a bridge in the sending
+// agent's outbox, so h2 resend can replay it. Does nothing outside an agent
+// and ignores all errors: recording must never stop the message going out.
+func recordOutboxBestEffort(bridgeName, body, urgency string) {
+	actor := os.Getenv("H2_ACTOR")
+	if actor == "" || body == "" {
+		return
+	}
+	_, _ = sendSocketRequest(actor, &message.Request{
+		Type:    "outbox_add",
+		To:      bridgeName,
+		Body:    body,
+		Urgency: urgency,
+	})
+}
+
 // handleCloses handles the --responds-to flow: optionally send a response,
 // then remove the trigger from own daemon.
 func handleCloses(triggerID string, args []string, file, priority string, allowSelf bool) error {
@@ -234,6 +361,9 @@ func handleCloses(triggerID string, args []string, file, priority string, allowS
 	if body != "" && name == "" {
 		return fmt.Errorf("target agent name is required when sending a response body")
 	}
+	if err := checkSendBodySize(body); err != nil {
+		return err
+	}
 
 	// Send the response message first (if body present).
 	if body != "" {
@@ -257,6 +387,7 @@ func handleCloses(triggerID string, args []string, file, priority string, allowS
 			return agentConnError(name, err)
 		}
 		defer conn.Close()
+		recordBridgeSendBestEffort(sockPath, body, "")
 
 		if err := message.SendRequest(conn, &message.Request{
 			Type:     "send",
@@ -302,7 +433,11 @@ func handleCloses(triggerID string, args []string, file, priority string, allowS
 		return nil
 	}
 	if !resp.OK {
-		fmt.Fprintf(os.Stderr, "warning: trigger not found (may have already fired): %s\n", resp.Error)
+		if resp.Code == message.ErrCodeNotFound || resp.Code == "" {
+			fmt.Fprintf(os.Stderr, "warning: trigger not found (may have already fired): %s\n", resp.Error)
+		} else {
+			fmt.Fprintf(os.Stderr, "warning: trigger remove failed: %s\n", resp.Error)
+		}
 	}
 
 	return nil
@@ -353,3 +488,24 @@ func stripBackslashPunctuation(s string) string {
 	}
 	return b.String()
 }
+
+// fileAttachments turns --ref paths into file attachments with absolute
+// paths, since the recipient's daemon resolves them from its own directory.
+func fileAttachments(refs []string) ([]message.Attachment, error) {
+	var attachments []message.Attachment
+	for _, ref := range refs {
+		path, err := filepath.Abs(ref)
+		if err != nil {
+			return nil, fmt.Errorf("resolve --ref %s: %w", ref, err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("--ref: %w", err)
+		}
+		if info.IsDir() {
+			return nil, fmt.Errorf("--ref %s is a directory", ref)
+		}
+		attachments = append(attachments, message.Attachment{Kind: message.AttachmentFile, Path: path})
+	}
+	return attachments, nil
+}
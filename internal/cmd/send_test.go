@@ -1,10 +1,14 @@
 package cmd
 
 import (
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"h2/internal/session/message"
+	"h2/internal/socketdir"
 )
 
 func TestSendCmd_SelfSendBlocked(t *testing.T) {
@@ -178,3 +182,198 @@ func TestGenShortID(t *testing.T) {
 		t.Fatal("expected different IDs")
 	}
 }
+
+func TestSend_AttachSendsFileRequestToBridge(t *testing.T) {
+	sockDir := setupBridgeSocketDir(t)
+	t.Setenv("H2_ACTOR", "coder")
+
+	artifact := filepath.Join(t.TempDir(), "report.txt")
+	os.WriteFile(artifact, []byte("done"), 0o644)
+	got := serveRequests(t, sockDir, socketdir.TypeBridge, "alice", &message.Response{OK: true})
+
+	cmd := newSendCmd()
+	cmd.SetArgs([]string{"alice", "--attach", artifact, "nightly", "report"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("send --attach: %v", err)
+	}
+
+	req := <-got
+	if req.Type != "send-file" || req.FilePath != artifact || req.Body != "nightly report" || req.From != "coder" {
+		t.Fatalf("unexpected request: %+v", req)
+	}
+}
+
+// serveRequests answers every request on a fake socket in sockDir with resp
+// and passes the requests on.
+func serveRequests(t *testing.T, sockDir, socketType, name string, resp *message.Response) chan *message.Request {
+	t.Helper()
+	ln, err := net.Listen("unix", filepath.Join(sockDir, socketdir.Format(socketType, name)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	got := make(chan *message.Request, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			if req, err := message.ReadRequest(conn); err == nil {
+				got <- req
+				_ = message.SendResponse(conn, resp)
+			}
+			conn.Close()
+		}
+	}()
+	return got
+}
+
+func TestSend_BridgeMessageRecordedInOutbox(t *testing.T) {
+	sockDir := setupBridgeSocketDir(t)
+	t.Setenv("H2_ACTOR", "coder")
+
+	bridgeReqs := serveRequests(t, sockDir, socketdir.TypeBridge, "alice", &message.Response{OK: true})
+	agentReqs := serveRequests(t, sockDir, socketdir.TypeAgent, "coder", &message.Response{OK: true})
+
+	cmd := newSendCmd()
+	cmd.SetArgs([]string{"alice", "--urgency", "high", "deploy", "finished"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	if req := <-bridgeReqs; req.Type != "send" || req.Body != "deploy finished" {
+		t.Fatalf("unexpected bridge request: %+v", req)
+	}
+	req := <-agentReqs
+	if req.Type != "outbox_add" || req.To != "alice" || req.Body != "deploy finished" || req.Urgency != "high" {
+		t.Fatalf("unexpected outbox request: %+v", req)
+	}
+}
+
+func TestResend_NeedsAgentAndPositiveCount(t *testing.T) {
+	t.Setenv("H2_ACTOR", "")
+
+	cmd := newResendCmd()
+	cmd.SetArgs([]string{"--last", "0", "--agent", "coder"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "--last must be positive") {
+		t.Errorf("expected --last error, got %v", err)
+	}
+
+	cmd = newResendCmd()
+	cmd.SetArgs([]string{"--last", "3"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "--agent is required") {
+		t.Errorf("expected --agent error, got %v", err)
+	}
+}
+
+func TestSend_AttachMissingFile(t *testing.T) {
+	cmd := newSendCmd()
+	cmd.SetArgs([]string{"alice", "--attach", filepath.Join(t.TempDir(), "missing.txt")})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "attachment") {
+		t.Fatalf("expected attachment error, got %v", err)
+	}
+}
+
+func TestSend_RefMissingFile(t *testing.T) {
+	cmd := newSendCmd()
+	cmd.SetArgs([]string{"alice", "--ref", filepath.Join(t.TempDir(), "missing.md"), "see", "spec"})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "--ref") {
+		t.Fatalf("expected --ref error, got %v", err)
+	}
+}
+
+func TestSend_InvalidUrgency(t *testing.T) {
+	cmd := newSendCmd()
+	cmd.SetArgs([]string{"telegram", "--urgency", "urgent", "hello"})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "invalid urgency") {
+		t.Fatalf("expected invalid urgency error, got %v", err)
+	}
+}
+
+func TestSend_UrgencyOnlyForBridgeMessages(t *testing.T) {
+	sockDir := setupBridgeSocketDir(t)
+	t.Setenv("H2_ACTOR", "")
+
+	serveRequests(t, sockDir, socketdir.TypeAgent, "coder", &message.Response{OK: true})
+	artifact := filepath.Join(t.TempDir(), "report.txt")
+	if err := os.WriteFile(artifact, []byte("ok"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"coder", "--urgency", "high", "hello"}, "--urgency only applies to messages sent to a bridge; coder is an agent"},
+		{[]string{"alice", "--attach", artifact, "--urgency", "low"}, "--attach cannot be combined with --raw, --expects-response or --urgency"},
+		{[]string{"--closes", "abc123", "--urgency", "high"}, "--urgency cannot be combined with --closes"},
+	} {
+		cmd := newSendCmd()
+		cmd.SetArgs(tt.args)
+		if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("send %v: error = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
+
+func TestSend_TooLargeBody(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "big.txt")
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", message.MaxMessageBodySize+1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"alice", "--file", path},
+		{"--closes", "t1", "alice", "--file", path},
+	} {
+		cmd := newSendCmd()
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		if err == nil || !strings.Contains(err.Error(), "message is too large") || !strings.Contains(err.Error(), "--ref") {
+			t.Errorf("%v: expected a too large error suggesting --ref, got %v", args, err)
+		}
+	}
+}
+
+func TestFileAttachments_AbsolutePaths(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	os.WriteFile(filepath.Join(dir, "spec.md"), []byte("spec"), 0o644)
+
+	got, err := fileAttachments([]string{"spec.md"})
+	if err != nil {
+		t.Fatalf("fileAttachments: %v", err)
+	}
+	cwd, _ := os.Getwd()
+	want := filepath.Join(cwd, "spec.md")
+	if len(got) != 1 || got[0].Kind != message.AttachmentFile || got[0].Path != want {
+		t.Fatalf("fileAttachments = %+v, want one file attachment at %s", got, want)
+	}
+}
+
+func TestSend_BridgeMessageRecordedEvenWhenDeliveryFails(t *testing.T) {
+	sockDir := setupBridgeSocketDir(t)
+	t.Setenv("H2_ACTOR", "coder")
+
+	bridgeReqs := serveRequests(t, sockDir, socketdir.TypeBridge, "alice", &message.Response{Error: "telegram: connection refused"})
+	agentReqs := serveRequests(t, sockDir, socketdir.TypeAgent, "coder", &message.Response{OK: true})
+
+	for _, args := range [][]string{
+		{"alice", "deploy", "finished"},
+		{"--closes", "abc123", "alice", "done", "here"},
+	} {
+		cmd := newSendCmd()
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "connection refused") {
+			t.Fatalf("send %q: err = %v, want the bridge's failure", args, err)
+		}
+		bridgeReq := <-bridgeReqs
+		req := <-agentReqs
+		if req.Type != "outbox_add" || req.To != "alice" || req.Body != bridgeReq.Body {
+			t.Errorf("send %q: outbox request = %+v, want the undelivered %q recorded", args, req, bridgeReq.Body)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"h2/internal/session"
+)
+
+func newSessionLogCmd() *cobra.Command {
+	var filter string
+	var since string
+	var contains string
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "log <agent-name>",
+		Short: "Show an agent's activity log",
+		Long: `Print events from the activity log (hooks, permission decisions, state
+changes, session summaries) for one agent, oldest first.
+
+--filter matches either the event name (e.g. state_change, permission_decision)
+or a hook event name (e.g. UserPromptSubmit, PreToolUse).
+
+--since accepts an RFC 3339 timestamp or an age like 30m, 12h, 3d.
+
+Examples:
+  h2 session log coder-1
+  h2 session log coder-1 --filter UserPromptSubmit --since 1h
+  h2 session log coder-1 --contains compact --json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			q := session.ActivityQuery{
+				Actor:    args[0],
+				Type:     filter,
+				Contains: contains,
+			}
+			if since != "" {
+				t, err := parseSince(since, time.Now())
+				if err != nil {
+					return fmt.Errorf("invalid --since: %w", err)
+				}
+				q.Since = t
+			}
+
+			out := cmd.OutOrStdout()
+			return session.QueryActivityLog(session.ActivityLogPath(), q, func(e session.ActivityEvent) error {
+				if jsonOutput {
+					_, err := fmt.Fprintln(out, string(e.Raw))
+					return err
+				}
+				return printActivityEvent(out, e)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&filter, "filter", "", "Only show events of this type (event or hook event name)")
+	cmd.Flags().StringVar(&since, "since", "", "Only show events since this time (RFC 3339 or age like 30m, 12h, 3d)")
+	cmd.Flags().StringVar(&contains, "contains", "", "Only show events whose log line contains this text")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print matching events as raw JSONL")
+	return cmd
+}
+
+// parseSince parses an absolute RFC 3339 time or an age relative to now.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	d, err := parseAge(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return now.Add(-d), nil
+}
+
+func printActivityEvent(w io.Writer, e session.ActivityEvent) error {
+	ts := e.TS
+	if !e.Timestamp.IsZero() {
+		ts = e.Timestamp.Local().Format("2006-01-02 15:04:05")
+	}
+
+	var details []string
+	if e.ToolName != "" {
+		details = append(details, "tool="+e.ToolName)
+	}
+	if e.Decision != "" {
+		details = append(details, "decision="+e.Decision)
+	}
+	if e.From != "" || e.To != "" {
+		details = append(details, e.From+" -> "+e.To)
+	}
+	if e.Reason != "" {
+		details = append(details, fmt.Sprintf("reason=%q", e.Reason))
+	}
+
+	line := fmt.Sprintf("%s  %-20s %s", ts, e.Type(), strings.Join(details, " "))
+	_, err := fmt.Fprintln(w, strings.TrimRight(line, " "))
+	return err
+}
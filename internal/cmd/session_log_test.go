@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionLogCmd_FiltersByAgentAndType(t *testing.T) {
+	h2Dir := setupRoleTestH2Dir(t)
+	logDir := filepath.Join(h2Dir, "logs")
+	os.MkdirAll(logDir, 0o755)
+	os.WriteFile(filepath.Join(logDir, "session-activity.jsonl"), []byte(
+		`{"ts":"2025-01-01T00:00:00Z","actor":"coder","session_id":"s","event":"hook","hook_event":"PreToolUse","tool_name":"Bash"}
+{"ts":"2025-01-01T00:00:01Z","actor":"other","session_id":"t","event":"hook","hook_event":"PreToolUse","tool_name":"Read"}
+{"ts":"2025-01-01T00:00:02Z","actor":"coder","session_id":"s","event":"state_change","from":"active","to":"idle"}
+`), 0o644)
+
+	output := captureStdout(func() {
+		cmd := newSessionLogCmd()
+		cmd.SetArgs([]string{"coder"})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("session log failed: %v", err)
+		}
+	})
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines for coder, got:\n%s", output)
+	}
+	if !strings.Contains(lines[0], "PreToolUse") || !strings.Contains(lines[0], "tool=Bash") {
+		t.Errorf("line 0 = %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "state_change") || !strings.Contains(lines[1], "active -> idle") {
+		t.Errorf("line 1 = %q", lines[1])
+	}
+
+	output = captureStdout(func() {
+		cmd := newSessionLogCmd()
+		cmd.SetArgs([]string{"coder", "--filter", "state_change", "--json"})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("session log failed: %v", err)
+		}
+	})
+	if strings.TrimSpace(output) != `{"ts":"2025-01-01T00:00:02Z","actor":"coder","session_id":"s","event":"state_change","from":"active","to":"idle"}` {
+		t.Fatalf("unexpected --json output:\n%s", output)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	got, err := parseSince("2h", now)
+	if err != nil || !got.Equal(now.Add(-2*time.Hour)) {
+		t.Fatalf("parseSince(2h) = %v, %v", got, err)
+	}
+	got, err = parseSince("2025-01-01T00:00:00Z", now)
+	if err != nil || !got.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("parseSince(RFC 3339) = %v, %v", got, err)
+	}
+	if _, err := parseSince("yesterday", now); err == nil {
+		t.Fatal("expected error for unparseable --since")
+	}
+}
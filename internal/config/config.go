@@ -5,8 +5,11 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
+	"time"
+	"unicode"
 
 	"gopkg.in/yaml.v3"
 
@@ -16,8 +19,256 @@ import (
 const markerFile = ".h2-dir.txt"
 
 type Config struct {
-	Bridges map[string]*BridgesConfig `yaml:"bridges"` // named bridge configs
-	Users   map[string]*UserConfig    `yaml:"users"`
+	Bridges  map[string]*BridgesConfig `yaml:"bridges"` // named bridge configs
+	Users    map[string]*UserConfig    `yaml:"users"`
+	Terminal *TerminalConfig           `yaml:"terminal,omitempty"`
+	Messages *MessagesConfig           `yaml:"messages,omitempty"`
+
+	AgentState  *AgentStateConfig  `yaml:"agent_state,omitempty"`
+	ActivityLog *ActivityLogConfig `yaml:"activity_log,omitempty"`
+}
+
+// Activity log rotation defaults.
+const (
+	DefaultActivityLogMaxSizeMB = 50
+	DefaultActivityLogKeep      = 10
+)
+
+// ActivityLogConfig controls rotation of the shared activity log
+// (logs/session-activity.jsonl).
+type ActivityLogConfig struct {
+	// MaxSizeMB rotates the log once it grows past this size. 0 keeps the
+	// default of 50.
+	MaxSizeMB int `yaml:"max_size_mb,omitempty"`
+
+	// MaxAge (Go duration, e.g. "24h") also rotates the log once its first
+	// event is this old. Unset means size-based rotation only.
+	MaxAge string `yaml:"max_age,omitempty"`
+
+	// Keep is how many rotated files to keep; older ones are deleted. 0
+	// keeps the default of 10.
+	Keep int `yaml:"keep,omitempty"`
+
+	// Compress gzips rotated files, except the most recent one.
+	Compress bool `yaml:"compress,omitempty"`
+}
+
+// DefaultInlineMessageLength is the longest message body typed directly into
+// an agent; longer bodies are delivered as a reference to the message file.
+const DefaultInlineMessageLength = 300
+
+// MessagesConfig holds settings for delivering messages to agents.
+type MessagesConfig struct {
+	// InlineMaxLength overrides DefaultInlineMessageLength.
+	InlineMaxLength *int `yaml:"inline_max_length,omitempty"`
+}
+
+// InlineMessageLength returns the longest message body delivered inline.
+func (c *Config) InlineMessageLength() int {
+	if c.Messages == nil || c.Messages.InlineMaxLength == nil {
+		return DefaultInlineMessageLength
+	}
+	return *c.Messages.InlineMaxLength
+}
+
+// AgentStateConfig tunes how agents' active/idle state is detected.
+type AgentStateConfig struct {
+	// IdleDebounce (Go duration, e.g. "3s") is how long an agent must stay
+	// idle before it's reported idle, so the short gaps between fast tool
+	// calls aren't taken for the agent being done. Unset reports idle as
+	// soon as the harness does.
+	IdleDebounce string `yaml:"idle_debounce,omitempty"`
+}
+
+// IdleDebounce returns the configured idle debounce, 0 if unset. Call
+// Validate first; an invalid duration is treated as unset.
+func (c *Config) IdleDebounce() time.Duration {
+	if c.AgentState == nil || c.AgentState.IdleDebounce == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(c.AgentState.IdleDebounce)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
+// TerminalConfig holds settings for the agent terminal UI.
+type TerminalConfig struct {
+	// OSC52Copy makes h2 handle mouse-drag selection itself and copy the
+	// selected text to the system clipboard via OSC 52. Off by default
+	// since terminal support varies; when off, clicks show a hint to use
+	// the host terminal's shift-select instead.
+	OSC52Copy bool `yaml:"osc52_copy,omitempty"`
+
+	// SelectHint replaces that hint's text, e.g. for a terminal that
+	// selects with Option/Alt, and SelectHintDuration sets how long it
+	// stays up as a Go duration. Unset keeps "(hold shift to select)"
+	// for 3s.
+	SelectHint         string `yaml:"select_hint,omitempty"`
+	SelectHintDuration string `yaml:"select_hint_duration,omitempty"`
+
+	// PersistScrollback saves each agent's scroll history to its session
+	// dir so it can still be scrolled through after the agent restarts.
+	PersistScrollback bool `yaml:"persist_scrollback,omitempty"`
+
+	// ScrollbackLines caps the lines of output kept for scroll mode; the
+	// oldest are dropped first. 0 keeps the default of 20000.
+	ScrollbackLines int `yaml:"scrollback_lines,omitempty"`
+
+	// StatusClock and StatusIdleTimer add the current time and how long the
+	// agent has gone without activity to the right side of the status bar.
+	StatusClock     bool `yaml:"status_clock,omitempty"`
+	StatusIdleTimer bool `yaml:"status_idle_timer,omitempty"`
+
+	// Highlights colors regex matches in the agent's output, in both the
+	// live and scroll views. Only unstyled text is highlighted.
+	Highlights []HighlightRule `yaml:"highlights,omitempty"`
+
+	// MenuKey and PassthroughExitKey replace Ctrl+\ as the key that opens
+	// the menu and the key that leaves passthrough mode, for terminals that
+	// bind Ctrl+\ to something else. Written like "ctrl+g"; see
+	// ParseControlKey.
+	MenuKey            string `yaml:"menu_key,omitempty"`
+	PassthroughExitKey string `yaml:"passthrough_exit_key,omitempty"`
+
+	// Theme overrides the status bar colors for each mode.
+	Theme *StatusBarTheme `yaml:"theme,omitempty"`
+}
+
+// SelectHintTimeout returns how long the select hint stays up, or 0 when
+// select_hint_duration is unset.
+func (t *TerminalConfig) SelectHintTimeout() (time.Duration, error) {
+	if t.SelectHintDuration == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(t.SelectHintDuration)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q; must be positive, e.g. 5s", t.SelectHintDuration)
+	}
+	return d, nil
+}
+
+// StatusBarTheme sets the status bar style per mode as SGR parameters (e.g.
+// "7;36" for inverse cyan). Unset entries keep the built-in colors.
+type StatusBarTheme struct {
+	Normal       string `yaml:"normal,omitempty"`
+	Passthrough  string `yaml:"passthrough,omitempty"` // also passthrough scroll
+	Menu         string `yaml:"menu,omitempty"`
+	Scroll       string `yaml:"scroll,omitempty"`
+	Exited       string `yaml:"exited,omitempty"`        // the agent has exited
+	ExitedScroll string `yaml:"exited_scroll,omitempty"` // scrolling after the agent exited
+}
+
+// entries returns the theme's settings by YAML key, in declaration order.
+func (t *StatusBarTheme) entries() []struct{ key, sgr string } {
+	return []struct{ key, sgr string }{
+		{"normal", t.Normal},
+		{"passthrough", t.Passthrough},
+		{"menu", t.Menu},
+		{"scroll", t.Scroll},
+		{"exited", t.Exited},
+		{"exited_scroll", t.ExitedScroll},
+	}
+}
+
+// validate checks that every set entry is well-formed SGR parameters, so a
+// typo can't inject a broken escape sequence into the status bar.
+func (t *StatusBarTheme) validate() error {
+	for _, e := range t.entries() {
+		if e.sgr != "" && !rawSGRRe.MatchString(e.sgr) {
+			return fmt.Errorf("%s: invalid SGR parameters %q (want e.g. \"7;36\")", e.key, e.sgr)
+		}
+	}
+	return nil
+}
+
+// reservedControlKeys are control bytes the input handlers already give a
+// meaning to, so they can't be remapped to open the menu or exit passthrough.
+var reservedControlKeys = map[byte]string{
+	0x00: "ctrl+space, which always opens the menu",
+	0x08: "Backspace",
+	0x09: "Tab",
+	0x0A: "Enter",
+	0x0D: "Enter",
+	0x1B: "Esc",
+}
+
+// ParseControlKey parses a control key written as "ctrl+<key>" (or "^<key>")
+// where key is a letter or one of [ \ ] ^ _, returning the byte the terminal
+// sends for it. Keys that collide with Enter, Esc, Tab or Backspace are
+// rejected.
+func ParseControlKey(s string) (byte, error) {
+	key := strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case strings.HasPrefix(key, "ctrl+"):
+		key = strings.TrimPrefix(key, "ctrl+")
+	case strings.HasPrefix(key, "^"):
+		key = strings.TrimPrefix(key, "^")
+	default:
+		return 0, fmt.Errorf("invalid key %q (want a control key like \"ctrl+g\")", s)
+	}
+	if key == "space" {
+		key = "@"
+	}
+	if len(key) != 1 {
+		return 0, fmt.Errorf("invalid key %q (want a control key like \"ctrl+g\")", s)
+	}
+	c := key[0]
+	var b byte
+	switch {
+	case c >= 'a' && c <= 'z':
+		b = c - 'a' + 1
+	case strings.IndexByte("@[\\]^_", c) >= 0:
+		b = c - '@'
+	default:
+		return 0, fmt.Errorf("invalid key %q (want a control key like \"ctrl+g\")", s)
+	}
+	if what, ok := reservedControlKeys[b]; ok {
+		return 0, fmt.Errorf("key %q is %s", s, what)
+	}
+	return b, nil
+}
+
+// HighlightRule maps a regular expression to a highlight color.
+type HighlightRule struct {
+	Pattern string `yaml:"pattern"`
+	// Color is a color name (e.g. "red", "bright_yellow") or raw SGR
+	// parameters (e.g. "1;31").
+	Color string `yaml:"color"`
+}
+
+// highlightColors maps color names to SGR foreground parameters.
+var highlightColors = map[string]string{
+	"black":          "30",
+	"red":            "31",
+	"green":          "32",
+	"yellow":         "33",
+	"blue":           "34",
+	"magenta":        "35",
+	"cyan":           "36",
+	"white":          "37",
+	"bright_black":   "90",
+	"bright_red":     "91",
+	"bright_green":   "92",
+	"bright_yellow":  "93",
+	"bright_blue":    "94",
+	"bright_magenta": "95",
+	"bright_cyan":    "96",
+	"bright_white":   "97",
+}
+
+var rawSGRRe = regexp.MustCompile(`^[0-9]+(;[0-9]+)*$`)
+
+// SGR returns the SGR parameters for the rule's color.
+func (r HighlightRule) SGR() (string, error) {
+	if sgr, ok := highlightColors[strings.ToLower(r.Color)]; ok {
+		return sgr, nil
+	}
+	if rawSGRRe.MatchString(r.Color) {
+		return r.Color, nil
+	}
+	return "", fmt.Errorf("unknown color %q (use a color name like \"red\" or SGR parameters like \"1;31\")", r.Color)
 }
 
 type UserConfig struct {
@@ -28,6 +279,55 @@ type UserConfig struct {
 type BridgesConfig struct {
 	Telegram    *TelegramConfig    `yaml:"telegram"`
 	MacOSNotify *MacOSNotifyConfig `yaml:"macos_notify"`
+
+	// ConciergeRotation switches the concierge between agents on a daily
+	// schedule. Each shift runs from its start time until the next shift's.
+	ConciergeRotation []ConciergeShift `yaml:"concierge_rotation,omitempty"`
+
+	// AskTimeout, when set, makes each inbound message an "ask": the bridge
+	// waits this long (Go duration, e.g. "2m") for the agent's reply and
+	// tells the channel if none arrives. "0s" is the same as unset.
+	AskTimeout string `yaml:"ask_timeout,omitempty"`
+
+	// Threads posts each non-concierge agent's messages into its own thread
+	// (a reply chain on Telegram), and routes replies there to that agent.
+	Threads bool `yaml:"threads,omitempty"`
+
+	// NotifyTransitions lists the agent state changes the bridge announces:
+	// "idle" (busy to idle) and "blocked" (blocked on a permission prompt).
+	NotifyTransitions []string `yaml:"notify_transitions,omitempty"`
+
+	// NotifyDebounce is how long (Go duration, e.g. "30s") a new state must
+	// hold before it is announced, so a brief idle between tool calls stays
+	// quiet. Defaults to 10s.
+	NotifyDebounce string `yaml:"notify_debounce,omitempty"`
+
+	// NoConciergeFallback decides where un-addressed messages go when no
+	// concierge is set and no agent has messaged over the bridge yet:
+	// "first-agent" (the default) picks the first running agent, "reject"
+	// asks the human to address an agent, and "broadcast" sends to all.
+	NoConciergeFallback string `yaml:"no_concierge_fallback,omitempty"`
+}
+
+// ValidNotifyTransitions are the accepted notify_transitions values.
+var ValidNotifyTransitions = []string{"idle", "blocked"}
+
+// ValidNoConciergeFallbacks are the accepted no_concierge_fallback values.
+var ValidNoConciergeFallbacks = []string{"first-agent", "reject", "broadcast"}
+
+// ConciergeShift is one entry of a bridge's concierge rotation.
+type ConciergeShift struct {
+	Agent string `yaml:"agent"`
+	Start string `yaml:"start"` // local time of day, "HH:MM"
+}
+
+// StartOffset returns the shift start as an offset from local midnight.
+func (cs ConciergeShift) StartOffset() (time.Duration, error) {
+	t, err := time.Parse("15:04", cs.Start)
+	if err != nil {
+		return 0, fmt.Errorf("invalid start %q (want HH:MM)", cs.Start)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
 }
 
 type TelegramConfig struct {
@@ -35,6 +335,10 @@ type TelegramConfig struct {
 	ChatID          int64    `yaml:"chat_id"`
 	AllowedCommands []string `yaml:"allowed_commands,omitempty"`
 	ExpectsResponse bool     `yaml:"expects_response,omitempty"`
+
+	// MaxFileMB caps the size of photos, documents and voice notes passed
+	// on to agents. Defaults to 20, the most a bot can download.
+	MaxFileMB int `yaml:"max_file_mb,omitempty"`
 }
 
 type MacOSNotifyConfig struct {
@@ -193,11 +497,97 @@ var allowedCommandRe = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
 
 func (c *Config) validate() error {
 	for name, bc := range c.Bridges {
-		if bc == nil || bc.Telegram == nil {
+		if bc == nil {
 			continue
 		}
-		if err := validateAllowedCommands(bc.Telegram.AllowedCommands); err != nil {
-			return fmt.Errorf("bridges.%s.telegram: %w", name, err)
+		if bc.Telegram != nil {
+			if err := validateAllowedCommands(bc.Telegram.AllowedCommands); err != nil {
+				return fmt.Errorf("bridges.%s.telegram: %w", name, err)
+			}
+			if bc.Telegram.MaxFileMB < 0 {
+				return fmt.Errorf("bridges.%s.telegram.max_file_mb must not be negative, got %d", name, bc.Telegram.MaxFileMB)
+			}
+		}
+		if err := validateConciergeRotation(bc.ConciergeRotation); err != nil {
+			return fmt.Errorf("bridges.%s.concierge_rotation: %w", name, err)
+		}
+		for _, tr := range bc.NotifyTransitions {
+			if !slices.Contains(ValidNotifyTransitions, tr) {
+				return fmt.Errorf("bridges.%s.notify_transitions: invalid transition %q; valid values: %s",
+					name, tr, strings.Join(ValidNotifyTransitions, ", "))
+			}
+		}
+		if bc.AskTimeout != "" {
+			if d, err := time.ParseDuration(bc.AskTimeout); err != nil || d < 0 {
+				return fmt.Errorf("bridges.%s.ask_timeout: invalid duration %q", name, bc.AskTimeout)
+			}
+		}
+		if bc.NotifyDebounce != "" {
+			if d, err := time.ParseDuration(bc.NotifyDebounce); err != nil || d < 0 {
+				return fmt.Errorf("bridges.%s.notify_debounce: invalid duration %q", name, bc.NotifyDebounce)
+			}
+		}
+		if f := bc.NoConciergeFallback; f != "" && !slices.Contains(ValidNoConciergeFallbacks, f) {
+			return fmt.Errorf("bridges.%s.no_concierge_fallback: invalid value %q; valid values: %s",
+				name, f, strings.Join(ValidNoConciergeFallbacks, ", "))
+		}
+	}
+	if c.Terminal != nil {
+		for i, rule := range c.Terminal.Highlights {
+			if rule.Pattern == "" {
+				return fmt.Errorf("terminal.highlights[%d]: pattern is required", i)
+			}
+			if _, err := regexp.Compile(rule.Pattern); err != nil {
+				return fmt.Errorf("terminal.highlights[%d]: invalid pattern: %w", i, err)
+			}
+			if _, err := rule.SGR(); err != nil {
+				return fmt.Errorf("terminal.highlights[%d]: %w", i, err)
+			}
+		}
+		if strings.IndexFunc(c.Terminal.SelectHint, unicode.IsControl) >= 0 {
+			return fmt.Errorf("terminal.select_hint must be a single line of plain text")
+		}
+		if _, err := c.Terminal.SelectHintTimeout(); err != nil {
+			return fmt.Errorf("terminal.select_hint_duration: %w", err)
+		}
+		if c.Terminal.ScrollbackLines < 0 {
+			return fmt.Errorf("terminal.scrollback_lines must not be negative, got %d", c.Terminal.ScrollbackLines)
+		}
+		if c.Terminal.MenuKey != "" {
+			if _, err := ParseControlKey(c.Terminal.MenuKey); err != nil {
+				return fmt.Errorf("terminal.menu_key: %w", err)
+			}
+		}
+		if c.Terminal.PassthroughExitKey != "" {
+			if _, err := ParseControlKey(c.Terminal.PassthroughExitKey); err != nil {
+				return fmt.Errorf("terminal.passthrough_exit_key: %w", err)
+			}
+		}
+		if c.Terminal.Theme != nil {
+			if err := c.Terminal.Theme.validate(); err != nil {
+				return fmt.Errorf("terminal.theme.%w", err)
+			}
+		}
+	}
+	if al := c.ActivityLog; al != nil {
+		if al.MaxSizeMB < 0 {
+			return fmt.Errorf("activity_log.max_size_mb must not be negative, got %d", al.MaxSizeMB)
+		}
+		if al.Keep < 0 {
+			return fmt.Errorf("activity_log.keep must not be negative, got %d", al.Keep)
+		}
+		if al.MaxAge != "" {
+			if d, err := time.ParseDuration(al.MaxAge); err != nil || d <= 0 {
+				return fmt.Errorf("activity_log.max_age: invalid duration %q", al.MaxAge)
+			}
+		}
+	}
+	if c.Messages != nil && c.Messages.InlineMaxLength != nil && *c.Messages.InlineMaxLength <= 0 {
+		return fmt.Errorf("messages.inline_max_length must be positive, got %d", *c.Messages.InlineMaxLength)
+	}
+	if c.AgentState != nil && c.AgentState.IdleDebounce != "" {
+		if d, err := time.ParseDuration(c.AgentState.IdleDebounce); err != nil || d < 0 {
+			return fmt.Errorf("agent_state.idle_debounce: invalid duration %q", c.AgentState.IdleDebounce)
 		}
 	}
 	return nil
@@ -218,6 +608,24 @@ func (c *Config) LookupBridge(name string) (*BridgesConfig, error) {
 	return bc, nil
 }
 
+func validateConciergeRotation(shifts []ConciergeShift) error {
+	starts := make(map[time.Duration]bool, len(shifts))
+	for i, shift := range shifts {
+		if shift.Agent == "" {
+			return fmt.Errorf("[%d]: agent is required", i)
+		}
+		start, err := shift.StartOffset()
+		if err != nil {
+			return fmt.Errorf("[%d]: %w", i, err)
+		}
+		if starts[start] {
+			return fmt.Errorf("[%d]: duplicate start %q", i, shift.Start)
+		}
+		starts[start] = true
+	}
+	return nil
+}
+
 func validateAllowedCommands(cmds []string) error {
 	for _, cmd := range cmds {
 		if cmd == "" {
@@ -1,10 +1,12 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"h2/internal/version"
 )
@@ -213,6 +215,252 @@ func TestLoadFrom_NoBridges(t *testing.T) {
 	}
 }
 
+func TestLoadFrom_TerminalOSC52Copy(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+
+	yaml := `terminal:
+  osc52_copy: true
+`
+	if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatalf("LoadFrom: %v", err)
+	}
+	if cfg.Terminal == nil || !cfg.Terminal.OSC52Copy {
+		t.Errorf("expected terminal.osc52_copy = true, got %+v", cfg.Terminal)
+	}
+}
+
+func TestLoadFrom_TerminalSelectHint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	yaml := "terminal:\n  select_hint: \"(hold option to select)\"\n  select_hint_duration: 5s\n"
+	if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatalf("LoadFrom: %v", err)
+	}
+	if cfg.Terminal.SelectHint != "(hold option to select)" {
+		t.Errorf("select_hint = %q", cfg.Terminal.SelectHint)
+	}
+	if d, err := cfg.Terminal.SelectHintTimeout(); err != nil || d != 5*time.Second {
+		t.Errorf("SelectHintTimeout() = %v, %v; want 5s", d, err)
+	}
+	if d, err := (&TerminalConfig{}).SelectHintTimeout(); err != nil || d != 0 {
+		t.Errorf("unset SelectHintTimeout() = %v, %v; want 0", d, err)
+	}
+
+	for yaml, want := range map[string]string{
+		"select_hint_duration: 0s":   `terminal.select_hint_duration: invalid duration "0s"`,
+		"select_hint_duration: -1s":  `terminal.select_hint_duration: invalid duration "-1s"`,
+		"select_hint_duration: soon": `terminal.select_hint_duration: invalid duration "soon"`,
+		`select_hint: "a\nb"`:        "terminal.select_hint must be a single line",
+	} {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(path, []byte("terminal:\n  "+yaml+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadFrom(path)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected %q error, got %v", yaml, want, err)
+		}
+	}
+}
+
+func TestLoadFrom_TerminalHighlights(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+
+	yaml := `terminal:
+  highlights:
+    - pattern: "(?i)error"
+      color: red
+    - pattern: "WARN"
+      color: "1;33"
+`
+	if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatalf("LoadFrom: %v", err)
+	}
+	if cfg.Terminal == nil || len(cfg.Terminal.Highlights) != 2 {
+		t.Fatalf("expected 2 highlight rules, got %+v", cfg.Terminal)
+	}
+	for i, want := range []string{"31", "1;33"} {
+		sgr, err := cfg.Terminal.Highlights[i].SGR()
+		if err != nil || sgr != want {
+			t.Errorf("rule %d SGR = %q, %v; want %q", i, sgr, err, want)
+		}
+	}
+}
+
+func TestLoadFrom_TerminalHighlights_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"bad pattern": "- pattern: \"(unclosed\"\n      color: red\n",
+		"bad color":   "- pattern: error\n      color: chartreuse\n",
+		"no pattern":  "- color: red\n",
+	}
+	for name, rules := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			yaml := "terminal:\n  highlights:\n    " + rules
+			if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadFrom(path)
+			if err == nil || !strings.Contains(err.Error(), "terminal.highlights[0]") {
+				t.Fatalf("expected terminal.highlights[0] error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadFrom_TerminalTheme(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	yaml := "terminal:\n  theme:\n    scroll: \"7;34\"\n    exited: \"1;7;35\"\n"
+	if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatalf("LoadFrom: %v", err)
+	}
+	theme := cfg.Terminal.Theme
+	if theme == nil || theme.Scroll != "7;34" || theme.Exited != "1;7;35" || theme.Normal != "" {
+		t.Fatalf("unexpected theme: %+v", theme)
+	}
+}
+
+func TestLoadFrom_TerminalTheme_Invalid(t *testing.T) {
+	for _, sgr := range []string{"\\033[7m", "7;", "cyan", "7m"} {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		yaml := "terminal:\n  theme:\n    exited_scroll: \"" + sgr + "\"\n"
+		if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadFrom(path)
+		if err == nil || !strings.Contains(err.Error(), "terminal.theme.exited_scroll") {
+			t.Errorf("theme %q: expected terminal.theme.exited_scroll error, got %v", sgr, err)
+		}
+	}
+}
+
+func TestLoadFrom_MessagesInlineMaxLength(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("messages:\n  inline_max_length: 1200\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatalf("LoadFrom: %v", err)
+	}
+	if got := cfg.InlineMessageLength(); got != 1200 {
+		t.Errorf("InlineMessageLength = %d, want 1200", got)
+	}
+	if got := (&Config{}).InlineMessageLength(); got != DefaultInlineMessageLength {
+		t.Errorf("default InlineMessageLength = %d, want %d", got, DefaultInlineMessageLength)
+	}
+}
+
+func TestLoadFrom_ActivityLogInvalid(t *testing.T) {
+	for yaml, want := range map[string]string{
+		"max_size_mb: -1": "activity_log.max_size_mb must not be negative",
+		"keep: -2":        "activity_log.keep must not be negative",
+		"max_age: soon":   `activity_log.max_age: invalid duration "soon"`,
+		"max_age: -1h":    `activity_log.max_age: invalid duration "-1h"`,
+	} {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(path, []byte("activity_log:\n  "+yaml+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadFrom(path)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected %q error, got %v", yaml, want, err)
+		}
+	}
+}
+
+func TestLoadFrom_MessagesInlineMaxLength_NotPositive(t *testing.T) {
+	for _, v := range []string{"0", "-5"} {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(path, []byte("messages:\n  inline_max_length: "+v+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadFrom(path)
+		if err == nil || !strings.Contains(err.Error(), "messages.inline_max_length must be positive") {
+			t.Errorf("inline_max_length %s: expected positive error, got %v", v, err)
+		}
+	}
+}
+
+func TestLoadFrom_AgentStateIdleDebounce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("agent_state:\n  idle_debounce: 3s\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatalf("LoadFrom: %v", err)
+	}
+	if got := cfg.IdleDebounce(); got != 3*time.Second {
+		t.Errorf("IdleDebounce = %v, want 3s", got)
+	}
+	if got := (&Config{}).IdleDebounce(); got != 0 {
+		t.Errorf("default IdleDebounce = %v, want 0", got)
+	}
+
+	for _, v := range []string{"soon", "-1s"} {
+		if err := os.WriteFile(path, []byte("agent_state:\n  idle_debounce: "+v+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadFrom(path)
+		if err == nil || !strings.Contains(err.Error(), "agent_state.idle_debounce: invalid duration") {
+			t.Errorf("idle_debounce %s: expected invalid duration error, got %v", v, err)
+		}
+	}
+}
+
+func TestParseControlKey(t *testing.T) {
+	valid := map[string]byte{
+		"ctrl+g": 0x07,
+		"Ctrl+G": 0x07,
+		"^g":     0x07,
+		`ctrl+\`: 0x1C,
+		"ctrl+]": 0x1D,
+		"ctrl+_": 0x1F,
+	}
+	for in, want := range valid {
+		got, err := ParseControlKey(in)
+		if err != nil || got != want {
+			t.Errorf("ParseControlKey(%q) = %#x, %v; want %#x", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"g", "ctrl+", "ctrl+gg", "ctrl+1", "ctrl+m", "ctrl+j", "ctrl+[", "ctrl+i", "ctrl+h", "ctrl+space"} {
+		if _, err := ParseControlKey(in); err == nil {
+			t.Errorf("ParseControlKey(%q) succeeded, want error", in)
+		}
+	}
+}
+
+func TestLoadFrom_TerminalMenuKey_CollidesWithEnter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("terminal:\n  menu_key: ctrl+m\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadFrom(path)
+	if err == nil || !strings.Contains(err.Error(), "terminal.menu_key") || !strings.Contains(err.Error(), "Enter") {
+		t.Fatalf("expected terminal.menu_key Enter collision error, got %v", err)
+	}
+}
+
 func TestLoadFrom_ExpectsResponse(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "config.yaml")
@@ -239,6 +487,193 @@ func TestLoadFrom_ExpectsResponse(t *testing.T) {
 	}
 }
 
+func TestLoadFrom_ConciergeRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+
+	data := `bridges:
+  personal:
+    telegram:
+      bot_token: "tok"
+      chat_id: 1
+    concierge_rotation:
+      - agent: day-concierge
+        start: "09:00"
+      - agent: night-concierge
+        start: "18:30"
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatalf("LoadFrom: %v", err)
+	}
+	shifts := cfg.Bridges["personal"].ConciergeRotation
+	if len(shifts) != 2 || shifts[1].Agent != "night-concierge" {
+		t.Fatalf("ConciergeRotation = %+v", shifts)
+	}
+	start, err := shifts[1].StartOffset()
+	if err != nil || start != 18*time.Hour+30*time.Minute {
+		t.Errorf("StartOffset = %v, %v; want 18h30m", start, err)
+	}
+}
+
+func TestLoadFrom_ConciergeRotation_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		rotation string
+	}{
+		{"missing agent", `[{start: "09:00"}]`},
+		{"bad start", `[{agent: a, start: "9am"}]`},
+		{"duplicate start", `[{agent: a, start: "09:00"}, {agent: b, start: "09:00"}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			data := `bridges:
+  personal:
+    concierge_rotation: ` + tt.rotation + "\n"
+			if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := LoadFrom(path)
+			if err == nil || !strings.Contains(err.Error(), "concierge_rotation") {
+				t.Fatalf("expected concierge_rotation error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadFrom_NotifyTransitions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `bridges:
+  personal:
+    notify_transitions: [idle, blocked]
+    notify_debounce: 30s
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatalf("LoadFrom: %v", err)
+	}
+	bc := cfg.Bridges["personal"]
+	if strings.Join(bc.NotifyTransitions, ",") != "idle,blocked" || bc.NotifyDebounce != "30s" {
+		t.Errorf("NotifyTransitions = %v, NotifyDebounce = %q", bc.NotifyTransitions, bc.NotifyDebounce)
+	}
+}
+
+func TestLoadFrom_NotifyTransitions_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `bridges:
+  personal:
+    notify_transitions: [idle, done]
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadFrom(path)
+	if err == nil || !strings.Contains(err.Error(), `invalid transition "done"`) {
+		t.Fatalf("expected invalid transition error, got %v", err)
+	}
+}
+
+func TestLoadFrom_NoConciergeFallback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `bridges:
+  personal:
+    no_concierge_fallback: reject
+  team:
+    no_concierge_fallback: everyone
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadFrom(path)
+	if err == nil || !strings.Contains(err.Error(), `bridges.team.no_concierge_fallback: invalid value "everyone"`) {
+		t.Fatalf("expected invalid fallback error, got %v", err)
+	}
+
+	data = `bridges:
+  personal:
+    no_concierge_fallback: reject
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatalf("LoadFrom: %v", err)
+	}
+	if got := cfg.Bridges["personal"].NoConciergeFallback; got != "reject" {
+		t.Errorf("NoConciergeFallback = %q, want %q", got, "reject")
+	}
+}
+
+func TestLoadFrom_AskTimeoutInvalid(t *testing.T) {
+	for _, v := range []string{"soon", "-1m"} {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		data := "bridges:\n  personal:\n    ask_timeout: " + v + "\n"
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadFrom(path)
+		if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("bridges.personal.ask_timeout: invalid duration %q", v)) {
+			t.Errorf("ask_timeout %s: expected invalid duration error, got %v", v, err)
+		}
+	}
+
+	// 0s turns asks off, as if unset.
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("bridges:\n  personal:\n    ask_timeout: 0s\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFrom(path); err != nil {
+		t.Errorf("ask_timeout 0s: %v", err)
+	}
+}
+
+func TestLoadFrom_NotifyDebounceInvalid(t *testing.T) {
+	for _, v := range []string{"soon", "-5s"} {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		data := "bridges:\n  personal:\n    notify_debounce: " + v + "\n"
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadFrom(path)
+		if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("bridges.personal.notify_debounce: invalid duration %q", v)) {
+			t.Errorf("notify_debounce %s: expected invalid duration error, got %v", v, err)
+		}
+	}
+}
+
+func TestLoadFrom_TelegramMaxFileMB_Negative(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `bridges:
+  personal:
+    telegram:
+      bot_token: "tok"
+      chat_id: 1
+      max_file_mb: -1
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadFrom(path)
+	if err == nil || !strings.Contains(err.Error(), "max_file_mb must not be negative") {
+		t.Fatalf("expected max_file_mb error, got %v", err)
+	}
+}
+
 func TestLoadFrom_MultipleBridges(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "config.yaml")
@@ -3,6 +3,8 @@ package config
 import (
 	"strings"
 	"testing"
+
+	"h2/internal/tmpl"
 )
 
 func TestApplyOverrides_SimpleString(t *testing.T) {
@@ -306,3 +308,59 @@ func TestRuntimeConfigWithoutOverrides(t *testing.T) {
 		t.Errorf("Overrides should be nil when not set, got %v", got.Overrides)
 	}
 }
+
+func TestApplyOverrides_DisablesTemplateEnabledReviewers(t *testing.T) {
+	path := writeTempFile(t, "reviewed.yaml.tmpl", `
+role_name: reviewed
+variables:
+  review:
+    default: "true"
+instructions: test
+permission_review:
+  dcg:
+    enabled: {{ .Var.review }}
+  ai_reviewer:
+    enabled: {{ .Var.review }}
+    instructions: Review carefully.
+`)
+	role, err := LoadRoleRenderedFrom(path, &tmpl.Context{})
+	if err != nil {
+		t.Fatalf("LoadRoleRenderedFrom: %v", err)
+	}
+	if !role.PermissionReview.AIReviewer.IsEnabled() || !role.PermissionReview.DCG.IsEnabled() {
+		t.Fatal("precondition: rendered role should have both reviewers enabled")
+	}
+
+	err = ApplyOverrides(role, []string{
+		"permission_review.ai_reviewer.enabled=false",
+		"permission_review.dcg.enabled=false",
+	})
+	if err != nil {
+		t.Fatalf("ApplyOverrides: %v", err)
+	}
+	if role.PermissionReview.AIReviewer.IsEnabled() {
+		t.Error("AI reviewer should be disabled by override")
+	}
+	if role.PermissionReview.DCG.IsEnabled() {
+		t.Error("DCG should be disabled by override")
+	}
+	if role.PermissionReview.HasAnyEnabled() {
+		t.Error("HasAnyEnabled should be false")
+	}
+	if role.PermissionReview.AIReviewer.GetInstructions() != "Review carefully." {
+		t.Error("override should leave the other reviewer fields alone")
+	}
+}
+
+func TestApplyOverrides_ReviewerEnabledWithoutPermissionReviewBlock(t *testing.T) {
+	role := &Role{RoleName: "test", Instructions: "test"}
+	if err := ApplyOverrides(role, []string{"permission_review.ai_reviewer.enabled=false"}); err != nil {
+		t.Fatalf("ApplyOverrides: %v", err)
+	}
+	if role.PermissionReview == nil || role.PermissionReview.AIReviewer == nil || role.PermissionReview.AIReviewer.Enabled == nil {
+		t.Fatal("expected nested Enabled to be set")
+	}
+	if role.PermissionReview.AIReviewer.IsEnabled() {
+		t.Error("AI reviewer should be disabled")
+	}
+}
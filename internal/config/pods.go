@@ -37,8 +37,9 @@ type PodTemplate struct {
 
 // PodBridge links a named bridge config to a concierge agent in the pod.
 type PodBridge struct {
-	Bridge    string `yaml:"bridge"`    // key into config.yaml bridges map
-	Concierge string `yaml:"concierge"` // agent name in this pod; empty = no concierge
+	Bridge      string `yaml:"bridge"`                 // key into config.yaml bridges map
+	Concierge   string `yaml:"concierge"`              // agent name in this pod; empty = no concierge
+	MetricsAddr string `yaml:"metrics_addr,omitempty"` // serve Prometheus metrics on this address; empty = none
 }
 
 // PodTemplateAgent defines a single agent within a pod.
@@ -120,7 +121,7 @@ func ExpandPodAgents(pt *PodTemplate) ([]ExpandedAgent, error) {
 					return nil, fmt.Errorf("render agent name %q (index %d): %w", a.Name, i, err)
 				}
 				name = rendered
-			} else {
+			} else if a.Name != "" {
 				// Auto-append 1-based index suffix for human-friendly names.
 				name = fmt.Sprintf("%s-%d", a.Name, i+1)
 			}
@@ -195,10 +196,62 @@ func renderStringMap(m map[string]string, ctx *tmpl.Context) (map[string]string,
 	return result, nil
 }
 
-// checkNameCollisions detects duplicate agent names after expansion.
+// ResolvePodAgentNames names the agents the pod template left unnamed from
+// their role's agent_name. The role is rendered with tmpl.PodNameFuncs, so
+// the pod's agents using {{ autoIncrement "worker" }} are named worker-1,
+// worker-2 and so on in template order, across all entries, rather than
+// racing other launches for the next free number. Roles without an
+// agent_name fall back to "<role>-<n>", numbered the same way. base supplies
+// the pod-wide context; each agent's vars are merged under base.Var. Returns
+// an error if the resolved names collide.
+func ResolvePodAgentNames(agents []ExpandedAgent, base *tmpl.Context, generateName func() string, existingNames []string) error {
+	ordinals := map[string]int{}
+	for i := range agents {
+		a := &agents[i]
+		if a.Name != "" {
+			continue
+		}
+		roleName := a.Role
+		if roleName == "" {
+			roleName = "default"
+		}
+		vars := make(map[string]string, len(a.Vars)+len(base.Var))
+		for k, v := range a.Vars {
+			vars[k] = v
+		}
+		for k, v := range base.Var {
+			vars[k] = v
+		}
+		ctx := *base
+		ctx.RoleName = roleName
+		ctx.Index = a.Index
+		ctx.Count = a.Count
+		ctx.Var = vars
+
+		_, name, err := LoadRoleWithNameResolution(
+			ResolveRolePath(roleName), &ctx,
+			tmpl.PodNameFuncs(generateName, existingNames, ordinals), "",
+			func() string {
+				ordinals[roleName]++
+				return fmt.Sprintf("%s-%d", roleName, ordinals[roleName])
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("resolve name for agent at position %d (role %q): %w", i+1, roleName, err)
+		}
+		a.Name = name
+	}
+	return checkNameCollisions(agents)
+}
+
+// checkNameCollisions detects duplicate agent names after expansion. Unnamed
+// agents are skipped; ResolvePodAgentNames checks them once named.
 func checkNameCollisions(agents []ExpandedAgent) error {
 	seen := make(map[string]int) // name → first index in agents slice
 	for i, a := range agents {
+		if a.Name == "" {
+			continue
+		}
 		if prev, ok := seen[a.Name]; ok {
 			return fmt.Errorf("duplicate agent name %q: agent at position %d collides with agent at position %d", a.Name, i+1, prev+1)
 		}
@@ -284,15 +337,13 @@ func ParsePodTemplateRendered(yamlText string, name string, ctx *tmpl.Context) (
 		return nil, fmt.Errorf("pod template %q: %w", name, err)
 	}
 
-	// Clone ctx.Var so we don't mutate the caller's map.
-	vars := make(map[string]string, len(ctx.Var))
-	for k, v := range ctx.Var {
-		vars[k] = v
+	if err := tmpl.ValidateVarDefs(varDefs); err != nil {
+		return nil, fmt.Errorf("pod template %q: %w", name, err)
 	}
-	for k, def := range varDefs {
-		if _, provided := vars[k]; !provided && def.Default != nil {
-			vars[k] = *def.Default
-		}
+	// Returns a copy, so the caller's ctx.Var isn't mutated.
+	vars, err := tmpl.ResolveDefaults(varDefs, ctx.Var)
+	if err != nil {
+		return nil, fmt.Errorf("pod template %q: %w", name, err)
 	}
 
 	// Validate required variables.
@@ -394,11 +445,9 @@ func loadPodTemplateForDisplay(name string) (*PodTemplate, error) {
 		return nil, fmt.Errorf("pod template %q: %w", name, err)
 	}
 
-	vars := make(map[string]string)
-	for k, def := range varDefs {
-		if def.Default != nil {
-			vars[k] = *def.Default
-		}
+	vars, err := tmpl.ResolveDefaults(varDefs, nil)
+	if err != nil {
+		return nil, fmt.Errorf("pod template %q: %w", name, err)
 	}
 
 	// Render with defaults only (no required-var validation).
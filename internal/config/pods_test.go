@@ -295,6 +295,118 @@ func TestExpandPodAgents_DuplicateStaticNames(t *testing.T) {
 	}
 }
 
+func TestExpandPodAgents_UnnamedLeftForResolution(t *testing.T) {
+	pt := &PodTemplate{
+		Agents: []PodTemplateAgent{
+			{Role: "worker", Count: intPtr(2)},
+			{Role: "reviewer"},
+		},
+	}
+	agents, err := ExpandPodAgents(pt)
+	if err != nil {
+		t.Fatalf("ExpandPodAgents: %v", err)
+	}
+	if len(agents) != 3 {
+		t.Fatalf("got %d agents, want 3", len(agents))
+	}
+	for i, a := range agents {
+		if a.Name != "" {
+			t.Errorf("agents[%d].Name = %q, want empty until resolved", i, a.Name)
+		}
+	}
+}
+
+const podWorkerRole = `
+role_name: worker
+agent_name: '{{ autoIncrement "worker" }}'
+instructions: |
+  You are {{ .AgentName }}.
+`
+
+func TestResolvePodAgentNames_UsesIndex(t *testing.T) {
+	h2Dir := setupTestH2Dir(t)
+	os.WriteFile(filepath.Join(h2Dir, "roles", "worker.yaml"), []byte(podWorkerRole), 0o644)
+	os.WriteFile(filepath.Join(h2Dir, "roles", "reviewer.yaml"), []byte("role_name: reviewer\ninstructions: Review.\n"), 0o644)
+
+	agents, err := ExpandPodAgents(&PodTemplate{
+		Agents: []PodTemplateAgent{
+			{Role: "worker", Count: intPtr(3)},
+			{Role: "reviewer"},
+			{Name: "lead", Role: "worker"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ExpandPodAgents: %v", err)
+	}
+	// Running worker-1 and worker-2 must not shift the pod's numbering.
+	existing := []string{"worker-1", "worker-2"}
+	base := &tmpl.Context{PodName: "team", H2Dir: h2Dir}
+	if err := ResolvePodAgentNames(agents, base, func() string { return "random" }, existing); err != nil {
+		t.Fatalf("ResolvePodAgentNames: %v", err)
+	}
+
+	var got []string
+	for _, a := range agents {
+		got = append(got, a.Name)
+	}
+	want := []string{"worker-1", "worker-2", "worker-3", "reviewer-1", "lead"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("names = %v, want %v", got, want)
+	}
+}
+
+func TestResolvePodAgentNames_Collision(t *testing.T) {
+	h2Dir := setupTestH2Dir(t)
+	os.WriteFile(filepath.Join(h2Dir, "roles", "worker.yaml"), []byte(podWorkerRole), 0o644)
+
+	agents, err := ExpandPodAgents(&PodTemplate{
+		Agents: []PodTemplateAgent{
+			{Role: "worker"},
+			{Name: "worker-1", Role: "worker"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ExpandPodAgents: %v", err)
+	}
+	err = ResolvePodAgentNames(agents, &tmpl.Context{H2Dir: h2Dir}, func() string { return "random" }, nil)
+	if err == nil || !strings.Contains(err.Error(), "worker-1") {
+		t.Fatalf("expected collision on worker-1, got %v", err)
+	}
+}
+
+func TestResolvePodAgentNames_NumbersAcrossEntries(t *testing.T) {
+	h2Dir := setupTestH2Dir(t)
+	os.WriteFile(filepath.Join(h2Dir, "roles", "worker.yaml"), []byte(podWorkerRole), 0o644)
+	os.WriteFile(filepath.Join(h2Dir, "roles", "reviewer.yaml"), []byte("role_name: reviewer\ninstructions: Review.\n"), 0o644)
+
+	// Unnamed entries of the same role share one numbering, whether they
+	// use autoIncrement or fall back to the role name.
+	agents, err := ExpandPodAgents(&PodTemplate{
+		Agents: []PodTemplateAgent{
+			{Role: "worker"},
+			{Role: "reviewer"},
+			{Role: "worker", Count: intPtr(2)},
+			{Role: "reviewer"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ExpandPodAgents: %v", err)
+	}
+	base := &tmpl.Context{PodName: "team", H2Dir: h2Dir}
+	if err := ResolvePodAgentNames(agents, base, func() string { return "random" }, []string{"worker-1"}); err != nil {
+		t.Fatalf("ResolvePodAgentNames: %v", err)
+	}
+
+	var got []string
+	for _, a := range agents {
+		got = append(got, a.Name)
+	}
+	want := []string{"worker-1", "reviewer-1", "worker-2", "worker-3", "reviewer-2"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("names = %v, want %v", got, want)
+	}
+}
+
 func TestExpandPodAgents_NegativeCount(t *testing.T) {
 	pt := &PodTemplate{
 		Agents: []PodTemplateAgent{
@@ -491,6 +603,29 @@ agents:
 	}
 }
 
+func TestParsePodTemplateRendered_DefaultReferencesVar(t *testing.T) {
+	yamlText := `variables:
+  team:
+    description: Team name
+  branch_prefix:
+    default: "{{ .Var.team }}/work"
+
+pod_name: backend
+agents:
+  - name: coder
+    role: coding
+    vars:
+      branch: {{ .Var.branch_prefix }}
+`
+	pt, err := ParsePodTemplateRendered(yamlText, "backend", &tmpl.Context{Var: map[string]string{"team": "platform"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := pt.Agents[0].Vars["branch"]; got != "platform/work" {
+		t.Errorf("vars[branch] = %q, want platform/work", got)
+	}
+}
+
 func TestParsePodTemplateRendered_MissingRequiredVar(t *testing.T) {
 	yamlText := `variables:
   team:
@@ -0,0 +1,324 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofrs/flock"
+)
+
+// Profile selection strategies for a role's profiles list.
+const (
+	ProfileStrategyRoundRobin        = "round-robin"         // each launch takes the next profile in list order
+	ProfileStrategyLeastRecentlyUsed = "least-recently-used" // each launch takes the profile unused the longest
+)
+
+// ValidProfileStrategies lists the accepted profile_strategy values.
+var ValidProfileStrategies = []string{ProfileStrategyRoundRobin, ProfileStrategyLeastRecentlyUsed}
+
+// GetProfileStrategy returns the role's profile_strategy, defaulting to
+// round-robin.
+func (r *Role) GetProfileStrategy() string {
+	if r.ProfileStrategy != "" {
+		return r.ProfileStrategy
+	}
+	return ProfileStrategyRoundRobin
+}
+
+// validateProfiles checks the profile, profiles and profile_strategy fields.
+func (r *Role) validateProfiles() error {
+	if len(r.Profiles) == 0 {
+		if r.ProfileStrategy != "" {
+			return fmt.Errorf("profile_strategy requires profiles")
+		}
+		return nil
+	}
+	if strings.TrimSpace(r.Profile) != "" {
+		return fmt.Errorf("profile and profiles are mutually exclusive")
+	}
+	seen := make(map[string]bool, len(r.Profiles))
+	for _, p := range r.Profiles {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			return fmt.Errorf("profiles must not contain empty names")
+		}
+		if seen[p] {
+			return fmt.Errorf("profiles lists %q more than once", p)
+		}
+		seen[p] = true
+	}
+	for _, s := range ValidProfileStrategies {
+		if r.GetProfileStrategy() == s {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid profile_strategy %q; valid values: %s",
+		r.ProfileStrategy, strings.Join(ValidProfileStrategies, ", "))
+}
+
+// SkippedProfile is a profile SelectProfile passed over, and why.
+type SkippedProfile struct {
+	Name   string
+	Reason string
+}
+
+// HasExplicitProfile reports whether the role has a profiles list but its
+// profile was set outright, by --override profile=... or a pod override,
+// so no profile is chosen from the list.
+func (r *Role) HasExplicitProfile() bool {
+	return len(r.Profiles) > 0 && strings.TrimSpace(r.Profile) != "" && !r.profileChosen
+}
+
+// SelectProfile picks the profile to launch with from the role's profiles
+// list using its profile_strategy, sets it as the role's profile and
+// records the choice in the h2 dir so the next launch moves on. Profiles
+// whose config dir is missing, not authenticated or rate limited are
+// skipped. With force, when every profile is skipped, the first in strategy
+// order is used anyway. Roles without a profiles list, or with an explicit
+// profile (see HasExplicitProfile), keep their profile and nothing is
+// recorded.
+func (r *Role) SelectProfile(force bool) ([]SkippedProfile, error) {
+	if len(r.Profiles) == 0 || r.HasExplicitProfile() {
+		return nil, nil
+	}
+
+	fl, err := lockProfileUsage()
+	if err != nil {
+		return nil, err
+	}
+	defer fl.Unlock()
+
+	usage, err := readProfileUsage()
+	if err != nil {
+		return nil, err
+	}
+
+	chosen, skipped, err := r.chooseProfile(usage, force)
+	if err != nil {
+		return skipped, err
+	}
+	usage.record(r, chosen)
+	if err := writeProfileUsage(usage); err != nil {
+		return skipped, err
+	}
+	r.Profile = chosen
+	r.profileChosen = true
+	return skipped, nil
+}
+
+// ProfilePreview predicts the profiles successive launches would choose,
+// for --dry-run, without recording anything.
+type ProfilePreview struct {
+	usage *profileUsage
+}
+
+// NewProfilePreview returns a preview starting from the recorded usage.
+func NewProfilePreview() (*ProfilePreview, error) {
+	usage, err := readProfileUsage()
+	if err != nil {
+		return nil, err
+	}
+	return &ProfilePreview{usage: usage}, nil
+}
+
+// Select sets the role's profile to the one SelectProfile would choose, as
+// if the roles previously passed to Select had launched first. When every
+// profile would be skipped, it picks the one --force would launch with.
+func (p *ProfilePreview) Select(r *Role) []SkippedProfile {
+	if len(r.Profiles) == 0 || r.HasExplicitProfile() {
+		return nil
+	}
+	chosen, skipped, _ := r.chooseProfile(p.usage, true)
+	p.usage.record(r, chosen)
+	r.Profile = chosen
+	r.profileChosen = true
+	return skipped
+}
+
+// chooseProfile returns the profile SelectProfile would choose given usage,
+// and the profiles skipped on the way.
+func (r *Role) chooseProfile(usage *profileUsage, force bool) (string, []SkippedProfile, error) {
+	prefix := r.harnessConfigPathPrefix()
+	order := r.profileOrder(prefix, usage)
+	var skipped []SkippedProfile
+	for _, p := range order {
+		if prefix != "" {
+			if reason := r.profileUnavailable(filepath.Join(prefix, p)); reason != "" {
+				skipped = append(skipped, SkippedProfile{Name: p, Reason: reason})
+				continue
+			}
+		}
+		return p, skipped, nil
+	}
+	if !force {
+		var reasons []string
+		for _, s := range skipped {
+			reasons = append(reasons, fmt.Sprintf("%s (%s)", s.Name, s.Reason))
+		}
+		return "", skipped, fmt.Errorf("no usable profile for role %q: %s (pass --force to launch anyway)",
+			r.RoleName, strings.Join(reasons, ", "))
+	}
+	return order[0], skipped, nil
+}
+
+// profileOrder returns the role's profiles in the order SelectProfile tries
+// them. Round-robin starts after the profile the role last chose;
+// least-recently-used starts with the profile unused the longest by any
+// role, with never-used profiles first and ties kept in list order.
+func (r *Role) profileOrder(prefix string, usage *profileUsage) []string {
+	profiles := make([]string, len(r.Profiles))
+	for i, p := range r.Profiles {
+		profiles[i] = strings.TrimSpace(p)
+	}
+
+	if r.GetProfileStrategy() == ProfileStrategyLeastRecentlyUsed {
+		sort.SliceStable(profiles, func(i, j int) bool {
+			return usage.LastUsed[profileUsageKey(prefix, profiles[i])].Before(usage.LastUsed[profileUsageKey(prefix, profiles[j])])
+		})
+		return profiles
+	}
+
+	start := 0
+	for i, p := range profiles {
+		if p == usage.LastChosen[r.RoleName] {
+			start = i + 1
+			break
+		}
+	}
+	start %= len(profiles)
+	return append(profiles[start:], profiles[:start]...)
+}
+
+// harnessConfigPathPrefix returns the config path prefix for the role's
+// harness, or "" when h2 doesn't manage its config dirs.
+func (r *Role) harnessConfigPathPrefix() string {
+	switch r.GetHarnessType() {
+	case "codex":
+		return r.GetCodexConfigPathPrefix()
+	case "generic":
+		return ""
+	default:
+		return expandClaudeConfigDir(r.GetClaudeConfigPathPrefix())
+	}
+}
+
+// profileUnavailable returns why the profile config dir at dir can't be
+// launched with, or "" if it can. An API key in the environment stands in
+// for stored credentials, as it does for the launch check.
+func (r *Role) profileUnavailable(dir string) string {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "missing"
+	}
+	if IsProfileRateLimited(dir) != nil {
+		return "rate limited"
+	}
+	if IsProfileAuthError(dir) != nil {
+		return "auth error"
+	}
+	var ok bool
+	var err error
+	switch r.GetHarnessType() {
+	case "codex":
+		if os.Getenv("OPENAI_API_KEY") != "" {
+			return ""
+		}
+		ok, err = IsCodexConfigAuthenticated(dir)
+	default:
+		if os.Getenv("ANTHROPIC_API_KEY") != "" {
+			return ""
+		}
+		ok, err = IsClaudeConfigAuthenticated(dir)
+	}
+	if err != nil || !ok {
+		return "not authenticated"
+	}
+	return ""
+}
+
+// profileUsage is the profile selection state in profile-usage.json.
+type profileUsage struct {
+	LastUsed   map[string]time.Time `json:"last_used"`   // harness config dir/profile -> when a launch last chose it
+	LastChosen map[string]string    `json:"last_chosen"` // role name -> profile its last launch chose
+}
+
+// record notes that a launch of r chose profile.
+func (u *profileUsage) record(r *Role, profile string) {
+	u.LastUsed[profileUsageKey(r.harnessConfigPathPrefix(), profile)] = time.Now().UTC()
+	u.LastChosen[r.RoleName] = profile
+}
+
+// profileUsageKey identifies a profile across harnesses: its config dir
+// relative to the h2 dir when it's inside it.
+func profileUsageKey(prefix, profile string) string {
+	dir := filepath.Join(prefix, profile)
+	if rel, err := filepath.Rel(ConfigDir(), dir); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return dir
+}
+
+func profileUsagePath() string {
+	return filepath.Join(ConfigDir(), "profile-usage.json")
+}
+
+// lockProfileUsage takes an exclusive lock on profile-usage.json so
+// concurrent launches don't choose the same profile.
+func lockProfileUsage() (*flock.Flock, error) {
+	if err := os.MkdirAll(ConfigDir(), 0o755); err != nil {
+		return nil, fmt.Errorf("create h2 dir: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), lockTimeout)
+	defer cancel()
+
+	lockPath := profileUsagePath() + ".lock"
+	fl := flock.New(lockPath)
+	ok, err := fl.TryLockContext(ctx, 50*time.Millisecond)
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("acquire profile usage lock: %w", err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("acquire profile usage lock: timed out after %s waiting for %s; another h2 process is holding it", lockTimeout, lockPath)
+	}
+	return fl, nil
+}
+
+// readProfileUsage reads profile-usage.json. A missing file is empty usage.
+func readProfileUsage() (*profileUsage, error) {
+	usage := &profileUsage{}
+	data, err := os.ReadFile(profileUsagePath())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read profile usage: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, usage); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", profileUsagePath(), err)
+		}
+	}
+	if usage.LastUsed == nil {
+		usage.LastUsed = make(map[string]time.Time)
+	}
+	if usage.LastChosen == nil {
+		usage.LastChosen = make(map[string]string)
+	}
+	return usage, nil
+}
+
+func writeProfileUsage(usage *profileUsage) error {
+	data, err := json.MarshalIndent(usage, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if err := os.WriteFile(profileUsagePath(), data, 0o644); err != nil {
+		return fmt.Errorf("write profile usage: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// makeClaudeProfiles creates Claude profile dirs under the h2 dir, logged
+// in unless listed in loggedOut.
+func makeClaudeProfiles(t *testing.T, h2Dir string, names []string, loggedOut ...string) {
+	t.Helper()
+	for _, name := range names {
+		dir := filepath.Join(h2Dir, "claude-config", name)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		out := false
+		for _, l := range loggedOut {
+			out = out || l == name
+		}
+		if out {
+			continue
+		}
+		data := `{"oauthAccount":{"accountUuid":"u-1","emailAddress":"test@example.com"}}`
+		if err := os.WriteFile(filepath.Join(dir, ".claude.json"), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func selectProfiles(t *testing.T, role *Role, n int) []string {
+	t.Helper()
+	var got []string
+	for i := 0; i < n; i++ {
+		if _, err := role.SelectProfile(false); err != nil {
+			t.Fatalf("SelectProfile: %v", err)
+		}
+		got = append(got, role.GetProfile())
+	}
+	return got
+}
+
+func TestSelectProfile_RoundRobin(t *testing.T) {
+	h2Dir := setupTestH2Dir(t)
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	makeClaudeProfiles(t, h2Dir, []string{"a", "b", "c"})
+	role := &Role{RoleName: "coder", Profiles: []string{"a", "b", "c"}}
+
+	got := selectProfiles(t, role, 4)
+	if want := "a b c a"; strings.Join(got, " ") != want {
+		t.Errorf("profiles = %v, want %s", got, want)
+	}
+
+	// The rotation is per role and survives across launches.
+	again := &Role{RoleName: "coder", Profiles: []string{"a", "b", "c"}}
+	if got := selectProfiles(t, again, 1); got[0] != "b" {
+		t.Errorf("next launch got %q, want b", got[0])
+	}
+	other := &Role{RoleName: "reviewer", Profiles: []string{"a", "b", "c"}}
+	if got := selectProfiles(t, other, 1); got[0] != "a" {
+		t.Errorf("other role got %q, want a", got[0])
+	}
+}
+
+func TestSelectProfile_LeastRecentlyUsed(t *testing.T) {
+	h2Dir := setupTestH2Dir(t)
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	makeClaudeProfiles(t, h2Dir, []string{"a", "b", "c"})
+
+	// Another role used b, so it's the most recent.
+	if _, err := (&Role{RoleName: "other", Profiles: []string{"b"}}).SelectProfile(false); err != nil {
+		t.Fatal(err)
+	}
+	role := &Role{RoleName: "coder", Profiles: []string{"b", "a", "c"}, ProfileStrategy: ProfileStrategyLeastRecentlyUsed}
+	got := selectProfiles(t, role, 3)
+	if want := "a c b"; strings.Join(got, " ") != want {
+		t.Errorf("profiles = %v, want %s", got, want)
+	}
+}
+
+func TestSelectProfile_SkipsUnusable(t *testing.T) {
+	h2Dir := setupTestH2Dir(t)
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	makeClaudeProfiles(t, h2Dir, []string{"a", "b", "c"}, "a")
+	if err := WriteRateLimit(filepath.Join(h2Dir, "claude-config", "b"), &RateLimitInfo{ResetsAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	role := &Role{RoleName: "coder", Profiles: []string{"a", "b", "missing", "c"}}
+
+	skipped, err := role.SelectProfile(false)
+	if err != nil {
+		t.Fatalf("SelectProfile: %v", err)
+	}
+	if role.GetProfile() != "c" {
+		t.Errorf("profile = %q, want c", role.GetProfile())
+	}
+	want := []SkippedProfile{{"a", "not authenticated"}, {"b", "rate limited"}, {"missing", "missing"}}
+	if len(skipped) != len(want) {
+		t.Fatalf("skipped = %v, want %v", skipped, want)
+	}
+	for i := range want {
+		if skipped[i] != want[i] {
+			t.Errorf("skipped[%d] = %v, want %v", i, skipped[i], want[i])
+		}
+	}
+}
+
+func TestSelectProfile_NoneUsable(t *testing.T) {
+	h2Dir := setupTestH2Dir(t)
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	makeClaudeProfiles(t, h2Dir, []string{"a", "b"}, "a", "b")
+	role := &Role{RoleName: "coder", Profiles: []string{"a", "b"}}
+
+	_, err := role.SelectProfile(false)
+	if err == nil {
+		t.Fatal("expected error when no profile is authenticated")
+	}
+	for _, want := range []string{"a (not authenticated)", "b (not authenticated)", "--force"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q missing %q", err, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(h2Dir, "profile-usage.json")); !os.IsNotExist(err) {
+		t.Errorf("a failed selection should not be recorded (err %v)", err)
+	}
+
+	if _, err := role.SelectProfile(true); err != nil {
+		t.Fatalf("SelectProfile(force): %v", err)
+	}
+	if role.GetProfile() != "a" {
+		t.Errorf("forced profile = %q, want a", role.GetProfile())
+	}
+
+	// An API key stands in for stored credentials.
+	t.Setenv("ANTHROPIC_API_KEY", "sk-test")
+	if _, err := role.SelectProfile(false); err != nil {
+		t.Fatalf("SelectProfile with API key: %v", err)
+	}
+}
+
+func TestRoleValidate_Profiles(t *testing.T) {
+	tests := []struct {
+		name string
+		role Role
+		want string
+	}{
+		{"both profile and profiles", Role{Profile: "a", Profiles: []string{"b"}}, "mutually exclusive"},
+		{"duplicate", Role{Profiles: []string{"a", "a"}}, `lists "a" more than once`},
+		{"empty name", Role{Profiles: []string{"a", " "}}, "empty names"},
+		{"bad strategy", Role{Profiles: []string{"a"}, ProfileStrategy: "random"}, `invalid profile_strategy "random"`},
+		{"strategy without profiles", Role{ProfileStrategy: ProfileStrategyRoundRobin}, "requires profiles"},
+		{"valid", Role{Profiles: []string{"a", "b"}, ProfileStrategy: ProfileStrategyLeastRecentlyUsed}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.role.RoleName = "r"
+			err := tt.role.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectProfile_KeepsExplicitProfile(t *testing.T) {
+	h2Dir := setupTestH2Dir(t)
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	makeClaudeProfiles(t, h2Dir, []string{"a", "b"})
+	role := &Role{RoleName: "coder", Profiles: []string{"a", "b"}}
+	if err := ApplyOverrides(role, []string{"profile=b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := selectProfiles(t, role, 2); strings.Join(got, " ") != "b b" {
+		t.Errorf("profiles = %v, want the explicit b", got)
+	}
+	if _, err := os.Stat(filepath.Join(h2Dir, "profile-usage.json")); !os.IsNotExist(err) {
+		t.Errorf("an explicit profile should not be recorded (err %v)", err)
+	}
+}
+
+func TestProfilePreview_MatchesSelection(t *testing.T) {
+	h2Dir := setupTestH2Dir(t)
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	makeClaudeProfiles(t, h2Dir, []string{"a", "b", "c"}, "b")
+	if _, err := (&Role{RoleName: "coder", Profiles: []string{"a", "b", "c"}}).SelectProfile(false); err != nil {
+		t.Fatal(err)
+	}
+
+	preview, err := NewProfilePreview()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for i := 0; i < 3; i++ {
+		role := &Role{RoleName: "coder", Profiles: []string{"a", "b", "c"}}
+		preview.Select(role)
+		got = append(got, role.GetProfile())
+	}
+	if want := "c a c"; strings.Join(got, " ") != want {
+		t.Errorf("previewed profiles = %v, want %s", got, want)
+	}
+
+	// Nothing was recorded: the next launch still gets c.
+	role := &Role{RoleName: "coder", Profiles: []string{"a", "b", "c"}}
+	if got := selectProfiles(t, role, 1); got[0] != "c" {
+		t.Errorf("launch after preview got %q, want c", got[0])
+	}
+}
@@ -2,9 +2,11 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"text/template"
@@ -16,10 +18,202 @@ import (
 )
 
 // HeartbeatConfig defines a heartbeat nudge mechanism for idle agents.
+//
+// The condition field accepts either a single shell command or a list form:
+//
+//	condition: "bd ready -q"        # single command
+//	condition: ["cmd1", "cmd2"]     # all commands must pass
+//	condition: {any: ["cmd1", ...]} # at least one command must pass
+//	condition: {all: ["cmd1", ...]} # explicit form of the plain list
+//
+// A command passes when it exits 0; any other outcome (non-zero exit,
+// command not found, timeout) counts as the condition being unmet.
+//
+// idle_requires narrows what counts as idle for the heartbeat, e.g.
+// [not_blocked_on_permission] skips firings while a tool approval is
+// outstanding.
 type HeartbeatConfig struct {
-	IdleTimeout string `yaml:"idle_timeout"`
-	Message     string `yaml:"message"`
-	Condition   string `yaml:"condition,omitempty"`
+	IdleTimeout  string   `yaml:"idle_timeout"`
+	Message      string   `yaml:"message"`
+	Condition    string   `yaml:"condition,omitempty"` // single-command form
+	IdleRequires []string `yaml:"idle_requires,omitempty"`
+	PodStrategy  string   `yaml:"pod_strategy,omitempty"` // leader | staggered; empty: each agent on its own
+
+	// Conditions and ConditionCombine hold the list form of condition.
+	// When Condition is also set (e.g. via --override heartbeat.condition=...),
+	// the single command takes precedence.
+	Conditions       []string `yaml:"-"`
+	ConditionCombine string   `yaml:"-"` // "all" (default) or "any"
+}
+
+// Heartbeat condition combiners.
+const (
+	HeartbeatCombineAll = "all"
+	HeartbeatCombineAny = "any"
+)
+
+// Heartbeat idle requirements.
+const (
+	// HeartbeatIdleNotBlockedOnPermission holds the heartbeat while the
+	// agent is waiting on (or reviewing) a tool permission request.
+	HeartbeatIdleNotBlockedOnPermission = "not_blocked_on_permission"
+)
+
+// ValidHeartbeatIdleRequires lists valid values for heartbeat.idle_requires.
+var ValidHeartbeatIdleRequires = []string{
+	HeartbeatIdleNotBlockedOnPermission,
+}
+
+// Heartbeat pod strategies: how agents a pod starts from one entry with count
+// share a heartbeat, so they don't all run its condition at once. Agents
+// outside a pod ignore them.
+const (
+	// HeartbeatPodLeader runs the heartbeat only on the Index 0 agent.
+	HeartbeatPodLeader = "leader"
+	// HeartbeatPodStaggered offsets each agent's heartbeat by Index/Count
+	// of the interval.
+	HeartbeatPodStaggered = "staggered"
+)
+
+// ValidHeartbeatPodStrategies lists valid values for heartbeat.pod_strategy.
+var ValidHeartbeatPodStrategies = []string{
+	HeartbeatPodLeader,
+	HeartbeatPodStaggered,
+}
+
+// heartbeatIdleGuards maps each idle requirement to a shell test over the
+// H2_AGENT_SUBSTATE env var the schedule engine sets for conditions.
+var heartbeatIdleGuards = map[string]string{
+	HeartbeatIdleNotBlockedOnPermission: `[ "$H2_AGENT_SUBSTATE" != blocked_on_permission ] && [ "$H2_AGENT_SUBSTATE" != permission_review ]`,
+}
+
+// heartbeatConfigYAML mirrors HeartbeatConfig with a raw condition node.
+type heartbeatConfigYAML struct {
+	IdleTimeout  string    `yaml:"idle_timeout"`
+	Message      string    `yaml:"message"`
+	Condition    yaml.Node `yaml:"condition,omitempty"`
+	IdleRequires []string  `yaml:"idle_requires,omitempty"`
+	PodStrategy  string    `yaml:"pod_strategy,omitempty"`
+}
+
+// UnmarshalYAML decodes a heartbeat config, accepting both the single-string
+// and list forms of condition.
+func (k *HeartbeatConfig) UnmarshalYAML(value *yaml.Node) error {
+	var aux heartbeatConfigYAML
+	if err := value.Decode(&aux); err != nil {
+		return err
+	}
+	*k = HeartbeatConfig{IdleTimeout: aux.IdleTimeout, Message: aux.Message, IdleRequires: aux.IdleRequires, PodStrategy: aux.PodStrategy}
+
+	cond := &aux.Condition
+	switch cond.Kind {
+	case 0:
+		// condition omitted.
+	case yaml.ScalarNode:
+		if cond.Tag == "!!null" {
+			return nil
+		}
+		k.Condition = cond.Value
+	case yaml.SequenceNode:
+		if err := cond.Decode(&k.Conditions); err != nil {
+			return fmt.Errorf("heartbeat.condition: %w", err)
+		}
+		k.ConditionCombine = HeartbeatCombineAll
+	case yaml.MappingNode:
+		var m map[string][]string
+		if err := cond.Decode(&m); err != nil {
+			return fmt.Errorf("heartbeat.condition: expected {all: [...]} or {any: [...]}: %w", err)
+		}
+		if len(m) != 1 {
+			return fmt.Errorf("heartbeat.condition: mapping form must set exactly one of %q or %q", HeartbeatCombineAll, HeartbeatCombineAny)
+		}
+		for combine, cmds := range m {
+			if combine != HeartbeatCombineAll && combine != HeartbeatCombineAny {
+				return fmt.Errorf("heartbeat.condition: unknown combiner %q; valid values: %s, %s", combine, HeartbeatCombineAll, HeartbeatCombineAny)
+			}
+			k.Conditions = cmds
+			k.ConditionCombine = combine
+		}
+	default:
+		return fmt.Errorf("heartbeat.condition: expected a string, list, or {all|any: [...]} mapping")
+	}
+	return nil
+}
+
+// MarshalYAML encodes a heartbeat config, emitting the list form of
+// condition as an {all|any: [...]} mapping.
+func (k HeartbeatConfig) MarshalYAML() (interface{}, error) {
+	out := map[string]interface{}{
+		"idle_timeout": k.IdleTimeout,
+		"message":      k.Message,
+	}
+	if k.Condition != "" {
+		out["condition"] = k.Condition
+	} else if len(k.Conditions) > 0 {
+		out["condition"] = map[string][]string{k.GetConditionCombine(): k.Conditions}
+	}
+	if len(k.IdleRequires) > 0 {
+		out["idle_requires"] = k.IdleRequires
+	}
+	if k.PodStrategy != "" {
+		out["pod_strategy"] = k.PodStrategy
+	}
+	return out, nil
+}
+
+// GetConditionCombine returns the combiner for the list form, defaulting to "all".
+func (k *HeartbeatConfig) GetConditionCombine() string {
+	if k.ConditionCombine != "" {
+		return k.ConditionCombine
+	}
+	return HeartbeatCombineAll
+}
+
+// ConditionCommand returns the heartbeat condition as a single shell command,
+// including any idle_requires guards. The list form is joined with && (all)
+// or || (any); each command runs in its own subshell so its exit status
+// counts as one boolean and a failing command only makes its own term false.
+// Returns "" when no condition is configured.
+func (k *HeartbeatConfig) ConditionCommand() string {
+	cond := k.userConditionCommand()
+	var guards []string
+	for _, req := range k.IdleRequires {
+		if g, ok := heartbeatIdleGuards[req]; ok {
+			guards = append(guards, "(\n"+g+"\n)")
+		}
+	}
+	if len(guards) == 0 {
+		return cond
+	}
+	if cond != "" {
+		guards = append([]string{"(\n" + cond + "\n)"}, guards...)
+	}
+	return strings.Join(guards, " && ")
+}
+
+// userConditionCommand returns the configured condition (without
+// idle_requires guards) as a single shell command.
+func (k *HeartbeatConfig) userConditionCommand() string {
+	if k.Condition != "" {
+		return k.Condition
+	}
+	var terms []string
+	for _, c := range k.Conditions {
+		if strings.TrimSpace(c) == "" {
+			continue
+		}
+		// Newlines (not spaces) around the command so a trailing
+		// comment cannot swallow the closing parenthesis.
+		terms = append(terms, "(\n"+c+"\n)")
+	}
+	if len(terms) == 0 {
+		return ""
+	}
+	op := " && "
+	if k.GetConditionCombine() == HeartbeatCombineAny {
+		op = " || "
+	}
+	return strings.Join(terms, op)
 }
 
 // ParseIdleTimeout parses the IdleTimeout string as a Go duration.
@@ -27,6 +221,80 @@ func (k *HeartbeatConfig) ParseIdleTimeout() (time.Duration, error) {
 	return time.ParseDuration(k.IdleTimeout)
 }
 
+// PostLaunchCommand is a shell command h2 runs in the agent's working dir
+// before the agent starts, e.g. "direnv allow" or seeding a scratch DB.
+type PostLaunchCommand struct {
+	Command       string `yaml:"command"`
+	IgnoreFailure bool   `yaml:"ignore_failure,omitempty"` // launch the agent even if the command fails
+	Timeout       string `yaml:"timeout,omitempty"`        // Go duration; empty = DefaultPostLaunchTimeout
+}
+
+// DefaultPostLaunchTimeout is how long a post_launch command may run before
+// it is killed and counted as failed, unless it sets timeout.
+const DefaultPostLaunchTimeout = 5 * time.Minute
+
+// GetTimeout returns the command's timeout, DefaultPostLaunchTimeout if
+// unset. Call after validation; an invalid value also returns the default.
+func (c PostLaunchCommand) GetTimeout() time.Duration {
+	if d, err := time.ParseDuration(c.Timeout); err == nil && d > 0 {
+		return d
+	}
+	return DefaultPostLaunchTimeout
+}
+
+// PostLaunchCommands is a role's post_launch setting, run in order.
+type PostLaunchCommands []PostLaunchCommand
+
+// UnmarshalYAML decodes post_launch, accepting a single command string or a
+// list whose entries are command strings or {command, ignore_failure,
+// timeout} mappings.
+func (p *PostLaunchCommands) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		if value.Tag == "!!null" {
+			*p = nil
+			return nil
+		}
+		*p = PostLaunchCommands{{Command: value.Value}}
+	case yaml.SequenceNode:
+		cmds := make(PostLaunchCommands, 0, len(value.Content))
+		for i, item := range value.Content {
+			var c PostLaunchCommand
+			switch item.Kind {
+			case yaml.ScalarNode:
+				c.Command = item.Value
+			case yaml.MappingNode:
+				if err := item.Decode(&c); err != nil {
+					return fmt.Errorf("post_launch[%d]: %w", i, err)
+				}
+			default:
+				return fmt.Errorf("post_launch[%d]: expected a command string or {command, ignore_failure, timeout} mapping", i)
+			}
+			cmds = append(cmds, c)
+		}
+		*p = cmds
+	default:
+		return fmt.Errorf("post_launch: expected a command string or a list of commands")
+	}
+	return nil
+}
+
+// validate checks that every post_launch entry has a command and a valid
+// timeout.
+func (p PostLaunchCommands) validate() error {
+	for i, c := range p {
+		if strings.TrimSpace(c.Command) == "" {
+			return fmt.Errorf("post_launch[%d]: command is required", i)
+		}
+		if c.Timeout != "" {
+			if d, err := time.ParseDuration(c.Timeout); err != nil || d <= 0 {
+				return fmt.Errorf("post_launch[%d]: invalid timeout %q", i, c.Timeout)
+			}
+		}
+	}
+	return nil
+}
+
 // TriggerYAMLSpec defines a trigger in role YAML.
 type TriggerYAMLSpec struct {
 	ID        string `yaml:"id,omitempty"`
@@ -71,6 +339,8 @@ type WorktreeConfig struct {
 	Path       string
 	BranchFrom string
 	Branch     string
+	GitAuthor  string // user.name for commits in the worktree; empty inherits the host's
+	GitEmail   string // user.email for commits in the worktree; empty inherits the host's
 }
 
 // GetBranchFrom returns the branch to base the worktree on, defaulting to "main".
@@ -145,14 +415,32 @@ func (w *WorktreeConfig) Validate() error {
 	if !w.IsDetachedHead() && w.GetBranch() == "" {
 		return fmt.Errorf("worktree_branch is required when worktree_name is empty")
 	}
+	if w.GitEmail != "" && !looksLikeEmail(w.GitEmail) {
+		return fmt.Errorf("worktree_git_email %q is not an email address", w.GitEmail)
+	}
 	return nil
 }
 
+// looksLikeEmail reports whether s has the shape local@domain, without
+// whitespace. It deliberately accepts anything git would, such as
+// "{{ .AgentName }}@agents.local" rendered with a placeholder name.
+func looksLikeEmail(s string) bool {
+	local, domain, ok := strings.Cut(s, "@")
+	return ok && local != "" && domain != "" &&
+		!strings.Contains(domain, "@") && !strings.ContainsAny(s, " \t\r\n")
+}
+
 // WorktreesDir returns <h2-dir>/worktrees/.
 func WorktreesDir() string {
 	return filepath.Join(ConfigDir(), "worktrees")
 }
 
+// NamesDir returns <h2-dir>/names/, which holds custom word lists for
+// random agent names.
+func NamesDir() string {
+	return filepath.Join(ConfigDir(), "names")
+}
+
 // ValidClaudePermissionModes lists all valid values for the claude_permission_mode field.
 var ValidClaudePermissionModes = []string{
 	"default", "acceptEdits", "plan", "dontAsk", "bypassPermissions",
@@ -266,21 +554,28 @@ type Role struct {
 	Description string `yaml:"description,omitempty"`
 
 	// Harness fields.
-	AgentHarness               string `yaml:"agent_harness,omitempty"`                  // claude_code | codex | generic
-	AgentModel                 string `yaml:"agent_model,omitempty"`                    // explicit model; empty => agent app's own default
-	AgentHarnessCommand        string `yaml:"agent_harness_command,omitempty"`          // command override for any harness
-	Profile                    string `yaml:"profile,omitempty"`                        // profile name (default: "default")
-	ClaudeCodeConfigPathPrefix string `yaml:"claude_code_config_path_prefix,omitempty"` // parent dir for Claude config profiles; default: <H2Dir>/claude-config
-	CodexConfigPathPrefix      string `yaml:"codex_config_path_prefix,omitempty"`       // parent dir for Codex config profiles; default: <H2Dir>/codex-config
+	AgentHarness               string            `yaml:"agent_harness,omitempty"`                  // claude_code | codex | generic
+	AgentModel                 string            `yaml:"agent_model,omitempty"`                    // explicit model; empty => agent app's own default
+	AgentModels                map[string]string `yaml:"agent_models,omitempty"`                   // per-harness model, keyed by harness type; overrides agent_model
+	AgentHarnessCommand        string            `yaml:"agent_harness_command,omitempty"`          // command override for any harness
+	Profile                    string            `yaml:"profile,omitempty"`                        // profile name (default: "default")
+	Profiles                   []string          `yaml:"profiles,omitempty"`                       // profiles to choose from at launch, instead of profile
+	ProfileStrategy            string            `yaml:"profile_strategy,omitempty"`               // how profiles are chosen: round-robin (default) | least-recently-used
+	ClaudeCodeConfigPathPrefix string            `yaml:"claude_code_config_path_prefix,omitempty"` // parent dir for Claude config profiles; default: <H2Dir>/claude-config
+	CodexConfigPathPrefix      string            `yaml:"codex_config_path_prefix,omitempty"`       // parent dir for Codex config profiles; default: <H2Dir>/codex-config
 
 	WorkingDir              string                 `yaml:"working_dir,omitempty"`               // agent CWD (default ".")
+	CreateWorkingDir        bool                   `yaml:"create_working_dir,omitempty"`        // create working_dir at launch if missing
 	AdditionalDirs          []string               `yaml:"additional_dirs,omitempty"`           // extra dirs passed via --add-dir
+	SkillsDir               string                 `yaml:"skills_dir,omitempty"`                // skills dir linked into the session, on top of the profile's skills
 	WorktreeEnabled         bool                   `yaml:"worktree_enabled,omitempty"`          // enable git worktree mode
 	WorktreeName            string                 `yaml:"worktree_name,omitempty"`             // worktree name
 	WorktreePathPrefix      string                 `yaml:"worktree_path_prefix,omitempty"`      // defaults to <h2-dir>/worktrees
 	WorktreePath            string                 `yaml:"worktree_path,omitempty"`             // explicit worktree path override
 	WorktreeBranchFrom      string                 `yaml:"worktree_branch_from,omitempty"`      // defaults to "main"
 	WorktreeBranch          string                 `yaml:"worktree_branch,omitempty"`           // defaults to worktree_name; supports "<detached_head>"
+	WorktreeGitAuthor       string                 `yaml:"worktree_git_author,omitempty"`       // user.name set in the worktree; default: host identity
+	WorktreeGitEmail        string                 `yaml:"worktree_git_email,omitempty"`        // user.email set in the worktree; default: host identity
 	SystemPrompt            string                 `yaml:"system_prompt,omitempty"`             // replaces Claude's entire default system prompt (--system-prompt)
 	Instructions            string                 `yaml:"instructions,omitempty"`              // appended to default system prompt (--append-system-prompt)
 	InstructionsIntro       string                 `yaml:"instructions_intro,omitempty"`        // split instructions: intro
@@ -288,16 +583,50 @@ type Role struct {
 	InstructionsAdditional1 string                 `yaml:"instructions_additional_1,omitempty"` // split instructions: additional 1
 	InstructionsAdditional2 string                 `yaml:"instructions_additional_2,omitempty"` // split instructions: additional 2
 	InstructionsAdditional3 string                 `yaml:"instructions_additional_3,omitempty"` // split instructions: additional 3
+	InitialPrompt           string                 `yaml:"initial_prompt,omitempty"`            // sent as the agent's first message once it is idle after a fresh launch
 	ClaudePermissionMode    string                 `yaml:"claude_permission_mode,omitempty"`    // Claude Code --permission-mode flag
 	CodexSandboxMode        string                 `yaml:"codex_sandbox_mode,omitempty"`        // Codex --sandbox flag
 	CodexAskForApproval     string                 `yaml:"codex_ask_for_approval,omitempty"`    // Codex --ask-for-approval flag
 	PermissionReview        *PermissionReview      `yaml:"permission_review,omitempty"`         // Permission handling strategies (DCG + AI reviewer)
+	AllowedTools            []string               `yaml:"allowed_tools,omitempty"`             // tools always allowed, independent of permission mode
+	DeniedTools             []string               `yaml:"denied_tools,omitempty"`              // tools always denied, independent of permission mode
+	PostLaunch              PostLaunchCommands     `yaml:"post_launch,omitempty"`               // setup commands run in the working dir before the agent starts
+	ReloadOnChange          bool                   `yaml:"reload_on_change,omitempty"`          // re-read this file while the agent runs and apply what can change live
 	Heartbeat               *HeartbeatConfig       `yaml:"heartbeat,omitempty"`
 	Triggers                []TriggerYAMLSpec      `yaml:"triggers,omitempty"`
 	Schedules               []ScheduleYAMLSpec     `yaml:"schedules,omitempty"`
-	Hooks                   yaml.Node              `yaml:"hooks,omitempty"`     // passed through as-is to settings.json
-	Settings                yaml.Node              `yaml:"settings,omitempty"`  // extra settings.json keys
-	Variables               map[string]tmpl.VarDef `yaml:"variables,omitempty"` // template variable definitions
+	Hooks                   yaml.Node              `yaml:"hooks,omitempty"`        // passed through as-is to settings.json
+	Settings                yaml.Node              `yaml:"settings,omitempty"`     // extra settings.json keys
+	StrictHooks             bool                   `yaml:"strict_hooks,omitempty"` // unknown hook events fail validation instead of warning
+	Variables               map[string]tmpl.VarDef `yaml:"variables,omitempty"`    // template variable definitions
+
+	// secretValues holds the rendered values of variables marked secret, so
+	// output that echoes the role can mask them.
+	secretValues []string `yaml:"-"`
+
+	// renderCtx is the template context the role was rendered with, so it
+	// can be rendered again the same way when reloaded.
+	renderCtx *tmpl.Context `yaml:"-"`
+
+	// profileChosen is set once SelectProfile has set Profile from the
+	// profiles list, so a later selection doesn't take it as explicit.
+	profileChosen bool `yaml:"-"`
+}
+
+// SecretValues returns the rendered values of the role's secret variables.
+func (r *Role) SecretValues() []string {
+	return r.secretValues
+}
+
+// RenderContext returns the template context the role was rendered with,
+// variable defaults included, or nil if it wasn't rendered.
+func (r *Role) RenderContext() *tmpl.Context {
+	return r.renderCtx
+}
+
+// Redact masks the values of the role's secret variables in s.
+func (r *Role) Redact(s string) string {
+	return tmpl.Redact(s, r.secretValues)
 }
 
 // UnmarshalYAML decodes a role from YAML.
@@ -331,12 +660,56 @@ func (r *Role) ResolveWorkingDir(invocationCWD string) (string, error) {
 	return filepath.Join(h2Dir, dir), nil
 }
 
+// EnsureWorkingDir creates dir, the resolved working directory, when
+// create_working_dir is set. It does nothing otherwise, leaving a missing
+// directory to fail at launch.
+func (r *Role) EnsureWorkingDir(dir string) error {
+	if !r.CreateWorkingDir {
+		return nil
+	}
+	if err := r.validateCreateWorkingDir(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return fmt.Errorf("create working_dir %s: permission denied (create it yourself or choose a writable path): %w", dir, err)
+		}
+		return fmt.Errorf("create working_dir %s: %w", dir, err)
+	}
+	return nil
+}
+
+// validateCreateWorkingDir checks that create_working_dir only applies to a
+// working_dir h2 may create: an absolute path, or a relative path that stays
+// inside the h2 dir. The invocation CWD always exists, and a path that climbs
+// out of the h2 dir would create directories under an unexpected parent.
+func (r *Role) validateCreateWorkingDir() error {
+	if !r.CreateWorkingDir {
+		return nil
+	}
+	dir := r.WorkingDir
+	if dir == "" || dir == "." {
+		return fmt.Errorf("create_working_dir requires working_dir to be an absolute path or a path relative to the h2 dir")
+	}
+	if r.WorktreeEnabled {
+		return fmt.Errorf("create_working_dir cannot be used with worktree_enabled (working_dir must be an existing git repository)")
+	}
+	if !filepath.IsAbs(dir) {
+		if clean := filepath.Clean(dir); clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("create_working_dir: working_dir %q is outside the h2 dir; use an absolute path to create it elsewhere", dir)
+		}
+	}
+	return nil
+}
+
 func (r *Role) hasWorktreeFields() bool {
 	return r.WorktreeName != "" ||
 		r.WorktreePathPrefix != "" ||
 		r.WorktreePath != "" ||
 		r.WorktreeBranchFrom != "" ||
-		r.WorktreeBranch != ""
+		r.WorktreeBranch != "" ||
+		r.WorktreeGitAuthor != "" ||
+		r.WorktreeGitEmail != ""
 }
 
 // BuildWorktreeConfig returns normalized worktree configuration derived from
@@ -369,6 +742,8 @@ func (r *Role) BuildWorktreeConfig(invocationCWD, agentName string) (*WorktreeCo
 		Path:       r.WorktreePath,
 		BranchFrom: r.WorktreeBranchFrom,
 		Branch:     wtBranch,
+		GitAuthor:  strings.TrimSpace(r.WorktreeGitAuthor),
+		GitEmail:   strings.TrimSpace(r.WorktreeGitEmail),
 	}
 	if err := cfg.Validate(); err != nil {
 		return nil, err
@@ -376,8 +751,15 @@ func (r *Role) BuildWorktreeConfig(invocationCWD, agentName string) (*WorktreeCo
 	return cfg, nil
 }
 
+// AdditionalDirOptionalSuffix marks an additional_dirs glob that may match
+// nothing, e.g. "projects/*:optional".
+const AdditionalDirOptionalSuffix = ":optional"
+
 // ResolveAdditionalDirs returns absolute paths for additional directories.
 // Relative paths are resolved against the h2 dir. Absolute paths are used as-is.
+// Entries containing glob metacharacters (*, ?, [) expand to the matching
+// directories, sorted; matches already in the list are skipped. A glob that
+// matches nothing is an error unless it ends in ":optional".
 func (r *Role) ResolveAdditionalDirs(invocationCWD string) ([]string, error) {
 	if len(r.AdditionalDirs) == 0 {
 		return nil, nil
@@ -387,18 +769,84 @@ func (r *Role) ResolveAdditionalDirs(invocationCWD string) ([]string, error) {
 		return nil, fmt.Errorf("resolve h2 dir for additional_dirs: %w", err)
 	}
 	resolved := make([]string, 0, len(r.AdditionalDirs))
+	seen := make(map[string]bool, len(r.AdditionalDirs))
 	for _, dir := range r.AdditionalDirs {
+		pattern, optional := strings.CutSuffix(dir, AdditionalDirOptionalSuffix)
+		if strings.ContainsAny(pattern, "*?[") {
+			if !filepath.IsAbs(pattern) {
+				pattern = filepath.Join(h2Dir, pattern)
+			}
+			matches, err := globDirs(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("additional_dirs %q: %w", dir, err)
+			}
+			if len(matches) == 0 && !optional {
+				return nil, fmt.Errorf("additional_dirs %q matched no directories (append %q to allow)", dir, AdditionalDirOptionalSuffix)
+			}
+			for _, m := range matches {
+				if !seen[m] {
+					seen[m] = true
+					resolved = append(resolved, m)
+				}
+			}
+			continue
+		}
+
+		var path string
 		if dir == "" || dir == "." {
-			resolved = append(resolved, invocationCWD)
+			path = invocationCWD
 		} else if filepath.IsAbs(dir) {
-			resolved = append(resolved, dir)
+			path = dir
 		} else {
-			resolved = append(resolved, filepath.Join(h2Dir, dir))
+			path = filepath.Join(h2Dir, dir)
 		}
+		seen[path] = true
+		resolved = append(resolved, path)
 	}
 	return resolved, nil
 }
 
+// ResolveSkillsDir returns the absolute path of skills_dir, resolving a
+// relative path against the h2 dir. It returns "" when skills_dir is unset
+// and an error when it doesn't name an existing directory.
+func (r *Role) ResolveSkillsDir() (string, error) {
+	if r.SkillsDir == "" {
+		return "", nil
+	}
+	dir := r.SkillsDir
+	if !filepath.IsAbs(dir) {
+		h2Dir, err := ResolveDir()
+		if err != nil {
+			return "", fmt.Errorf("resolve h2 dir for skills_dir: %w", err)
+		}
+		dir = filepath.Join(h2Dir, dir)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", fmt.Errorf("skills_dir %q: %w", r.SkillsDir, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("skills_dir %q is not a directory", r.SkillsDir)
+	}
+	return dir, nil
+}
+
+// globDirs returns the sorted directories matching pattern.
+func globDirs(pattern string) ([]string, error) {
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, m := range matches {
+		if info, err := os.Stat(m); err == nil && info.IsDir() {
+			dirs = append(dirs, m)
+		}
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
 // GetInstructions returns the assembled instructions string.
 // If any of the split fields (instructions_intro, instructions_body, etc.) are set,
 // they are concatenated with newlines. Otherwise falls back to the single instructions field.
@@ -448,6 +896,30 @@ func validateInstructionsMutualExclusivity(label, single, intro, body, add1, add
 	return nil
 }
 
+// validateToolLists checks that no tool appears in both allowed_tools and denied_tools.
+func validateToolLists(allowed, denied []string) error {
+	allowedSet := make(map[string]bool, len(allowed))
+	for _, t := range allowed {
+		if strings.TrimSpace(t) == "" {
+			return fmt.Errorf("allowed_tools: tool name must not be empty")
+		}
+		allowedSet[t] = true
+	}
+	var both []string
+	for _, t := range denied {
+		if strings.TrimSpace(t) == "" {
+			return fmt.Errorf("denied_tools: tool name must not be empty")
+		}
+		if allowedSet[t] {
+			both = append(both, t)
+		}
+	}
+	if len(both) > 0 {
+		return fmt.Errorf("tools listed in both allowed_tools and denied_tools: %s", strings.Join(both, ", "))
+	}
+	return nil
+}
+
 func isValidDCGPolicy(p string) bool {
 	for _, v := range ValidDCGPolicies {
 		if p == v {
@@ -473,8 +945,13 @@ func (r *Role) GetAgentType() string {
 	return ""
 }
 
-// GetModel returns the explicit configured model, or empty string for the agent app's own default.
+// GetModel returns the explicit configured model for the role's harness:
+// the agent_models entry for GetHarnessType if set, otherwise agent_model.
+// Empty means the agent app's own default.
 func (r *Role) GetModel() string {
+	if m := r.AgentModels[r.GetHarnessType()]; m != "" {
+		return m
+	}
 	return r.AgentModel
 }
 
@@ -491,11 +968,15 @@ func (r *Role) GetCodexConfigDir() string {
 	return filepath.Join(r.GetCodexConfigPathPrefix(), r.GetProfile())
 }
 
-// GetProfile returns the selected profile name.
+// GetProfile returns the selected profile name. Before SelectProfile has
+// chosen from a profiles list, that's the list's first profile.
 func (r *Role) GetProfile() string {
 	if strings.TrimSpace(r.Profile) != "" {
 		return strings.TrimSpace(r.Profile)
 	}
+	if len(r.Profiles) > 0 {
+		return strings.TrimSpace(r.Profiles[0])
+	}
 	return "default"
 }
 
@@ -570,9 +1051,52 @@ func IsClaudeConfigAuthenticated(configDir string) (bool, error) {
 		config.OAuthAccount.EmailAddress != "", nil
 }
 
-// IsRoleAuthenticated checks if the role's Claude config directory is authenticated.
+// IsCodexConfigAuthenticated checks if the given Codex config directory
+// has been authenticated (i.e., has an auth.json with an API key or login tokens).
+func IsCodexConfigAuthenticated(configDir string) (bool, error) {
+	authJSON := filepath.Join(configDir, "auth.json")
+
+	data, err := os.ReadFile(authJSON)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("read auth.json: %w", err)
+	}
+
+	// Codex writes either an API key (codex login --with-api-key) or
+	// ChatGPT login tokens.
+	var auth struct {
+		OpenAIAPIKey *string `json:"OPENAI_API_KEY"`
+		Tokens       *struct {
+			AccessToken  string `json:"access_token"`
+			RefreshToken string `json:"refresh_token"`
+		} `json:"tokens"`
+	}
+
+	if err := json.Unmarshal(data, &auth); err != nil {
+		return false, fmt.Errorf("parse auth.json: %w", err)
+	}
+
+	if auth.OpenAIAPIKey != nil && *auth.OpenAIAPIKey != "" {
+		return true, nil
+	}
+	return auth.Tokens != nil &&
+		(auth.Tokens.AccessToken != "" || auth.Tokens.RefreshToken != ""), nil
+}
+
+// IsRoleAuthenticated checks if the config directory for the role's harness
+// is authenticated. Generic harnesses have no h2-managed credentials and are
+// always considered authenticated.
 func (r *Role) IsRoleAuthenticated() (bool, error) {
-	return IsClaudeConfigAuthenticated(r.GetClaudeConfigDir())
+	switch r.GetHarnessType() {
+	case "codex":
+		return IsCodexConfigAuthenticated(r.GetCodexConfigDir())
+	case "generic":
+		return true, nil
+	default:
+		return IsClaudeConfigAuthenticated(r.GetClaudeConfigDir())
+	}
 }
 
 // resolveRolePath finds the role file for the given name, trying .yaml.tmpl first, then .yaml.
@@ -691,6 +1215,9 @@ type RoleInheritanceMetadata struct {
 //     agent_name is extracted and used as AgentName for the second pass.
 //  3. If agent_name is empty after pass 1, generateFallback() is called.
 //
+// Pod launches pass tmpl.PodNameFuncs so autoIncrement numbers agents within
+// the pod instead of after the running agents (see ResolvePodAgentNames).
+//
 // Returns the final Role and the resolved agent name.
 func LoadRoleWithNameResolution(
 	path string,
@@ -703,12 +1230,15 @@ func LoadRoleWithNameResolution(
 		ctx = &tmpl.Context{}
 	}
 
-	plan, err := buildInheritanceRenderPlan(path)
+	plan, err := buildInheritanceRenderPlan(path, "")
 	if err != nil {
 		return nil, "", err
 	}
 
-	vars := mergeVarDefaults(ctx.Var, plan.renderDefs)
+	vars, err := mergeVarDefaults(ctx.Var, plan.renderDefs)
+	if err != nil {
+		return nil, "", fmt.Errorf("role %q: %w", filepath.Base(path), redactError(err, tmpl.SecretValues(plan.renderDefs, ctx.Var)))
+	}
 	if err := tmpl.ValidateVars(plan.renderDefs, vars); err != nil {
 		return nil, "", fmt.Errorf("role %q: %w", filepath.Base(path), err)
 	}
@@ -722,7 +1252,7 @@ func LoadRoleWithNameResolution(
 	// Fast path: CLI name provided, no two-pass needed.
 	if cliName != "" {
 		renderCtx.AgentName = cliName
-		role, err := renderRoleFromPlan(plan, &renderCtx, nameFuncs, filepath.Base(path))
+		role, err := renderRoleFromPlan(plan, &renderCtx, nameFuncs, filepath.Base(path), false)
 		if err != nil {
 			return nil, "", err
 		}
@@ -734,7 +1264,7 @@ func LoadRoleWithNameResolution(
 	pass1Ctx.AgentName = agentNamePlaceholder
 	pass1Merged, err := renderMergedRoleMap(plan.chain, &pass1Ctx, nameFuncs, filepath.Base(path), "pass 1")
 	if err != nil {
-		return nil, "", err
+		return nil, "", redactError(err, tmpl.SecretValues(plan.renderDefs, vars))
 	}
 
 	resolvedName, err := extractResolvedAgentName(pass1Merged.data, filepath.Base(path))
@@ -748,7 +1278,7 @@ func LoadRoleWithNameResolution(
 	// Pass 2 with resolved agent name.
 	pass2Ctx := renderCtx
 	pass2Ctx.AgentName = resolvedName
-	role, err := renderRoleFromPlan(plan, &pass2Ctx, nameFuncs, filepath.Base(path))
+	role, err := renderRoleFromPlan(plan, &pass2Ctx, nameFuncs, filepath.Base(path), false)
 	if err != nil {
 		return nil, "", err
 	}
@@ -762,41 +1292,52 @@ func loadRoleRenderedFromWithFuncs(path string, ctx *tmpl.Context, extraFuncs te
 		return LoadRoleFrom(path)
 	}
 
-	plan, err := buildInheritanceRenderPlan(path)
+	plan, err := buildInheritanceRenderPlan(path, "")
 	if err != nil {
 		return nil, err
 	}
 
-	vars := mergeVarDefaults(ctx.Var, plan.renderDefs)
+	vars, err := mergeVarDefaults(ctx.Var, plan.renderDefs)
+	if err != nil {
+		return nil, fmt.Errorf("role %q: %w", filepath.Base(path), redactError(err, tmpl.SecretValues(plan.renderDefs, ctx.Var)))
+	}
 	if err := tmpl.ValidateVars(plan.renderDefs, vars); err != nil {
 		return nil, fmt.Errorf("role %q: %w", filepath.Base(path), err)
 	}
 
 	renderCtx := *ctx
 	renderCtx.Var = vars
-	return renderRoleFromPlan(plan, &renderCtx, extraFuncs, filepath.Base(path))
+	return renderRoleFromPlan(plan, &renderCtx, extraFuncs, filepath.Base(path), false)
 }
 
 // loadRoleRenderedForDisplay renders a role for display commands.
 // Unlike launch-time rendering, it intentionally does not enforce required-var
-// presence so role metadata can be inspected without caller-provided --var values.
+// presence so role metadata can be inspected without caller-provided --var values,
+// and it masks the values of secret variables.
 func loadRoleRenderedForDisplay(path string, ctx *tmpl.Context, extraFuncs template.FuncMap) (*Role, error) {
 	if ctx == nil {
 		return LoadRoleFrom(path)
 	}
 
-	plan, err := buildInheritanceRenderPlan(path)
+	plan, err := buildInheritanceRenderPlan(path, "")
 	if err != nil {
 		return nil, err
 	}
 
+	vars, err := mergeVarDefaults(ctx.Var, plan.renderDefs)
+	if err != nil {
+		return nil, fmt.Errorf("role %q: %w", filepath.Base(path), redactError(err, tmpl.SecretValues(plan.renderDefs, ctx.Var)))
+	}
 	renderCtx := *ctx
-	renderCtx.Var = mergeVarDefaults(ctx.Var, plan.renderDefs)
-	return renderRoleFromPlan(plan, &renderCtx, extraFuncs, filepath.Base(path))
+	renderCtx.Var = vars
+	return renderRoleFromPlan(plan, &renderCtx, extraFuncs, filepath.Base(path), true)
 }
 
-func buildInheritanceRenderPlan(path string) (*inheritanceRenderPlan, error) {
-	chain, err := resolveInheritanceChain(path, map[string]bool{}, 1)
+// buildInheritanceRenderPlan resolves the inheritance chain of the role at
+// path and the variables it renders with. The role's parent is looked up in
+// parentsDir, or next to the role if parentsDir is empty.
+func buildInheritanceRenderPlan(path, parentsDir string) (*inheritanceRenderPlan, error) {
+	chain, err := resolveInheritanceChain(path, parentsDir, map[string]bool{}, 1)
 	if err != nil {
 		return nil, err
 	}
@@ -810,6 +1351,9 @@ func buildInheritanceRenderPlan(path string) (*inheritanceRenderPlan, error) {
 		}
 		renderDefs = tmpl.MergeVarDefs(renderDefs, level.defs)
 	}
+	if err := tmpl.ValidateVarDefs(renderDefs); err != nil {
+		return nil, fmt.Errorf("role %q: %w", chain[len(chain)-1].name, err)
+	}
 
 	exposedDefs := copyVarDefs(renderDefs)
 	if len(chain) > 1 {
@@ -837,7 +1381,7 @@ func buildInheritanceRenderPlan(path string) (*inheritanceRenderPlan, error) {
 	}, nil
 }
 
-func resolveInheritanceChain(path string, seen map[string]bool, depth int) ([]inheritanceLevel, error) {
+func resolveInheritanceChain(path, parentsDir string, seen map[string]bool, depth int) ([]inheritanceLevel, error) {
 	if depth > maxRoleInheritanceDepth {
 		return nil, fmt.Errorf("role inheritance depth exceeds maximum of %d", maxRoleInheritanceDepth)
 	}
@@ -874,7 +1418,13 @@ func resolveInheritanceChain(path string, seen map[string]bool, depth int) ([]in
 		return []inheritanceLevel{current}, nil
 	}
 
-	parentPath, _ := resolveRolePath(RolesDir(), inherits)
+	// Parents live next to the role, which is roles/ for every role but
+	// those checked outside an h2 dir (e.g. in an init template), unless
+	// the caller says where (e.g. h2 role validate on a file being edited).
+	if parentsDir == "" {
+		parentsDir = filepath.Dir(path)
+	}
+	parentPath, _ := resolveRolePath(parentsDir, inherits)
 	if _, err := os.Stat(parentPath); err != nil {
 		if os.IsNotExist(err) {
 			return nil, fmt.Errorf("role %q inherits unknown parent role %q", name, inherits)
@@ -882,18 +1432,38 @@ func resolveInheritanceChain(path string, seen map[string]bool, depth int) ([]in
 		return nil, fmt.Errorf("resolve parent role %q: %w", inherits, err)
 	}
 
-	parentChain, err := resolveInheritanceChain(parentPath, seen, depth+1)
+	parentChain, err := resolveInheritanceChain(parentPath, "", seen, depth+1)
 	if err != nil {
 		return nil, err
 	}
 	return append(parentChain, current), nil
 }
 
-func renderRoleFromPlan(plan *inheritanceRenderPlan, ctx *tmpl.Context, extraFuncs template.FuncMap, roleLabel string) (*Role, error) {
+// renderRoleFromPlan renders and merges the plan's chain into a Role. Errors
+// never contain secret variable values; with maskSecrets, neither does the
+// returned role.
+func renderRoleFromPlan(plan *inheritanceRenderPlan, ctx *tmpl.Context, extraFuncs template.FuncMap, roleLabel string, maskSecrets bool) (*Role, error) {
+	secrets := tmpl.SecretValues(plan.renderDefs, ctx.Var)
+	role, err := renderRoleFromPlanUnredacted(plan, ctx, extraFuncs, roleLabel, maskSecrets, secrets)
+	if err != nil {
+		return nil, redactError(err, secrets)
+	}
+	role.secretValues = secrets
+	renderCtx := *ctx
+	role.renderCtx = &renderCtx
+	return role, nil
+}
+
+func renderRoleFromPlanUnredacted(plan *inheritanceRenderPlan, ctx *tmpl.Context, extraFuncs template.FuncMap, roleLabel string, maskSecrets bool, secrets []string) (*Role, error) {
 	merged, err := renderMergedRoleMap(plan.chain, ctx, extraFuncs, roleLabel, "")
 	if err != nil {
 		return nil, err
 	}
+	if maskSecrets && len(secrets) > 0 {
+		merged.data = redactRenderedValue(merged.data, secrets).(map[string]interface{})
+		redactYAMLNode(merged.hooks, secrets)
+		redactYAMLNode(merged.settings, secrets)
+	}
 
 	var role Role
 	roleYAML, err := yaml.Marshal(merged.data)
@@ -911,6 +1481,9 @@ func renderRoleFromPlan(plan *inheritanceRenderPlan, ctx *tmpl.Context, extraFun
 	}
 
 	role.Variables = copyVarDefs(plan.exposedDefs)
+	if maskSecrets {
+		maskSecretDefaults(role.Variables)
+	}
 	if err := role.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid role %q: %w", roleLabel, err)
 	}
@@ -918,6 +1491,58 @@ func renderRoleFromPlan(plan *inheritanceRenderPlan, ctx *tmpl.Context, extraFun
 	return &role, nil
 }
 
+// redactError returns err with secret values masked in its message. The
+// original error is returned unchanged when it contains no secrets.
+func redactError(err error, secrets []string) error {
+	msg := err.Error()
+	if redacted := tmpl.Redact(msg, secrets); redacted != msg {
+		return errors.New(redacted)
+	}
+	return err
+}
+
+// redactRenderedValue masks secrets in every string within a value decoded
+// from rendered role YAML.
+func redactRenderedValue(v interface{}, secrets []string) interface{} {
+	switch val := v.(type) {
+	case string:
+		return tmpl.Redact(val, secrets)
+	case map[string]interface{}:
+		for k, child := range val {
+			val[k] = redactRenderedValue(child, secrets)
+		}
+	case []interface{}:
+		for i, child := range val {
+			val[i] = redactRenderedValue(child, secrets)
+		}
+	}
+	return v
+}
+
+// redactYAMLNode masks secrets in every scalar under node.
+func redactYAMLNode(node *yaml.Node, secrets []string) {
+	if node == nil {
+		return
+	}
+	if node.Kind == yaml.ScalarNode {
+		node.Value = tmpl.Redact(node.Value, secrets)
+	}
+	for _, child := range node.Content {
+		redactYAMLNode(child, secrets)
+	}
+}
+
+// maskSecretDefaults replaces the defaults of secret variables in defs.
+func maskSecretDefaults(defs map[string]tmpl.VarDef) {
+	masked := tmpl.RedactedValue
+	for name, def := range defs {
+		if def.Secret && def.Default != nil {
+			def.Default = &masked
+			defs[name] = def
+		}
+	}
+}
+
 func renderMergedRoleMap(chain []inheritanceLevel, ctx *tmpl.Context, extraFuncs template.FuncMap, roleLabel, passLabel string) (*mergedRoleRender, error) {
 	merged := map[string]interface{}{}
 	var mergedHooks *yaml.Node
@@ -925,8 +1550,17 @@ func renderMergedRoleMap(chain []inheritanceLevel, ctx *tmpl.Context, extraFuncs
 	hooksPresent := false
 	settingsPresent := false
 
+	h2Dir := ctx.H2Dir
+	if h2Dir == "" {
+		h2Dir = ConfigDir()
+	}
+	funcs := tmpl.FileFuncs(h2Dir)
+	for k, v := range extraFuncs {
+		funcs[k] = v
+	}
+
 	for _, level := range chain {
-		rendered, err := tmpl.RenderWithExtraFuncs(level.remaining, ctx, extraFuncs)
+		rendered, err := tmpl.RenderWithExtraFuncs(level.remaining, ctx, funcs)
 		if err != nil {
 			if passLabel != "" {
 				return nil, fmt.Errorf("template error in role %q (%s, %s): %w", roleLabel, level.path, passLabel, err)
@@ -976,6 +1610,8 @@ func renderMergedRoleMap(chain []inheritanceLevel, ctx *tmpl.Context, extraFuncs
 		delete(roleMap, "variables")
 		delete(roleMap, "hooks")
 		delete(roleMap, "settings")
+		mergeToolLists(merged, roleMap)
+		replaceHeartbeatCondition(merged, roleMap)
 		merged = deepMergeMaps(merged, roleMap)
 	}
 
@@ -1032,6 +1668,110 @@ func deepMergeMaps(base, overlay map[string]interface{}) map[string]interface{}
 	return merged
 }
 
+// mergeToolLists merges allowed_tools/denied_tools from a child level into the
+// overlay map before the generic deep merge. Unlike other lists, tool lists are
+// unioned across the chain; when the child names a tool, its placement wins
+// over the parent's (e.g. a parent-denied tool listed in the child's
+// allowed_tools moves to the allowed list).
+func mergeToolLists(base, overlay map[string]interface{}) {
+	_, hasAllowed := overlay["allowed_tools"]
+	_, hasDenied := overlay["denied_tools"]
+	if !hasAllowed && !hasDenied {
+		return
+	}
+	childAllowed := toolListFromValue(overlay["allowed_tools"])
+	childDenied := toolListFromValue(overlay["denied_tools"])
+
+	childNamed := make(map[string]bool, len(childAllowed)+len(childDenied))
+	for _, t := range childAllowed {
+		childNamed[t] = true
+	}
+	for _, t := range childDenied {
+		childNamed[t] = true
+	}
+
+	merge := func(parent, child []string) []interface{} {
+		var out []interface{}
+		seen := map[string]bool{}
+		for _, t := range parent {
+			if childNamed[t] || seen[t] {
+				continue
+			}
+			seen[t] = true
+			out = append(out, t)
+		}
+		for _, t := range child {
+			if seen[t] {
+				continue
+			}
+			seen[t] = true
+			out = append(out, t)
+		}
+		return out
+	}
+
+	// An explicit null in the child clears the parent's list, as with other fields.
+	parentAllowed := toolListFromValue(base["allowed_tools"])
+	if hasAllowed && overlay["allowed_tools"] == nil {
+		parentAllowed = nil
+	}
+	parentDenied := toolListFromValue(base["denied_tools"])
+	if hasDenied && overlay["denied_tools"] == nil {
+		parentDenied = nil
+	}
+
+	if allowed := merge(parentAllowed, childAllowed); len(allowed) > 0 {
+		overlay["allowed_tools"] = allowed
+	} else {
+		overlay["allowed_tools"] = nil
+	}
+	if denied := merge(parentDenied, childDenied); len(denied) > 0 {
+		overlay["denied_tools"] = denied
+	} else {
+		overlay["denied_tools"] = nil
+	}
+}
+
+// replaceHeartbeatCondition drops the parent's heartbeat.condition when the
+// child sets one, so switching between the string, list, and {all|any}
+// mapping forms replaces rather than deep-merges the condition.
+func replaceHeartbeatCondition(base, overlay map[string]interface{}) {
+	overlayHB, ok := overlay["heartbeat"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	if _, ok := overlayHB["condition"]; !ok {
+		return
+	}
+	baseHB, ok := base["heartbeat"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	trimmed := make(map[string]interface{}, len(baseHB))
+	for k, v := range baseHB {
+		if k != "condition" {
+			trimmed[k] = v
+		}
+	}
+	base["heartbeat"] = trimmed
+}
+
+// toolListFromValue converts a decoded YAML list into tool names, skipping
+// non-string entries (validation reports type errors on the final unmarshal).
+func toolListFromValue(v interface{}) []string {
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+	var out []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
 func extractTopLevelYAMLNode(doc *yaml.Node, key string) (*yaml.Node, bool) {
 	if doc == nil || len(doc.Content) == 0 {
 		return nil, false
@@ -1176,18 +1916,10 @@ func copyVarDefs(defs map[string]tmpl.VarDef) map[string]tmpl.VarDef {
 	return copied
 }
 
-// mergeVarDefaults creates a new map with provided vars + defaults for missing ones.
-func mergeVarDefaults(provided map[string]string, defs map[string]tmpl.VarDef) map[string]string {
-	vars := make(map[string]string, len(provided)+len(defs))
-	for k, v := range provided {
-		vars[k] = v
-	}
-	for name, def := range defs {
-		if _, ok := vars[name]; !ok && def.Default != nil {
-			vars[name] = *def.Default
-		}
-	}
-	return vars
+// mergeVarDefaults creates a new map with provided vars + defaults for
+// missing ones, rendering defaults that reference other variables.
+func mergeVarDefaults(provided map[string]string, defs map[string]tmpl.VarDef) (map[string]string, error) {
+	return tmpl.ResolveDefaults(defs, provided)
 }
 
 // roleFileExtensions lists recognized role file extensions in priority order.
@@ -1214,19 +1946,29 @@ func roleNameFromFile(name string) string {
 }
 
 // NameStubFuncs provides stub template functions for contexts where
-// randomName and autoIncrement are not meaningful (e.g. listing roles,
-// daemon re-resolve). Templates may reference these functions but only
-// the agent launch path provides real implementations.
+// randomName, autoIncrement and autoIncrementFill are not meaningful (e.g.
+// listing roles, daemon re-resolve). Templates may reference these functions
+// but only the agent launch path provides real implementations.
 var NameStubFuncs = template.FuncMap{
-	"randomName":    func() string { return "<name>" },
-	"autoIncrement": func(prefix string) int { return 0 },
+	"randomName":        func() string { return "<name>" },
+	"autoIncrement":     func(prefix string) int { return 0 },
+	"autoIncrementFill": func(prefix string) int { return 0 },
 }
 
 // listStubFuncs is an alias kept for internal use.
 var listStubFuncs = NameStubFuncs
 
-// listRolesFromDir scans a directory for role files (.yaml and .yaml.tmpl) and loads them.
-func listRolesFromDir(dir string) ([]*Role, error) {
+// RoleListing is one role file found in the roles dir: the loaded role, or
+// the error that kept it from loading.
+type RoleListing struct {
+	Name string
+	Role *Role // nil if Err is set
+	Err  error
+}
+
+// listRoleFilesFromDir scans a directory for role files (.yaml and
+// .yaml.tmpl) and loads each one, recording load errors alongside.
+func listRoleFilesFromDir(dir string) ([]RoleListing, error) {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -1236,7 +1978,7 @@ func listRolesFromDir(dir string) ([]*Role, error) {
 	}
 
 	seen := make(map[string]bool) // track role names to avoid duplicates
-	var roles []*Role
+	var listings []RoleListing
 	for _, entry := range entries {
 		if entry.IsDir() || !isRoleFile(entry.Name()) {
 			continue
@@ -1261,10 +2003,27 @@ func listRolesFromDir(dir string) ([]*Role, error) {
 			// Fallback to plain load (handles roles with required vars).
 			role, err = LoadRoleFrom(path)
 			if err != nil {
+				listings = append(listings, RoleListing{Name: roleName, Err: err})
 				continue
 			}
 		}
-		roles = append(roles, role)
+		listings = append(listings, RoleListing{Name: roleName, Role: role})
+	}
+	return listings, nil
+}
+
+// listRolesFromDir loads the roles in a directory, skipping any that fail
+// to load.
+func listRolesFromDir(dir string) ([]*Role, error) {
+	listings, err := listRoleFilesFromDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var roles []*Role
+	for _, l := range listings {
+		if l.Err == nil {
+			roles = append(roles, l.Role)
+		}
 	}
 	return roles, nil
 }
@@ -1274,17 +2033,35 @@ func ListRoles() ([]*Role, error) {
 	return listRolesFromDir(RolesDir())
 }
 
+// ListRoleFiles is like ListRoles but also reports the role files that fail
+// to load, with their errors.
+func ListRoleFiles() ([]RoleListing, error) {
+	return listRoleFilesFromDir(RolesDir())
+}
+
+// ListRoleFilesIn is ListRoleFiles for the role files in dir instead of the
+// h2 dir's roles/.
+func ListRoleFilesIn(dir string) ([]RoleListing, error) {
+	return listRoleFilesFromDir(dir)
+}
+
 // LoadRoleForDisplay loads a role for display purposes (e.g., `h2 role show`).
 // It renders templates with stub values so that template files can be parsed
 // and displayed. The returned role has Variables populated from the template's
 // variable definitions. Returns the role and a map of variable definitions.
 func LoadRoleForDisplay(name string) (*Role, map[string]tmpl.VarDef, error) {
+	return LoadRoleForDisplayWithVars(name, nil)
+}
+
+// LoadRoleForDisplayWithVars is like LoadRoleForDisplay but renders with the
+// given template variables (e.g. from --var flags) on top of the defaults.
+func LoadRoleForDisplayWithVars(name string, vars map[string]string) (*Role, map[string]tmpl.VarDef, error) {
 	path, _ := resolveRolePath(RolesDir(), name)
-	return loadRoleForDisplay(path, name)
+	return loadRoleForDisplay(path, name, vars)
 }
 
 // loadRoleForDisplay loads a role for display from a specific path.
-func loadRoleForDisplay(path, roleName string) (*Role, map[string]tmpl.VarDef, error) {
+func loadRoleForDisplay(path, roleName string, vars map[string]string) (*Role, map[string]tmpl.VarDef, error) {
 	// Read the raw file to extract variable definitions.
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -1303,6 +2080,7 @@ func loadRoleForDisplay(path, roleName string) (*Role, map[string]tmpl.VarDef, e
 		AgentName: "<name>",
 		H2Dir:     ConfigDir(),
 		H2RootDir: rootDir,
+		Var:       vars,
 	}
 	role, err := loadRoleRenderedForDisplay(path, ctx, listStubFuncs)
 	if err != nil {
@@ -1316,9 +2094,11 @@ func loadRoleForDisplay(path, roleName string) (*Role, map[string]tmpl.VarDef, e
 	}
 
 	// Ensure Variables is populated from defs (may not be if fallback was used).
+	maskSecretDefaults(defs)
 	if role.Variables == nil && len(defs) > 0 {
 		role.Variables = defs
 	}
+	maskSecretDefaults(role.Variables)
 
 	return role, defs, nil
 }
@@ -1337,7 +2117,7 @@ func GetRoleInheritanceMetadata(name string) (*RoleInheritanceMetadata, error) {
 		return nil, fmt.Errorf("parse inherits in role %q: %w", path, err)
 	}
 
-	chain, err := resolveInheritanceChain(path, map[string]bool{}, 1)
+	chain, err := resolveInheritanceChain(path, "", map[string]bool{}, 1)
 	if err != nil {
 		return nil, err
 	}
@@ -1393,69 +2173,125 @@ func GetRoleInheritanceMetadata(name string) (*RoleInheritanceMetadata, error) {
 	return meta, nil
 }
 
-// Validate checks that a role has the minimum required fields.
-func (r *Role) Validate() error {
-	if r.RoleName == "" {
-		return fmt.Errorf("role_name is required")
-	}
-	if r.AgentHarness != "" {
-		valid := false
-		for _, harnessType := range ValidHarnessTypes {
-			if r.AgentHarness == harnessType {
-				valid = true
-				break
-			}
-		}
-		if !valid {
-			return fmt.Errorf("invalid agent_harness %q; valid values: %s",
-				r.AgentHarness, strings.Join(ValidHarnessTypes, ", "))
+// harnessOnlyFields returns the YAML names of the set role fields that only
+// the given harness reads.
+func (r *Role) harnessOnlyFields(harness string) []string {
+	var fields []string
+	add := func(name string, set bool) {
+		if set {
+			fields = append(fields, name)
 		}
 	}
-	if r.ClaudePermissionMode != "" {
-		valid := false
-		for _, mode := range ValidClaudePermissionModes {
-			if r.ClaudePermissionMode == mode {
-				valid = true
-				break
-			}
+	switch harness {
+	case "claude_code":
+		add("claude_permission_mode", r.ClaudePermissionMode != "")
+		add("claude_code_config_path_prefix", r.ClaudeCodeConfigPathPrefix != "")
+		add("skills_dir", r.SkillsDir != "")
+	case "codex":
+		add("codex_sandbox_mode", r.CodexSandboxMode != "")
+		add("codex_ask_for_approval", r.CodexAskForApproval != "")
+		add("codex_config_path_prefix", r.CodexConfigPathPrefix != "")
+	}
+	return fields
+}
+
+// validateHarnessFields rejects fields for another harness when agent_harness
+// is set explicitly, which usually means a copy-paste mistake.
+func (r *Role) validateHarnessFields() error {
+	other := map[string]string{"claude_code": "codex", "codex": "claude_code"}[r.AgentHarness]
+	if other == "" {
+		return nil
+	}
+	if fields := r.harnessOnlyFields(other); len(fields) > 0 {
+		return fmt.Errorf("%s only applies to agent_harness %s, but agent_harness is %s; remove it or change agent_harness",
+			fields[0], other, r.AgentHarness)
+	}
+	return nil
+}
+
+// HarnessFieldWarnings describes harness-specific fields that will be
+// ignored when agent_harness is unset or generic. These aren't errors since
+// such roles may be reused with a different harness.
+func (r *Role) HarnessFieldWarnings() []string {
+	var warnings []string
+	switch r.AgentHarness {
+	case "":
+		for _, f := range r.harnessOnlyFields("codex") {
+			warnings = append(warnings, fmt.Sprintf("%s only applies to agent_harness codex and is ignored (agent_harness is unset, so claude_code runs)", f))
 		}
-		if !valid {
-			return fmt.Errorf("invalid claude_permission_mode %q; valid values: %s",
-				r.ClaudePermissionMode, strings.Join(ValidClaudePermissionModes, ", "))
+	case "generic":
+		for _, h := range []string{"claude_code", "codex"} {
+			for _, f := range r.harnessOnlyFields(h) {
+				warnings = append(warnings, fmt.Sprintf("%s only applies to agent_harness %s and is ignored by generic", f, h))
+			}
 		}
 	}
-	if r.CodexSandboxMode != "" {
-		valid := false
-		for _, m := range ValidCodexSandboxModes {
-			if r.CodexSandboxMode == m {
-				valid = true
-				break
+	return warnings
+}
+
+// Validate checks that a role has the minimum required fields. Every
+// problem found is reported, joined with errors.Join.
+func (r *Role) Validate() error {
+	var errs []error
+	if r.RoleName == "" {
+		errs = append(errs, fmt.Errorf("role_name is required"))
+	}
+	if r.AgentHarness != "" && !slices.Contains(ValidHarnessTypes, r.AgentHarness) {
+		errs = append(errs, fmt.Errorf("invalid agent_harness %q; valid values: %s",
+			r.AgentHarness, strings.Join(ValidHarnessTypes, ", ")))
+	}
+	if err := r.validateHarnessFields(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := r.validateProfiles(); err != nil {
+		errs = append(errs, err)
+	}
+	if r.StrictHooks {
+		for _, w := range r.checkHooks() {
+			if w.unknownEvent {
+				errs = append(errs, fmt.Errorf("%s (strict_hooks is set)", w.text))
 			}
 		}
-		if !valid {
-			return fmt.Errorf("invalid codex_sandbox_mode %q; valid values: %s",
-				r.CodexSandboxMode, strings.Join(ValidCodexSandboxModes, ", "))
+	}
+	for harnessType := range r.AgentModels {
+		if !slices.Contains(ValidHarnessTypes, harnessType) {
+			errs = append(errs, fmt.Errorf("invalid agent_models key %q; valid values: %s",
+				harnessType, strings.Join(ValidHarnessTypes, ", ")))
 		}
 	}
-	if r.CodexAskForApproval != "" {
-		valid := false
-		for _, v := range ValidCodexAskForApproval {
-			if r.CodexAskForApproval == v {
-				valid = true
-				break
+	if r.ClaudePermissionMode != "" && !slices.Contains(ValidClaudePermissionModes, r.ClaudePermissionMode) {
+		errs = append(errs, fmt.Errorf("invalid claude_permission_mode %q; valid values: %s",
+			r.ClaudePermissionMode, strings.Join(ValidClaudePermissionModes, ", ")))
+	}
+	if r.CodexSandboxMode != "" && !slices.Contains(ValidCodexSandboxModes, r.CodexSandboxMode) {
+		errs = append(errs, fmt.Errorf("invalid codex_sandbox_mode %q; valid values: %s",
+			r.CodexSandboxMode, strings.Join(ValidCodexSandboxModes, ", ")))
+	}
+	if r.CodexAskForApproval != "" && !slices.Contains(ValidCodexAskForApproval, r.CodexAskForApproval) {
+		errs = append(errs, fmt.Errorf("invalid codex_ask_for_approval %q; valid values: %s",
+			r.CodexAskForApproval, strings.Join(ValidCodexAskForApproval, ", ")))
+	}
+	if r.Heartbeat != nil {
+		for _, req := range r.Heartbeat.IdleRequires {
+			if _, ok := heartbeatIdleGuards[req]; !ok {
+				errs = append(errs, fmt.Errorf("invalid heartbeat.idle_requires %q; valid values: %s",
+					req, strings.Join(ValidHeartbeatIdleRequires, ", ")))
 			}
 		}
-		if !valid {
-			return fmt.Errorf("invalid codex_ask_for_approval %q; valid values: %s",
-				r.CodexAskForApproval, strings.Join(ValidCodexAskForApproval, ", "))
+		if s := r.Heartbeat.PodStrategy; s != "" && !slices.Contains(ValidHeartbeatPodStrategies, s) {
+			errs = append(errs, fmt.Errorf("invalid heartbeat.pod_strategy %q; valid values: %s",
+				s, strings.Join(ValidHeartbeatPodStrategies, ", ")))
 		}
 	}
+	if err := validateToolLists(r.AllowedTools, r.DeniedTools); err != nil {
+		errs = append(errs, err)
+	}
 	// instructions and split instruction fields are mutually exclusive.
 	if err := validateInstructionsMutualExclusivity("role",
 		r.Instructions, r.InstructionsIntro, r.InstructionsBody,
 		r.InstructionsAdditional1, r.InstructionsAdditional2, r.InstructionsAdditional3,
 	); err != nil {
-		return err
+		errs = append(errs, err)
 	}
 	if r.PermissionReview != nil {
 		if r.PermissionReview.AIReviewer != nil {
@@ -1464,28 +2300,34 @@ func (r *Role) Validate() error {
 				r.PermissionReview.AIReviewer.InstructionsIntro, r.PermissionReview.AIReviewer.InstructionsBody,
 				r.PermissionReview.AIReviewer.InstructionsAdditional1, r.PermissionReview.AIReviewer.InstructionsAdditional2, r.PermissionReview.AIReviewer.InstructionsAdditional3,
 			); err != nil {
-				return err
+				errs = append(errs, err)
 			}
 		}
 		if r.PermissionReview.DCG != nil {
 			dcg := r.PermissionReview.DCG
 			if dcg.DestructivePolicy != "" && !isValidDCGPolicy(dcg.DestructivePolicy) {
-				return fmt.Errorf("invalid permission_review.dcg.destructive_policy %q; valid values: %s",
-					dcg.DestructivePolicy, strings.Join(ValidDCGPolicies, ", "))
+				errs = append(errs, fmt.Errorf("invalid permission_review.dcg.destructive_policy %q; valid values: %s",
+					dcg.DestructivePolicy, strings.Join(ValidDCGPolicies, ", ")))
 			}
 			if dcg.PrivacyPolicy != "" && !isValidDCGPolicy(dcg.PrivacyPolicy) {
-				return fmt.Errorf("invalid permission_review.dcg.privacy_policy %q; valid values: %s",
-					dcg.PrivacyPolicy, strings.Join(ValidDCGPolicies, ", "))
+				errs = append(errs, fmt.Errorf("invalid permission_review.dcg.privacy_policy %q; valid values: %s",
+					dcg.PrivacyPolicy, strings.Join(ValidDCGPolicies, ", ")))
 			}
 		}
 	}
 	if !r.WorktreeEnabled && r.hasWorktreeFields() {
-		return fmt.Errorf("worktree_* fields require worktree_enabled=true")
+		errs = append(errs, fmt.Errorf("worktree_* fields require worktree_enabled=true"))
+	}
+	if err := r.validateCreateWorkingDir(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := r.PostLaunch.validate(); err != nil {
+		errs = append(errs, err)
 	}
 	if r.WorktreeEnabled {
 		if _, err := r.BuildWorktreeConfig(".", r.AgentName); err != nil {
-			return err
+			errs = append(errs, err)
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
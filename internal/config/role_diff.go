@@ -0,0 +1,128 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// roleDiffSkipFields are compared separately (instructions) or are identity
+// rather than config (role_name).
+var roleDiffSkipFields = map[string]bool{
+	"role_name":                 true,
+	"instructions":              true,
+	"instructions_intro":        true,
+	"instructions_body":         true,
+	"instructions_additional_1": true,
+	"instructions_additional_2": true,
+	"instructions_additional_3": true,
+}
+
+// FlattenRoleFields marshals a role to YAML and flattens it into dotted
+// field paths (e.g. "heartbeat.idle_timeout") mapped to a one-line
+// rendering of each leaf value. Lists are kept whole so a reordered or
+// extended list shows as one change. Instructions are left out; compare
+// GetInstructions instead.
+func FlattenRoleFields(role *Role) (map[string]string, error) {
+	data, err := yaml.Marshal(role)
+	if err != nil {
+		return nil, fmt.Errorf("marshal role %q: %w", role.RoleName, err)
+	}
+	var tree map[string]any
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("unmarshal role %q: %w", role.RoleName, err)
+	}
+	out := map[string]string{}
+	for k, v := range tree {
+		if roleDiffSkipFields[k] {
+			continue
+		}
+		flattenValue(out, k, v)
+	}
+	return out, nil
+}
+
+// ChangedRoleFields returns the top-level fields whose effective values
+// differ between a and b, sorted. The split instruction fields are compared
+// as one assembled "instructions" field.
+func ChangedRoleFields(a, b *Role) ([]string, error) {
+	fieldsA, err := RoleFieldSnapshot(a)
+	if err != nil {
+		return nil, err
+	}
+	fieldsB, err := RoleFieldSnapshot(b)
+	if err != nil {
+		return nil, err
+	}
+	return ChangedFields(fieldsA, fieldsB), nil
+}
+
+// RoleFieldSnapshot returns the role's FlattenRoleFields with the assembled
+// instructions added as "instructions", so it can be stored and compared
+// against a later version of the role with ChangedFields.
+func RoleFieldSnapshot(role *Role) (map[string]string, error) {
+	fields, err := FlattenRoleFields(role)
+	if err != nil {
+		return nil, err
+	}
+	fields["instructions"] = role.GetInstructions()
+	return fields, nil
+}
+
+// ChangedFields returns the top-level fields that differ between two
+// RoleFieldSnapshot results, sorted.
+func ChangedFields(fieldsA, fieldsB map[string]string) []string {
+	changed := map[string]bool{}
+	for k, va := range fieldsA {
+		if vb, ok := fieldsB[k]; !ok || va != vb {
+			changed[topLevelField(k)] = true
+		}
+	}
+	for k := range fieldsB {
+		if _, ok := fieldsA[k]; !ok {
+			changed[topLevelField(k)] = true
+		}
+	}
+
+	out := make([]string, 0, len(changed))
+	for k := range changed {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// topLevelField returns the role field a dotted field path belongs to.
+func topLevelField(path string) string {
+	field, _, _ := strings.Cut(path, ".")
+	return field
+}
+
+func flattenValue(out map[string]string, prefix string, v any) {
+	if m, ok := v.(map[string]any); ok && len(m) > 0 {
+		for k, sub := range m {
+			flattenValue(out, prefix+"."+k, sub)
+		}
+		return
+	}
+	out[prefix] = formatDiffValue(v)
+}
+
+// formatDiffValue renders a leaf value on a single line. Strings print as
+// is (multi-line strings are quoted); everything else prints as JSON.
+func formatDiffValue(v any) string {
+	if s, ok := v.(string); ok {
+		if strings.Contains(s, "\n") {
+			return fmt.Sprintf("%q", s)
+		}
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"h2/internal/tmpl"
+)
+
+func TestChangedRoleFields(t *testing.T) {
+	a := &Role{
+		RoleName:     "sched",
+		AgentHarness: "claude_code",
+		WorkingDir:   ".",
+		Instructions: "Check the inbox.",
+		Heartbeat:    &HeartbeatConfig{IdleTimeout: "1m", Message: "ping"},
+	}
+	b := &Role{
+		RoleName:          "sched",
+		AgentHarness:      "claude_code",
+		WorkingDir:        "/srv",
+		InstructionsIntro: "Check the inbox.",
+		Heartbeat:         &HeartbeatConfig{IdleTimeout: "5m", Message: "ping"},
+	}
+
+	changed, err := ChangedRoleFields(a, b)
+	if err != nil {
+		t.Fatalf("ChangedRoleFields: %v", err)
+	}
+	// The split instructions assemble to the same text, so only the
+	// heartbeat interval and working dir differ.
+	if want := []string{"heartbeat", "working_dir"}; !reflect.DeepEqual(changed, want) {
+		t.Errorf("changed = %v, want %v", changed, want)
+	}
+
+	b.InstructionsIntro = "Check the outbox."
+	changed, err = ChangedRoleFields(a, b)
+	if err != nil {
+		t.Fatalf("ChangedRoleFields: %v", err)
+	}
+	if want := []string{"heartbeat", "instructions", "working_dir"}; !reflect.DeepEqual(changed, want) {
+		t.Errorf("changed = %v, want %v", changed, want)
+	}
+}
+
+func TestLoadRoleRenderedFrom_RecordsRenderContext(t *testing.T) {
+	path := writeTempFile(t, "ctx.yaml", `
+role_name: coder
+variables:
+  team:
+    default: backend
+instructions: |
+  Team: {{ .Var.team }}.
+`)
+	role, err := LoadRoleRenderedFrom(path, &tmpl.Context{AgentName: "coder-1", Var: map[string]string{}})
+	if err != nil {
+		t.Fatalf("LoadRoleRenderedFrom: %v", err)
+	}
+	ctx := role.RenderContext()
+	if ctx == nil {
+		t.Fatal("RenderContext should be recorded")
+	}
+	if ctx.AgentName != "coder-1" || ctx.Var["team"] != "backend" {
+		t.Errorf("RenderContext = %+v, want agent coder-1 with team=backend", ctx)
+	}
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ClaudeHookEvents are the hook events Claude Code knows about. Hooks under
+// any other name are never run.
+var ClaudeHookEvents = []string{
+	"PreToolUse",
+	"PostToolUse",
+	"PostToolUseFailure",
+	"PermissionRequest",
+	"Notification",
+	"UserPromptSubmit",
+	"Stop",
+	"SubagentStart",
+	"SubagentStop",
+	"PreCompact",
+	"SessionStart",
+	"SessionEnd",
+}
+
+// H2HookEvents are the Claude Code hook events h2 registers "h2 handle-hook"
+// for in each session's settings, and so the events the Claude harness
+// handles. Each is one of ClaudeHookEvents.
+var H2HookEvents = []string{
+	"PreToolUse",
+	"PostToolUse",
+	"PostToolUseFailure",
+	"PermissionRequest",
+	"PreCompact",
+	"SessionStart",
+	"SessionEnd",
+	"Stop",
+	"UserPromptSubmit",
+}
+
+// Keys Claude Code reads from a hook event's matcher groups and from the
+// hooks inside them.
+var (
+	claudeHookMatcherKeys = []string{"matcher", "hooks"}
+	claudeHookEntryKeys   = []string{"type", "command", "prompt", "timeout"}
+)
+
+// HookWarnings checks the role's hooks, and the hooks in its settings, for
+// event names and keys Claude Code doesn't know, which it silently ignores.
+// Only Claude Code roles are checked.
+func (r *Role) HookWarnings() []string {
+	var warnings []string
+	for _, w := range r.checkHooks() {
+		warnings = append(warnings, w.text)
+	}
+	return warnings
+}
+
+// hookWarning is one HookWarnings entry. Only unknown event names fail
+// validation under strict_hooks; unknown keys stay warnings, since Claude
+// Code adds hook options more often than events.
+type hookWarning struct {
+	text         string
+	unknownEvent bool
+}
+
+// checkHooks returns the HookWarnings for the role, in document order.
+func (r *Role) checkHooks() []hookWarning {
+	if r.GetHarnessType() != "claude_code" {
+		return nil
+	}
+	warnings := checkClaudeHooks("hooks", &r.Hooks)
+	if hooks := mappingValue(&r.Settings, "hooks"); hooks != nil {
+		warnings = append(warnings, checkClaudeHooks("settings.hooks", hooks)...)
+	}
+	return warnings
+}
+
+// checkClaudeHooks checks a hooks mapping found at path.
+func checkClaudeHooks(path string, hooks *yaml.Node) []hookWarning {
+	if hooks.Kind != yaml.MappingNode {
+		return nil
+	}
+	var warnings []hookWarning
+	for i := 0; i+1 < len(hooks.Content); i += 2 {
+		event, groups := hooks.Content[i].Value, hooks.Content[i+1]
+		if !slices.Contains(ClaudeHookEvents, event) {
+			msg := fmt.Sprintf("%s: unknown hook event %q", path, event)
+			if s := closestName(event, ClaudeHookEvents); s != "" {
+				msg += fmt.Sprintf(" (did you mean %q?)", s)
+			}
+			warnings = append(warnings, hookWarning{text: msg + "; Claude Code will never run it", unknownEvent: true})
+			continue
+		}
+		if groups.Kind != yaml.SequenceNode {
+			continue
+		}
+		for gi, group := range groups.Content {
+			groupPath := fmt.Sprintf("%s.%s[%d]", path, event, gi)
+			warnings = append(warnings, unknownKeys(groupPath, group, claudeHookMatcherKeys)...)
+			entries := mappingValue(group, "hooks")
+			if entries == nil || entries.Kind != yaml.SequenceNode {
+				continue
+			}
+			for ei, entry := range entries.Content {
+				entryPath := fmt.Sprintf("%s.hooks[%d]", groupPath, ei)
+				warnings = append(warnings, unknownKeys(entryPath, entry, claudeHookEntryKeys)...)
+			}
+		}
+	}
+	return warnings
+}
+
+// unknownKeys returns a warning for each key of mapping m not in known.
+func unknownKeys(path string, m *yaml.Node, known []string) []hookWarning {
+	if m.Kind != yaml.MappingNode {
+		return nil
+	}
+	var warnings []hookWarning
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if key := m.Content[i].Value; !slices.Contains(known, key) {
+			warnings = append(warnings, hookWarning{text: fmt.Sprintf("%s: unknown key %q; valid keys: %s", path, key, strings.Join(known, ", "))})
+		}
+	}
+	return warnings
+}
+
+// mappingValue returns the value for key in mapping m, or nil.
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	if m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// closestName returns the name in names that name most likely misspells:
+// one differing only in case, or within two single-character edits.
+func closestName(name string, names []string) string {
+	best, bestDist := "", 3
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return n
+		}
+		if d := editDistance(strings.ToLower(name), strings.ToLower(n)); d < bestDist {
+			best, bestDist = n, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
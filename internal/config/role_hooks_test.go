@@ -0,0 +1,112 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHookWarnings(t *testing.T) {
+	path := writeTempFile(t, "hooks.yaml", `
+role_name: hooked
+hooks:
+  PreToolUze:
+    - matcher: ""
+      hooks:
+        - type: command
+          command: echo pre
+  PostToolUse:
+    - matcher: Bash
+      match: Bash
+      hooks:
+        - type: command
+          command: echo post
+          timout: 5
+  MadeUpEvent: []
+settings:
+  hooks:
+    stop:
+      - hooks:
+          - type: command
+            command: echo stop
+`)
+	role, err := LoadRoleFrom(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		`hooks: unknown hook event "PreToolUze" (did you mean "PreToolUse"?); Claude Code will never run it`,
+		`hooks.PostToolUse[0]: unknown key "match"; valid keys: matcher, hooks`,
+		`hooks.PostToolUse[0].hooks[0]: unknown key "timout"; valid keys: type, command, prompt, timeout`,
+		`hooks: unknown hook event "MadeUpEvent"; Claude Code will never run it`,
+		`settings.hooks: unknown hook event "stop" (did you mean "Stop"?); Claude Code will never run it`,
+	}
+	got := role.HookWarnings()
+	if len(got) != len(want) {
+		t.Fatalf("got %d warnings, want %d:\n%s", len(got), len(want), strings.Join(got, "\n"))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("warning %d:\n got  %s\n want %s", i, got[i], want[i])
+		}
+	}
+
+	role.AgentHarness = "codex"
+	if w := role.HookWarnings(); len(w) != 0 {
+		t.Errorf("codex role got hook warnings: %v", w)
+	}
+}
+
+func TestLoadRoleFrom_StrictHooks(t *testing.T) {
+	valid := `
+role_name: strict
+strict_hooks: true
+hooks:
+  PreToolUse:
+    - matcher: Bash
+      hooks:
+        - type: command
+          command: echo ok
+`
+	if _, err := LoadRoleFrom(writeTempFile(t, "strict-ok.yaml", valid)); err != nil {
+		t.Fatalf("valid hooks with strict_hooks: %v", err)
+	}
+
+	_, err := LoadRoleFrom(writeTempFile(t, "strict-bad.yaml", strings.Replace(valid, "PreToolUse", "PreToolUze", 1)))
+	if err == nil {
+		t.Fatal("expected strict_hooks to reject an unknown hook event")
+	}
+	if !strings.Contains(err.Error(), `unknown hook event "PreToolUze"`) || !strings.Contains(err.Error(), "strict_hooks") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestLoadRoleFrom_StrictHooksOnlyWarnsOnUnknownKeys(t *testing.T) {
+	yaml := `
+role_name: strict
+strict_hooks: true
+hooks:
+  PreToolUse:
+    - matcher: Bash
+      hooks:
+        - type: command
+          command: echo ok
+          timout: 5
+`
+	role, err := LoadRoleFrom(writeTempFile(t, "strict-keys.yaml", yaml))
+	if err != nil {
+		t.Fatalf("unknown hook key with strict_hooks: %v", err)
+	}
+	if w := role.HookWarnings(); len(w) != 1 || !strings.Contains(w[0], `unknown key "timout"`) {
+		t.Errorf("HookWarnings() = %v, want the unknown key", w)
+	}
+}
+
+func TestH2HookEvents_AreClaudeHookEvents(t *testing.T) {
+	for _, event := range H2HookEvents {
+		if !slices.Contains(ClaudeHookEvents, event) {
+			t.Errorf("H2HookEvents has %q, which isn't in ClaudeHookEvents", event)
+		}
+	}
+}
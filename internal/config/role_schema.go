@@ -0,0 +1,172 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RoleSchemaID is the $id of the generated role JSON Schema.
+const RoleSchemaID = "https://github.com/dcosson/h2/schemas/role.json"
+
+// roleSchemaEnums constrains string fields, keyed by dotted YAML path.
+// The values come from the same lists role validation uses, so the schema
+// can't drift from what LoadRole accepts.
+var roleSchemaEnums = map[string][]string{
+	"agent_harness":                            ValidHarnessTypes,
+	"claude_permission_mode":                   ValidClaudePermissionModes,
+	"codex_sandbox_mode":                       ValidCodexSandboxModes,
+	"codex_ask_for_approval":                   ValidCodexAskForApproval,
+	"heartbeat.idle_requires":                  ValidHeartbeatIdleRequires,
+	"heartbeat.pod_strategy":                   ValidHeartbeatPodStrategies,
+	"permission_review.dcg.destructive_policy": ValidDCGPolicies,
+	"permission_review.dcg.privacy_policy":     ValidDCGPolicies,
+}
+
+// roleSchemaOverrides replaces the reflected schema for fields whose YAML
+// form differs from their Go type (custom unmarshalers, free-form nodes).
+var roleSchemaOverrides = map[string]map[string]any{
+	// condition: "cmd" | ["cmd", ...] | {any: [...]} | {all: [...]}
+	"heartbeat.condition": {
+		"oneOf": []any{
+			map[string]any{"type": "string"},
+			map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			map[string]any{
+				"type":                 "object",
+				"additionalProperties": false,
+				"minProperties":        1,
+				"maxProperties":        1,
+				"properties": map[string]any{
+					"any": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+					"all": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+				},
+			},
+		},
+	},
+	// agent_models: keys must be harness types.
+	"agent_models": {
+		"type":                 "object",
+		"propertyNames":        map[string]any{"enum": ValidHarnessTypes},
+		"additionalProperties": map[string]any{"type": "string"},
+	},
+	// post_launch: "cmd" | ["cmd" | {command, ignore_failure, timeout}, ...]
+	"post_launch": {
+		"oneOf": []any{
+			map[string]any{"type": "string"},
+			map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"oneOf": []any{
+						map[string]any{"type": "string"},
+						map[string]any{
+							"type":                 "object",
+							"additionalProperties": false,
+							"required":             []any{"command"},
+							"properties": map[string]any{
+								"command":        map[string]any{"type": "string"},
+								"ignore_failure": map[string]any{"type": "boolean"},
+								"timeout":        map[string]any{"type": "string"},
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+	"hooks":    {"type": "object"},
+	"settings": {"type": "object"},
+}
+
+// RoleJSONSchema returns a JSON Schema (draft 2020-12) describing role YAML
+// files, derived from the Role struct's YAML tags. Fields tagged yaml:"-"
+// are omitted. Template roles (.yaml.tmpl) are only valid against it once
+// rendered.
+func RoleJSONSchema() ([]byte, error) {
+	root := schemaForType(reflect.TypeOf(Role{}), "")
+	props := root["properties"].(map[string]any)
+	props["inherits"] = map[string]any{
+		"type":        "string",
+		"description": "Name of a parent role to inherit fields and variables from.",
+	}
+	root["required"] = []string{"role_name"}
+
+	schema := map[string]any{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$id":     RoleSchemaID,
+		"title":   "h2 role",
+	}
+	for k, v := range root {
+		schema[k] = v
+	}
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+var yamlNodeType = reflect.TypeOf(yaml.Node{})
+
+func schemaForType(t reflect.Type, path string) map[string]any {
+	if override, ok := roleSchemaOverrides[path]; ok {
+		return override
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == yamlNodeType {
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		s := map[string]any{"type": "string"}
+		if enum := roleSchemaEnums[path]; len(enum) > 0 {
+			s["enum"] = enum
+		}
+		return s
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaForType(t.Elem(), path)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaForType(t.Elem(), path+".*")}
+	case reflect.Struct:
+		props := map[string]any{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name := yamlFieldName(f)
+			if name == "-" {
+				continue
+			}
+			childPath := name
+			if path != "" {
+				childPath = path + "." + name
+			}
+			props[name] = schemaForType(f.Type, childPath)
+		}
+		return map[string]any{
+			"type":                 "object",
+			"properties":           props,
+			"additionalProperties": false,
+		}
+	default:
+		return map[string]any{}
+	}
+}
+
+// yamlFieldName returns the key yaml.v3 uses for a struct field: the tag
+// name if set, otherwise the lowercased field name.
+func yamlFieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	if name == "" {
+		name = strings.ToLower(f.Name)
+	}
+	return name
+}
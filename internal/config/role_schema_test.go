@@ -0,0 +1,80 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func loadRoleSchema(t *testing.T) map[string]any {
+	t.Helper()
+	data, err := RoleJSONSchema()
+	if err != nil {
+		t.Fatalf("RoleJSONSchema: %v", err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+	return schema
+}
+
+func schemaProp(t *testing.T, schema map[string]any, path ...string) map[string]any {
+	t.Helper()
+	cur := schema
+	for _, name := range path {
+		props, ok := cur["properties"].(map[string]any)
+		if !ok {
+			t.Fatalf("no properties at %q in %v", name, cur)
+		}
+		cur, ok = props[name].(map[string]any)
+		if !ok {
+			t.Fatalf("missing schema property %v", path)
+		}
+	}
+	return cur
+}
+
+func TestRoleJSONSchema_CoversEveryRoleField(t *testing.T) {
+	schema := loadRoleSchema(t)
+	props := schema["properties"].(map[string]any)
+
+	rt := reflect.TypeOf(Role{})
+	for i := 0; i < rt.NumField(); i++ {
+		name := yamlFieldName(rt.Field(i))
+		if name == "-" {
+			continue
+		}
+		if _, ok := props[name]; !ok {
+			t.Errorf("schema missing role field %q", name)
+		}
+	}
+	if _, ok := props["inherits"]; !ok {
+		t.Error("schema missing inherits")
+	}
+}
+
+func TestRoleJSONSchema_EnumsAndNestedTypes(t *testing.T) {
+	schema := loadRoleSchema(t)
+
+	enum := schemaProp(t, schema, "claude_permission_mode")["enum"].([]any)
+	if len(enum) != len(ValidClaudePermissionModes) || enum[0] != ValidClaudePermissionModes[0] {
+		t.Fatalf("claude_permission_mode enum = %v", enum)
+	}
+	if got := schemaProp(t, schema, "codex_sandbox_mode")["enum"].([]any); len(got) != len(ValidCodexSandboxModes) {
+		t.Fatalf("codex_sandbox_mode enum = %v", got)
+	}
+	if got := schemaProp(t, schema, "permission_review", "dcg", "destructive_policy")["enum"]; got == nil {
+		t.Fatal("dcg destructive_policy should have an enum")
+	}
+	if got := schemaProp(t, schema, "worktree_enabled")["type"]; got != "boolean" {
+		t.Fatalf("worktree_enabled type = %v, want boolean", got)
+	}
+	if got := schemaProp(t, schema, "heartbeat", "condition")["oneOf"]; got == nil {
+		t.Fatal("heartbeat.condition should accept string, list, and any/all forms")
+	}
+	vars := schemaProp(t, schema, "variables")["additionalProperties"].(map[string]any)
+	if _, ok := vars["properties"].(map[string]any)["default"]; !ok {
+		t.Fatalf("variables entries should describe default, got %v", vars)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -85,6 +87,56 @@ instructions: |
 	}
 }
 
+func TestGetModel_PerHarnessMap(t *testing.T) {
+	yaml := `
+role_name: coder
+agent_model: fallback-model
+agent_models:
+  claude_code: opus
+  codex: gpt-5-codex
+instructions: |
+  You are a coding agent.
+`
+	path := writeTempFile(t, "coder.yaml", yaml)
+
+	role, err := LoadRoleFrom(path)
+	if err != nil {
+		t.Fatalf("LoadRoleFrom: %v", err)
+	}
+
+	tests := []struct {
+		harness string
+		want    string
+	}{
+		{"", "opus"}, // defaults to claude_code
+		{"claude_code", "opus"},
+		{"codex", "gpt-5-codex"},
+		{"generic", "fallback-model"}, // no entry: flat agent_model
+	}
+	for _, tt := range tests {
+		role.AgentHarness = tt.harness
+		if got := role.GetModel(); got != tt.want {
+			t.Errorf("GetModel() with harness %q = %q, want %q", tt.harness, got, tt.want)
+		}
+	}
+}
+
+func TestLoadRoleFrom_InvalidAgentModelsKey(t *testing.T) {
+	yaml := `
+role_name: coder
+agent_models:
+  claude: opus
+instructions: |
+  You are a coding agent.
+`
+	path := writeTempFile(t, "coder.yaml", yaml)
+
+	_, err := LoadRoleFrom(path)
+	if err == nil || !strings.Contains(err.Error(), `invalid agent_models key "claude"`) {
+		t.Fatalf("expected invalid agent_models key error, got %v", err)
+	}
+}
+
 func TestLoadRoleFrom_ValidationError(t *testing.T) {
 	// Missing role_name.
 	yaml := `
@@ -387,6 +439,97 @@ func TestIsClaudeConfigAuthenticated(t *testing.T) {
 	})
 }
 
+func TestIsCodexConfigAuthenticated(t *testing.T) {
+	tests := []struct {
+		name     string
+		authJSON string
+		want     bool
+		wantErr  bool
+	}{
+		{
+			name:     "api key",
+			authJSON: `{"OPENAI_API_KEY": "sk-test"}`,
+			want:     true,
+		},
+		{
+			name: "chatgpt login tokens",
+			authJSON: `{
+				"OPENAI_API_KEY": null,
+				"tokens": {"id_token": "id", "access_token": "access", "refresh_token": "refresh"}
+			}`,
+			want: true,
+		},
+		{
+			name:     "null api key and no tokens",
+			authJSON: `{"OPENAI_API_KEY": null}`,
+			want:     false,
+		},
+		{
+			name:     "empty tokens",
+			authJSON: `{"tokens": {}}`,
+			want:     false,
+		},
+		{
+			name:     "malformed",
+			authJSON: `{`,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "auth.json"), []byte(tt.authJSON), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := IsCodexConfigAuthenticated(dir)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("IsCodexConfigAuthenticated() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("IsCodexConfigAuthenticated() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("not authenticated - no file", func(t *testing.T) {
+		got, err := IsCodexConfigAuthenticated(t.TempDir())
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if got {
+			t.Error("should not be authenticated when auth.json doesn't exist")
+		}
+	})
+}
+
+func TestRole_IsRoleAuthenticatedDispatchesOnHarness(t *testing.T) {
+	claudePrefix := t.TempDir()
+	codexPrefix := t.TempDir()
+	os.MkdirAll(filepath.Join(claudePrefix, "default"), 0o755)
+	os.MkdirAll(filepath.Join(codexPrefix, "default"), 0o755)
+	os.WriteFile(filepath.Join(codexPrefix, "default", "auth.json"), []byte(`{"OPENAI_API_KEY":"sk-test"}`), 0o600)
+
+	base := Role{ClaudeCodeConfigPathPrefix: claudePrefix, CodexConfigPathPrefix: codexPrefix}
+
+	claude := base
+	if ok, err := claude.IsRoleAuthenticated(); err != nil || ok {
+		t.Fatalf("claude role: got (%v, %v), want (false, nil)", ok, err)
+	}
+	codex := base
+	codex.AgentHarness = "codex"
+	if ok, err := codex.IsRoleAuthenticated(); err != nil || !ok {
+		t.Fatalf("codex role: got (%v, %v), want (true, nil)", ok, err)
+	}
+	generic := base
+	generic.AgentHarness = "generic"
+	if ok, err := generic.IsRoleAuthenticated(); err != nil || !ok {
+		t.Fatalf("generic role: got (%v, %v), want (true, nil)", ok, err)
+	}
+}
+
 func TestRole_GetClaudeConfigDir(t *testing.T) {
 	ResetResolveCache()
 	t.Cleanup(ResetResolveCache)
@@ -481,6 +624,277 @@ heartbeat:
 	}
 }
 
+func TestLoadRoleFrom_HeartbeatConditionListForms(t *testing.T) {
+	tests := []struct {
+		name        string
+		condition   string
+		wantCmds    []string
+		wantCombine string
+	}{
+		{"plain list", "[\"bd ready -q\", \"test -f /tmp/x\"]", []string{"bd ready -q", "test -f /tmp/x"}, "all"},
+		{"all mapping", "{all: [\"a\", \"b\"]}", []string{"a", "b"}, "all"},
+		{"any mapping", "{any: [\"a\", \"b\"]}", []string{"a", "b"}, "any"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTempFile(t, "scheduler.yaml", `
+role_name: scheduler
+heartbeat:
+  idle_timeout: 30s
+  message: nudge
+  condition: `+tt.condition+`
+`)
+			role, err := LoadRoleFrom(path)
+			if err != nil {
+				t.Fatalf("LoadRoleFrom: %v", err)
+			}
+			hb := role.Heartbeat
+			if hb.Condition != "" {
+				t.Errorf("Condition = %q, want empty for list form", hb.Condition)
+			}
+			if strings.Join(hb.Conditions, "|") != strings.Join(tt.wantCmds, "|") {
+				t.Errorf("Conditions = %v, want %v", hb.Conditions, tt.wantCmds)
+			}
+			if hb.GetConditionCombine() != tt.wantCombine {
+				t.Errorf("combine = %q, want %q", hb.GetConditionCombine(), tt.wantCombine)
+			}
+		})
+	}
+}
+
+func TestLoadRoleFrom_HeartbeatConditionInvalidCombiner(t *testing.T) {
+	path := writeTempFile(t, "scheduler.yaml", `
+role_name: scheduler
+heartbeat:
+  idle_timeout: 30s
+  message: nudge
+  condition:
+    xor: ["a", "b"]
+`)
+	_, err := LoadRoleFrom(path)
+	if err == nil || !strings.Contains(err.Error(), "unknown combiner") {
+		t.Fatalf("expected unknown combiner error, got %v", err)
+	}
+}
+
+func TestLoadRoleFrom_PostLaunchForms(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want PostLaunchCommands
+	}{
+		{"single string", `direnv allow`, PostLaunchCommands{{Command: "direnv allow"}}},
+		{
+			"mixed list",
+			"\n  - nvm use\n  - command: make seed-db\n    ignore_failure: true\n    timeout: 10m",
+			PostLaunchCommands{{Command: "nvm use"}, {Command: "make seed-db", IgnoreFailure: true, Timeout: "10m"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTempFile(t, "setup.yaml", "role_name: setup\npost_launch: "+tt.yaml+"\n")
+			role, err := LoadRoleFrom(path)
+			if err != nil {
+				t.Fatalf("LoadRoleFrom: %v", err)
+			}
+			if !reflect.DeepEqual(role.PostLaunch, tt.want) {
+				t.Errorf("PostLaunch = %+v, want %+v", role.PostLaunch, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadRoleFrom_PostLaunchInvalid(t *testing.T) {
+	path := writeTempFile(t, "setup.yaml", "role_name: setup\npost_launch:\n  cmd: nvm use\n")
+	if _, err := LoadRoleFrom(path); err == nil || !strings.Contains(err.Error(), "post_launch") {
+		t.Fatalf("expected post_launch error for a mapping, got %v", err)
+	}
+
+	role := &Role{RoleName: "setup", PostLaunch: PostLaunchCommands{{Command: " "}}}
+	if err := role.Validate(); err == nil || !strings.Contains(err.Error(), "post_launch[0]: command is required") {
+		t.Fatalf("expected missing command error, got %v", err)
+	}
+
+	role = &Role{RoleName: "setup", PostLaunch: PostLaunchCommands{{Command: "make seed-db", Timeout: "0s"}}}
+	if err := role.Validate(); err == nil || !strings.Contains(err.Error(), `post_launch[0]: invalid timeout "0s"`) {
+		t.Fatalf("expected invalid timeout error, got %v", err)
+	}
+}
+
+func TestHeartbeatConfig_ConditionCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		hb   HeartbeatConfig
+		want bool
+	}{
+		{"single pass", HeartbeatConfig{Condition: "true"}, true},
+		{"all pass", HeartbeatConfig{Conditions: []string{"true", "exit 0"}}, true},
+		{"all one false", HeartbeatConfig{Conditions: []string{"true", "false"}}, false},
+		{"all erroring command", HeartbeatConfig{Conditions: []string{"true", "no-such-command-h2-test"}}, false},
+		{"any one pass", HeartbeatConfig{Conditions: []string{"false", "true"}, ConditionCombine: "any"}, true},
+		{"any erroring then pass", HeartbeatConfig{Conditions: []string{"no-such-command-h2-test", "true"}, ConditionCombine: "any"}, true},
+		{"any none pass", HeartbeatConfig{Conditions: []string{"false", "exit 3"}, ConditionCombine: "any"}, false},
+		{"trailing comment", HeartbeatConfig{Conditions: []string{"true # ok", "true"}}, true},
+		{"single wins over list", HeartbeatConfig{Condition: "true", Conditions: []string{"false"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := tt.hb.ConditionCommand()
+			got := exec.Command("sh", "-c", cmd).Run() == nil
+			if got != tt.want {
+				t.Errorf("ConditionCommand() = %q evaluated to %v, want %v", cmd, got, tt.want)
+			}
+		})
+	}
+
+	empty := HeartbeatConfig{}
+	if got := empty.ConditionCommand(); got != "" {
+		t.Errorf("ConditionCommand() with no condition = %q, want empty", got)
+	}
+}
+
+func TestHeartbeatConfig_IdleRequiresNotBlockedOnPermission(t *testing.T) {
+	tests := []struct {
+		name     string
+		hb       HeartbeatConfig
+		subState string
+		want     bool
+	}{
+		{"idle", HeartbeatConfig{}, "none", true},
+		{"blocked", HeartbeatConfig{}, "blocked_on_permission", false},
+		{"reviewing", HeartbeatConfig{}, "permission_review", false},
+		{"condition and idle", HeartbeatConfig{Condition: "true"}, "none", true},
+		{"condition fails", HeartbeatConfig{Condition: "false"}, "none", false},
+		{"any list and blocked", HeartbeatConfig{Conditions: []string{"false", "true"}, ConditionCombine: "any"}, "blocked_on_permission", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hb := tt.hb
+			hb.IdleRequires = []string{HeartbeatIdleNotBlockedOnPermission}
+			cmd := exec.Command("sh", "-c", hb.ConditionCommand())
+			cmd.Env = append(os.Environ(), "H2_AGENT_SUBSTATE="+tt.subState)
+			if got := cmd.Run() == nil; got != tt.want {
+				t.Errorf("ConditionCommand() = %q with substate %s evaluated to %v, want %v",
+					hb.ConditionCommand(), tt.subState, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadRoleFrom_HeartbeatIdleRequires(t *testing.T) {
+	path := writeTempFile(t, "hb.yaml", `
+role_name: hb
+heartbeat:
+  idle_timeout: 30s
+  message: nudge
+  idle_requires: [not_blocked_on_permission]
+`)
+	role, err := LoadRoleFrom(path)
+	if err != nil {
+		t.Fatalf("LoadRoleFrom: %v", err)
+	}
+	if got := role.Heartbeat.IdleRequires; len(got) != 1 || got[0] != HeartbeatIdleNotBlockedOnPermission {
+		t.Errorf("IdleRequires = %v", got)
+	}
+
+	path = writeTempFile(t, "hb-invalid.yaml", `
+role_name: hb
+heartbeat:
+  idle_timeout: 30s
+  message: nudge
+  idle_requires: [not_compacting]
+`)
+	if _, err := LoadRoleFrom(path); err == nil || !strings.Contains(err.Error(), "heartbeat.idle_requires") {
+		t.Fatalf("expected idle_requires validation error, got %v", err)
+	}
+}
+
+func TestLoadRoleFrom_HeartbeatPodStrategy(t *testing.T) {
+	path := writeTempFile(t, "hb.yaml", `
+role_name: hb
+heartbeat:
+  idle_timeout: 30s
+  message: nudge
+  pod_strategy: staggered
+`)
+	role, err := LoadRoleFrom(path)
+	if err != nil {
+		t.Fatalf("LoadRoleFrom: %v", err)
+	}
+	if role.Heartbeat.PodStrategy != HeartbeatPodStaggered {
+		t.Errorf("PodStrategy = %q, want staggered", role.Heartbeat.PodStrategy)
+	}
+	out, err := yaml.Marshal(role.Heartbeat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "pod_strategy: staggered") {
+		t.Errorf("marshaled heartbeat lost pod_strategy:\n%s", out)
+	}
+
+	path = writeTempFile(t, "hb-invalid.yaml", `
+role_name: hb
+heartbeat:
+  idle_timeout: 30s
+  message: nudge
+  pod_strategy: random
+`)
+	if _, err := LoadRoleFrom(path); err == nil || !strings.Contains(err.Error(), "heartbeat.pod_strategy") {
+		t.Fatalf("expected pod_strategy validation error, got %v", err)
+	}
+}
+
+func TestLoadRoleRenderedFrom_InheritanceHeartbeatConditionReplaced(t *testing.T) {
+	rolesDir := setupInheritanceRolesEnv(t)
+	writeRoleFile(t, rolesDir, "parent.yaml", `
+role_name: parent
+heartbeat:
+  idle_timeout: 30s
+  message: nudge
+  condition:
+    all: ["a", "b"]
+`)
+	childPath := writeRoleFile(t, rolesDir, "child.yaml", `
+role_name: child
+inherits: parent
+heartbeat:
+  condition:
+    any: ["c"]
+`)
+
+	role, err := LoadRoleRenderedFrom(childPath, &tmpl.Context{})
+	if err != nil {
+		t.Fatalf("LoadRoleRenderedFrom: %v", err)
+	}
+	hb := role.Heartbeat
+	if hb.IdleTimeout != "30s" || hb.Message != "nudge" {
+		t.Errorf("expected parent idle_timeout/message to be inherited, got %+v", hb)
+	}
+	if hb.GetConditionCombine() != "any" || strings.Join(hb.Conditions, ",") != "c" {
+		t.Errorf("expected child condition to replace parent, got %v (%s)", hb.Conditions, hb.GetConditionCombine())
+	}
+}
+
+func TestLoadRoleRenderedFrom_HeartbeatMessageKeepsConditionOutput(t *testing.T) {
+	rolesDir := setupInheritanceRolesEnv(t)
+	path := writeRoleFile(t, rolesDir, "scheduler.yaml.tmpl", `
+role_name: scheduler
+heartbeat:
+  idle_timeout: 30s
+  message: "{{ .AgentName }}: {{ .ConditionOutput }} tasks are ready"
+  condition: "bd ready -q"
+`)
+
+	role, err := LoadRoleRenderedFrom(path, &tmpl.Context{AgentName: "sched-1"})
+	if err != nil {
+		t.Fatalf("LoadRoleRenderedFrom: %v", err)
+	}
+	want := "sched-1: {{ .ConditionOutput }} tasks are ready"
+	if role.Heartbeat.Message != want {
+		t.Errorf("Message = %q, want %q", role.Heartbeat.Message, want)
+	}
+}
+
 func TestLoadRoleFrom_HeartbeatOptional(t *testing.T) {
 	yaml := `
 role_name: simple
@@ -584,6 +998,173 @@ func TestResolveWorkingDir_Relative(t *testing.T) {
 	}
 }
 
+func TestEnsureWorkingDir_CreatesUnderH2Dir(t *testing.T) {
+	ResetResolveCache()
+	defer ResetResolveCache()
+
+	h2Dir := t.TempDir()
+	WriteMarker(h2Dir)
+	t.Setenv("H2_DIR", h2Dir)
+
+	role := &Role{RoleName: "test", WorkingDir: "scratch/run-1", CreateWorkingDir: true}
+	dir, err := role.ResolveWorkingDir("/my/cwd")
+	if err != nil {
+		t.Fatalf("ResolveWorkingDir: %v", err)
+	}
+	if err := role.EnsureWorkingDir(dir); err != nil {
+		t.Fatalf("EnsureWorkingDir: %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(h2Dir, "scratch", "run-1")); err != nil || !info.IsDir() {
+		t.Fatalf("expected working dir to be created, stat err = %v", err)
+	}
+}
+
+func TestEnsureWorkingDir_DisabledLeavesMissingDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "missing")
+	role := &Role{RoleName: "test", WorkingDir: dir}
+	if err := role.EnsureWorkingDir(dir); err != nil {
+		t.Fatalf("EnsureWorkingDir: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected %s not to be created, stat err = %v", dir, err)
+	}
+}
+
+func TestEnsureWorkingDir_ReportsFailure(t *testing.T) {
+	parent := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(parent, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(parent, "sub")
+	role := &Role{RoleName: "test", WorkingDir: dir, CreateWorkingDir: true}
+	err := role.EnsureWorkingDir(dir)
+	if err == nil || !strings.Contains(err.Error(), "create working_dir "+dir) {
+		t.Fatalf("expected create working_dir error, got %v", err)
+	}
+}
+
+func TestValidateCreateWorkingDir(t *testing.T) {
+	tests := []struct {
+		name    string
+		role    Role
+		wantErr string
+	}{
+		{"absolute", Role{WorkingDir: "/tmp/scratch", CreateWorkingDir: true}, ""},
+		{"relative", Role{WorkingDir: "scratch/a", CreateWorkingDir: true}, ""},
+		{"unset", Role{CreateWorkingDir: true}, "requires working_dir"},
+		{"dot", Role{WorkingDir: ".", CreateWorkingDir: true}, "requires working_dir"},
+		{"escapes h2 dir", Role{WorkingDir: "scratch/../../elsewhere", CreateWorkingDir: true}, "outside the h2 dir"},
+		{"worktree", Role{WorkingDir: "/tmp/repo", CreateWorkingDir: true, WorktreeEnabled: true}, "worktree_enabled"},
+		{"flag off", Role{WorkingDir: "../elsewhere"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.role.validateCreateWorkingDir()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveAdditionalDirs_Glob(t *testing.T) {
+	ResetResolveCache()
+	defer ResetResolveCache()
+
+	h2Dir := t.TempDir()
+	WriteMarker(h2Dir)
+	t.Setenv("H2_DIR", h2Dir)
+	for _, d := range []string{"projects/b", "projects/a", "projects/c"} {
+		os.MkdirAll(filepath.Join(h2Dir, d), 0o755)
+	}
+	os.WriteFile(filepath.Join(h2Dir, "projects", "README.md"), []byte("x"), 0o644)
+
+	role := &Role{RoleName: "test", AdditionalDirs: []string{"projects/c", "projects/*", "/abs/literal"}}
+	got, err := role.ResolveAdditionalDirs("/my/cwd")
+	if err != nil {
+		t.Fatalf("ResolveAdditionalDirs: %v", err)
+	}
+	want := []string{
+		filepath.Join(h2Dir, "projects/c"),
+		filepath.Join(h2Dir, "projects/a"),
+		filepath.Join(h2Dir, "projects/b"),
+		"/abs/literal",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ResolveAdditionalDirs = %v, want %v", got, want)
+	}
+}
+
+func TestResolveAdditionalDirs_GlobNoMatch(t *testing.T) {
+	ResetResolveCache()
+	defer ResetResolveCache()
+
+	h2Dir := t.TempDir()
+	WriteMarker(h2Dir)
+	t.Setenv("H2_DIR", h2Dir)
+
+	role := &Role{RoleName: "test", AdditionalDirs: []string{"projcts/*"}}
+	if _, err := role.ResolveAdditionalDirs("/my/cwd"); err == nil || !strings.Contains(err.Error(), "matched no directories") {
+		t.Fatalf("expected no-match error, got %v", err)
+	}
+
+	role.AdditionalDirs = []string{"projcts/*:optional", "."}
+	got, err := role.ResolveAdditionalDirs("/my/cwd")
+	if err != nil {
+		t.Fatalf("optional glob: %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{"/my/cwd"}) {
+		t.Errorf("ResolveAdditionalDirs = %v, want [/my/cwd]", got)
+	}
+}
+
+func TestResolveSkillsDir(t *testing.T) {
+	ResetResolveCache()
+	defer ResetResolveCache()
+
+	h2Dir := t.TempDir()
+	WriteMarker(h2Dir)
+	t.Setenv("H2_DIR", h2Dir)
+	os.MkdirAll(filepath.Join(h2Dir, "skills", "reviewer"), 0o755)
+	os.WriteFile(filepath.Join(h2Dir, "skills", "notes.md"), []byte("x"), 0o644)
+
+	role := &Role{RoleName: "test"}
+	if got, err := role.ResolveSkillsDir(); err != nil || got != "" {
+		t.Fatalf("unset skills_dir = %q, %v", got, err)
+	}
+
+	role.SkillsDir = "skills/reviewer"
+	got, err := role.ResolveSkillsDir()
+	if err != nil {
+		t.Fatalf("ResolveSkillsDir: %v", err)
+	}
+	if want := filepath.Join(h2Dir, "skills", "reviewer"); got != want {
+		t.Errorf("ResolveSkillsDir = %q, want %q", got, want)
+	}
+
+	role.SkillsDir = "skills/missing"
+	if _, err := role.ResolveSkillsDir(); err == nil || !strings.Contains(err.Error(), "skills_dir") {
+		t.Errorf("expected missing dir error, got %v", err)
+	}
+	role.SkillsDir = "skills/notes.md"
+	if _, err := role.ResolveSkillsDir(); err == nil || !strings.Contains(err.Error(), "not a directory") {
+		t.Errorf("expected not a directory error, got %v", err)
+	}
+}
+
+func TestValidate_SkillsDirIsClaudeOnly(t *testing.T) {
+	role := &Role{RoleName: "test", AgentHarness: "codex", SkillsDir: "skills/reviewer"}
+	if err := role.Validate(); err == nil || !strings.Contains(err.Error(), "skills_dir only applies to agent_harness claude_code") {
+		t.Fatalf("expected harness field error, got %v", err)
+	}
+}
+
 func TestResolveWorkingDir_FromYAML(t *testing.T) {
 	yaml := `
 role_name: worker
@@ -711,6 +1292,60 @@ func TestBuildWorktreeConfig_DetachedHeadSentinel(t *testing.T) {
 	}
 }
 
+func TestBuildWorktreeConfig_GitIdentity(t *testing.T) {
+	path := writeTempFile(t, "identity.yaml", `
+role_name: coder
+instructions: Work.
+worktree_enabled: true
+working_dir: /tmp/repo
+worktree_name: "{{ .AgentName }}"
+worktree_git_author: "{{ .AgentName }} (h2)"
+worktree_git_email: "{{ .AgentName }}@agents.local"
+`)
+	role, err := LoadRoleRenderedFrom(path, &tmpl.Context{AgentName: "coder-1"})
+	if err != nil {
+		t.Fatalf("LoadRoleRenderedFrom: %v", err)
+	}
+	cfg, err := role.BuildWorktreeConfig("/tmp/repo", "coder-1")
+	if err != nil {
+		t.Fatalf("BuildWorktreeConfig: %v", err)
+	}
+	if cfg.GitAuthor != "coder-1 (h2)" || cfg.GitEmail != "coder-1@agents.local" {
+		t.Errorf("identity = %q <%s>, want coder-1 (h2) <coder-1@agents.local>", cfg.GitAuthor, cfg.GitEmail)
+	}
+}
+
+func TestValidate_WorktreeGitEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		valid bool
+	}{
+		{"coder-1@agents.local", true},
+		{"<AGENT_NAME_PLACEHOLDER>@agents.local", true},
+		{"coder-1", false},
+		{"@agents.local", false},
+		{"coder-1@", false},
+		{"a@b@c", false},
+		{"coder 1@agents.local", false},
+	}
+	for _, tt := range tests {
+		role := &Role{
+			RoleName:         "test",
+			WorktreeEnabled:  true,
+			WorkingDir:       "/tmp/repo",
+			WorktreeName:     "wt-1",
+			WorktreeGitEmail: tt.email,
+		}
+		err := role.Validate()
+		if tt.valid && err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.email, err)
+		}
+		if !tt.valid && (err == nil || !strings.Contains(err.Error(), "worktree_git_email")) {
+			t.Errorf("%q: error = %v, want a worktree_git_email error", tt.email, err)
+		}
+	}
+}
+
 func TestLoadRoleFrom_QuotedTemplateValues(t *testing.T) {
 	// Quoted {{ }} values should be valid YAML and parse correctly.
 	yaml := `
@@ -735,6 +1370,24 @@ instructions: |
 
 // --- LoadRoleRendered tests ---
 
+func TestLoadRoleRenderedFrom_FileContents(t *testing.T) {
+	rolesDir := setupInheritanceRolesEnv(t)
+	os.MkdirAll(filepath.Join(rolesDir, "shared"), 0o755)
+	os.WriteFile(filepath.Join(rolesDir, "shared", "guidelines.md"), []byte("Review carefully.\nCite line numbers."), 0o644)
+	path := writeRoleFile(t, rolesDir, "reviewer.yaml", `
+role_name: reviewer
+instructions: {{ fileContents "shared/guidelines.md" | quote }}
+`)
+
+	role, err := LoadRoleRenderedFrom(path, &tmpl.Context{})
+	if err != nil {
+		t.Fatalf("LoadRoleRenderedFrom: %v", err)
+	}
+	if role.Instructions != "Review carefully.\nCite line numbers." {
+		t.Errorf("Instructions = %q", role.Instructions)
+	}
+}
+
 func TestLoadRoleRenderedFrom_BasicRendering(t *testing.T) {
 	yamlContent := `
 role_name: coder
@@ -908,6 +1561,47 @@ instructions: |
 	}
 }
 
+func TestLoadRoleRenderedFrom_DefaultReferencesVar(t *testing.T) {
+	path := writeTempFile(t, "branchvar.yaml", `
+role_name: coder
+variables:
+  ticket:
+    description: "Ticket ID"
+  branch_name:
+    default: "feature/{{ .Var.ticket }}"
+instructions: |
+  Work on {{ .Var.branch_name }}.
+`)
+	role, err := LoadRoleRenderedFrom(path, &tmpl.Context{Var: map[string]string{"ticket": "H2-42"}})
+	if err != nil {
+		t.Fatalf("LoadRoleRenderedFrom: %v", err)
+	}
+	if !strings.Contains(role.Instructions, "Work on feature/H2-42.") {
+		t.Errorf("Instructions = %q", role.Instructions)
+	}
+
+	_, err = LoadRoleRenderedFrom(path, &tmpl.Context{Var: map[string]string{}})
+	if err == nil || !strings.Contains(err.Error(), "ticket") {
+		t.Errorf("expected missing ticket error, got %v", err)
+	}
+}
+
+func TestLoadRoleRenderedFrom_DefaultCycle(t *testing.T) {
+	path := writeTempFile(t, "cyclevar.yaml", `
+role_name: coder
+variables:
+  a:
+    default: "{{ .Var.b }}"
+  b:
+    default: "{{ .Var.a }}"
+instructions: hi
+`)
+	_, err := LoadRoleRenderedFrom(path, &tmpl.Context{Var: map[string]string{"a": "set"}})
+	if err == nil || !strings.Contains(err.Error(), "cycle: a -> b -> a") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+}
+
 func TestLoadRoleRenderedFrom_NilContext(t *testing.T) {
 	yamlContent := `
 role_name: coder
@@ -1075,6 +1769,34 @@ agent_model: "{{ .Var.model }}"
 	}
 }
 
+func TestListRoleFiles_ReportsLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "good.yaml"), []byte("role_name: good\ndescription: Works\ninstructions: hi\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "broken.yaml"), []byte("role_name: broken\n  instructions: [\n"), 0o644)
+
+	listings, err := listRoleFilesFromDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listings) != 2 {
+		t.Fatalf("got %d listings, want 2", len(listings))
+	}
+	if l := listings[0]; l.Name != "broken" || l.Err == nil || l.Role != nil {
+		t.Errorf("broken listing = %+v, want a load error", l)
+	}
+	if l := listings[1]; l.Name != "good" || l.Err != nil || l.Role.Description != "Works" {
+		t.Errorf("good listing = %+v", l)
+	}
+
+	roles, err := listRolesFromDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roles) != 1 || roles[0].RoleName != "good" {
+		t.Errorf("listRolesFromDir = %v, want only the good role", roles)
+	}
+}
+
 // --- Section 6.4: ListRoles with Templated Roles ---
 
 func TestListRoles_WithTemplatedRoles(t *testing.T) {
@@ -1464,6 +2186,55 @@ func TestValidate_CodexSandboxMode_Empty(t *testing.T) {
 	}
 }
 
+func TestValidate_HarnessFieldConflicts(t *testing.T) {
+	tests := []struct {
+		name    string
+		role    Role
+		wantErr string
+	}{
+		{"claude with codex sandbox", Role{AgentHarness: "claude_code", CodexSandboxMode: "read-only"}, "codex_sandbox_mode only applies to agent_harness codex"},
+		{"claude with codex approval", Role{AgentHarness: "claude_code", CodexAskForApproval: "never"}, "codex_ask_for_approval"},
+		{"claude with codex config", Role{AgentHarness: "claude_code", CodexConfigPathPrefix: "/x"}, "codex_config_path_prefix"},
+		{"codex with claude permission", Role{AgentHarness: "codex", ClaudePermissionMode: "plan"}, "claude_permission_mode only applies to agent_harness claude_code"},
+		{"claude with its own fields", Role{AgentHarness: "claude_code", ClaudePermissionMode: "plan"}, ""},
+		{"codex with its own fields", Role{AgentHarness: "codex", CodexSandboxMode: "read-only"}, ""},
+		{"unset harness", Role{CodexSandboxMode: "read-only", ClaudePermissionMode: "plan"}, ""},
+		{"generic harness", Role{AgentHarness: "generic", CodexSandboxMode: "read-only", ClaudePermissionMode: "plan"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.role.RoleName = "test"
+			err := tt.role.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHarnessFieldWarnings(t *testing.T) {
+	unset := &Role{RoleName: "test", CodexSandboxMode: "read-only", ClaudePermissionMode: "plan"}
+	if w := unset.HarnessFieldWarnings(); len(w) != 1 || !strings.Contains(w[0], "codex_sandbox_mode") {
+		t.Errorf("unset harness warnings = %q, want one for codex_sandbox_mode", w)
+	}
+
+	generic := &Role{RoleName: "test", AgentHarness: "generic", CodexAskForApproval: "never", ClaudePermissionMode: "plan"}
+	if w := generic.HarnessFieldWarnings(); len(w) != 2 {
+		t.Errorf("generic harness warnings = %q, want 2", w)
+	}
+
+	codex := &Role{RoleName: "test", AgentHarness: "codex", CodexSandboxMode: "read-only"}
+	if w := codex.HarnessFieldWarnings(); len(w) != 0 {
+		t.Errorf("codex harness warnings = %q, want none", w)
+	}
+}
+
 func TestLoadRoleFrom_CodexAskForApprovalField(t *testing.T) {
 	yaml := `
 role_name: test
@@ -2365,6 +3136,72 @@ instructions: child
 	}
 }
 
+func TestValidate_ToolInBothLists(t *testing.T) {
+	role := &Role{
+		RoleName:     "test",
+		AllowedTools: []string{"Read", "WebFetch"},
+		DeniedTools:  []string{"WebFetch"},
+	}
+	err := role.Validate()
+	if err == nil {
+		t.Fatal("expected error when a tool is both allowed and denied")
+	}
+	if !strings.Contains(err.Error(), "WebFetch") {
+		t.Errorf("error should name the conflicting tool: %v", err)
+	}
+}
+
+func TestLoadRoleRenderedFrom_InheritanceToolListsMerge(t *testing.T) {
+	rolesDir := setupInheritanceRolesEnv(t)
+	writeRoleFile(t, rolesDir, "parent.yaml", `
+role_name: parent
+allowed_tools: [Read, WebFetch]
+denied_tools: [Bash]
+instructions: parent
+`)
+	childPath := writeRoleFile(t, rolesDir, "child.yaml", `
+role_name: child
+inherits: parent
+allowed_tools: [Bash]
+denied_tools: [WebFetch]
+instructions: child
+`)
+
+	role, err := LoadRoleRenderedFrom(childPath, &tmpl.Context{})
+	if err != nil {
+		t.Fatalf("LoadRoleRenderedFrom: %v", err)
+	}
+	if got := strings.Join(role.AllowedTools, ","); got != "Read,Bash" {
+		t.Errorf("AllowedTools = %q, want %q", got, "Read,Bash")
+	}
+	if got := strings.Join(role.DeniedTools, ","); got != "WebFetch" {
+		t.Errorf("DeniedTools = %q, want %q", got, "WebFetch")
+	}
+}
+
+func TestLoadRoleRenderedFrom_InheritanceToolListsNullClears(t *testing.T) {
+	rolesDir := setupInheritanceRolesEnv(t)
+	writeRoleFile(t, rolesDir, "parent.yaml", `
+role_name: parent
+denied_tools: [WebFetch, Bash]
+instructions: parent
+`)
+	childPath := writeRoleFile(t, rolesDir, "child.yaml", `
+role_name: child
+inherits: parent
+denied_tools: null
+instructions: child
+`)
+
+	role, err := LoadRoleRenderedFrom(childPath, &tmpl.Context{})
+	if err != nil {
+		t.Fatalf("LoadRoleRenderedFrom: %v", err)
+	}
+	if len(role.DeniedTools) != 0 {
+		t.Errorf("DeniedTools = %v, want empty", role.DeniedTools)
+	}
+}
+
 func setupInheritanceRolesEnv(t *testing.T) string {
 	t.Helper()
 	h2Dir := t.TempDir()
@@ -2416,3 +3253,81 @@ func writeTempFile(t *testing.T, name, content string) string {
 	}
 	return path
 }
+
+const secretRoleYAML = `
+role_name: deployer
+variables:
+  api_token:
+    description: "Deploy API token"
+    secret: true
+    default: "tok-default-123"
+  team:
+    default: "core"
+instructions: |
+  Team {{ .Var.team }} deploys with token {{ .Var.api_token }}.
+settings:
+  env:
+    DEPLOY_TOKEN: "{{ .Var.api_token }}"
+`
+
+func TestLoadRoleRenderedFrom_SecretVarKeepsRealValue(t *testing.T) {
+	path := writeTempFile(t, "deployer.yaml", secretRoleYAML)
+	role, err := LoadRoleRenderedFrom(path, &tmpl.Context{Var: map[string]string{"api_token": "tok-real-456"}})
+	if err != nil {
+		t.Fatalf("LoadRoleRenderedFrom: %v", err)
+	}
+	if !strings.Contains(role.Instructions, "tok-real-456") {
+		t.Errorf("launch render should keep the real secret, got: %s", role.Instructions)
+	}
+	if got := role.SecretValues(); len(got) != 1 || got[0] != "tok-real-456" {
+		t.Errorf("SecretValues = %q", got)
+	}
+	if got := role.Redact(role.Instructions); strings.Contains(got, "tok-real-456") || !strings.Contains(got, "token ***.") {
+		t.Errorf("Redact = %q", got)
+	}
+}
+
+func TestLoadRoleForDisplay_MasksSecretVars(t *testing.T) {
+	rolesDir := setupInheritanceRolesEnv(t)
+	writeRoleFile(t, rolesDir, "deployer.yaml", secretRoleYAML)
+
+	for _, vars := range []map[string]string{nil, {"api_token": "tok-real-456"}} {
+		role, _, err := LoadRoleForDisplayWithVars("deployer", vars)
+		if err != nil {
+			t.Fatalf("LoadRoleForDisplayWithVars(%v): %v", vars, err)
+		}
+		if strings.Contains(role.Instructions, "tok-") || !strings.Contains(role.Instructions, "token ***.") {
+			t.Errorf("instructions not masked: %q", role.Instructions)
+		}
+		settings, err := yaml.Marshal(&role.Settings)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(settings), "tok-") {
+			t.Errorf("settings not masked: %s", settings)
+		}
+		if def := role.Variables["api_token"]; def.Default == nil || *def.Default != "***" {
+			t.Errorf("secret default not masked: %+v", def)
+		}
+		if def := role.Variables["team"]; def.Default == nil || *def.Default != "core" {
+			t.Errorf("non-secret default changed: %+v", def)
+		}
+	}
+}
+
+func TestLoadRoleRenderedFrom_ErrorRedactsSecrets(t *testing.T) {
+	path := writeTempFile(t, "broken.yaml", `
+role_name: broken
+variables:
+  api_token:
+    secret: true
+claude_permission_mode: "{{ .Var.api_token }}"
+`)
+	_, err := LoadRoleRenderedFrom(path, &tmpl.Context{Var: map[string]string{"api_token": "tok-real-456"}})
+	if err == nil {
+		t.Fatal("expected invalid permission mode error")
+	}
+	if strings.Contains(err.Error(), "tok-real-456") {
+		t.Errorf("error leaks secret: %v", err)
+	}
+}
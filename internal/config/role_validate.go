@@ -0,0 +1,96 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"h2/internal/tmpl"
+)
+
+// RoleFileCheck is the outcome of CheckRoleFile.
+type RoleFileCheck struct {
+	Role     *Role    // the rendered role; nil if it didn't render
+	Chain    []string // inheritance chain, root first, ending with the file's own role
+	Problems []error  // everything that would stop the role loading
+	Warnings []string // fields that will be ignored
+}
+
+// CheckRoleFile checks a role file that needn't be in the roles dir, e.g. one
+// being edited before it's moved there. Like a launch, it resolves inherits,
+// renders the file with vars on top of the variable defaults and validates
+// the result, but parents always come from the roles dir and it carries on
+// past missing required variables (rendering them as "<name>") so later
+// problems are reported too. Works on .yaml and .yaml.tmpl files.
+func CheckRoleFile(path string, vars map[string]string) *RoleFileCheck {
+	check := &RoleFileCheck{}
+	if _, err := os.Stat(path); err != nil {
+		check.Problems = append(check.Problems, fmt.Errorf("read role file: %w", err))
+		return check
+	}
+
+	plan, err := buildInheritanceRenderPlan(path, RolesDir())
+	if err != nil {
+		check.Problems = append(check.Problems, err)
+		return check
+	}
+	for _, level := range plan.chain {
+		check.Chain = append(check.Chain, level.name)
+	}
+	label := filepath.Base(path)
+
+	if err := tmpl.ValidateNoUnknownVars(plan.exposedDefs, vars); err != nil {
+		check.Problems = append(check.Problems, err)
+	}
+	resolved, err := mergeVarDefaults(vars, plan.renderDefs)
+	if err != nil {
+		check.Problems = append(check.Problems, redactError(err, tmpl.SecretValues(plan.renderDefs, vars)))
+		return check
+	}
+	var missing []string
+	for name, def := range plan.renderDefs {
+		if _, ok := resolved[name]; !ok && def.Required() {
+			missing = append(missing, name)
+			resolved[name] = "<" + name + ">"
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		check.Problems = append(check.Problems, fmt.Errorf("required variables not provided: %s (pass --var %s=VALUE)",
+			strings.Join(missing, ", "), missing[0]))
+	}
+
+	rootDir, _ := RootDir()
+	ctx := &tmpl.Context{
+		RoleName:  roleNameFromFile(label),
+		AgentName: "<name>",
+		H2Dir:     ConfigDir(),
+		H2RootDir: rootDir,
+		Var:       resolved,
+	}
+	role, err := renderRoleFromPlan(plan, ctx, listStubFuncs, label, true)
+	if err != nil {
+		check.Problems = append(check.Problems, splitRoleProblems(err, label)...)
+		return check
+	}
+	check.Role = role
+	check.Warnings = append(role.HarnessFieldWarnings(), role.HookWarnings()...)
+	return check
+}
+
+// splitRoleProblems splits the problems Role.Validate joins into one error
+// each, so they're listed separately.
+func splitRoleProblems(err error, label string) []error {
+	var joined interface{ Unwrap() []error }
+	if !errors.As(err, &joined) {
+		return []error{err}
+	}
+	var problems []error
+	for _, e := range joined.Unwrap() {
+		problems = append(problems, fmt.Errorf("invalid role %q: %w", label, e))
+	}
+	return problems
+}
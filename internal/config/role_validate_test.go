@@ -0,0 +1,117 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckRoleFile_OutsideRolesDirInheritsFromRolesDir(t *testing.T) {
+	h2Dir := setupTestH2Dir(t)
+	if err := os.WriteFile(filepath.Join(h2Dir, "roles", "base.yaml"), []byte(`
+role_name: base
+agent_model: sonnet
+instructions: base
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := writeTempFile(t, "child.yaml", `
+role_name: child
+inherits: base
+instructions: child
+`)
+
+	check := CheckRoleFile(path, nil)
+	if len(check.Problems) > 0 {
+		t.Fatalf("unexpected problems: %v", check.Problems)
+	}
+	if check.Role.RoleName != "child" || check.Role.GetModel() != "sonnet" {
+		t.Errorf("role = %q model %q, want child inheriting sonnet", check.Role.RoleName, check.Role.GetModel())
+	}
+	if got := strings.Join(check.Chain, " -> "); got != "base -> child" {
+		t.Errorf("chain = %q, want base -> child", got)
+	}
+}
+
+func TestCheckRoleFile_TemplateWithVars(t *testing.T) {
+	setupTestH2Dir(t)
+	path := writeTempFile(t, "worker.yaml.tmpl", `
+role_name: worker
+variables:
+  team:
+    description: "Team"
+instructions: |
+  Team {{ .Var.team }}
+`)
+
+	check := CheckRoleFile(path, map[string]string{"team": "platform"})
+	if len(check.Problems) > 0 {
+		t.Fatalf("unexpected problems: %v", check.Problems)
+	}
+	if !strings.Contains(check.Role.Instructions, "Team platform") {
+		t.Errorf("instructions = %q, want the var rendered", check.Role.Instructions)
+	}
+}
+
+func TestCheckRoleFile_ReportsAllProblems(t *testing.T) {
+	setupTestH2Dir(t)
+	path := writeTempFile(t, "worker.yaml.tmpl", `
+role_name: worker
+variables:
+  team:
+    description: "Team"
+instructions: |
+  Team {{ .Var.team }}
+claude_permission_mode: sometimes
+`)
+
+	check := CheckRoleFile(path, map[string]string{"tema": "platform"})
+	if check.Role != nil {
+		t.Fatal("invalid role should not be returned")
+	}
+	var msgs []string
+	for _, p := range check.Problems {
+		msgs = append(msgs, p.Error())
+	}
+	all := strings.Join(msgs, "\n")
+	for _, want := range []string{"tema", "required variables not provided: team", "claude_permission_mode"} {
+		if !strings.Contains(all, want) {
+			t.Errorf("problems %q missing %q", all, want)
+		}
+	}
+}
+
+func TestCheckRoleFile_ListsEachValidationProblem(t *testing.T) {
+	setupTestH2Dir(t)
+	path := writeTempFile(t, "worker.yaml", `
+role_name: worker
+claude_permission_mode: sometimes
+codex_sandbox_mode: wide-open
+worktree_branch_from: main
+`)
+
+	check := CheckRoleFile(path, nil)
+	if len(check.Problems) != 3 {
+		t.Fatalf("problems = %v, want 3", check.Problems)
+	}
+	for i, want := range []string{"claude_permission_mode", "codex_sandbox_mode", "worktree_* fields"} {
+		if msg := check.Problems[i].Error(); !strings.Contains(msg, want) || !strings.Contains(msg, `invalid role "worker.yaml"`) {
+			t.Errorf("problem %d = %q, want one about %s", i, msg, want)
+		}
+	}
+}
+
+func TestCheckRoleFile_MissingParentAndFile(t *testing.T) {
+	setupTestH2Dir(t)
+	path := writeTempFile(t, "child.yaml", "role_name: child\ninherits: nope\n")
+	if check := CheckRoleFile(path, nil); len(check.Problems) != 1 || !strings.Contains(check.Problems[0].Error(), "nope") {
+		t.Errorf("problems = %v, want one naming the missing parent", check.Problems)
+	}
+
+	check := CheckRoleFile(filepath.Join(t.TempDir(), "missing.yaml"), nil)
+	if len(check.Problems) != 1 || !errors.Is(check.Problems[0], os.ErrNotExist) {
+		t.Errorf("problems = %v, want a not-exist error", check.Problems)
+	}
+}
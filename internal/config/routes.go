@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -63,7 +64,11 @@ func lockFilePath(rootDir string) string {
 	return filepath.Join(rootDir, "routes.jsonl.lock")
 }
 
-const lockTimeout = 5 * time.Second
+// lockTimeout bounds how long registration waits for another process's
+// lock. flock locks are dropped by the kernel when their holder exits, so a
+// crashed h2 never leaves one behind; this only guards against a holder that
+// is alive but stuck. A var so tests can shorten it.
+var lockTimeout = 5 * time.Second
 
 // acquireExclusiveLock takes an exclusive (write) lock on routes.jsonl.
 // The caller must call Unlock() on the returned lock when done.
@@ -78,11 +83,11 @@ func acquireExclusiveLock(rootDir string) (*flock.Flock, error) {
 
 	fl := flock.New(lockFilePath(rootDir))
 	ok, err := fl.TryLockContext(ctx, 50*time.Millisecond)
-	if err != nil {
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
 		return nil, fmt.Errorf("acquire routes lock: %w", err)
 	}
 	if !ok {
-		return nil, fmt.Errorf("acquire routes lock: timed out after %s", lockTimeout)
+		return nil, fmt.Errorf("acquire routes lock: timed out after %s waiting for %s; another h2 process is holding it", lockTimeout, lockFilePath(rootDir))
 	}
 	return fl, nil
 }
@@ -100,11 +105,11 @@ func acquireSharedLock(rootDir string) (*flock.Flock, error) {
 
 	fl := flock.New(lockFilePath(rootDir))
 	ok, err := fl.TryRLockContext(ctx, 50*time.Millisecond)
-	if err != nil {
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
 		return nil, fmt.Errorf("acquire routes read lock: %w", err)
 	}
 	if !ok {
-		return nil, fmt.Errorf("acquire routes read lock: timed out after %s", lockTimeout)
+		return nil, fmt.Errorf("acquire routes read lock: timed out after %s waiting for %s; another h2 process is holding it", lockTimeout, lockFilePath(rootDir))
 	}
 	return fl, nil
 }
@@ -7,6 +7,9 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
+
+	"github.com/gofrs/flock"
 )
 
 func TestRootDir_Default(t *testing.T) {
@@ -483,6 +486,34 @@ func TestRegisterRouteWithAutoPrefix_ConcurrentAutoIncrement(t *testing.T) {
 	}
 }
 
+func TestRegisterRouteWithAutoPrefix_TimesOutOnHeldLock(t *testing.T) {
+	rootDir := t.TempDir()
+	old := lockTimeout
+	lockTimeout = 100 * time.Millisecond
+	t.Cleanup(func() { lockTimeout = old })
+
+	// A live process holding the lock makes registration give up rather
+	// than wait forever.
+	held := flock.New(lockFilePath(rootDir))
+	if err := held.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	_, err := RegisterRouteWithAutoPrefix(rootDir, "", filepath.Join(t.TempDir(), "worker"))
+	if err == nil || !strings.Contains(err.Error(), "timed out") || !strings.Contains(err.Error(), "routes.jsonl.lock") {
+		t.Fatalf("expected lock timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("took %s to time out", elapsed)
+	}
+
+	// Once the holder lets go (or dies), registration goes through.
+	held.Unlock()
+	if _, err := RegisterRouteWithAutoPrefix(rootDir, "", filepath.Join(t.TempDir(), "worker")); err != nil {
+		t.Fatalf("register after unlock: %v", err)
+	}
+}
+
 // --- ResolvePrefix (public, for backward compat) ---
 
 func TestResolvePrefix_Default(t *testing.T) {
@@ -6,6 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"h2/internal/tmpl"
 )
 
 // RuntimeConfig is the fully-resolved, serialized configuration for a daemon
@@ -47,25 +49,44 @@ type RuntimeConfig struct {
 	CWD string `json:"cwd"`
 
 	// Prompt configuration.
-	Instructions string `json:"instructions,omitempty"`
-	SystemPrompt string `json:"system_prompt,omitempty"`
+	Instructions  string `json:"instructions,omitempty"`
+	SystemPrompt  string `json:"system_prompt,omitempty"`
+	InitialPrompt string `json:"initial_prompt,omitempty"`
 
 	// Permission configuration.
 	ClaudePermissionMode string            `json:"claude_permission_mode,omitempty"`
 	CodexSandboxMode     string            `json:"codex_sandbox_mode,omitempty"`
 	CodexAskForApproval  string            `json:"codex_ask_for_approval,omitempty"`
 	PermissionReview     *PermissionReview `json:"permission_review,omitempty"`
+	AllowedTools         []string          `json:"allowed_tools,omitempty"`
+	DeniedTools          []string          `json:"denied_tools,omitempty"`
 
 	// Additional directories.
 	AdditionalDirs []string `json:"additional_dirs,omitempty"`
 
+	// SkillsDir is the role's resolved skills_dir, linked into the session
+	// dir while the daemon runs.
+	SkillsDir string `json:"skills_dir,omitempty"`
+
 	// Automation: role-defined triggers and schedules.
 	Triggers  []TriggerYAMLSpec  `json:"triggers,omitempty"`
 	Schedules []ScheduleYAMLSpec `json:"schedules,omitempty"`
 
-	// Overrides (recorded for display/debugging).
+	// Overrides (recorded for display/debugging, and re-applied when the
+	// role is reloaded).
 	Overrides map[string]string `json:"overrides,omitempty"`
 
+	// Role reloading: when ReloadRoleOnChange is set, the daemon renders the
+	// role file again with RoleContext whenever it changes, and compares it
+	// with RoleFields, the RoleFieldSnapshot of the role as last applied.
+	ReloadRoleOnChange bool              `json:"reload_role_on_change,omitempty"`
+	RoleContext        *tmpl.Context     `json:"role_context,omitempty"`
+	RoleFields         map[string]string `json:"role_fields,omitempty"`
+
+	// SecretValues are the values of the role's secret variables, masked in
+	// the activity log.
+	SecretValues []string `json:"secret_values,omitempty"`
+
 	// Timestamps.
 	StartedAt string `json:"started_at"`
 
@@ -124,6 +124,36 @@ func SetupSessionDir(agentName string, role *Role) (string, error) {
 	return sessionDir, nil
 }
 
+// SessionSkillsDir returns the dir passed to Claude Code with --add-dir for
+// a role's skills_dir. Claude Code loads skills from .claude/skills in each
+// added dir, and that is a link to the skills_dir.
+func SessionSkillsDir(sessionDir string) string {
+	return filepath.Join(sessionDir, "skills")
+}
+
+// LinkSessionSkills links skillsDir into the session's skills dir,
+// replacing a link left by an earlier run of the session.
+func LinkSessionSkills(sessionDir, skillsDir string) error {
+	linkDir := filepath.Join(SessionSkillsDir(sessionDir), ".claude")
+	if err := os.MkdirAll(linkDir, 0o755); err != nil {
+		return fmt.Errorf("create session skills dir: %w", err)
+	}
+	link := filepath.Join(linkDir, "skills")
+	if err := os.Remove(link); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove old skills link: %w", err)
+	}
+	if err := os.Symlink(skillsDir, link); err != nil {
+		return fmt.Errorf("link skills dir: %w", err)
+	}
+	return nil
+}
+
+// UnlinkSessionSkills removes the session's skills dir made by
+// LinkSessionSkills. The linked skills_dir itself is left alone.
+func UnlinkSessionSkills(sessionDir string) error {
+	return os.RemoveAll(SessionSkillsDir(sessionDir))
+}
+
 // EnsureClaudeConfigDir creates the shared Claude config directory and writes
 // the h2 standard settings.json (hooks + permissions) if it doesn't exist yet.
 func EnsureClaudeConfigDir(configDir string) error {
@@ -177,37 +207,25 @@ func buildH2Hooks() map[string][]hookMatcher {
 		Timeout: 5,
 	}
 
-	// Standard hook events.
-	standardEvents := []string{
-		"PreToolUse",
-		"PostToolUse",
-		"PostToolUseFailure",
-		"PreCompact",
-		"SessionStart",
-		"SessionEnd",
-		"Stop",
-		"UserPromptSubmit",
+	// PermissionRequest needs a longer timeout for the AI reviewer.
+	permissionHook := hookEntry{
+		Type:    "command",
+		Command: "h2 handle-hook",
+		Timeout: 60,
 	}
 
 	hooks := make(map[string][]hookMatcher)
 
-	for _, event := range standardEvents {
+	for _, event := range H2HookEvents {
+		h := hook
+		if event == "PermissionRequest" {
+			h = permissionHook
+		}
 		hooks[event] = []hookMatcher{{
 			Matcher: "",
-			Hooks:   []hookEntry{hook},
+			Hooks:   []hookEntry{h},
 		}}
 	}
 
-	// PermissionRequest needs a longer timeout for the AI reviewer.
-	permissionHook := hookEntry{
-		Type:    "command",
-		Command: "h2 handle-hook",
-		Timeout: 60,
-	}
-	hooks["PermissionRequest"] = []hookMatcher{{
-		Matcher: "",
-		Hooks:   []hookEntry{permissionHook},
-	}}
-
 	return hooks
 }
@@ -132,3 +132,31 @@ func TestFindSessionDirByHarnessSessionID(t *testing.T) {
 		t.Fatalf("FindSessionDirByHarnessSessionID(\"\") = %q, want empty", got)
 	}
 }
+
+func TestLinkSessionSkills(t *testing.T) {
+	sessionDir := t.TempDir()
+	first, second := t.TempDir(), t.TempDir()
+	link := filepath.Join(SessionSkillsDir(sessionDir), ".claude", "skills")
+
+	if err := LinkSessionSkills(sessionDir, first); err != nil {
+		t.Fatalf("LinkSessionSkills: %v", err)
+	}
+	// A resumed session replaces the link left by the previous run.
+	if err := LinkSessionSkills(sessionDir, second); err != nil {
+		t.Fatalf("LinkSessionSkills again: %v", err)
+	}
+	if got, err := os.Readlink(link); err != nil || got != second {
+		t.Fatalf("link = %q, %v; want %q", got, err, second)
+	}
+
+	os.WriteFile(filepath.Join(second, "SKILL.md"), []byte("x"), 0o644)
+	if err := UnlinkSessionSkills(sessionDir); err != nil {
+		t.Fatalf("UnlinkSessionSkills: %v", err)
+	}
+	if _, err := os.Lstat(SessionSkillsDir(sessionDir)); !os.IsNotExist(err) {
+		t.Errorf("session skills dir still exists: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(second, "SKILL.md")); err != nil {
+		t.Errorf("skills dir contents were removed: %v", err)
+	}
+}
@@ -15,6 +15,8 @@ import (
 //
 // If the worktree already exists with a valid .git file, it is reused.
 // cfg.ProjectDir (resolved) must be a git repository (or worktree).
+// cfg.GitAuthor and cfg.GitEmail, when set, become the worktree's commit
+// identity; see setWorktreeIdentity.
 func CreateWorktree(cfg *config.WorktreeConfig) (string, error) {
 	repoDir, err := cfg.ResolveProjectDir()
 	if err != nil {
@@ -44,6 +46,9 @@ func CreateWorktree(cfg *config.WorktreeConfig) (string, error) {
 		if !strings.HasPrefix(content, "gitdir:") {
 			return "", fmt.Errorf("worktree path %q has corrupt .git file (missing gitdir reference)", worktreePath)
 		}
+		if err := setWorktreeIdentity(worktreePath, cfg); err != nil {
+			return "", err
+		}
 		return worktreePath, nil
 	}
 
@@ -77,13 +82,96 @@ func CreateWorktree(cfg *config.WorktreeConfig) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("git worktree add: %s: %w", strings.TrimSpace(string(output)), err)
 	}
+	if err := setWorktreeIdentity(worktreePath, cfg); err != nil {
+		return "", err
+	}
 
 	return worktreePath, nil
 }
 
+// setWorktreeIdentity sets user.name and user.email in the worktree's own
+// config from cfg.GitAuthor and cfg.GitEmail. Plain local config is shared
+// by the repo and all its worktrees, so this enables extensions.worktreeConfig
+// on the repo and writes with --worktree, leaving other checkouts' identity
+// alone. Unset fields keep whatever identity git would otherwise use.
+//
+// Enabling the extension is a change to the whole repo. Git then reads
+// core.bare and core.worktree from the shared config for every worktree,
+// so if either is set there (see git-worktree(1), CONFIGURATION FILE) the
+// extension is left off and an error says how to move them.
+func setWorktreeIdentity(worktreePath string, cfg *config.WorktreeConfig) error {
+	if cfg.GitAuthor == "" && cfg.GitEmail == "" {
+		return nil
+	}
+	if err := checkWorktreeConfigSafe(worktreePath); err != nil {
+		return err
+	}
+	settings := [][]string{{"extensions.worktreeConfig", "true"}}
+	if cfg.GitAuthor != "" {
+		settings = append(settings, []string{"--worktree", "user.name", cfg.GitAuthor})
+	}
+	if cfg.GitEmail != "" {
+		settings = append(settings, []string{"--worktree", "user.email", cfg.GitEmail})
+	}
+	for _, s := range settings {
+		cmd := exec.Command("git", append([]string{"config"}, s...)...)
+		cmd.Dir = worktreePath
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("git config %s: %s: %w", strings.Join(s, " "), strings.TrimSpace(string(output)), err)
+		}
+	}
+	return nil
+}
+
+// checkWorktreeConfigSafe returns an error if turning on
+// extensions.worktreeConfig in the repo of worktreePath would apply its
+// shared core.bare = true or core.worktree to every worktree. It does
+// nothing once the extension is on.
+func checkWorktreeConfigSafe(worktreePath string) error {
+	if sharedConfigValue(worktreePath, "--type=bool", "extensions.worktreeConfig") == "true" {
+		return nil
+	}
+	var found []string
+	if sharedConfigValue(worktreePath, "--type=bool", "core.bare") == "true" {
+		found = append(found, "core.bare = true")
+	}
+	if v := sharedConfigValue(worktreePath, "core.worktree"); v != "" {
+		found = append(found, "core.worktree = "+v)
+	}
+	if len(found) == 0 {
+		return nil
+	}
+	return fmt.Errorf("worktree_git_author/worktree_git_email need extensions.worktreeConfig, but the repo config sets %s, "+
+		"which would then apply to every worktree; move it to the main worktree's config.worktree "+
+		"(git config --worktree) and run git config extensions.worktreeConfig true, or unset the identity fields",
+		strings.Join(found, " and "))
+}
+
+// sharedConfigValue returns the value of key in the repo's shared config
+// (not the worktree's own), or "" if it isn't set.
+func sharedConfigValue(dir string, args ...string) string {
+	cmd := exec.Command("git", append([]string{"config", "--local", "--get"}, args...)...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
 // isGitRepo returns true if the directory is a git repository or worktree.
 func isGitRepo(dir string) bool {
 	cmd := exec.Command("git", "rev-parse", "--git-dir")
 	cmd.Dir = dir
 	return cmd.Run() == nil
 }
+
+// Clone makes a shallow clone of the repository at url in dir.
+func Clone(url, dir string) error {
+	cmd := exec.Command("git", "clone", "--depth", "1", "--quiet", url, dir)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git clone %s: %s: %w", url, strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
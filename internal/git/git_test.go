@@ -250,6 +250,74 @@ func TestCreateWorktree_CustomBranchName(t *testing.T) {
 	}
 }
 
+func gitConfigValue(t *testing.T, dir, key string) string {
+	t.Helper()
+	cmd := exec.Command("git", "config", "--get", key)
+	cmd.Dir = dir
+	out, _ := cmd.Output()
+	return strings.TrimSpace(string(out))
+}
+
+func TestCreateWorktree_GitIdentity(t *testing.T) {
+	repoDir := setupWorktreeTest(t)
+
+	cfg := &config.WorktreeConfig{
+		ProjectDir: repoDir,
+		Name:       "coder-1",
+		BranchFrom: "main",
+		GitAuthor:  "coder-1",
+		GitEmail:   "coder-1@agents.local",
+	}
+	path, err := CreateWorktree(cfg)
+	if err != nil {
+		t.Fatalf("CreateWorktree: %v", err)
+	}
+	if got := gitConfigValue(t, path, "user.name"); got != "coder-1" {
+		t.Errorf("worktree user.name = %q, want coder-1", got)
+	}
+	if got := gitConfigValue(t, path, "user.email"); got != "coder-1@agents.local" {
+		t.Errorf("worktree user.email = %q, want coder-1@agents.local", got)
+	}
+	// The repo and other worktrees keep their own identity.
+	if got := gitConfigValue(t, repoDir, "user.email"); got != "test@test.com" {
+		t.Errorf("repo user.email = %q, want test@test.com", got)
+	}
+	other, err := CreateWorktree(&config.WorktreeConfig{ProjectDir: repoDir, Name: "other", BranchFrom: "main"})
+	if err != nil {
+		t.Fatalf("CreateWorktree (other): %v", err)
+	}
+	if got := gitConfigValue(t, other, "user.email"); got != "test@test.com" {
+		t.Errorf("other worktree user.email = %q, want test@test.com", got)
+	}
+
+	// A reused worktree picks up a changed identity.
+	cfg.GitEmail = "coder-1@example.com"
+	if _, err := CreateWorktree(cfg); err != nil {
+		t.Fatalf("CreateWorktree (reuse): %v", err)
+	}
+	if got := gitConfigValue(t, path, "user.email"); got != "coder-1@example.com" {
+		t.Errorf("reused worktree user.email = %q, want coder-1@example.com", got)
+	}
+}
+
+func TestCreateWorktree_GitIdentityRefusesSharedCoreWorktree(t *testing.T) {
+	repoDir := setupWorktreeTest(t)
+	run(t, repoDir, "git", "config", "core.worktree", repoDir)
+
+	_, err := CreateWorktree(&config.WorktreeConfig{
+		ProjectDir: repoDir,
+		Name:       "coder-1",
+		BranchFrom: "main",
+		GitEmail:   "coder-1@agents.local",
+	})
+	if err == nil || !strings.Contains(err.Error(), "core.worktree = "+repoDir) {
+		t.Fatalf("expected core.worktree error, got %v", err)
+	}
+	if got := gitConfigValue(t, repoDir, "extensions.worktreeConfig"); got != "" {
+		t.Errorf("extensions.worktreeConfig = %q, want it left unset", got)
+	}
+}
+
 func TestWorktreeConfig_GetBranchFrom(t *testing.T) {
 	tests := []struct {
 		name string
@@ -0,0 +1,166 @@
+package session
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"h2/internal/activitylog"
+	"h2/internal/config"
+)
+
+// ActivityLogPath returns the path of the shared activity log all agents
+// append to.
+func ActivityLogPath() string {
+	return filepath.Join(config.ConfigDir(), "logs", "session-activity.jsonl")
+}
+
+// activityLogRotation returns the rotation settings for the activity log
+// from its config.yaml section, filling in defaults.
+func activityLogRotation(c *config.ActivityLogConfig) activitylog.Rotation {
+	r := activitylog.Rotation{
+		MaxBytes: config.DefaultActivityLogMaxSizeMB << 20,
+		Keep:     config.DefaultActivityLogKeep,
+	}
+	if c == nil {
+		return r
+	}
+	if c.MaxSizeMB > 0 {
+		r.MaxBytes = int64(c.MaxSizeMB) << 20
+	}
+	if c.Keep > 0 {
+		r.Keep = c.Keep
+	}
+	// Validated when config.yaml is loaded.
+	r.MaxAge, _ = time.ParseDuration(c.MaxAge)
+	r.Compress = c.Compress
+	return r
+}
+
+// ActivityEvent is one parsed line of the activity log. Fields that don't
+// apply to an event type are empty; Raw holds the original JSON line so
+// event-specific fields not mirrored here (e.g. session summary metrics)
+// are still available.
+type ActivityEvent struct {
+	Timestamp time.Time `json:"-"`
+	TS        string    `json:"ts"`
+	Actor     string    `json:"actor"`
+	SessionID string    `json:"session_id"`
+	Event     string    `json:"event"`
+	HookEvent string    `json:"hook_event,omitempty"`
+	ToolName  string    `json:"tool_name,omitempty"`
+	Decision  string    `json:"decision,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to,omitempty"`
+
+	Raw json.RawMessage `json:"-"`
+}
+
+// Type returns the most specific type name for the event: the hook event
+// name for hook events (e.g. "UserPromptSubmit"), the event name otherwise.
+func (e ActivityEvent) Type() string {
+	if e.Event == "hook" && e.HookEvent != "" {
+		return e.HookEvent
+	}
+	return e.Event
+}
+
+// ActivityQuery selects activity log events. Zero-valued fields match
+// everything.
+type ActivityQuery struct {
+	Actor    string    // agent name
+	Type     string    // event name (e.g. "state_change") or hook event name (e.g. "PreToolUse")
+	Since    time.Time // only events at or after this time
+	Contains string    // substring of the raw JSON line
+}
+
+func (q ActivityQuery) matches(e ActivityEvent, line string) bool {
+	if q.Actor != "" && e.Actor != q.Actor {
+		return false
+	}
+	if q.Type != "" && e.Event != q.Type && e.HookEvent != q.Type {
+		return false
+	}
+	if !q.Since.IsZero() && (e.Timestamp.IsZero() || e.Timestamp.Before(q.Since)) {
+		return false
+	}
+	if q.Contains != "" && !strings.Contains(line, q.Contains) {
+		return false
+	}
+	return true
+}
+
+// QueryActivityLog streams the activity log at path, calling fn for each
+// event matching q in file order, starting with its oldest rotated segment.
+// With q.Since set, segments last written before it are skipped unread.
+// Stops early if fn returns an error, which is returned. A missing log
+// yields no events; malformed lines (e.g. a write cut short by a crash) are
+// skipped.
+func QueryActivityLog(path string, q ActivityQuery, fn func(ActivityEvent) error) error {
+	if _, err := os.Stat(filepath.Dir(path)); os.IsNotExist(err) {
+		return nil
+	}
+	// Hold off rotation so no event moves between files mid-query.
+	unlock, err := activitylog.LockForRead(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	segments, err := activitylog.Segments(path)
+	if err != nil {
+		return fmt.Errorf("list activity log segments: %w", err)
+	}
+	for _, seg := range segments {
+		if !q.Since.IsZero() && seg.ModTime.Before(q.Since) {
+			continue
+		}
+		if err := queryActivityFile(seg.Path, q, fn); err != nil {
+			return err
+		}
+	}
+	return queryActivityFile(path, q, fn)
+}
+
+// queryActivityFile streams one activity log file or rotated segment.
+func queryActivityFile(path string, q ActivityQuery, fn func(ActivityEvent) error) error {
+	f, err := activitylog.OpenSegment(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("open activity log: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	// Session summaries can be long; allow lines well past the default 64KB.
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var e ActivityEvent
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			continue
+		}
+		e.Timestamp, _ = time.Parse(time.RFC3339Nano, e.TS)
+		if !q.matches(e, line) {
+			continue
+		}
+		e.Raw = json.RawMessage(line)
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read activity log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package session
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"h2/internal/config"
+)
+
+const testActivityLog = `{"ts":"2025-01-01T00:00:00Z","actor":"a1","session_id":"s","event":"hook","hook_event":"UserPromptSubmit"}
+{"ts":"2025-01-01T00:00:01Z","actor":"a1","session_id":"s","event":"hook","hook_event":"PreToolUse","tool_name":"Bash"}
+not json
+{"ts":"2025-01-01T00:00:02Z","actor":"a2","session_id":"t","event":"state_change","from":"idle","to":"active"}
+
+{"ts":"2025-01-01T00:00:03Z","actor":"a1","session_id":"s","event":"hook","hook_event":"PreCompact"}
+{"ts":"2025-01-01T00:00:04Z","actor":"a1","session_id":"s","event":"state_change","from":"active","to":"idle"}
+`
+
+func writeTestActivityLog(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "session-activity.jsonl")
+	if err := os.WriteFile(path, []byte(testActivityLog), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func queryAll(t *testing.T, path string, q ActivityQuery) []ActivityEvent {
+	t.Helper()
+	var events []ActivityEvent
+	if err := QueryActivityLog(path, q, func(e ActivityEvent) error {
+		events = append(events, e)
+		return nil
+	}); err != nil {
+		t.Fatalf("QueryActivityLog: %v", err)
+	}
+	return events
+}
+
+func TestQueryActivityLog_ParsesAndSkipsMalformed(t *testing.T) {
+	events := queryAll(t, writeTestActivityLog(t), ActivityQuery{})
+	if len(events) != 5 {
+		t.Fatalf("got %d events, want 5", len(events))
+	}
+	if events[1].ToolName != "Bash" || events[1].Type() != "PreToolUse" {
+		t.Errorf("events[1] = %+v", events[1])
+	}
+	if events[2].Type() != "state_change" || events[2].From != "idle" || events[2].To != "active" {
+		t.Errorf("events[2] = %+v", events[2])
+	}
+	if !events[0].Timestamp.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("events[0].Timestamp = %v", events[0].Timestamp)
+	}
+	if len(events[0].Raw) == 0 {
+		t.Error("expected Raw to hold the original line")
+	}
+}
+
+func TestQueryActivityLog_Filters(t *testing.T) {
+	path := writeTestActivityLog(t)
+
+	tests := []struct {
+		name string
+		q    ActivityQuery
+		want int
+	}{
+		{"actor", ActivityQuery{Actor: "a1"}, 4},
+		{"hook event type", ActivityQuery{Actor: "a1", Type: "UserPromptSubmit"}, 1},
+		{"event type", ActivityQuery{Type: "state_change"}, 2},
+		{"since", ActivityQuery{Since: time.Date(2025, 1, 1, 0, 0, 2, 0, time.UTC)}, 3},
+		{"contains", ActivityQuery{Contains: "Compact"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := queryAll(t, path, tt.q); len(got) != tt.want {
+				t.Fatalf("got %d events, want %d: %+v", len(got), tt.want, got)
+			}
+		})
+	}
+}
+
+func TestQueryActivityLog_MissingFileAndEarlyStop(t *testing.T) {
+	if got := queryAll(t, filepath.Join(t.TempDir(), "missing.jsonl"), ActivityQuery{}); len(got) != 0 {
+		t.Fatalf("missing log should yield no events, got %d", len(got))
+	}
+
+	stop := errors.New("stop")
+	n := 0
+	err := QueryActivityLog(writeTestActivityLog(t), ActivityQuery{}, func(ActivityEvent) error {
+		n++
+		return stop
+	})
+	if !errors.Is(err, stop) || n != 1 {
+		t.Fatalf("expected early stop after 1 event, got n=%d err=%v", n, err)
+	}
+}
+
+func TestQueryActivityLog_ReadsRotatedSegmentsInOrder(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "session-activity.jsonl")
+	lines := strings.Split(strings.TrimSpace(testActivityLog), "\n")
+	files := map[string]string{
+		"session-activity.2.jsonl": lines[0] + "\n" + lines[1] + "\n",
+		"session-activity.1.jsonl": lines[3] + "\n",
+		"session-activity.jsonl":   lines[5] + "\n" + lines[6] + "\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The oldest segment was last written long ago; a --since query after
+	// that must not even open it.
+	old := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(filepath.Join(dir, "session-activity.2.jsonl"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, e := range queryAll(t, path, ActivityQuery{}) {
+		got = append(got, e.TS[len(e.TS)-3:])
+	}
+	if strings.Join(got, ",") != "00Z,01Z,02Z,03Z,04Z" {
+		t.Errorf("events in order %v", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "session-activity.2.jsonl"), []byte(lines[0]+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(filepath.Join(dir, "session-activity.2.jsonl"), old, old)
+	since := queryAll(t, path, ActivityQuery{Since: old.Add(time.Hour)})
+	if len(since) != 3 {
+		t.Errorf("got %d events since segment 2 was written, want 3: %+v", len(since), since)
+	}
+}
+
+func TestActivityLogRotation_Defaults(t *testing.T) {
+	r := activityLogRotation(nil)
+	if r.MaxBytes != config.DefaultActivityLogMaxSizeMB<<20 || r.Keep != config.DefaultActivityLogKeep || r.MaxAge != 0 || r.Compress {
+		t.Errorf("default rotation = %+v", r)
+	}
+	r = activityLogRotation(&config.ActivityLogConfig{MaxSizeMB: 5, MaxAge: "24h", Keep: 3, Compress: true})
+	if r.MaxBytes != 5<<20 || r.Keep != 3 || r.MaxAge != 24*time.Hour || !r.Compress {
+		t.Errorf("configured rotation = %+v", r)
+	}
+}
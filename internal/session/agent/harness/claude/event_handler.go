@@ -6,12 +6,14 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"h2/internal/activitylog"
+	"h2/internal/config"
 	"h2/internal/session/agent/monitor"
 	"h2/internal/session/agent/shared/debugenv"
 )
@@ -286,22 +288,15 @@ func (h *EventHandler) shouldIgnoreHookSession(sessionID string) bool {
 	return sessionID != h.expectedSessionID
 }
 
+// isKnownHookEvent reports whether eventName is one of the hook events h2
+// registers, or one of the events h2 itself reports (permission_decision
+// and Interrupt).
 func isKnownHookEvent(eventName string) bool {
 	switch eventName {
-	case "UserPromptSubmit",
-		"PreToolUse",
-		"PostToolUse",
-		"PostToolUseFailure",
-		"PermissionRequest",
-		"permission_decision",
-		"PreCompact",
-		"SessionStart",
-		"Stop",
-		"Interrupt",
-		"SessionEnd":
+	case "permission_decision", "Interrupt":
 		return true
 	default:
-		return false
+		return slices.Contains(config.H2HookEvents, eventName)
 	}
 }
 
@@ -98,6 +98,12 @@ func (h *ClaudeCodeHarness) BuildCommandArgs(prependArgs, extraArgs []string) []
 	if rc.ClaudePermissionMode != "" {
 		roleArgs = append(roleArgs, "--permission-mode", rc.ClaudePermissionMode)
 	}
+	if len(rc.AllowedTools) > 0 {
+		roleArgs = append(roleArgs, "--allowedTools", strings.Join(rc.AllowedTools, ","))
+	}
+	if len(rc.DeniedTools) > 0 {
+		roleArgs = append(roleArgs, "--disallowedTools", strings.Join(rc.DeniedTools, ","))
+	}
 	for _, dir := range rc.AdditionalDirs {
 		roleArgs = append(roleArgs, "--add-dir", dir)
 	}
@@ -100,6 +100,28 @@ func TestBuildCommandArgs_ClaudePermissionMode(t *testing.T) {
 	}
 }
 
+func TestBuildCommandArgs_ToolLists(t *testing.T) {
+	h := New(&config.RuntimeConfig{
+		HarnessType:  "claude_code",
+		Command:      "claude",
+		AgentName:    "test",
+		CWD:          "/tmp",
+		StartedAt:    "2024-01-01T00:00:00Z",
+		AllowedTools: []string{"Read", "Bash(git log:*)"},
+		DeniedTools:  []string{"WebFetch"},
+	}, nil)
+	args := h.BuildCommandArgs(nil, nil)
+	expected := []string{"--allowedTools", "Read,Bash(git log:*)", "--disallowedTools", "WebFetch"}
+	if len(args) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, args)
+	}
+	for i, want := range expected {
+		if args[i] != want {
+			t.Errorf("arg[%d] = %q, want %q", i, args[i], want)
+		}
+	}
+}
+
 func TestBuildCommandArgs_Empty(t *testing.T) {
 	h := New(&config.RuntimeConfig{HarnessType: "claude_code", Command: "claude", AgentName: "test", CWD: "/tmp", StartedAt: "2024-01-01T00:00:00Z"}, nil)
 	args := h.BuildCommandArgs(nil, nil)
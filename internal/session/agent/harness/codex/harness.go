@@ -92,6 +92,13 @@ func (h *CodexHarness) BuildCommandArgs(prependArgs, extraArgs []string) []strin
 	if rc.CodexSandboxMode != "" {
 		roleArgs = append(roleArgs, "--sandbox", rc.CodexSandboxMode)
 	}
+	// Codex has no allow/deny flags; tools are toggled via the [tools] config table.
+	for _, tool := range rc.AllowedTools {
+		roleArgs = append(roleArgs, "-c", "tools."+tool+"=true")
+	}
+	for _, tool := range rc.DeniedTools {
+		roleArgs = append(roleArgs, "-c", "tools."+tool+"=false")
+	}
 	for _, dir := range rc.AdditionalDirs {
 		roleArgs = append(roleArgs, "--add-dir", dir)
 	}
@@ -270,6 +270,20 @@ func TestBuildCommandArgs_AdditionalDirs(t *testing.T) {
 	}
 }
 
+func TestBuildCommandArgs_ToolLists(t *testing.T) {
+	h := New(&config.RuntimeConfig{HarnessType: "codex", Command: "codex", AgentName: "test", CWD: "/tmp", StartedAt: "2024-01-01T00:00:00Z", AllowedTools: []string{"view_image"}, DeniedTools: []string{"web_search"}}, nil)
+	args := h.BuildCommandArgs(nil, nil)
+	expected := []string{"-c", "tools.view_image=true", "-c", "tools.web_search=false"}
+	if len(args) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, args)
+	}
+	for i, want := range expected {
+		if args[i] != want {
+			t.Errorf("arg[%d] = %q, want %q", i, args[i], want)
+		}
+	}
+}
+
 func TestBuildCommandEnvVars_ReturnsNil(t *testing.T) {
 	h := New(&config.RuntimeConfig{HarnessType: "codex", Command: "codex", AgentName: "test", CWD: "/tmp", StartedAt: "2024-01-01T00:00:00Z"}, nil)
 	envVars := h.BuildCommandEnvVars("/home/user/.h2")
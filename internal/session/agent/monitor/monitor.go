@@ -21,6 +21,12 @@ type AgentMonitor struct {
 	stateChangedAt time.Time
 	stateCh        chan struct{} // closed on state change
 
+	// idleDebounce holds off a plain active -> idle transition for this
+	// long; pendingIdle is the timer that applies it, dropped by any state
+	// change that arrives first.
+	idleDebounce time.Duration
+	pendingIdle  *time.Timer
+
 	sessionID            string
 	onSessionStarted     func(SessionStartedData)
 	onUsageLimit         func(UsageLimitData)
@@ -212,7 +218,11 @@ func (m *AgentMonitor) processEvent(ev AgentEvent) {
 
 	case EventStateChange:
 		if data, ok := ev.Data.(StateChangeData); ok {
-			m.setStateLocked(data.State, data.SubState)
+			if m.shouldDebounceIdleLocked(data) {
+				m.deferIdleLocked()
+			} else {
+				m.setStateLocked(data.State, data.SubState)
+			}
 			if data.SubState == SubStateBlockedOnPermission {
 				m.blockedOnPermission = true
 			} else if data.SubState != SubStateBlockedOnPermission {
@@ -288,9 +298,41 @@ func (m *AgentMonitor) processEvent(ev AgentEvent) {
 	}
 }
 
-// setStateLocked updates state under the lock. Notifies waiters when
-// the top-level State changes.
+// shouldDebounceIdleLocked reports whether a state change is a plain
+// active -> idle transition that should wait out the idle debounce. Idle
+// with an error sub-state (usage limit, auth, server error) is never held.
+func (m *AgentMonitor) shouldDebounceIdleLocked(data StateChangeData) bool {
+	return m.idleDebounce > 0 && m.state == StateActive &&
+		data.State == StateIdle && data.SubState == SubStateNone
+}
+
+// deferIdleLocked schedules the transition to idle after the idle debounce,
+// keeping an already pending one so repeated idle events don't extend it.
+// The agent stays active in the meantime; a state change before the timer
+// fires (e.g. the next tool call) cancels it.
+func (m *AgentMonitor) deferIdleLocked() {
+	if m.pendingIdle != nil {
+		return
+	}
+	var t *time.Timer
+	t = time.AfterFunc(m.idleDebounce, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.pendingIdle != t {
+			return // cancelled by a later state change
+		}
+		m.setStateLocked(StateIdle, SubStateNone)
+	})
+	m.pendingIdle = t
+}
+
+// setStateLocked updates state under the lock, cancelling any pending
+// debounced idle. Notifies waiters when the top-level State changes.
 func (m *AgentMonitor) setStateLocked(newState State, newSubState SubState) {
+	if m.pendingIdle != nil {
+		m.pendingIdle.Stop()
+		m.pendingIdle = nil
+	}
 	if m.state != newState {
 		m.stateChangedAt = time.Now()
 		close(m.stateCh)
@@ -351,6 +393,14 @@ func (m *AgentMonitor) SessionID() string {
 	return m.sessionID
 }
 
+// SetIdleDebounce sets how long the agent must stay idle before an active ->
+// idle transition is reported, so the brief idle gaps between fast tool calls
+// don't read as the agent being done. 0 (the default) reports idle at once.
+// Must be called before Run.
+func (m *AgentMonitor) SetIdleDebounce(d time.Duration) {
+	m.idleDebounce = d
+}
+
 // SetOnSessionStarted sets a callback invoked when EventSessionStarted is
 // processed. The daemon uses this to persist the harness session ID to the
 // RuntimeConfig file. Must be called before Run.
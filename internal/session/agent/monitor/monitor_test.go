@@ -879,3 +879,89 @@ func TestProcessEvent_ServerError_NotClearedByZeroTokenTurn(t *testing.T) {
 		t.Fatalf("ServerErrorMessage should not be cleared by zero-token turn, got %q", m.ServerErrorMessage())
 	}
 }
+
+func stateEvent(state State, sub SubState) AgentEvent {
+	return AgentEvent{
+		Type:      EventStateChange,
+		Timestamp: time.Now(),
+		Data:      StateChangeData{State: state, SubState: sub},
+	}
+}
+
+func TestIdleDebounce_ShortToolGapStaysActive(t *testing.T) {
+	m := New()
+	m.SetIdleDebounce(500 * time.Millisecond)
+	m.processEvent(stateEvent(StateActive, SubStateToolUse))
+	changed := m.StateChanged()
+
+	// Idle between two tool calls, for less than the debounce.
+	m.processEvent(stateEvent(StateIdle, SubStateNone))
+	if state, _ := m.State(); state != StateActive {
+		t.Fatalf("state = %v right after idle event, want Active", state)
+	}
+	time.Sleep(20 * time.Millisecond)
+	m.processEvent(stateEvent(StateActive, SubStateToolUse))
+
+	// Well past when the idle would have applied.
+	time.Sleep(700 * time.Millisecond)
+	if state, sub := m.State(); state != StateActive || sub != SubStateToolUse {
+		t.Errorf("state = %v/%v, want Active/ToolUse", state, sub)
+	}
+	select {
+	case <-changed:
+		t.Error("waiters were notified of a state change during the tool gap")
+	default:
+	}
+}
+
+func TestIdleDebounce_IdleAfterDebounce(t *testing.T) {
+	m := New()
+	m.SetIdleDebounce(50 * time.Millisecond)
+	m.processEvent(stateEvent(StateActive, SubStateThinking))
+
+	start := time.Now()
+	m.processEvent(stateEvent(StateIdle, SubStateNone))
+	// A repeated idle event doesn't push the transition back.
+	m.processEvent(stateEvent(StateIdle, SubStateNone))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if !m.WaitForState(ctx, StateIdle) {
+		t.Fatal("agent never went idle")
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("went idle after %v, want at least the 50ms debounce", elapsed)
+	}
+}
+
+func TestIdleDebounce_ErrorAndExitApplyImmediately(t *testing.T) {
+	m := New()
+	m.SetIdleDebounce(time.Hour)
+	m.processEvent(stateEvent(StateActive, SubStateThinking))
+	m.processEvent(stateEvent(StateIdle, SubStateUsageLimit))
+	if state, sub := m.State(); state != StateIdle || sub != SubStateUsageLimit {
+		t.Errorf("state = %v/%v, want Idle/UsageLimit at once", state, sub)
+	}
+
+	m.processEvent(stateEvent(StateActive, SubStateThinking))
+	m.processEvent(stateEvent(StateIdle, SubStateNone))
+	m.SetExited()
+	if state, _ := m.State(); state != StateExited {
+		t.Errorf("state = %v, want Exited", state)
+	}
+	m.mu.RLock()
+	pending := m.pendingIdle
+	m.mu.RUnlock()
+	if pending != nil {
+		t.Error("exit should cancel the pending idle")
+	}
+}
+
+func TestIdleDebounce_Disabled(t *testing.T) {
+	m := New()
+	m.processEvent(stateEvent(StateActive, SubStateThinking))
+	m.processEvent(stateEvent(StateIdle, SubStateNone))
+	if state, _ := m.State(); state != StateIdle {
+		t.Errorf("state = %v, want Idle with no debounce", state)
+	}
+}
@@ -79,6 +79,10 @@ func (d *Daemon) handleAttach(conn net.Conn, req *message.Request) {
 			}
 		}
 
+		// Pick up where the last client to detach left off in scroll mode.
+		cl.RestoreScrollState(s.detachedScroll)
+		s.detachedScroll = nil
+
 		// Set detach callback to close the client connection.
 		cl.OnDetach = func() { conn.Close() }
 
@@ -103,6 +107,7 @@ func (d *Daemon) handleAttach(conn net.Conn, req *message.Request) {
 		defer vt.Mu.Unlock()
 		cl.OnDetach = nil
 		cl.Output.Write([]byte("\033[?1000l\033[?1003l\033[?1006l"))
+		s.detachedScroll = cl.SaveScrollState()
 
 		// Release passthrough ownership if this client held it.
 		if s.PassthroughOwner == cl {
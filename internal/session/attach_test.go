@@ -313,3 +313,56 @@ func TestAttach_NormalResize(t *testing.T) {
 		t.Fatal("handleConn did not return after disconnect")
 	}
 }
+
+// attachClient attaches a new connection to d and returns it along with a
+// channel that closes when the daemon side finishes.
+func attachClient(t *testing.T, d *Daemon) (net.Conn, chan struct{}) {
+	t.Helper()
+	server, clientC := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.handleConn(server)
+	}()
+	if err := message.SendRequest(clientC, &message.Request{Type: "attach", Cols: 80, Rows: 24}); err != nil {
+		t.Fatalf("send attach: %v", err)
+	}
+	if resp, err := message.ReadResponse(clientC); err != nil || !resp.OK {
+		t.Fatalf("attach response: %+v, %v", resp, err)
+	}
+	drainClient(clientC)
+	time.Sleep(50 * time.Millisecond)
+	return clientC, done
+}
+
+func TestAttach_ReattachRestoresScrollPosition(t *testing.T) {
+	d := newTestDaemon()
+	for i := 0; i < 60; i++ {
+		d.Session.VT.Scrollback.Write([]byte("line\n"))
+	}
+
+	conn, done := attachClient(t, d)
+	d.Session.VT.Mu.Lock()
+	d.Session.ForEachClient(func(cl *client.Client) {
+		cl.EnterScrollMode()
+		cl.ScrollUp(12)
+	})
+	d.Session.VT.Mu.Unlock()
+	conn.Close()
+	<-done
+
+	conn, done = attachClient(t, d)
+	defer func() { conn.Close(); <-done }()
+	d.Session.VT.Mu.Lock()
+	defer d.Session.VT.Mu.Unlock()
+	var found bool
+	d.Session.ForEachClient(func(cl *client.Client) {
+		found = true
+		if cl.Mode != client.ModeScroll || cl.ScrollOffset != 12 {
+			t.Errorf("reattached client mode %d offset %d, want scroll mode at 12", cl.Mode, cl.ScrollOffset)
+		}
+	})
+	if !found {
+		t.Fatal("no client attached")
+	}
+}
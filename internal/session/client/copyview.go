@@ -0,0 +1,74 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CopyViewport copies the plain text of the scroll view rows on screen to
+// the system clipboard via OSC 52. With terminal.osc52_copy off, the text
+// is written to a file in ExportDir instead and its path shown. Must be
+// called with VT.Mu held.
+func (c *Client) CopyViewport() {
+	text := c.viewportText()
+	if text == "" {
+		c.ShowNotice("nothing to copy")
+		return
+	}
+	if c.OSC52Copy {
+		c.writeOSC52(text)
+		c.ShowNotice(fmt.Sprintf("copied %d lines", strings.Count(text, "\n")+1))
+		return
+	}
+	path, err := c.writeViewportText(text, time.Now())
+	if err != nil {
+		c.ShowNotice("copy failed: " + err.Error())
+		return
+	}
+	c.ShowNotice("copied to " + path)
+}
+
+// viewportText returns the scroll view rows currently on screen, laid out
+// as the renderer draws them: wrapped or cut at the terminal width, with
+// markers as their labelled rule. Trailing blanks on each row and blank
+// rows at the bottom are dropped.
+func (c *Client) viewportText() string {
+	if c.VT == nil {
+		return ""
+	}
+	layout := c.scrollLayout()
+	cols := c.VT.Cols
+	start := max(layout.rows-c.VT.ChildRows-c.ScrollOffset, 0)
+
+	var lines []string
+	for i := 0; i < c.VT.ChildRows && start+i < layout.rows; i++ {
+		line, seg := layout.at(start + i)
+		content := entrySegment(c.scrollLineEntry(line), seg*cols, (seg+1)*cols).Content
+		lines = append(lines, strings.TrimRight(strings.ReplaceAll(string(content), "\x00", " "), " "))
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// writeViewportText writes copied viewport text to a file and returns its
+// path, named like scrollback exports.
+func (c *Client) writeViewportText(text string, now time.Time) (string, error) {
+	dir := c.ExportDir
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	name := "viewport-" + now.Format("20060102-150405") + ".txt"
+	if c.AgentName != "" && c.ExportDir == "" {
+		name = c.AgentName + "-" + name
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(text+"\n"), 0o600); err != nil {
+		return "", fmt.Errorf("write %s: %w", path, err)
+	}
+	return path, nil
+}
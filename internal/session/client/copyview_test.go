@@ -0,0 +1,88 @@
+package client
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"h2/internal/session/virtualterminal"
+)
+
+func TestCopyViewport_HistoryRowsViaOSC52(t *testing.T) {
+	c := newTestClient(3, 20)
+	for i := 1; i <= 5; i++ {
+		c.VT.ScrollHistory = append(c.VT.ScrollHistory, historyEntry(fmt.Sprintf("hist %d", i)))
+	}
+	c.VT.ScrollRegionUsed = true
+	c.VT.Vt.Write([]byte("live 1\r\nlive 2\r\nlive 3"))
+	var out bytes.Buffer
+	c.Output = &out
+	c.OSC52Copy = true
+
+	c.EnterScrollMode()
+	c.ScrollOffset = 2
+	c.HandleScrollBytes([]byte("y"), 0, 1)
+	if c.noticeTimer != nil {
+		c.noticeTimer.Stop()
+	}
+
+	// Offset 2 from the bottom of [hist 1..5, live 1..3] shows rows 3-5.
+	want := "hist 4\nhist 5\nlive 1"
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(want)) + "\x1b\\"
+	if !strings.Contains(out.String(), seq) {
+		t.Fatalf("expected OSC 52 copy of %q in output, got %q", want, out.String())
+	}
+	if c.notice != "copied 3 lines" {
+		t.Errorf("notice = %q, want %q", c.notice, "copied 3 lines")
+	}
+}
+
+func TestCopyViewport_WrappedRowsMatchRender(t *testing.T) {
+	c := newTestClient(3, 10)
+	c.VT.ScrollHistory = []virtualterminal.ScrollHistoryEntry{historyEntry("abcdefghijklmno"), historyEntry("short")}
+	c.VT.ScrollRegionUsed = true
+	c.EnterScrollMode()
+	c.WrapLines = true
+	c.ScrollOffset = 3 // past the live screen, onto the history
+
+	if got, want := c.viewportText(), "abcdefghij\nklmno\nshort"; got != want {
+		t.Errorf("viewportText() = %q, want %q", got, want)
+	}
+}
+
+func TestCopyViewport_WithoutOSC52WritesFile(t *testing.T) {
+	c := newTestClient(3, 20)
+	c.ExportDir = t.TempDir()
+	c.VT.Scrollback.Write([]byte("one\r\ntwo\r\nthree\r\nfour\r\n"))
+	var out bytes.Buffer
+	c.Output = &out
+
+	c.EnterScrollMode()
+	c.ScrollOffset = 1
+	c.HandleScrollBytes([]byte("y"), 0, 1)
+	if c.noticeTimer != nil {
+		c.noticeTimer.Stop()
+	}
+
+	if strings.Contains(out.String(), "\x1b]52;") {
+		t.Error("OSC 52 should not be written when osc52_copy is off")
+	}
+	files, _ := filepath.Glob(filepath.Join(c.ExportDir, "viewport-*.txt"))
+	if len(files) != 1 {
+		t.Fatalf("expected one viewport file, got %v", files)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "two\nthree\nfour\n" {
+		t.Errorf("file = %q, want the rows on screen", data)
+	}
+	if c.notice != "copied to "+files[0] {
+		t.Errorf("notice = %q, want the file path", c.notice)
+	}
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"time"
+
+	"h2/internal/session/virtualterminal"
+)
+
+// noticeDuration is how long a notice stays on screen.
+const noticeDuration = 5 * time.Second
+
+// ExportScrollback writes the agent's scrollback to ExportDir twice, as a
+// colorized HTML page and as plain text beside it, and shows where they
+// went. Must be called with VT.Mu held.
+func (c *Client) ExportScrollback() {
+	path, err := c.writeScrollbackExport(time.Now())
+	if err != nil {
+		c.ShowNotice("export failed: " + err.Error())
+		return
+	}
+	c.ShowNotice("exported to " + path + " (+ .txt)")
+}
+
+// writeScrollbackExport writes the HTML and plain-text exports and returns
+// the HTML one's path; the text one has the same name ending in .txt.
+func (c *Client) writeScrollbackExport(now time.Time) (string, error) {
+	dir := c.ExportDir
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	title := "h2 scrollback"
+	if c.AgentName != "" {
+		title = c.AgentName + " scrollback"
+	}
+	name := "scrollback-" + now.Format("20060102-150405")
+	if c.AgentName != "" && c.ExportDir == "" {
+		name = c.AgentName + "-" + name
+	}
+	base := filepath.Join(dir, name)
+	page := c.VT.HTMLScrollback(title, virtualterminal.DefaultExportMaxBytes)
+	if err := os.WriteFile(base+".html", []byte(page), 0o600); err != nil {
+		return "", fmt.Errorf("write %s: %w", base+".html", err)
+	}
+	if err := os.WriteFile(base+".txt", []byte(c.VT.PlainTextScrollback()), 0o600); err != nil {
+		return "", fmt.Errorf("write %s: %w", base+".txt", err)
+	}
+	return base + ".html", nil
+}
+
+// ShowNotice displays text at the top right of the screen for a few
+// seconds. Must be called with VT.Mu held.
+func (c *Client) ShowNotice(text string) {
+	c.notice = text
+	if c.noticeTimer != nil {
+		c.noticeTimer.Stop()
+	}
+	c.RenderScreen()
+	c.noticeTimer = time.AfterFunc(noticeDuration, func() {
+		defer func() {
+			if r := recover(); r != nil {
+				fmt.Fprintf(os.Stderr, "panic recovered in noticeTimer: %v\n%s\n", r, debug.Stack())
+			}
+		}()
+		c.VT.Mu.Lock()
+		defer c.VT.Mu.Unlock()
+		c.notice = ""
+		c.RenderScreen()
+	})
+}
+
+// renderNotice draws the current notice, if any, below where the select
+// hint goes.
+func (c *Client) renderNotice(buf *bytes.Buffer) {
+	if c.notice == "" || c.VT.Cols <= 0 {
+		return
+	}
+	text := []rune(" " + c.notice + " ")
+	if len(text) > c.VT.Cols {
+		text = text[len(text)-c.VT.Cols:]
+	}
+	row := 2
+	if c.IsScrollMode() {
+		row = 3
+	}
+	if row > c.VT.ChildRows {
+		row = 1
+	}
+	fmt.Fprintf(buf, "\033[%d;%dH\033[7m%s\033[0m", row, c.VT.Cols-len(text)+1, string(text))
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMenuExport_WritesHTMLAndTextAndShowsNotice(t *testing.T) {
+	o := newTestClient(10, 80)
+	o.ExportDir = t.TempDir()
+	o.Mode = ModeMenu
+	o.VT.Scrollback.Write([]byte("\033[32mbuild ok\033[0m\r\n"))
+	var out bytes.Buffer
+	o.Output = &out
+
+	o.HandleMenuBytes([]byte("e"), 0, 1)
+	if o.noticeTimer != nil {
+		o.noticeTimer.Stop()
+	}
+
+	if o.Mode != ModeNormal {
+		t.Errorf("Mode = %d, want ModeNormal after export", o.Mode)
+	}
+	files, _ := filepath.Glob(filepath.Join(o.ExportDir, "scrollback-*.html"))
+	if len(files) != 1 {
+		t.Fatalf("expected one export file, got %v", files)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `<span style="color:#008000">build ok</span>`) {
+		t.Errorf("export missing colored output:\n%s", data)
+	}
+	text, err := os.ReadFile(strings.TrimSuffix(files[0], ".html") + ".txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(text) != "build ok\n" {
+		t.Errorf("text export = %q, want %q", text, "build ok\n")
+	}
+	// Long paths are cut from the left, so only the file name is certain.
+	if o.notice != "exported to "+files[0]+" (+ .txt)" || !strings.Contains(out.String(), filepath.Base(files[0])) {
+		t.Errorf("notice = %q, want the export path rendered", o.notice)
+	}
+}
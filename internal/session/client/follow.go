@@ -0,0 +1,40 @@
+package client
+
+// ToggleFollow turns follow mode on or off while in scroll mode. Following,
+// the view stays pinned to the live bottom and new output appears as it
+// arrives, like tail -f. Turning it off, or scrolling up, freezes the view
+// where it is.
+func (c *Client) ToggleFollow() {
+	if !c.IsScrollMode() {
+		return
+	}
+	c.ScrollFollow = !c.ScrollFollow
+	if c.ScrollFollow {
+		c.ScrollOffset = 0
+		c.followLive()
+	}
+	c.RenderScreen()
+	c.RenderBar()
+}
+
+// FollowOutput re-renders a following scroll view after new output. A view
+// the user has moved off the bottom, e.g. by dragging the scrollbar, stops
+// following and stays where it is.
+func (c *Client) FollowOutput() {
+	if !c.IsScrollMode() || !c.ScrollFollow {
+		return
+	}
+	if c.ScrollOffset > 0 {
+		c.ScrollFollow = false
+		c.RenderBar()
+		return
+	}
+	c.followLive()
+	c.RenderScreen()
+}
+
+// followLive moves the frozen scroll anchors to the live bottom.
+func (c *Client) followLive() {
+	c.ScrollAnchorY = c.scrollbackBottomRow()
+	c.ScrollHistoryAnchor = len(c.VT.ScrollHistory)
+}
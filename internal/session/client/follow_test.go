@@ -0,0 +1,98 @@
+package client
+
+import "testing"
+
+func newFollowTestClient(lines int) *Client {
+	o := newTestClient(10, 80)
+	for i := 0; i < lines; i++ {
+		o.VT.Scrollback.Write([]byte("line\n"))
+	}
+	return o
+}
+
+func TestToggleFollow_TracksLiveOutput(t *testing.T) {
+	o := newFollowTestClient(50)
+	o.EnterScrollMode()
+	frozen := o.ScrollAnchorY
+
+	// Frozen, new output doesn't move the anchor.
+	o.VT.Scrollback.Write([]byte("more\nmore\n"))
+	o.FollowOutput()
+	if o.ScrollAnchorY != frozen {
+		t.Fatalf("anchor moved to %d while frozen, want %d", o.ScrollAnchorY, frozen)
+	}
+
+	o.HandleScrollBytes([]byte("f"), 0, 1)
+	if !o.ScrollFollow || o.ScrollAnchorY != o.scrollbackBottomRow() {
+		t.Fatalf("follow=%v anchor=%d, want following at %d", o.ScrollFollow, o.ScrollAnchorY, o.scrollbackBottomRow())
+	}
+	if got := o.ModeLabel(); got != "Scroll (follow)" {
+		t.Fatalf("ModeLabel = %q, want %q", got, "Scroll (follow)")
+	}
+
+	o.VT.Scrollback.Write([]byte("live\nlive\nlive\n"))
+	o.FollowOutput()
+	if o.ScrollAnchorY != o.scrollbackBottomRow() {
+		t.Fatalf("anchor = %d, want live bottom %d", o.ScrollAnchorY, o.scrollbackBottomRow())
+	}
+
+	// Toggling off re-freezes at the current position.
+	o.ToggleFollow()
+	pinned := o.ScrollAnchorY
+	o.VT.Scrollback.Write([]byte("later\n"))
+	o.FollowOutput()
+	if o.ScrollFollow || o.ScrollAnchorY != pinned || !o.IsScrollMode() {
+		t.Fatalf("follow=%v anchor=%d mode=%d, want frozen at %d in scroll mode", o.ScrollFollow, o.ScrollAnchorY, o.Mode, pinned)
+	}
+}
+
+func TestToggleFollow_ScrollUpStopsFollowing(t *testing.T) {
+	o := newFollowTestClient(50)
+	o.EnterScrollMode()
+	o.ToggleFollow()
+
+	o.ScrollUp(5)
+	if o.ScrollFollow {
+		t.Fatal("scrolling up should stop following")
+	}
+	anchor := o.ScrollAnchorY
+	o.VT.Scrollback.Write([]byte("more\n"))
+	o.FollowOutput()
+	if o.ScrollAnchorY != anchor || o.ScrollOffset != 5 {
+		t.Fatalf("anchor=%d offset=%d, want the view to stay at %d/5", o.ScrollAnchorY, o.ScrollOffset, anchor)
+	}
+}
+
+func TestFollowOutput_MovedViewStopsFollowing(t *testing.T) {
+	o := newFollowTestClient(50)
+	o.EnterScrollMode()
+	o.ToggleFollow()
+	anchor := o.ScrollAnchorY
+
+	// e.g. a scrollbar drag sets the offset directly.
+	o.ScrollOffset = 8
+	o.VT.Scrollback.Write([]byte("more\n"))
+	o.FollowOutput()
+	if o.ScrollFollow || o.ScrollAnchorY != anchor || o.ScrollOffset != 8 {
+		t.Fatalf("follow=%v anchor=%d offset=%d, want stopped at %d/8", o.ScrollFollow, o.ScrollAnchorY, o.ScrollOffset, anchor)
+	}
+}
+
+func TestToggleFollow_OutsideScrollMode(t *testing.T) {
+	o := newFollowTestClient(5)
+	o.ToggleFollow()
+	if o.ScrollFollow {
+		t.Fatal("follow should only toggle in scroll mode")
+	}
+
+	o.EnterScrollMode()
+	o.ToggleFollow()
+	o.ExitScrollMode()
+	if o.ScrollFollow {
+		t.Fatal("leaving scroll mode should stop following")
+	}
+	o.EnterScrollMode()
+	if o.ScrollFollow {
+		t.Fatal("scroll mode should start frozen")
+	}
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/muesli/termenv"
+	"github.com/vito/midterm"
+)
+
+// HighlightRule colors matches of Pattern with the given SGR parameters
+// (e.g. "31" or "1;33") when rendering the agent's output.
+type HighlightRule struct {
+	Pattern *regexp.Regexp
+	SGR     string
+}
+
+// highlightCells returns the highlight SGR for each cell in line, or nil if
+// no rule matches. Only cells rendered with a plain format are highlighted so
+// the inner program's own styling is never overridden. Later rules win where
+// matches overlap.
+func highlightCells(line []rune, formats []midterm.Format, rules []HighlightRule) []string {
+	if len(rules) == 0 || len(line) == 0 {
+		return nil
+	}
+	text := string(line)
+	// Map byte offsets from the regexp back to cell indices.
+	// Match boundaries always fall on rune starts, which is all this fills.
+	cellAt := make([]int, len(text)+1)
+	cell := 0
+	for off := range text {
+		cellAt[off] = cell
+		cell++
+	}
+	cellAt[len(text)] = len(line)
+
+	var cells []string
+	for _, rule := range rules {
+		for _, m := range rule.Pattern.FindAllStringIndex(text, -1) {
+			start, end := cellAt[m[0]], cellAt[m[1]]
+			for i := start; i < end && i < len(formats); i++ {
+				if !isPlainFormat(formats[i]) {
+					continue
+				}
+				if cells == nil {
+					cells = make([]string, len(line))
+				}
+				cells[i] = rule.SGR
+			}
+		}
+	}
+	return cells
+}
+
+// isPlainFormat reports whether f carries no colors or text attributes.
+func isPlainFormat(f midterm.Format) bool {
+	if f.Properties&^midterm.ResetBit != 0 {
+		return false
+	}
+	return isDefaultColor(f.Fg) && isDefaultColor(f.Bg)
+}
+
+func isDefaultColor(c termenv.Color) bool {
+	if c == nil {
+		return true
+	}
+	_, ok := c.(termenv.NoColor)
+	return ok
+}
+
+// writeHighlightSGR emits the SGR for a highlighted cell run, if any.
+func writeHighlightSGR(buf *bytes.Buffer, sgr string) {
+	if sgr == "" {
+		return
+	}
+	buf.WriteString("\033[")
+	buf.WriteString(sgr)
+	buf.WriteString("m")
+}
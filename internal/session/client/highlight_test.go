@@ -0,0 +1,81 @@
+package client
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/muesli/termenv"
+	"github.com/vito/midterm"
+)
+
+func TestHighlightCells_NoRules(t *testing.T) {
+	line := []rune("error")
+	if got := highlightCells(line, make([]midterm.Format, len(line)), nil); got != nil {
+		t.Fatalf("expected nil with no rules, got %v", got)
+	}
+}
+
+func TestHighlightCells_SkipsStyledCells(t *testing.T) {
+	line := []rune("error error")
+	formats := make([]midterm.Format, len(line))
+	for i := 6; i < len(line); i++ {
+		formats[i].Fg = termenv.ANSIColor(2)
+	}
+	rules := []HighlightRule{{Pattern: regexp.MustCompile("error"), SGR: "31"}}
+
+	got := highlightCells(line, formats, rules)
+	for i := range line {
+		want := ""
+		if i < 5 {
+			want = "31"
+		}
+		if got[i] != want {
+			t.Errorf("cell %d = %q, want %q", i, got[i], want)
+		}
+	}
+}
+
+func TestHighlightCells_MultiByteRunes(t *testing.T) {
+	line := []rune("✓ WARN ✓")
+	rules := []HighlightRule{{Pattern: regexp.MustCompile("WARN"), SGR: "33"}}
+
+	got := highlightCells(line, make([]midterm.Format, len(line)), rules)
+	var marked []int
+	for i, sgr := range got {
+		if sgr != "" {
+			marked = append(marked, i)
+		}
+	}
+	if len(marked) != 4 || marked[0] != 2 || marked[3] != 5 {
+		t.Fatalf("expected cells 2-5 highlighted, got %v", marked)
+	}
+}
+
+func TestRenderLineFrom_AppliesHighlights(t *testing.T) {
+	c := newTestClient(5, 40)
+	c.VT.Vt.Write([]byte("\x1b[32mok\x1b[0m fatal error"))
+	c.Highlights = []HighlightRule{{Pattern: regexp.MustCompile("ok|error"), SGR: "1;31"}}
+
+	var buf bytes.Buffer
+	c.RenderLineFrom(&buf, c.VT.Vt, 0, nil)
+	out := buf.String()
+	if !strings.Contains(out, "\033[1;31merror") {
+		t.Fatalf("expected highlighted \"error\", got %q", out)
+	}
+	if strings.Contains(out, "\033[1;31mok") {
+		t.Fatalf("already-styled text should not be highlighted, got %q", out)
+	}
+}
+
+func TestRenderHistoryEntry_AppliesHighlights(t *testing.T) {
+	c := newTestClient(5, 40)
+	c.Highlights = []HighlightRule{{Pattern: regexp.MustCompile("panic"), SGR: "31"}}
+
+	var buf bytes.Buffer
+	c.renderHistoryEntry(&buf, historyEntry("a panic here"), nil)
+	if !strings.Contains(buf.String(), "\033[31mpanic\033[0m") {
+		t.Fatalf("expected highlighted \"panic\", got %q", buf.String())
+	}
+}
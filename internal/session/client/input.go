@@ -16,6 +16,17 @@ import (
 const ptyWriteTimeout = 3 * time.Second
 const scrollStep = 3
 
+// Bracketed paste markers sent by the terminal around pasted text.
+var (
+	bracketedPasteStart = []byte("\x1b[200~")
+	bracketedPasteEnd   = []byte("\x1b[201~")
+)
+
+// pasteIdleTimeout bounds how long passthrough stays in bracketed-paste
+// state without receiving input, so a lost end marker can't disable the
+// exit shortcuts for good.
+const pasteIdleTimeout = 2 * time.Second
+
 func (c *Client) setMode(mode InputMode) {
 	c.Mode = mode
 	if c.OnModeChange != nil {
@@ -54,6 +65,7 @@ func (c *Client) ResetModeOnExit() {
 	case ModePassthrough:
 		c.CancelPendingEsc()
 		c.PassthroughEsc = c.PassthroughEsc[:0]
+		c.resetBracketedPaste()
 		if c.ReleasePassthrough != nil {
 			c.ReleasePassthrough()
 		}
@@ -63,6 +75,7 @@ func (c *Client) ResetModeOnExit() {
 		c.ScrollOffset = 0
 		c.ScrollAnchorY = 0
 		c.ScrollHistoryAnchor = 0
+		c.ScrollFollow = false
 		c.setMode(ModeNormal)
 	case ModeMenu:
 		c.setMode(ModeNormal)
@@ -138,6 +151,7 @@ func (c *Client) HandlePassthroughBytes(buf []byte, start, n int) int {
 		if c.VT.ChildExited || c.VT.ChildHung {
 			c.CancelPendingEsc()
 			c.PassthroughEsc = c.PassthroughEsc[:0]
+			c.resetBracketedPaste()
 			if c.ReleasePassthrough != nil {
 				c.ReleasePassthrough()
 			}
@@ -145,6 +159,19 @@ func (c *Client) HandlePassthroughBytes(buf []byte, start, n int) int {
 			c.RenderBar()
 			return c.HandleExitedBytes(buf, i, n)
 		}
+		if c.InBracketedPaste && time.Since(c.lastPasteInput) > pasteIdleTimeout {
+			c.resetBracketedPaste()
+		}
+		if c.InBracketedPaste {
+			// Pasted text is literal: exit shortcuts and newline
+			// translation don't apply until the end marker arrives.
+			var ok bool
+			i, ok = c.handleBracketedPasteBytes(buf, i, n)
+			if !ok {
+				return n
+			}
+			continue
+		}
 		b := buf[i]
 		if c.PendingEsc {
 			if b != '[' && b != 'O' {
@@ -183,7 +210,7 @@ func (c *Client) HandlePassthroughBytes(buf []byte, start, n int) int {
 				return n
 			}
 			i++
-		case 0x1C, 0x00: // ctrl+\ or ctrl+space — exit passthrough (universal fallback)
+		case c.passthroughExitKey(), 0x00: // ctrl+\ (or remapped key) or ctrl+space — exit passthrough (universal fallback)
 			c.CancelPendingEsc()
 			c.PassthroughEsc = c.PassthroughEsc[:0]
 			c.setMode(ModeNormal)
@@ -207,6 +234,40 @@ func (c *Client) HandlePassthroughBytes(buf []byte, start, n int) int {
 	return n
 }
 
+// handleBracketedPasteBytes forwards pasted bytes to the child verbatim,
+// watching for the paste-end marker. The marker may be split across read
+// buffers, so match progress is kept on the Client. Returns the index after
+// the consumed bytes and false if the PTY write failed.
+func (c *Client) handleBracketedPasteBytes(buf []byte, start, n int) (int, bool) {
+	c.lastPasteInput = time.Now()
+	i := start
+	for i < n {
+		b := buf[i]
+		i++
+		if b == bracketedPasteEnd[c.pasteEndMatched] {
+			c.pasteEndMatched++
+		} else if b == bracketedPasteEnd[0] {
+			c.pasteEndMatched = 1
+		} else {
+			c.pasteEndMatched = 0
+		}
+		if c.pasteEndMatched == len(bracketedPasteEnd) {
+			c.resetBracketedPaste()
+			break
+		}
+	}
+	if !c.writePTYOrHang(buf[start:i]) {
+		return n, false
+	}
+	return i, true
+}
+
+// resetBracketedPaste clears bracketed-paste tracking state.
+func (c *Client) resetBracketedPaste() {
+	c.InBracketedPaste = false
+	c.pasteEndMatched = 0
+}
+
 func (c *Client) HandleMenuBytes(buf []byte, start, n int) int {
 	for i := start; i < n; {
 		b := buf[i]
@@ -244,7 +305,7 @@ func (c *Client) HandleMenuBytes(buf []byte, start, n int) int {
 				c.setMode(ModeNormal)
 				c.RenderBar()
 			}
-		case 0x1C, 0x00: // ctrl+\ or ctrl+space — exit menu (toggle with default mode shortcut)
+		case c.menuKey(), 0x00: // ctrl+\ (or remapped key) or ctrl+space — exit menu (toggle with default mode shortcut)
 			c.setMode(ModeNormal)
 			c.RenderBar()
 		case 'p', 'P': // passthrough mode
@@ -271,6 +332,10 @@ func (c *Client) HandleMenuBytes(buf []byte, start, n int) int {
 			c.RenderScreen()
 			c.setMode(ModeNormal)
 			c.RenderBar()
+		case 'e', 'E': // export scrollback as HTML and plain text
+			c.setMode(ModeNormal)
+			c.ExportScrollback()
+			c.RenderBar()
 		case 'd', 'D': // detach
 			if c.OnDetach != nil {
 				c.setMode(ModeNormal)
@@ -363,7 +428,7 @@ func (c *Client) HandleDefaultBytes(buf []byte, start, n int) int {
 		}
 
 		switch b {
-		case 0x1C, 0x00: // ctrl+\ or ctrl+space — open menu (universal fallback)
+		case c.menuKey(), 0x00: // ctrl+\ (or remapped key) or ctrl+space — open menu (universal fallback)
 			c.setMode(ModeMenu)
 			c.RenderBar()
 
@@ -449,8 +514,19 @@ func (c *Client) FlushPassthroughEscIfComplete() bool {
 	if !virtualterminal.IsEscSequenceComplete(c.PassthroughEsc) {
 		return false
 	}
-	if virtualterminal.IsCtrlEscapeSequence(c.PassthroughEsc) {
-		// Ctrl+Escape exits passthrough mode (don't write to PTY).
+	if c.Mode == ModePassthrough && string(c.PassthroughEsc) == string(bracketedPasteStart) {
+		// Start of a bracketed paste: forward the marker and treat
+		// everything up to the end marker as literal data.
+		c.writePTYOrHang(c.PassthroughEsc)
+		c.PassthroughEsc = c.PassthroughEsc[:0]
+		c.InBracketedPaste = true
+		c.pasteEndMatched = 0
+		c.lastPasteInput = time.Now()
+		return true
+	}
+	if virtualterminal.IsCtrlEscapeSequence(c.PassthroughEsc) || c.isKittyPassthroughExit(c.PassthroughEsc) {
+		// Ctrl+Escape (or the remapped exit key) exits passthrough mode
+		// (don't write to PTY).
 		c.PassthroughEsc = c.PassthroughEsc[:0]
 		c.setMode(ModeNormal)
 		c.RenderBar()
@@ -578,6 +654,17 @@ func (c *Client) HandleCSI(remaining []byte) (consumed int, handled bool) {
 				c.setMode(ModeMenu)
 				c.RenderBar()
 			}
+		} else if c.MenuKey != 0 && isKittyControlKey(params, c.MenuKey) && (c.Mode == ModeNormal || c.Mode == ModeMenu) {
+			// Remapped menu key, kitty-encoded — toggle the menu.
+			if c.Mode == ModeNormal {
+				c.setMode(ModeMenu)
+			} else {
+				c.setMode(ModeNormal)
+			}
+			c.RenderBar()
+		} else if c.PassthroughExitKey != 0 && isKittyControlKey(params, c.PassthroughExitKey) && c.Mode == ModePassthrough {
+			c.setMode(ModeNormal)
+			c.RenderBar()
 		} else if c.Mode == ModeNormal || c.Mode == ModePassthrough {
 			c.writePTYOrHang(append([]byte{0x1B, '['}, remaining[:i+1]...))
 		}
@@ -683,7 +770,9 @@ func (c *Client) CyclePriority() {
 }
 
 // HandleScrollBytes processes input when in scroll mode.
-// Esc or q exits scroll mode. Arrow keys scroll. All other input is ignored.
+// Esc or q exits scroll mode. Arrow keys scroll. m toggles the mini-map,
+// w toggles line wrapping, and y copies the rows on screen.
+// All other input is ignored.
 func (c *Client) HandleScrollBytes(buf []byte, start, n int) int {
 	for i := start; i < n; {
 		if c.VT.ChildExited || c.VT.ChildHung {
@@ -718,6 +807,14 @@ func (c *Client) HandleScrollBytes(buf []byte, start, n int) int {
 				// ESC at end of buffer — wait to see if it's bare Esc.
 				c.StartPendingEsc()
 			}
+		case 'm', 'M':
+			c.ToggleMinimap()
+		case 'w', 'W':
+			c.ToggleWrap()
+		case 'y', 'Y':
+			c.CopyViewport()
+		case 'f', 'F':
+			c.ToggleFollow()
 		default:
 			// Pass control characters through to the PTY.
 			if b < 0x20 && !c.VT.ChildExited && !c.VT.ChildHung {
@@ -751,6 +848,7 @@ func (c *Client) ExitScrollMode() {
 	c.ScrollOffset = 0
 	c.ScrollAnchorY = 0
 	c.ScrollHistoryAnchor = 0
+	c.ScrollFollow = false
 	if c.Mode == ModePassthroughScroll {
 		c.setMode(ModePassthrough)
 	} else {
@@ -760,8 +858,9 @@ func (c *Client) ExitScrollMode() {
 	c.RenderBar()
 }
 
-// ScrollUp moves the scroll view up by the given number of lines.
-// If the offset is already at the maximum, this is a no-op to avoid re-rendering.
+// ScrollUp moves the scroll view up by the given number of lines, which
+// stops following live output. If the offset is already at the maximum,
+// this is a no-op to avoid re-rendering.
 func (c *Client) ScrollUp(lines int) {
 	prev := c.ScrollOffset
 	c.ScrollOffset += lines
@@ -769,6 +868,7 @@ func (c *Client) ScrollUp(lines int) {
 	if c.ScrollOffset == prev {
 		return
 	}
+	c.ScrollFollow = false
 	c.RenderScreen()
 	c.RenderBar()
 }
@@ -805,25 +905,13 @@ func (c *Client) ClampScrollOffset() {
 	}
 }
 
-// scrollbackBottomRow returns the effective last row in scrollback.
-// Returns max(Scrollback.MaxY, Scrollback.Cursor.Y). Cursor.Y alone is
-// unreliable because TUIs reposition the cursor via \033[H mid-chunk,
-// which would drop the scroll-mode anchor at the oldest content and pin
-// maxOffset to 0. MaxY is midterm's per-paint watermark of the highest
-// row ever written, so it survives cursor resets even within a single
-// chunk that writes content then jumps the cursor home.
+// scrollbackBottomRow returns the effective last row in scrollback. See
+// VT.ScrollbackBottomRow.
 func (c *Client) scrollbackBottomRow() int {
-	if c.VT == nil || c.VT.Scrollback == nil {
-		return 0
-	}
-	y := c.VT.Scrollback.Cursor.Y
-	if c.VT.Scrollback.MaxY > y {
-		y = c.VT.Scrollback.MaxY
-	}
-	if y < 0 {
+	if c.VT == nil {
 		return 0
 	}
-	return y
+	return c.VT.ScrollbackBottomRow()
 }
 
 // scrollbackScrollBottom returns the effective bottom for midterm scrollback rendering.
@@ -834,38 +922,26 @@ func (c *Client) scrollbackScrollBottom() int {
 	return c.scrollbackBottomRow()
 }
 
+// scrollMaxOffset returns the largest ScrollOffset: the display rows of
+// content above the screen's worth at the bottom. ok is false when there is
+// no scrollback to show.
 func (c *Client) scrollMaxOffset() (int, bool) {
-	if c.VT == nil {
+	if _, ok := c.scrollLineCount(); !ok {
 		return 0, false
 	}
-	// Prefer ScrollHistory (from midterm OnScrollback callback) when available.
-	if c.hasScrollHistory() {
-		histLen := c.scrollHistoryLen()
-		// Total content = ScrollHistory + live screen (ChildRows).
-		// Max offset = total - ChildRows = histLen.
-		if histLen < 0 {
-			histLen = 0
-		}
-		return histLen, true
-	}
-	// Fallback to AppendOnly scrollback for apps without scroll regions.
-	if c.VT.Scrollback == nil {
-		return 0, false
-	}
-	bottom := c.scrollbackScrollBottom()
-	maxOffset := bottom - c.VT.ChildRows + 1
-	if maxOffset < 0 {
-		maxOffset = 0
-	}
-	return maxOffset, true
+	return max(c.scrollLayout().rows-c.VT.ChildRows, 0), true
 }
 
 // hasScrollHistory returns true if ScrollHistory should be used for scrollback.
 // This is only preferred when the child uses scroll regions (DECSTBM), which
 // breaks the AppendOnly Scrollback terminal. For apps without scroll regions
-// (e.g. Claude Code), Scrollback works better.
+// (e.g. Claude Code), Scrollback works better — unless history was restored
+// from a previous session process, which only ScrollHistory holds.
 func (c *Client) hasScrollHistory() bool {
-	return c.VT != nil && c.VT.ScrollRegionUsed && len(c.VT.ScrollHistory) > 0
+	if c.VT == nil || len(c.VT.ScrollHistory) == 0 {
+		return false
+	}
+	return c.VT.ScrollRegionUsed || c.VT.RestoredHistory > 0
 }
 
 // scrollHistoryLen returns the ScrollHistory length to use for rendering.
@@ -908,10 +984,21 @@ func (c *Client) HandleSGRMouse(params []byte, press bool) {
 		return
 	}
 
+	if c.handleMinimapMouse(button, parts[1], parts[2], press) {
+		return
+	}
+	if c.handleScrollbarMouse(button, parts[1], parts[2], press) {
+		return
+	}
+
 	// Motion-only event (no button), used for hover-link affordance.
 	// SGR encodes motion in bit 5 (mask 32); the low bits then carry button
 	// state (3 = released/no button). We treat any motion event as hover.
 	if button&32 != 0 {
+		if button == 32 && c.selection != nil && c.selection.dragging {
+			c.extendSelection(parts[1], parts[2])
+			return
+		}
 		c.handleHover(parts[1], parts[2])
 		return
 	}
@@ -927,7 +1014,14 @@ func (c *Client) HandleSGRMouse(params []byte, press bool) {
 
 	switch button {
 	case 0: // left click
-		if press {
+		switch {
+		case c.OSC52Copy && !c.IsScrollMode():
+			if press {
+				c.beginSelection(parts[1], parts[2])
+			} else {
+				c.finishSelection(parts[1], parts[2])
+			}
+		case press:
 			c.ShowSelectHint()
 		}
 	case 64: // scroll up
@@ -956,6 +1050,13 @@ func (c *Client) HandleSGRMouse(params []byte, press bool) {
 	}
 }
 
+// DefaultSelectHint and DefaultSelectHintDuration are the select hint's text
+// and how long it stays up when config.yaml doesn't set them.
+const (
+	DefaultSelectHint         = "(hold shift to select)"
+	DefaultSelectHintDuration = 3 * time.Second
+)
+
 // ShowSelectHint displays a transient hint about using shift for text selection.
 func (c *Client) ShowSelectHint() {
 	c.SelectHint = true
@@ -964,7 +1065,11 @@ func (c *Client) ShowSelectHint() {
 	}
 	c.RenderScreen()
 	c.RenderBar()
-	c.SelectHintTimer = time.AfterFunc(3*time.Second, func() {
+	d := c.SelectHintDuration
+	if d <= 0 {
+		d = DefaultSelectHintDuration
+	}
+	c.SelectHintTimer = time.AfterFunc(d, func() {
 		defer func() {
 			if r := recover(); r != nil {
 				fmt.Fprintf(os.Stderr, "panic recovered in SelectHintTimer: %v\n%s\n", r, debug.Stack())
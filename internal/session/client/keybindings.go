@@ -1,6 +1,7 @@
 package client
 
 import (
+	"fmt"
 	"os"
 	"time"
 )
@@ -31,12 +32,54 @@ var keybindingHelpText = map[KeybindingMode]KeybindingHelp{
 }
 
 func (c *Client) keybindingHelp() KeybindingHelp {
+	if c.KeybindingMode == KeybindingsLegacy && (c.MenuKey != 0 || c.PassthroughExitKey != 0) {
+		return KeybindingHelp{
+			NormalMode:      "Enter send | " + controlKeyName(c.menuKey()) + " menu",
+			PassthroughMode: controlKeyName(c.passthroughExitKey()) + " exit",
+		}
+	}
 	if h, ok := keybindingHelpText[c.KeybindingMode]; ok {
 		return h
 	}
 	return keybindingHelpText[KeybindingsLegacy]
 }
 
+// defaultMenuKey is Ctrl+\, which opens the menu and exits passthrough
+// unless remapped.
+const defaultMenuKey = 0x1C
+
+// menuKey returns the control byte that opens and closes the menu.
+func (c *Client) menuKey() byte {
+	if c.MenuKey != 0 {
+		return c.MenuKey
+	}
+	return defaultMenuKey
+}
+
+// passthroughExitKey returns the control byte that exits passthrough mode.
+func (c *Client) passthroughExitKey() byte {
+	if c.PassthroughExitKey != 0 {
+		return c.PassthroughExitKey
+	}
+	return defaultMenuKey
+}
+
+// controlKeyName returns the display name of a control byte, e.g. "Ctrl+G".
+func controlKeyName(b byte) string {
+	return "Ctrl+" + string(rune(b+'@'))
+}
+
+// isKittyControlKey reports whether a kitty keyboard protocol key event
+// (CSI <code>;<modifiers> u params) is Ctrl plus the key that sends control
+// byte b in legacy mode. Kitty reports letters by their lowercase code.
+func isKittyControlKey(params string, b byte) bool {
+	code := int(b) + '@'
+	if code >= 'A' && code <= 'Z' {
+		code += 'a' - 'A'
+	}
+	return params == fmt.Sprintf("%d;5", code)
+}
+
 // detectKittyKeyboard probes the terminal for kitty keyboard protocol support.
 // Must be called after entering raw mode with stdin available.
 func (c *Client) detectKittyKeyboard() {
@@ -68,3 +111,12 @@ func (c *Client) detectKittyKeyboard() {
 		c.KeybindingMode = KeybindingsLegacy
 	}
 }
+
+// isKittyPassthroughExit reports whether seq is the remapped passthrough
+// exit key as a kitty-encoded CSI u sequence.
+func (c *Client) isKittyPassthroughExit(seq []byte) bool {
+	if c.PassthroughExitKey == 0 || len(seq) < 4 || seq[1] != '[' || seq[len(seq)-1] != 'u' {
+		return false
+	}
+	return isKittyControlKey(string(seq[2:len(seq)-1]), c.PassthroughExitKey)
+}
@@ -0,0 +1,180 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"unicode"
+)
+
+// minimapWidth is the number of columns the mini-map occupies at the right
+// edge of the child area, replacing the scrollbar while it is shown.
+const minimapWidth = 8
+
+// minimapSamples caps how many lines are inspected per mini-map row so long
+// histories stay cheap to draw.
+const minimapSamples = 8
+
+// minimapShades maps a cell's ink density (0-4) to a block character.
+var minimapShades = []rune{' ', '░', '▒', '▓', '█'}
+
+// ToggleMinimap shows or hides the scroll-mode mini-map. It does nothing
+// when there is no history to overview.
+func (c *Client) ToggleMinimap() {
+	if !c.minimapAvailable() && !c.MinimapVisible {
+		return
+	}
+	c.MinimapVisible = !c.MinimapVisible
+	c.RenderScreen()
+}
+
+// renderScrollGutter draws the mini-map when it is shown, otherwise the
+// scrollbar.
+func (c *Client) renderScrollGutter(buf *bytes.Buffer) {
+	if c.minimapShown() {
+		c.renderMinimap(buf)
+		return
+	}
+	c.renderScrollbar(buf)
+}
+
+// minimapAvailable reports whether the mini-map can be drawn: it needs
+// history to scroll and room beside the content.
+func (c *Client) minimapAvailable() bool {
+	if c.VT == nil || c.VT.Cols <= minimapWidth*2 || c.VT.ChildRows <= 0 {
+		return false
+	}
+	maxOffset, ok := c.scrollMaxOffset()
+	return ok && maxOffset > 0
+}
+
+// minimapShown reports whether the mini-map replaces the scrollbar in the
+// current frame.
+func (c *Client) minimapShown() bool {
+	return c.MinimapVisible && c.IsScrollMode() && c.minimapAvailable()
+}
+
+// scrollContentRow returns display row i of the scroll view (the rows
+// renderScrollView pages through) as laid out by layout, or nil for marker
+// rows and rows that are out of range.
+func (c *Client) scrollContentRow(layout scrollLayout, i int) []rune {
+	line, seg := layout.at(i)
+	content := c.scrollLineRunes(line)
+	if layout.starts == nil {
+		return content
+	}
+	cols := c.VT.Cols
+	return content[min(seg*cols, len(content)):min((seg+1)*cols, len(content))]
+}
+
+// minimapBucket returns the content lines [start, end) that mini-map row r
+// summarizes, given total content lines.
+func (c *Client) minimapBucket(r, total int) (start, end int) {
+	rows := c.VT.ChildRows
+	start = r * total / rows
+	end = (r + 1) * total / rows
+	if end <= start {
+		end = start + 1
+	}
+	return start, end
+}
+
+// minimapCells downsamples the content lines of mini-map row r into
+// minimapWidth shade characters by the fraction of non-blank cells.
+func (c *Client) minimapCells(layout scrollLayout, r, total int) []rune {
+	start, end := c.minimapBucket(r, total)
+	step := max((end-start)/minimapSamples, 1)
+	span := max(c.VT.Cols/minimapWidth, 1)
+
+	ink := make([]int, minimapWidth)
+	cells := 0
+	for line := start; line < end; line += step {
+		row := c.scrollContentRow(layout, line)
+		for col, ch := range row {
+			if col >= span*minimapWidth {
+				break
+			}
+			if ch != 0 && !unicode.IsSpace(ch) {
+				ink[col/span]++
+			}
+		}
+		cells += span
+	}
+
+	out := make([]rune, minimapWidth)
+	for i, n := range ink {
+		level := 0
+		if n > 0 && cells > 0 {
+			level = 1 + n*(len(minimapShades)-2)/cells
+		}
+		out[i] = minimapShades[min(level, len(minimapShades)-1)]
+	}
+	return out
+}
+
+// renderMinimap draws the mini-map over the rightmost minimapWidth columns:
+// each row summarizes an even slice of the whole scrollback, and rows that
+// overlap the visible window are highlighted.
+func (c *Client) renderMinimap(buf *bytes.Buffer) {
+	layout := c.scrollLayout()
+	rows := c.VT.ChildRows
+	maxOffset := max(layout.rows-rows, 0)
+	total := maxOffset + rows
+	offset := min(max(c.ScrollOffset, 0), maxOffset)
+	viewStart := total - rows - offset
+	viewEnd := viewStart + rows
+
+	col := c.VT.Cols - minimapWidth + 1
+	for r := 0; r < rows; r++ {
+		start, end := c.minimapBucket(r, total)
+		style := "\033[0;2m"
+		if start < viewEnd && end > viewStart {
+			style = "\033[0;100m"
+		}
+		fmt.Fprintf(buf, "\033[%d;%dH%s%s\033[0m", r+1, col, style, string(c.minimapCells(layout, r, total)))
+	}
+}
+
+// handleMinimapMouse handles a left-button press or drag on the mini-map,
+// scrolling so the clicked slice of history is centered. Returns true if
+// the event was consumed.
+func (c *Client) handleMinimapMouse(button int, cxStr, cyStr string, press bool) bool {
+	if !c.minimapShown() {
+		return false
+	}
+	cx, err1 := strconv.Atoi(cxStr)
+	cy, err2 := strconv.Atoi(cyStr)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	switch {
+	case button == 0 && press:
+		if cx <= c.VT.Cols-minimapWidth || cy < 1 || cy > c.VT.ChildRows {
+			return false
+		}
+		c.scrollbarDragging = true
+	case button == 32 && c.scrollbarDragging:
+		cy = min(max(cy, 1), c.VT.ChildRows)
+	case button == 0 && !press && c.scrollbarDragging:
+		c.scrollbarDragging = false
+		return true
+	default:
+		return false
+	}
+	c.scrollToMinimapRow(cy - 1)
+	return true
+}
+
+// scrollToMinimapRow centers the view on the slice of history that
+// mini-map row r (0-indexed) summarizes.
+func (c *Client) scrollToMinimapRow(r int) {
+	maxOffset, _ := c.scrollMaxOffset()
+	rows := c.VT.ChildRows
+	total := maxOffset + rows
+	start, end := c.minimapBucket(r, total)
+	viewStart := (start+end)/2 - rows/2
+	c.ScrollOffset = total - rows - viewStart
+	c.ClampScrollOffset()
+	c.RenderScreen()
+	c.RenderBar()
+}
@@ -62,9 +62,13 @@ type Client struct {
 	PendingEsc          bool
 	EscTimer            *time.Timer
 	PassthroughEsc      []byte
+	InBracketedPaste    bool      // inside ESC[200~ ... ESC[201~ in passthrough mode
+	pasteEndMatched     int       // bytes of the paste-end marker matched so far
+	lastPasteInput      time.Time // when paste bytes were last received
 	ScrollOffset        int
-	ScrollAnchorY       int // frozen scrollback bottom row while in scroll mode
-	ScrollHistoryAnchor int // frozen len(ScrollHistory) at scroll mode entry
+	ScrollAnchorY       int  // frozen scrollback bottom row while in scroll mode
+	ScrollHistoryAnchor int  // frozen len(ScrollHistory) at scroll mode entry
+	ScrollFollow        bool // scroll mode anchors track the live bottom
 	SelectHint          bool
 	SelectHintTimer     *time.Timer
 	InputPriority       message.Priority
@@ -79,6 +83,7 @@ type Client struct {
 	WorkingDir          func() string                                                                                  // returns agent working directory for status bar
 	AgentState          func() (state string, subState string, duration string)                                        // returns Agent's derived state + sub-state
 	HookState           func() (lastToolName string)                                                                   // returns hook collector state
+	LastActivity        func() time.Time                                                                               // returns when the agent last showed activity (zero if never)
 	OnInterrupt         func()                                                                                         // called when Ctrl+C is written to the PTY
 	OnSubmit            func(text string, priority message.Priority)                                                   // called for non-normal input
 	OnDetach            func()                                                                                         // called when user selects detach from menu
@@ -101,11 +106,66 @@ type Client struct {
 	KeybindingMode KeybindingMode
 	KittyKeyboard  bool // true if kitty keyboard protocol is active
 
+	// MenuKey and PassthroughExitKey replace Ctrl+\ as the control byte
+	// that opens the menu and the one that exits passthrough
+	// (terminal.menu_key and terminal.passthrough_exit_key in config.yaml).
+	// Zero keeps Ctrl+\.
+	MenuKey            byte
+	PassthroughExitKey byte
+
+	// OSC52Copy enables h2-managed drag selection that copies to the system
+	// clipboard via OSC 52 (terminal.osc52_copy in config.yaml). When false,
+	// clicks show the "hold shift to select" hint instead.
+	OSC52Copy bool
+
+	// SelectHintText and SelectHintDuration override the hint's text and
+	// how long it stays up (terminal.select_hint and
+	// terminal.select_hint_duration in config.yaml). Empty/zero keep
+	// DefaultSelectHint and DefaultSelectHintDuration.
+	SelectHintText     string
+	SelectHintDuration time.Duration
+
+	// Optional status-bar sections (terminal.status_clock and
+	// terminal.status_idle_timer in config.yaml).
+	ShowClock     bool
+	ShowIdleTimer bool
+
+	// Highlights are regex highlight rules applied to unstyled output text
+	// in both the live and scroll views (terminal.highlights in config.yaml).
+	Highlights []HighlightRule
+
+	// Theme styles the status bar per mode (terminal.theme in config.yaml).
+	// Nil uses DefaultBarTheme.
+	Theme *BarTheme
+
 	// HoveredURL is the URL of the cell the mouse is currently over (or "").
 	// Set by motion mouse events (?1003h); read by the renderer to apply
 	// an underline overlay on cells whose URL matches, giving the user a
 	// visual affordance that the cell is clickable.
 	HoveredURL string
+
+	// selection is the current OSC 52 drag selection (nil when none).
+	selection *mouseSelection
+
+	// scrollbarDragging is set while the left button, pressed on the
+	// scroll-mode scrollbar or mini-map, is held.
+	scrollbarDragging bool
+
+	// MinimapVisible shows the mini-map in place of the scrollbar while in
+	// scroll mode. Toggled with 'm' and kept across scroll sessions.
+	MinimapVisible bool
+	// WrapLines soft-wraps lines wider than the terminal in the scroll view
+	// instead of cutting them off. ScrollOffset then counts display rows.
+	WrapLines bool
+
+	// ExportDir is where the menu's scrollback export writes HTML files
+	// (the session dir). Empty uses the system temp dir.
+	ExportDir string
+
+	// notice is a transient message shown at the top right of the screen
+	// (e.g. where an export was written); noticeTimer clears it.
+	notice      string
+	noticeTimer *time.Timer
 }
 
 // InitClient initializes per-client state. Called by Session after creating
@@ -7,11 +7,13 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
 	"unicode/utf8"
 
+	"github.com/mattn/go-runewidth"
 	"github.com/vito/midterm"
 
 	"h2/internal/session/agent/monitor"
@@ -36,6 +38,7 @@ func (c *Client) RenderScreen() {
 		c.renderLiveView(&buf)
 	}
 	c.renderSelectHint(&buf)
+	c.renderNotice(&buf)
 	buf.WriteString("\0338")       // DECRC: restore cursor position
 	buf.WriteString("\033[?2026l") // end synchronized update
 	c.OutputMu.Lock()
@@ -48,12 +51,15 @@ func (c *Client) renderSelectHint(buf *bytes.Buffer) {
 	if !c.SelectHint {
 		return
 	}
-	hint := "(hold shift to select)"
+	hint := c.SelectHintText
+	if hint == "" {
+		hint = DefaultSelectHint
+	}
 	row := 1
 	if c.IsScrollMode() {
 		row = 2
 	}
-	col := c.VT.Cols - len(hint) + 1
+	col := c.VT.Cols - runewidth.StringWidth(hint) + 1
 	if col < 1 {
 		col = 1
 	}
@@ -79,6 +85,10 @@ func (c *Client) renderLiveView(buf *bytes.Buffer) {
 
 // renderScrollView renders the scrollback buffer at the current ScrollOffset.
 func (c *Client) renderScrollView(buf *bytes.Buffer) {
+	if _, ok := c.scrollLineCount(); ok && c.WrapLines && c.VT.Cols > 0 {
+		c.renderScrollViewWrapped(buf)
+		return
+	}
 	// Prefer ScrollHistory (captured via midterm OnScrollback) when available.
 	// This is populated for apps that use scroll regions (e.g. codex inline viewport).
 	if c.hasScrollHistory() {
@@ -92,23 +102,58 @@ func (c *Client) renderScrollView(buf *bytes.Buffer) {
 		return
 	}
 	bottom := c.scrollbackScrollBottom()
-	startRow := bottom - c.VT.ChildRows + 1 - c.ScrollOffset
+	markers := c.scrollbackMarkers(bottom)
+	totalRows := bottom + 1 + len(markers)
+	startRow := totalRows - c.VT.ChildRows - c.ScrollOffset
 	if startRow < 0 {
 		startRow = 0
 	}
-	rows := collectVTRows(sb, startRow, c.VT.ChildRows)
+	rows := make([][]rune, c.VT.ChildRows)
+	for i := range rows {
+		if row, m := scrollbackRowAt(startRow+i, markers); m < 0 && row < len(sb.Content) {
+			rows[i] = sb.Content[row]
+		}
+	}
 	spans := detectURLSpans(rows, c.VT.Cols)
 	for i := 0; i < c.VT.ChildRows; i++ {
 		fmt.Fprintf(buf, "\033[%d;1H", i+1)
-		row := startRow + i
-		if row >= 0 && row < len(sb.Content) {
+		row, m := scrollbackRowAt(startRow+i, markers)
+		if m >= 0 {
+			c.renderHistoryEntry(buf, virtualterminal.MarkerEntry(markers[m].Label, c.VT.Cols), nil)
+		} else if row >= 0 && row < len(sb.Content) {
 			c.RenderLineFrom(buf, sb, row, spans[i])
 		}
 		buf.WriteString("\033[0m\033[K")
 	}
+	c.renderScrollGutter(buf)
 	c.renderScrollIndicator(buf)
 }
 
+// scrollbackMarkers returns the VT's ScrollbackMarkers that sit at or above
+// the Scrollback row bottom.
+func (c *Client) scrollbackMarkers(bottom int) []virtualterminal.ScrollMarker {
+	ms := c.VT.ScrollbackMarkers
+	n := sort.Search(len(ms), func(i int) bool { return ms[i].Row > bottom })
+	return ms[:n]
+}
+
+// scrollbackRowAt maps a row of the Scrollback scroll view, in which each
+// marker occupies a row of its own just above its Scrollback row, to the
+// Scrollback row shown there, or to an index into markers (m >= 0) when
+// that row is a marker.
+func scrollbackRowAt(d int, markers []virtualterminal.ScrollMarker) (row, m int) {
+	for i, mk := range markers {
+		pos := mk.Row + i
+		if d == pos {
+			return -1, i
+		}
+		if d < pos {
+			return d - i, -1
+		}
+	}
+	return d - len(markers), -1
+}
+
 // renderScrollViewHistory renders using ScrollHistory (scrolled-off lines from
 // VT.Vt's OnScrollback callback) combined with the live VT.Vt screen content.
 // The full content is: [ScrollHistory...] ++ [VT.Vt.Content rows].
@@ -155,6 +200,7 @@ func (c *Client) renderScrollViewHistory(buf *bytes.Buffer) {
 		}
 		buf.WriteString("\033[0m\033[K")
 	}
+	c.renderScrollGutter(buf)
 	c.renderScrollIndicator(buf)
 }
 
@@ -211,19 +257,26 @@ func (c *Client) renderHistoryEntry(buf *bytes.Buffer, entry virtualterminal.Scr
 		pos = end
 	}
 	overlayAutoSpans(urls, autoSpans, n)
+	hl := highlightCells(entry.Content[:n], formats, c.Highlights)
 
 	var lastFormat midterm.Format
-	var lastURL string
+	var lastURL, lastHL string
 	hover := c.HoveredURL
 	for i := 0; i < n; i++ {
 		f := formats[i]
 		if hover != "" && urls[i] == hover {
 			f.SetUnderline(true)
 		}
-		if f != lastFormat {
+		var cellHL string
+		if hl != nil {
+			cellHL = hl[i]
+		}
+		if f != lastFormat || cellHL != lastHL {
 			buf.WriteString("\033[0m")
 			buf.WriteString(f.Render())
+			writeHighlightSGR(buf, cellHL)
 			lastFormat = f
+			lastHL = cellHL
 		}
 		if urls[i] != lastURL {
 			writeOSC8BoundaryStr(buf, lastURL, urls[i])
@@ -307,7 +360,11 @@ func (c *Client) renderScrollIndicator(buf *bytes.Buffer) {
 			c.VT.ScrollRegionUsed,
 		)
 	}
-	col := c.VT.Cols - len(indicator) + 1
+	// Leave the last column to the scrollbar, or the mini-map's columns.
+	col := c.VT.Cols - len(indicator)
+	if c.minimapShown() {
+		col -= minimapWidth - 1
+	}
 	if col < 1 {
 		col = 1
 	}
@@ -354,19 +411,33 @@ func (c *Client) RenderLineFrom(buf *bytes.Buffer, vt *midterm.Terminal, row int
 		pos = end
 	}
 	overlayAutoSpans(urls, autoSpans, cols)
+	hl := highlightCells(line, formats, c.Highlights)
 
 	var lastFormat midterm.Format
-	var lastURL string
+	var lastURL, lastHL string
 	hover := c.HoveredURL
+	sel := c.selection
+	if vt != c.VT.Vt || c.IsScrollMode() {
+		sel = nil // selections only cover the live view
+	}
 	for i := 0; i < cols; i++ {
 		f := formats[i]
 		if hover != "" && urls[i] == hover {
 			f.SetUnderline(true)
 		}
-		if f != lastFormat {
+		if sel != nil && sel.contains(row, i) {
+			f.SetReverse(!f.IsReverse())
+		}
+		var cellHL string
+		if hl != nil {
+			cellHL = hl[i]
+		}
+		if f != lastFormat || cellHL != lastHL {
 			buf.WriteString("\033[0m")
 			buf.WriteString(f.Render())
+			writeHighlightSGR(buf, cellHL)
 			lastFormat = f
+			lastHL = cellHL
 		}
 		if urls[i] != lastURL {
 			writeOSC8BoundaryStr(buf, lastURL, urls[i])
@@ -429,8 +500,10 @@ func (c *Client) RenderStatusBar() {
 
 	var style, label, right string
 	if c.VT.ChildExited {
-		style = "\033[7m\033[31m" // red inverse
+		theme := c.barTheme()
+		style = theme.Exited
 		if c.IsScrollMode() {
+			style = theme.ExitedScroll
 			label = " Scroll | " + c.exitMessage() + " | Esc exit"
 		} else {
 			label = " " + c.exitMessage() + " | [Enter] relaunch \u00b7 [q] quit"
@@ -465,14 +538,39 @@ func (c *Client) RenderStatusBar() {
 	c.OutputMu.Unlock()
 }
 
+// statusClockNow returns the time shown by the status-bar clock. Swapped
+// out in tests.
+var statusClockNow = time.Now
+
 // fitStatusBarSections assembles the left status-bar label and the
-// right-aligned agent name, dropping sections one at a time when the bar
-// is too narrow. Drop order: tokens, help, mode, agent name, working dir.
-// The activity status is kept until nothing else fits, then hard-truncated
-// as a last resort.
+// right-aligned section (idle timer, clock, agent name), dropping sections
+// one at a time when the bar is too narrow. Drop order: clock, idle timer,
+// tokens, help, mode, agent name, working dir. The activity status is kept
+// until nothing else fits, then hard-truncated as a last resort.
 func (c *Client) fitStatusBarSections() (label, right string) {
+	var idle, clock, name string
+	if c.ShowIdleTimer && c.LastActivity != nil {
+		if at := c.LastActivity(); !at.IsZero() {
+			idle = "idle " + virtualterminal.FormatIdleDuration(time.Since(at))
+		}
+	}
+	if c.ShowClock {
+		clock = statusClockNow().Format("15:04")
+	}
 	if c.AgentName != "" {
-		right = c.AgentName + " "
+		name = c.AgentName
+	}
+	joinRight := func() string {
+		var parts []string
+		for _, part := range []string{idle, clock, name} {
+			if part != "" {
+				parts = append(parts, part)
+			}
+		}
+		if len(parts) == 0 {
+			return ""
+		}
+		return strings.Join(parts, " | ") + " "
 	}
 
 	mode := c.ModeStatusLabel()
@@ -512,19 +610,19 @@ func (c *Client) fitStatusBarSections() (label, right string) {
 		return b.String()
 	}
 
-	drops := []*string{&tokens, &help, &mode, &right, &wd}
+	drops := []*string{&clock, &idle, &tokens, &help, &mode, &name, &wd}
 	if c.Mode == ModeMenu {
 		// The menu items are the whole bar — keep them and drop help,
 		// then the agent name.
-		drops = []*string{&help, &right}
+		drops = []*string{&clock, &idle, &help, &name}
 	}
-	label = join()
+	label, right = join(), joinRight()
 	for _, drop := range drops {
 		if len(label)+len(right) <= c.VT.Cols {
 			return label, right
 		}
 		*drop = ""
-		label = join()
+		label, right = join(), joinRight()
 	}
 	if len(label) > c.VT.Cols {
 		label = label[:c.VT.Cols]
@@ -640,8 +738,14 @@ func (c *Client) ModeLabel() string {
 	case ModeMenu:
 		return c.MenuLabel()
 	case ModeScroll:
+		if c.ScrollFollow {
+			return "Scroll (follow)"
+		}
 		return "Scroll"
 	case ModePassthroughScroll:
+		if c.ScrollFollow {
+			return "Scroll (PT, follow)"
+		}
 		return "Scroll (PT)"
 	default:
 		return "Normal"
@@ -661,17 +765,46 @@ func (c *Client) ModeStatusLabel() string {
 	return label
 }
 
+// BarTheme holds the status bar's ANSI style for each mode.
+type BarTheme struct {
+	Normal       string
+	Passthrough  string // passthrough and passthrough scroll
+	Menu         string
+	Scroll       string
+	Exited       string // the child has exited
+	ExitedScroll string // scroll mode after the child exited
+}
+
+// DefaultBarTheme is the built-in status bar theme.
+var DefaultBarTheme = BarTheme{
+	Normal:       "\033[7m\033[36m", // cyan inverse
+	Passthrough:  "\033[7m\033[33m", // yellow inverse
+	Menu:         "\033[7m\033[34m", // blue inverse
+	Scroll:       "\033[7m\033[36m", // cyan inverse
+	Exited:       "\033[7m\033[31m", // red inverse
+	ExitedScroll: "\033[7m\033[31m", // red inverse
+}
+
+// barTheme returns the client's status bar theme, or the default.
+func (c *Client) barTheme() *BarTheme {
+	if c.Theme != nil {
+		return c.Theme
+	}
+	return &DefaultBarTheme
+}
+
 // ModeBarStyle returns the ANSI style for the current mode.
 func (c *Client) ModeBarStyle() string {
+	theme := c.barTheme()
 	switch c.Mode {
 	case ModePassthrough, ModePassthroughScroll:
-		return "\033[7m\033[33m"
+		return theme.Passthrough
 	case ModeMenu:
-		return "\033[7m\033[34m"
+		return theme.Menu
 	case ModeScroll:
-		return "\033[7m\033[36m"
+		return theme.Scroll
 	default:
-		return "\033[7m\033[36m"
+		return theme.Normal
 	}
 }
 
@@ -683,7 +816,7 @@ func (c *Client) HelpLabel() string {
 	case ModeMenu:
 		return `Ctrl+\ back | Up/Down history`
 	case ModeScroll, ModePassthroughScroll:
-		return "Scroll/Up/Down navigate | Esc exit scroll"
+		return "Scroll/Up/Down navigate | f follow | m mini-map | w wrap | y copy | Esc exit scroll"
 	default:
 		return c.keybindingHelp().NormalMode
 	}
@@ -782,6 +915,7 @@ func (c *Client) MenuLabel() string {
 	} else {
 		items = "Menu | p:passthrough | c:clear | r:redraw"
 	}
+	items += " | e:export"
 	if c.OnDetach != nil {
 		items += " | d:detach"
 	}
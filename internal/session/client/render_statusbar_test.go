@@ -3,6 +3,7 @@ package client
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"h2/internal/session/agent/monitor"
 )
@@ -149,7 +150,7 @@ func TestFitStatusBarSections_SweepInvariants(t *testing.T) {
 }
 
 func TestFitStatusBarSections_MenuModeDropsHelpAndAgentKeepsMenuItems(t *testing.T) {
-	menuLabel := " Menu | p:passthrough | c:clear | r:redraw | q:quit"
+	menuLabel := " Menu | p:passthrough | c:clear | r:redraw | e:export | q:quit"
 	o := newStatusBarTestClient(t, len(menuLabel)+5)
 	o.Mode = ModeMenu
 	label, right := o.fitStatusBarSections()
@@ -172,3 +173,54 @@ func TestFitStatusBarSections_MenuModeTruncatesMenuItemsAsLastResort(t *testing.
 		t.Fatalf("right = %q, want empty", right)
 	}
 }
+
+// newClockTestClient extends the full status-bar client with the clock
+// (pinned to 09:30) and an idle timer showing two minutes.
+func newClockTestClient(t *testing.T, cols int) *Client {
+	t.Helper()
+	orig := statusClockNow
+	statusClockNow = func() time.Time { return time.Date(2026, 1, 2, 9, 30, 0, 0, time.Local) }
+	t.Cleanup(func() { statusClockNow = orig })
+
+	o := newStatusBarTestClient(t, cols)
+	o.ShowClock = true
+	o.ShowIdleTimer = true
+	lastActivity := time.Now().Add(-2*time.Minute - time.Second)
+	o.LastActivity = func() time.Time { return lastActivity }
+	return o
+}
+
+func TestFitStatusBarSections_ClockAndIdleTimer(t *testing.T) {
+	_, _, full, _, _, _, _, right := statusBarParts()
+	withClock := "idle 2m | 09:30 | " + right
+	withoutClock := "idle 2m | " + right
+
+	o := newClockTestClient(t, len(full)+len(withClock))
+	label, gotRight := o.fitStatusBarSections()
+	if label != full || gotRight != withClock {
+		t.Fatalf("got %q + %q, want %q + %q", label, gotRight, full, withClock)
+	}
+
+	// One column short: the clock goes first, everything else stays.
+	o = newClockTestClient(t, len(full)+len(withClock)-1)
+	label, gotRight = o.fitStatusBarSections()
+	if label != full || gotRight != withoutClock {
+		t.Fatalf("got %q + %q, want %q + %q", label, gotRight, full, withoutClock)
+	}
+
+	// Then the idle timer, before any of the left-hand sections.
+	o = newClockTestClient(t, len(full)+len(withoutClock)-1)
+	label, gotRight = o.fitStatusBarSections()
+	if label != full || gotRight != right {
+		t.Fatalf("got %q + %q, want %q + %q", label, gotRight, full, right)
+	}
+}
+
+func TestFitStatusBarSections_IdleTimerHiddenWithoutActivity(t *testing.T) {
+	o := newStatusBarTestClient(t, 200)
+	o.ShowIdleTimer = true
+	o.LastActivity = func() time.Time { return time.Time{} }
+	if _, right := o.fitStatusBarSections(); strings.Contains(right, "idle") {
+		t.Fatalf("right = %q, expected no idle timer before any activity", right)
+	}
+}
@@ -2,6 +2,7 @@ package client
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"os"
 	"strings"
@@ -506,6 +507,47 @@ func TestRenderSelectHint_ScrollMode(t *testing.T) {
 	}
 }
 
+func TestRenderSelectHint_CustomText(t *testing.T) {
+	for _, mode := range []InputMode{ModeNormal, ModeScroll} {
+		o := newTestClient(10, 80)
+		o.Mode = mode
+		o.SelectHint = true
+		o.SelectHintText = "(按住 Option 选择)"
+		var buf bytes.Buffer
+		o.renderSelectHint(&buf)
+
+		// 18 columns: each CJK character is two columns wide.
+		row := 1
+		if mode == ModeScroll {
+			row = 2
+		}
+		want := fmt.Sprintf("\033[%d;63H\033[7m(按住 Option 选择)", row)
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("mode %d: output %q missing %q", mode, buf.String(), want)
+		}
+	}
+}
+
+func TestShowSelectHint_CustomDuration(t *testing.T) {
+	o := newTestClient(10, 80)
+	o.SelectHintDuration = 10 * time.Millisecond
+	o.HandleSGRMouse([]byte("<0;5;5"), true)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		o.VT.Mu.Lock()
+		shown := o.SelectHint
+		o.VT.Mu.Unlock()
+		if !shown {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("select hint did not clear after its duration")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
 func TestRenderSelectHint_NotShownWhenFalse(t *testing.T) {
 	o := newTestClient(10, 80)
 	o.SelectHint = false
@@ -825,7 +867,7 @@ func TestHelpLabel_Scroll(t *testing.T) {
 	o := newTestClient(10, 80)
 	o.Mode = ModeScroll
 	got := o.HelpLabel()
-	if got != "Scroll/Up/Down navigate | Esc exit scroll" {
+	if got != "Scroll/Up/Down navigate | f follow | m mini-map | w wrap | y copy | Esc exit scroll" {
 		t.Fatalf("unexpected help label: %q", got)
 	}
 }
@@ -886,7 +928,7 @@ func TestExitedScrollMode_BarStaysRed(t *testing.T) {
 	// ModeBarStyle returns cyan for scroll, but ChildExited overrides to red.
 	// We verify the bar rendering path uses the red style by checking that
 	// the label includes "Scroll" and the exit message.
-	// (The actual ANSI color is hardcoded in the render code, not in ModeBarStyle.)
+	// (The exited colors come from the bar theme, not from ModeBarStyle.)
 
 	// Verify the mode label still says Scroll.
 	if got := o.ModeLabel(); got != "Scroll" {
@@ -1097,6 +1139,63 @@ func TestCtrlBackslash_EntersMenuWithInput(t *testing.T) {
 	}
 }
 
+func TestMenuKey_RemappedKeyEntersAndExitsMenu(t *testing.T) {
+	o := newTestClient(10, 80)
+	o.MenuKey = 0x07 // ctrl+g
+	o.HandleDefaultBytes([]byte{0x07}, 0, 1)
+	if o.Mode != ModeMenu {
+		t.Fatalf("expected ModeMenu, got %d", o.Mode)
+	}
+	o.HandleMenuBytes([]byte{0x07}, 0, 1)
+	if o.Mode != ModeNormal {
+		t.Fatalf("expected ModeNormal after second ctrl+g, got %d", o.Mode)
+	}
+}
+
+func TestMenuKey_RemappedKittyKeyEntersMenu(t *testing.T) {
+	o := newTestClient(10, 80)
+	o.MenuKey = 0x07
+	buf := []byte("\x1b[103;5u") // kitty ctrl+g
+	o.HandleDefaultBytes(buf, 0, len(buf))
+	if o.Mode != ModeMenu {
+		t.Fatalf("expected ModeMenu, got %d", o.Mode)
+	}
+}
+
+func TestMenuKey_RemappedCtrlBackslashPassesThrough(t *testing.T) {
+	o, r := newTestClientWithPTY(10, 80)
+	defer r.Close()
+	defer o.VT.Ptm.Close()
+	o.MenuKey = 0x07
+	o.HandleDefaultBytes([]byte{0x1C}, 0, 1)
+	if o.Mode != ModeNormal {
+		t.Fatalf("expected ModeNormal, got %d", o.Mode)
+	}
+	got := make([]byte, 1)
+	if _, err := r.Read(got); err != nil || got[0] != 0x1C {
+		t.Fatalf("expected ctrl+\\ forwarded to child, got %q (err %v)", got, err)
+	}
+}
+
+func TestPassthroughExitKey_Remapped(t *testing.T) {
+	o := newTestClient(10, 80)
+	o.PassthroughExitKey = 0x1D // ctrl+]
+	o.Mode = ModePassthrough
+	o.HandlePassthroughBytes([]byte{0x1D}, 0, 1)
+	if o.Mode != ModeNormal {
+		t.Fatalf("expected ModeNormal, got %d", o.Mode)
+	}
+}
+
+func TestKeybindingHelp_ShowsRemappedKeys(t *testing.T) {
+	o := newTestClient(10, 80)
+	o.MenuKey = 0x07
+	h := o.keybindingHelp()
+	if h.NormalMode != "Enter send | Ctrl+G menu" || h.PassthroughMode != `Ctrl+\ exit` {
+		t.Fatalf("help = %+v", h)
+	}
+}
+
 func TestCtrlPN_PassedThroughInNormalMode(t *testing.T) {
 	// Ctrl+P and Ctrl+N no longer navigate history — they pass through to PTY.
 	// Without a real PTY, we just verify they don't trigger history navigation.
@@ -1145,6 +1244,82 @@ func TestCtrlEnterCSI_NoOpInPassthrough(t *testing.T) {
 	}
 }
 
+// --- Bracketed paste in passthrough ---
+
+func TestPassthrough_BracketedPasteIgnoresExitShortcuts(t *testing.T) {
+	c, r := newTestClientWithPTY(10, 80)
+	defer r.Close()
+	defer c.VT.Ptm.Close()
+	c.Mode = ModePassthrough
+
+	paste := "\x1b[200~a\x1cb\x1b[27;5uc\nd\x1b[201~"
+	buf := []byte(paste)
+	c.HandlePassthroughBytes(buf, 0, len(buf))
+	if c.Mode != ModePassthrough {
+		t.Fatalf("expected ModePassthrough after paste, got %d", c.Mode)
+	}
+	if c.InBracketedPaste {
+		t.Fatal("expected paste state cleared after end marker")
+	}
+
+	out := make([]byte, 256)
+	n, _ := r.Read(out)
+	if got := string(out[:n]); got != paste {
+		t.Fatalf("expected paste forwarded verbatim %q, got %q", paste, got)
+	}
+
+	// Outside the paste, Ctrl+\ exits passthrough again.
+	c.HandlePassthroughBytes([]byte{0x1C}, 0, 1)
+	if c.Mode != ModeNormal {
+		t.Fatalf("expected ModeNormal after Ctrl+\\, got %d", c.Mode)
+	}
+}
+
+func TestPassthrough_BracketedPasteSpansReadBuffers(t *testing.T) {
+	c, r := newTestClientWithPTY(10, 80)
+	defer r.Close()
+	defer c.VT.Ptm.Close()
+	c.Mode = ModePassthrough
+
+	// The end marker is split across buffers, with Ctrl+\ in between chunks.
+	chunks := []string{"\x1b[200~one", "\x1ctwo\x1b[2", "01~"}
+	for i, chunk := range chunks {
+		buf := []byte(chunk)
+		c.HandlePassthroughBytes(buf, 0, len(buf))
+		if c.Mode != ModePassthrough {
+			t.Fatalf("chunk %d: expected ModePassthrough, got %d", i, c.Mode)
+		}
+		if wantIn := i < len(chunks)-1; c.InBracketedPaste != wantIn {
+			t.Fatalf("chunk %d: InBracketedPaste = %v, want %v", i, c.InBracketedPaste, wantIn)
+		}
+	}
+
+	c.HandlePassthroughBytes([]byte{0x1C}, 0, 1)
+	if c.Mode != ModeNormal {
+		t.Fatalf("expected ModeNormal after paste ended, got %d", c.Mode)
+	}
+}
+
+func TestPassthrough_BracketedPasteIdleTimeoutRestoresExit(t *testing.T) {
+	c, r := newTestClientWithPTY(10, 80)
+	defer r.Close()
+	defer c.VT.Ptm.Close()
+	c.Mode = ModePassthrough
+
+	buf := []byte("\x1b[200~partial")
+	c.HandlePassthroughBytes(buf, 0, len(buf))
+	if !c.InBracketedPaste {
+		t.Fatal("expected InBracketedPaste after start marker")
+	}
+
+	// Simulate a lost end marker.
+	c.lastPasteInput = time.Now().Add(-2 * pasteIdleTimeout)
+	c.HandlePassthroughBytes([]byte{0x1C}, 0, 1)
+	if c.Mode != ModeNormal {
+		t.Fatalf("expected ModeNormal after stale paste, got %d", c.Mode)
+	}
+}
+
 func TestUpDown_PassedThroughInNormalMode(t *testing.T) {
 	// Up/down arrows in normal mode now pass through to the PTY.
 	// We can't verify the PTY write without a real PTY, but we verify
@@ -1452,7 +1627,7 @@ func TestMenu_DetachIgnoredWithoutCallback(t *testing.T) {
 func TestMenuLabel(t *testing.T) {
 	o := newTestClient(10, 80)
 	got := o.MenuLabel()
-	if got != "Menu | p:passthrough | c:clear | r:redraw | q:quit" {
+	if got != "Menu | p:passthrough | c:clear | r:redraw | e:export | q:quit" {
 		t.Fatalf("unexpected menu label: %q", got)
 	}
 }
@@ -1461,7 +1636,7 @@ func TestMenuLabel_WithDetach(t *testing.T) {
 	o := newTestClient(10, 80)
 	o.OnDetach = func() {}
 	got := o.MenuLabel()
-	if got != "Menu | p:passthrough | c:clear | r:redraw | d:detach | q:quit" {
+	if got != "Menu | p:passthrough | c:clear | r:redraw | e:export | d:detach | q:quit" {
 		t.Fatalf("unexpected menu label: %q", got)
 	}
 }
@@ -1791,6 +1966,45 @@ func TestModeBarStyle_Scroll_Cyan(t *testing.T) {
 	}
 }
 
+func TestModeBarStyle_Theme(t *testing.T) {
+	o := newTestClient(10, 80)
+	theme := DefaultBarTheme
+	theme.Scroll = "\033[0;7;34m"
+	o.Theme = &theme
+
+	o.Mode = ModeScroll
+	if got := o.ModeBarStyle(); got != "\033[0;7;34m" {
+		t.Fatalf("scroll style = %q, want themed style", got)
+	}
+	o.Mode = ModePassthrough
+	if got := o.ModeBarStyle(); got != DefaultBarTheme.Passthrough {
+		t.Fatalf("passthrough style = %q, want default %q", got, DefaultBarTheme.Passthrough)
+	}
+}
+
+func TestRenderBar_ExitedThemes(t *testing.T) {
+	o := newTestClient(10, 80)
+	theme := DefaultBarTheme
+	theme.Exited = "\033[0;7;35m"
+	theme.ExitedScroll = "\033[0;7;32m"
+	o.Theme = &theme
+	o.VT.ChildExited = true
+
+	var out bytes.Buffer
+	o.Output = &out
+	o.RenderBar()
+	if !strings.Contains(out.String(), theme.Exited) {
+		t.Fatalf("exited bar should use the exited style, got %q", out.String())
+	}
+
+	out.Reset()
+	o.Mode = ModeScroll
+	o.RenderBar()
+	if !strings.Contains(out.String(), theme.ExitedScroll) || strings.Contains(out.String(), theme.Exited) {
+		t.Fatalf("exited scroll bar should use the exited_scroll style, got %q", out.String())
+	}
+}
+
 func TestModeLabel_PassthroughScroll(t *testing.T) {
 	o := newTestClient(10, 80)
 	o.Mode = ModePassthroughScroll
@@ -1803,7 +2017,7 @@ func TestHelpLabel_PassthroughScroll(t *testing.T) {
 	o := newTestClient(10, 80)
 	o.Mode = ModePassthroughScroll
 	got := o.HelpLabel()
-	if got != "Scroll/Up/Down navigate | Esc exit scroll" {
+	if got != "Scroll/Up/Down navigate | f follow | m mini-map | w wrap | y copy | Esc exit scroll" {
 		t.Fatalf("unexpected help label: %q", got)
 	}
 }
@@ -1912,6 +2126,31 @@ func TestScrollMaxOffset_WithScrollHistory(t *testing.T) {
 	}
 }
 
+func TestScrollMaxOffset_RestoredHistoryWithoutScrollRegion(t *testing.T) {
+	o := newTestClient(10, 80)
+	// Restored history is used even before the new child sets a scroll
+	// region, and counts once alongside newly captured lines.
+	o.VT.RestoreScrollHistory([]virtualterminal.ScrollHistoryEntry{
+		historyEntry("old 1"), historyEntry("old 2"), historyEntry("old 3"),
+	})
+	o.VT.ScrollHistory = append(o.VT.ScrollHistory, historyEntry("new"))
+	if !o.hasScrollHistory() {
+		t.Fatal("expected restored history to be used for scrollback")
+	}
+	maxOff, ok := o.scrollMaxOffset()
+	if !ok || maxOff != 4 {
+		t.Fatalf("expected maxOffset 4, got %d (ok=%v)", maxOff, ok)
+	}
+
+	o.EnterScrollMode()
+	o.ScrollOffset = maxOff
+	var buf bytes.Buffer
+	o.renderScrollViewHistory(&buf)
+	if !strings.Contains(buf.String(), "old 1") {
+		t.Fatalf("expected oldest restored line at the top, got %q", buf.String())
+	}
+}
+
 func TestScrollHistoryAnchor_FrozenInScrollMode(t *testing.T) {
 	o := newTestClient(10, 80)
 	o.VT.ScrollRegionUsed = true
@@ -2306,3 +2545,280 @@ func TestHandleSGRMouse_AltScrollInPassthrough(t *testing.T) {
 		t.Fatalf("expected PTY output %q, got %q", want, got)
 	}
 }
+
+// --- Scrollback marker rows ---
+
+func TestScrollbackRowAt_InterleavesMarkers(t *testing.T) {
+	markers := []virtualterminal.ScrollMarker{{Row: 1, Label: "a"}, {Row: 3, Label: "b"}}
+	// Display: row0, [a], row1, row2, [b], row3, row4
+	want := []struct{ row, m int }{{0, -1}, {-1, 0}, {1, -1}, {2, -1}, {-1, 1}, {3, -1}, {4, -1}}
+	for d, w := range want {
+		row, m := scrollbackRowAt(d, markers)
+		if row != w.row || m != w.m {
+			t.Errorf("scrollbackRowAt(%d) = (%d, %d), want (%d, %d)", d, row, m, w.row, w.m)
+		}
+	}
+}
+
+func TestRenderScrollView_ShowsScrollbackMarker(t *testing.T) {
+	o := newTestClient(3, 20)
+	o.VT.Scrollback.Write([]byte("one\r\ntwo\r\nthree"))
+	o.VT.AddMarker(virtualterminal.InterruptMarkerLabel) // above "three"
+	o.VT.Scrollback.Write([]byte("\r\nfour"))
+
+	o.EnterScrollMode()
+	maxOffset, _ := o.scrollMaxOffset()
+	if maxOffset != 2 { // 4 rows + 1 marker - 3 visible
+		t.Fatalf("maxOffset = %d, want 2", maxOffset)
+	}
+	o.ScrollOffset = 1
+
+	var buf bytes.Buffer
+	o.renderScrollView(&buf)
+	out := buf.String()
+	iTwo := strings.Index(out, "two")
+	iMarker := strings.Index(out, " interrupt ")
+	iThree := strings.Index(out, "three")
+	if iTwo < 0 || iMarker < 0 || iThree < 0 || !(iTwo < iMarker && iMarker < iThree) {
+		t.Fatalf("want two, marker, three in order; got %q", out)
+	}
+}
+
+// --- scrollbar ---
+
+func newScrollbarTestClient(t *testing.T) *Client {
+	t.Helper()
+	o := newTestClient(10, 80)
+	for i := 0; i < 50; i++ {
+		o.VT.Scrollback.Write([]byte("line\r\n"))
+	}
+	return o
+}
+
+func TestScrollbarThumb_TracksOffset(t *testing.T) {
+	o := newScrollbarTestClient(t)
+	o.EnterScrollMode()
+	maxOffset, _ := o.scrollMaxOffset()
+
+	top, size, ok := o.scrollbarThumb()
+	if !ok || top+size != o.VT.ChildRows {
+		t.Fatalf("at offset 0 thumb should touch the bottom: top=%d size=%d ok=%v", top, size, ok)
+	}
+	o.ScrollOffset = maxOffset
+	if top, _, _ := o.scrollbarThumb(); top != 0 {
+		t.Fatalf("at max offset thumb top = %d, want 0", top)
+	}
+}
+
+func TestScrollbarThumb_NothingToScroll(t *testing.T) {
+	o := newTestClient(10, 80)
+	if _, _, ok := o.scrollbarThumb(); ok {
+		t.Fatal("expected no scrollbar without history")
+	}
+}
+
+func TestScrollbarClick_EntersScrollModeAndJumps(t *testing.T) {
+	o := newScrollbarTestClient(t)
+	maxOffset, _ := o.scrollMaxOffset()
+
+	o.HandleSGRMouse([]byte("<0;80;1"), true) // press, top of scrollbar
+	if o.Mode != ModeScroll {
+		t.Fatalf("expected ModeScroll, got %d", o.Mode)
+	}
+	if o.ScrollOffset != maxOffset {
+		t.Fatalf("ScrollOffset = %d, want %d", o.ScrollOffset, maxOffset)
+	}
+	if o.SelectHint {
+		t.Fatal("scrollbar click should not show the select hint")
+	}
+
+	o.HandleSGRMouse([]byte("<32;80;10"), true) // drag to bottom
+	if o.ScrollOffset != 0 || o.Mode != ModeScroll {
+		t.Fatalf("drag to bottom: offset=%d mode=%d, want 0 and ModeScroll", o.ScrollOffset, o.Mode)
+	}
+	o.HandleSGRMouse([]byte("<0;80;10"), false)
+	if o.scrollbarDragging {
+		t.Fatal("release should end the drag")
+	}
+}
+
+func TestScrollbarClick_OtherColumnFallsThrough(t *testing.T) {
+	o := newScrollbarTestClient(t)
+	o.HandleSGRMouse([]byte("<0;40;1"), true)
+	if o.Mode != ModeNormal || !o.SelectHint {
+		t.Fatalf("expected select hint in normal mode, got mode=%d hint=%v", o.Mode, o.SelectHint)
+	}
+}
+
+func TestRenderScrollView_DrawsScrollbar(t *testing.T) {
+	o := newScrollbarTestClient(t)
+	o.EnterScrollMode()
+	var buf bytes.Buffer
+	o.renderScrollView(&buf)
+	if !strings.Contains(buf.String(), "\033[10;80H\033[0m┃") {
+		t.Fatalf("expected thumb on the bottom row of the last column, got %q", buf.String())
+	}
+}
+
+func TestToggleMinimap_NoOpWithoutHistory(t *testing.T) {
+	o := newTestClient(10, 80)
+	o.EnterScrollMode()
+	o.HandleScrollBytes([]byte("m"), 0, 1)
+	if o.MinimapVisible {
+		t.Fatal("mini-map should not toggle on without history")
+	}
+}
+
+func TestMinimap_RendersDensityAndViewport(t *testing.T) {
+	o := newTestClient(10, 80)
+	for i := 0; i < 45; i++ {
+		o.VT.Scrollback.Write([]byte("line\r\n"))
+	}
+	for i := 0; i < 5; i++ {
+		o.VT.Scrollback.Write([]byte(strings.Repeat("#", 80) + "\r\n"))
+	}
+	o.EnterScrollMode()
+	o.HandleScrollBytes([]byte("m"), 0, 1)
+	if !o.MinimapVisible {
+		t.Fatal("expected m to show the mini-map")
+	}
+
+	var buf bytes.Buffer
+	o.renderScrollView(&buf)
+	out := buf.String()
+	// Dense bottom slice is in view: highlighted and heavily shaded.
+	if !strings.Contains(out, "\033[10;73H\033[0;100m▓▓▓▓▓▓▓▓") {
+		t.Errorf("expected highlighted dense bottom row, got %q", out)
+	}
+	// Top slice ("line" text) is sparser, out of view and only in the
+	// first downsampled column.
+	if !strings.Contains(out, "\033[1;73H\033[0;2m▒       ") {
+		t.Errorf("expected faint sparse top row, got %q", out)
+	}
+	if strings.Contains(out, "┃") {
+		t.Error("mini-map should replace the scrollbar")
+	}
+}
+
+func TestMinimapClick_JumpsToSlice(t *testing.T) {
+	o := newScrollbarTestClient(t)
+	o.EnterScrollMode()
+	o.MinimapVisible = true
+	maxOffset, _ := o.scrollMaxOffset()
+
+	o.HandleSGRMouse([]byte("<0;75;1"), true) // top row of the mini-map
+	if o.ScrollOffset != maxOffset {
+		t.Fatalf("click on top row: ScrollOffset = %d, want %d", o.ScrollOffset, maxOffset)
+	}
+	o.HandleSGRMouse([]byte("<32;60;10"), true) // drag off the map to the bottom
+	if o.ScrollOffset != 0 {
+		t.Fatalf("drag to bottom row: ScrollOffset = %d, want 0", o.ScrollOffset)
+	}
+	o.HandleSGRMouse([]byte("<0;60;10"), false)
+	if o.scrollbarDragging {
+		t.Fatal("release should end the drag")
+	}
+
+	// Outside scroll mode the mini-map isn't drawn and clicks fall through.
+	o.ExitScrollMode()
+	o.HandleSGRMouse([]byte("<0;75;1"), true)
+	if o.ScrollOffset != 0 || o.Mode != ModeNormal {
+		t.Fatalf("click outside scroll mode should not scroll: offset=%d mode=%d", o.ScrollOffset, o.Mode)
+	}
+}
+
+// --- Line wrapping ---
+
+// newWrapTestClient returns a scroll-region client 10 columns wide whose
+// ScrollHistory holds lines, in scroll mode.
+func newWrapTestClient(lines ...string) *Client {
+	o := newTestClient(3, 10)
+	o.VT.ScrollRegionUsed = true
+	for _, l := range lines {
+		o.VT.ScrollHistory = append(o.VT.ScrollHistory, historyEntry(l))
+	}
+	o.EnterScrollMode()
+	return o
+}
+
+func TestWrap_MaxOffsetCountsWrappedRows(t *testing.T) {
+	o := newWrapTestClient("short", "ABCDEFGHIJKLMNOPQRSTUVWXY", "end")
+
+	if got, _ := o.scrollMaxOffset(); got != 3 {
+		t.Fatalf("unwrapped maxOffset = %d, want 3", got)
+	}
+	o.WrapLines = true
+	// 1 + 3 + 1 history rows, plus the 3-row live screen, minus one screen.
+	if got, _ := o.scrollMaxOffset(); got != 5 {
+		t.Fatalf("wrapped maxOffset = %d, want 5", got)
+	}
+}
+
+func TestWrap_RendersContinuationRows(t *testing.T) {
+	o := newWrapTestClient("ABCDEFGHIJKLMNOPQRSTUVWXY", "end")
+	var out bytes.Buffer
+	o.Output = &out
+	o.WrapLines = true
+	o.ScrollOffset = 4 // long line at the top of the view
+	o.RenderScreen()
+
+	got := out.String()
+	for _, want := range []string{"ABCDEFGHIJ", "KLMNOPQRST", "UVWXY"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in wrapped output, got %q", want, got)
+		}
+	}
+	if strings.Contains(got, "end") {
+		t.Errorf("expected the next line to be pushed below the view, got %q", got)
+	}
+}
+
+func TestToggleWrap_KeepsTopLine(t *testing.T) {
+	var lines []string
+	for i := 0; i < 20; i++ {
+		lines = append(lines, fmt.Sprintf("line-%02d-%s", i, strings.Repeat("x", i%3*10)))
+	}
+	o := newWrapTestClient(lines...)
+	// Unwrapped, 23 rows of content: an offset of 10 puts line 10 on top.
+	o.ScrollOffset = 10
+
+	o.ToggleWrap()
+	layout := o.scrollLayout()
+	top := layout.rows - o.VT.ChildRows - o.ScrollOffset
+	if line, seg := layout.at(top); line != 10 || seg != 0 {
+		t.Fatalf("after wrapping, top is line %d segment %d, want line 10", line, seg)
+	}
+
+	o.ToggleWrap()
+	if o.ScrollOffset != 10 {
+		t.Fatalf("after unwrapping, ScrollOffset = %d, want 10", o.ScrollOffset)
+	}
+}
+
+func TestToggleWrap_StaysAtBottom(t *testing.T) {
+	o := newWrapTestClient("ABCDEFGHIJKLMNOPQRSTUVWXY", "end")
+	o.ToggleWrap()
+	if !o.WrapLines || o.ScrollOffset != 0 {
+		t.Fatalf("WrapLines = %v, ScrollOffset = %d; want wrapping at the bottom", o.WrapLines, o.ScrollOffset)
+	}
+}
+
+func TestEntrySegment_SplitsRuns(t *testing.T) {
+	bold := midterm.Format{}
+	bold.SetBold(true)
+	entry := virtualterminal.ScrollHistoryEntry{
+		Content: []rune("aaaabbbbbbcc"),
+		Runs: []virtualterminal.FormatRun{
+			{Size: 4},
+			{Size: 6, Format: bold},
+			{Size: 2},
+		},
+	}
+	seg := entrySegment(entry, 2, 8)
+	if string(seg.Content) != "aabbbb" {
+		t.Fatalf("content = %q, want %q", string(seg.Content), "aabbbb")
+	}
+	if len(seg.Runs) != 2 || seg.Runs[0].Size != 2 || seg.Runs[1].Size != 4 || !seg.Runs[1].Format.IsBold() {
+		t.Fatalf("runs = %+v, want [2 plain, 4 bold]", seg.Runs)
+	}
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// scrollbarThumb returns the first row (0-indexed) and height of the
+// scrollbar thumb for the current ScrollOffset. The thumb is sized by the
+// fraction of the content that fits on screen, with a one-row minimum. ok is
+// false when there is nothing to scroll.
+func (c *Client) scrollbarThumb() (top, size int, ok bool) {
+	maxOffset, ok := c.scrollMaxOffset()
+	rows := c.VT.ChildRows
+	if !ok || maxOffset <= 0 || rows <= 0 {
+		return 0, 0, false
+	}
+	size = rows * rows / (maxOffset + rows)
+	if size < 1 {
+		size = 1
+	}
+	offset := min(max(c.ScrollOffset, 0), maxOffset)
+	top = (rows - size) * (maxOffset - offset) / maxOffset
+	return top, size, true
+}
+
+// renderScrollbar draws the scrollbar on the rightmost column of the child
+// area: a faint track with the thumb marking the visible window.
+func (c *Client) renderScrollbar(buf *bytes.Buffer) {
+	top, size, ok := c.scrollbarThumb()
+	if !ok || c.VT.Cols <= 0 {
+		return
+	}
+	for row := 0; row < c.VT.ChildRows; row++ {
+		fmt.Fprintf(buf, "\033[%d;%dH", row+1, c.VT.Cols)
+		if row >= top && row < top+size {
+			buf.WriteString("\033[0m┃")
+		} else {
+			buf.WriteString("\033[0;2m│\033[0m")
+		}
+	}
+}
+
+// scrollbarRow returns the 1-indexed child row of an SGR mouse event on the
+// scrollbar column, or false if the event is elsewhere. The column only acts
+// as a scrollbar when there is history to scroll and, outside scroll mode,
+// when the child isn't handling the wheel itself.
+func (c *Client) scrollbarRow(cxStr, cyStr string) (int, bool) {
+	cx, err1 := strconv.Atoi(cxStr)
+	cy, err2 := strconv.Atoi(cyStr)
+	if err1 != nil || err2 != nil || c.VT == nil || c.VT.Cols <= 0 {
+		return 0, false
+	}
+	if cx != c.VT.Cols || cy < 1 || cy > c.VT.ChildRows {
+		return 0, false
+	}
+	if !c.IsScrollMode() && c.VT.AltScrollEnabled {
+		return 0, false
+	}
+	if maxOffset, ok := c.scrollMaxOffset(); !ok || maxOffset <= 0 {
+		return 0, false
+	}
+	return cy, true
+}
+
+// scrollToScrollbarRow sets ScrollOffset proportionally to a scrollbar row:
+// the top row shows the oldest content, the bottom row the newest. Stays in
+// scroll mode at offset 0 so a drag can come back up.
+func (c *Client) scrollToScrollbarRow(row int) {
+	maxOffset, _ := c.scrollMaxOffset()
+	rows := c.VT.ChildRows
+	if rows <= 1 {
+		c.ScrollOffset = 0
+	} else {
+		c.ScrollOffset = maxOffset * (rows - row) / (rows - 1)
+	}
+	c.ClampScrollOffset()
+	c.RenderScreen()
+	c.RenderBar()
+}
+
+// handleScrollbarMouse handles left-button press, drag and release on the
+// scrollbar column. A press outside scroll mode enters it first. Returns
+// true if the event was consumed.
+func (c *Client) handleScrollbarMouse(button int, cxStr, cyStr string, press bool) bool {
+	switch {
+	case button == 0 && press:
+		row, ok := c.scrollbarRow(cxStr, cyStr)
+		if !ok {
+			return false
+		}
+		c.scrollbarDragging = true
+		if !c.IsScrollMode() {
+			c.EnterScrollMode()
+		}
+		c.scrollToScrollbarRow(row)
+		return true
+	case button == 32 && c.scrollbarDragging:
+		// Left-button drag: follow the pointer's row even once it leaves
+		// the scrollbar column.
+		cy, err := strconv.Atoi(cyStr)
+		if err != nil {
+			return true
+		}
+		c.scrollToScrollbarRow(min(max(cy, 1), c.VT.ChildRows))
+		return true
+	case button == 0 && !press && c.scrollbarDragging:
+		c.scrollbarDragging = false
+		return true
+	}
+	return false
+}
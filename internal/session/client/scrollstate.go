@@ -0,0 +1,49 @@
+package client
+
+// ScrollState is the scroll position a client had when it detached, kept by
+// the session so the next attach can pick up where it left off.
+type ScrollState struct {
+	Offset        int  // ScrollOffset
+	AnchorY       int  // ScrollAnchorY
+	HistoryAnchor int  // ScrollHistoryAnchor
+	Follow        bool // ScrollFollow
+	WrapLines     bool // WrapLines; Offset counts wrapped rows when set
+}
+
+// SaveScrollState returns the client's scroll position, or nil when it is
+// not in scroll mode.
+func (c *Client) SaveScrollState() *ScrollState {
+	if !c.IsScrollMode() {
+		return nil
+	}
+	return &ScrollState{
+		Offset:        c.ScrollOffset,
+		AnchorY:       c.ScrollAnchorY,
+		HistoryAnchor: c.ScrollHistoryAnchor,
+		Follow:        c.ScrollFollow,
+		WrapLines:     c.WrapLines,
+	}
+}
+
+// RestoreScrollState puts the client back in scroll mode at st. Passthrough
+// ownership doesn't survive a detach, so a client that left from
+// passthrough scroll comes back in plain scroll mode. The output may have
+// grown or been truncated in the meantime, so the anchors are capped to
+// what still exists and the offset is clamped; a client that was following
+// live output picks up at the live bottom. Does not render.
+func (c *Client) RestoreScrollState(st *ScrollState) {
+	if st == nil || c.VT == nil {
+		return
+	}
+	c.ScrollAnchorY = min(st.AnchorY, c.scrollbackBottomRow())
+	c.ScrollHistoryAnchor = min(st.HistoryAnchor, len(c.VT.ScrollHistory))
+	c.setMode(ModeScroll)
+	c.WrapLines = st.WrapLines
+	c.ScrollOffset = st.Offset
+	c.ScrollFollow = st.Follow
+	if c.ScrollFollow {
+		c.ScrollOffset = 0
+		c.followLive()
+	}
+	c.ClampScrollOffset()
+}
@@ -0,0 +1,117 @@
+package client
+
+import "testing"
+
+func TestScrollState_RoundTrip(t *testing.T) {
+	o := newTestClient(10, 80)
+	for i := 0; i < 50; i++ {
+		o.VT.Scrollback.Write([]byte("line\n"))
+	}
+	o.EnterScrollMode()
+	o.ScrollUp(15)
+	st := o.SaveScrollState()
+	if st == nil {
+		t.Fatal("expected scroll state in scroll mode")
+	}
+
+	// More output arrives while detached; the restored view stays put.
+	for i := 0; i < 5; i++ {
+		o.VT.Scrollback.Write([]byte("more\n"))
+	}
+	re := newTestClient(10, 80)
+	re.VT = o.VT
+	re.RestoreScrollState(st)
+	if re.Mode != ModeScroll {
+		t.Fatalf("expected ModeScroll, got %d", re.Mode)
+	}
+	if re.ScrollOffset != 15 || re.ScrollAnchorY != o.ScrollAnchorY {
+		t.Fatalf("restored offset %d anchor %d, want 15 and %d", re.ScrollOffset, re.ScrollAnchorY, o.ScrollAnchorY)
+	}
+}
+
+func TestScrollState_RestoresWrapLines(t *testing.T) {
+	o := newTestClient(10, 20)
+	for i := 0; i < 30; i++ {
+		o.VT.Scrollback.Write([]byte("a line wider than the twenty column terminal\r\n"))
+	}
+	o.EnterScrollMode()
+	o.ToggleWrap()
+	o.ScrollUp(40) // only reachable with wrapped rows
+	st := o.SaveScrollState()
+
+	re := newTestClient(10, 20)
+	re.VT = o.VT
+	re.RestoreScrollState(st)
+	if !re.WrapLines {
+		t.Fatal("expected WrapLines restored")
+	}
+	if re.ScrollOffset != o.ScrollOffset {
+		t.Fatalf("restored offset %d, want %d", re.ScrollOffset, o.ScrollOffset)
+	}
+}
+
+func TestScrollState_NotScrolled(t *testing.T) {
+	o := newTestClient(10, 80)
+	if st := o.SaveScrollState(); st != nil {
+		t.Fatalf("expected nil state outside scroll mode, got %+v", st)
+	}
+	o.RestoreScrollState(nil)
+	if o.Mode != ModeNormal {
+		t.Fatalf("expected ModeNormal, got %d", o.Mode)
+	}
+}
+
+func TestScrollState_PassthroughScrollRestoresAsScroll(t *testing.T) {
+	o := newTestClient(10, 80)
+	o.Mode = ModePassthrough
+	o.EnterScrollMode()
+	re := newTestClient(10, 80)
+	re.RestoreScrollState(o.SaveScrollState())
+	if re.Mode != ModeScroll {
+		t.Fatalf("expected ModeScroll, got %d", re.Mode)
+	}
+}
+
+func TestScrollState_ClampsToTruncatedOutput(t *testing.T) {
+	o := newTestClient(10, 80)
+	for i := 0; i < 50; i++ {
+		o.VT.Scrollback.Write([]byte("line\n"))
+	}
+	o.EnterScrollMode()
+	o.ScrollUp(30)
+	st := o.SaveScrollState()
+
+	// The scrollback was reset while detached and holds far less now.
+	re := newTestClient(10, 80)
+	for i := 0; i < 15; i++ {
+		re.VT.Scrollback.Write([]byte("line\n"))
+	}
+	re.RestoreScrollState(st)
+	if re.ScrollAnchorY > re.scrollbackBottomRow() {
+		t.Fatalf("anchor %d beyond bottom row %d", re.ScrollAnchorY, re.scrollbackBottomRow())
+	}
+	maxOffset, _ := re.scrollMaxOffset()
+	if re.ScrollOffset > maxOffset {
+		t.Fatalf("offset %d beyond max %d", re.ScrollOffset, maxOffset)
+	}
+}
+
+func TestScrollState_FollowRestoresAtLiveBottom(t *testing.T) {
+	o := newTestClient(10, 80)
+	for i := 0; i < 50; i++ {
+		o.VT.Scrollback.Write([]byte("line\n"))
+	}
+	o.EnterScrollMode()
+	o.ToggleFollow()
+	st := o.SaveScrollState()
+
+	for i := 0; i < 5; i++ {
+		o.VT.Scrollback.Write([]byte("more\n"))
+	}
+	re := newTestClient(10, 80)
+	re.VT = o.VT
+	re.RestoreScrollState(st)
+	if !re.ScrollFollow || re.ScrollOffset != 0 || re.ScrollAnchorY != re.scrollbackBottomRow() {
+		t.Fatalf("follow=%v offset=%d anchor=%d, want following at %d", re.ScrollFollow, re.ScrollOffset, re.ScrollAnchorY, re.scrollbackBottomRow())
+	}
+}
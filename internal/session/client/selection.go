@@ -0,0 +1,150 @@
+package client
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+)
+
+// cellPos is a cell in the live VT's Content, 0-indexed.
+type cellPos struct {
+	row, col int
+}
+
+// before reports whether p comes before q in reading order.
+func (p cellPos) before(q cellPos) bool {
+	return p.row < q.row || (p.row == q.row && p.col < q.col)
+}
+
+// mouseSelection is a drag selection over the live view. Positions are in
+// VT content coordinates (not screen rows) so the highlight stays on the
+// same text while the view is redrawn.
+type mouseSelection struct {
+	anchor   cellPos // where the drag started
+	head     cellPos // current drag position
+	dragging bool    // left button still held
+}
+
+// bounds returns the selection's start and end cells in reading order.
+func (s *mouseSelection) bounds() (start, end cellPos) {
+	if s.head.before(s.anchor) {
+		return s.head, s.anchor
+	}
+	return s.anchor, s.head
+}
+
+// contains reports whether the given cell is inside the selection.
+func (s *mouseSelection) contains(row, col int) bool {
+	start, end := s.bounds()
+	p := cellPos{row, col}
+	return !p.before(start) && !end.before(p)
+}
+
+// liveCellAt converts 1-indexed SGR mouse coordinates to a cell in the live
+// VT. Returns false for coordinates outside the child's rows.
+func (c *Client) liveCellAt(cxStr, cyStr string) (cellPos, bool) {
+	cx, err1 := strconv.Atoi(cxStr)
+	cy, err2 := strconv.Atoi(cyStr)
+	if err1 != nil || err2 != nil || c.VT == nil || c.VT.Vt == nil {
+		return cellPos{}, false
+	}
+	termRow, col := cy-1, cx-1
+	if termRow < 0 || termRow >= c.VT.ChildRows || col < 0 {
+		return cellPos{}, false
+	}
+	startRow := c.VT.Vt.Cursor.Y - c.VT.ChildRows + 1
+	if startRow < 0 {
+		startRow = 0
+	}
+	return cellPos{row: startRow + termRow, col: col}, true
+}
+
+// beginSelection starts a drag selection at the given SGR coordinates,
+// replacing any previous selection.
+func (c *Client) beginSelection(cxStr, cyStr string) {
+	pos, ok := c.liveCellAt(cxStr, cyStr)
+	if !ok {
+		return
+	}
+	c.selection = &mouseSelection{anchor: pos, head: pos, dragging: true}
+	c.RenderScreen()
+}
+
+// extendSelection moves the head of an in-progress drag selection.
+func (c *Client) extendSelection(cxStr, cyStr string) {
+	pos, ok := c.liveCellAt(cxStr, cyStr)
+	if !ok || c.selection == nil || c.selection.head == pos {
+		return
+	}
+	c.selection.head = pos
+	c.RenderScreen()
+}
+
+// finishSelection ends a drag and copies the selected text to the system
+// clipboard. A click without a drag just clears the selection.
+func (c *Client) finishSelection(cxStr, cyStr string) {
+	sel := c.selection
+	if sel == nil || !sel.dragging {
+		return
+	}
+	if pos, ok := c.liveCellAt(cxStr, cyStr); ok {
+		sel.head = pos
+	}
+	sel.dragging = false
+	if sel.anchor == sel.head {
+		c.clearSelection()
+		return
+	}
+	if text := c.selectedText(); text != "" {
+		c.writeOSC52(text)
+	}
+	c.RenderScreen()
+}
+
+// clearSelection drops the current selection highlight, if any.
+func (c *Client) clearSelection() {
+	if c.selection == nil {
+		return
+	}
+	c.selection = nil
+	c.RenderScreen()
+}
+
+// selectedText extracts the selected cells from the live VT. Trailing blanks
+// on each row are trimmed and rows are joined with newlines.
+func (c *Client) selectedText() string {
+	if c.selection == nil || c.VT == nil || c.VT.Vt == nil {
+		return ""
+	}
+	start, end := c.selection.bounds()
+	content := c.VT.Vt.Content
+	var lines []string
+	for row := start.row; row <= end.row; row++ {
+		if row < 0 || row >= len(content) {
+			continue
+		}
+		line := content[row]
+		from, to := 0, len(line)
+		if row == start.row {
+			from = start.col
+		}
+		if row == end.row && end.col+1 < to {
+			to = end.col + 1
+		}
+		if from > to {
+			from = to
+		}
+		lines = append(lines, strings.TrimRight(string(line[from:to]), " "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// writeOSC52 asks the outer terminal to set the system clipboard. OSC 52
+// travels over the same stream as the rendered output, so it works over ssh
+// as long as the host terminal supports it.
+func (c *Client) writeOSC52(text string) {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\x1b\\"
+	c.OutputMu.Lock()
+	c.Output.Write([]byte(seq))
+	c.OutputMu.Unlock()
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func newSelectionTestClient(t *testing.T) (*Client, *bytes.Buffer) {
+	t.Helper()
+	c := newTestClient(5, 20)
+	c.VT.Vt.Write([]byte("hello world\r\nsecond line"))
+	var out bytes.Buffer
+	c.Output = &out
+	c.OSC52Copy = true
+	return c, &out
+}
+
+func TestSelection_DragCopiesViaOSC52(t *testing.T) {
+	c, out := newSelectionTestClient(t)
+
+	c.HandleSGRMouse([]byte("<0;7;1"), true)  // press on "w"
+	c.HandleSGRMouse([]byte("<32;6;2"), true) // drag to "d" on row 2
+	c.HandleSGRMouse([]byte("<0;6;2"), false) // release
+	if c.SelectHint {
+		t.Fatal("select hint should not show when OSC 52 copy is enabled")
+	}
+
+	want := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte("world\nsecond")) + "\x1b\\"
+	if !strings.Contains(out.String(), want) {
+		t.Fatalf("expected OSC 52 sequence %q in output, got %q", want, out.String())
+	}
+}
+
+func TestSelection_ReverseDragSelectsSameText(t *testing.T) {
+	c, _ := newSelectionTestClient(t)
+	c.HandleSGRMouse([]byte("<0;6;2"), true)
+	c.HandleSGRMouse([]byte("<32;7;1"), true)
+	if got := c.selectedText(); got != "world\nsecond" {
+		t.Fatalf("selectedText = %q, want %q", got, "world\nsecond")
+	}
+}
+
+func TestSelection_ClickWithoutDragClears(t *testing.T) {
+	c, out := newSelectionTestClient(t)
+	c.HandleSGRMouse([]byte("<0;3;1"), true)
+	c.HandleSGRMouse([]byte("<0;3;1"), false)
+	if c.selection != nil {
+		t.Fatal("expected selection to be cleared after a plain click")
+	}
+	if strings.Contains(out.String(), "\x1b]52;") {
+		t.Fatal("plain click should not touch the clipboard")
+	}
+}
+
+func TestSelection_DisabledShowsSelectHint(t *testing.T) {
+	c, out := newSelectionTestClient(t)
+	c.OSC52Copy = false
+	c.HandleSGRMouse([]byte("<0;7;1"), true)
+	if c.SelectHintTimer != nil {
+		defer c.SelectHintTimer.Stop()
+	}
+	if !c.SelectHint {
+		t.Fatal("expected select hint when OSC 52 copy is disabled")
+	}
+	if c.selection != nil {
+		t.Fatal("expected no selection when OSC 52 copy is disabled")
+	}
+	if strings.Contains(out.String(), "\x1b]52;") {
+		t.Fatal("disabled copy should not touch the clipboard")
+	}
+}
@@ -0,0 +1,211 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"h2/internal/session/virtualterminal"
+)
+
+// ToggleWrap turns soft-wrapping of long lines in the scroll view on or off.
+// The line at the top of the view stays there, unless the view is at the
+// bottom, where it stays pinned to the newest output.
+func (c *Client) ToggleWrap() {
+	if c.VT == nil {
+		return
+	}
+	before := c.scrollLayout()
+	top := max(before.rows-c.VT.ChildRows-c.ScrollOffset, 0)
+	line, _ := before.at(top)
+
+	c.WrapLines = !c.WrapLines
+	if c.ScrollOffset > 0 {
+		after := c.scrollLayout()
+		c.ScrollOffset = after.rows - c.VT.ChildRows - after.firstRow(line)
+	}
+	c.ClampScrollOffset()
+	c.RenderScreen()
+	c.RenderBar()
+}
+
+// scrollLayout maps the scroll view's lines of content to display rows.
+// Unwrapped, each line is one row and starts is nil; wrapped, a line wider
+// than the terminal takes as many rows as it needs and starts[i] is the first
+// row of line i.
+type scrollLayout struct {
+	lines  int
+	rows   int
+	starts []int
+}
+
+// at returns the line of content shown on display row, and which
+// terminal-width segment of it.
+func (l scrollLayout) at(row int) (line, seg int) {
+	if l.starts == nil {
+		return row, 0
+	}
+	line = sort.Search(len(l.starts), func(i int) bool { return l.starts[i] > row }) - 1
+	if line < 0 {
+		return 0, 0
+	}
+	return line, row - l.starts[line]
+}
+
+// firstRow returns the display row on which line starts.
+func (l scrollLayout) firstRow(line int) int {
+	if l.starts == nil || line < 0 {
+		return line
+	}
+	if line >= len(l.starts) {
+		return l.rows
+	}
+	return l.starts[line]
+}
+
+// scrollLineCount returns the number of lines of content in the scroll view:
+// ScrollHistory plus the live screen, or the Scrollback rows plus their
+// markers. ok is false when there is no scrollback to show.
+func (c *Client) scrollLineCount() (int, bool) {
+	if c.VT == nil {
+		return 0, false
+	}
+	if c.hasScrollHistory() {
+		return c.scrollHistoryLen() + c.VT.ChildRows, true
+	}
+	if c.VT.Scrollback == nil {
+		return 0, false
+	}
+	bottom := c.scrollbackScrollBottom()
+	return bottom + 1 + len(c.scrollbackMarkers(bottom)), true
+}
+
+// scrollLayout returns the current display layout of the scroll view.
+func (c *Client) scrollLayout() scrollLayout {
+	lines, _ := c.scrollLineCount()
+	if !c.WrapLines || c.VT.Cols <= 0 {
+		return scrollLayout{lines: lines, rows: lines}
+	}
+	starts := make([]int, lines)
+	rows := 0
+	for i := range starts {
+		starts[i] = rows
+		rows += wrappedRows(c.scrollLineRunes(i), c.VT.Cols)
+	}
+	return scrollLayout{lines: lines, rows: rows, starts: starts}
+}
+
+// wrappedRows returns how many rows of cols cells content needs, ignoring
+// trailing blank cells. Every line takes at least one row.
+func wrappedRows(content []rune, cols int) int {
+	n := len(content)
+	for n > 0 && (content[n-1] == ' ' || content[n-1] == 0) {
+		n--
+	}
+	return max((n+cols-1)/cols, 1)
+}
+
+// scrollLineRunes returns line i of the scroll view's content, or nil for
+// marker rows and lines that are out of range.
+func (c *Client) scrollLineRunes(i int) []rune {
+	if c.hasScrollHistory() {
+		histLen := c.scrollHistoryLen()
+		if i < histLen {
+			return c.VT.ScrollHistory[i].Content
+		}
+		if vtRow := i - histLen; vtRow < len(c.VT.Vt.Content) {
+			return c.VT.Vt.Content[vtRow]
+		}
+		return nil
+	}
+	sb := c.VT.Scrollback
+	if sb == nil {
+		return nil
+	}
+	row, m := scrollbackRowAt(i, c.scrollbackMarkers(c.scrollbackScrollBottom()))
+	if m >= 0 || row < 0 || row >= len(sb.Content) {
+		return nil
+	}
+	return sb.Content[row]
+}
+
+// scrollLineEntry returns line i of the scroll view's content with its
+// formatting. Markers render as their labelled rule.
+func (c *Client) scrollLineEntry(i int) virtualterminal.ScrollHistoryEntry {
+	if c.hasScrollHistory() {
+		histLen := c.scrollHistoryLen()
+		if i < histLen {
+			return c.VT.ScrollHistory[i]
+		}
+		if vtRow := i - histLen; vtRow < len(c.VT.Vt.Content) {
+			return virtualterminal.TerminalRowEntry(c.VT.Vt, vtRow)
+		}
+		return virtualterminal.ScrollHistoryEntry{}
+	}
+	sb := c.VT.Scrollback
+	markers := c.scrollbackMarkers(c.scrollbackScrollBottom())
+	row, m := scrollbackRowAt(i, markers)
+	if m >= 0 {
+		return virtualterminal.MarkerEntry(markers[m].Label, c.VT.Cols)
+	}
+	if sb == nil || row < 0 || row >= len(sb.Content) {
+		return virtualterminal.ScrollHistoryEntry{}
+	}
+	return virtualterminal.TerminalRowEntry(sb, row)
+}
+
+// entrySegment returns cells [start, end) of entry, with its format runs cut
+// to match.
+func entrySegment(entry virtualterminal.ScrollHistoryEntry, start, end int) virtualterminal.ScrollHistoryEntry {
+	end = min(end, len(entry.Content))
+	if start >= end {
+		return virtualterminal.ScrollHistoryEntry{}
+	}
+	seg := virtualterminal.ScrollHistoryEntry{Content: entry.Content[start:end]}
+	pos := 0
+	for _, run := range entry.Runs {
+		runStart, runEnd := max(pos, start), min(pos+run.Size, end)
+		pos += run.Size
+		if runEnd > runStart {
+			run.Size = runEnd - runStart
+			seg.Runs = append(seg.Runs, run)
+		}
+		if pos >= end {
+			break
+		}
+	}
+	return seg
+}
+
+// renderScrollViewWrapped renders the scroll view with long lines wrapped
+// onto the following rows instead of cut off at the terminal width.
+func (c *Client) renderScrollViewWrapped(buf *bytes.Buffer) {
+	layout := c.scrollLayout()
+	cols := c.VT.Cols
+	startRow := max(layout.rows-c.VT.ChildRows-c.ScrollOffset, 0)
+
+	segs := make([]virtualterminal.ScrollHistoryEntry, c.VT.ChildRows)
+	rows := make([][]rune, c.VT.ChildRows)
+	cached, entry := -1, virtualterminal.ScrollHistoryEntry{}
+	for i := range segs {
+		row := startRow + i
+		if row >= layout.rows {
+			break
+		}
+		line, seg := layout.at(row)
+		if line != cached {
+			cached, entry = line, c.scrollLineEntry(line)
+		}
+		segs[i] = entrySegment(entry, seg*cols, (seg+1)*cols)
+		rows[i] = segs[i].Content
+	}
+	spans := detectURLSpans(rows, cols)
+
+	for i, seg := range segs {
+		fmt.Fprintf(buf, "\033[%d;1H", i+1)
+		c.renderHistoryEntry(buf, seg, spans[i])
+		buf.WriteString("\033[0m\033[K")
+	}
+	c.renderScrollGutter(buf)
+	c.renderScrollIndicator(buf)
+}
@@ -26,6 +26,8 @@ type Daemon struct {
 	StartTime      time.Time
 	TriggerEngine  *automation.TriggerEngine
 	ScheduleEngine *automation.ScheduleEngine
+
+	outbox outbox // recent bridge messages, for resend
 }
 
 // sessionEnqueuer adapts a Session's MessageQueue to the automation.MessageEnqueuer interface.
@@ -66,6 +68,19 @@ func RunDaemon(sessionDir string, rc *config.RuntimeConfig, resume bool) error {
 
 	s.StartTime = time.Now()
 	s.SessionDir = sessionDir
+	s.resumed = resume
+
+	// Terminal UI, message and activity log settings are best-effort: a
+	// broken config.yaml shouldn't keep the agent from starting.
+	s.logRotation = activityLogRotation(nil)
+	if cfg, err := config.Load(); err != nil {
+		log.Printf("warning: load config: %v", err)
+	} else {
+		s.SetTerminalConfig(cfg.Terminal)
+		s.logRotation = activityLogRotation(cfg.ActivityLog)
+		s.InlineMessageLength = cfg.InlineMessageLength()
+		s.monitor.SetIdleDebounce(cfg.IdleDebounce())
+	}
 
 	// Track whether NativeLogPathSuffix has been persisted to disk.
 	// PrepareForLaunch sets it in memory but the initial WriteRuntimeConfig
@@ -77,6 +92,8 @@ func RunDaemon(sessionDir string, rc *config.RuntimeConfig, resume bool) error {
 	// (e.g. Codex reports its conversation ID async) OR when NativeLogPathSuffix
 	// was set by PrepareForLaunch but not yet persisted to disk.
 	s.monitor.SetOnSessionStarted(func(data monitor.SessionStartedData) {
+		s.rcMu.Lock()
+		defer s.rcMu.Unlock()
 		needsWrite := false
 		if data.SessionID != "" && data.SessionID != rc.HarnessSessionID {
 			rc.HarnessSessionID = data.SessionID
@@ -179,6 +196,19 @@ func RunDaemon(sessionDir string, rc *config.RuntimeConfig, resume bool) error {
 		return fmt.Errorf("create socket dir: %w", err)
 	}
 
+	// Link the role's skills_dir into the session dir for as long as the
+	// agent runs. A resumed session links it again.
+	if rc.SkillsDir != "" && sessionDir != "" {
+		if err := config.LinkSessionSkills(sessionDir, rc.SkillsDir); err != nil {
+			return err
+		}
+		defer func() {
+			if err := config.UnlinkSessionSkills(sessionDir); err != nil {
+				log.Printf("warning: unlink session skills: %v", err)
+			}
+		}()
+	}
+
 	sockPath := socketdir.Path(socketdir.TypeAgent, rc.AgentName)
 
 	if err := socketdir.ProbeSocket(sockPath, fmt.Sprintf("agent %q", rc.AgentName)); err != nil {
@@ -186,7 +216,7 @@ func RunDaemon(sessionDir string, rc *config.RuntimeConfig, resume bool) error {
 	}
 
 	// Create Unix socket.
-	ln, err := net.Listen("unix", sockPath)
+	ln, err := socketdir.Listen(sockPath)
 	if err != nil {
 		return fmt.Errorf("listen on socket: %w", err)
 	}
@@ -235,11 +265,24 @@ func RunDaemon(sessionDir string, rc *config.RuntimeConfig, resume bool) error {
 		return fmt.Errorf("load role automations: %w", err)
 	}
 
+	if err := enqueueInitialPrompt(enqueuer, rc, resume); err != nil {
+		log.Printf("warning: %v", err)
+	}
+
 	// Start automation engines.
 	automationCtx, automationCancel := context.WithCancel(context.Background())
 	go triggerEngine.Run(automationCtx, eventCh)
 	go scheduleEngine.Run(automationCtx)
 
+	// Watch the role file for changes the running agent can pick up.
+	if rc.ReloadRoleOnChange {
+		if err := d.seedRoleReloadBaseline(); err != nil {
+			log.Printf("warning: role reload disabled: %v", err)
+		} else {
+			go d.watchRole(automationCtx)
+		}
+	}
+
 	// Start socket listener.
 	go d.acceptLoop()
 
@@ -250,6 +293,20 @@ func RunDaemon(sessionDir string, rc *config.RuntimeConfig, resume bool) error {
 	return err
 }
 
+// enqueueInitialPrompt queues the role's initial prompt as the agent's first
+// message. It goes through the normal message path at idle priority, so it is
+// typed into the agent once the harness has started up, whatever the harness
+// or permission mode. Resumed sessions already had their first turn.
+func enqueueInitialPrompt(e *sessionEnqueuer, rc *config.RuntimeConfig, resume bool) error {
+	if rc.InitialPrompt == "" || resume {
+		return nil
+	}
+	if _, err := e.EnqueueMessage("h2-initial-prompt", rc.InitialPrompt, "", message.PriorityIdle); err != nil {
+		return fmt.Errorf("enqueue initial prompt: %w", err)
+	}
+	return nil
+}
+
 // ReloadAutomations clears existing role-defined triggers/schedules and
 // re-registers them from the current RuntimeConfig. Called after configRelaunch
 // to pick up changes to the session metadata.
@@ -260,10 +317,12 @@ func (d *Daemon) ReloadAutomations() error {
 }
 
 // loadRoleAutomations registers triggers and schedules from the RuntimeConfig
-// (originally defined in the role YAML). Called during daemon startup.
+// (originally defined in the role YAML). Called during daemon startup. IDs
+// generated for entries without one are recorded back in rc, so a role
+// reload can remove exactly these entries.
 func (d *Daemon) loadRoleAutomations(rc *config.RuntimeConfig) error {
 	now := time.Now()
-	for _, ts := range rc.Triggers {
+	for i, ts := range rc.Triggers {
 		t := &automation.Trigger{
 			ID:        ts.ID,
 			Name:      ts.Name,
@@ -299,9 +358,10 @@ func (d *Daemon) loadRoleAutomations(rc *config.RuntimeConfig) error {
 		if !d.TriggerEngine.Add(t) {
 			return fmt.Errorf("duplicate trigger ID %q in role config", ts.ID)
 		}
+		rc.Triggers[i].ID = t.ID
 	}
 
-	for _, ss := range rc.Schedules {
+	for i, ss := range rc.Schedules {
 		mode, _ := automation.ParseConditionMode(ss.ConditionMode)
 		s := &automation.Schedule{
 			ID:            ss.ID,
@@ -320,6 +380,7 @@ func (d *Daemon) loadRoleAutomations(rc *config.RuntimeConfig) error {
 		if err := d.ScheduleEngine.Add(s); err != nil {
 			return fmt.Errorf("register schedule %q: %w", ss.ID, err)
 		}
+		rc.Schedules[i].ID = s.ID
 	}
 	return nil
 }
@@ -330,6 +391,7 @@ func (d *Daemon) AgentInfo() *message.AgentInfo {
 	uptime := time.Since(d.StartTime)
 	st, sub := s.State()
 	activity := s.ActivitySnapshot()
+	queue := s.Queue.Snapshot()
 	var toolName string
 	if st == monitor.StateActive {
 		toolName = activity.LastToolName
@@ -347,7 +409,14 @@ func (d *Daemon) AgentInfo() *message.AgentInfo {
 		SubState:         sub.String(),
 		StateDisplayText: monitor.FormatStateLabel(st.String(), sub.String(), toolName),
 		StateDuration:    virtualterminal.FormatIdleDuration(s.StateDuration()),
-		QueuedCount:      s.Queue.PendingCount(),
+		QueuedCount:      queue.Total(),
+		QueuedInterrupt:  queue.Interrupt,
+		QueuedNormal:     queue.Normal,
+		QueuedIdleFirst:  queue.IdleFirst,
+		QueuedIdle:       queue.Idle,
+	}
+	if !queue.OldestCreatedAt.IsZero() {
+		info.OldestQueuedAt = queue.OldestCreatedAt.UTC().Format(time.RFC3339)
 	}
 	if !activity.LastActivityAt.IsZero() {
 		info.LastActivity = virtualterminal.FormatIdleDuration(time.Since(activity.LastActivityAt))
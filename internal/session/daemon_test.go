@@ -5,6 +5,7 @@ import (
 
 	"h2/internal/config"
 	"h2/internal/session/agent/harness"
+	"h2/internal/session/message"
 )
 
 func TestRuntimeConfig_FieldsStoredOnSession(t *testing.T) {
@@ -149,3 +150,44 @@ func TestRuntimeConfig_CodexFields(t *testing.T) {
 		t.Fatalf("CodexSandboxMode not stored: got %q", s.RC.CodexSandboxMode)
 	}
 }
+
+func TestEnqueueInitialPrompt(t *testing.T) {
+	setupLaunchTestH2Dir(t)
+	rc := &config.RuntimeConfig{AgentName: "test", InitialPrompt: "Start on the backlog."}
+
+	q := message.NewMessageQueue()
+	if err := enqueueInitialPrompt(&sessionEnqueuer{queue: q, agentName: rc.AgentName}, rc, false); err != nil {
+		t.Fatalf("enqueueInitialPrompt: %v", err)
+	}
+	if q.Dequeue(false, false) != nil {
+		t.Fatal("initial prompt should wait for the agent to be idle")
+	}
+	msg := q.Dequeue(true, false)
+	if msg == nil {
+		t.Fatal("expected the initial prompt to be queued")
+	}
+	if msg.Body != "Start on the backlog." || msg.From != "h2-initial-prompt" || msg.Priority != message.PriorityIdle {
+		t.Errorf("queued %q from %q at %v", msg.Body, msg.From, msg.Priority)
+	}
+}
+
+func TestEnqueueInitialPrompt_SkippedOnResumeOrEmpty(t *testing.T) {
+	setupLaunchTestH2Dir(t)
+	for _, tc := range []struct {
+		name   string
+		prompt string
+		resume bool
+	}{
+		{"resume", "Start on the backlog.", true},
+		{"empty", "", false},
+	} {
+		q := message.NewMessageQueue()
+		rc := &config.RuntimeConfig{AgentName: "test", InitialPrompt: tc.prompt}
+		if err := enqueueInitialPrompt(&sessionEnqueuer{queue: q, agentName: rc.AgentName}, rc, tc.resume); err != nil {
+			t.Fatalf("%s: enqueueInitialPrompt: %v", tc.name, err)
+		}
+		if n := q.PendingCount(); n != 0 {
+			t.Errorf("%s: %d messages queued, want 0", tc.name, n)
+		}
+	}
+}
@@ -0,0 +1,414 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"h2/internal/config"
+	"h2/internal/git"
+	"h2/internal/session/agent/harness"
+	"h2/internal/socketdir"
+)
+
+// LaunchOptions configures a programmatic agent launch via LaunchAgent.
+type LaunchOptions struct {
+	Name      string   // agent name; a random name is generated when empty
+	Pod       string   // pod name (empty for standalone agents)
+	PodIndex  int      // position in the pod's agent list
+	Overrides []string // key=value overrides, recorded in the RuntimeConfig for display
+
+	// Force launches even when none of the role's profiles is usable or
+	// its harness config dir isn't authenticated.
+	Force bool
+
+	// Output, if set, gets progress notes such as the profile chosen for a
+	// role with a profiles list.
+	Output io.Writer
+
+	// InvocationCWD is the directory that "." in working_dir and
+	// additional_dirs resolves to. Defaults to the process working directory.
+	InvocationCWD string
+
+	// TerminalHints are forwarded to the daemon for color detection.
+	TerminalHints TerminalHints
+
+	// Fork starts the daemon for a prepared session dir. Defaults to ForkDaemon.
+	// Tests and embedders override this to avoid spawning real processes.
+	Fork func(sessionDir string, hints TerminalHints, resume bool) error
+}
+
+// AgentHandle refers to an agent daemon started by LaunchAgent.
+type AgentHandle struct {
+	Name       string
+	SessionDir string
+	SocketPath string
+	RC         *config.RuntimeConfig
+
+	ctx context.Context
+}
+
+// agentWaitPollInterval is how often Wait probes the agent socket.
+const agentWaitPollInterval = 500 * time.Millisecond
+
+// Wait blocks until the agent daemon stops accepting connections on its
+// socket, or until the context passed to LaunchAgent is cancelled.
+func (h *AgentHandle) Wait() error {
+	ticker := time.NewTicker(agentWaitPollInterval)
+	defer ticker.Stop()
+	for {
+		conn, err := net.Dial("unix", h.SocketPath)
+		if err != nil {
+			return nil
+		}
+		conn.Close()
+		select {
+		case <-h.ctx.Done():
+			return h.ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// LaunchAgent launches an agent daemon for the given role: it chooses the
+// role's profile and checks it is authenticated (unless opts.Force),
+// resolves the working directory (creating a worktree if configured), sets
+// up the session dir, runs the role's post_launch commands, writes the
+// RuntimeConfig, and forks the daemon. It returns once the agent socket is
+// available. The CLI commands are thin wrappers over this.
+func LaunchAgent(ctx context.Context, role *config.Role, opts LaunchOptions) (*AgentHandle, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := selectRoleProfile(role, opts.Output, opts.Force); err != nil {
+		return nil, err
+	}
+	if !opts.Force {
+		if err := checkRoleAuthenticated(role); err != nil {
+			return nil, err
+		}
+	}
+	name := opts.Name
+	if name == "" {
+		nameGen, err := LoadNameGenerator(config.NamesDir())
+		if err != nil {
+			return nil, fmt.Errorf("load agent name word lists: %w", err)
+		}
+		name = nameGen.Generate()
+	}
+	sockPath := socketdir.Path(socketdir.TypeAgent, name)
+	// The daemon would fail to listen, leaving us waiting for a socket
+	// that never appears; report the real problem now.
+	if err := socketdir.CheckPathLen(sockPath); err != nil {
+		return nil, err
+	}
+	if err := socketdir.ProbeSocket(sockPath, fmt.Sprintf("agent %q", name)); err != nil {
+		return nil, err
+	}
+
+	sessionDir, err := config.SetupSessionDir(name, role)
+	if err != nil {
+		return nil, fmt.Errorf("setup session dir: %w", err)
+	}
+
+	// Build a minimal RuntimeConfig for pre-launch harness resolution.
+	minRC := BuildRoleRuntimeConfig(role)
+
+	// Resolve harness and ensure config directories exist.
+	h, err := harness.Resolve(minRC, nil)
+	if err != nil {
+		return nil, fmt.Errorf("resolve harness: %w", err)
+	}
+	if err := ValidateHarnessConfigDirExists(role, minRC); err != nil {
+		return nil, err
+	}
+	if err := h.EnsureConfigDir(config.ConfigDir()); err != nil {
+		return nil, fmt.Errorf("ensure config dir: %w", err)
+	}
+
+	cwd := opts.InvocationCWD
+	if cwd == "" {
+		cwd, err = os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("get working directory: %w", err)
+		}
+	}
+
+	// Resolve the working directory for the agent.
+	var agentCWD string
+	worktreeCfg, err := role.BuildWorktreeConfig(cwd, name)
+	if err != nil {
+		return nil, fmt.Errorf("build worktree config: %w", err)
+	}
+	if worktreeCfg != nil {
+		// Worktree mode: create/reuse worktree, CWD = worktree path.
+		worktreePath, err := git.CreateWorktree(worktreeCfg)
+		if err != nil {
+			return nil, fmt.Errorf("create worktree: %w", err)
+		}
+		agentCWD = worktreePath
+	} else {
+		// Normal mode: resolve working_dir.
+		agentCWD, err = role.ResolveWorkingDir(cwd)
+		if err != nil {
+			return nil, fmt.Errorf("resolve working_dir: %w", err)
+		}
+		if err := role.EnsureWorkingDir(agentCWD); err != nil {
+			return nil, err
+		}
+	}
+
+	// Resolve additional dirs.
+	additionalDirs, err := role.ResolveAdditionalDirs(cwd)
+	if err != nil {
+		return nil, fmt.Errorf("resolve additional_dirs: %w", err)
+	}
+	skillsDir, err := role.ResolveSkillsDir()
+	if err != nil {
+		return nil, err
+	}
+	if skillsDir != "" {
+		additionalDirs = append(additionalDirs, config.SessionSkillsDir(sessionDir))
+	}
+
+	// Parse overrides into a map for RuntimeConfig.
+	var overrideMap map[string]string
+	if len(opts.Overrides) > 0 {
+		overrideMap, err = config.ParseOverrides(opts.Overrides)
+		if err != nil {
+			return nil, fmt.Errorf("parse overrides: %w", err)
+		}
+	}
+
+	rc := BuildLaunchRuntimeConfig(role, h, LaunchValues{
+		Name:           name,
+		SessionID:      uuid.New().String(),
+		Pod:            opts.Pod,
+		PodIndex:       opts.PodIndex,
+		CWD:            agentCWD,
+		AdditionalDirs: additionalDirs,
+		SkillsDir:      skillsDir,
+		Overrides:      overrideMap,
+		StartedAt:      time.Now().UTC().Format(time.RFC3339),
+	})
+
+	// Run setup commands in the working dir before the agent takes over.
+	if err := runPostLaunch(ctx, role.PostLaunch, rc, sessionDir); err != nil {
+		return nil, err
+	}
+
+	// Write RuntimeConfig before forking so the daemon can read it.
+	if err := config.WriteRuntimeConfig(sessionDir, rc); err != nil {
+		return nil, fmt.Errorf("write runtime config: %w", err)
+	}
+
+	fork := opts.Fork
+	if fork == nil {
+		fork = ForkDaemon
+	}
+	if err := fork(sessionDir, opts.TerminalHints, false); err != nil {
+		return nil, err
+	}
+
+	return &AgentHandle{
+		Name:       name,
+		SessionDir: sessionDir,
+		SocketPath: sockPath,
+		RC:         rc,
+		ctx:        ctx,
+	}, nil
+}
+
+// LaunchValues are the values BuildLaunchRuntimeConfig takes from the
+// launch rather than the role.
+type LaunchValues struct {
+	Name           string
+	SessionID      string
+	Pod            string
+	PodIndex       int
+	CWD            string
+	AdditionalDirs []string
+	SkillsDir      string
+	Overrides      map[string]string
+	StartedAt      string
+}
+
+// BuildLaunchRuntimeConfig builds the RuntimeConfig an agent launched from
+// role runs with, h being the role's resolved harness. LaunchAgent writes
+// it for the daemon; --dry-run builds it the same way to show the command.
+func BuildLaunchRuntimeConfig(role *config.Role, h harness.Harness, v LaunchValues) *config.RuntimeConfig {
+	minRC := BuildRoleRuntimeConfig(role)
+
+	// For Claude Code, h2 passes --session-id so HarnessSessionID equals SessionID.
+	// For other harnesses (Codex), the harness reports its own session ID async
+	// via OTEL and the daemon writes it to HarnessSessionID when received.
+	harnessSessionID := ""
+	if minRC.HarnessType == "claude_code" {
+		harnessSessionID = v.SessionID
+	}
+
+	rc := &config.RuntimeConfig{
+		AgentName:               v.Name,
+		SessionID:               v.SessionID,
+		HarnessSessionID:        harnessSessionID,
+		RoleName:                role.RoleName,
+		Pod:                     v.Pod,
+		PodIndex:                v.PodIndex,
+		HarnessType:             minRC.HarnessType,
+		HarnessConfigPathPrefix: minRC.HarnessConfigPathPrefix,
+		Profile:                 role.GetProfile(),
+		Command:                 h.Command(),
+		// Args is not set for role-based launches; the harness builds
+		// the full command args via BuildCommandArgs.
+		Model:                minRC.Model,
+		CWD:                  v.CWD,
+		Instructions:         role.GetInstructions(),
+		SystemPrompt:         role.SystemPrompt,
+		InitialPrompt:        role.InitialPrompt,
+		ClaudePermissionMode: role.ClaudePermissionMode,
+		CodexSandboxMode:     role.CodexSandboxMode,
+		CodexAskForApproval:  role.CodexAskForApproval,
+		PermissionReview:     role.PermissionReview,
+		AllowedTools:         role.AllowedTools,
+		DeniedTools:          role.DeniedTools,
+		AdditionalDirs:       v.AdditionalDirs,
+		SkillsDir:            v.SkillsDir,
+		Overrides:            v.Overrides,
+		SecretValues:         role.SecretValues(),
+		ReloadRoleOnChange:   role.ReloadOnChange,
+		RoleContext:          role.RenderContext(),
+		StartedAt:            v.StartedAt,
+	}
+
+	// Copy role-defined triggers and schedules.
+	rc.Triggers = append(rc.Triggers, role.Triggers...)
+	rc.Schedules = roleSchedules(role)
+	return rc
+}
+
+// BuildRoleRuntimeConfig builds a minimal RuntimeConfig from a Role, suitable
+// for pre-launch harness resolution (command name, config dir validation).
+// The full RuntimeConfig is built with BuildLaunchRuntimeConfig.
+func BuildRoleRuntimeConfig(role *config.Role) *config.RuntimeConfig {
+	ht := harness.CanonicalName(role.GetHarnessType())
+	command := role.GetAgentType()
+	if command == "" {
+		command = harness.DefaultCommand(ht)
+	}
+	var harnessConfigPathPrefix string
+	switch ht {
+	case "claude_code":
+		harnessConfigPathPrefix = role.GetClaudeConfigPathPrefix()
+	case "codex":
+		harnessConfigPathPrefix = role.GetCodexConfigPathPrefix()
+	}
+	return &config.RuntimeConfig{
+		HarnessType:             ht,
+		Command:                 command,
+		Model:                   role.GetModel(),
+		HarnessConfigPathPrefix: harnessConfigPathPrefix,
+		Profile:                 role.GetProfile(),
+	}
+}
+
+// HeartbeatInterval converts a Go duration string (e.g. "30s") to an RRULE
+// INTERVAL in seconds for the backwards-compatible heartbeat→schedule
+// conversion. Falls back to "30" if parsing fails.
+func HeartbeatInterval(durStr string) string {
+	d, err := time.ParseDuration(durStr)
+	if err != nil || d <= 0 {
+		return "30"
+	}
+	secs := int(d.Seconds())
+	if secs < 1 {
+		secs = 1
+	}
+	return fmt.Sprintf("%d", secs)
+}
+
+// ValidateHarnessConfigDirExists checks that the profile-derived harness config
+// directory exists. h2 does not auto-create profiles on launch.
+func ValidateHarnessConfigDirExists(role *config.Role, rc *config.RuntimeConfig) error {
+	configDir := rc.HarnessConfigDir()
+	if configDir == "" {
+		return nil
+	}
+	info, err := os.Stat(configDir)
+	if err == nil {
+		if !info.IsDir() {
+			return fmt.Errorf("harness config path is not a directory: %s", configDir)
+		}
+		return nil
+	}
+	if !os.IsNotExist(err) {
+		return fmt.Errorf("stat harness config dir %s: %w", configDir, err)
+	}
+
+	// Config dir is always derived from prefix + profile now.
+	profile := role.GetProfile()
+	return fmt.Errorf("profile %q not found (missing %s); h2 does not auto-create profiles on run, use 'h2 profile create %s' or choose an existing profile via 'h2 profile list'",
+		profile, configDir, profile)
+}
+
+// roleSchedules returns the role's schedules, preceded by its heartbeat
+// converted to a schedule (backwards compatibility).
+func roleSchedules(role *config.Role) []config.ScheduleYAMLSpec {
+	var schedules []config.ScheduleYAMLSpec
+	if hb := HeartbeatSchedule(role, time.Now()); hb != nil {
+		schedules = append(schedules, *hb)
+	}
+	return append(schedules, role.Schedules...)
+}
+
+// HeartbeatSchedule returns the role's heartbeat converted to a schedule, or
+// nil if it has none or, under pod_strategy leader, the agent isn't its pod
+// entry's leader. Under pod_strategy staggered the schedule's occurrences are
+// Index/Count of the interval after those of an occurrence grid anchored at
+// the Unix epoch, so agents of one pod entry fire in turn however far apart
+// they were launched (even on different days, when the interval doesn't
+// divide 24h), and it starts at the next one rather than firing at launch.
+func HeartbeatSchedule(role *config.Role, now time.Time) *config.ScheduleYAMLSpec {
+	hb := role.Heartbeat
+	if hb == nil || hb.IdleTimeout == "" || hb.Message == "" {
+		return nil
+	}
+	interval := HeartbeatInterval(hb.IdleTimeout)
+	spec := &config.ScheduleYAMLSpec{
+		ID:            "heartbeat",
+		Name:          "heartbeat",
+		RRule:         "FREQ=SECONDLY;INTERVAL=" + interval,
+		Condition:     hb.ConditionCommand(),
+		ConditionMode: "run_if",
+		Message:       hb.Message,
+		From:          "h2-heartbeat",
+		Priority:      "idle",
+	}
+
+	ctx := role.RenderContext()
+	if ctx == nil || ctx.PodName == "" {
+		return spec
+	}
+	switch hb.PodStrategy {
+	case config.HeartbeatPodLeader:
+		if ctx.Index != 0 {
+			return nil
+		}
+	case config.HeartbeatPodStaggered:
+		if ctx.Count > 1 {
+			secs, _ := strconv.Atoi(interval)
+			period := time.Duration(secs) * time.Second
+			start := time.Unix(0, 0).UTC().Add(period * time.Duration(ctx.Index) / time.Duration(ctx.Count))
+			if start.Before(now) {
+				start = start.Add(period * ((now.Sub(start) + period - 1) / period))
+			}
+			spec.Start = start.Format(time.RFC3339)
+		}
+	}
+	return spec
+}
@@ -0,0 +1,314 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"h2/internal/config"
+	"h2/internal/socketdir"
+	"h2/internal/tmpl"
+)
+
+func setupLaunchTestH2Dir(t *testing.T) string {
+	t.Helper()
+	h2Dir := filepath.Join(t.TempDir(), "h2")
+	for _, sub := range []string{"claude-config/default", "codex-config/default", "roles", "sessions", "sockets"} {
+		if err := os.MkdirAll(filepath.Join(h2Dir, sub), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := config.WriteMarker(h2Dir); err != nil {
+		t.Fatal(err)
+	}
+	writeClaudeAuth(t, filepath.Join(h2Dir, "claude-config", "default"))
+	if err := os.WriteFile(filepath.Join(h2Dir, "codex-config", "default", "auth.json"), []byte(`{"OPENAI_API_KEY":"sk-test"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("H2_DIR", h2Dir)
+	config.ResetResolveCache()
+	socketdir.ResetDirCache()
+	t.Cleanup(func() {
+		config.ResetResolveCache()
+		socketdir.ResetDirCache()
+	})
+	return h2Dir
+}
+
+func TestLaunchAgent_WritesRuntimeConfigAndForks(t *testing.T) {
+	setupLaunchTestH2Dir(t)
+	cwd := t.TempDir()
+	role := &config.Role{
+		RoleName:      "coder",
+		AgentHarness:  "codex",
+		AgentModel:    "gpt-5",
+		Instructions:  "Write code.",
+		InitialPrompt: "Start on the backlog.",
+		DeniedTools:   []string{"web_search"},
+		Heartbeat:     &config.HeartbeatConfig{IdleTimeout: "2m", Message: "ping"},
+	}
+
+	var forkedDir string
+	handle, err := LaunchAgent(context.Background(), role, LaunchOptions{
+		Name:          "launch-test",
+		Pod:           "pod-a",
+		PodIndex:      2,
+		InvocationCWD: cwd,
+		Fork: func(sessionDir string, hints TerminalHints, resume bool) error {
+			forkedDir = sessionDir
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("LaunchAgent: %v", err)
+	}
+	if forkedDir != handle.SessionDir {
+		t.Errorf("fork called with %q, want %q", forkedDir, handle.SessionDir)
+	}
+	if !strings.HasSuffix(handle.SocketPath, "agent.launch-test.sock") {
+		t.Errorf("SocketPath = %q", handle.SocketPath)
+	}
+
+	rc, err := config.ReadRuntimeConfig(handle.SessionDir)
+	if err != nil {
+		t.Fatalf("ReadRuntimeConfig: %v", err)
+	}
+	if rc.AgentName != "launch-test" || rc.Pod != "pod-a" || rc.PodIndex != 2 {
+		t.Errorf("identity = %q/%q/%d", rc.AgentName, rc.Pod, rc.PodIndex)
+	}
+	if rc.CWD != cwd {
+		t.Errorf("CWD = %q, want %q", rc.CWD, cwd)
+	}
+	if rc.HarnessType != "codex" || rc.Model != "gpt-5" {
+		t.Errorf("harness/model = %q/%q", rc.HarnessType, rc.Model)
+	}
+	if rc.HarnessSessionID != "" {
+		t.Errorf("HarnessSessionID should be empty for codex, got %q", rc.HarnessSessionID)
+	}
+	if rc.InitialPrompt != "Start on the backlog." {
+		t.Errorf("InitialPrompt = %q", rc.InitialPrompt)
+	}
+	if len(rc.DeniedTools) != 1 || rc.DeniedTools[0] != "web_search" {
+		t.Errorf("DeniedTools = %v", rc.DeniedTools)
+	}
+	if len(rc.Schedules) != 1 || rc.Schedules[0].RRule != "FREQ=SECONDLY;INTERVAL=120" {
+		t.Errorf("Schedules = %+v", rc.Schedules)
+	}
+}
+
+func TestLaunchAgent_MissingProfileDoesNotFork(t *testing.T) {
+	setupLaunchTestH2Dir(t)
+	role := &config.Role{RoleName: "coder", AgentHarness: "codex", Profile: "alt"}
+
+	forked := false
+	_, err := LaunchAgent(context.Background(), role, LaunchOptions{
+		Name: "launch-missing-profile",
+		Fork: func(string, TerminalHints, bool) error {
+			forked = true
+			return nil
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), `profile "alt" not found`) {
+		t.Fatalf("expected missing profile error, got %v", err)
+	}
+	if forked {
+		t.Error("fork should not be called when the profile is missing")
+	}
+}
+
+func TestLaunchAgent_GeneratesNameFromWordLists(t *testing.T) {
+	h2Dir := setupLaunchTestH2Dir(t)
+	namesDir := filepath.Join(h2Dir, "names")
+	if err := os.MkdirAll(namesDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(namesDir, AdjectivesFile), []byte("solo\n"), 0o644)
+	os.WriteFile(filepath.Join(namesDir, NounsFile), []byte("agent\n"), 0o644)
+
+	handle, err := LaunchAgent(context.Background(), &config.Role{RoleName: "coder"}, LaunchOptions{
+		InvocationCWD: t.TempDir(),
+		Fork:          func(string, TerminalHints, bool) error { return nil },
+	})
+	if err != nil {
+		t.Fatalf("LaunchAgent: %v", err)
+	}
+	if handle.Name != "solo-agent" {
+		t.Errorf("Name = %q, want solo-agent from the names dir", handle.Name)
+	}
+}
+
+func TestLaunchAgent_SocketPathTooLongDoesNotFork(t *testing.T) {
+	setupLaunchTestH2Dir(t)
+	t.Setenv(socketdir.DirEnv, filepath.Join(t.TempDir(), strings.Repeat("s", 120)))
+
+	forked := false
+	_, err := LaunchAgent(context.Background(), &config.Role{RoleName: "coder"}, LaunchOptions{
+		Name: "launch-long-socket",
+		Fork: func(string, TerminalHints, bool) error {
+			forked = true
+			return nil
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "socket path too long") {
+		t.Fatalf("expected socket path error, got %v", err)
+	}
+	if forked {
+		t.Error("fork should not be called when the socket path is too long")
+	}
+}
+
+func TestAgentHandle_WaitReturnsWhenSocketGone(t *testing.T) {
+	h := &AgentHandle{
+		SocketPath: filepath.Join(t.TempDir(), "missing.sock"),
+		ctx:        context.Background(),
+	}
+	if err := h.Wait(); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+}
+
+func TestHeartbeatInterval(t *testing.T) {
+	tests := map[string]string{
+		"30s":   "30",
+		"5m":    "300",
+		"500ms": "1",
+		"bogus": "30",
+		"-1s":   "30",
+	}
+	for in, want := range tests {
+		if got := HeartbeatInterval(in); got != want {
+			t.Errorf("HeartbeatInterval(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestHeartbeatSchedule_PodStrategy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "worker.yaml")
+	writeRole := func(strategy string) {
+		t.Helper()
+		data := "role_name: worker\nheartbeat:\n  idle_timeout: 60s\n  message: ping\n  condition: bd ready -q\n"
+		if strategy != "" {
+			data += "  pod_strategy: " + strategy + "\n"
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Date(2026, 10, 17, 9, 30, 10, 0, time.UTC)
+	heartbeat := func(ctx *tmpl.Context) *config.ScheduleYAMLSpec {
+		t.Helper()
+		role, err := config.LoadRoleRenderedFrom(path, ctx)
+		if err != nil {
+			t.Fatalf("LoadRoleRenderedFrom: %v", err)
+		}
+		return HeartbeatSchedule(role, now)
+	}
+	standalone := &tmpl.Context{AgentName: "worker"}
+	pod := func(index int) *tmpl.Context {
+		return &tmpl.Context{AgentName: fmt.Sprintf("worker-%d", index), PodName: "crew", Index: index, Count: 4}
+	}
+
+	writeRole(config.HeartbeatPodLeader)
+	if hb := heartbeat(pod(0)); hb == nil || hb.Start != "" {
+		t.Errorf("leader: got %+v, want an unchanged heartbeat", hb)
+	}
+	if hb := heartbeat(pod(2)); hb != nil {
+		t.Errorf("non-leader: got %+v, want no heartbeat", hb)
+	}
+	if hb := heartbeat(standalone); hb == nil {
+		t.Error("standalone agent should keep its heartbeat under leader")
+	}
+
+	writeRole(config.HeartbeatPodStaggered)
+	// 60s split four ways: offsets 0, 15, 30, 45s on a grid from the epoch,
+	// each starting at its next slot after 09:30:10.
+	for index, want := range []string{"09:31:00", "09:30:15", "09:30:30", "09:30:45"} {
+		hb := heartbeat(pod(index))
+		if hb == nil || hb.Start != "2026-10-17T"+want+"Z" {
+			t.Errorf("index %d: got %+v, want start %s", index, hb, want)
+		}
+	}
+	if hb := heartbeat(standalone); hb == nil || hb.Start != "" {
+		t.Errorf("standalone: got %+v, want an unchanged heartbeat", hb)
+	}
+
+	writeRole("")
+	for index := range 4 {
+		if hb := heartbeat(pod(index)); hb == nil || hb.Start != "" {
+			t.Errorf("no strategy, index %d: got %+v, want an unchanged heartbeat", index, hb)
+		}
+	}
+}
+
+func TestHeartbeatSchedule_StaggeredAcrossMidnight(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "worker.yaml")
+	data := "role_name: worker\nheartbeat:\n  idle_timeout: 7m\n  message: ping\n  pod_strategy: staggered\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	start := func(index int, now time.Time) time.Time {
+		t.Helper()
+		role, err := config.LoadRoleRenderedFrom(path, &tmpl.Context{
+			AgentName: fmt.Sprintf("worker-%d", index), PodName: "crew", Index: index, Count: 2,
+		})
+		if err != nil {
+			t.Fatalf("LoadRoleRenderedFrom: %v", err)
+		}
+		hb := HeartbeatSchedule(role, now)
+		if hb == nil || hb.Start == "" {
+			t.Fatalf("index %d: got %+v, want a staggered start", index, hb)
+		}
+		s, err := time.Parse(time.RFC3339, hb.Start)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	// 7m doesn't divide a day, so the two agents only stay half an interval
+	// apart if their grids don't restart at midnight.
+	first := start(0, time.Date(2026, 10, 16, 23, 58, 0, 0, time.UTC))
+	second := start(1, time.Date(2026, 10, 17, 0, 2, 0, 0, time.UTC))
+	if got := second.Sub(first) % (7 * time.Minute); got != 210*time.Second {
+		t.Errorf("offset between agents = %s (starts %s, %s), want 3m30s", got, first, second)
+	}
+}
+
+func TestValidateHarnessConfigDirExists_MissingProfileDerivedDir(t *testing.T) {
+	h2Dir := setupLaunchTestH2Dir(t)
+	role := &config.Role{
+		AgentHarness: "codex",
+		Profile:      "alt",
+	}
+
+	err := ValidateHarnessConfigDirExists(role, BuildRoleRuntimeConfig(role))
+	if err == nil {
+		t.Fatal("expected error for missing profile-derived config dir")
+	}
+	if !strings.Contains(err.Error(), `profile "alt" not found`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(err.Error(), filepath.Join(h2Dir, "codex-config", "alt")) {
+		t.Fatalf("error missing expected config dir path: %v", err)
+	}
+}
+
+func TestValidateHarnessConfigDirExists_ExistingProfileDerivedDir(t *testing.T) {
+	h2Dir := setupLaunchTestH2Dir(t)
+	role := &config.Role{
+		AgentHarness: "codex",
+		Profile:      "alt1",
+	}
+
+	if err := os.MkdirAll(filepath.Join(h2Dir, "codex-config", "alt1"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	err := ValidateHarnessConfigDirExists(role, BuildRoleRuntimeConfig(role))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
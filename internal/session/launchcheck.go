@@ -0,0 +1,71 @@
+package session
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"h2/internal/config"
+)
+
+// selectRoleProfile chooses the profile of a role with a profiles list,
+// reporting skipped profiles and the choice to w (if not nil). A profile
+// set explicitly is kept.
+func selectRoleProfile(role *config.Role, w io.Writer, force bool) error {
+	if len(role.Profiles) == 0 || role.HasExplicitProfile() {
+		return nil
+	}
+	skipped, err := role.SelectProfile(force)
+	if err != nil {
+		return err
+	}
+	if w != nil {
+		for _, s := range skipped {
+			fmt.Fprintf(w, "Skipping profile %q (%s)\n", s.Name, s.Reason)
+		}
+		fmt.Fprintf(w, "Using profile %q (%s over %s).\n",
+			role.GetProfile(), role.GetProfileStrategy(), strings.Join(role.Profiles, ", "))
+	}
+	return nil
+}
+
+// checkRoleAuthenticated returns an error when the role's harness config
+// dir has no stored credentials, since the agent would otherwise start and
+// sit at a login prompt. An API key in the environment also counts. Dirs
+// that don't exist are left to ValidateHarnessConfigDirExists, and a
+// Claude "~/" config prefix defers to Claude's own default location, which
+// h2 doesn't inspect.
+func checkRoleAuthenticated(role *config.Role) error {
+	var dir, login string
+	switch role.GetHarnessType() {
+	case "generic":
+		return nil
+	case "codex":
+		if os.Getenv("OPENAI_API_KEY") != "" {
+			return nil
+		}
+		dir = role.GetCodexConfigDir()
+		login = fmt.Sprintf("CODEX_HOME=%s codex login", dir)
+	default:
+		if os.Getenv("ANTHROPIC_API_KEY") != "" {
+			return nil
+		}
+		dir = role.GetClaudeConfigDir()
+		login = "h2 auth claude " + dir
+	}
+	if dir == "" {
+		return nil
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return nil
+	}
+	ok, err := role.IsRoleAuthenticated()
+	if err != nil {
+		return fmt.Errorf("check authentication of %s: %w (pass --force to launch anyway)", dir, err)
+	}
+	if !ok {
+		return fmt.Errorf("config dir %s is not authenticated; run '%s' (or pass --force to launch anyway)", dir, login)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package session
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"h2/internal/config"
+)
+
+// writeClaudeAuth marks a Claude config dir as logged in, so launches from
+// it pass checkRoleAuthenticated.
+func writeClaudeAuth(t *testing.T, dir string) {
+	t.Helper()
+	data := `{"oauthAccount":{"accountUuid":"u-1","emailAddress":"test@example.com"}}`
+	if err := os.WriteFile(filepath.Join(dir, ".claude.json"), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckRoleAuthenticated_Claude(t *testing.T) {
+	h2Dir := setupLaunchTestH2Dir(t)
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	dir := filepath.Join(h2Dir, "claude-config", "fresh")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	role := &config.Role{RoleName: "r", Profile: "fresh"}
+
+	err := checkRoleAuthenticated(role)
+	if err == nil {
+		t.Fatal("expected error for unauthenticated config dir")
+	}
+	for _, want := range []string{dir + " is not authenticated", "h2 auth claude " + dir, "--force"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q missing %q", err, want)
+		}
+	}
+
+	writeClaudeAuth(t, dir)
+	if err := checkRoleAuthenticated(role); err != nil {
+		t.Fatalf("unexpected error after auth: %v", err)
+	}
+}
+
+func TestCheckRoleAuthenticated_Skips(t *testing.T) {
+	h2Dir := setupLaunchTestH2Dir(t)
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	t.Setenv("OPENAI_API_KEY", "")
+	if err := os.MkdirAll(filepath.Join(h2Dir, "codex-config", "fresh"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]*config.Role{
+		"system default claude dir": {ClaudeCodeConfigPathPrefix: "~/"},
+		"generic harness":           {AgentHarness: "generic", AgentHarnessCommand: "true"},
+		"missing profile dir":       {Profile: "nope"},
+	}
+	for name, role := range tests {
+		if err := checkRoleAuthenticated(role); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+	}
+
+	codex := &config.Role{AgentHarness: "codex", Profile: "fresh"}
+	if err := checkRoleAuthenticated(codex); err == nil || !strings.Contains(err.Error(), "codex login") {
+		t.Fatalf("expected codex login hint, got %v", err)
+	}
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+	if err := checkRoleAuthenticated(codex); err != nil {
+		t.Fatalf("OPENAI_API_KEY should count as authenticated: %v", err)
+	}
+}
+
+func TestLaunchAgent_RefusesUnauthenticatedUnlessForced(t *testing.T) {
+	h2Dir := setupLaunchTestH2Dir(t)
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	if err := os.MkdirAll(filepath.Join(h2Dir, "claude-config", "fresh"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	launch := func(name string, force bool) (bool, error) {
+		forked := false
+		_, err := LaunchAgent(context.Background(), &config.Role{RoleName: "coder", Profile: "fresh"}, LaunchOptions{
+			Name:          name,
+			Force:         force,
+			InvocationCWD: t.TempDir(),
+			Fork: func(string, TerminalHints, bool) error {
+				forked = true
+				return nil
+			},
+		})
+		return forked, err
+	}
+
+	forked, err := launch("launch-unauthed", false)
+	if err == nil || !strings.Contains(err.Error(), "is not authenticated") {
+		t.Fatalf("expected authentication error, got %v", err)
+	}
+	if forked {
+		t.Error("fork should not be called for an unauthenticated config dir")
+	}
+	if forked, err := launch("launch-unauthed-forced", true); err != nil || !forked {
+		t.Fatalf("forced launch: forked=%v err=%v", forked, err)
+	}
+}
@@ -1,6 +1,8 @@
 package session
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -8,6 +10,7 @@ import (
 	"time"
 
 	"h2/internal/automation"
+	"h2/internal/session/agent/monitor"
 	"h2/internal/session/message"
 )
 
@@ -53,6 +56,8 @@ func (d *Daemon) handleConn(conn net.Conn) {
 		d.handleHookEvent(conn, req)
 	case "stop":
 		d.handleStop(conn)
+	case "graceful_stop":
+		d.handleGracefulStop(conn, req)
 	case "relaunch":
 		d.handleRelaunch(conn, req)
 	case "trigger_add":
@@ -67,10 +72,12 @@ func (d *Daemon) handleConn(conn net.Conn) {
 		d.handleScheduleList(conn)
 	case "schedule_remove":
 		d.handleScheduleRemove(conn, req)
+	case "outbox_add":
+		d.handleOutboxAdd(conn, req)
+	case "resend":
+		d.handleResend(conn, req)
 	default:
-		message.SendResponse(conn, &message.Response{
-			Error: "unknown request type: " + req.Type,
-		})
+		message.SendResponse(conn, message.ErrorResponse(message.ErrCodeInvalidRequest, "unknown request type: "+req.Type))
 		conn.Close()
 	}
 }
@@ -80,7 +87,18 @@ func (d *Daemon) handleSend(conn net.Conn, req *message.Request) {
 
 	s := d.Session
 
+	if err := message.CheckBodySize(req.Body); err != nil {
+		message.SendResponse(conn, message.ErrorResponse(message.ErrCodeTooLarge, err.Error()))
+		return
+	}
+
 	if req.Raw {
+		// Attachments are referenced from the message header, which raw
+		// mode doesn't write, so they would be silently dropped.
+		if len(req.Attachments) > 0 {
+			message.SendResponse(conn, message.ErrorResponse(message.ErrCodeInvalidRequest, "raw messages cannot have attachments"))
+			return
+		}
 		// Raw mode: send body directly to PTY without prefix.
 		// Uses interrupt priority so it bypasses the blocked-agent check
 		// (the main use case is responding to permission prompts).
@@ -94,9 +112,7 @@ func (d *Daemon) handleSend(conn net.Conn, req *message.Request) {
 
 	priority, ok := message.ParsePriority(req.Priority)
 	if !ok {
-		message.SendResponse(conn, &message.Response{
-			Error: "invalid priority: " + req.Priority,
-		})
+		message.SendResponse(conn, message.ErrorResponse(message.ErrCodeInvalidRequest, "invalid priority: "+req.Priority))
 		return
 	}
 
@@ -105,16 +121,21 @@ func (d *Daemon) handleSend(conn net.Conn, req *message.Request) {
 		from = "unknown"
 	}
 
-	opts := message.PrepareOpts{}
+	for i, a := range req.Attachments {
+		if err := a.Validate(); err != nil {
+			message.SendResponse(conn, message.ErrorResponse(message.ErrCodeInvalidRequest, fmt.Sprintf("attachment %d: %v", i+1, err)))
+			return
+		}
+	}
+
+	opts := message.PrepareOpts{Attachments: req.Attachments}
 	if req.ExpectsResponse && req.ERTriggerID != "" {
 		opts.ExpectsResponse = true
 		opts.TriggerID = req.ERTriggerID
 	}
 	id, err := message.PrepareMessage(s.Queue, s.Name(), from, req.Body, priority, opts)
 	if err != nil {
-		message.SendResponse(conn, &message.Response{
-			Error: err.Error(),
-		})
+		message.SendResponse(conn, message.ErrorResponse(message.ErrCodeInternal, err.Error()))
 		return
 	}
 
@@ -130,9 +151,7 @@ func (d *Daemon) handleShow(conn net.Conn, req *message.Request) {
 	s := d.Session
 	msg := s.Queue.Lookup(req.MessageID)
 	if msg == nil {
-		message.SendResponse(conn, &message.Response{
-			Error: "message not found: " + req.MessageID,
-		})
+		message.SendResponse(conn, message.ErrorResponse(message.ErrCodeNotFound, "message not found: "+req.MessageID))
 		return
 	}
 
@@ -162,11 +181,69 @@ func (d *Daemon) handleStatus(conn net.Conn) {
 	})
 }
 
+// defaultStopTimeout is how long a graceful stop waits for the current turn
+// to finish when the request doesn't say.
+const defaultStopTimeout = 30 * time.Second
+
+// handleGracefulStop stops the agent gracefully: it refuses while a tool is
+// running, since killing the agent then can leave a write half done, and
+// otherwise holds back queued messages and waits for the current turn to
+// finish before shutting down. A turn still running after the timeout is
+// left alone and the stop fails.
+func (d *Daemon) handleGracefulStop(conn net.Conn, req *message.Request) {
+	defer conn.Close()
+	s := d.Session
+
+	timeout := defaultStopTimeout
+	if req.Timeout != "" {
+		t, err := time.ParseDuration(req.Timeout)
+		if err != nil || t <= 0 {
+			message.SendResponse(conn, message.ErrorResponse(message.ErrCodeInvalidRequest, fmt.Sprintf("invalid timeout %q", req.Timeout)))
+			return
+		}
+		timeout = t
+	}
+
+	if st, sub := s.State(); st == monitor.StateActive && sub == monitor.SubStateToolUse {
+		tool := s.ActivitySnapshot().LastToolName
+		if tool == "" {
+			tool = "a tool"
+		}
+		message.SendResponse(conn, message.ErrorResponse(message.ErrCodeBusy,
+			fmt.Sprintf("agent is running %s; stop it once the tool finishes, or use --force to stop it now", tool)))
+		return
+	}
+
+	if !s.Queue.IsPaused() {
+		s.Queue.Pause()
+		defer func() {
+			if !s.Quit {
+				s.Queue.Unpause()
+			}
+		}()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if !s.waitForTurnEnd(ctx) {
+		message.SendResponse(conn, message.ErrorResponse(message.ErrCodeBusy,
+			fmt.Sprintf("agent is still working after %s; not stopped (use --force to stop it now)", timeout)))
+		return
+	}
+	d.shutdown()
+	message.SendResponse(conn, &message.Response{OK: true})
+}
+
+// handleStop stops the agent immediately, whatever it is doing.
 func (d *Daemon) handleStop(conn net.Conn) {
 	defer conn.Close()
 	message.SendResponse(conn, &message.Response{OK: true})
+	d.shutdown()
+}
 
-	// Trigger graceful shutdown: set Quit so lifecycleLoop exits.
+// shutdown kills the child and makes the lifecycle loop exit, which flushes
+// the activity log and removes the socket on the way out.
+func (d *Daemon) shutdown() {
 	s := d.Session
 	s.Quit = true
 	s.VT.KillChild()
@@ -223,9 +300,7 @@ func (d *Daemon) handleHookEvent(conn net.Conn, req *message.Request) {
 	defer conn.Close()
 
 	if req.EventName == "" {
-		message.SendResponse(conn, &message.Response{
-			Error: "event_name is required",
-		})
+		message.SendResponse(conn, message.ErrorResponse(message.ErrCodeInvalidRequest, "event_name is required"))
 		return
 	}
 
@@ -237,23 +312,21 @@ func (d *Daemon) handleTriggerAdd(conn net.Conn, req *message.Request) {
 	defer conn.Close()
 
 	if d.TriggerEngine == nil {
-		message.SendResponse(conn, &message.Response{Error: "trigger engine not initialized"})
+		message.SendResponse(conn, message.ErrorResponse(message.ErrCodeUnavailable, "trigger engine not initialized"))
 		return
 	}
 	if req.Trigger == nil {
-		message.SendResponse(conn, &message.Response{Error: "trigger spec is required"})
+		message.SendResponse(conn, message.ErrorResponse(message.ErrCodeInvalidRequest, "trigger spec is required"))
 		return
 	}
 
 	t, err := triggerFromSpec(req.Trigger)
 	if err != nil {
-		message.SendResponse(conn, &message.Response{Error: err.Error()})
+		message.SendResponse(conn, message.ErrorResponse(message.ErrCodeInvalidRequest, err.Error()))
 		return
 	}
 	if !d.TriggerEngine.Add(t) {
-		message.SendResponse(conn, &message.Response{
-			Error: fmt.Sprintf("trigger ID %q already exists", t.ID),
-		})
+		message.SendResponse(conn, message.ErrorResponse(message.ErrCodeAlreadyExists, fmt.Sprintf("trigger ID %q already exists", t.ID)))
 		return
 	}
 
@@ -280,18 +353,16 @@ func (d *Daemon) handleTriggerRemove(conn net.Conn, req *message.Request) {
 	defer conn.Close()
 
 	if d.TriggerEngine == nil {
-		message.SendResponse(conn, &message.Response{Error: "trigger engine not initialized"})
+		message.SendResponse(conn, message.ErrorResponse(message.ErrCodeUnavailable, "trigger engine not initialized"))
 		return
 	}
 	if req.TriggerID == "" {
-		message.SendResponse(conn, &message.Response{Error: "trigger_id is required"})
+		message.SendResponse(conn, message.ErrorResponse(message.ErrCodeInvalidRequest, "trigger_id is required"))
 		return
 	}
 
 	if !d.TriggerEngine.Remove(req.TriggerID) {
-		message.SendResponse(conn, &message.Response{
-			Error: fmt.Sprintf("trigger %q not found", req.TriggerID),
-		})
+		message.SendResponse(conn, message.ErrorResponse(message.ErrCodeNotFound, fmt.Sprintf("trigger %q not found", req.TriggerID)))
 		return
 	}
 	message.SendResponse(conn, &message.Response{OK: true})
@@ -301,17 +372,21 @@ func (d *Daemon) handleScheduleAdd(conn net.Conn, req *message.Request) {
 	defer conn.Close()
 
 	if d.ScheduleEngine == nil {
-		message.SendResponse(conn, &message.Response{Error: "schedule engine not initialized"})
+		message.SendResponse(conn, message.ErrorResponse(message.ErrCodeUnavailable, "schedule engine not initialized"))
 		return
 	}
 	if req.Schedule == nil {
-		message.SendResponse(conn, &message.Response{Error: "schedule spec is required"})
+		message.SendResponse(conn, message.ErrorResponse(message.ErrCodeInvalidRequest, "schedule spec is required"))
 		return
 	}
 
 	s := scheduleFromSpec(req.Schedule)
 	if err := d.ScheduleEngine.Add(s); err != nil {
-		message.SendResponse(conn, &message.Response{Error: err.Error()})
+		code := message.ErrCodeInvalidRequest
+		if errors.Is(err, automation.ErrScheduleExists) {
+			code = message.ErrCodeAlreadyExists
+		}
+		message.SendResponse(conn, message.ErrorResponse(code, err.Error()))
 		return
 	}
 
@@ -338,18 +413,16 @@ func (d *Daemon) handleScheduleRemove(conn net.Conn, req *message.Request) {
 	defer conn.Close()
 
 	if d.ScheduleEngine == nil {
-		message.SendResponse(conn, &message.Response{Error: "schedule engine not initialized"})
+		message.SendResponse(conn, message.ErrorResponse(message.ErrCodeUnavailable, "schedule engine not initialized"))
 		return
 	}
 	if req.ScheduleID == "" {
-		message.SendResponse(conn, &message.Response{Error: "schedule_id is required"})
+		message.SendResponse(conn, message.ErrorResponse(message.ErrCodeInvalidRequest, "schedule_id is required"))
 		return
 	}
 
 	if !d.ScheduleEngine.Remove(req.ScheduleID) {
-		message.SendResponse(conn, &message.Response{
-			Error: fmt.Sprintf("schedule %q not found", req.ScheduleID),
-		})
+		message.SendResponse(conn, message.ErrorResponse(message.ErrCodeNotFound, fmt.Sprintf("schedule %q not found", req.ScheduleID)))
 		return
 	}
 	message.SendResponse(conn, &message.Response{OK: true})
@@ -1,11 +1,15 @@
 package session
 
 import (
+	"fmt"
 	"net"
+	"strings"
 	"testing"
+	"time"
 
 	"h2/internal/automation"
 	"h2/internal/config"
+	"h2/internal/session/agent/monitor"
 	"h2/internal/session/message"
 	"h2/internal/session/virtualterminal"
 )
@@ -51,6 +55,144 @@ func TestHandleStop_SetsQuitAndRespondsOK(t *testing.T) {
 	}
 }
 
+// stopAgent sends a graceful_stop request to d and returns the response
+// once the handler is done.
+func stopAgent(t *testing.T, d *Daemon, req *message.Request) *message.Response {
+	t.Helper()
+	server, client := net.Pipe()
+	defer client.Close()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.handleGracefulStop(server, req)
+	}()
+	resp, err := message.ReadResponse(client)
+	if err != nil {
+		t.Errorf("read response: %v", err)
+		return &message.Response{}
+	}
+	<-done
+	return resp
+}
+
+// setAgentState drives s's monitor to st/sub and waits for it to apply.
+func setAgentState(t *testing.T, s *Session, st monitor.State, sub monitor.SubState) {
+	t.Helper()
+	s.monitor.Events() <- monitor.AgentEvent{
+		Type: monitor.EventStateChange,
+		Data: monitor.StateChangeData{State: st, SubState: sub},
+	}
+	deadline := time.After(2 * time.Second)
+	for {
+		if got, gotSub := s.State(); got == st && gotSub == sub {
+			return
+		}
+		select {
+		case <-s.StateChanged():
+		case <-deadline:
+			t.Fatalf("timed out waiting for %v/%v", st, sub)
+		}
+	}
+}
+
+func TestHandleGracefulStop_IdleAgentStops(t *testing.T) {
+	s := NewFromConfig(testRC("test", "true", nil))
+	s.VT = &virtualterminal.VT{}
+	d := &Daemon{Session: s}
+
+	if resp := stopAgent(t, d, &message.Request{Type: "graceful_stop"}); !resp.OK {
+		t.Fatalf("expected OK, got error: %s", resp.Error)
+	}
+	if !s.Quit {
+		t.Error("expected Session.Quit to be true after stop")
+	}
+}
+
+func TestHandleGracefulStop_RefusesDuringToolUseUnlessForced(t *testing.T) {
+	s := NewFromConfig(testRC("test", "true", nil))
+	defer s.Stop()
+	startAgent(t, s)
+	s.VT = &virtualterminal.VT{}
+	d := &Daemon{Session: s}
+	setAgentState(t, s, monitor.StateActive, monitor.SubStateToolUse)
+
+	resp := stopAgent(t, d, &message.Request{Type: "graceful_stop"})
+	if resp.OK || !strings.Contains(resp.Error, "--force") || resp.Code != message.ErrCodeBusy {
+		t.Fatalf("expected a busy refusal mentioning --force, got %+v", resp)
+	}
+	if s.Quit {
+		t.Fatal("agent should keep running after a refused stop")
+	}
+
+	server, client := net.Pipe()
+	defer client.Close()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.handleStop(server)
+	}()
+	if resp, err := message.ReadResponse(client); err != nil || !resp.OK {
+		t.Fatalf("forced stop: %v %+v", err, resp)
+	}
+	<-done
+	if !s.Quit {
+		t.Error("expected forced stop to set Session.Quit")
+	}
+}
+
+func TestHandleGracefulStop_WaitsForTurnToFinish(t *testing.T) {
+	s := NewFromConfig(testRC("test", "true", nil))
+	defer s.Stop()
+	startAgent(t, s)
+	s.VT = &virtualterminal.VT{}
+	d := &Daemon{Session: s}
+	setAgentState(t, s, monitor.StateActive, monitor.SubStateThinking)
+
+	// A turn that outlasts the timeout is left running.
+	resp := stopAgent(t, d, &message.Request{Type: "graceful_stop", Timeout: "20ms"})
+	if resp.OK || !strings.Contains(resp.Error, "still working after 20ms") {
+		t.Fatalf("expected a timeout, got %+v", resp)
+	}
+	if s.Quit || s.Queue.IsPaused() {
+		t.Fatalf("agent should be left running with its queue unpaused (quit=%v paused=%v)", s.Quit, s.Queue.IsPaused())
+	}
+
+	got := make(chan *message.Response, 1)
+	go func() { got <- stopAgent(t, d, &message.Request{Type: "graceful_stop"}) }()
+	select {
+	case resp := <-got:
+		t.Fatalf("stop returned before the turn finished: %+v", resp)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if !s.Queue.IsPaused() {
+		t.Error("expected queued messages to be held while waiting")
+	}
+
+	setAgentState(t, s, monitor.StateIdle, monitor.SubStateNone)
+	select {
+	case resp := <-got:
+		if !resp.OK {
+			t.Fatalf("expected OK once the turn finished, got %s", resp.Error)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("stop did not return after the turn finished")
+	}
+	if !s.Quit {
+		t.Error("expected Session.Quit to be true after stop")
+	}
+}
+
+func TestHandleGracefulStop_InvalidTimeout(t *testing.T) {
+	s := NewFromConfig(testRC("test", "true", nil))
+	s.VT = &virtualterminal.VT{}
+	d := &Daemon{Session: s}
+
+	resp := stopAgent(t, d, &message.Request{Type: "graceful_stop", Timeout: "soon"})
+	if resp.OK || !strings.Contains(resp.Error, `invalid timeout "soon"`) {
+		t.Fatalf("expected invalid timeout error, got %+v", resp)
+	}
+}
+
 func newTestDaemonWithEngines(t *testing.T) *Daemon {
 	t.Helper()
 	s := NewFromConfig(&config.RuntimeConfig{
@@ -128,8 +270,8 @@ func TestHandleTriggerAdd_DuplicateID(t *testing.T) {
 	if err != nil {
 		t.Fatalf("read response: %v", err)
 	}
-	if resp.OK {
-		t.Fatal("expected error for duplicate ID")
+	if resp.OK || resp.Code != message.ErrCodeAlreadyExists {
+		t.Fatalf("expected already_exists error for duplicate ID, got %+v", resp)
 	}
 }
 
@@ -199,8 +341,8 @@ func TestHandleTriggerRemove_NotFound(t *testing.T) {
 	if err != nil {
 		t.Fatalf("read response: %v", err)
 	}
-	if resp.OK {
-		t.Fatal("expected error for nonexistent trigger")
+	if resp.OK || resp.Code != message.ErrCodeNotFound {
+		t.Fatalf("expected not_found error for nonexistent trigger, got %+v", resp)
 	}
 }
 
@@ -232,6 +374,30 @@ func TestHandleScheduleAdd_Success(t *testing.T) {
 	}
 }
 
+func TestHandleScheduleAdd_ErrorCodes(t *testing.T) {
+	d := newTestDaemonWithEngines(t)
+	add := func(spec *message.ScheduleSpec) *message.Response {
+		server, client := net.Pipe()
+		defer client.Close()
+		go d.handleScheduleAdd(server, &message.Request{Type: "schedule_add", Schedule: spec})
+		resp, err := message.ReadResponse(client)
+		if err != nil {
+			t.Fatalf("read response: %v", err)
+		}
+		return resp
+	}
+
+	if resp := add(&message.ScheduleSpec{ID: "s1", RRule: "FREQ=SECONDLY;INTERVAL=30", Exec: "echo hi"}); !resp.OK {
+		t.Fatalf("expected OK, got error: %s", resp.Error)
+	}
+	if resp := add(&message.ScheduleSpec{ID: "s1", RRule: "FREQ=SECONDLY;INTERVAL=30", Exec: "echo hi"}); resp.Code != message.ErrCodeAlreadyExists {
+		t.Errorf("duplicate ID: got %+v, want already_exists", resp)
+	}
+	if resp := add(&message.ScheduleSpec{ID: "s2", RRule: "NOT A RULE", Exec: "echo hi"}); resp.Code != message.ErrCodeInvalidRequest {
+		t.Errorf("bad rrule: got %+v, want invalid_request", resp)
+	}
+}
+
 func TestHandleScheduleList(t *testing.T) {
 	d := newTestDaemonWithEngines(t)
 	d.ScheduleEngine.Add(&automation.Schedule{
@@ -273,8 +439,8 @@ func TestHandleScheduleRemove_NotFound(t *testing.T) {
 	if err != nil {
 		t.Fatalf("read response: %v", err)
 	}
-	if resp.OK {
-		t.Fatal("expected error for nonexistent schedule")
+	if resp.OK || resp.Code != message.ErrCodeNotFound {
+		t.Fatalf("expected not_found error for nonexistent schedule, got %+v", resp)
 	}
 }
 
@@ -294,7 +460,95 @@ func TestHandleTriggerAdd_NilEngine(t *testing.T) {
 	if err != nil {
 		t.Fatalf("read response: %v", err)
 	}
-	if resp.OK {
-		t.Fatal("expected error when engine is nil")
+	if resp.OK || resp.Code != message.ErrCodeUnavailable {
+		t.Fatalf("expected unavailable error when engine is nil, got %+v", resp)
+	}
+}
+
+func TestHandleSend_RejectsInvalidAttachment(t *testing.T) {
+	d := newTestDaemonWithEngines(t)
+	server, client := net.Pipe()
+	defer client.Close()
+
+	go d.handleSend(server, &message.Request{
+		Type:        "send",
+		Priority:    "normal",
+		Body:        "see attached",
+		Attachments: []message.Attachment{{Kind: message.AttachmentFile, Path: "relative.md"}},
+	})
+
+	resp, err := message.ReadResponse(client)
+	if err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+	if resp.OK || !strings.Contains(resp.Error, "attachment 1") || resp.Code != message.ErrCodeInvalidRequest {
+		t.Fatalf("expected attachment error, got %+v", resp)
+	}
+}
+
+func TestHandleSend_RejectsTooLargeBody(t *testing.T) {
+	d := newTestDaemonWithEngines(t)
+	server, client := net.Pipe()
+	defer client.Close()
+
+	go d.handleSend(server, &message.Request{
+		Type:     "send",
+		Priority: "normal",
+		Body:     strings.Repeat("x", message.MaxMessageBodySize+1),
+	})
+
+	resp, err := message.ReadResponse(client)
+	if err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+	if resp.OK || resp.Code != message.ErrCodeTooLarge {
+		t.Fatalf("expected too_large error, got %+v", resp)
+	}
+	if err := resp.Err(); message.ErrorCodeOf(fmt.Errorf("send: %w", err)) != message.ErrCodeTooLarge {
+		t.Errorf("ErrorCodeOf(wrapped %v) lost the code", err)
+	}
+}
+
+func TestHandleSend_RejectsTooLargeRawBody(t *testing.T) {
+	d := newTestDaemonWithEngines(t)
+	server, client := net.Pipe()
+	defer client.Close()
+
+	go d.handleSend(server, &message.Request{
+		Type: "send",
+		Raw:  true,
+		Body: strings.Repeat("x", message.MaxMessageBodySize+1),
+	})
+
+	resp, err := message.ReadResponse(client)
+	if err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+	if resp.OK || resp.Code != message.ErrCodeTooLarge {
+		t.Fatalf("expected too_large error, got %+v", resp)
+	}
+}
+
+func TestHandleSend_RejectsRawWithAttachments(t *testing.T) {
+	d := newTestDaemonWithEngines(t)
+	server, client := net.Pipe()
+	defer client.Close()
+
+	go d.handleSend(server, &message.Request{
+		Type:        "send",
+		Raw:         true,
+		Body:        "y",
+		Attachments: []message.Attachment{{Kind: message.AttachmentFile, Path: "/tmp/notes.md"}},
+	})
+
+	resp, err := message.ReadResponse(client)
+	if err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+	if resp.OK || resp.Code != message.ErrCodeInvalidRequest || !strings.Contains(resp.Error, "attachments") {
+		t.Fatalf("expected invalid_request about attachments, got %+v", resp)
+	}
+	if n := d.Session.Queue.PendingCount(); n != 0 {
+		t.Errorf("queued %d messages, want none", n)
 	}
 }
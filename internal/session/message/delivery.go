@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -33,7 +34,9 @@ type DeliveryConfig struct {
 	IsBlocked       IsBlockedFunc   // checks if agent is blocked (nil = never blocked)
 	WaitForIdle     WaitForIdleFunc // blocks until idle (for interrupt retry)
 	SignalInterrupt func()          // called when sending Ctrl+C for interrupt delivery
+	MarkInterrupt   func()          // called before typing an interrupt message (e.g. to mark the transcript)
 	OnDeliver       func()          // called after each delivery (e.g. to render)
+	InlineMaxLength int             // longest body typed inline; 0 uses config.DefaultInlineMessageLength
 	Stop            <-chan struct{}
 }
 
@@ -61,6 +64,7 @@ type PrepareOpts struct {
 	Header          string // custom header text inside [...]; if empty, MessageHeader builds the default
 	ExpectsResponse bool
 	TriggerID       string
+	Attachments     []Attachment
 }
 
 // PrepareMessage creates a Message, writes its body to disk, and enqueues it.
@@ -93,6 +97,7 @@ func PrepareMessage(q *MessageQueue, agentName, from, body string, priority Prio
 		msg.ExpectsResponse = opts[0].ExpectsResponse
 		msg.TriggerID = opts[0].TriggerID
 		msg.Header = opts[0].Header
+		msg.Attachments = opts[0].Attachments
 	}
 	if msg.Header == "" {
 		msg.Header = MessageHeader(from, priority, msg.ExpectsResponse, msg.TriggerID)
@@ -118,19 +123,18 @@ func RunDelivery(cfg DeliveryConfig) {
 		for {
 			idle := cfg.IsIdle != nil && cfg.IsIdle()
 			blocked := cfg.IsBlocked != nil && cfg.IsBlocked()
-			msg := cfg.Queue.Dequeue(idle, blocked)
-			if msg == nil {
+			batch := cfg.Queue.DequeueBatch(idle, blocked)
+			if batch == nil {
 				break
 			}
-			deliver(cfg, msg)
+			for _, msg := range batch {
+				deliver(cfg, msg)
+			}
 		}
 	}
 }
 
-const (
-	interruptRetries = 3
-	maxInlineBodyLen = 300
-)
+const interruptRetries = 3
 
 // interruptWaitTimeout is how long to wait for idle after each Ctrl+C.
 // Var so tests can override it.
@@ -157,20 +161,20 @@ func deliver(cfg DeliveryConfig, msg *Message) {
 				break
 			}
 		}
+		if cfg.MarkInterrupt != nil {
+			cfg.MarkInterrupt()
+		}
 	}
 
 	if msg.FilePath == "" {
 		// Raw user input — send body directly.
 		cfg.PtyWriter.Write([]byte(msg.Body))
 	} else {
-		// Structured message — inline short messages, reference long ones.
-		var line string
-		if len(msg.Body) <= maxInlineBodyLen {
-			line = fmt.Sprintf("[%s] %s", msg.Header, msg.Body)
-		} else {
-			line = fmt.Sprintf("[%s] Read %s", msg.Header, msg.FilePath)
+		inlineMax := cfg.InlineMaxLength
+		if inlineMax <= 0 {
+			inlineMax = config.DefaultInlineMessageLength
 		}
-		cfg.PtyWriter.Write([]byte(line))
+		cfg.PtyWriter.Write([]byte(deliveryLine(msg, inlineMax)))
 	}
 	// Delay before sending Enter so the child's UI framework can process
 	// the typed text before the submit (same pattern as user Enter).
@@ -185,3 +189,37 @@ func deliver(cfg DeliveryConfig, msg *Message) {
 		cfg.OnDeliver()
 	}
 }
+
+// deliveryLine returns the text typed into the agent for a structured
+// message: the header, then the body inline when it is at most inlineMax
+// bytes or as a reference to its file when longer, then each attachment as
+// its kind says.
+func deliveryLine(msg *Message, inlineMax int) string {
+	parts := []string{"[" + msg.Header + "]"}
+	switch {
+	case msg.Body == "" && len(msg.Attachments) > 0:
+	case len(msg.Body) <= inlineMax:
+		parts = append(parts, msg.Body)
+	default:
+		parts = append(parts, "Read "+msg.FilePath)
+	}
+	for _, a := range msg.Attachments {
+		var part string
+		switch a.Kind {
+		case AttachmentFile:
+			part = "Read " + a.Path
+			if a.Description != "" {
+				part += " (" + a.Description + ")"
+			}
+		case AttachmentInline:
+			part = a.Text
+			if a.Description != "" {
+				part = a.Description + ": " + part
+			}
+		default:
+			continue
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
@@ -352,6 +352,7 @@ func TestDeliver_InterruptCallsNoteInterrupt(t *testing.T) {
 	q.Enqueue(msg)
 
 	interruptCalls := 0
+	var markedBefore []string
 	delivered := make(chan struct{}, 1)
 	go RunDelivery(DeliveryConfig{
 		Queue:     q,
@@ -363,6 +364,9 @@ func TestDeliver_InterruptCallsNoteInterrupt(t *testing.T) {
 		SignalInterrupt: func() {
 			interruptCalls++
 		},
+		MarkInterrupt: func() {
+			markedBefore = append(markedBefore, buf.String())
+		},
 		OnDeliver: func() {
 			select {
 			case delivered <- struct{}{}:
@@ -382,6 +386,10 @@ func TestDeliver_InterruptCallsNoteInterrupt(t *testing.T) {
 	if interruptCalls != 1 {
 		t.Fatalf("expected 1 SignalInterrupt call, got %d", interruptCalls)
 	}
+	// The marker goes in after Ctrl+C but before the message is typed.
+	if len(markedBefore) != 1 || markedBefore[0] != "\x03" {
+		t.Fatalf("expected 1 MarkInterrupt call after Ctrl+C only, got %q", markedBefore)
+	}
 }
 
 func TestDeliver_NormalDoesNotCallNoteInterrupt(t *testing.T) {
@@ -400,6 +408,7 @@ func TestDeliver_NormalDoesNotCallNoteInterrupt(t *testing.T) {
 	q.Enqueue(msg)
 
 	interruptCalls := 0
+	markCalls := 0
 	delivered := make(chan struct{}, 1)
 	go RunDelivery(DeliveryConfig{
 		Queue:     q,
@@ -408,6 +417,9 @@ func TestDeliver_NormalDoesNotCallNoteInterrupt(t *testing.T) {
 		SignalInterrupt: func() {
 			interruptCalls++
 		},
+		MarkInterrupt: func() {
+			markCalls++
+		},
 		OnDeliver: func() {
 			select {
 			case delivered <- struct{}{}:
@@ -427,6 +439,9 @@ func TestDeliver_NormalDoesNotCallNoteInterrupt(t *testing.T) {
 	if interruptCalls != 0 {
 		t.Fatalf("SignalInterrupt should not be called for normal priority, got %d calls", interruptCalls)
 	}
+	if markCalls != 0 {
+		t.Fatalf("MarkInterrupt should not be called for normal priority, got %d calls", markCalls)
+	}
 }
 
 func TestEnqueueRaw_BypassesBlockedAndNoPrefix(t *testing.T) {
@@ -653,3 +668,155 @@ func TestDeliver_NormalNoWaitForIdle(t *testing.T) {
 		t.Fatalf("WaitForIdle should not be called for normal priority, got %d calls", waitCalls)
 	}
 }
+
+func TestDeliver_IdleFirstBatchOrder(t *testing.T) {
+	var buf threadSafeBuffer
+	q := NewMessageQueue()
+	stop := make(chan struct{})
+
+	for _, body := range []string{"idle-A", "first-A", "first-B", "first-C"} {
+		priority := PriorityIdleFirst
+		if body == "idle-A" {
+			priority = PriorityIdle
+		}
+		q.Enqueue(&Message{
+			ID:        body,
+			From:      "user",
+			Priority:  priority,
+			Body:      body,
+			Status:    StatusQueued,
+			CreatedAt: time.Now(),
+		})
+	}
+
+	// The agent goes busy as soon as the first message lands, as a real
+	// agent would; the rest of the idle-first batch must still follow.
+	var mu sync.Mutex
+	idle := true
+	delivered := make(chan struct{}, 10)
+	go RunDelivery(DeliveryConfig{
+		Queue:     q,
+		PtyWriter: &buf,
+		IsIdle: func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return idle
+		},
+		OnDeliver: func() {
+			mu.Lock()
+			idle = false
+			mu.Unlock()
+			delivered <- struct{}{}
+		},
+		Stop: stop,
+	})
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-delivered:
+		case <-time.After(3 * time.Second):
+			t.Fatalf("delivery %d timed out", i+1)
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(stop)
+
+	if got, want := buf.String(), "first-C\rfirst-B\rfirst-A\r"; got != want {
+		t.Fatalf("delivered %q, want %q", got, want)
+	}
+}
+
+func TestDeliveryLine_Attachments(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  *Message
+		want string
+	}{
+		{
+			name: "body only",
+			msg:  &Message{Header: "h", Body: "hi", FilePath: "/m.md"},
+			want: "[h] hi",
+		},
+		{
+			name: "long body still referenced",
+			msg:  &Message{Header: "h", Body: strings.Repeat("x", 301), FilePath: "/m.md"},
+			want: "[h] Read /m.md",
+		},
+		{
+			name: "file attachment",
+			msg: &Message{Header: "h", Body: "review this", FilePath: "/m.md", Attachments: []Attachment{
+				{Kind: AttachmentFile, Path: "/repo/spec.md", Description: "design spec"},
+			}},
+			want: "[h] review this Read /repo/spec.md (design spec)",
+		},
+		{
+			name: "attachments without body",
+			msg: &Message{Header: "h", FilePath: "/m.md", Attachments: []Attachment{
+				{Kind: AttachmentFile, Path: "/repo/spec.md"},
+				{Kind: AttachmentInline, Text: "TOKEN-1", Description: "token"},
+			}},
+			want: "[h] Read /repo/spec.md token: TOKEN-1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deliveryLine(tt.msg, config.DefaultInlineMessageLength); got != tt.want {
+				t.Fatalf("deliveryLine = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeliveryLine_InlineMaxLength(t *testing.T) {
+	msg := &Message{Header: "h", Body: strings.Repeat("x", 50), FilePath: "/m.md"}
+	if got := deliveryLine(msg, 40); got != "[h] Read /m.md" {
+		t.Errorf("body over the limit: got %q", got)
+	}
+	if got := deliveryLine(msg, 50); got != "[h] "+msg.Body {
+		t.Errorf("body at the limit: got %q", got)
+	}
+}
+
+func TestAttachment_Validate(t *testing.T) {
+	valid := []Attachment{
+		{Kind: AttachmentFile, Path: "/tmp/a.md"},
+		{Kind: AttachmentInline, Text: "hello"},
+	}
+	for _, a := range valid {
+		if err := a.Validate(); err != nil {
+			t.Errorf("Validate(%+v) = %v, want nil", a, err)
+		}
+	}
+	invalid := []Attachment{
+		{Kind: AttachmentFile, Path: "a.md"},
+		{Kind: AttachmentFile},
+		{Kind: AttachmentInline},
+		{Kind: "image", Path: "/tmp/a.png"},
+	}
+	for _, a := range invalid {
+		if err := a.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded, want error", a)
+		}
+	}
+}
+
+func TestPrepareMessage_KeepsAttachments(t *testing.T) {
+	h2Dir := t.TempDir()
+	if err := config.WriteMarker(h2Dir); err != nil {
+		t.Fatalf("write marker: %v", err)
+	}
+	t.Setenv("H2_DIR", h2Dir)
+	config.ResetResolveCache()
+	t.Cleanup(config.ResetResolveCache)
+
+	q := NewMessageQueue()
+	refs := []Attachment{{Kind: AttachmentFile, Path: "/tmp/spec.md"}}
+	id, err := PrepareMessage(q, "agent", "sender", "see spec", PriorityNormal, PrepareOpts{Attachments: refs})
+	if err != nil {
+		t.Fatalf("PrepareMessage: %v", err)
+	}
+	msg := q.Lookup(id)
+	if msg == nil || len(msg.Attachments) != 1 || msg.Attachments[0].Path != "/tmp/spec.md" {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+}
@@ -62,8 +62,9 @@ type Message struct {
 	Priority    Priority
 	Body        string
 	FilePath    string
-	Header      string // text inside [...] when delivered to PTY (e.g. "h2 message from: agent-a")
-	Raw         bool   // send body directly to PTY, skip Ctrl+C interrupt loop
+	Header      string       // text inside [...] when delivered to PTY (e.g. "h2 message from: agent-a")
+	Attachments []Attachment // delivered after Body, each as its Kind says
+	Raw         bool         // send body directly to PTY, skip Ctrl+C interrupt loop
 	Status      MessageStatus
 	CreatedAt   time.Time
 	DeliveredAt *time.Time
@@ -3,14 +3,16 @@ package message
 import (
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
+	"path/filepath"
 )
 
 // Request is the JSON request sent over the Unix socket.
 type Request struct {
-	Type string `json:"type"` // "send", "attach", "show", "status", "hook_event", "stop", "relaunch", "trigger_add", "trigger_list", "trigger_remove", "schedule_add", "schedule_list", "schedule_remove"
+	Type string `json:"type"` // "send", "send-file", "attach", "show", "status", "hook_event", "stop", "graceful_stop", "relaunch", "trigger_add", "trigger_list", "trigger_remove", "schedule_add", "schedule_list", "schedule_remove", "ask", "outbox_add", "resend"
 
 	// send fields
 	Priority        string `json:"priority,omitempty"`
@@ -20,6 +22,30 @@ type Request struct {
 	ExpectsResponse bool   `json:"expects_response,omitempty"` // sender expects a response (adds annotation)
 	ERTriggerID     string `json:"er_trigger_id,omitempty"`    // trigger ID for expects-response annotation
 
+	// Attachments is structured content delivered after Body (send only;
+	// not allowed with Raw).
+	Attachments []Attachment `json:"attachments,omitempty"`
+
+	// Urgency is how prominently a bridge delivers a send: low, normal
+	// (default), or high (bridge sockets only).
+	Urgency string `json:"urgency,omitempty"`
+
+	// Replay marks a send as a re-send of a message the agent already sent,
+	// so the bridge labels it and leaves it out of its sent count (bridge
+	// sockets only).
+	Replay bool `json:"replay,omitempty"`
+
+	// ask fields (bridge sockets only; Body is the question)
+	To      string `json:"to,omitempty"`      // agent to ask; empty uses the bridge's default routing
+	Timeout string `json:"timeout,omitempty"` // Go duration to wait for the reply; for graceful_stop, for the turn to finish
+
+	// outbox_add and resend fields (agent sockets only). outbox_add records
+	// a message the agent sent to bridge To, with Body and Urgency.
+	Last int `json:"last,omitempty"` // how many recent outbound messages to re-send
+
+	// send-file fields (bridge sockets only; Body is the caption)
+	FilePath string `json:"file_path,omitempty"`
+
 	// attach fields
 	Cols      int    `json:"cols,omitempty"`
 	Rows      int    `json:"rows,omitempty"`
@@ -47,6 +73,39 @@ type Request struct {
 	ScheduleID string        `json:"schedule_id,omitempty"`
 }
 
+// Attachment kinds.
+const (
+	AttachmentInline = "inline" // Text is typed in along with the message
+	AttachmentFile   = "file"   // the agent is told to read Path
+)
+
+// Attachment is structured content sent with a message. Unlike Body, which
+// is inlined or swapped for a file reference depending on its length, each
+// attachment is delivered the way its Kind says.
+type Attachment struct {
+	Kind        string `json:"kind"`
+	Path        string `json:"path,omitempty"`        // absolute path of the file (file)
+	Text        string `json:"text,omitempty"`        // content to deliver (inline)
+	Description string `json:"description,omitempty"` // what the attachment is, shown to the agent
+}
+
+// Validate checks that the attachment has the fields its kind needs.
+func (a Attachment) Validate() error {
+	switch a.Kind {
+	case AttachmentInline:
+		if a.Text == "" {
+			return fmt.Errorf("inline attachment has no text")
+		}
+	case AttachmentFile:
+		if !filepath.IsAbs(a.Path) {
+			return fmt.Errorf("file attachment path must be absolute, got %q", a.Path)
+		}
+	default:
+		return fmt.Errorf("unknown attachment kind %q (want %s or %s)", a.Kind, AttachmentInline, AttachmentFile)
+	}
+	return nil
+}
+
 // TriggerSpec is the wire representation of a trigger for socket requests/responses.
 type TriggerSpec struct {
 	ID        string `json:"id,omitempty"`
@@ -85,15 +144,43 @@ type ScheduleSpec struct {
 	NextFireAt    string `json:"next_fire_at,omitempty"` // RFC 3339, computed at list time
 }
 
+// ErrorCode classifies a failed Response so clients can act on the kind of
+// failure without parsing the Error text.
+type ErrorCode string
+
+const (
+	ErrCodeInvalidRequest ErrorCode = "invalid_request" // malformed request or missing/invalid fields
+	ErrCodeTooLarge       ErrorCode = "too_large"       // message body over MaxMessageBodySize
+	ErrCodeNotFound       ErrorCode = "not_found"       // the message, trigger or schedule doesn't exist
+	ErrCodeAlreadyExists  ErrorCode = "already_exists"  // a trigger or schedule with that ID exists
+	ErrCodeBusy           ErrorCode = "busy"            // the agent is mid-work and refused; try again later
+	ErrCodeUnavailable    ErrorCode = "unavailable"     // the daemon can't serve this request type
+	ErrCodeInternal       ErrorCode = "internal"        // the daemon failed, e.g. writing the message file
+)
+
+// MaxMessageBodySize is the largest message body an agent accepts.
+const MaxMessageBodySize = 10 * 1024 * 1024
+
+// CheckBodySize returns an error if body is over MaxMessageBodySize.
+func CheckBodySize(body string) error {
+	if len(body) > MaxMessageBodySize {
+		return fmt.Errorf("message is too large (%d bytes, max %d MB)", len(body), MaxMessageBodySize>>20)
+	}
+	return nil
+}
+
 // Response is the JSON response sent back over the Unix socket.
 type Response struct {
 	OK           bool         `json:"ok"`
-	Error        string       `json:"error,omitempty"`
+	Error        string       `json:"error,omitempty"` // human-readable, for display
+	Code         ErrorCode    `json:"code,omitempty"`  // set with Error by agent daemons; may be empty from older ones
 	MessageID    string       `json:"message_id,omitempty"`
 	OldConcierge string       `json:"old_concierge,omitempty"`
 	Message      *MessageInfo `json:"message,omitempty"`
 	Agent        *AgentInfo   `json:"agent,omitempty"`
 	Bridge       *BridgeInfo  `json:"bridge,omitempty"`
+	Reply        string       `json:"reply,omitempty"`  // agent's reply to an ask request
+	Resent       int          `json:"resent,omitempty"` // messages re-sent by a resend request
 
 	// trigger/schedule responses
 	TriggerID  string          `json:"trigger_id,omitempty"`
@@ -106,11 +193,14 @@ type Response struct {
 type BridgeInfo struct {
 	Name             string   `json:"name"`
 	Pod              string   `json:"pod,omitempty"` // pod name if launched from a pod
+	Concierge        string   `json:"concierge,omitempty"`
 	Channels         []string `json:"channels"`
 	Uptime           string   `json:"uptime"`
 	MessagesSent     int64    `json:"messages_sent"`
 	MessagesReceived int64    `json:"messages_received"`
-	LastActivity     string   `json:"last_activity,omitempty"` // duration since last message, empty if none
+	MessagesMuted    int64    `json:"messages_muted,omitempty"` // messages dropped from muted agents
+	Muted            []string `json:"muted,omitempty"`          // agents whose messages are dropped
+	LastActivity     string   `json:"last_activity,omitempty"`  // duration since last message, empty if none
 }
 
 // MessageInfo is the public representation of a message in responses.
@@ -141,6 +231,14 @@ type AgentInfo struct {
 	StateDuration    string `json:"state_duration"`
 	QueuedCount      int    `json:"queued_count"`
 
+	// Undelivered messages by priority, from the same queue snapshot as
+	// QueuedCount.
+	QueuedInterrupt int    `json:"queued_interrupt,omitempty"`
+	QueuedNormal    int    `json:"queued_normal,omitempty"`
+	QueuedIdleFirst int    `json:"queued_idle_first,omitempty"`
+	QueuedIdle      int    `json:"queued_idle,omitempty"`
+	OldestQueuedAt  string `json:"oldest_queued_at,omitempty"` // RFC3339 timestamp
+
 	// Per-model cost and token breakdowns from OTEL metrics
 	ModelStats   []ModelStat `json:"model_stats,omitempty"`
 	InputTokens  int64       `json:"input_tokens,omitempty"`
@@ -200,6 +298,38 @@ type ResizeControl struct {
 	Rows int    `json:"rows"`
 }
 
+// ErrorResponse returns a failed Response with the given code and message.
+func ErrorResponse(code ErrorCode, msg string) *Response {
+	return &Response{Error: msg, Code: code}
+}
+
+// ResponseError is a failed Response as an error.
+type ResponseError struct {
+	Code    ErrorCode
+	Message string
+}
+
+func (e *ResponseError) Error() string { return e.Message }
+
+// Err returns nil if the response is OK, otherwise a *ResponseError with
+// its code and message.
+func (r *Response) Err() error {
+	if r.OK {
+		return nil
+	}
+	return &ResponseError{Code: r.Code, Message: r.Error}
+}
+
+// ErrorCodeOf returns the code of the ResponseError in err's chain, or ""
+// if there is none (e.g. the agent couldn't be reached at all).
+func ErrorCodeOf(err error) ErrorCode {
+	var re *ResponseError
+	if errors.As(err, &re) {
+		return re.Code
+	}
+	return ""
+}
+
 // SendRequest sends a JSON-encoded request over a connection.
 func SendRequest(conn net.Conn, req *Request) error {
 	return json.NewEncoder(conn).Encode(req)
@@ -2,12 +2,14 @@ package message
 
 import (
 	"sync"
+	"time"
 )
 
 // MessageQueue is a priority queue for inter-agent messages.
 // Messages are ordered by priority: interrupt > normal > idle-first > idle.
 // Within each priority level, messages are FIFO except idle-first which
-// prepends (most recent first).
+// prepends (most recent first). Held idle-first messages are drained as one
+// batch (see DequeueBatch) so their order doesn't depend on drain timing.
 type MessageQueue struct {
 	mu          sync.Mutex
 	interrupt   []*Message // priority 1 - FIFO
@@ -26,6 +28,10 @@ type QueueSnapshot struct {
 	IdleFirst int
 	Idle      int
 	Paused    bool
+
+	// OldestCreatedAt is the CreatedAt of the longest-waiting undelivered
+	// message, zero when the queue is empty.
+	OldestCreatedAt time.Time
 }
 
 // Total returns the total number of undelivered messages.
@@ -83,6 +89,32 @@ func (q *MessageQueue) Enqueue(msg *Message) {
 func (q *MessageQueue) Dequeue(idle, blocked bool) *Message {
 	q.mu.Lock()
 	defer q.mu.Unlock()
+	return q.dequeueLocked(idle, blocked)
+}
+
+// DequeueBatch is like Dequeue, except that when the next message is
+// idle-first it removes and returns every held idle-first message at once,
+// most recent first. The delivery loop writes the whole batch before looking
+// at the queue again, so the agent going busy after the first one (or more
+// arriving mid-drain) can't reorder or split the group. Returns nil if no
+// deliverable message is available.
+func (q *MessageQueue) DequeueBatch(idle, blocked bool) []*Message {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if idle && !q.paused && !blocked && len(q.interrupt) == 0 && len(q.normal) == 0 && len(q.idleFirst) > 0 {
+		batch := q.idleFirst
+		q.idleFirst = nil
+		return batch
+	}
+	if msg := q.dequeueLocked(idle, blocked); msg != nil {
+		return []*Message{msg}
+	}
+	return nil
+}
+
+// dequeueLocked implements Dequeue. Must be called with q.mu held.
+func (q *MessageQueue) dequeueLocked(idle, blocked bool) *Message {
 
 	if q.paused {
 		// Interrupt bypasses pause.
@@ -160,13 +192,24 @@ func (q *MessageQueue) PendingCount() int {
 func (q *MessageQueue) Snapshot() QueueSnapshot {
 	q.mu.Lock()
 	defer q.mu.Unlock()
-	return QueueSnapshot{
+	snap := QueueSnapshot{
 		Interrupt: len(q.interrupt),
 		Normal:    len(q.normal),
 		IdleFirst: len(q.idleFirst),
 		Idle:      len(q.idle),
 		Paused:    q.paused,
 	}
+	for _, sub := range [][]*Message{q.interrupt, q.normal, q.idleFirst, q.idle} {
+		for _, msg := range sub {
+			if msg.CreatedAt.IsZero() {
+				continue
+			}
+			if snap.OldestCreatedAt.IsZero() || msg.CreatedAt.Before(snap.OldestCreatedAt) {
+				snap.OldestCreatedAt = msg.CreatedAt
+			}
+		}
+	}
+	return snap
 }
 
 // Notify returns the channel that is signaled on enqueue or unpause.
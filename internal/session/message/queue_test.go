@@ -1,6 +1,7 @@
 package message
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -89,6 +90,47 @@ func TestDequeueOrder_IdleFirstPrepends(t *testing.T) {
 	}
 }
 
+func TestDequeueBatch_DrainsIdleFirstTogether(t *testing.T) {
+	q := NewMessageQueue()
+	q.Enqueue(newMsg("idle-1", PriorityIdle))
+	q.Enqueue(newMsg("if-1", PriorityIdleFirst))
+	q.Enqueue(newMsg("if-2", PriorityIdleFirst))
+	q.Enqueue(newMsg("if-3", PriorityIdleFirst))
+
+	if batch := q.DequeueBatch(false, false); batch != nil {
+		t.Fatalf("expected nothing while busy, got %d messages", len(batch))
+	}
+
+	batch := q.DequeueBatch(true, false)
+	var ids []string
+	for _, msg := range batch {
+		ids = append(ids, msg.ID)
+	}
+	if got, want := strings.Join(ids, ","), "if-3,if-2,if-1"; got != want {
+		t.Fatalf("batch = %s, want %s", got, want)
+	}
+
+	batch = q.DequeueBatch(true, false)
+	if len(batch) != 1 || batch[0].ID != "idle-1" {
+		t.Fatalf("expected idle-1 alone, got %v", batch)
+	}
+}
+
+func TestDequeueBatch_NormalStillFirst(t *testing.T) {
+	q := NewMessageQueue()
+	q.Enqueue(newMsg("if-1", PriorityIdleFirst))
+	q.Enqueue(newMsg("normal-1", PriorityNormal))
+
+	batch := q.DequeueBatch(true, false)
+	if len(batch) != 1 || batch[0].ID != "normal-1" {
+		t.Fatalf("expected normal-1 alone, got %v", batch)
+	}
+	batch = q.DequeueBatch(true, false)
+	if len(batch) != 1 || batch[0].ID != "if-1" {
+		t.Fatalf("expected if-1, got %v", batch)
+	}
+}
+
 func TestDequeueOrder_NormalFIFO(t *testing.T) {
 	q := NewMessageQueue()
 	q.Enqueue(newMsg("n-1", PriorityNormal))
@@ -231,6 +273,38 @@ func TestSnapshot(t *testing.T) {
 	}
 }
 
+func TestSnapshot_OldestCreatedAt(t *testing.T) {
+	q := NewMessageQueue()
+	if !q.Snapshot().OldestCreatedAt.IsZero() {
+		t.Fatal("expected zero OldestCreatedAt for empty queue")
+	}
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	normal := newMsg("normal-1", PriorityNormal)
+	normal.CreatedAt = base.Add(2 * time.Minute)
+	idle := newMsg("idle-1", PriorityIdle)
+	idle.CreatedAt = base
+	interrupt := newMsg("interrupt-1", PriorityInterrupt)
+	interrupt.CreatedAt = base.Add(time.Minute)
+	q.Enqueue(normal)
+	q.Enqueue(idle)
+	q.Enqueue(interrupt)
+
+	if got := q.Snapshot().OldestCreatedAt; !got.Equal(base) {
+		t.Fatalf("OldestCreatedAt = %v, want %v (idle message)", got, base)
+	}
+
+	q.Dequeue(true, false) // interrupt
+	q.Dequeue(true, false) // normal
+	if got := q.Snapshot().OldestCreatedAt; !got.Equal(base) {
+		t.Fatalf("OldestCreatedAt = %v, want %v while idle message is queued", got, base)
+	}
+	q.Dequeue(true, false) // idle
+	if !q.Snapshot().OldestCreatedAt.IsZero() {
+		t.Fatal("expected zero OldestCreatedAt after draining queue")
+	}
+}
+
 func TestLookup(t *testing.T) {
 	q := NewMessageQueue()
 	q.Enqueue(newMsg("msg-1", PriorityNormal))
@@ -1,7 +1,13 @@
 package session
 
 import (
+	"errors"
+	"fmt"
 	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 )
 
 // adjectives for name generation.
@@ -45,9 +51,73 @@ var nouns = []string{
 	"vine", "wren", "wolf", "wood", "yarn",
 }
 
-// GenerateName produces a random adjective-noun name like "calm-brook".
+// GenerateName produces a random adjective-noun name like "calm-brook"
+// from the built-in word lists.
 func GenerateName() string {
-	adj := adjectives[rand.IntN(len(adjectives))]
-	noun := nouns[rand.IntN(len(nouns))]
+	return defaultNameGenerator.Generate()
+}
+
+var defaultNameGenerator = &NameGenerator{adjectives: adjectives, nouns: nouns}
+
+// NameGenerator produces random adjective-noun names from a pair of word
+// lists.
+type NameGenerator struct {
+	adjectives []string
+	nouns      []string
+}
+
+// Generate returns a random "<adjective>-<noun>" name.
+func (g *NameGenerator) Generate() string {
+	adj := g.adjectives[rand.IntN(len(g.adjectives))]
+	noun := g.nouns[rand.IntN(len(g.nouns))]
 	return adj + "-" + noun
 }
+
+// Word list files, relative to the names dir.
+const (
+	AdjectivesFile = "adjectives.txt"
+	NounsFile      = "nouns.txt"
+)
+
+var nameWordRe = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// LoadNameGenerator returns a generator that reads adjectives.txt and
+// nouns.txt from dir, one word per line (blank lines and # comments are
+// skipped). A missing file falls back to the built-in list; an empty or
+// malformed one is an error so names never come out blank.
+func LoadNameGenerator(dir string) (*NameGenerator, error) {
+	adj, err := loadWordList(filepath.Join(dir, AdjectivesFile), adjectives)
+	if err != nil {
+		return nil, err
+	}
+	noun, err := loadWordList(filepath.Join(dir, NounsFile), nouns)
+	if err != nil {
+		return nil, err
+	}
+	return &NameGenerator{adjectives: adj, nouns: noun}, nil
+}
+
+func loadWordList(path string, fallback []string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fallback, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read word list: %w", err)
+	}
+	var words []string
+	for i, line := range strings.Split(string(data), "\n") {
+		word := strings.TrimSpace(line)
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		if !nameWordRe.MatchString(word) {
+			return nil, fmt.Errorf("word list %s line %d: invalid word %q (use lowercase letters, digits and single hyphens)", path, i+1, word)
+		}
+		words = append(words, word)
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("word list %s has no words; add one per line or delete the file to use the built-in list", path)
+	}
+	return words, nil
+}
@@ -1,6 +1,9 @@
 package session
 
 import (
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -24,3 +27,66 @@ func TestGenerateName(t *testing.T) {
 		t.Fatalf("expected some variety in 20 names, got %d unique", len(seen))
 	}
 }
+
+func TestLoadNameGenerator_CustomWordLists(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, AdjectivesFile), []byte("# team theme\nrebel\n\nimperial\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, NounsFile), []byte("x-wing\n"), 0o644)
+
+	gen, err := LoadNameGenerator(dir)
+	if err != nil {
+		t.Fatalf("LoadNameGenerator: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		name := gen.Generate()
+		if name != "rebel-x-wing" && name != "imperial-x-wing" {
+			t.Fatalf("unexpected name %q", name)
+		}
+	}
+}
+
+func TestLoadNameGenerator_FallsBackToBuiltIn(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, NounsFile), []byte("otter\n"), 0o644)
+
+	gen, err := LoadNameGenerator(dir)
+	if err != nil {
+		t.Fatalf("LoadNameGenerator: %v", err)
+	}
+	name := gen.Generate()
+	adj, noun, _ := strings.Cut(name, "-")
+	if noun != "otter" || !slices.Contains(adjectives, adj) {
+		t.Errorf("expected built-in adjective with custom noun, got %q", name)
+	}
+
+	gen, err = LoadNameGenerator(filepath.Join(dir, "missing"))
+	if err != nil {
+		t.Fatalf("LoadNameGenerator without word lists: %v", err)
+	}
+	if name := gen.Generate(); !strings.Contains(name, "-") {
+		t.Errorf("expected built-in name, got %q", name)
+	}
+}
+
+func TestLoadNameGenerator_RejectsBadWordLists(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"empty", "", "has no words"},
+		{"only comments", "# nothing here\n\n", "has no words"},
+		{"spaces", "calm\nbig brook\n", `line 2: invalid word "big brook"`},
+		{"uppercase", "Calm\n", `invalid word "Calm"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			os.WriteFile(filepath.Join(dir, AdjectivesFile), []byte(tt.content), 0o644)
+			_, err := LoadNameGenerator(dir)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
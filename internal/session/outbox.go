@@ -0,0 +1,114 @@
+package session
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"h2/internal/session/message"
+	"h2/internal/socketdir"
+)
+
+// outboxSize caps how many recent bridge messages an agent keeps for resend.
+const outboxSize = 50
+
+// outboundMessage is a message the agent sent to a bridge.
+type outboundMessage struct {
+	Bridge  string
+	Body    string
+	Urgency string
+	SentAt  time.Time
+}
+
+// outbox is a fixed-size ring buffer of the agent's most recent bridge
+// messages. Once full, each new message overwrites the oldest.
+type outbox struct {
+	mu      sync.Mutex
+	entries [outboxSize]outboundMessage
+	next    int // index the next message is written to
+	count   int // number of messages held, up to outboxSize
+}
+
+func (o *outbox) add(m outboundMessage) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.entries[o.next] = m
+	o.next = (o.next + 1) % outboxSize
+	o.count = min(o.count+1, outboxSize)
+}
+
+// last returns up to n of the most recent messages, oldest first.
+func (o *outbox) last(n int) []outboundMessage {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	n = min(n, o.count)
+	out := make([]outboundMessage, 0, n)
+	for i := n; i > 0; i-- {
+		out = append(out, o.entries[(o.next-i+outboxSize)%outboxSize])
+	}
+	return out
+}
+
+func (d *Daemon) handleOutboxAdd(conn net.Conn, req *message.Request) {
+	defer conn.Close()
+
+	if req.To == "" || req.Body == "" {
+		message.SendResponse(conn, message.ErrorResponse(message.ErrCodeInvalidRequest, "outbox_add needs a bridge and a body"))
+		return
+	}
+	d.outbox.add(outboundMessage{Bridge: req.To, Body: req.Body, Urgency: req.Urgency, SentAt: time.Now()})
+	message.SendResponse(conn, &message.Response{OK: true})
+}
+
+// handleResend re-sends the agent's last req.Last bridge messages, oldest
+// first, each to the bridge it originally went to, marked as a replay.
+func (d *Daemon) handleResend(conn net.Conn, req *message.Request) {
+	defer conn.Close()
+
+	if req.Last <= 0 {
+		message.SendResponse(conn, message.ErrorResponse(message.ErrCodeInvalidRequest, fmt.Sprintf("invalid count %d: must be positive", req.Last)))
+		return
+	}
+	resent := 0
+	var errs []string
+	for _, m := range d.outbox.last(req.Last) {
+		if err := d.replayToBridge(m); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		resent++
+	}
+	resp := &message.Response{OK: len(errs) == 0, Resent: resent}
+	if len(errs) > 0 {
+		resp.Error = strings.Join(errs, "; ")
+	}
+	message.SendResponse(conn, resp)
+}
+
+// replayToBridge sends m to its bridge again as a replay.
+func (d *Daemon) replayToBridge(m outboundMessage) error {
+	conn, err := net.Dial("unix", socketdir.Path(socketdir.TypeBridge, m.Bridge))
+	if err != nil {
+		return fmt.Errorf("bridge %s: %w", m.Bridge, err)
+	}
+	defer conn.Close()
+	if err := message.SendRequest(conn, &message.Request{
+		Type:    "send",
+		From:    d.Session.Name(),
+		Body:    m.Body,
+		Urgency: m.Urgency,
+		Replay:  true,
+	}); err != nil {
+		return fmt.Errorf("bridge %s: send request: %w", m.Bridge, err)
+	}
+	resp, err := message.ReadResponse(conn)
+	if err != nil {
+		return fmt.Errorf("bridge %s: read response: %w", m.Bridge, err)
+	}
+	if !resp.OK {
+		return fmt.Errorf("bridge %s: %s", m.Bridge, resp.Error)
+	}
+	return nil
+}
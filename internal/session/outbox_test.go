@@ -0,0 +1,159 @@
+package session
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"h2/internal/session/message"
+	"h2/internal/socketdir"
+)
+
+func TestOutbox_KeepsMostRecent(t *testing.T) {
+	var o outbox
+	if got := o.last(5); len(got) != 0 {
+		t.Fatalf("empty outbox returned %d messages", len(got))
+	}
+	for i := 1; i <= outboxSize+5; i++ {
+		o.add(outboundMessage{Bridge: "b", Body: fmt.Sprintf("m%d", i)})
+	}
+
+	all := o.last(outboxSize * 2)
+	if len(all) != outboxSize {
+		t.Fatalf("got %d messages, want %d", len(all), outboxSize)
+	}
+	if all[0].Body != "m6" || all[len(all)-1].Body != fmt.Sprintf("m%d", outboxSize+5) {
+		t.Errorf("oldest/newest = %q/%q", all[0].Body, all[len(all)-1].Body)
+	}
+
+	got := o.last(3)
+	want := []string{fmt.Sprintf("m%d", outboxSize+3), fmt.Sprintf("m%d", outboxSize+4), fmt.Sprintf("m%d", outboxSize+5)}
+	for i := range want {
+		if got[i].Body != want[i] {
+			t.Errorf("last(3)[%d] = %q, want %q", i, got[i].Body, want[i])
+		}
+	}
+}
+
+// fakeBridge listens on a bridge socket and records the requests it gets.
+type fakeBridge struct {
+	mu   sync.Mutex
+	reqs []*message.Request
+}
+
+func newFakeBridge(t *testing.T, dir, name string) *fakeBridge {
+	t.Helper()
+	ln, err := net.Listen("unix", filepath.Join(dir, socketdir.Format(socketdir.TypeBridge, name)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	fb := &fakeBridge{}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			req, err := message.ReadRequest(conn)
+			if err == nil {
+				fb.mu.Lock()
+				fb.reqs = append(fb.reqs, req)
+				fb.mu.Unlock()
+				message.SendResponse(conn, &message.Response{OK: true})
+			}
+			conn.Close()
+		}
+	}()
+	return fb
+}
+
+func (fb *fakeBridge) requests() []*message.Request {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	return append([]*message.Request(nil), fb.reqs...)
+}
+
+func TestHandleResend_ReplaysToEachBridge(t *testing.T) {
+	dir, err := os.MkdirTemp("/tmp", "h2-outbox-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	t.Setenv(socketdir.DirEnv, dir)
+	socketdir.ResetDirCache()
+	t.Cleanup(socketdir.ResetDirCache)
+
+	tg := newFakeBridge(t, dir, "tg")
+	mac := newFakeBridge(t, dir, "mac")
+
+	d := newTestDaemonWithEngines(t)
+	for _, m := range []outboundMessage{
+		{Bridge: "tg", Body: "first"},
+		{Bridge: "mac", Body: "second", Urgency: "high"},
+		{Bridge: "tg", Body: "third"},
+	} {
+		server, client := net.Pipe()
+		go d.handleOutboxAdd(server, &message.Request{Type: "outbox_add", To: m.Bridge, Body: m.Body, Urgency: m.Urgency})
+		if resp, err := message.ReadResponse(client); err != nil || !resp.OK {
+			t.Fatalf("outbox_add: %v %+v", err, resp)
+		}
+		client.Close()
+	}
+
+	server, client := net.Pipe()
+	defer client.Close()
+	go d.handleResend(server, &message.Request{Type: "resend", Last: 2})
+	resp, err := message.ReadResponse(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.OK || resp.Resent != 2 {
+		t.Fatalf("resend response = %+v", resp)
+	}
+
+	macReqs := mac.requests()
+	if len(macReqs) != 1 || macReqs[0].Body != "second" || macReqs[0].Urgency != "high" {
+		t.Errorf("mac got %+v", macReqs)
+	}
+	tgReqs := tg.requests()
+	if len(tgReqs) != 1 || tgReqs[0].Body != "third" {
+		t.Fatalf("tg got %+v", tgReqs)
+	}
+	if r := tgReqs[0]; r.Type != "send" || !r.Replay || r.From != "test" {
+		t.Errorf("replay request = %+v, want a send from test marked as replay", r)
+	}
+}
+
+func TestHandleResend_Errors(t *testing.T) {
+	dir, err := os.MkdirTemp("/tmp", "h2-outbox-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	t.Setenv(socketdir.DirEnv, dir)
+	socketdir.ResetDirCache()
+	t.Cleanup(socketdir.ResetDirCache)
+
+	d := newTestDaemonWithEngines(t)
+
+	server, client := net.Pipe()
+	go d.handleResend(server, &message.Request{Type: "resend", Last: 0})
+	resp, err := message.ReadResponse(client)
+	client.Close()
+	if err != nil || resp.OK || resp.Error == "" {
+		t.Errorf("expected an error for a zero count, got %+v (%v)", resp, err)
+	}
+
+	d.outbox.add(outboundMessage{Bridge: "gone", Body: "hello"})
+	server, client = net.Pipe()
+	go d.handleResend(server, &message.Request{Type: "resend", Last: 1})
+	resp, err = message.ReadResponse(client)
+	client.Close()
+	if err != nil || resp.OK || resp.Resent != 0 {
+		t.Errorf("expected a failed resend to a missing bridge, got %+v (%v)", resp, err)
+	}
+}
@@ -0,0 +1,90 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"h2/internal/activitylog"
+	"h2/internal/config"
+	"h2/internal/tmpl"
+)
+
+// maxPostLaunchOutput caps how much of a post_launch command's output is
+// logged; the end is kept since that's where errors show up.
+const maxPostLaunchOutput = 4096
+
+// postLaunchWaitDelay is how long a killed post_launch command's output is
+// still read for.
+const postLaunchWaitDelay = time.Second
+
+// runPostLaunch runs the role's post_launch commands in order, in the
+// agent's working dir, before its daemon is started. Each command's output
+// is written to the activity log. A command that fails, or runs past its
+// timeout and is killed, aborts the launch unless it is marked
+// ignore_failure.
+func runPostLaunch(ctx context.Context, cmds config.PostLaunchCommands, rc *config.RuntimeConfig, sessionDir string) error {
+	if len(cmds) == 0 {
+		return nil
+	}
+	logPath := ActivityLogPath()
+	os.MkdirAll(filepath.Dir(logPath), 0o755)
+	actLog := activitylog.New(true, logPath, rc.AgentName, rc.SessionID)
+	defer actLog.Close()
+	actLog.SetSecrets(rc.SecretValues)
+
+	env := append(os.Environ(), "H2_ACTOR="+rc.AgentName, "H2_SESSION_DIR="+sessionDir)
+	if rc.RoleName != "" {
+		env = append(env, "H2_ROLE="+rc.RoleName)
+	}
+	for i, c := range cmds {
+		timeout := c.GetTimeout()
+		cmdCtx, cancel := context.WithTimeout(ctx, timeout)
+		cmd := exec.CommandContext(cmdCtx, "sh", "-c", c.Command)
+		cmd.Dir = rc.CWD
+		cmd.Env = env
+		// Don't wait on background children still holding the output pipe
+		// once sh itself has been killed.
+		cmd.WaitDelay = postLaunchWaitDelay
+		out, err := cmd.CombinedOutput()
+		if errors.Is(cmdCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		cancel()
+		output := tailOutput(string(out), maxPostLaunchOutput)
+
+		exitCode := 0
+		if err != nil {
+			exitCode = -1
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				exitCode = exitErr.ExitCode()
+			}
+		}
+		actLog.PostLaunch(c.Command, exitCode, output)
+		if err == nil || c.IgnoreFailure {
+			continue
+		}
+
+		msg := fmt.Sprintf("post_launch[%d] %q failed in %s: %v", i, c.Command, rc.CWD, err)
+		if trimmed := strings.TrimSpace(output); trimmed != "" {
+			msg += "\n" + trimmed
+		}
+		msg += "\n(set ignore_failure: true on the command to launch anyway)"
+		return errors.New(tmpl.Redact(msg, rc.SecretValues))
+	}
+	return nil
+}
+
+// tailOutput returns the last limit bytes of s, noting when the start was cut.
+func tailOutput(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	return "...\n" + s[len(s)-limit:]
+}
@@ -0,0 +1,113 @@
+package session
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"h2/internal/config"
+)
+
+func TestLaunchAgent_RunsPostLaunchInWorkingDir(t *testing.T) {
+	setupLaunchTestH2Dir(t)
+	cwd := t.TempDir()
+	role := &config.Role{
+		RoleName:     "coder",
+		AgentHarness: "codex",
+		PostLaunch: config.PostLaunchCommands{
+			{Command: `echo "$H2_ACTOR" > seeded.txt`},
+			{Command: "echo flaky >&2; exit 3", IgnoreFailure: true},
+		},
+	}
+
+	forked := false
+	_, err := LaunchAgent(context.Background(), role, LaunchOptions{
+		Name:          "post-launch-ok",
+		InvocationCWD: cwd,
+		Fork: func(string, TerminalHints, bool) error {
+			forked = true
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("LaunchAgent: %v", err)
+	}
+	if !forked {
+		t.Error("expected the daemon to be forked")
+	}
+	data, err := os.ReadFile(filepath.Join(cwd, "seeded.txt"))
+	if err != nil || strings.TrimSpace(string(data)) != "post-launch-ok" {
+		t.Fatalf("seeded.txt = %q, %v; want the agent name", data, err)
+	}
+
+	logData, err := os.ReadFile(ActivityLogPath())
+	if err != nil {
+		t.Fatalf("read activity log: %v", err)
+	}
+	log := string(logData)
+	if !strings.Contains(log, `"event":"post_launch"`) || !strings.Contains(log, `"exit_code":3`) || !strings.Contains(log, "flaky") {
+		t.Errorf("activity log missing post_launch entries:\n%s", log)
+	}
+}
+
+func TestLaunchAgent_PostLaunchFailureAbortsLaunch(t *testing.T) {
+	setupLaunchTestH2Dir(t)
+	role := &config.Role{
+		RoleName:     "coder",
+		AgentHarness: "codex",
+		PostLaunch:   config.PostLaunchCommands{{Command: "echo no direnv >&2; exit 1"}},
+	}
+
+	forked := false
+	_, err := LaunchAgent(context.Background(), role, LaunchOptions{
+		Name:          "post-launch-fail",
+		InvocationCWD: t.TempDir(),
+		Fork: func(string, TerminalHints, bool) error {
+			forked = true
+			return nil
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "post_launch[0]") || !strings.Contains(err.Error(), "no direnv") {
+		t.Fatalf("expected post_launch failure with output, got %v", err)
+	}
+	if forked {
+		t.Error("fork should not be called when post_launch fails")
+	}
+}
+
+func TestLaunchAgent_PostLaunchTimeout(t *testing.T) {
+	setupLaunchTestH2Dir(t)
+	role := &config.Role{
+		RoleName:     "coder",
+		AgentHarness: "codex",
+		PostLaunch:   config.PostLaunchCommands{{Command: "echo waiting; sleep 30", Timeout: "200ms"}},
+	}
+
+	start := time.Now()
+	_, err := LaunchAgent(context.Background(), role, LaunchOptions{
+		Name:          "post-launch-timeout",
+		InvocationCWD: t.TempDir(),
+		Fork: func(string, TerminalHints, bool) error {
+			t.Error("fork should not be called when post_launch times out")
+			return nil
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "timed out after 200ms") || !strings.Contains(err.Error(), "waiting") {
+		t.Fatalf("expected post_launch timeout with output, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("launch took %s; the command should have been killed", elapsed)
+	}
+}
+
+func TestTailOutput(t *testing.T) {
+	if got := tailOutput("short", 10); got != "short" {
+		t.Errorf("tailOutput kept %q, want short", got)
+	}
+	if got := tailOutput("0123456789", 4); got != "...\n6789" {
+		t.Errorf("tailOutput = %q, want the last 4 bytes", got)
+	}
+}
@@ -0,0 +1,225 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"h2/internal/config"
+	"h2/internal/tmpl"
+)
+
+// roleWatchInterval is how often the role file is checked for changes when
+// the role sets reload_on_change.
+const roleWatchInterval = 2 * time.Second
+
+// Role fields a reload can apply without restarting the agent process.
+// Automations are re-registered straight away; prompts are recorded in the
+// RuntimeConfig and used the next time the agent process starts. Changes to
+// any other field, apart from the ignored ones, need a restart.
+var (
+	roleReloadLiveFields       = map[string]bool{"heartbeat": true, "triggers": true, "schedules": true}
+	roleReloadNextLaunchFields = map[string]bool{"instructions": true, "system_prompt": true}
+	roleReloadIgnoredFields    = map[string]bool{"description": true, "variables": true, "initial_prompt": true}
+)
+
+// roleFileStamp identifies one version of a role file on disk.
+type roleFileStamp struct {
+	modTime int64
+	size    int64
+}
+
+func statRoleFile(path string) roleFileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return roleFileStamp{}
+	}
+	return roleFileStamp{modTime: info.ModTime().UnixNano(), size: info.Size()}
+}
+
+// loadRuntimeRole renders the agent's role file again the way it was
+// rendered at launch, with its recorded overrides applied.
+func loadRuntimeRole(rc *config.RuntimeConfig) (*config.Role, error) {
+	if rc.RoleName == "" || rc.RoleContext == nil {
+		return nil, fmt.Errorf("agent %q was not launched from a role file", rc.AgentName)
+	}
+	ctx := *rc.RoleContext
+	role, err := config.LoadRoleRenderedWithFuncs(rc.RoleName, &ctx, tmpl.FixedNameFuncs(rc.AgentName))
+	if err != nil {
+		return nil, fmt.Errorf("load role %q: %w", rc.RoleName, err)
+	}
+	if len(rc.Overrides) > 0 {
+		overrides := make([]string, 0, len(rc.Overrides))
+		for k, v := range rc.Overrides {
+			overrides = append(overrides, k+"="+v)
+		}
+		if err := config.ApplyOverrides(role, overrides); err != nil {
+			return nil, fmt.Errorf("apply overrides: %w", err)
+		}
+	}
+	return role, nil
+}
+
+// seedRoleReloadBaseline records the agent's role as launched in RC and the
+// runtime config on disk as the baseline reloads are compared against. A
+// resumed agent already has one, so edits made while it was down are picked
+// up by the first reload.
+func (d *Daemon) seedRoleReloadBaseline() error {
+	d.Session.rcMu.Lock()
+	defer d.Session.rcMu.Unlock()
+	rc := d.Session.RC
+	if rc.RoleFields != nil {
+		return nil
+	}
+	role, err := loadRuntimeRole(rc)
+	if err != nil {
+		return err
+	}
+	fields, err := config.RoleFieldSnapshot(role)
+	if err != nil {
+		return err
+	}
+	rc.RoleFields = fields
+	onDisk, err := config.ReadRuntimeConfig(d.Session.SessionDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("read runtime config: %w", err)
+	}
+	onDisk.RoleFields = fields
+	if err := config.WriteRuntimeConfig(d.Session.SessionDir, onDisk); err != nil {
+		return fmt.Errorf("write runtime config: %w", err)
+	}
+	return nil
+}
+
+// watchRole reloads the agent's role whenever its file changes, and once
+// at the start, until ctx is done. Reload errors are logged and the
+// previous role stays in effect.
+func (d *Daemon) watchRole(ctx context.Context) {
+	var stamp roleFileStamp
+	ticker := time.NewTicker(roleWatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		rc := d.runtimeConfigSnapshot()
+		next := statRoleFile(config.ResolveRolePath(rc.RoleName))
+		if next == stamp {
+			continue
+		}
+		stamp = next
+		role, err := loadRuntimeRole(rc)
+		if err != nil {
+			log.Printf("role reload: %v", err)
+			continue
+		}
+		if err := d.applyRoleReload(role); err != nil {
+			log.Printf("role reload: %v", err)
+		}
+	}
+}
+
+// runtimeConfigSnapshot returns a copy of the session's RuntimeConfig, safe
+// to read while a config relaunch replaces it.
+func (d *Daemon) runtimeConfigSnapshot() *config.RuntimeConfig {
+	d.Session.rcMu.Lock()
+	defer d.Session.rcMu.Unlock()
+	rc := *d.Session.RC
+	return &rc
+}
+
+// applyRoleReload applies the differences between the role as last applied
+// (RC.RoleFields) and the reloaded role next that are safe to change on a
+// running agent, and logs what changed. Fields that need a restart are
+// reported once and left alone. The changed fields are merged into the
+// runtime config on disk, so changes others made to it (e.g. h2 rotate's
+// new profile) are kept.
+func (d *Daemon) applyRoleReload(next *config.Role) error {
+	nextFields, err := config.RoleFieldSnapshot(next)
+	if err != nil {
+		return err
+	}
+
+	d.Session.rcMu.Lock()
+	defer d.Session.rcMu.Unlock()
+	rc := d.Session.RC
+	changed := config.ChangedFields(rc.RoleFields, nextFields)
+	if len(changed) == 0 {
+		return nil
+	}
+	var applied, restart []string
+	for _, field := range changed {
+		switch {
+		case roleReloadLiveFields[field], roleReloadNextLaunchFields[field]:
+			applied = append(applied, field)
+		case !roleReloadIgnoredFields[field]:
+			restart = append(restart, field)
+		}
+	}
+	onDisk, err := config.ReadRuntimeConfig(d.Session.SessionDir)
+	if errors.Is(err, os.ErrNotExist) {
+		copied := *rc
+		onDisk = &copied
+	} else if err != nil {
+		return fmt.Errorf("read runtime config: %w", err)
+	}
+
+	triggersChanged := slices.Contains(changed, "triggers")
+	schedulesChanged := slices.Contains(changed, "heartbeat") || slices.Contains(changed, "schedules")
+	if triggersChanged || schedulesChanged {
+		// Only the role's own entries are replaced; triggers and schedules
+		// added at runtime stay registered.
+		reloaded := &config.RuntimeConfig{}
+		if triggersChanged {
+			for _, ts := range rc.Triggers {
+				d.TriggerEngine.Remove(ts.ID)
+			}
+			reloaded.Triggers = slices.Clone(next.Triggers)
+		}
+		if schedulesChanged {
+			for _, ss := range rc.Schedules {
+				d.ScheduleEngine.Remove(ss.ID)
+			}
+			reloaded.Schedules = roleSchedules(next)
+		}
+		err := d.loadRoleAutomations(reloaded)
+		if triggersChanged {
+			rc.Triggers = reloaded.Triggers
+		}
+		if schedulesChanged {
+			rc.Schedules = reloaded.Schedules
+		}
+		if err != nil {
+			return fmt.Errorf("register reloaded automations: %w", err)
+		}
+	}
+	rc.Instructions = next.GetInstructions()
+	rc.SystemPrompt = next.SystemPrompt
+	rc.RoleFields = nextFields
+	onDisk.Triggers, onDisk.Schedules = rc.Triggers, rc.Schedules
+	onDisk.Instructions, onDisk.SystemPrompt = rc.Instructions, rc.SystemPrompt
+	onDisk.RoleFields = rc.RoleFields
+	if err := config.WriteRuntimeConfig(d.Session.SessionDir, onDisk); err != nil {
+		return fmt.Errorf("write runtime config: %w", err)
+	}
+
+	if len(applied) > 0 {
+		log.Printf("role reload: applied %s", strings.Join(applied, ", "))
+	}
+	if len(restart) > 0 {
+		log.Printf("role reload: %s changed; requires restart, ignored until then", strings.Join(restart, ", "))
+	}
+	if al := d.Session.activityLog; al != nil && (len(applied) > 0 || len(restart) > 0) {
+		al.RoleReload(applied, restart)
+	}
+	return nil
+}
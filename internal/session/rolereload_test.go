@@ -0,0 +1,210 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"h2/internal/automation"
+	"h2/internal/config"
+	"h2/internal/tmpl"
+)
+
+func TestLoadRuntimeRole_RendersWithLaunchContext(t *testing.T) {
+	h2Dir := setupLaunchTestH2Dir(t)
+	roleYAML := `
+role_name: sched
+agent_name: "{{ randomName }}"
+agent_harness: claude_code
+variables:
+  queue:
+    description: "Queue to watch"
+heartbeat:
+  idle_timeout: 1m
+  message: "{{ .AgentName }}: check {{ .Var.queue }}"
+`
+	if err := os.WriteFile(filepath.Join(h2Dir, "roles", "sched.yaml"), []byte(roleYAML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rc := &config.RuntimeConfig{
+		AgentName:   "sched-1",
+		RoleName:    "sched",
+		RoleContext: &tmpl.Context{AgentName: "sched-1", RoleName: "sched", Var: map[string]string{"queue": "inbox"}},
+		Overrides:   map[string]string{"heartbeat.idle_timeout": "5m"},
+	}
+
+	role, err := loadRuntimeRole(rc)
+	if err != nil {
+		t.Fatalf("loadRuntimeRole: %v", err)
+	}
+	if role.AgentName != "sched-1" {
+		t.Errorf("AgentName = %q, want sched-1", role.AgentName)
+	}
+	if role.Heartbeat.Message != "sched-1: check inbox" {
+		t.Errorf("Heartbeat.Message = %q", role.Heartbeat.Message)
+	}
+	if role.Heartbeat.IdleTimeout != "5m" {
+		t.Errorf("Heartbeat.IdleTimeout = %q, want the 5m override", role.Heartbeat.IdleTimeout)
+	}
+
+	if _, err := loadRuntimeRole(&config.RuntimeConfig{AgentName: "bare"}); err == nil {
+		t.Error("expected an error for an agent launched without a role")
+	}
+}
+
+func TestApplyRoleReload(t *testing.T) {
+	setupLaunchTestH2Dir(t)
+	d := newTestDaemonWithEngines(t)
+	d.Session.SessionDir = t.TempDir()
+	rc := d.Session.RC
+
+	prev := &config.Role{
+		RoleName:     "sched",
+		AgentHarness: "claude_code",
+		WorkingDir:   ".",
+		Instructions: "Watch the inbox.",
+		Heartbeat:    &config.HeartbeatConfig{IdleTimeout: "1m", Message: "old"},
+	}
+	rc.Instructions = prev.GetInstructions()
+	rc.Schedules = roleSchedules(prev)
+	rc.RoleFields = roleFields(t, prev)
+	if err := d.loadRoleAutomations(rc); err != nil {
+		t.Fatalf("loadRoleAutomations: %v", err)
+	}
+	// Added at runtime, so a reload must leave it alone.
+	if err := d.ScheduleEngine.Add(&automation.Schedule{
+		ID: "runtime", RRule: "FREQ=HOURLY", Action: automation.Action{Message: "hourly"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	next := *prev
+	next.Heartbeat = &config.HeartbeatConfig{IdleTimeout: "2m", Message: "new"}
+	next.Instructions = "Watch the outbox."
+	next.WorkingDir = "/srv"
+	next.AgentHarness = "codex"
+	if err := d.applyRoleReload(&next); err != nil {
+		t.Fatalf("applyRoleReload: %v", err)
+	}
+
+	schedules := map[string]*automation.Schedule{}
+	for _, s := range d.ScheduleEngine.List() {
+		schedules[s.ID] = s
+	}
+	if hb := schedules["heartbeat"]; hb == nil || hb.Action.Message != "new" || hb.RRule != "FREQ=SECONDLY;INTERVAL=120" {
+		t.Errorf("heartbeat schedule = %+v, want the reloaded one", hb)
+	}
+	if schedules["runtime"] == nil {
+		t.Error("runtime schedule should survive the reload")
+	}
+	if rc.Instructions != "Watch the outbox." {
+		t.Errorf("rc.Instructions = %q, want the reloaded instructions", rc.Instructions)
+	}
+	if rc.CWD != "/tmp" || rc.HarnessType != "generic" {
+		t.Errorf("restart-only fields changed: cwd=%q harness=%q", rc.CWD, rc.HarnessType)
+	}
+
+	onDisk, err := config.ReadRuntimeConfig(d.Session.SessionDir)
+	if err != nil {
+		t.Fatalf("ReadRuntimeConfig: %v", err)
+	}
+	if onDisk.Instructions != "Watch the outbox." || !reflect.DeepEqual(onDisk.Schedules, rc.Schedules) {
+		t.Errorf("runtime config on disk not updated: %+v", onDisk)
+	}
+	if !reflect.DeepEqual(onDisk.RoleFields, roleFields(t, &next)) {
+		t.Errorf("role fields on disk = %v, want the reloaded role's", onDisk.RoleFields)
+	}
+}
+
+func roleFields(t *testing.T, role *config.Role) map[string]string {
+	t.Helper()
+	fields, err := config.RoleFieldSnapshot(role)
+	if err != nil {
+		t.Fatalf("RoleFieldSnapshot: %v", err)
+	}
+	return fields
+}
+
+func TestSeedRoleReloadBaseline_KeepsPersistedBaseline(t *testing.T) {
+	h2Dir := setupLaunchTestH2Dir(t)
+	rolePath := filepath.Join(h2Dir, "roles", "sched.yaml")
+	writeRole := func(instructions string) {
+		t.Helper()
+		data := "role_name: sched\nagent_harness: claude_code\ninstructions: " + instructions + "\n"
+		if err := os.WriteFile(rolePath, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d := newTestDaemonWithEngines(t)
+	d.Session.SessionDir = t.TempDir()
+	rc := d.Session.RC
+	rc.RoleName = "sched"
+	rc.RoleContext = &tmpl.Context{AgentName: rc.AgentName, RoleName: "sched"}
+	if err := config.WriteRuntimeConfig(d.Session.SessionDir, rc); err != nil {
+		t.Fatal(err)
+	}
+
+	// First start: the baseline is the role as launched, saved to disk.
+	writeRole("Watch the inbox.")
+	if err := d.seedRoleReloadBaseline(); err != nil {
+		t.Fatalf("seedRoleReloadBaseline: %v", err)
+	}
+	onDisk, err := config.ReadRuntimeConfig(d.Session.SessionDir)
+	if err != nil {
+		t.Fatalf("ReadRuntimeConfig: %v", err)
+	}
+	if onDisk.RoleFields["instructions"] != "Watch the inbox." {
+		t.Fatalf("baseline on disk = %v, want the launched role", onDisk.RoleFields)
+	}
+
+	// Edited while the agent was down, then resumed from the runtime config.
+	writeRole("Watch the outbox.")
+	rc.RoleFields = onDisk.RoleFields
+	if err := d.seedRoleReloadBaseline(); err != nil {
+		t.Fatalf("seedRoleReloadBaseline: %v", err)
+	}
+	if rc.RoleFields["instructions"] != "Watch the inbox." {
+		t.Fatalf("resume re-seeded the baseline from the edited file: %v", rc.RoleFields)
+	}
+	role, err := loadRuntimeRole(rc)
+	if err != nil {
+		t.Fatalf("loadRuntimeRole: %v", err)
+	}
+	if err := d.applyRoleReload(role); err != nil {
+		t.Fatalf("applyRoleReload: %v", err)
+	}
+	if rc.Instructions != "Watch the outbox." {
+		t.Errorf("rc.Instructions = %q, want the edit made while down applied", rc.Instructions)
+	}
+}
+
+func TestApplyRoleReload_KeepsRuntimeConfigChangesOnDisk(t *testing.T) {
+	setupLaunchTestH2Dir(t)
+	d := newTestDaemonWithEngines(t)
+	d.Session.SessionDir = t.TempDir()
+	rc := d.Session.RC
+
+	// h2 rotate rewrote the runtime config since the agent started.
+	rotated := *rc
+	rotated.Profile = "backup"
+	if err := config.WriteRuntimeConfig(d.Session.SessionDir, &rotated); err != nil {
+		t.Fatal(err)
+	}
+
+	prev := &config.Role{RoleName: "r", Instructions: "old"}
+	rc.RoleFields = roleFields(t, prev)
+	next := *prev
+	next.Instructions = "new"
+	if err := d.applyRoleReload(&next); err != nil {
+		t.Fatalf("applyRoleReload: %v", err)
+	}
+
+	onDisk, err := config.ReadRuntimeConfig(d.Session.SessionDir)
+	if err != nil {
+		t.Fatalf("ReadRuntimeConfig: %v", err)
+	}
+	if onDisk.Profile != "backup" || onDisk.Instructions != "new" {
+		t.Errorf("on disk profile=%q instructions=%q, want the rotated profile kept and the new instructions", onDisk.Profile, onDisk.Instructions)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime/debug"
 	"sync"
 	"time"
@@ -59,6 +60,36 @@ type Session struct {
 	// ExtraEnv holds additional environment variables to pass to the child process.
 	ExtraEnv map[string]string
 
+	// InlineMessageLength is the longest message body typed into the agent
+	// directly (messages.inline_max_length; 0 = default).
+	InlineMessageLength int
+
+	// logRotation is how the activity log is rotated (activity_log in
+	// config.yaml); the zero value never rotates.
+	logRotation activitylog.Rotation
+
+	// Terminal holds terminal UI settings from config.yaml (nil = defaults).
+	Terminal *config.TerminalConfig
+	// highlights are Terminal.Highlights compiled once and shared by clients.
+	highlights []client.HighlightRule
+	// barTheme is Terminal.Theme resolved against the default bar theme.
+	barTheme *client.BarTheme
+
+	// detachedScroll is the scroll position of the last client to detach,
+	// restored on the next attach. Nil if it wasn't scrolled.
+	detachedScroll *client.ScrollState
+	// historyStore persists scroll history when Terminal.PersistScrollback
+	// is set (nil otherwise).
+	historyStore *virtualterminal.HistoryStore
+	// resumed is set when the daemon was started with --resume; only a
+	// resumed session restores persisted scroll history.
+	resumed bool
+
+	// rcMu serializes changes to RC, and the runtime config writes that go
+	// with them, between the lifecycle loop's config relaunch and the role
+	// file watcher.
+	rcMu sync.Mutex
+
 	// Daemon holds the networking/attach layer (nil in interactive mode).
 	Daemon    *Daemon
 	StartTime time.Time
@@ -138,6 +169,22 @@ func (s *Session) initVT(rows, cols int) {
 	s.VT = &virtualterminal.VT{}
 	s.VT.Rows = rows
 	s.VT.Cols = cols
+	s.VT.TrimHeld = s.anyClientScrolling
+	if s.Terminal != nil {
+		s.VT.SetScrollbackLimit(s.Terminal.ScrollbackLines)
+	}
+}
+
+// anyClientScrolling reports whether a client is in scroll mode, so the VT
+// holds off trimming the lines it is showing. Clients following live output
+// at the bottom re-anchor after every chunk, so they don't hold trims.
+// Called with VT.Mu held.
+func (s *Session) anyClientScrolling() bool {
+	scrolling := false
+	s.ForEachClient(func(cl *client.Client) {
+		scrolling = scrolling || (cl.IsScrollMode() && !(cl.ScrollFollow && cl.ScrollOffset == 0))
+	})
+	return scrolling
 }
 
 // setupAgent configures the agent harness and launch config. Sets up
@@ -146,10 +193,11 @@ func (s *Session) initVT(rows, cols int) {
 // the session's ExtraEnv and prependArgs.
 func (s *Session) setupAgent() error {
 	// Set up activity logger.
-	logDir := filepath.Join(config.ConfigDir(), "logs")
-	os.MkdirAll(logDir, 0o755)
-	logPath := filepath.Join(logDir, "session-activity.jsonl")
+	logPath := ActivityLogPath()
+	os.MkdirAll(filepath.Dir(logPath), 0o755)
 	actLog := activitylog.New(true, logPath, s.RC.AgentName, s.RC.SessionID)
+	actLog.SetSecrets(s.RC.SecretValues)
+	actLog.SetRotation(s.logRotation)
 	s.activityLog = actLog
 
 	// Resolve harness from RuntimeConfig.
@@ -196,14 +244,37 @@ func (s *Session) childArgs() []string {
 	return s.harness.BuildCommandArgs(s.prependArgs, s.RC.Args)
 }
 
+// childCommand returns the command and args to start the child with. It
+// reads RC under rcMu, since the role file watcher may be updating it.
+func (s *Session) childCommand() (string, []string) {
+	s.rcMu.Lock()
+	defer s.rcMu.Unlock()
+	return s.RC.Command, s.childArgs()
+}
+
 // NewClient creates a new Client with all session callbacks wired.
 func (s *Session) NewClient() *client.Client {
 	cl := &client.Client{
 		VT:        s.VT,
 		Output:    io.Discard, // overridden by caller (attach sets frameWriter, interactive sets os.Stdout)
 		AgentName: s.Name(),
+		ExportDir: s.SessionDir,
 	}
 	cl.InitClient()
+	if s.Terminal != nil {
+		cl.OSC52Copy = s.Terminal.OSC52Copy
+		cl.SelectHintText = s.Terminal.SelectHint
+		// Invalid durations are rejected when config.yaml is loaded.
+		cl.SelectHintDuration, _ = s.Terminal.SelectHintTimeout()
+		cl.ShowClock = s.Terminal.StatusClock
+		cl.ShowIdleTimer = s.Terminal.StatusIdleTimer
+		// Unset keys parse to 0, keeping Ctrl+\; invalid ones are
+		// rejected when config.yaml is loaded.
+		cl.MenuKey, _ = config.ParseControlKey(s.Terminal.MenuKey)
+		cl.PassthroughExitKey, _ = config.ParseControlKey(s.Terminal.PassthroughExitKey)
+	}
+	cl.Highlights = s.highlights
+	cl.Theme = s.barTheme
 
 	// Wire lifecycle callbacks.
 	cl.OnRelaunch = func() {
@@ -235,6 +306,10 @@ func (s *Session) NewClient() *client.Client {
 			s.PassthroughOwner = nil
 			s.Queue.Unpause()
 		}
+		// Catch up on trims held while this client was scrolling.
+		if !cl.IsScrollMode() {
+			s.VT.TrimScrollback()
+		}
 	}
 
 	// Passthrough locking callbacks.
@@ -289,6 +364,9 @@ func (s *Session) NewClient() *client.Client {
 	cl.HookState = func() string {
 		return s.ActivitySnapshot().LastToolName
 	}
+	cl.LastActivity = func() time.Time {
+		return s.ActivitySnapshot().LastActivityAt
+	}
 	cl.OnInterrupt = func() {
 		s.SignalInterrupt()
 	}
@@ -342,6 +420,8 @@ func (s *Session) pipeOutputCallback() func() {
 		s.ForEachClient(func(cl *client.Client) {
 			if !cl.IsScrollMode() {
 				cl.RenderScreen()
+			} else {
+				cl.FollowOutput()
 			}
 		})
 	}
@@ -387,6 +467,7 @@ func (s *Session) RunDaemon() error {
 	s.VT.Scrollback = midterm.NewTerminal(s.VT.ChildRows, s.VT.Cols)
 	s.VT.Scrollback.AutoResizeY = true
 	s.VT.Scrollback.AppendOnly = true
+	s.restoreScrollHistory()
 
 	s.VT.LastOut = time.Now()
 	s.VT.Output = io.Discard
@@ -428,7 +509,8 @@ func (s *Session) RunDaemon() error {
 	}
 
 	// Start child in a PTY.
-	if err := s.VT.StartPTY(s.RC.Command, s.childArgs(), s.VT.ChildRows, s.VT.Cols, s.ExtraEnv); err != nil {
+	command, args := s.childCommand()
+	if err := s.VT.StartPTY(command, args, s.VT.ChildRows, s.VT.Cols, s.ExtraEnv); err != nil {
 		return err
 	}
 	// Don't forward requests to stdout in daemon mode - there's no terminal.
@@ -443,6 +525,9 @@ func (s *Session) RunDaemon() error {
 	// Update status bar every second.
 	stopStatus := make(chan struct{})
 	go s.TickStatus(stopStatus)
+	if s.historyStore != nil {
+		go s.persistScrollHistory(stopStatus)
+	}
 
 	// Pipe child output to virtual terminal.
 	go s.VT.PipeOutput(s.pipeOutputCallback())
@@ -514,7 +599,8 @@ func (s *Session) RunInteractive() error {
 	s.ExtraEnv["H2_ACTOR"] = s.Name()
 
 	// Start child in a PTY.
-	if err := s.VT.StartPTY(s.RC.Command, s.childArgs(), s.VT.ChildRows, cols, s.ExtraEnv); err != nil {
+	command, args := s.childCommand()
+	if err := s.VT.StartPTY(command, args, s.VT.ChildRows, cols, s.ExtraEnv); err != nil {
 		return err
 	}
 	s.VT.Vt.ForwardRequests = os.Stdout
@@ -591,7 +677,10 @@ func (s *Session) lifecycleLoop(stopStatus chan struct{}, interactive bool) erro
 			// If a config-changing relaunch was requested (e.g. profile
 			// rotation), stop the old agent pipeline, re-read the config,
 			// and re-setup the harness before starting the new child.
+			// RC is only read under rcMu here, since the role file
+			// watcher may be updating it.
 			restartPipeline := false
+			s.rcMu.Lock()
 			if s.relaunchWithSetup {
 				s.relaunchWithSetup = false
 				if err := s.configRelaunch(); err != nil {
@@ -607,8 +696,10 @@ func (s *Session) lifecycleLoop(stopStatus chan struct{}, interactive bool) erro
 					}
 				}
 			}
+			command, args, profile := s.RC.Command, s.childArgs(), s.RC.Profile
+			s.rcMu.Unlock()
 
-			if err := s.VT.StartPTY(s.RC.Command, s.childArgs(), s.VT.ChildRows, s.VT.Cols, s.ExtraEnv); err != nil {
+			if err := s.VT.StartPTY(command, args, s.VT.ChildRows, s.VT.Cols, s.ExtraEnv); err != nil {
 				close(stopStatus)
 				s.Stop()
 				return err
@@ -622,7 +713,9 @@ func (s *Session) lifecycleLoop(stopStatus chan struct{}, interactive bool) erro
 			s.VT.Scrollback = midterm.NewTerminal(s.VT.ChildRows, s.VT.Cols)
 			s.VT.Scrollback.AutoResizeY = true
 			s.VT.Scrollback.AppendOnly = true
+			s.VT.ScrollbackMarkers = nil
 			s.VT.ResetScanState()
+			s.flushScrollHistory()
 			s.VT.ResetScrollHistory()
 
 			func() {
@@ -656,7 +749,7 @@ func (s *Session) lifecycleLoop(stopStatus chan struct{}, interactive bool) erro
 					Timestamp: now,
 					Data: monitor.SessionRotatedData{
 						OldProfile: s.relaunchOldProfile,
-						NewProfile: s.RC.Profile,
+						NewProfile: profile,
 					},
 				})
 			}
@@ -707,6 +800,61 @@ func (s *Session) TickStatus(stop <-chan struct{}) {
 	}
 }
 
+// scrollHistoryFlushInterval is how often captured scroll history is
+// written to the session dir when persistence is enabled.
+const scrollHistoryFlushInterval = 5 * time.Second
+
+// restoreScrollHistory loads scroll history persisted by a previous session
+// process into the VT and starts persisting new history. A fresh (not
+// resumed) session discards history left behind by an earlier agent of the
+// same name instead. No-op unless terminal.persist_scrollback is enabled.
+func (s *Session) restoreScrollHistory() {
+	if s.Terminal == nil || !s.Terminal.PersistScrollback || s.SessionDir == "" {
+		return
+	}
+	s.historyStore = virtualterminal.NewHistoryStore(s.SessionDir)
+	if !s.resumed {
+		if err := s.historyStore.Clear(); err != nil {
+			log.Printf("warning: clear scroll history: %v", err)
+		}
+		return
+	}
+	entries, err := s.historyStore.Load(virtualterminal.DefaultScrollHistoryMax)
+	if err != nil {
+		log.Printf("warning: restore scroll history: %v", err)
+		return
+	}
+	s.VT.RestoreScrollHistory(entries)
+}
+
+// persistScrollHistory flushes scroll history to disk periodically until stop
+// is closed.
+func (s *Session) persistScrollHistory(stop <-chan struct{}) {
+	ticker := time.NewTicker(scrollHistoryFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.flushScrollHistory()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// flushScrollHistory writes scroll history captured since the last flush.
+func (s *Session) flushScrollHistory() {
+	if s.historyStore == nil || s.VT == nil {
+		return
+	}
+	s.VT.Mu.Lock()
+	pending := s.historyStore.TakePending(s.VT)
+	s.VT.Mu.Unlock()
+	if err := s.historyStore.Append(pending); err != nil {
+		log.Printf("warning: persist scroll history: %v", err)
+	}
+}
+
 // OtelPort returns the OTEL collector port when supported by the harness.
 func (s *Session) OtelPort() int {
 	type otelPorter interface {
@@ -738,6 +886,22 @@ func (s *Session) WaitForState(ctx context.Context, target monitor.State) bool {
 	return s.monitor.WaitForState(ctx, target)
 }
 
+// waitForTurnEnd blocks until the agent is no longer active, i.e. its
+// current turn (if any) has finished, or ctx is cancelled.
+func (s *Session) waitForTurnEnd(ctx context.Context) bool {
+	for {
+		ch := s.StateChanged()
+		if st, _ := s.State(); st != monitor.StateActive {
+			return true
+		}
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
 // StateDuration returns how long the agent has been in its current state.
 func (s *Session) StateDuration() time.Duration {
 	return s.monitor.StateDuration()
@@ -829,8 +993,14 @@ func (s *Session) StartServices() {
 		SignalInterrupt: func() {
 			s.SignalInterrupt()
 		},
-		OnDeliver: s.OnDeliver,
-		Stop:      s.stopCh,
+		MarkInterrupt: func() {
+			s.VT.Mu.Lock()
+			defer s.VT.Mu.Unlock()
+			s.VT.AddMarker(virtualterminal.InterruptMarkerLabel)
+		},
+		OnDeliver:       s.OnDeliver,
+		InlineMaxLength: s.InlineMessageLength,
+		Stop:            s.stopCh,
 	})
 }
 
@@ -843,6 +1013,8 @@ func (s *Session) Stop() {
 		close(s.stopCh)
 	}
 
+	s.flushScrollHistory()
+
 	// Gather session summary data before stopping the agent (which closes files).
 	summary := s.buildSessionSummary()
 	if s.activityLog != nil {
@@ -945,3 +1117,52 @@ func (s *Session) stopAgentPipeline() {
 		s.harness.Stop()
 	}
 }
+
+// SetTerminalConfig sets the terminal UI settings and precompiles the
+// highlight rules and status bar theme shared by all clients. Rules are
+// validated when config.yaml is loaded; any that fail to compile here are
+// skipped.
+func (s *Session) SetTerminalConfig(tc *config.TerminalConfig) {
+	s.Terminal = tc
+	s.highlights = nil
+	s.barTheme = nil
+	if tc == nil {
+		return
+	}
+	s.barTheme = resolveBarTheme(tc.Theme)
+	for _, rule := range tc.Highlights {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			continue
+		}
+		sgr, err := rule.SGR()
+		if err != nil {
+			continue
+		}
+		s.highlights = append(s.highlights, client.HighlightRule{Pattern: re, SGR: sgr})
+	}
+}
+
+// resolveBarTheme returns the default bar theme with the styles set in t
+// applied, or nil when t sets nothing.
+func resolveBarTheme(t *config.StatusBarTheme) *client.BarTheme {
+	if t == nil {
+		return nil
+	}
+	theme := client.DefaultBarTheme
+	set := func(dst *string, sgr string) {
+		if sgr != "" {
+			*dst = "\033[0;" + sgr + "m"
+		}
+	}
+	set(&theme.Normal, t.Normal)
+	set(&theme.Passthrough, t.Passthrough)
+	set(&theme.Menu, t.Menu)
+	set(&theme.Scroll, t.Scroll)
+	set(&theme.Exited, t.Exited)
+	set(&theme.ExitedScroll, t.ExitedScroll)
+	if theme == client.DefaultBarTheme {
+		return nil
+	}
+	return &theme
+}
@@ -889,3 +889,87 @@ func TestSetupAgent_LogDirUsesH2Dir(t *testing.T) {
 		t.Fatalf("activity log not created at expected path %s", logPath)
 	}
 }
+
+func TestSetTerminalConfig_BarTheme(t *testing.T) {
+	s := &Session{}
+	s.SetTerminalConfig(&config.TerminalConfig{Theme: &config.StatusBarTheme{Scroll: "7;34"}})
+	if s.barTheme == nil {
+		t.Fatal("expected a bar theme")
+	}
+	if s.barTheme.Scroll != "\033[0;7;34m" {
+		t.Errorf("Scroll = %q, want %q", s.barTheme.Scroll, "\033[0;7;34m")
+	}
+	if s.barTheme.Exited != client.DefaultBarTheme.Exited {
+		t.Errorf("Exited = %q, want default %q", s.barTheme.Exited, client.DefaultBarTheme.Exited)
+	}
+
+	s.SetTerminalConfig(&config.TerminalConfig{})
+	if s.barTheme != nil {
+		t.Errorf("expected no bar theme without terminal.theme, got %+v", s.barTheme)
+	}
+}
+
+func TestScrollbackTrim_HeldWhileClientScrolls(t *testing.T) {
+	s := NewFromConfig(&config.RuntimeConfig{
+		AgentName:   "test",
+		Command:     "true",
+		HarnessType: "generic",
+		SessionID:   "test-uuid",
+		CWD:         "/tmp",
+		StartedAt:   "2024-01-01T00:00:00Z",
+	})
+	s.SetTerminalConfig(&config.TerminalConfig{ScrollbackLines: 3})
+	s.initVT(4, 20)
+	s.VT.ChildRows = 2
+	s.VT.Vt = midterm.NewTerminal(2, 20)
+	s.VT.SetupScrollCapture()
+
+	cl := s.NewClient()
+	s.AddClient(cl)
+	cl.EnterScrollMode()
+
+	s.VT.Vt.Write([]byte("1\r\n2\r\n3\r\n4\r\n5\r\n6\r\n"))
+	if len(s.VT.ScrollHistory) <= 3 {
+		t.Fatalf("len(ScrollHistory) = %d, want untrimmed while scrolling", len(s.VT.ScrollHistory))
+	}
+
+	cl.ExitScrollMode()
+	if len(s.VT.ScrollHistory) != 3 {
+		t.Errorf("len(ScrollHistory) = %d, want 3 after leaving scroll mode", len(s.VT.ScrollHistory))
+	}
+}
+
+func TestRestoreScrollHistory_OnlyOnResume(t *testing.T) {
+	for _, resumed := range []bool{false, true} {
+		dir := t.TempDir()
+		store := virtualterminal.NewHistoryStore(dir)
+		if err := store.Append([]virtualterminal.ScrollHistoryEntry{{Content: []rune("old output")}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, virtualterminal.ScrollHistoryFile+".1"), []byte("{}\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		s := NewFromConfig(testRC("test", "true", nil))
+		s.VT = &virtualterminal.VT{}
+		s.SessionDir = dir
+		s.Terminal = &config.TerminalConfig{PersistScrollback: true}
+		s.resumed = resumed
+		s.restoreScrollHistory()
+
+		if resumed {
+			if len(s.VT.ScrollHistory) == 0 || string(s.VT.ScrollHistory[len(s.VT.ScrollHistory)-1].Content) != "old output" {
+				t.Errorf("resumed: history = %+v, want the persisted output", s.VT.ScrollHistory)
+			}
+			continue
+		}
+		if len(s.VT.ScrollHistory) != 0 {
+			t.Errorf("fresh start: history = %+v, want none", s.VT.ScrollHistory)
+		}
+		for _, name := range []string{virtualterminal.ScrollHistoryFile, virtualterminal.ScrollHistoryFile + ".1"} {
+			if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+				t.Errorf("fresh start: %s still exists (err=%v)", name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package virtualterminal
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/muesli/termenv"
+	"github.com/vito/midterm"
+)
+
+// DefaultExportMaxBytes bounds the size of an HTML scrollback export. The
+// oldest lines are dropped first.
+const DefaultExportMaxBytes = 8 << 20
+
+// Page colors for HTML exports, used for cells in the default colors.
+const (
+	exportFg = "#d4d4d4"
+	exportBg = "#1e1e1e"
+)
+
+// Transcript returns every line of the agent's output, oldest first, as
+// ScrollHistoryEntries: ScrollHistory followed by the live screen when the
+// child uses scroll regions (or history was restored), otherwise each row
+// of Scrollback. Trailing blank lines are dropped. Must be called with
+// vt.Mu held.
+func (vt *VT) Transcript() []ScrollHistoryEntry {
+	var lines []ScrollHistoryEntry
+	if len(vt.ScrollHistory) > 0 && (vt.ScrollRegionUsed || vt.RestoredHistory > 0) {
+		lines = append(lines, vt.ScrollHistory...)
+		if vt.Vt != nil {
+			for row := range vt.Vt.Content {
+				lines = append(lines, TerminalRowEntry(vt.Vt, row))
+			}
+		}
+	} else if vt.Scrollback != nil {
+		for row := range vt.Scrollback.Content {
+			lines = append(lines, TerminalRowEntry(vt.Scrollback, row))
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(string(lines[len(lines)-1].Content)) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// TerminalRowEntry copies row of t, with its formatting, into a
+// ScrollHistoryEntry.
+func TerminalRowEntry(t *midterm.Terminal, row int) ScrollHistoryEntry {
+	entry := ScrollHistoryEntry{Content: append([]rune(nil), t.Content[row]...)}
+	for region := range t.Format.Regions(row) {
+		var url string
+		if region.URLID != 0 {
+			url = t.URL(region.URLID)
+		}
+		entry.Runs = append(entry.Runs, FormatRun{Size: region.Size, Format: region.F, URL: url})
+	}
+	return entry
+}
+
+// PlainTextScrollback returns the transcript as plain text, one line per
+// row with trailing spaces removed, for the text copy of a scrollback
+// export. Must be called with vt.Mu held.
+func (vt *VT) PlainTextScrollback() string {
+	var b strings.Builder
+	for _, line := range vt.Transcript() {
+		b.WriteString(strings.TrimRight(string(line.Content), " \x00"))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// HTMLScrollback renders the transcript as a standalone HTML page. Each run
+// of cells becomes an inline-styled span carrying its colors and
+// attributes, and cell text is HTML-escaped. When the page would exceed
+// maxBytes, the oldest lines are replaced by a note saying how many were
+// omitted. Must be called with vt.Mu held.
+func (vt *VT) HTMLScrollback(title string, maxBytes int) string {
+	head := fmt.Sprintf("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n"+
+		"<body style=\"margin:0;background:%s\">\n<pre style=\"margin:0;padding:1em;color:%s;background:%s;font-family:monospace\">\n",
+		html.EscapeString(title), exportBg, exportFg, exportBg)
+	const tail = "</pre>\n</body>\n</html>\n"
+
+	lines := vt.Transcript()
+	rendered := make([]string, len(lines))
+	for i, line := range lines {
+		rendered[i] = htmlLine(line)
+	}
+
+	// Keep the newest lines that fit, leaving room for the omitted note.
+	budget := maxBytes - len(head) - len(tail) - 64
+	first := len(rendered)
+	for first > 0 && budget-len(rendered[first-1]) >= 0 {
+		first--
+		budget -= len(rendered[first])
+	}
+
+	var b strings.Builder
+	b.WriteString(head)
+	if first > 0 {
+		fmt.Fprintf(&b, "<i style=\"opacity:0.6\">… %d earlier lines omitted</i>\n", first)
+	}
+	for _, line := range rendered[first:] {
+		b.WriteString(line)
+	}
+	b.WriteString(tail)
+	return b.String()
+}
+
+// htmlLine renders one transcript line, with trailing blank cells trimmed.
+func htmlLine(line ScrollHistoryEntry) string {
+	content := line.Content
+	end := len(content)
+	for end > 0 && (content[end-1] == ' ' || content[end-1] == 0) {
+		end--
+	}
+
+	var b strings.Builder
+	pos := 0
+	for _, run := range line.Runs {
+		if pos >= end {
+			break
+		}
+		stop := min(pos+run.Size, end)
+		text := html.EscapeString(strings.ReplaceAll(string(content[pos:stop]), "\x00", " "))
+		if style := cssStyle(run.Format); style != "" {
+			fmt.Fprintf(&b, "<span style=\"%s\">%s</span>", style, text)
+		} else {
+			b.WriteString(text)
+		}
+		pos = stop
+	}
+	if pos < end {
+		b.WriteString(html.EscapeString(string(content[pos:end])))
+	}
+	b.WriteByte('\n')
+	return b.String()
+}
+
+// cssStyle returns inline CSS for a cell format, or "" for default cells.
+func cssStyle(f midterm.Format) string {
+	fg, bg := cssColor(f.Fg), cssColor(f.Bg)
+	if f.IsReverse() {
+		if fg == "" {
+			fg = exportFg
+		}
+		if bg == "" {
+			bg = exportBg
+		}
+		fg, bg = bg, fg
+	}
+
+	var parts []string
+	if fg != "" {
+		parts = append(parts, "color:"+fg)
+	}
+	if bg != "" {
+		parts = append(parts, "background:"+bg)
+	}
+	if f.IsBold() {
+		parts = append(parts, "font-weight:bold")
+	}
+	if f.IsFaint() {
+		parts = append(parts, "opacity:0.6")
+	}
+	if f.IsItalic() {
+		parts = append(parts, "font-style:italic")
+	}
+	if f.IsUnderline() {
+		parts = append(parts, "text-decoration:underline")
+	}
+	if f.IsConceal() {
+		parts = append(parts, "visibility:hidden")
+	}
+	return strings.Join(parts, ";")
+}
+
+// cssColor converts a cell color to a CSS hex color, or "" for the
+// terminal's default color.
+func cssColor(c termenv.Color) string {
+	switch c.(type) {
+	case nil, termenv.NoColor:
+		return ""
+	}
+	return termenv.ConvertToRGB(c).Hex()
+}
@@ -0,0 +1,76 @@
+package virtualterminal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vito/midterm"
+)
+
+func newExportTestVT(output string) *VT {
+	vt := &VT{Scrollback: midterm.NewTerminal(6, 30)}
+	vt.Scrollback.Write([]byte(output))
+	return vt
+}
+
+func TestPlainTextScrollback(t *testing.T) {
+	vt := newExportTestVT("hello\r\n\033[31mred\033[0m text\r\n")
+
+	got := vt.PlainTextScrollback()
+	if got != "hello\nred text\n" {
+		t.Fatalf("PlainTextScrollback() = %q", got)
+	}
+}
+
+func TestTranscript_PrefersScrollHistory(t *testing.T) {
+	vt := newExportTestVT("scrollback only\r\n")
+	vt.Vt = midterm.NewTerminal(2, 30)
+	vt.Vt.Write([]byte("live"))
+	vt.ScrollHistory = []ScrollHistoryEntry{{Content: []rune("old"), Runs: []FormatRun{{Size: 3}}}}
+	vt.ScrollRegionUsed = true
+
+	got := vt.PlainTextScrollback()
+	if got != "old\nlive\n" {
+		t.Fatalf("PlainTextScrollback() = %q, want history then live screen", got)
+	}
+}
+
+func TestHTMLScrollback_StylesAndEscapes(t *testing.T) {
+	vt := newExportTestVT("\033[1;31m<b>&\033[0m plain\r\n\033[7minv\033[0m\r\n")
+
+	page := vt.HTMLScrollback("coder <scrollback>", DefaultExportMaxBytes)
+
+	for _, want := range []string{
+		"<title>coder &lt;scrollback&gt;</title>",
+		`<span style="color:#800000;font-weight:bold">&lt;b&gt;&amp;</span> plain` + "\n",
+		`<span style="color:#1e1e1e;background:#d4d4d4">inv</span>` + "\n",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page missing %q:\n%s", want, page)
+		}
+	}
+	if strings.Contains(page, "<b>") {
+		t.Errorf("cell text was not escaped:\n%s", page)
+	}
+}
+
+func TestHTMLScrollback_BoundsSize(t *testing.T) {
+	var out strings.Builder
+	for i := 0; i < 5; i++ {
+		out.WriteString(strings.Repeat("x", 20) + "\r\n")
+	}
+	vt := newExportTestVT(out.String())
+
+	full := vt.HTMLScrollback("t", DefaultExportMaxBytes)
+	limit := len(full) - 10
+	page := vt.HTMLScrollback("t", limit)
+	if len(page) > limit {
+		t.Errorf("len(page) = %d, want <= %d", len(page), limit)
+	}
+	if !strings.Contains(page, "earlier lines omitted") {
+		t.Errorf("expected an omitted-lines note:\n%s", page)
+	}
+	if strings.Count(page, strings.Repeat("x", 20)) == 0 {
+		t.Errorf("expected the newest lines to be kept:\n%s", page)
+	}
+}
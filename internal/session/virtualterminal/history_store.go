@@ -0,0 +1,205 @@
+package virtualterminal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/muesli/termenv"
+	"github.com/vito/midterm"
+)
+
+// ScrollHistoryFile is the session-dir file persisted scroll history is
+// appended to. When it grows past the size limit it is rotated to
+// ScrollHistoryFile+".1", replacing any previous rotation.
+const ScrollHistoryFile = "scrollback.jsonl"
+
+// defaultHistoryFileMaxBytes bounds each history file, so at most about
+// twice this is kept on disk per session.
+const defaultHistoryFileMaxBytes = 4 << 20
+
+// HistoryStore persists ScrollHistory entries to a session dir so scroll
+// mode can page through output from before a session process restart.
+type HistoryStore struct {
+	path     string
+	maxBytes int64
+	flushed  int // VT.scrollHistoryTotal as of the last TakePending
+}
+
+// NewHistoryStore returns a store writing to ScrollHistoryFile in dir.
+func NewHistoryStore(dir string) *HistoryStore {
+	return &HistoryStore{
+		path:     filepath.Join(dir, ScrollHistoryFile),
+		maxBytes: defaultHistoryFileMaxBytes,
+	}
+}
+
+// TakePending returns copies of the entries captured since the previous
+// call. Entries trimmed from ScrollHistory before they were taken are lost.
+// Must be called with vt.Mu held; write the result with Append after
+// releasing it.
+func (hs *HistoryStore) TakePending(vt *VT) []ScrollHistoryEntry {
+	n := vt.scrollHistoryTotal - hs.flushed
+	hs.flushed = vt.scrollHistoryTotal
+	if n > len(vt.ScrollHistory)-vt.RestoredHistory {
+		n = len(vt.ScrollHistory) - vt.RestoredHistory
+	}
+	if n <= 0 {
+		return nil
+	}
+	return append([]ScrollHistoryEntry(nil), vt.ScrollHistory[len(vt.ScrollHistory)-n:]...)
+}
+
+// Append writes entries to the history file, rotating it first if it has
+// reached the size limit.
+func (hs *HistoryStore) Append(entries []ScrollHistoryEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if info, err := os.Stat(hs.path); err == nil && info.Size() >= hs.maxBytes {
+		if err := os.Rename(hs.path, hs.path+".1"); err != nil {
+			return fmt.Errorf("rotate scroll history: %w", err)
+		}
+	}
+	f, err := os.OpenFile(hs.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("open scroll history: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, e := range entries {
+		if err := enc.Encode(encodeHistoryEntry(e)); err != nil {
+			f.Close()
+			return fmt.Errorf("write scroll history: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("write scroll history: %w", err)
+	}
+	return f.Close()
+}
+
+// Clear deletes the history file and its rotation.
+func (hs *HistoryStore) Clear() error {
+	for _, path := range []string{hs.path, hs.path + ".1"} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("clear scroll history: %w", err)
+		}
+	}
+	return nil
+}
+
+// Load reads persisted entries, oldest first, keeping at most the newest
+// max (all of them if max <= 0). Missing files yield no entries; malformed
+// lines (e.g. a write cut short by a crash) are skipped.
+func (hs *HistoryStore) Load(max int) ([]ScrollHistoryEntry, error) {
+	var entries []ScrollHistoryEntry
+	for _, path := range []string{hs.path + ".1", hs.path} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("read scroll history: %w", err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if line == "" {
+				continue
+			}
+			var pe persistedEntry
+			if err := json.Unmarshal([]byte(line), &pe); err != nil {
+				continue
+			}
+			entries = append(entries, pe.decode())
+		}
+	}
+	if max > 0 && len(entries) > max {
+		entries = entries[len(entries)-max:]
+	}
+	return entries, nil
+}
+
+// persistedEntry is the on-disk form of a ScrollHistoryEntry. Content is
+// stored as []rune rather than a string so cells round-trip exactly, which
+// keeps restored entries aligned with their format runs.
+type persistedEntry struct {
+	Content []rune         `json:"c"`
+	Runs    []persistedRun `json:"r,omitempty"`
+}
+
+type persistedRun struct {
+	Size  int    `json:"n"`
+	Props uint8  `json:"p,omitempty"`
+	Fg    string `json:"fg,omitempty"`
+	Bg    string `json:"bg,omitempty"`
+	URL   string `json:"u,omitempty"`
+}
+
+func encodeHistoryEntry(e ScrollHistoryEntry) persistedEntry {
+	pe := persistedEntry{Content: e.Content}
+	for _, r := range e.Runs {
+		pe.Runs = append(pe.Runs, persistedRun{
+			Size:  r.Size,
+			Props: r.Format.Properties,
+			Fg:    encodeColor(r.Format.Fg),
+			Bg:    encodeColor(r.Format.Bg),
+			URL:   r.URL,
+		})
+	}
+	return pe
+}
+
+func (pe persistedEntry) decode() ScrollHistoryEntry {
+	e := ScrollHistoryEntry{Content: pe.Content}
+	for _, r := range pe.Runs {
+		e.Runs = append(e.Runs, FormatRun{
+			Size: r.Size,
+			Format: midterm.Format{
+				Fg:         decodeColor(r.Fg),
+				Bg:         decodeColor(r.Bg),
+				Properties: r.Props,
+			},
+			URL: r.URL,
+		})
+	}
+	return e
+}
+
+// encodeColor serializes the termenv color kinds midterm produces:
+// "a<n>" for ANSI, "x<n>" for 256-color, "#rrggbb" for true color.
+func encodeColor(c termenv.Color) string {
+	switch v := c.(type) {
+	case termenv.ANSIColor:
+		return "a" + strconv.Itoa(int(v))
+	case termenv.ANSI256Color:
+		return "x" + strconv.Itoa(int(v))
+	case termenv.RGBColor:
+		return string(v)
+	default:
+		return ""
+	}
+}
+
+func decodeColor(s string) termenv.Color {
+	if s == "" {
+		return nil
+	}
+	switch s[0] {
+	case 'a':
+		if n, err := strconv.Atoi(s[1:]); err == nil {
+			return termenv.ANSIColor(n)
+		}
+	case 'x':
+		if n, err := strconv.Atoi(s[1:]); err == nil {
+			return termenv.ANSI256Color(n)
+		}
+	case '#':
+		return termenv.RGBColor(s)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package virtualterminal
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/vito/midterm"
+)
+
+func newCaptureVT(rows, cols int) *VT {
+	vt := &VT{}
+	vt.Vt = midterm.NewTerminal(rows, cols)
+	vt.SetupScrollCapture()
+	return vt
+}
+
+func TestHistoryStore_RoundTripPreservesFormatsAndURLs(t *testing.T) {
+	vt := newCaptureVT(2, 20)
+	vt.Vt.Write([]byte("\033[1;31mred\033[0m \033[38;5;200mpink\033[0m \033[38;2;1;2;3mrgb\033[0m\r\n"))
+	vt.Vt.Write([]byte("\033]8;;https://example.com\033\\link\033]8;;\033\\ \033[44mbg\033[0m\r\n"))
+	vt.Vt.Write([]byte("tail\r\n"))
+	if len(vt.ScrollHistory) < 2 {
+		t.Fatalf("expected captured history, got %d entries", len(vt.ScrollHistory))
+	}
+
+	hs := NewHistoryStore(t.TempDir())
+	if err := hs.Append(hs.TakePending(vt)); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	got, err := hs.Load(0)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !reflect.DeepEqual(got, vt.ScrollHistory) {
+		t.Fatalf("restored history differs:\n got  %+v\n want %+v", got, vt.ScrollHistory)
+	}
+}
+
+func TestHistoryStore_TakePendingOnlyReturnsNewEntries(t *testing.T) {
+	vt := newCaptureVT(2, 10)
+	hs := NewHistoryStore(t.TempDir())
+
+	vt.Vt.Write([]byte("a\r\nb\r\nc\r\n"))
+	first := hs.TakePending(vt)
+	if len(first) == 0 {
+		t.Fatal("expected pending entries")
+	}
+	if again := hs.TakePending(vt); len(again) != 0 {
+		t.Fatalf("expected no pending entries, got %d", len(again))
+	}
+	vt.Vt.Write([]byte("d\r\n"))
+	if next := hs.TakePending(vt); len(next) != 1 {
+		t.Fatalf("expected 1 new entry, got %d", len(next))
+	}
+}
+
+func TestHistoryStore_RestoredEntriesAreNotRewritten(t *testing.T) {
+	vt := newCaptureVT(2, 10)
+	vt.RestoreScrollHistory([]ScrollHistoryEntry{{Content: []rune("old")}})
+	hs := NewHistoryStore(t.TempDir())
+	if pending := hs.TakePending(vt); len(pending) != 0 {
+		t.Fatalf("restored entries should not be pending, got %d", len(pending))
+	}
+	if vt.RestoredHistory != 1 {
+		t.Fatalf("RestoredHistory = %d, want 1", vt.RestoredHistory)
+	}
+}
+
+func TestHistoryStore_RotatesAndLoadsBothFiles(t *testing.T) {
+	dir := t.TempDir()
+	hs := NewHistoryStore(dir)
+	hs.maxBytes = 1 // rotate before every append after the first
+
+	for _, s := range []string{"one", "two", "three"} {
+		if err := hs.Append([]ScrollHistoryEntry{{Content: []rune(s)}}); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	if _, err := os.Stat(hs.path + ".1"); err != nil {
+		t.Fatalf("expected rotated file: %v", err)
+	}
+
+	// Only the rotated file and the current one are kept.
+	got, err := hs.Load(0)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(got) != 2 || string(got[0].Content) != "two" || string(got[1].Content) != "three" {
+		t.Fatalf("unexpected entries after rotation: %+v", got)
+	}
+
+	got, _ = hs.Load(1)
+	if len(got) != 1 || string(got[0].Content) != "three" {
+		t.Fatalf("Load(1) = %+v, want only newest entry", got)
+	}
+}
+
+func TestHistoryStore_LoadSkipsMalformedLines(t *testing.T) {
+	dir := t.TempDir()
+	hs := NewHistoryStore(dir)
+	if err := hs.Append([]ScrollHistoryEntry{{Content: []rune("ok")}}); err != nil {
+		t.Fatal(err)
+	}
+	f, _ := os.OpenFile(hs.path, os.O_APPEND|os.O_WRONLY, 0o600)
+	f.WriteString(`{"c":[1`)
+	f.Close()
+
+	got, err := hs.Load(0)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(got) != 1 || string(got[0].Content) != "ok" {
+		t.Fatalf("unexpected entries: %+v", got)
+	}
+}
+
+func TestRestoreScrollHistory_TrimAdjustsRestoredCount(t *testing.T) {
+	vt := newCaptureVT(2, 10)
+	vt.scrollHistoryMax = 3
+	vt.RestoreScrollHistory([]ScrollHistoryEntry{
+		{Content: []rune("r1")}, {Content: []rune("r2")},
+	})
+	vt.Vt.Write([]byte("a\r\nb\r\nc\r\nd\r\n")) // scrolls a, b, c off
+	if len(vt.ScrollHistory) != 3 {
+		t.Fatalf("len(ScrollHistory) = %d, want 3", len(vt.ScrollHistory))
+	}
+	if vt.RestoredHistory != 0 {
+		t.Fatalf("RestoredHistory = %d, want 0 once restored entries are trimmed", vt.RestoredHistory)
+	}
+}
@@ -7,11 +7,14 @@ import (
 	"os"
 	"os/exec"
 	"runtime/debug"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/creack/pty"
+	"github.com/muesli/termenv"
 	"github.com/vito/midterm"
 	"golang.org/x/term"
 )
@@ -70,11 +73,45 @@ type VT struct {
 	ScrollHistory    []ScrollHistoryEntry
 	scrollHistoryMax int
 
+	// scrollbackMax caps the rows kept in Scrollback, like scrollHistoryMax
+	// for ScrollHistory. 0 means DefaultScrollHistoryMax.
+	scrollbackMax int
+
+	// TrimHeld, if set, reports whether trimming ScrollHistory and
+	// Scrollback must wait, e.g. while a client is scrolled through them and
+	// dropping lines would shift its view. Held trims happen on the next
+	// capture after it clears, or on TrimScrollback.
+	TrimHeld func() bool
+
+	// RestoredHistory is the number of entries at the front of ScrollHistory
+	// that were loaded from a previous session process rather than captured
+	// live. Restored history is shown even if the current child hasn't used
+	// scroll regions yet.
+	RestoredHistory int
+
+	// scrollHistoryTotal counts every entry ever captured (it is not reduced
+	// by trimming or reset), so a HistoryStore can tell which are unflushed.
+	scrollHistoryTotal int
+
+	// ScrollbackMarkers are divider rows (e.g. interrupt markers) shown
+	// between Scrollback rows in scroll mode, sorted by Row. They are kept
+	// beside Scrollback rather than written into it so the child's cursor
+	// positioning in the append-only terminal isn't disturbed.
+	ScrollbackMarkers []ScrollMarker
+
+	// pendingMarkers are ScrollHistory markers waiting for the live rows
+	// above them to scroll off, so they land below those rows.
+	pendingMarkers []pendingMarker
+
 	// scanState tracks the ANSI parser state for ScanPTYOutput.
 	scanState         int
 	scanCSIPrivateNum int // accumulates mode number during CSI ? <num> h/l parsing
 }
 
+// DefaultScrollHistoryMax is the number of ScrollHistory entries, and of
+// Scrollback rows, kept.
+const DefaultScrollHistoryMax = 20000
+
 // ScrollHistoryEntry is a single line that scrolled off the top of the live
 // viewport. Stored in RLE form (Content + FormatRuns) so callers can re-render
 // it sized to whatever the current terminal width happens to be — the previous
@@ -101,7 +138,7 @@ type FormatRun struct {
 // after VT.Vt is created.
 func (vt *VT) SetupScrollCapture() {
 	if vt.scrollHistoryMax <= 0 {
-		vt.scrollHistoryMax = 20000
+		vt.scrollHistoryMax = DefaultScrollHistoryMax
 	}
 	vt.Vt.OnScrollback(func(line midterm.Line) {
 		// Guard against Content/Format length mismatch in midterm: wide
@@ -122,14 +159,122 @@ func (vt *VT) SetupScrollCapture() {
 			Content: append([]rune(nil), line.Content...),
 			Runs:    coalesceFormatRuns(line.Format, urls),
 		}
-		vt.ScrollHistory = append(vt.ScrollHistory, entry)
-		if len(vt.ScrollHistory) > vt.scrollHistoryMax {
-			trim := len(vt.ScrollHistory) - vt.scrollHistoryMax
-			vt.ScrollHistory = vt.ScrollHistory[trim:]
-		}
+		vt.appendScrollHistory(entry)
+		vt.releasePendingMarkers()
 	})
 }
 
+// appendScrollHistory adds entry to the end of ScrollHistory.
+func (vt *VT) appendScrollHistory(entry ScrollHistoryEntry) {
+	vt.ScrollHistory = append(vt.ScrollHistory, entry)
+	vt.scrollHistoryTotal++
+	vt.trimScrollHistory()
+}
+
+// pendingMarker is a ScrollHistory marker held back until rows more lines
+// have been captured.
+type pendingMarker struct {
+	entry ScrollHistoryEntry
+	rows  int
+}
+
+// releasePendingMarkers counts one captured line against each pending
+// marker and appends those with no rows left to wait for.
+func (vt *VT) releasePendingMarkers() {
+	kept := vt.pendingMarkers[:0]
+	for _, p := range vt.pendingMarkers {
+		if p.rows--; p.rows > 0 {
+			kept = append(kept, p)
+			continue
+		}
+		vt.appendScrollHistory(p.entry)
+	}
+	vt.pendingMarkers = kept
+}
+
+// SetScrollbackLimit caps both ScrollHistory and Scrollback at n lines; n
+// <= 0 restores DefaultScrollHistoryMax. Must be called with vt.Mu held.
+func (vt *VT) SetScrollbackLimit(n int) {
+	if n <= 0 {
+		n = DefaultScrollHistoryMax
+	}
+	vt.scrollHistoryMax = n
+	vt.scrollbackMax = n
+	vt.TrimScrollback()
+}
+
+// TrimScrollback drops the oldest ScrollHistory entries and Scrollback rows
+// beyond their limits, unless TrimHeld says to wait. Must be called with
+// vt.Mu held.
+func (vt *VT) TrimScrollback() {
+	vt.trimScrollHistory()
+	vt.trimScrollbackRows()
+}
+
+// trimHeld reports whether TrimHeld is deferring trims.
+func (vt *VT) trimHeld() bool {
+	return vt.TrimHeld != nil && vt.TrimHeld()
+}
+
+// trimScrollHistory drops the oldest entries beyond scrollHistoryMax.
+func (vt *VT) trimScrollHistory() {
+	if vt.scrollHistoryMax <= 0 || len(vt.ScrollHistory) <= vt.scrollHistoryMax || vt.trimHeld() {
+		return
+	}
+	trim := len(vt.ScrollHistory) - vt.scrollHistoryMax
+	vt.ScrollHistory = vt.ScrollHistory[trim:]
+	vt.RestoredHistory -= trim
+	if vt.RestoredHistory < 0 {
+		vt.RestoredHistory = 0
+	}
+}
+
+// trimScrollbackRows drops the oldest Scrollback rows beyond scrollbackMax,
+// shifting the cursor and ScrollbackMarkers to match. At least a screenful
+// is kept, and never the cursor's row.
+func (vt *VT) trimScrollbackRows() {
+	sb := vt.Scrollback
+	if sb == nil {
+		return
+	}
+	limit := vt.scrollbackMax
+	if limit <= 0 {
+		limit = DefaultScrollHistoryMax
+	}
+	limit = max(limit, vt.ChildRows)
+	n := min(len(sb.Content)-limit, sb.Cursor.Y)
+	if n <= 0 || vt.trimHeld() {
+		return
+	}
+	sb.Content = sb.Content[n:]
+	sb.Format.Rows = sb.Format.Rows[min(n, len(sb.Format.Rows)):]
+	sb.Changes = sb.Changes[min(n, len(sb.Changes)):]
+	sb.Height -= n
+	sb.Cursor.Y -= n
+	sb.SavedCursor.Y = max(sb.SavedCursor.Y-n, 0)
+	sb.MaxY = max(sb.MaxY-n, -1)
+
+	markers := vt.ScrollbackMarkers[:0]
+	for _, m := range vt.ScrollbackMarkers {
+		if m.Row -= n; m.Row >= 0 {
+			markers = append(markers, m)
+		}
+	}
+	vt.ScrollbackMarkers = markers
+}
+
+// RestoreScrollHistory prepends entries persisted by a previous session
+// process to ScrollHistory. Restored entries don't count as newly captured,
+// so they aren't written back out by a HistoryStore.
+func (vt *VT) RestoreScrollHistory(entries []ScrollHistoryEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	vt.ScrollHistory = append(append([]ScrollHistoryEntry(nil), entries...), vt.ScrollHistory...)
+	vt.RestoredHistory += len(entries)
+	vt.trimScrollHistory()
+}
+
 // coalesceFormatRuns RLE-encodes per-cell []Format (and parallel per-cell
 // URLs, if non-nil) into spans where adjacent cells share both Format and
 // URL. Most TUI rows have a small handful of runs (often one — all default),
@@ -188,9 +333,88 @@ func resolveLineURLs(ids []uint32, n int, lookup func(uint32) string) []string {
 	return out
 }
 
+// InterruptMarkerLabel labels the marker row recorded when an
+// interrupt-priority message is injected into the child.
+const InterruptMarkerLabel = "interrupt"
+
+// ScrollMarker is a divider row shown above Scrollback row Row.
+type ScrollMarker struct {
+	Row   int
+	Label string
+}
+
+// AddMarker records a divider row labeled label at the current end of the
+// transcript, in both ScrollHistory and Scrollback, so it shows in scroll
+// mode whichever source is rendered. In both it goes above the bottom row;
+// in ScrollHistory it waits for the live rows above that to scroll off
+// first. The child's screen is not touched. Must be called with vt.Mu held.
+func (vt *VT) AddMarker(label string) {
+	entry := MarkerEntry(label, vt.Cols)
+	if rows := vt.liveBottomRow(); rows > 0 {
+		vt.pendingMarkers = append(vt.pendingMarkers, pendingMarker{entry: entry, rows: rows})
+	} else {
+		vt.appendScrollHistory(entry)
+	}
+
+	if vt.Scrollback == nil {
+		return
+	}
+	m := ScrollMarker{Row: vt.ScrollbackBottomRow(), Label: label}
+	i := sort.Search(len(vt.ScrollbackMarkers), func(i int) bool {
+		return vt.ScrollbackMarkers[i].Row > m.Row
+	})
+	vt.ScrollbackMarkers = slices.Insert(vt.ScrollbackMarkers, i, m)
+}
+
+// liveBottomRow is ScrollbackBottomRow for the live terminal Vt.
+func (vt *VT) liveBottomRow() int {
+	if vt.Vt == nil {
+		return 0
+	}
+	return max(vt.Vt.Cursor.Y, vt.Vt.MaxY, 0)
+}
+
+// ScrollbackBottomRow returns the effective last row in Scrollback:
+// max(Scrollback.MaxY, Scrollback.Cursor.Y). Cursor.Y alone is unreliable
+// because TUIs reposition the cursor via \033[H mid-chunk, which would drop
+// the scroll-mode anchor (or a marker) at the oldest content. MaxY is
+// midterm's per-paint watermark of the highest row ever written, so it
+// survives cursor resets even within a single chunk that writes content
+// then jumps the cursor home. Must be called with vt.Mu held.
+func (vt *VT) ScrollbackBottomRow() int {
+	if vt.Scrollback == nil {
+		return 0
+	}
+	return max(vt.Scrollback.Cursor.Y, vt.Scrollback.MaxY, 0)
+}
+
+// MarkerEntry renders a marker row cols wide: the label centered in a
+// faint yellow "⎯" rule.
+func MarkerEntry(label string, cols int) ScrollHistoryEntry {
+	text := []rune(" " + label + " ")
+	if cols < len(text) {
+		cols = len(text)
+	}
+	content := make([]rune, cols)
+	for i := range content {
+		content[i] = '⎯'
+	}
+	copy(content[(cols-len(text))/2:], text)
+
+	var f midterm.Format
+	f.Fg = termenv.ANSIYellow
+	f.SetFaint(true)
+	return ScrollHistoryEntry{
+		Content: content,
+		Runs:    []FormatRun{{Size: cols, Format: f}},
+	}
+}
+
 // ResetScrollHistory clears the captured scroll history.
 func (vt *VT) ResetScrollHistory() {
 	vt.ScrollHistory = nil
+	vt.RestoredHistory = 0
+	vt.pendingMarkers = nil
 }
 
 // KillChild sends SIGKILL to the child process. Used when the child is hung
@@ -268,6 +492,7 @@ func (vt *VT) pipeChunk(data []byte, onData func()) {
 	vt.clampLiveVtHeight()
 	if vt.Scrollback != nil {
 		vt.Scrollback.Write(data)
+		vt.trimScrollbackRows()
 	}
 	vt.ScanPTYOutput(data)
 	if !vt.SyncOutputActive {
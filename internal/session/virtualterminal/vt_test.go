@@ -1,8 +1,10 @@
 package virtualterminal
 
 import (
+	"fmt"
 	"io"
 	"os"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -716,3 +718,167 @@ func TestResetScanState(t *testing.T) {
 		t.Fatal("expected scanCSIPrivateNum=0 after reset")
 	}
 }
+
+func TestAddMarker_RecordsInBothScrollSources(t *testing.T) {
+	vt := &VT{Cols: 20}
+	vt.Vt = midterm.NewTerminal(3, 20)
+	vt.SetupScrollCapture()
+	vt.Scrollback = midterm.NewTerminal(3, 20)
+	vt.Scrollback.AutoResizeY = true
+	vt.Scrollback.AppendOnly = true
+	vt.Scrollback.Write([]byte("a\r\nb\r\nc\r\nd"))
+
+	vt.AddMarker(InterruptMarkerLabel)
+
+	if len(vt.ScrollHistory) != 1 {
+		t.Fatalf("ScrollHistory len = %d, want 1", len(vt.ScrollHistory))
+	}
+	got := string(vt.ScrollHistory[0].Content)
+	if !strings.Contains(got, " interrupt ") || len([]rune(got)) != 20 {
+		t.Fatalf("marker row = %q, want 20-col rule containing the label", got)
+	}
+	if len(vt.ScrollbackMarkers) != 1 || vt.ScrollbackMarkers[0].Row != 3 {
+		t.Fatalf("ScrollbackMarkers = %+v, want one at bottom row 3", vt.ScrollbackMarkers)
+	}
+	if vt.Vt.Cursor.Y != 0 || string(vt.Vt.Content[0][:1]) != " " {
+		t.Fatal("AddMarker must not write to the live terminal")
+	}
+}
+
+func TestAddMarker_ScrollHistoryMarkerFollowsLiveRows(t *testing.T) {
+	vt := &VT{Cols: 20}
+	vt.Vt = midterm.NewTerminal(3, 20)
+	vt.SetupScrollCapture()
+	vt.Vt.Write([]byte("one\r\ntwo\r\nthree"))
+
+	vt.AddMarker(InterruptMarkerLabel)
+	if len(vt.ScrollHistory) != 0 {
+		t.Fatalf("ScrollHistory = %d entries, want the marker held until its rows scroll off", len(vt.ScrollHistory))
+	}
+
+	vt.Vt.Write([]byte("\r\nfour\r\nfive\r\nsix"))
+	var got []string
+	for _, e := range vt.ScrollHistory {
+		got = append(got, strings.TrimSpace(string(e.Content)))
+	}
+	if len(got) != 4 || got[0] != "one" || got[1] != "two" || !strings.Contains(got[2], " interrupt ") || got[3] != "three" {
+		t.Fatalf("ScrollHistory = %q, want one, two, the marker, three", got)
+	}
+}
+
+func TestAddMarker_KeepsScrollbackMarkersSorted(t *testing.T) {
+	vt := &VT{Cols: 10}
+	vt.Scrollback = midterm.NewTerminal(3, 10)
+	vt.Scrollback.AutoResizeY = true
+	vt.Scrollback.Cursor.Y = 5
+	vt.AddMarker("b")
+	vt.Scrollback.Cursor.Y = 2
+	vt.AddMarker("a")
+
+	if vt.ScrollbackMarkers[0].Label != "a" || vt.ScrollbackMarkers[1].Label != "b" {
+		t.Fatalf("markers not sorted by row: %+v", vt.ScrollbackMarkers)
+	}
+}
+
+func TestAddMarker_AnchorsAtBottomRowAfterCursorHome(t *testing.T) {
+	vt := &VT{Cols: 20}
+	vt.Scrollback = midterm.NewTerminal(3, 20)
+	vt.Scrollback.AutoResizeY = true
+	vt.Scrollback.AppendOnly = true
+	// A TUI repaint that writes content then jumps the cursor home.
+	vt.Scrollback.Write([]byte("a\r\nb\r\nc\r\nd\033[H"))
+	if vt.Scrollback.Cursor.Y != 0 {
+		t.Fatalf("Cursor.Y = %d, want 0 after \\033[H", vt.Scrollback.Cursor.Y)
+	}
+
+	vt.AddMarker(InterruptMarkerLabel)
+
+	if len(vt.ScrollbackMarkers) != 1 || vt.ScrollbackMarkers[0].Row != 3 {
+		t.Fatalf("ScrollbackMarkers = %+v, want one at bottom row 3", vt.ScrollbackMarkers)
+	}
+}
+
+func TestMarkerEntry_WiderLabelThanCols(t *testing.T) {
+	e := MarkerEntry("interrupt", 4)
+	if string(e.Content) != " interrupt " {
+		t.Fatalf("Content = %q", string(e.Content))
+	}
+	if len(e.Runs) != 1 || e.Runs[0].Size != len(e.Content) {
+		t.Fatalf("Runs = %+v, want one run covering the row", e.Runs)
+	}
+}
+
+// --- Scrollback trimming ---
+
+func newScrollbackVT(childRows, cols int) *VT {
+	vt := &VT{Rows: childRows + 2, Cols: cols, ChildRows: childRows}
+	vt.Vt = midterm.NewTerminal(childRows, cols)
+	vt.SetupScrollCapture()
+	vt.Scrollback = midterm.NewTerminal(childRows, cols)
+	vt.Scrollback.AutoResizeY = true
+	vt.Scrollback.AppendOnly = true
+	return vt
+}
+
+func TestTrimScrollback_DropsOldestRows(t *testing.T) {
+	vt := newScrollbackVT(2, 20)
+	vt.SetScrollbackLimit(4)
+	for i := 1; i <= 9; i++ {
+		vt.pipeChunk([]byte(fmt.Sprintf("line %d\r\n", i)), func() {})
+	}
+
+	sb := vt.Scrollback
+	if len(sb.Content) != 4 || sb.Height != 4 {
+		t.Fatalf("Scrollback has %d rows (Height %d), want 4", len(sb.Content), sb.Height)
+	}
+	if got := strings.TrimRight(string(sb.Content[0]), " "); got != "line 6" {
+		t.Errorf("oldest kept row = %q, want %q", got, "line 6")
+	}
+	if got := strings.TrimRight(string(sb.Content[sb.Cursor.Y-1]), " "); got != "line 9" {
+		t.Errorf("row above cursor = %q, want %q", got, "line 9")
+	}
+	if len(vt.ScrollHistory) != 4 {
+		t.Errorf("len(ScrollHistory) = %d, want 4", len(vt.ScrollHistory))
+	}
+}
+
+func TestTrimScrollback_ShiftsMarkers(t *testing.T) {
+	vt := newScrollbackVT(2, 20)
+	vt.SetScrollbackLimit(4)
+	vt.AddMarker("old") // above "a", trimmed away with it
+	vt.pipeChunk([]byte("a\r\nb\r\nc\r\n"), func() {})
+	vt.AddMarker(InterruptMarkerLabel) // above "d"
+	vt.pipeChunk([]byte("d\r\ne\r\nf\r\n"), func() {})
+
+	if len(vt.ScrollbackMarkers) != 1 || vt.ScrollbackMarkers[0].Label != InterruptMarkerLabel {
+		t.Fatalf("ScrollbackMarkers = %+v, want only the interrupt marker", vt.ScrollbackMarkers)
+	}
+	row := vt.ScrollbackMarkers[0].Row
+	if got := strings.TrimRight(string(vt.Scrollback.Content[row]), " "); got != "d" {
+		t.Errorf("marker sits above %q, want %q", got, "d")
+	}
+}
+
+func TestTrimScrollback_HeldUntilReleased(t *testing.T) {
+	vt := newScrollbackVT(2, 20)
+	held := true
+	vt.TrimHeld = func() bool { return held }
+	vt.SetScrollbackLimit(3)
+	for i := 1; i <= 8; i++ {
+		vt.pipeChunk([]byte(fmt.Sprintf("line %d\r\n", i)), func() {})
+	}
+	if len(vt.Scrollback.Content) <= 3 || len(vt.ScrollHistory) <= 3 {
+		t.Fatalf("trimmed while held: %d Scrollback rows, %d history entries",
+			len(vt.Scrollback.Content), len(vt.ScrollHistory))
+	}
+	if got := strings.TrimRight(string(vt.Scrollback.Content[0]), " "); got != "line 1" {
+		t.Errorf("first row = %q, want %q while held", got, "line 1")
+	}
+
+	held = false
+	vt.TrimScrollback()
+	if len(vt.Scrollback.Content) != 3 || len(vt.ScrollHistory) != 3 {
+		t.Errorf("after release: %d Scrollback rows, %d history entries, want 3 each",
+			len(vt.Scrollback.Content), len(vt.ScrollHistory))
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"sync"
 	"time"
@@ -21,6 +22,10 @@ const (
 	// macOS has sizeof(sockaddr_un.sun_path) = 104.
 	// We use 100 to leave room for the socket filename.
 	maxSocketPathLen = 100
+
+	// DirEnv overrides the socket directory, e.g. to keep socket paths
+	// short when the h2 dir is deeply nested.
+	DirEnv = "H2_SOCKET_DIR"
 )
 
 // Entry represents a parsed socket file in the socket directory.
@@ -57,11 +62,19 @@ var (
 	socketDirOnce sync.Once
 )
 
-// Dir returns the socket directory, derived from the resolved h2 dir.
-// If the resulting path would be too long for Unix domain sockets,
-// a symlink from /tmp/h2-<hash>/ is created and returned instead.
+// Dir returns the socket directory: $H2_SOCKET_DIR if set, otherwise
+// derived from the resolved h2 dir. If the derived path would be too long
+// for Unix domain sockets, a symlink from /tmp/h2-<hash>/ is created and
+// returned instead.
 func Dir() string {
 	socketDirOnce.Do(func() {
+		if dir := os.Getenv(DirEnv); dir != "" {
+			if abs, err := filepath.Abs(dir); err == nil {
+				dir = abs
+			}
+			socketDir = dir
+			return
+		}
 		socketDir = ResolveSocketDir(config.ConfigDir())
 	})
 	return socketDir
@@ -125,6 +138,42 @@ func ProbeSocket(sockPath, label string) error {
 	return nil
 }
 
+// sunPathLen returns the size of sockaddr_un.sun_path on this platform,
+// which includes the trailing NUL.
+func sunPathLen() int {
+	if runtime.GOOS == "linux" {
+		return 108
+	}
+	return 104 // darwin and the BSDs
+}
+
+// CheckPathLen returns an error if sockPath is too long to bind as a Unix
+// domain socket on this platform. The kernel would otherwise reject (or on
+// some platforms silently truncate) the path.
+func CheckPathLen(sockPath string) error {
+	if limit := sunPathLen() - 1; len(sockPath) > limit {
+		return fmt.Errorf("socket path too long: %d > %d (%s), shorten H2_DIR or set %s", len(sockPath), limit, sockPath, DirEnv)
+	}
+	return nil
+}
+
+// Listen checks the path length, listens on a Unix socket at sockPath, and
+// restricts the socket file to the current user.
+func Listen(sockPath string) (net.Listener, error) {
+	if err := CheckPathLen(sockPath); err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen("unix", sockPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(sockPath, 0o600); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("chmod socket: %w", err)
+	}
+	return ln, nil
+}
+
 // Path returns the full socket path for a given type and name.
 func Path(socketType, name string) string {
 	return filepath.Join(Dir(), Format(socketType, name))
@@ -297,3 +297,59 @@ func TestResolveSocketDir_SymlinkCreation(t *testing.T) {
 		t.Errorf("symlink target = %q, want %q", target, realDir)
 	}
 }
+
+func TestDir_SocketDirEnvOverride(t *testing.T) {
+	ResetDirCache()
+	t.Cleanup(ResetDirCache)
+	t.Setenv(DirEnv, "/tmp/h2s")
+
+	if got := Dir(); got != "/tmp/h2s" {
+		t.Errorf("Dir() = %q, want /tmp/h2s", got)
+	}
+}
+
+func TestCheckPathLen(t *testing.T) {
+	limit := sunPathLen() - 1
+	ok := "/" + strings.Repeat("a", limit-1)
+	if err := CheckPathLen(ok); err != nil {
+		t.Errorf("CheckPathLen(%d bytes): %v", len(ok), err)
+	}
+
+	err := CheckPathLen(ok + "a")
+	if err == nil {
+		t.Fatalf("expected error for %d-byte path", len(ok)+1)
+	}
+	if !strings.Contains(err.Error(), "socket path too long") || !strings.Contains(err.Error(), DirEnv) {
+		t.Errorf("error = %q, want length message mentioning %s", err, DirEnv)
+	}
+}
+
+func TestListen_RestrictsPermissions(t *testing.T) {
+	dir, err := os.MkdirTemp("/tmp", "h2s-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sockPath := filepath.Join(dir, "agent.test.sock")
+
+	ln, err := Listen(sockPath)
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer ln.Close()
+
+	info, err := os.Stat(sockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("socket perms = %o, want 600", perm)
+	}
+}
+
+func TestListen_PathTooLong(t *testing.T) {
+	sockPath := filepath.Join(os.TempDir(), strings.Repeat("a", sunPathLen()), "agent.test.sock")
+	if _, err := Listen(sockPath); err == nil || !strings.Contains(err.Error(), "socket path too long") {
+		t.Fatalf("Listen = %v, want path length error", err)
+	}
+}
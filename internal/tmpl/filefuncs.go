@@ -0,0 +1,77 @@
+package tmpl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// FileFuncs returns template functions that read files from the h2 dir:
+//
+//	fileContents "path"  — the file's contents. Relative paths are looked up
+//	                       in <h2Dir>/roles first, then <h2Dir>.
+//
+// Paths that resolve outside h2Dir, including through symlinks, are
+// rejected. The contents are inserted as-is; pipe through quote to embed
+// them in a YAML string.
+func FileFuncs(h2Dir string) template.FuncMap {
+	return template.FuncMap{
+		"fileContents": func(path string) (string, error) {
+			return fileContents(h2Dir, path)
+		},
+	}
+}
+
+func fileContents(h2Dir, path string) (string, error) {
+	if h2Dir == "" {
+		return "", fmt.Errorf("fileContents %q: h2 dir is not set", path)
+	}
+	root := filepath.Clean(h2Dir)
+
+	candidates := []string{path}
+	if !filepath.IsAbs(path) {
+		candidates = []string{
+			filepath.Join(root, "roles", path),
+			filepath.Join(root, path),
+		}
+	}
+
+	for _, c := range candidates {
+		if !within(root, filepath.Clean(c)) {
+			return "", fmt.Errorf("fileContents %q: path is outside the h2 dir %s", path, root)
+		}
+	}
+
+	// Compare the resolved paths too, so a symlink under the h2 dir can't
+	// point out of it (and a symlinked h2 dir still works).
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("fileContents %q: %w", path, err)
+	}
+	for _, c := range candidates {
+		resolved, err := filepath.EvalSymlinks(c)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("fileContents %q: %w", path, err)
+		}
+		if !within(realRoot, resolved) {
+			return "", fmt.Errorf("fileContents %q: path is outside the h2 dir %s (resolves to %s)", path, root, resolved)
+		}
+		data, err := os.ReadFile(resolved)
+		if err != nil {
+			return "", fmt.Errorf("fileContents %q: %w", path, err)
+		}
+		return string(data), nil
+	}
+	return "", fmt.Errorf("fileContents %q: file not found\n\nLooked in:\n  %s", path, strings.Join(candidates, "\n  "))
+}
+
+// within reports whether path is root or lies under it.
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
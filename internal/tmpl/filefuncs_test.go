@@ -0,0 +1,74 @@
+package tmpl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileContents(t *testing.T) {
+	h2Dir := t.TempDir()
+	os.MkdirAll(filepath.Join(h2Dir, "roles", "shared"), 0o755)
+	os.MkdirAll(filepath.Join(h2Dir, "docs"), 0o755)
+	os.WriteFile(filepath.Join(h2Dir, "roles", "shared", "review.md"), []byte("Be \"kind\".\nBe brief."), 0o644)
+	os.WriteFile(filepath.Join(h2Dir, "docs", "style.md"), []byte("h2 dir file"), 0o644)
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	os.WriteFile(outside, []byte("nope"), 0o644)
+
+	fns := FileFuncs(h2Dir)
+
+	t.Run("roles dir", func(t *testing.T) {
+		got, err := RenderWithExtraFuncs(`instructions: {{ fileContents "shared/review.md" | quote }}`, &Context{}, fns)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := `instructions: "Be \"kind\".\nBe brief."`; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("h2 dir fallback", func(t *testing.T) {
+		got, err := RenderWithExtraFuncs(`{{ fileContents "docs/style.md" }}`, &Context{}, fns)
+		if err != nil || got != "h2 dir file" {
+			t.Errorf("got %q, %v", got, err)
+		}
+	})
+
+	for _, path := range []string{"../../etc/passwd", "shared/../../../x", outside} {
+		t.Run("traversal "+path, func(t *testing.T) {
+			_, err := RenderWithExtraFuncs(`{{ fileContents "`+path+`" }}`, &Context{}, fns)
+			if err == nil || !strings.Contains(err.Error(), "outside the h2 dir") {
+				t.Errorf("expected traversal error, got %v", err)
+			}
+		})
+	}
+
+	t.Run("symlink out of h2 dir", func(t *testing.T) {
+		if err := os.Symlink(outside, filepath.Join(h2Dir, "roles", "shared", "link.md")); err != nil {
+			t.Fatal(err)
+		}
+		_, err := RenderWithExtraFuncs(`{{ fileContents "shared/link.md" }}`, &Context{}, fns)
+		if err == nil || !strings.Contains(err.Error(), "outside the h2 dir") {
+			t.Errorf("expected symlink error, got %v", err)
+		}
+	})
+
+	t.Run("symlinked h2 dir", func(t *testing.T) {
+		link := filepath.Join(t.TempDir(), "h2")
+		if err := os.Symlink(h2Dir, link); err != nil {
+			t.Fatal(err)
+		}
+		got, err := RenderWithExtraFuncs(`{{ fileContents "docs/style.md" }}`, &Context{}, FileFuncs(link))
+		if err != nil || got != "h2 dir file" {
+			t.Errorf("got %q, %v", got, err)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := RenderWithExtraFuncs(`{{ fileContents "shared/nope.md" }}`, &Context{}, fns)
+		if err == nil || !strings.Contains(err.Error(), `fileContents "shared/nope.md": file not found`) {
+			t.Errorf("expected not-found error, got %v", err)
+		}
+	})
+}
@@ -14,10 +14,13 @@ import (
 // The returned FuncMap contains:
 //   - randomName: generates a random name avoiding collisions with existingNames
 //   - autoIncrement: given a prefix, returns "<prefix>-N" where N is max+1
+//   - autoIncrementFill: like autoIncrement, but N is the lowest unused
+//     positive index, so names freed by stopped agents are reused
 //
-// Both functions cache their results so repeated calls (across template passes)
+// All functions cache their results so repeated calls (across template passes)
 // return the same value. The generateName function is called to produce candidate
-// names (typically session.GenerateName).
+// names (typically a session.NameGenerator's Generate, which may use a custom
+// word list).
 func NameFuncs(generateName func() string, existingNames []string) template.FuncMap {
 	existing := make(map[string]bool, len(existingNames))
 	for _, n := range existingNames {
@@ -29,6 +32,7 @@ func NameFuncs(generateName func() string, existingNames []string) template.Func
 		randomCache    string
 		randomResolved bool
 		autoIncrCache  = map[string]string{} // prefix → result
+		autoFillCache  = map[string]string{} // prefix → result
 	)
 
 	randomNameFn := func() (string, error) {
@@ -48,7 +52,8 @@ func NameFuncs(generateName func() string, existingNames []string) template.Func
 				return name, nil
 			}
 		}
-		// Extremely unlikely — 5600+ combinations with few agents.
+		// Extremely unlikely with the built-in lists (5600+ combinations);
+		// a tiny custom word list can exhaust its names.
 		return "", fmt.Errorf("randomName: failed to generate unique name after %d retries", maxRetries)
 	}
 
@@ -61,13 +66,9 @@ func NameFuncs(generateName func() string, existingNames []string) template.Func
 		}
 
 		maxN := 0
-		pattern := regexp.MustCompile(`^` + regexp.QuoteMeta(prefix) + `-(\d+)$`)
-		for _, name := range existingNames {
-			if m := pattern.FindStringSubmatch(name); m != nil {
-				n, _ := strconv.Atoi(m[1])
-				if n > maxN {
-					maxN = n
-				}
+		for n := range usedIndexes(prefix, existingNames) {
+			if n > maxN {
+				maxN = n
 			}
 		}
 
@@ -76,19 +77,78 @@ func NameFuncs(generateName func() string, existingNames []string) template.Func
 		return result, nil
 	}
 
+	autoIncrementFillFn := func(prefix string) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if cached, ok := autoFillCache[prefix]; ok {
+			return cached, nil
+		}
+
+		used := usedIndexes(prefix, existingNames)
+		n := 1
+		for used[n] {
+			n++
+		}
+
+		result := fmt.Sprintf("%s-%d", prefix, n)
+		autoFillCache[prefix] = result
+		return result, nil
+	}
+
 	return template.FuncMap{
-		"randomName":    randomNameFn,
-		"autoIncrement": autoIncrementFn,
+		"randomName":        randomNameFn,
+		"autoIncrement":     autoIncrementFn,
+		"autoIncrementFill": autoIncrementFillFn,
+	}
+}
+
+// usedIndexes returns the N of every "<prefix>-N" name in names.
+func usedIndexes(prefix string, names []string) map[int]bool {
+	used := map[int]bool{}
+	pattern := regexp.MustCompile(`^` + regexp.QuoteMeta(prefix) + `-(\d+)$`)
+	for _, name := range names {
+		if m := pattern.FindStringSubmatch(name); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			used[n] = true
+		}
+	}
+	return used
+}
+
+// PodNameFuncs returns name template functions for one agent of a pod.
+// autoIncrement and autoIncrementFill number agents within the pod instead
+// of scanning running agents, so agents launched together get contiguous
+// names whatever order they start in. ordinals holds the last number handed
+// out per prefix across the whole pod; the first call for a prefix takes the
+// next one, and repeat calls while rendering the same agent return the same
+// name. Name the pod's agents one at a time, sharing ordinals. randomName
+// behaves as in NameFuncs.
+func PodNameFuncs(generateName func() string, existingNames []string, ordinals map[string]int) template.FuncMap {
+	fns := NameFuncs(generateName, existingNames)
+	names := map[string]string{}
+	ordinal := func(prefix string) (string, error) {
+		if name, ok := names[prefix]; ok {
+			return name, nil
+		}
+		ordinals[prefix]++
+		names[prefix] = fmt.Sprintf("%s-%d", prefix, ordinals[prefix])
+		return names[prefix], nil
 	}
+	fns["autoIncrement"] = ordinal
+	fns["autoIncrementFill"] = ordinal
+	return fns
 }
 
 // FixedNameFuncs returns name template functions that always return the given
 // agent name. Use this when the agent name is already known (e.g. pod launches)
-// but the role template may reference {{ randomName }} or {{ autoIncrement }}.
+// but the role template may reference {{ randomName }}, {{ autoIncrement }} or
+// {{ autoIncrementFill }}.
 func FixedNameFuncs(agentName string) template.FuncMap {
 	return template.FuncMap{
-		"randomName":    func() (string, error) { return agentName, nil },
-		"autoIncrement": func(prefix string) (string, error) { return agentName, nil },
+		"randomName":        func() (string, error) { return agentName, nil },
+		"autoIncrement":     func(prefix string) (string, error) { return agentName, nil },
+		"autoIncrementFill": func(prefix string) (string, error) { return agentName, nil },
 	}
 }
 
@@ -172,6 +172,45 @@ func TestAutoIncrement_ViaTemplate(t *testing.T) {
 	}
 }
 
+func TestAutoIncrementFill_ReusesGap(t *testing.T) {
+	tests := []struct {
+		existing []string
+		want     string
+	}{
+		{nil, "worker-1"},
+		{[]string{"worker-1", "worker-3"}, "worker-2"},
+		{[]string{"worker-2", "worker-3"}, "worker-1"},
+		{[]string{"worker-1", "worker-2", "other-3"}, "worker-3"},
+		{[]string{"worker-1", "worker-01x"}, "worker-2"},
+	}
+	for _, tt := range tests {
+		fns := NameFuncs(nil, tt.existing)
+		fn := fns["autoIncrementFill"].(func(string) (string, error))
+		name, err := fn("worker")
+		if err != nil {
+			t.Fatalf("autoIncrementFill: %v", err)
+		}
+		if name != tt.want {
+			t.Errorf("autoIncrementFill('worker') with %v = %q, want %q", tt.existing, name, tt.want)
+		}
+	}
+}
+
+func TestAutoIncrementFill_CachesIndependentlyOfAutoIncrement(t *testing.T) {
+	fns := NameFuncs(nil, []string{"worker-1", "worker-3"})
+	fill := fns["autoIncrementFill"].(func(string) (string, error))
+	incr := fns["autoIncrement"].(func(string) (string, error))
+
+	first, _ := fill("worker")
+	second, _ := fill("worker")
+	if first != "worker-2" || second != first {
+		t.Errorf("autoIncrementFill = %q then %q, want worker-2 twice", first, second)
+	}
+	if name, _ := incr("worker"); name != "worker-4" {
+		t.Errorf("autoIncrement = %q, want worker-4", name)
+	}
+}
+
 // --- RenderWithExtraFuncs tests ---
 
 func TestRenderWithExtraFuncs_MergesWithStandard(t *testing.T) {
@@ -196,3 +235,30 @@ func TestRenderWithExtraFuncs_NilExtraFuncs(t *testing.T) {
 		t.Errorf("got %q, want %q", got, "TEST")
 	}
 }
+
+// --- PodNameFuncs tests ---
+
+func TestPodNameFuncs_IndexBased(t *testing.T) {
+	ordinals := map[string]int{"worker": 2}
+	fns := PodNameFuncs(func() string { return "bright-hare" }, []string{"worker-1", "worker-5"}, ordinals)
+
+	for _, fn := range []string{"autoIncrement", "autoIncrementFill"} {
+		got, err := fns[fn].(func(string) (string, error))("worker")
+		if err != nil {
+			t.Fatalf("%s: %v", fn, err)
+		}
+		if got != "worker-3" {
+			t.Errorf("%s(worker) = %q, want %q", fn, got, "worker-3")
+		}
+	}
+	got, err := fns["randomName"].(func() (string, error))()
+	if err != nil || got != "bright-hare" {
+		t.Errorf("randomName() = %q, %v; want bright-hare", got, err)
+	}
+
+	// The next agent's funcs continue the pod's numbering.
+	next := PodNameFuncs(func() string { return "bright-hare" }, nil, ordinals)
+	if got, _ := next["autoIncrement"].(func(string) (string, error))("worker"); got != "worker-4" {
+		t.Errorf("next agent's autoIncrement(worker) = %q, want worker-4", got)
+	}
+}
@@ -14,6 +14,7 @@ import (
 type VarDef struct {
 	Description string  `yaml:"description"`
 	Default     *string `yaml:"default"`
+	Secret      bool    `yaml:"secret,omitempty"` // mask the value wherever the rendered role is displayed
 }
 
 // Required returns true if the variable has no default value.
@@ -21,16 +22,59 @@ func (v VarDef) Required() bool {
 	return v.Default == nil
 }
 
-// Context holds all template data available during rendering.
+// RedactedValue replaces secret variable values in displayed output.
+const RedactedValue = "***"
+
+// SecretValues returns the non-empty values in vars of the variables defs
+// marks secret, longest first so overlapping secrets redact fully.
+func SecretValues(defs map[string]VarDef, vars map[string]string) []string {
+	var secrets []string
+	for name, def := range defs {
+		if v := vars[name]; def.Secret && v != "" {
+			secrets = append(secrets, v)
+		}
+	}
+	sort.Slice(secrets, func(i, j int) bool {
+		if len(secrets[i]) != len(secrets[j]) {
+			return len(secrets[i]) > len(secrets[j])
+		}
+		return secrets[i] < secrets[j]
+	})
+	return secrets
+}
+
+// Redact replaces every occurrence of each secret in s with RedactedValue.
+func Redact(s string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, RedactedValue)
+		}
+	}
+	return s
+}
+
+// Context holds all template data available during rendering. Launched
+// agents record it so their role can be rendered the same way again.
 type Context struct {
-	AgentName string
-	RoleName  string
-	PodName   string
-	Index     int
-	Count     int
-	H2Dir     string
-	H2RootDir string
-	Var       map[string]string
+	AgentName string            `json:"agent_name,omitempty"`
+	RoleName  string            `json:"role_name,omitempty"`
+	PodName   string            `json:"pod_name,omitempty"`
+	Index     int               `json:"index,omitempty"`
+	Count     int               `json:"count,omitempty"`
+	H2Dir     string            `json:"h2_dir,omitempty"`
+	H2RootDir string            `json:"h2_root_dir,omitempty"`
+	Var       map[string]string `json:"var,omitempty"`
+}
+
+// ConditionOutputRef is the template reference to a schedule condition's
+// output. Schedule (and heartbeat) messages are rendered again each time
+// they fire, with the condition command's stdout as .ConditionOutput.
+const ConditionOutputRef = "{{ .ConditionOutput }}"
+
+// ConditionOutput renders as ConditionOutputRef itself, so the reference
+// survives role-load rendering and is filled in at fire time.
+func (c *Context) ConditionOutput() string {
+	return ConditionOutputRef
 }
 
 // Render processes a template string with the given context.
@@ -1212,3 +1212,40 @@ role_name: child
 func strPtr(s string) *string {
 	return &s
 }
+
+func TestParseVarDefs_Secret(t *testing.T) {
+	defs, _, err := ParseVarDefs("variables:\n  token:\n    secret: true\n  team:\n    default: core\nrole_name: x\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !defs["token"].Secret {
+		t.Error("expected token to be secret")
+	}
+	if defs["team"].Secret {
+		t.Error("expected team not to be secret")
+	}
+}
+
+func TestSecretValuesAndRedact(t *testing.T) {
+	defs := map[string]VarDef{
+		"token":  {Secret: true},
+		"prefix": {Secret: true},
+		"empty":  {Secret: true},
+		"team":   {},
+	}
+	vars := map[string]string{"token": "sk-abc123", "prefix": "sk-abc", "empty": "", "team": "core"}
+
+	secrets := SecretValues(defs, vars)
+	if len(secrets) != 2 || secrets[0] != "sk-abc123" || secrets[1] != "sk-abc" {
+		t.Fatalf("SecretValues = %q, want longest first without empty or non-secret values", secrets)
+	}
+
+	got := Redact("use sk-abc123 for core, sk-abc for tests", secrets)
+	want := "use *** for core, *** for tests"
+	if got != want {
+		t.Errorf("Redact = %q, want %q", got, want)
+	}
+	if got := Redact("no secrets", nil); got != "no secrets" {
+		t.Errorf("Redact with no secrets = %q", got)
+	}
+}
@@ -0,0 +1,240 @@
+package tmpl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// A variable's default can be a template referencing other variables, e.g.
+// "feature/{{ .Var.ticket }}". Defaults are resolved after the variables
+// they reference, so those are final (provided or defaulted) by then. They
+// can't reference the rest of the context (.AgentName, .Index, ...), which
+// isn't known yet when variables are resolved. A default that doesn't
+// mention .Var is static and used as written, even if it contains "{{".
+
+// isTemplateDefault reports whether a default is a template to render
+// rather than a static value.
+func isTemplateDefault(def string) bool {
+	return strings.Contains(def, "{{") && strings.Contains(def, ".Var")
+}
+
+// ResolveDefaults returns provided plus the default of every variable in
+// defs that wasn't provided. Template defaults are rendered against the
+// variables resolved so far, in dependency order (alphabetical among
+// independent ones). A default that references a required variable that
+// wasn't provided is left unset, so ValidateVars reports the missing one.
+func ResolveDefaults(defs map[string]VarDef, provided map[string]string) (map[string]string, error) {
+	vars := make(map[string]string, len(provided)+len(defs))
+	for k, v := range provided {
+		vars[k] = v
+	}
+	order, refs, err := defaultOrder(defs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range order {
+		if _, ok := vars[name]; ok {
+			continue
+		}
+		def := *defs[name].Default
+		if !isTemplateDefault(def) {
+			vars[name] = def
+			continue
+		}
+		missing := false
+		for _, ref := range refs[name] {
+			if _, ok := vars[ref]; !ok {
+				missing = true
+			}
+		}
+		if missing {
+			continue
+		}
+		value, err := Render(def, &Context{Var: vars})
+		if err != nil {
+			return nil, fmt.Errorf("default of variable %q: %w", name, err)
+		}
+		vars[name] = value
+	}
+	return vars, nil
+}
+
+// ValidateVarDefs checks that every template default parses, references
+// only variables in defs (and nothing else in the context), and isn't part
+// of a reference cycle.
+func ValidateVarDefs(defs map[string]VarDef) error {
+	_, refs, err := defaultOrder(defs)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(refs))
+	for name := range refs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, ref := range refs[name] {
+			if _, ok := defs[ref]; !ok {
+				return fmt.Errorf("default of variable %q references undefined variable %q", name, ref)
+			}
+		}
+	}
+	return nil
+}
+
+// defaultOrder returns the names of the variables in defs that have a
+// default, ordered so each comes after the variables its default
+// references, along with those references. A cycle is an error.
+func defaultOrder(defs map[string]VarDef) ([]string, map[string][]string, error) {
+	names := make([]string, 0, len(defs))
+	refs := make(map[string][]string)
+	for name, def := range defs {
+		if def.Default == nil {
+			continue
+		}
+		names = append(names, name)
+		r, err := varRefs(*def.Default)
+		if err != nil {
+			return nil, nil, fmt.Errorf("default of variable %q: %w", name, err)
+		}
+		refs[name] = r
+	}
+	sort.Strings(names)
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(names))
+	var order, path []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			start := 0
+			for path[start] != name {
+				start++
+			}
+			cycle := append(append([]string{}, path[start:]...), name)
+			return fmt.Errorf("variable defaults reference each other in a cycle: %s", strings.Join(cycle, " -> "))
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, ref := range refs[name] {
+			if _, ok := refs[ref]; !ok {
+				continue // required or undefined; no default to order
+			}
+			if err := visit(ref); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+		order = append(order, name)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, nil, err
+		}
+	}
+	return order, refs, nil
+}
+
+// varRefs returns the variables a template default references as
+// .Var.name, $.Var.name or index .Var "name", sorted and deduplicated.
+// Referencing any other context field is an error. Static defaults have
+// none.
+func varRefs(text string) ([]string, error) {
+	if !isTemplateDefault(text) {
+		return nil, nil
+	}
+	t, err := template.New("").Funcs(funcMap()).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("template parse error: %w", err)
+	}
+	seen := make(map[string]bool)
+	other := make(map[string]bool)
+	if t.Tree != nil {
+		collectVarRefs(t.Tree.Root, seen, other)
+	}
+	if len(other) > 0 {
+		fields := make([]string, 0, len(other))
+		for f := range other {
+			fields = append(fields, "."+f)
+		}
+		sort.Strings(fields)
+		return nil, fmt.Errorf("references %s; defaults can only reference other variables (.Var.<name>)", strings.Join(fields, ", "))
+	}
+	refs := make([]string, 0, len(seen))
+	for name := range seen {
+		refs = append(refs, name)
+	}
+	sort.Strings(refs)
+	return refs, nil
+}
+
+// collectVarRefs adds the variables node references to seen, and the other
+// context fields it references to other.
+func collectVarRefs(node parse.Node, seen, other map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			collectVarRefs(c, seen, other)
+		}
+	case *parse.ActionNode:
+		collectVarRefs(n.Pipe, seen, other)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Cmds {
+			collectVarRefs(c, seen, other)
+		}
+	case *parse.CommandNode:
+		for i, arg := range n.Args {
+			if id, ok := arg.(*parse.IdentifierNode); ok && id.Ident == "index" && i+2 < len(n.Args) {
+				if f, ok := n.Args[i+1].(*parse.FieldNode); ok && len(f.Ident) == 1 && f.Ident[0] == "Var" {
+					if s, ok := n.Args[i+2].(*parse.StringNode); ok {
+						seen[s.Text] = true
+					}
+				}
+			}
+			collectVarRefs(arg, seen, other)
+		}
+	case *parse.FieldNode:
+		if n.Ident[0] != "Var" {
+			other[n.Ident[0]] = true
+		} else if len(n.Ident) >= 2 {
+			seen[n.Ident[1]] = true
+		}
+	case *parse.VariableNode:
+		if len(n.Ident) >= 2 && n.Ident[0] == "$" && n.Ident[1] != "Var" {
+			other[n.Ident[1]] = true
+		} else if len(n.Ident) >= 3 && n.Ident[0] == "$" {
+			seen[n.Ident[2]] = true
+		}
+	case *parse.IfNode:
+		collectBranchVarRefs(&n.BranchNode, seen, other)
+	case *parse.RangeNode:
+		collectBranchVarRefs(&n.BranchNode, seen, other)
+	case *parse.WithNode:
+		collectBranchVarRefs(&n.BranchNode, seen, other)
+	}
+}
+
+func collectBranchVarRefs(n *parse.BranchNode, seen, other map[string]bool) {
+	collectVarRefs(n.Pipe, seen, other)
+	collectVarRefs(n.List, seen, other)
+	collectVarRefs(n.ElseList, seen, other)
+}
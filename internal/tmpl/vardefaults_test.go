@@ -0,0 +1,107 @@
+package tmpl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveDefaults_TemplateDefaults(t *testing.T) {
+	defs := map[string]VarDef{
+		"ticket":      {},
+		"branch_name": {Default: strPtr("feature/{{ .Var.ticket }}")},
+		"pr_title":    {Default: strPtr(`{{ index .Var "branch_name" | upper }} ({{ $.Var.team }})`)},
+		"team":        {Default: strPtr("platform")},
+		"literal":     {Default: strPtr("plain text")},
+		"braces":      {Default: strPtr("{{ .AgentName }} {{ not a template")},
+	}
+
+	vars, err := ResolveDefaults(defs, map[string]string{"ticket": "H2-42"})
+	if err != nil {
+		t.Fatalf("ResolveDefaults: %v", err)
+	}
+	for name, want := range map[string]string{
+		"branch_name": "feature/H2-42",
+		"pr_title":    "FEATURE/H2-42 (platform)",
+		"team":        "platform",
+		"literal":     "plain text",
+		"braces":      "{{ .AgentName }} {{ not a template",
+	} {
+		if vars[name] != want {
+			t.Errorf("%s = %q, want %q", name, vars[name], want)
+		}
+	}
+
+	// A provided value wins, and defaults built on it see it.
+	vars, err = ResolveDefaults(defs, map[string]string{"ticket": "H2-42", "branch_name": "fix/x"})
+	if err != nil {
+		t.Fatalf("ResolveDefaults: %v", err)
+	}
+	if vars["branch_name"] != "fix/x" || vars["pr_title"] != "FIX/X (platform)" {
+		t.Errorf("vars = %v", vars)
+	}
+}
+
+func TestResolveDefaults_MissingRequiredLeavesDependentsUnset(t *testing.T) {
+	defs := map[string]VarDef{
+		"ticket":      {Description: "Ticket ID"},
+		"branch_name": {Default: strPtr("feature/{{ .Var.ticket }}")},
+	}
+	vars, err := ResolveDefaults(defs, nil)
+	if err != nil {
+		t.Fatalf("ResolveDefaults: %v", err)
+	}
+	if _, ok := vars["branch_name"]; ok {
+		t.Errorf("branch_name should be unset without ticket, got %q", vars["branch_name"])
+	}
+	err = ValidateVars(defs, vars)
+	if err == nil || !strings.Contains(err.Error(), "ticket") || strings.Contains(err.Error(), "branch_name") {
+		t.Errorf("expected only ticket to be reported missing, got %v", err)
+	}
+}
+
+func TestResolveDefaults_CycleIsAnError(t *testing.T) {
+	defs := map[string]VarDef{
+		"a":     {Default: strPtr("{{ .Var.b }}-a")},
+		"b":     {Default: strPtr("{{ .Var.c }}-b")},
+		"c":     {Default: strPtr("{{ if .Var.a }}x{{ end }}")},
+		"other": {Default: strPtr("fine")},
+	}
+	want := "variable defaults reference each other in a cycle: a -> b -> c -> a"
+
+	if _, err := ResolveDefaults(defs, nil); err == nil || err.Error() != want {
+		t.Errorf("ResolveDefaults error = %v, want %q", err, want)
+	}
+	if err := ValidateVarDefs(defs); err == nil || err.Error() != want {
+		t.Errorf("ValidateVarDefs error = %v, want %q", err, want)
+	}
+}
+
+func TestValidateVarDefs(t *testing.T) {
+	tests := []struct {
+		name string
+		defs map[string]VarDef
+		want string
+	}{
+		{"static", map[string]VarDef{"a": {Default: strPtr("x")}, "b": {}}, ""},
+		{"chain", map[string]VarDef{"a": {Default: strPtr("{{ .Var.b }}")}, "b": {Default: strPtr("{{ .Var.c }}")}, "c": {}}, ""},
+		{"self", map[string]VarDef{"a": {Default: strPtr("{{ .Var.a }}")}}, "cycle: a -> a"},
+		{"undefined", map[string]VarDef{"a": {Default: strPtr("{{ .Var.nope }}")}}, `default of variable "a" references undefined variable "nope"`},
+		{"bad template", map[string]VarDef{"a": {Default: strPtr("{{ .Var.b ")}, "b": {}}, `default of variable "a": template parse error`},
+		{"context field", map[string]VarDef{"a": {Default: strPtr("{{ .Var.b }}-{{ .AgentName }}-{{ $.Index }}")}, "b": {}}, `default of variable "a": references .AgentName, .Index; defaults can only reference other variables`},
+		{"static braces", map[string]VarDef{"a": {Default: strPtr("{{ .AgentName }}")}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateVarDefs(tt.defs)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"h2/internal/session"
 )
 
 // tokenPrefix is the common prefix for all receipt tokens.
@@ -29,6 +31,7 @@ type sandboxOpts struct {
 	agentType        string // agent type for the role (default "claude")
 	model            string // model to use (default "haiku")
 	permissionScript string // path to permission script (empty = no custom script)
+	heartbeat        string // role heartbeat: YAML block (empty = no heartbeat)
 }
 
 // createReliabilitySandbox creates a fully isolated h2 environment for a
@@ -103,6 +106,10 @@ instructions: |
 `, opts.permissionScript)
 	}
 
+	if opts.heartbeat != "" {
+		roleYAML += "heartbeat:\n" + opts.heartbeat
+	}
+
 	createRole(t, h2Dir, agentName, roleYAML)
 
 	return reliabilitySandbox{
@@ -262,49 +269,19 @@ func waitForActive(t *testing.T, h2Dir, agentName string, timeout time.Duration)
 
 // --- Activity Log Parsing ---
 
-// activityLogEntry represents a single line from session-activity.jsonl.
-type activityLogEntry struct {
-	Timestamp string `json:"ts"`
-	Actor     string `json:"actor"`
-	SessionID string `json:"session_id"`
-	Event     string `json:"event"`
-	HookEvent string `json:"hook_event,omitempty"`
-	ToolName  string `json:"tool_name,omitempty"`
-	From      string `json:"from,omitempty"`
-	To        string `json:"to,omitempty"`
-}
-
 // readActivityLog reads and parses all entries from session-activity.jsonl
 // for the given agent.
-func readActivityLog(t *testing.T, h2Dir, agentName string) []activityLogEntry {
+func readActivityLog(t *testing.T, h2Dir, agentName string) []session.ActivityEvent {
 	t.Helper()
 
 	logPath := filepath.Join(h2Dir, "sessions", agentName, "session-activity.jsonl")
-	f, err := os.Open(logPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
-		}
-		t.Fatalf("readActivityLog: open %s: %v", logPath, err)
-	}
-	defer f.Close()
-
-	var entries []activityLogEntry
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.TrimSpace(line) == "" {
-			continue
-		}
-		var e activityLogEntry
-		if err := json.Unmarshal([]byte(line), &e); err != nil {
-			t.Logf("readActivityLog: skipping malformed line: %v", err)
-			continue
-		}
+	var entries []session.ActivityEvent
+	err := session.QueryActivityLog(logPath, session.ActivityQuery{}, func(e session.ActivityEvent) error {
 		entries = append(entries, e)
-	}
-	if err := scanner.Err(); err != nil {
-		t.Fatalf("readActivityLog: scan error: %v", err)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("readActivityLog: %v", err)
 	}
 	return entries
 }